@@ -0,0 +1,691 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Password    PasswordConfig    `yaml:"password"`
+	Limits      LimitsConfig      `yaml:"limits"`
+	Trash       TrashConfig       `yaml:"trash"`
+	Tasks       TasksConfig       `yaml:"tasks"`
+	Recurrence  RecurrenceConfig  `yaml:"recurrence"`
+	Reminders   RemindersConfig   `yaml:"reminders"`
+	Webhooks    WebhooksConfig    `yaml:"webhooks"`
+	Stream      StreamConfig      `yaml:"stream"`
+	Calendar    CalendarConfig    `yaml:"calendar"`
+	Attachments AttachmentsConfig `yaml:"attachments"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Cache       CacheConfig       `yaml:"cache"`
+}
+
+type ServerConfig struct {
+	Address         string        `yaml:"address"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	RequestTimeout  time.Duration `yaml:"request_timeout"`
+}
+
+// DatabaseConfig says where MongoDB is. With MigrateOnStartup the server
+// applies pending schema migrations before it starts; without it they are
+// left to the migrate command.
+type DatabaseConfig struct {
+	URI              string        `yaml:"uri"`
+	Name             string        `yaml:"name"`
+	ConnectTimeout   time.Duration `yaml:"connect_timeout"`
+	QueryTimeout     time.Duration `yaml:"query_timeout"`
+	MigrateOnStartup bool          `yaml:"migrate_on_startup"`
+}
+
+// AuthConfig controls the JWTs. Tokens from login last TokenTTL. Stream
+// tokens only open the task streams, where browsers cannot send a header,
+// and last StreamTokenTTL.
+type AuthConfig struct {
+	JWTSecret      string        `yaml:"jwt_secret"`
+	TokenTTL       time.Duration `yaml:"token_ttl"`
+	StreamTokenTTL time.Duration `yaml:"stream_token_ttl"`
+}
+
+type PasswordConfig struct {
+	MinLength  int `yaml:"min_length"`
+	BcryptCost int `yaml:"bcrypt_cost"`
+}
+
+type LimitsConfig struct {
+	MaxRequestBodyBytes  int64 `yaml:"max_request_body_bytes"`
+	MaxTitleLength       int   `yaml:"max_title_length"`
+	MaxDescriptionLength int   `yaml:"max_description_length"`
+	MaxBatchSize         int   `yaml:"max_batch_size"`
+	MaxCommentLength     int   `yaml:"max_comment_length"`
+	MaxChecklistItems    int   `yaml:"max_checklist_items"`
+	// MaxImportBytes replaces MaxRequestBodyBytes for task imports, which
+	// are read row by row rather than all at once.
+	MaxImportBytes int64 `yaml:"max_import_bytes"`
+}
+
+// TrashConfig controls how long deleted tasks are kept. A zero retention
+// disables automatic purging.
+type TrashConfig struct {
+	Retention     time.Duration `yaml:"retention"`
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+// TasksConfig holds task behaviour settings. CompletionPolicy is "block" or
+// "cascade" and applies when a task with open subtasks or checklist items is
+// completed. MaxHistoryEntries bounds each task's history, which drops its
+// oldest entries beyond it; zero keeps them all.
+type TasksConfig struct {
+	CompletionPolicy  string `yaml:"completion_policy"`
+	MaxHistoryEntries int    `yaml:"max_history_entries"`
+}
+
+// RecurrenceConfig controls how often recurring tasks are checked for a due
+// next occurrence. A zero interval disables the generator.
+type RecurrenceConfig struct {
+	GenerateInterval time.Duration `yaml:"generate_interval"`
+}
+
+// RemindersConfig controls due-date reminders. They are sent Windows before a
+// task is due and, if Overdue is set, once it is past due, for tasks at most
+// OverdueLookback past due. A reminder that failed MaxAttempts times is given
+// up. A zero Interval disables them. Notifier picks the delivery channel:
+// log, file (JSON lines appended to File), webhook (POST to WebhookURL) or
+// smtp.
+type RemindersConfig struct {
+	Interval        time.Duration   `yaml:"interval"`
+	Windows         []time.Duration `yaml:"windows"`
+	Overdue         bool            `yaml:"overdue"`
+	OverdueLookback time.Duration   `yaml:"overdue_lookback"`
+	Lease           time.Duration   `yaml:"lease"`
+	MaxAttempts     int             `yaml:"max_attempts"`
+	Notifier        string          `yaml:"notifier"`
+	File            string          `yaml:"file"`
+	WebhookURL      string          `yaml:"webhook_url"`
+	SMTP            SMTPConfig      `yaml:"smtp"`
+}
+
+// SMTPConfig points at a mail server. Username and Password are optional;
+// without them mail is sent unauthenticated, e.g. to a local test server.
+type SMTPConfig struct {
+	Addr     string   `yaml:"addr"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+// WebhooksConfig controls how queued webhook deliveries are sent. Each run
+// of the delivery job, every DeliveryInterval, attempts up to BatchSize due
+// deliveries. A failed delivery is retried after Backoff, doubling after each
+// further failure up to a day, until MaxAttempts is reached. A zero
+// DeliveryInterval stops sending; events are still queued.
+type WebhooksConfig struct {
+	DeliveryInterval time.Duration `yaml:"delivery_interval"`
+	MaxAttempts      int           `yaml:"max_attempts"`
+	Backoff          time.Duration `yaml:"backoff"`
+	Timeout          time.Duration `yaml:"timeout"`
+	Lease            time.Duration `yaml:"lease"`
+	BatchSize        int           `yaml:"batch_size"`
+}
+
+// StreamConfig controls the live task event streams. History is how many
+// events are kept for clients that reconnect, Buffer how many may wait for a
+// slow client before it is disconnected, and Heartbeat how often an idle
+// stream sends a keep-alive. WebSocket handshakes from browsers are only
+// accepted from the page's own origin and from AllowedOrigins, such as
+// "https://app.example.com".
+type StreamConfig struct {
+	History        int           `yaml:"history"`
+	Buffer         int           `yaml:"buffer"`
+	Heartbeat      time.Duration `yaml:"heartbeat"`
+	AllowedOrigins []string      `yaml:"allowed_origins"`
+}
+
+// CalendarConfig controls the iCalendar feeds. Name is the calendar's name
+// in calendar apps, and UIDDomain the domain part of every entry's UID. The
+// UIDs must not change once feeds are subscribed to, or apps will show every
+// task twice.
+type CalendarConfig struct {
+	Name      string `yaml:"name"`
+	UIDDomain string `yaml:"uid_domain"`
+}
+
+// AttachmentsConfig controls task attachments. Store selects where the files
+// are kept; "local" keeps them under Dir. Uploads are limited to MaxBytes
+// and to the media types in AllowedTypes, where "image/*" allows any image.
+type AttachmentsConfig struct {
+	Store        string   `yaml:"store"`
+	Dir          string   `yaml:"dir"`
+	MaxBytes     int64    `yaml:"max_bytes"`
+	AllowedTypes []string `yaml:"allowed_types"`
+}
+
+// IdempotencyConfig controls the Idempotency-Key header. Responses are kept
+// for TTL; a zero TTL turns the header off. A request that is still running
+// holds its key for at most LockTimeout, after which a retry may run it
+// again, e.g. when the replica that had it stopped.
+type IdempotencyConfig struct {
+	TTL         time.Duration `yaml:"ttl"`
+	LockTimeout time.Duration `yaml:"lock_timeout"`
+}
+
+// CacheConfig controls the cache of task lookups by ID. Store selects the
+// cache; "memory" keeps up to Size tasks in the process. Each task is kept
+// for TTL, which also bounds how long a change made through another replica
+// can go unseen. A zero TTL turns the cache off.
+type CacheConfig struct {
+	Store string        `yaml:"store"`
+	Size  int           `yaml:"size"`
+	TTL   time.Duration `yaml:"ttl"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Address:         ":8080",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+			RequestTimeout:  10 * time.Second,
+		},
+		Database: DatabaseConfig{
+			URI:              "mongodb://localhost:27017",
+			Name:             "task_manager",
+			ConnectTimeout:   10 * time.Second,
+			QueryTimeout:     10 * time.Second,
+			MigrateOnStartup: true,
+		},
+		Auth: AuthConfig{
+			JWTSecret:      "your-secret-key-change-in-production",
+			TokenTTL:       24 * time.Hour,
+			StreamTokenTTL: time.Minute,
+		},
+		Password: PasswordConfig{
+			MinLength:  6,
+			BcryptCost: 10,
+		},
+		Limits: LimitsConfig{
+			MaxRequestBodyBytes:  1 << 20,
+			MaxTitleLength:       200,
+			MaxDescriptionLength: 5000,
+			MaxBatchSize:         100,
+			MaxCommentLength:     2000,
+			MaxChecklistItems:    100,
+			MaxImportBytes:       10 << 20,
+		},
+		Trash: TrashConfig{
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Tasks: TasksConfig{
+			CompletionPolicy:  "block",
+			MaxHistoryEntries: 500,
+		},
+		Recurrence: RecurrenceConfig{
+			GenerateInterval: time.Minute,
+		},
+		Reminders: RemindersConfig{
+			Interval:        time.Minute,
+			Windows:         []time.Duration{24 * time.Hour, time.Hour},
+			Overdue:         true,
+			OverdueLookback: 24 * time.Hour,
+			Lease:           5 * time.Minute,
+			MaxAttempts:     5,
+			Notifier:        "log",
+			File:            "reminders.jsonl",
+		},
+		Webhooks: WebhooksConfig{
+			DeliveryInterval: 5 * time.Second,
+			MaxAttempts:      8,
+			Backoff:          30 * time.Second,
+			Timeout:          10 * time.Second,
+			Lease:            2 * time.Minute,
+			BatchSize:        20,
+		},
+		Stream: StreamConfig{
+			History:   1000,
+			Buffer:    64,
+			Heartbeat: 15 * time.Second,
+		},
+		Calendar: CalendarConfig{
+			Name:      "Tasks",
+			UIDDomain: "task-manager",
+		},
+		Attachments: AttachmentsConfig{
+			Store:        "local",
+			Dir:          "data/attachments",
+			MaxBytes:     25 << 20,
+			AllowedTypes: []string{"image/*", "application/pdf", "text/plain", "text/csv", "text/markdown"},
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:         24 * time.Hour,
+			LockTimeout: time.Minute,
+		},
+		Cache: CacheConfig{
+			Store: "memory",
+			Size:  10000,
+			TTL:   5 * time.Second,
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML file at path
+// (skipped when path is empty) and finally the environment, then validates it.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("config: failed to read %s: %w", path, err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("config: failed to parse %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	var errs []error
+
+	str := func(key string, dst *string) {
+		if v, ok := lookup(key); ok && v != "" {
+			*dst = v
+		}
+	}
+	duration := func(key string, dst *time.Duration) {
+		if v, ok := lookup(key); ok && v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid duration %q", key, v))
+				return
+			}
+			*dst = d
+		}
+	}
+	integer := func(key string, dst *int) {
+		if v, ok := lookup(key); ok && v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid integer %q", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	boolean := func(key string, dst *bool) {
+		if v, ok := lookup(key); ok && v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid boolean %q", key, v))
+				return
+			}
+			*dst = b
+		}
+	}
+	list := func(key string, dst *[]string) {
+		if v, ok := lookup(key); ok && v != "" {
+			*dst = splitList(v)
+		}
+	}
+	durations := func(key string, dst *[]time.Duration) {
+		if v, ok := lookup(key); ok && v != "" {
+			var parsed []time.Duration
+			for _, item := range splitList(v) {
+				d, err := time.ParseDuration(item)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: invalid duration %q", key, item))
+					return
+				}
+				parsed = append(parsed, d)
+			}
+			*dst = parsed
+		}
+	}
+	integer64 := func(key string, dst *int64) {
+		if v, ok := lookup(key); ok && v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid integer %q", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+
+	str("SERVER_ADDRESS", &c.Server.Address)
+	duration("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout)
+	duration("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	duration("SERVER_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	duration("SERVER_REQUEST_TIMEOUT", &c.Server.RequestTimeout)
+
+	str("MONGODB_URI", &c.Database.URI)
+	str("MONGODB_DB", &c.Database.Name)
+	duration("MONGODB_CONNECT_TIMEOUT", &c.Database.ConnectTimeout)
+	duration("MONGODB_QUERY_TIMEOUT", &c.Database.QueryTimeout)
+	boolean("MONGODB_MIGRATE_ON_STARTUP", &c.Database.MigrateOnStartup)
+
+	str("JWT_SECRET", &c.Auth.JWTSecret)
+	duration("JWT_TOKEN_TTL", &c.Auth.TokenTTL)
+	duration("JWT_STREAM_TOKEN_TTL", &c.Auth.StreamTokenTTL)
+
+	integer("PASSWORD_MIN_LENGTH", &c.Password.MinLength)
+	integer("PASSWORD_BCRYPT_COST", &c.Password.BcryptCost)
+
+	integer64("LIMITS_MAX_REQUEST_BODY_BYTES", &c.Limits.MaxRequestBodyBytes)
+	integer("LIMITS_MAX_TITLE_LENGTH", &c.Limits.MaxTitleLength)
+	integer("LIMITS_MAX_DESCRIPTION_LENGTH", &c.Limits.MaxDescriptionLength)
+	integer("LIMITS_MAX_BATCH_SIZE", &c.Limits.MaxBatchSize)
+	integer("LIMITS_MAX_COMMENT_LENGTH", &c.Limits.MaxCommentLength)
+	integer("LIMITS_MAX_CHECKLIST_ITEMS", &c.Limits.MaxChecklistItems)
+	integer64("LIMITS_MAX_IMPORT_BYTES", &c.Limits.MaxImportBytes)
+
+	duration("TRASH_RETENTION", &c.Trash.Retention)
+	duration("TRASH_PURGE_INTERVAL", &c.Trash.PurgeInterval)
+
+	str("TASKS_COMPLETION_POLICY", &c.Tasks.CompletionPolicy)
+	integer("TASKS_MAX_HISTORY_ENTRIES", &c.Tasks.MaxHistoryEntries)
+
+	duration("RECURRENCE_GENERATE_INTERVAL", &c.Recurrence.GenerateInterval)
+
+	duration("REMINDERS_INTERVAL", &c.Reminders.Interval)
+	durations("REMINDERS_WINDOWS", &c.Reminders.Windows)
+	boolean("REMINDERS_OVERDUE", &c.Reminders.Overdue)
+	duration("REMINDERS_OVERDUE_LOOKBACK", &c.Reminders.OverdueLookback)
+	duration("REMINDERS_LEASE", &c.Reminders.Lease)
+	integer("REMINDERS_MAX_ATTEMPTS", &c.Reminders.MaxAttempts)
+	str("REMINDERS_NOTIFIER", &c.Reminders.Notifier)
+	str("REMINDERS_FILE", &c.Reminders.File)
+	str("REMINDERS_WEBHOOK_URL", &c.Reminders.WebhookURL)
+	str("REMINDERS_SMTP_ADDR", &c.Reminders.SMTP.Addr)
+	str("REMINDERS_SMTP_USERNAME", &c.Reminders.SMTP.Username)
+	str("REMINDERS_SMTP_PASSWORD", &c.Reminders.SMTP.Password)
+	str("REMINDERS_SMTP_FROM", &c.Reminders.SMTP.From)
+	list("REMINDERS_SMTP_TO", &c.Reminders.SMTP.To)
+
+	duration("WEBHOOKS_DELIVERY_INTERVAL", &c.Webhooks.DeliveryInterval)
+	integer("WEBHOOKS_MAX_ATTEMPTS", &c.Webhooks.MaxAttempts)
+	duration("WEBHOOKS_BACKOFF", &c.Webhooks.Backoff)
+	duration("WEBHOOKS_TIMEOUT", &c.Webhooks.Timeout)
+	duration("WEBHOOKS_LEASE", &c.Webhooks.Lease)
+	integer("WEBHOOKS_BATCH_SIZE", &c.Webhooks.BatchSize)
+	integer("STREAM_HISTORY", &c.Stream.History)
+	integer("STREAM_BUFFER", &c.Stream.Buffer)
+	duration("STREAM_HEARTBEAT", &c.Stream.Heartbeat)
+	list("STREAM_ALLOWED_ORIGINS", &c.Stream.AllowedOrigins)
+	str("CALENDAR_NAME", &c.Calendar.Name)
+	str("CALENDAR_UID_DOMAIN", &c.Calendar.UIDDomain)
+	str("ATTACHMENTS_STORE", &c.Attachments.Store)
+	str("ATTACHMENTS_DIR", &c.Attachments.Dir)
+	integer64("ATTACHMENTS_MAX_BYTES", &c.Attachments.MaxBytes)
+	list("ATTACHMENTS_ALLOWED_TYPES", &c.Attachments.AllowedTypes)
+	duration("IDEMPOTENCY_TTL", &c.Idempotency.TTL)
+	duration("IDEMPOTENCY_LOCK_TIMEOUT", &c.Idempotency.LockTimeout)
+	str("CACHE_STORE", &c.Cache.Store)
+	integer("CACHE_SIZE", &c.Cache.Size)
+	duration("CACHE_TTL", &c.Cache.TTL)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config: invalid environment override:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// splitList splits a comma-separated environment value, dropping blanks.
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Validate reports every invalid setting at once so a broken deployment can
+// be fixed in a single pass.
+func (c *Config) Validate() error {
+	var problems []string
+
+	if strings.TrimSpace(c.Server.Address) == "" {
+		problems = append(problems, "server.address must not be empty")
+	}
+	if c.Server.ReadTimeout < 0 {
+		problems = append(problems, "server.read_timeout must not be negative")
+	}
+	if c.Server.WriteTimeout < 0 {
+		problems = append(problems, "server.write_timeout must not be negative")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "server.shutdown_timeout must be positive")
+	}
+	if c.Server.RequestTimeout <= 0 {
+		problems = append(problems, "server.request_timeout must be positive")
+	}
+
+	if !strings.HasPrefix(c.Database.URI, "mongodb://") && !strings.HasPrefix(c.Database.URI, "mongodb+srv://") {
+		problems = append(problems, "database.uri must start with mongodb:// or mongodb+srv://")
+	}
+	if strings.TrimSpace(c.Database.Name) == "" {
+		problems = append(problems, "database.name must not be empty")
+	}
+	if c.Database.ConnectTimeout <= 0 {
+		problems = append(problems, "database.connect_timeout must be positive")
+	}
+	if c.Database.QueryTimeout <= 0 {
+		problems = append(problems, "database.query_timeout must be positive")
+	}
+
+	if c.Auth.JWTSecret == "" {
+		problems = append(problems, "auth.jwt_secret must not be empty")
+	}
+	if c.Auth.TokenTTL <= 0 {
+		problems = append(problems, "auth.token_ttl must be positive")
+	}
+	if c.Auth.StreamTokenTTL <= 0 {
+		problems = append(problems, "auth.stream_token_ttl must be positive")
+	}
+
+	if c.Password.MinLength < 1 || c.Password.MinLength > 72 {
+		problems = append(problems, "password.min_length must be between 1 and 72")
+	}
+	if c.Password.BcryptCost < 4 || c.Password.BcryptCost > 31 {
+		problems = append(problems, "password.bcrypt_cost must be between 4 and 31")
+	}
+
+	if c.Limits.MaxRequestBodyBytes <= 0 {
+		problems = append(problems, "limits.max_request_body_bytes must be positive")
+	}
+	if c.Limits.MaxTitleLength <= 0 {
+		problems = append(problems, "limits.max_title_length must be positive")
+	}
+	if c.Limits.MaxDescriptionLength <= 0 {
+		problems = append(problems, "limits.max_description_length must be positive")
+	}
+	if c.Limits.MaxBatchSize <= 0 {
+		problems = append(problems, "limits.max_batch_size must be positive")
+	}
+	if c.Limits.MaxCommentLength <= 0 {
+		problems = append(problems, "limits.max_comment_length must be positive")
+	}
+	if c.Limits.MaxChecklistItems <= 0 {
+		problems = append(problems, "limits.max_checklist_items must be positive")
+	}
+	if c.Limits.MaxImportBytes <= 0 {
+		problems = append(problems, "limits.max_import_bytes must be positive")
+	}
+
+	if c.Trash.Retention < 0 {
+		problems = append(problems, "trash.retention must not be negative")
+	}
+	if c.Trash.PurgeInterval <= 0 {
+		problems = append(problems, "trash.purge_interval must be positive")
+	}
+
+	if c.Tasks.CompletionPolicy != "block" && c.Tasks.CompletionPolicy != "cascade" {
+		problems = append(problems, "tasks.completion_policy must be block or cascade")
+	}
+	if c.Tasks.MaxHistoryEntries < 0 {
+		problems = append(problems, "tasks.max_history_entries must not be negative")
+	}
+
+	if c.Recurrence.GenerateInterval < 0 {
+		problems = append(problems, "recurrence.generate_interval must not be negative")
+	}
+
+	if c.Reminders.Interval < 0 {
+		problems = append(problems, "reminders.interval must not be negative")
+	}
+	if c.Reminders.Interval > 0 {
+		problems = append(problems, c.Reminders.problems()...)
+	}
+
+	if c.Webhooks.DeliveryInterval < 0 {
+		problems = append(problems, "webhooks.delivery_interval must not be negative")
+	}
+	if c.Webhooks.MaxAttempts < 1 {
+		problems = append(problems, "webhooks.max_attempts must be at least 1")
+	}
+	if c.Webhooks.Backoff <= 0 {
+		problems = append(problems, "webhooks.backoff must be positive")
+	}
+	if c.Webhooks.Timeout <= 0 {
+		problems = append(problems, "webhooks.timeout must be positive")
+	}
+	if c.Webhooks.Lease <= c.Webhooks.Timeout {
+		problems = append(problems, "webhooks.lease must be longer than webhooks.timeout")
+	}
+	if c.Webhooks.BatchSize < 1 {
+		problems = append(problems, "webhooks.batch_size must be at least 1")
+	}
+
+	if c.Stream.History < 0 {
+		problems = append(problems, "stream.history must not be negative")
+	}
+	if c.Stream.Buffer < 1 {
+		problems = append(problems, "stream.buffer must be at least 1")
+	}
+	if c.Stream.Heartbeat <= 0 {
+		problems = append(problems, "stream.heartbeat must be positive")
+	}
+	for _, origin := range c.Stream.AllowedOrigins {
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			problems = append(problems, fmt.Sprintf("stream.allowed_origins: %q is not an origin", origin))
+		}
+	}
+
+	if strings.TrimSpace(c.Calendar.Name) == "" {
+		problems = append(problems, "calendar.name must not be empty")
+	}
+	if c.Calendar.UIDDomain == "" || strings.ContainsAny(c.Calendar.UIDDomain, " @") {
+		problems = append(problems, "calendar.uid_domain must be a domain name")
+	}
+
+	if c.Attachments.Store != "local" {
+		problems = append(problems, "attachments.store must be local")
+	}
+	if c.Attachments.Store == "local" && c.Attachments.Dir == "" {
+		problems = append(problems, "attachments.dir must not be empty with the local store")
+	}
+	if c.Attachments.MaxBytes <= 0 {
+		problems = append(problems, "attachments.max_bytes must be positive")
+	}
+	for _, mediaType := range c.Attachments.AllowedTypes {
+		if strings.Count(mediaType, "/") != 1 || strings.HasPrefix(mediaType, "*") {
+			problems = append(problems, fmt.Sprintf("attachments.allowed_types: %q is not a media type", mediaType))
+		}
+	}
+
+	if c.Idempotency.TTL < 0 {
+		problems = append(problems, "idempotency.ttl must not be negative")
+	}
+	if c.Idempotency.TTL > 0 && c.Idempotency.LockTimeout <= 0 {
+		problems = append(problems, "idempotency.lock_timeout must be positive")
+	}
+
+	if c.Cache.TTL < 0 {
+		problems = append(problems, "cache.ttl must not be negative")
+	}
+	if c.Cache.TTL > 0 && c.Cache.Store != "memory" {
+		problems = append(problems, "cache.store must be memory")
+	}
+	if c.Cache.TTL > 0 && c.Cache.Size <= 0 {
+		problems = append(problems, "cache.size must be positive")
+	}
+
+	if len(problems) > 0 {
+		return errors.New("config: invalid configuration:\n  - " + strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// problems validates the reminder settings, which only matter when
+// reminders are enabled.
+func (r RemindersConfig) problems() []string {
+	var problems []string
+	for _, window := range r.Windows {
+		if window <= 0 {
+			problems = append(problems, "reminders.windows must all be positive")
+			break
+		}
+	}
+	if len(r.Windows) == 0 && !r.Overdue {
+		problems = append(problems, "reminders need at least one window or overdue enabled")
+	}
+	if r.Overdue && r.OverdueLookback < r.Interval {
+		problems = append(problems, "reminders.overdue_lookback must be at least reminders.interval")
+	}
+	if r.Lease <= 0 {
+		problems = append(problems, "reminders.lease must be positive")
+	}
+	if r.MaxAttempts < 1 {
+		problems = append(problems, "reminders.max_attempts must be at least 1")
+	}
+
+	switch r.Notifier {
+	case "log":
+	case "file":
+		if strings.TrimSpace(r.File) == "" {
+			problems = append(problems, "reminders.file must not be empty with the file notifier")
+		}
+	case "webhook":
+		if !strings.HasPrefix(r.WebhookURL, "http://") && !strings.HasPrefix(r.WebhookURL, "https://") {
+			problems = append(problems, "reminders.webhook_url must be an http:// or https:// URL")
+		}
+	case "smtp":
+		if strings.TrimSpace(r.SMTP.Addr) == "" {
+			problems = append(problems, "reminders.smtp.addr must not be empty")
+		}
+		if strings.TrimSpace(r.SMTP.From) == "" {
+			problems = append(problems, "reminders.smtp.from must not be empty")
+		}
+		if len(r.SMTP.To) == 0 {
+			problems = append(problems, "reminders.smtp.to must list at least one recipient")
+		}
+	default:
+		problems = append(problems, "reminders.notifier must be log, file, webhook or smtp")
+	}
+	return problems
+}
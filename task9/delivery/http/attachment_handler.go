@@ -0,0 +1,162 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"task9/domain"
+	"task9/usecase"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttachmentHandler uploads and downloads task attachments. Their content
+// is streamed rather than buffered, so like TransferHandler it applies the
+// server's read and write timeouts to each read and write.
+type AttachmentHandler struct {
+	attachmentUseCase *usecase.AttachmentUseCase
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+}
+
+func NewAttachmentHandler(attachmentUseCase *usecase.AttachmentUseCase, readTimeout, writeTimeout time.Duration) *AttachmentHandler {
+	return &AttachmentHandler{attachmentUseCase: attachmentUseCase, readTimeout: readTimeout, writeTimeout: writeTimeout}
+}
+
+func (h *AttachmentHandler) ListAttachments(c *gin.Context) {
+	attachments, err := h.attachmentUseCase.ListAttachments(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewAttachmentResponses(attachments),
+		"count":  len(attachments),
+	})
+}
+
+// UploadAttachment stores the "file" part of a multipart/form-data body.
+// Other parts are skipped.
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	mediaType, params, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType != "multipart/form-data" || params["boundary"] == "" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"status":  "error",
+			"message": "attachments must be uploaded as multipart/form-data",
+		})
+		return
+	}
+
+	body := &deadlineReader{body: c.Request.Body, controller: http.NewResponseController(c.Writer), timeout: h.readTimeout}
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "the upload has no file part",
+			})
+			return
+		}
+		if err != nil {
+			respondAttachmentError(c, err)
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		attachment, err := h.attachmentUseCase.UploadAttachment(c.Request.Context(), c.Param("id"), domain.AttachmentUpload{
+			Filename:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Body:        part,
+		})
+		if err != nil {
+			respondAttachmentError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"status":  "success",
+			"message": "attachment uploaded successfully",
+			"data":    NewAttachmentResponse(attachment),
+		})
+		return
+	}
+}
+
+// DownloadAttachment sends the content as a download. Uploads are not
+// trusted to be what they claim, so browsers are told not to sniff or run
+// them.
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	attachment, content, err := h.attachmentUseCase.OpenAttachment(c.Request.Context(), c.Param("id"), c.Param("attachment_id"))
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+	defer content.Close()
+
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", "sandbox")
+	c.Header("Cache-Control", "private, no-cache")
+	c.Status(http.StatusOK)
+
+	w := &deadlineWriter{w: c.Writer, controller: http.NewResponseController(c.Writer), timeout: h.writeTimeout}
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("download attachment %s: %v", attachment.ID, err)
+		abortResponse(c)
+	}
+}
+
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	err := h.attachmentUseCase.DeleteAttachment(c.Request.Context(), c.Param("id"), c.Param("attachment_id"))
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "attachment deleted successfully",
+	})
+}
+
+func respondAttachmentError(c *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	var validationErr *domain.ValidationError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &validationErr),
+		err.Error() == "invalid task ID format",
+		err.Error() == "invalid attachment ID format",
+		errors.Is(err, multipart.ErrMessageTooLarge):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, domain.ErrAttachmentTooLarge), errors.As(err, &maxBytesErr):
+		statusCode = http.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrAttachmentType):
+		statusCode = http.StatusUnsupportedMediaType
+	case errors.Is(err, domain.ErrNotUploader):
+		statusCode = http.StatusForbidden
+	case err.Error() == "task not found", err.Error() == "attachment not found":
+		statusCode = http.StatusNotFound
+	default:
+		if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+	}
+	c.JSON(statusCode, gin.H{
+		"status":  "error",
+		"message": err.Error(),
+	})
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"task9/domain"
 	"task9/usecase"
@@ -33,13 +34,16 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Password: reqDTO.Password,
 	}
 
-	user, err := h.authUseCase.Register(req)
+	user, err := h.authUseCase.Register(c.Request.Context(), req)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
+		var validationErr *domain.ValidationError
 		if err.Error() == "username already exists" {
 			statusCode = http.StatusConflict
-		} else if err.Error() == "password must be at least 6 characters" {
+		} else if errors.As(err, &validationErr) {
 			statusCode = http.StatusBadRequest
+		} else if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
 		}
 		c.JSON(statusCode, gin.H{
 			"status":  "error",
@@ -48,11 +52,10 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user.Password = ""
 	c.JSON(http.StatusCreated, gin.H{
 		"status":  "success",
 		"message": "user registered successfully",
-		"data":    user,
+		"data":    NewUserResponse(user),
 	})
 }
 
@@ -73,7 +76,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		Password: reqDTO.Password,
 	}
 
-	token, user, err := h.authUseCase.Login(req)
+	token, user, err := h.authUseCase.Login(c.Request.Context(), req)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"status":  "error",
@@ -84,13 +87,45 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"data": gin.H{
-			"token": token,
-			"user":  user,
+		"data": LoginResponse{
+			Token: token,
+			User:  NewUserResponse(user),
 		},
 	})
 }
 
+// IssueStreamToken returns a short-lived token for the task streams and sets
+// it as the stream_token cookie, which browsers send with EventSource and
+// WebSocket requests to the streams.
+func (h *AuthHandler) IssueStreamToken(c *gin.Context) {
+	token, expiresAt, err := h.authUseCase.IssueStreamToken(c.Request.Context())
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == "unauthorized" {
+			statusCode = http.StatusUnauthorized
+		}
+		c.JSON(statusCode, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "stream_token",
+		Value:    token,
+		Path:     "/workspaces/",
+		Expires:  expiresAt,
+		Secure:   c.Request.TLS != nil,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   StreamTokenResponse{Token: token, ExpiresAt: expiresAt},
+	})
+}
+
 func (h *AuthHandler) PromoteUser(c *gin.Context) {
 	var reqDTO PromoteRequest
 
@@ -103,11 +138,13 @@ func (h *AuthHandler) PromoteUser(c *gin.Context) {
 		return
 	}
 
-	err := h.authUseCase.PromoteUser(reqDTO.Username)
+	err := h.authUseCase.PromoteUser(c.Request.Context(), reqDTO.Username)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
 		if err.Error() == "user not found" {
 			statusCode = http.StatusNotFound
+		} else if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
 		}
 		c.JSON(statusCode, gin.H{
 			"status":  "error",
@@ -121,4 +158,3 @@ func (h *AuthHandler) PromoteUser(c *gin.Context) {
 		"message": "user promoted to admin successfully",
 	})
 }
-
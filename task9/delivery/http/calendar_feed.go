@@ -0,0 +1,104 @@
+package http
+
+import (
+	"io"
+	"strconv"
+	"task9/domain"
+	"task9/ical"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+// vtodoStatuses maps task statuses to VTODO statuses. VEVENTs have no status
+// for finished work, so there a completed task's summary is marked instead.
+var vtodoStatuses = map[string]string{
+	"pending":     "NEEDS-ACTION",
+	"in_progress": "IN-PROCESS",
+	"completed":   "COMPLETED",
+}
+
+// icalPriorities maps task priorities to PRIORITY values, where 1 is the
+// highest and 9 the lowest.
+var icalPriorities = map[string]int{
+	"urgent": 1,
+	"high":   3,
+	"medium": 5,
+	"low":    9,
+}
+
+// CalendarFeed renders tasks as an iCalendar feed, one VEVENT, or with Todos
+// one VTODO, per task. UIDs are the task IDs at UIDDomain, so calendar apps
+// update their entries when a task changes rather than adding new ones.
+type CalendarFeed struct {
+	Name      string
+	UIDDomain string
+	Todos     bool
+}
+
+// Write writes the calendar with every task that each passes to its
+// callback. Tasks without a due date are left out.
+func (f CalendarFeed) Write(w io.Writer, each func(fn func(domain.Task) error) error) error {
+	e := ical.NewEncoder(w)
+	e.Begin("VCALENDAR")
+	e.Property("VERSION", "2.0")
+	e.Property("PRODID", "-//Task Management API//Calendar Feed//EN")
+	e.Property("CALSCALE", "GREGORIAN")
+	e.Text("NAME", f.Name)
+	e.Text("X-WR-CALNAME", f.Name)
+
+	err := each(func(task domain.Task) error {
+		if !task.DueDate.IsZero() {
+			f.writeTask(e, task)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	e.End("VCALENDAR")
+	return e.Flush()
+}
+
+func (f CalendarFeed) writeTask(e *ical.Encoder, task domain.Task) {
+	component := "VEVENT"
+	if f.Todos {
+		component = "VTODO"
+	}
+	e.Begin(component)
+	e.Text("UID", f.uid(task.ID))
+	e.Time("DTSTAMP", task.UpdatedAt)
+	if !task.CreatedAt.IsZero() {
+		e.Time("CREATED", task.CreatedAt)
+	}
+	e.Time("LAST-MODIFIED", task.UpdatedAt)
+
+	summary := task.Title
+	if f.Todos {
+		e.Time("DUE", task.DueDate)
+		if status, ok := vtodoStatuses[task.Status]; ok {
+			e.Property("STATUS", status)
+		}
+	} else {
+		e.Time("DTSTART", task.DueDate)
+		if task.Status == "completed" {
+			summary = "✓ " + summary
+		}
+	}
+	e.Text("SUMMARY", summary)
+	if task.Description != "" {
+		e.Text("DESCRIPTION", task.Description)
+	}
+	if priority, ok := icalPriorities[task.Priority]; ok {
+		e.Property("PRIORITY", strconv.Itoa(priority))
+	}
+	e.TextList("CATEGORIES", task.Tags)
+	if task.ParentID != "" {
+		e.Text("RELATED-TO", f.uid(task.ParentID))
+	}
+	e.End(component)
+}
+
+func (f CalendarFeed) uid(taskID string) string {
+	return taskID + "@" + f.UIDDomain
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"task9/domain"
+	"task9/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarHandler struct {
+	calendarUseCase *usecase.CalendarUseCase
+	name            string
+	uidDomain       string
+}
+
+func NewCalendarHandler(calendarUseCase *usecase.CalendarUseCase, name, uidDomain string) *CalendarHandler {
+	return &CalendarHandler{calendarUseCase: calendarUseCase, name: name, uidDomain: uidDomain}
+}
+
+// GetFeed serves /calendar/:feed, where feed is the token followed by
+// ".ics". It needs no login; the token is the credential.
+func (h *CalendarHandler) GetFeed(c *gin.Context) {
+	token, ok := strings.CutSuffix(c.Param("feed"), ".ics")
+	if !ok {
+		respondCalendarError(c, domain.ErrCalendarFeedNotFound)
+		return
+	}
+	var query CalendarQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid query parameters",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	feed := CalendarFeed{Name: h.name, UIDDomain: h.uidDomain, Todos: query.Type == "todo"}
+	var body bytes.Buffer
+	err := feed.Write(&body, func(fn func(domain.Task) error) error {
+		return h.calendarUseCase.Feed(c.Request.Context(), token, fn)
+	})
+	if err != nil {
+		respondCalendarError(c, err)
+		return
+	}
+
+	// The URL holds the token, so shared caches must not keep the feed.
+	c.Header("Cache-Control", "private, no-cache")
+	c.Data(http.StatusOK, calendarContentType, body.Bytes())
+}
+
+// RotateFeedToken is the only response that carries the token; a lost
+// token is replaced by rotating again.
+func (h *CalendarHandler) RotateFeedToken(c *gin.Context) {
+	token, err := h.calendarUseCase.RotateFeedToken(c.Request.Context())
+	if err != nil {
+		respondCalendarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "calendar feed token issued, the previous one no longer works",
+		"data":    CalendarTokenResponse{Token: token, Path: "/calendar/" + token + ".ics"},
+	})
+}
+
+func (h *CalendarHandler) DisableFeed(c *gin.Context) {
+	if err := h.calendarUseCase.DisableFeed(c.Request.Context()); err != nil {
+		respondCalendarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "calendar feed turned off",
+	})
+}
+
+func respondCalendarError(c *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	if errors.Is(err, domain.ErrCalendarFeedNotFound) || err.Error() == "user not found" {
+		statusCode = http.StatusNotFound
+	} else if status, ok := contextErrorStatus(err); ok {
+		statusCode = status
+	}
+	c.JSON(statusCode, gin.H{
+		"status":  "error",
+		"message": err.Error(),
+	})
+}
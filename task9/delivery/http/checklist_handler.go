@@ -0,0 +1,101 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"task9/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *TaskHandler) AddChecklistItem(c *gin.Context) {
+	var reqDTO ChecklistItemRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	task, err := h.taskUseCase.AddChecklistItem(c.Request.Context(), c.Param("id"), reqDTO.Text)
+	if err != nil {
+		respondChecklistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "checklist item added",
+		"data":    NewTaskResponse(task),
+	})
+}
+
+func (h *TaskHandler) ToggleChecklistItem(c *gin.Context) {
+	task, err := h.taskUseCase.ToggleChecklistItem(c.Request.Context(), c.Param("id"), c.Param("item_id"))
+	if err != nil {
+		respondChecklistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "checklist item toggled",
+		"data":    NewTaskResponse(task),
+	})
+}
+
+func (h *TaskHandler) RemoveChecklistItem(c *gin.Context) {
+	task, err := h.taskUseCase.RemoveChecklistItem(c.Request.Context(), c.Param("id"), c.Param("item_id"))
+	if err != nil {
+		respondChecklistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "checklist item removed",
+		"data":    NewTaskResponse(task),
+	})
+}
+
+func (h *TaskHandler) ReorderChecklist(c *gin.Context) {
+	var reqDTO ChecklistOrderRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	task, err := h.taskUseCase.ReorderChecklist(c.Request.Context(), c.Param("id"), reqDTO.ItemIDs)
+	if err != nil {
+		respondChecklistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "checklist reordered",
+		"data":    NewTaskResponse(task),
+	})
+}
+
+func respondChecklistError(c *gin.Context, err error) {
+	statusCode := http.StatusNotFound
+	var validationErr *domain.ValidationError
+	if err.Error() == "invalid task ID format" || errors.As(err, &validationErr) {
+		statusCode = http.StatusBadRequest
+	} else if errors.Is(err, domain.ErrConflict) || errors.Is(err, domain.ErrProjectArchived) {
+		statusCode = http.StatusConflict
+	} else if status, ok := contextErrorStatus(err); ok {
+		statusCode = status
+	}
+	c.JSON(statusCode, gin.H{
+		"status":  "error",
+		"message": err.Error(),
+	})
+}
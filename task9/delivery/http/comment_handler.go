@@ -0,0 +1,116 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"task9/domain"
+	"task9/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommentHandler struct {
+	commentUseCase *usecase.CommentUseCase
+}
+
+func NewCommentHandler(commentUseCase *usecase.CommentUseCase) *CommentHandler {
+	return &CommentHandler{commentUseCase: commentUseCase}
+}
+
+func (h *CommentHandler) ListComments(c *gin.Context) {
+	comments, err := h.commentUseCase.ListComments(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewCommentResponses(comments),
+		"count":  len(comments),
+	})
+}
+
+func (h *CommentHandler) AddComment(c *gin.Context) {
+	var reqDTO CommentRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	comment, err := h.commentUseCase.AddComment(c.Request.Context(), c.Param("id"), reqDTO.Body)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "comment added successfully",
+		"data":    NewCommentResponse(comment),
+	})
+}
+
+func (h *CommentHandler) EditComment(c *gin.Context) {
+	var reqDTO CommentRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	comment, err := h.commentUseCase.EditComment(c.Request.Context(), c.Param("id"), c.Param("comment_id"), reqDTO.Body)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "comment updated successfully",
+		"data":    NewCommentResponse(comment),
+	})
+}
+
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	err := h.commentUseCase.DeleteComment(c.Request.Context(), c.Param("id"), c.Param("comment_id"))
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "comment deleted successfully",
+	})
+}
+
+func respondCommentError(c *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	var validationErr *domain.ValidationError
+	switch {
+	case errors.As(err, &validationErr),
+		err.Error() == "invalid task ID format",
+		err.Error() == "invalid comment ID format":
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, domain.ErrForbidden):
+		statusCode = http.StatusForbidden
+	case err.Error() == "task not found", err.Error() == "comment not found":
+		statusCode = http.StatusNotFound
+	default:
+		if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+	}
+	c.JSON(statusCode, gin.H{
+		"status":  "error",
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"task9/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *TaskHandler) AddDependency(c *gin.Context) {
+	var reqDTO DependencyRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	task, err := h.taskUseCase.AddDependency(c.Request.Context(), c.Param("id"), reqDTO.BlockerID)
+	if err != nil {
+		respondDependencyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "dependency added",
+		"data":    NewTaskResponse(task),
+	})
+}
+
+func (h *TaskHandler) RemoveDependency(c *gin.Context) {
+	task, err := h.taskUseCase.RemoveDependency(c.Request.Context(), c.Param("id"), c.Param("blocker_id"))
+	if err != nil {
+		respondDependencyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "dependency removed",
+		"data":    NewTaskResponse(task),
+	})
+}
+
+func (h *TaskHandler) GetDependencyGraph(c *gin.Context) {
+	graph, err := h.taskUseCase.GetDependencyGraph(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondDependencyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewDependencyGraphResponse(graph),
+	})
+}
+
+func respondDependencyError(c *gin.Context, err error) {
+	statusCode := http.StatusNotFound
+	var validationErr *domain.ValidationError
+	if err.Error() == "invalid task ID format" || errors.As(err, &validationErr) {
+		statusCode = http.StatusBadRequest
+	} else if errors.Is(err, domain.ErrConflict) || errors.Is(err, domain.ErrProjectArchived) {
+		statusCode = http.StatusConflict
+	} else if status, ok := contextErrorStatus(err); ok {
+		statusCode = status
+	}
+	c.JSON(statusCode, gin.H{
+		"status":  "error",
+		"message": err.Error(),
+	})
+}
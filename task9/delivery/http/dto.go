@@ -1,24 +1,161 @@
 package http
 
-import "time"
+import (
+	"encoding/json"
+	"task9/domain"
+	"task9/usecase"
+	"time"
+)
 
 type CreateTaskRequest struct {
 	Title       string    `json:"title" binding:"required"`
 	Description string    `json:"description"`
 	DueDate     time.Time `json:"due_date" binding:"required"`
-	Status      string    `json:"status"`
+	Status      string    `json:"status" binding:"omitempty,oneof=pending in_progress completed"`
+	Priority    string    `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
+	ParentID    string    `json:"parent_id"`
+	ProjectID   string    `json:"project_id"`
+	Tags        []string  `json:"tags"`
+	Recurrence  string    `json:"recurrence"`
+	ExternalID  string    `json:"external_id"`
 }
 
 type UpdateTaskRequest struct {
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
 	DueDate     time.Time `json:"due_date"`
-	Status      string    `json:"status"`
+	Status      string    `json:"status" binding:"omitempty,oneof=pending in_progress completed"`
+	Priority    string    `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
+	ParentID    string    `json:"parent_id"`
+	ProjectID   string    `json:"project_id"`
+	Tags        []string  `json:"tags"`
+}
+
+type BatchRequest struct {
+	Atomic     bool                    `json:"atomic"`
+	Operations []BatchOperationRequest `json:"operations" binding:"required,min=1,dive"`
+}
+
+type BatchOperationRequest struct {
+	Op   string            `json:"op" binding:"required,oneof=create update delete"`
+	ID   string            `json:"id"`
+	Task UpdateTaskRequest `json:"task"`
+}
+
+type CommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+type ChecklistItemRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+type ChecklistOrderRequest struct {
+	ItemIDs []string `json:"item_ids" binding:"required"`
+}
+
+type DependencyRequest struct {
+	BlockerID string `json:"blocker_id" binding:"required"`
+}
+
+type RecurrenceRequest struct {
+	Rule string `json:"rule" binding:"required"`
+}
+
+type TagRequest struct {
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+// ProjectRequest creates or edits a project. On edit, omitted fields keep
+// their current value.
+type ProjectRequest struct {
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	DefaultStatus string `json:"default_status" binding:"omitempty,oneof=pending in_progress completed"`
+	Archived      *bool  `json:"archived"`
+}
+
+type ProjectQuery struct {
+	Archived *bool `form:"archived"`
+}
+
+type WorkspaceRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// MemberRequest adds a user to a workspace. The role defaults to member.
+type MemberRequest struct {
+	Username string `json:"username" binding:"required"`
+	Role     string `json:"role" binding:"omitempty,oneof=admin member"`
+}
+
+type MemberRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin member"`
+}
+
+// TaskQuery filters and orders GET /tasks. Tags is a comma-separated list of
+// names.
+type TaskQuery struct {
+	Tags     string `form:"tags"`
+	TagMatch string `form:"tag_match" binding:"omitempty,oneof=any all"`
+	Sort     string `form:"sort" binding:"omitempty,oneof=smart"`
+}
+
+// WebhookRequest creates or edits a webhook subscription. On edit, omitted
+// fields keep their current value.
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+	Active *bool    `json:"active"`
+}
+
+type DeliveryQuery struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending succeeded dead"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// ExportQuery filters GET /tasks/export like TaskQuery and picks the format,
+// which defaults to json.
+type ExportQuery struct {
+	Format   string `form:"format" binding:"omitempty,oneof=csv json ndjson"`
+	Tags     string `form:"tags"`
+	TagMatch string `form:"tag_match" binding:"omitempty,oneof=any all"`
+}
+
+// TaskStatsQuery filters GET /tasks/stats like TaskQuery and picks the days
+// of the daily counts, as YYYY-MM-DD in UTC.
+type TaskStatsQuery struct {
+	ProjectID string    `form:"project_id"`
+	Tags      string    `form:"tags"`
+	TagMatch  string    `form:"tag_match" binding:"omitempty,oneof=any all"`
+	From      time.Time `form:"from" time_format:"2006-01-02" time_utc:"1"`
+	To        time.Time `form:"to" time_format:"2006-01-02" time_utc:"1"`
+}
+
+// ImportQuery configures POST /tasks/import. Without a format, it is taken
+// from the Content-Type of the body.
+type ImportQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv json ndjson"`
+	DryRun bool   `form:"dry_run"`
+	Upsert bool   `form:"upsert"`
+}
+
+type CalendarQuery struct {
+	Type string `form:"type" binding:"omitempty,oneof=event todo"`
+}
+
+type HistoryQuery struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
 }
 
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 }
 
 type LoginRequest struct {
@@ -29,3 +166,667 @@ type LoginRequest struct {
 type PromoteRequest struct {
 	Username string `json:"username" binding:"required"`
 }
+
+type TaskResponse struct {
+	ID           string                  `json:"id"`
+	WorkspaceID  string                  `json:"workspace_id"`
+	ExternalID   string                  `json:"external_id,omitempty"`
+	Title        string                  `json:"title"`
+	Description  string                  `json:"description"`
+	DueDate      time.Time               `json:"due_date"`
+	Status       string                  `json:"status" enum:"pending in_progress completed"`
+	Priority     string                  `json:"priority" enum:"low medium high urgent"`
+	CreatedAt    time.Time               `json:"created_at"`
+	UpdatedAt    time.Time               `json:"updated_at"`
+	DeletedAt    *time.Time              `json:"deleted_at,omitempty"`
+	DeletedBy    string                  `json:"deleted_by,omitempty"`
+	ParentID     string                  `json:"parent_id,omitempty"`
+	ProjectID    string                  `json:"project_id,omitempty"`
+	Checklist    []ChecklistItemResponse `json:"checklist"`
+	BlockedBy    []string                `json:"blocked_by"`
+	Tags         []string                `json:"tags"`
+	Recurrence   *RecurrenceResponse     `json:"recurrence,omitempty"`
+	SubtaskCount int                     `json:"subtask_count"`
+	Progress     *ProgressResponse       `json:"progress,omitempty"`
+	CommentCount int                     `json:"comment_count"`
+}
+
+// TaskRecord is a task as exported and imported. An import reads the fields
+// a task can be created with and ignores ID, CreatedAt and UpdatedAt, so an
+// export can be imported again. DueDate is an RFC 3339 time or a
+// YYYY-MM-DD date.
+type TaskRecord struct {
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"external_id,omitempty"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	DueDate     string   `json:"due_date"`
+	Status      string   `json:"status"`
+	Priority    string   `json:"priority"`
+	ParentID    string   `json:"parent_id,omitempty"`
+	ProjectID   string   `json:"project_id,omitempty"`
+	Tags        []string `json:"tags"`
+	Recurrence  string   `json:"recurrence,omitempty"`
+	CreatedAt   string   `json:"created_at,omitempty"`
+	UpdatedAt   string   `json:"updated_at,omitempty"`
+}
+
+// ImportReportResponse sums up an import. In a dry run, Created and Updated
+// count the rows that would have been written.
+type ImportReportResponse struct {
+	DryRun  bool                     `json:"dry_run"`
+	Rows    int                      `json:"rows"`
+	Created int                      `json:"created"`
+	Updated int                      `json:"updated"`
+	Failed  int                      `json:"failed"`
+	Errors  []ImportRowErrorResponse `json:"errors"`
+}
+
+type ImportRowErrorResponse struct {
+	Row        int    `json:"row"`
+	ExternalID string `json:"external_id,omitempty"`
+	Error      string `json:"error"`
+}
+
+type ChecklistItemResponse struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+// RecurrenceResponse links a task to its series. Rule is empty once the
+// series has stopped, and NextID is set once the next occurrence exists.
+type RecurrenceResponse struct {
+	Rule     string    `json:"rule"`
+	SeriesID string    `json:"series_id"`
+	Start    time.Time `json:"start"`
+	NextID   string    `json:"next_id,omitempty"`
+}
+
+// ProgressResponse counts checklist items and direct subtasks together.
+type ProgressResponse struct {
+	Percent int `json:"percent"`
+	Done    int `json:"done"`
+	Total   int `json:"total"`
+}
+
+// DependencyNodeResponse is a task in a dependency tree. Children are its
+// blockers in the upstream tree and the tasks it blocks downstream.
+type DependencyNodeResponse struct {
+	ID       string                   `json:"id"`
+	Title    string                   `json:"title"`
+	Status   string                   `json:"status" enum:"pending in_progress completed"`
+	Children []DependencyNodeResponse `json:"children"`
+}
+
+type DependencyGraphResponse struct {
+	Task       DependencyNodeResponse   `json:"task"`
+	Upstream   []DependencyNodeResponse `json:"upstream"`
+	Downstream []DependencyNodeResponse `json:"downstream"`
+}
+
+type TagResponse struct {
+	Name        string    `json:"name"`
+	Color       string    `json:"color,omitempty"`
+	Description string    `json:"description,omitempty"`
+	UsageCount  int       `json:"usage_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ProjectResponse struct {
+	ID            string    `json:"id"`
+	WorkspaceID   string    `json:"workspace_id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description,omitempty"`
+	OwnerID       string    `json:"owner_id"`
+	Owner         string    `json:"owner"`
+	Archived      bool      `json:"archived"`
+	DefaultStatus string    `json:"default_status" enum:"pending in_progress completed"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ProjectStatsResponse counts the live tasks of a project. Overdue counts
+// the open tasks whose due date has passed.
+type ProjectStatsResponse struct {
+	Total      int            `json:"total"`
+	ByStatus   map[string]int `json:"by_status"`
+	ByPriority map[string]int `json:"by_priority"`
+	Overdue    int            `json:"overdue"`
+}
+
+// TaskStatsResponse summarises the tasks outside the trash. due_this_week
+// counts the open tasks due this week, Monday to Sunday in UTC, overdue ones
+// included. average_seconds_to_complete is measured over the completions in
+// daily and is 0 without any.
+type TaskStatsResponse struct {
+	Total                    int                      `json:"total"`
+	ByStatus                 map[string]int           `json:"by_status"`
+	Overdue                  int                      `json:"overdue"`
+	DueThisWeek              int                      `json:"due_this_week"`
+	Daily                    []DailyTaskCountResponse `json:"daily"`
+	AverageSecondsToComplete int64                    `json:"average_seconds_to_complete"`
+}
+
+// DailyTaskCountResponse counts the tasks created and completed on a UTC
+// date.
+type DailyTaskCountResponse struct {
+	Date      string `json:"date"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+}
+
+// TaskEventResponse is one event on the task streams. Task is left out of
+// deletions for users who cannot see the trash, and of reset events, which
+// tell the client to reload its tasks because events were missed.
+type TaskEventResponse struct {
+	ID     string        `json:"id,omitempty"`
+	Type   string        `json:"type" enum:"task.created task.updated task.deleted task.restored reset"`
+	TaskID string        `json:"task_id,omitempty"`
+	Task   *TaskResponse `json:"task,omitempty"`
+}
+
+// WebhookResponse is a webhook subscription. The secret is only returned when
+// the subscription is created.
+type WebhookResponse struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDeliveryResponse is one event queued for a webhook. Payload is the
+// exact JSON body that is sent.
+type WebhookDeliveryResponse struct {
+	ID             string      `json:"id"`
+	WebhookID      string      `json:"webhook_id"`
+	EventID        string      `json:"event_id"`
+	EventType      string      `json:"event_type"`
+	Status         string      `json:"status" enum:"pending succeeded dead"`
+	Attempts       int         `json:"attempts"`
+	NextAttemptAt  *time.Time  `json:"next_attempt_at,omitempty"`
+	LastStatusCode int         `json:"last_status_code,omitempty"`
+	LastError      string      `json:"last_error,omitempty"`
+	Payload        interface{} `json:"payload"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+	DeliveredAt    *time.Time  `json:"delivered_at,omitempty"`
+}
+
+type CommentResponse struct {
+	ID        string    `json:"id"`
+	TaskID    string    `json:"task_id"`
+	AuthorID  string    `json:"author_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type AttachmentResponse struct {
+	ID          string    `json:"id"`
+	TaskID      string    `json:"task_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	UploaderID  string    `json:"uploader_id"`
+	Uploader    string    `json:"uploader"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WorkspaceResponse carries the caller's role in the workspace.
+type WorkspaceResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role" enum:"admin member"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MemberResponse struct {
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role" enum:"admin member"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UserResponse struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Role     string `json:"role" enum:"admin user"`
+}
+
+type BatchResultResponse struct {
+	Index  int           `json:"index"`
+	Op     string        `json:"op" enum:"create update delete"`
+	ID     string        `json:"id,omitempty"`
+	Status string        `json:"status" enum:"ok error"`
+	Task   *TaskResponse `json:"task,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+type HistoryEntryResponse struct {
+	Action  string                `json:"action" enum:"create update delete restore"`
+	ActorID string                `json:"actor_id"`
+	Actor   string                `json:"actor"`
+	At      time.Time             `json:"at"`
+	Changes []FieldChangeResponse `json:"changes"`
+}
+
+type FieldChangeResponse struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+type LoginResponse struct {
+	Token string       `json:"token"`
+	User  UserResponse `json:"user"`
+}
+
+// StreamTokenResponse carries a token for the stream_token query parameter
+// of the task streams.
+type StreamTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CalendarTokenResponse is the only place a feed token is shown. Path is
+// the feed's URL path on this server.
+type CalendarTokenResponse struct {
+	Token string `json:"token"`
+	Path  string `json:"path"`
+}
+
+func NewTaskResponse(task domain.Task) TaskResponse {
+	response := TaskResponse{
+		ID:           task.ID,
+		WorkspaceID:  task.WorkspaceID,
+		ExternalID:   task.ExternalID,
+		Title:        task.Title,
+		Description:  task.Description,
+		DueDate:      task.DueDate,
+		Status:       task.Status,
+		Priority:     task.EffectivePriority(),
+		CreatedAt:    task.CreatedAt,
+		UpdatedAt:    task.UpdatedAt,
+		DeletedBy:    task.DeletedBy,
+		ParentID:     task.ParentID,
+		ProjectID:    task.ProjectID,
+		Checklist:    NewChecklistItemResponses(task.Checklist),
+		BlockedBy:    append([]string{}, task.BlockedBy...),
+		Tags:         append([]string{}, task.Tags...),
+		SubtaskCount: task.Subtasks.Total,
+		CommentCount: task.CommentCount,
+	}
+	if !task.DeletedAt.IsZero() {
+		deletedAt := task.DeletedAt
+		response.DeletedAt = &deletedAt
+	}
+	if task.Recurrence != nil {
+		response.Recurrence = &RecurrenceResponse{
+			Rule:     task.Recurrence.Rule,
+			SeriesID: task.Recurrence.SeriesID,
+			Start:    task.Recurrence.Start,
+			NextID:   task.Recurrence.NextID,
+		}
+	}
+	if percent, ok := task.Progress(); ok {
+		response.Progress = &ProgressResponse{
+			Percent: percent,
+			Done:    task.Done(),
+			Total:   len(task.Checklist) + task.Subtasks.Total,
+		}
+	}
+	return response
+}
+
+// NewTaskRecord exports a task. Only the latest occurrence of a repeating
+// series carries the rule, so that importing the export starts each series
+// once.
+func NewTaskRecord(task domain.Task) TaskRecord {
+	record := TaskRecord{
+		ID:          task.ID,
+		ExternalID:  task.ExternalID,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		Priority:    task.EffectivePriority(),
+		ParentID:    task.ParentID,
+		ProjectID:   task.ProjectID,
+		Tags:        append([]string{}, task.Tags...),
+		CreatedAt:   task.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   task.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if !task.DueDate.IsZero() {
+		record.DueDate = task.DueDate.UTC().Format(time.RFC3339)
+	}
+	if task.Recurrence.IsActive() {
+		record.Recurrence = task.Recurrence.Rule
+	}
+	return record
+}
+
+func NewImportReportResponse(report domain.ImportReport, dryRun bool) ImportReportResponse {
+	rowErrors := make([]ImportRowErrorResponse, 0, len(report.Errors))
+	for _, rowErr := range report.Errors {
+		rowErrors = append(rowErrors, ImportRowErrorResponse{
+			Row:        rowErr.Row,
+			ExternalID: rowErr.ExternalID,
+			Error:      rowErr.Err.Error(),
+		})
+	}
+	return ImportReportResponse{
+		DryRun:  dryRun,
+		Rows:    report.Rows,
+		Created: report.Created,
+		Updated: report.Updated,
+		Failed:  len(report.Errors),
+		Errors:  rowErrors,
+	}
+}
+
+func NewChecklistItemResponses(items []domain.ChecklistItem) []ChecklistItemResponse {
+	responses := make([]ChecklistItemResponse, 0, len(items))
+	for _, item := range items {
+		responses = append(responses, ChecklistItemResponse{ID: item.ID, Text: item.Text, Done: item.Done})
+	}
+	return responses
+}
+
+func NewTaskResponses(tasks []domain.Task) []TaskResponse {
+	responses := make([]TaskResponse, 0, len(tasks))
+	for _, task := range tasks {
+		responses = append(responses, NewTaskResponse(task))
+	}
+	return responses
+}
+
+func NewBatchResultResponses(results []domain.BatchResult) []BatchResultResponse {
+	responses := make([]BatchResultResponse, 0, len(results))
+	for i, result := range results {
+		response := BatchResultResponse{
+			Index:  i,
+			Op:     result.Op,
+			ID:     result.ID,
+			Status: "ok",
+		}
+		if result.Task != nil {
+			task := NewTaskResponse(*result.Task)
+			response.Task = &task
+		}
+		if result.Error != nil {
+			response.Status = "error"
+			response.Error = result.Error.Error()
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+func NewHistoryEntryResponses(entries []domain.HistoryEntry) []HistoryEntryResponse {
+	responses := make([]HistoryEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		changes := make([]FieldChangeResponse, 0, len(entry.Changes))
+		for _, change := range entry.Changes {
+			changes = append(changes, FieldChangeResponse{
+				Field: change.Field,
+				Old:   historyValue(change.Old),
+				New:   historyValue(change.New),
+			})
+		}
+		responses = append(responses, HistoryEntryResponse{
+			Action:  entry.Action,
+			ActorID: entry.ActorID,
+			Actor:   entry.Actor,
+			At:      entry.At,
+			Changes: changes,
+		})
+	}
+	return responses
+}
+
+func NewDependencyGraphResponse(graph domain.DependencyGraph) DependencyGraphResponse {
+	return DependencyGraphResponse{
+		Task:       DependencyNodeResponse{ID: graph.Task.ID, Title: graph.Task.Title, Status: graph.Task.Status, Children: []DependencyNodeResponse{}},
+		Upstream:   newDependencyNodeResponses(graph.Upstream),
+		Downstream: newDependencyNodeResponses(graph.Downstream),
+	}
+}
+
+func newDependencyNodeResponses(nodes []domain.DependencyNode) []DependencyNodeResponse {
+	responses := make([]DependencyNodeResponse, 0, len(nodes))
+	for _, node := range nodes {
+		responses = append(responses, DependencyNodeResponse{
+			ID:       node.Task.ID,
+			Title:    node.Task.Title,
+			Status:   node.Task.Status,
+			Children: newDependencyNodeResponses(node.Children),
+		})
+	}
+	return responses
+}
+
+func historyValue(v interface{}) interface{} {
+	if items, ok := v.([]domain.ChecklistItem); ok {
+		return NewChecklistItemResponses(items)
+	}
+	return v
+}
+
+func NewTagResponse(tag domain.Tag) TagResponse {
+	return TagResponse{
+		Name:        tag.Name,
+		Color:       tag.Color,
+		Description: tag.Description,
+		UsageCount:  tag.UsageCount,
+		CreatedAt:   tag.CreatedAt,
+		UpdatedAt:   tag.UpdatedAt,
+	}
+}
+
+func NewTagResponses(tags []domain.Tag) []TagResponse {
+	responses := make([]TagResponse, 0, len(tags))
+	for _, tag := range tags {
+		responses = append(responses, NewTagResponse(tag))
+	}
+	return responses
+}
+
+func NewTaskEventResponse(event usecase.StreamEvent) TaskEventResponse {
+	response := TaskEventResponse{ID: event.ID, Type: event.Type, TaskID: event.TaskID}
+	if event.Task != nil {
+		task := NewTaskResponse(*event.Task)
+		response.Task = &task
+	}
+	return response
+}
+
+func NewWebhookResponse(subscription domain.WebhookSubscription) WebhookResponse {
+	return WebhookResponse{
+		ID:        subscription.ID,
+		URL:       subscription.URL,
+		Events:    append([]string{}, subscription.Events...),
+		Active:    subscription.Active,
+		CreatedAt: subscription.CreatedAt,
+		UpdatedAt: subscription.UpdatedAt,
+	}
+}
+
+func NewWebhookResponses(subscriptions []domain.WebhookSubscription) []WebhookResponse {
+	responses := make([]WebhookResponse, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		responses = append(responses, NewWebhookResponse(subscription))
+	}
+	return responses
+}
+
+func NewWebhookDeliveryResponse(delivery domain.WebhookDelivery) WebhookDeliveryResponse {
+	response := WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		WebhookID:      delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		Payload:        json.RawMessage(delivery.Payload),
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+	if delivery.Status == domain.WebhookPending {
+		nextAttemptAt := delivery.NextAttemptAt
+		response.NextAttemptAt = &nextAttemptAt
+	}
+	if !delivery.DeliveredAt.IsZero() {
+		deliveredAt := delivery.DeliveredAt
+		response.DeliveredAt = &deliveredAt
+	}
+	return response
+}
+
+func NewWebhookDeliveryResponses(deliveries []domain.WebhookDelivery) []WebhookDeliveryResponse {
+	responses := make([]WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		responses = append(responses, NewWebhookDeliveryResponse(delivery))
+	}
+	return responses
+}
+
+func NewCommentResponse(comment domain.Comment) CommentResponse {
+	return CommentResponse{
+		ID:        comment.ID,
+		TaskID:    comment.TaskID,
+		AuthorID:  comment.AuthorID,
+		Author:    comment.Author,
+		Body:      comment.Body,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+	}
+}
+
+func NewCommentResponses(comments []domain.Comment) []CommentResponse {
+	responses := make([]CommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		responses = append(responses, NewCommentResponse(comment))
+	}
+	return responses
+}
+
+func NewAttachmentResponse(attachment domain.Attachment) AttachmentResponse {
+	return AttachmentResponse{
+		ID:          attachment.ID,
+		TaskID:      attachment.TaskID,
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		UploaderID:  attachment.UploaderID,
+		Uploader:    attachment.Uploader,
+		CreatedAt:   attachment.CreatedAt,
+	}
+}
+
+func NewAttachmentResponses(attachments []domain.Attachment) []AttachmentResponse {
+	responses := make([]AttachmentResponse, 0, len(attachments))
+	for _, attachment := range attachments {
+		responses = append(responses, NewAttachmentResponse(attachment))
+	}
+	return responses
+}
+
+func NewProjectResponse(project domain.Project) ProjectResponse {
+	return ProjectResponse{
+		ID:            project.ID,
+		WorkspaceID:   project.WorkspaceID,
+		Name:          project.Name,
+		Description:   project.Description,
+		OwnerID:       project.OwnerID,
+		Owner:         project.Owner,
+		Archived:      project.Archived,
+		DefaultStatus: project.DefaultStatus,
+		CreatedAt:     project.CreatedAt,
+		UpdatedAt:     project.UpdatedAt,
+	}
+}
+
+func NewProjectResponses(projects []domain.Project) []ProjectResponse {
+	responses := make([]ProjectResponse, 0, len(projects))
+	for _, project := range projects {
+		responses = append(responses, NewProjectResponse(project))
+	}
+	return responses
+}
+
+func NewTaskStatsResponse(stats domain.TaskStats) TaskStatsResponse {
+	daily := make([]DailyTaskCountResponse, 0, len(stats.Daily))
+	for _, day := range stats.Daily {
+		daily = append(daily, DailyTaskCountResponse{
+			Date:      day.Day.Format("2006-01-02"),
+			Created:   day.Created,
+			Completed: day.Completed,
+		})
+	}
+	return TaskStatsResponse{
+		Total:                    stats.Total,
+		ByStatus:                 stats.ByStatus,
+		Overdue:                  stats.Overdue,
+		DueThisWeek:              stats.DueThisWeek,
+		Daily:                    daily,
+		AverageSecondsToComplete: int64(stats.AverageTimeToComplete / time.Second),
+	}
+}
+
+func NewProjectStatsResponse(stats domain.ProjectStats) ProjectStatsResponse {
+	return ProjectStatsResponse{
+		Total:      stats.Total,
+		ByStatus:   stats.ByStatus,
+		ByPriority: stats.ByPriority,
+		Overdue:    stats.Overdue,
+	}
+}
+
+func NewWorkspaceResponse(workspace domain.Workspace) WorkspaceResponse {
+	return WorkspaceResponse{
+		ID:        workspace.ID,
+		Name:      workspace.Name,
+		Role:      workspace.Role,
+		CreatedAt: workspace.CreatedAt,
+	}
+}
+
+func NewWorkspaceResponses(workspaces []domain.Workspace) []WorkspaceResponse {
+	responses := make([]WorkspaceResponse, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		responses = append(responses, NewWorkspaceResponse(workspace))
+	}
+	return responses
+}
+
+func NewMemberResponse(membership domain.Membership) MemberResponse {
+	return MemberResponse{
+		UserID:    membership.UserID,
+		Username:  membership.Username,
+		Role:      membership.Role,
+		CreatedAt: membership.CreatedAt,
+	}
+}
+
+func NewMemberResponses(members []domain.Membership) []MemberResponse {
+	responses := make([]MemberResponse, 0, len(members))
+	for _, membership := range members {
+		responses = append(responses, NewMemberResponse(membership))
+	}
+	return responses
+}
+
+func NewUserResponse(user domain.User) UserResponse {
+	return UserResponse{
+		ID:       user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+	}
+}
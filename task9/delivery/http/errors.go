@@ -0,0 +1,14 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+func contextErrorStatus(err error) (int, bool) {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return http.StatusGatewayTimeout, true
+	}
+	return 0, false
+}
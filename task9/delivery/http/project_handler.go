@@ -0,0 +1,194 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"task9/domain"
+	"task9/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProjectHandler struct {
+	projectUseCase *usecase.ProjectUseCase
+	taskUseCase    *usecase.TaskUseCase
+}
+
+func NewProjectHandler(projectUseCase *usecase.ProjectUseCase, taskUseCase *usecase.TaskUseCase) *ProjectHandler {
+	return &ProjectHandler{projectUseCase: projectUseCase, taskUseCase: taskUseCase}
+}
+
+func (h *ProjectHandler) ListProjects(c *gin.Context) {
+	var query ProjectQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid query parameters",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	projects, err := h.projectUseCase.ListProjects(c.Request.Context(), query.Archived)
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewProjectResponses(projects),
+		"count":  len(projects),
+	})
+}
+
+func (h *ProjectHandler) CreateProject(c *gin.Context) {
+	var reqDTO ProjectRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	project, err := h.projectUseCase.CreateProject(c.Request.Context(), reqDTO.toDomain())
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "project created successfully",
+		"data":    NewProjectResponse(project),
+	})
+}
+
+func (h *ProjectHandler) GetProject(c *gin.Context) {
+	project, err := h.projectUseCase.GetProject(c.Request.Context(), c.Param("project_id"))
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewProjectResponse(project),
+	})
+}
+
+func (h *ProjectHandler) UpdateProject(c *gin.Context) {
+	var reqDTO ProjectRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	project, err := h.projectUseCase.UpdateProject(c.Request.Context(), c.Param("project_id"), reqDTO.toDomain())
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "project updated successfully",
+		"data":    NewProjectResponse(project),
+	})
+}
+
+func (h *ProjectHandler) DeleteProject(c *gin.Context) {
+	if err := h.projectUseCase.DeleteProject(c.Request.Context(), c.Param("project_id")); err != nil {
+		respondProjectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "project deleted successfully",
+	})
+}
+
+// ListProjectTasks lists the live tasks of a project, filtered and ordered
+// like GET /tasks.
+func (h *ProjectHandler) ListProjectTasks(c *gin.Context) {
+	var query TaskQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid query parameters",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	project, err := h.projectUseCase.GetProject(c.Request.Context(), c.Param("project_id"))
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+	filter := domain.TaskFilter{ProjectID: project.ID, TagMatch: query.TagMatch, Sort: query.Sort}
+	if query.Tags != "" {
+		filter.Tags = strings.Split(query.Tags, ",")
+	}
+
+	tasks, err := h.taskUseCase.FindTasks(c.Request.Context(), filter)
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewTaskResponses(tasks),
+		"count":  len(tasks),
+	})
+}
+
+func (h *ProjectHandler) GetProjectStats(c *gin.Context) {
+	stats, err := h.projectUseCase.ProjectStats(c.Request.Context(), c.Param("project_id"))
+	if err != nil {
+		respondProjectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewProjectStatsResponse(stats),
+	})
+}
+
+func (r ProjectRequest) toDomain() domain.ProjectRequest {
+	return domain.ProjectRequest{
+		Name:          r.Name,
+		Description:   r.Description,
+		DefaultStatus: r.DefaultStatus,
+		Archived:      r.Archived,
+	}
+}
+
+func respondProjectError(c *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	var validationErr *domain.ValidationError
+	switch {
+	case err.Error() == "invalid project ID format" || errors.As(err, &validationErr):
+		statusCode = http.StatusBadRequest
+	case err.Error() == "project not found":
+		statusCode = http.StatusNotFound
+	default:
+		if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+	}
+	c.JSON(statusCode, gin.H{
+		"status":  "error",
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"task9/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *TaskHandler) SetRecurrence(c *gin.Context) {
+	var reqDTO RecurrenceRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	task, err := h.taskUseCase.SetRecurrence(c.Request.Context(), c.Param("id"), reqDTO.Rule)
+	if err != nil {
+		respondRecurrenceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "recurrence updated",
+		"data":    NewTaskResponse(task),
+	})
+}
+
+func (h *TaskHandler) StopRecurrence(c *gin.Context) {
+	task, err := h.taskUseCase.StopRecurrence(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondRecurrenceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "recurrence stopped",
+		"data":    NewTaskResponse(task),
+	})
+}
+
+func respondRecurrenceError(c *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	var validationErr *domain.ValidationError
+	switch {
+	case err.Error() == "invalid task ID format" || errors.As(err, &validationErr):
+		statusCode = http.StatusBadRequest
+	case err.Error() == "task not found":
+		statusCode = http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict), errors.Is(err, domain.ErrProjectArchived):
+		statusCode = http.StatusConflict
+	default:
+		if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+	}
+	c.JSON(statusCode, gin.H{
+		"status":  "error",
+		"message": err.Error(),
+	})
+}
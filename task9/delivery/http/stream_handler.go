@@ -0,0 +1,218 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"task9/usecase"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// StreamHandler pushes task events to connected clients, over Server-Sent
+// Events or a WebSocket. Both resume after the event ID the client last saw.
+type StreamHandler struct {
+	hub            *usecase.EventHub
+	heartbeat      time.Duration
+	writeTimeout   time.Duration
+	allowedOrigins map[string]bool
+}
+
+// NewStreamHandler sends a keep-alive after heartbeat without events, and
+// disconnects clients that take longer than writeTimeout to accept a write.
+// WebSocket handshakes from pages on other sites than the API are refused
+// unless their origin is in allowedOrigins.
+func NewStreamHandler(hub *usecase.EventHub, heartbeat, writeTimeout time.Duration, allowedOrigins []string) *StreamHandler {
+	origins := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		origins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+	return &StreamHandler{hub: hub, heartbeat: heartbeat, writeTimeout: writeTimeout, allowedOrigins: origins}
+}
+
+// StreamTasks serves the events as text/event-stream. The ID to resume from
+// comes from the Last-Event-ID header that EventSource sends when it
+// reconnects, or the last_event_id query parameter.
+func (h *StreamHandler) StreamTasks(c *gin.Context) {
+	ctx := c.Request.Context()
+	subscription, backlog, reset := h.hub.Subscribe(ctx, lastEventID(c))
+	defer subscription.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	controller := http.NewResponseController(c.Writer)
+	write := func(format string, args ...interface{}) error {
+		// The server's write timeout is meant for ordinary responses, so each
+		// write gets a deadline of its own instead.
+		if err := controller.SetWriteDeadline(deadlineAfter(h.writeTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		if _, err := fmt.Fprintf(c.Writer, format, args...); err != nil {
+			return err
+		}
+		return controller.Flush()
+	}
+	send := func(event TaskEventResponse) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if event.ID == "" {
+			return write("event: %s\ndata: %s\n\n", event.Type, data)
+		}
+		return write("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	}
+
+	if err := write("retry: 3000\n\n"); err != nil {
+		return
+	}
+	if reset {
+		if err := send(TaskEventResponse{Type: "reset"}); err != nil {
+			return
+		}
+	}
+	for _, event := range backlog {
+		if err := send(NewTaskEventResponse(event)); err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			if err := send(NewTaskEventResponse(event)); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := write(": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// StreamTasksWebSocket serves the events as JSON text messages on a
+// WebSocket. The ID to resume from comes from the last_event_id query
+// parameter. Messages from the client are ignored.
+func (h *StreamHandler) StreamTasksWebSocket(c *gin.Context) {
+	server := websocket.Server{
+		// The stream_token cookie is sent along with handshakes that pages on
+		// any site start, so those must be refused by their Origin.
+		Handshake: h.checkOrigin,
+		Handler: func(conn *websocket.Conn) {
+			h.serveWebSocket(c.Request.Context(), conn, lastEventID(c))
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *StreamHandler) serveWebSocket(ctx context.Context, conn *websocket.Conn, lastEventID string) {
+	defer conn.Close()
+	// The hijacked connection keeps the deadlines of the HTTP server.
+	conn.SetDeadline(time.Time{})
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		// Reading handles pings and notices when the client goes away.
+		defer cancel()
+		io.Copy(io.Discard, conn)
+	}()
+
+	subscription, backlog, reset := h.hub.Subscribe(ctx, lastEventID)
+	defer subscription.Close()
+
+	send := func(event TaskEventResponse) error {
+		conn.SetWriteDeadline(deadlineAfter(h.writeTimeout))
+		return websocket.JSON.Send(conn, event)
+	}
+
+	if reset {
+		if err := send(TaskEventResponse{Type: "reset"}); err != nil {
+			return
+		}
+	}
+	for _, event := range backlog {
+		if err := send(NewTaskEventResponse(event)); err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			if err := send(NewTaskEventResponse(event)); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(deadlineAfter(h.writeTimeout))
+			conn.PayloadType = websocket.PingFrame
+			_, err := conn.Write(nil)
+			conn.PayloadType = websocket.TextFrame
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// checkOrigin admits handshakes from the API's own origin and the allowed
+// ones. Clients other than browsers may leave Origin out; they cannot be
+// made to send someone else's cookie.
+func (h *StreamHandler) checkOrigin(config *websocket.Config, req *http.Request) error {
+	if req.Header.Get("Origin") == "" {
+		return nil
+	}
+	origin, err := websocket.Origin(config, req)
+	if err != nil {
+		return err
+	}
+	if origin == nil || origin.Host == "" {
+		return websocket.ErrBadWebSocketOrigin
+	}
+	if strings.EqualFold(origin.Host, req.Host) || h.allowedOrigins[strings.ToLower(origin.Scheme+"://"+origin.Host)] {
+		return nil
+	}
+	return websocket.ErrBadWebSocketOrigin
+}
+
+// deadlineAfter returns the deadline for an operation starting now. A zero
+// timeout means none, as it does for the server.
+func deadlineAfter(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(timeout)
+}
+
+func lastEventID(c *gin.Context) string {
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		return id
+	}
+	return c.Query("last_event_id")
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"task9/domain"
+	"task9/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TagHandler struct {
+	tagUseCase *usecase.TagUseCase
+}
+
+func NewTagHandler(tagUseCase *usecase.TagUseCase) *TagHandler {
+	return &TagHandler{tagUseCase: tagUseCase}
+}
+
+func (h *TagHandler) ListTags(c *gin.Context) {
+	tags, err := h.tagUseCase.ListTags(c.Request.Context())
+	if err != nil {
+		respondTagError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewTagResponses(tags),
+		"count":  len(tags),
+	})
+}
+
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	var reqDTO TagRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	tag, err := h.tagUseCase.CreateTag(c.Request.Context(), domain.TagRequest{
+		Name:        reqDTO.Name,
+		Color:       reqDTO.Color,
+		Description: reqDTO.Description,
+	})
+	if err != nil {
+		respondTagError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "tag created successfully",
+		"data":    NewTagResponse(tag),
+	})
+}
+
+func (h *TagHandler) UpdateTag(c *gin.Context) {
+	var reqDTO TagRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	tag, err := h.tagUseCase.UpdateTag(c.Request.Context(), c.Param("name"), domain.TagRequest{
+		Name:        reqDTO.Name,
+		Color:       reqDTO.Color,
+		Description: reqDTO.Description,
+	})
+	if err != nil {
+		respondTagError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "tag updated successfully",
+		"data":    NewTagResponse(tag),
+	})
+}
+
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	if err := h.tagUseCase.DeleteTag(c.Request.Context(), c.Param("name")); err != nil {
+		respondTagError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "tag deleted successfully",
+	})
+}
+
+func respondTagError(c *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	var validationErr *domain.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		statusCode = http.StatusBadRequest
+	case err.Error() == "tag not found":
+		statusCode = http.StatusNotFound
+	case err.Error() == "tag already exists", errors.Is(err, domain.ErrProjectArchived):
+		statusCode = http.StatusConflict
+	default:
+		if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+	}
+	c.JSON(statusCode, gin.H{
+		"status":  "error",
+		"message": err.Error(),
+	})
+}
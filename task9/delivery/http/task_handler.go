@@ -1,7 +1,9 @@
 package http
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 	"task9/domain"
 	"task9/usecase"
 
@@ -17,9 +19,31 @@ func NewTaskHandler(taskUseCase *usecase.TaskUseCase) *TaskHandler {
 }
 
 func (h *TaskHandler) GetAllTasks(c *gin.Context) {
-	tasks, err := h.taskUseCase.GetAllTasks()
+	var query TaskQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid query parameters",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	filter := domain.TaskFilter{TagMatch: query.TagMatch, Sort: query.Sort}
+	if query.Tags != "" {
+		filter.Tags = strings.Split(query.Tags, ",")
+	}
+
+	tasks, err := h.taskUseCase.FindTasks(c.Request.Context(), filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		statusCode := http.StatusInternalServerError
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			statusCode = http.StatusBadRequest
+		} else if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+		c.JSON(statusCode, gin.H{
 			"status":  "error",
 			"message": "failed to retrieve tasks",
 			"error":   err.Error(),
@@ -29,7 +53,7 @@ func (h *TaskHandler) GetAllTasks(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"data":   tasks,
+		"data":   NewTaskResponses(tasks),
 		"count":  len(tasks),
 	})
 }
@@ -37,11 +61,13 @@ func (h *TaskHandler) GetAllTasks(c *gin.Context) {
 func (h *TaskHandler) GetTaskByID(c *gin.Context) {
 	id := c.Param("id")
 
-	task, err := h.taskUseCase.GetTaskByID(id)
+	task, err := h.taskUseCase.GetTaskByID(c.Request.Context(), id)
 	if err != nil {
 		statusCode := http.StatusNotFound
 		if err.Error() == "invalid task ID format" {
 			statusCode = http.StatusBadRequest
+		} else if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
 		}
 		c.JSON(statusCode, gin.H{
 			"status":  "error",
@@ -52,7 +78,110 @@ func (h *TaskHandler) GetTaskByID(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"data":   task,
+		"data":   NewTaskResponse(task),
+	})
+}
+
+func (h *TaskHandler) GetTaskStats(c *gin.Context) {
+	var query TaskStatsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid query parameters",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	filter := domain.TaskFilter{ProjectID: query.ProjectID, TagMatch: query.TagMatch}
+	if query.Tags != "" {
+		filter.Tags = strings.Split(query.Tags, ",")
+	}
+
+	stats, err := h.taskUseCase.TaskStats(c.Request.Context(), filter, query.From, query.To)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			statusCode = http.StatusBadRequest
+		} else if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+		c.JSON(statusCode, gin.H{
+			"status":  "error",
+			"message": "failed to compute task statistics",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewTaskStatsResponse(stats),
+	})
+}
+
+func (h *TaskHandler) GetTaskHistory(c *gin.Context) {
+	id := c.Param("id")
+
+	query := HistoryQuery{Page: 1, PageSize: 20}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid query parameters",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	entries, total, err := h.taskUseCase.GetHistory(c.Request.Context(), id, query.Page, query.PageSize)
+	if err != nil {
+		statusCode := http.StatusNotFound
+		var validationErr *domain.ValidationError
+		if err.Error() == "invalid task ID format" || errors.As(err, &validationErr) {
+			statusCode = http.StatusBadRequest
+		} else if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+		c.JSON(statusCode, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"data":      NewHistoryEntryResponses(entries),
+		"count":     len(entries),
+		"page":      query.Page,
+		"page_size": query.PageSize,
+		"total":     total,
+	})
+}
+
+func (h *TaskHandler) GetSubtasks(c *gin.Context) {
+	id := c.Param("id")
+
+	tasks, err := h.taskUseCase.GetSubtasks(c.Request.Context(), id)
+	if err != nil {
+		statusCode := http.StatusNotFound
+		if err.Error() == "invalid task ID format" {
+			statusCode = http.StatusBadRequest
+		} else if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+		c.JSON(statusCode, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewTaskResponses(tasks),
+		"count":  len(tasks),
 	})
 }
 
@@ -73,13 +202,24 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		Description: reqDTO.Description,
 		DueDate:     reqDTO.DueDate,
 		Status:      reqDTO.Status,
+		Priority:    reqDTO.Priority,
+		ParentID:    reqDTO.ParentID,
+		ProjectID:   reqDTO.ProjectID,
+		Tags:        reqDTO.Tags,
+		Recurrence:  reqDTO.Recurrence,
+		ExternalID:  reqDTO.ExternalID,
 	}
 
-	task, err := h.taskUseCase.CreateTask(req)
+	task, err := h.taskUseCase.CreateTask(c.Request.Context(), req)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
-		if err.Error() == "invalid status" {
+		var validationErr *domain.ValidationError
+		if err.Error() == "invalid status" || errors.As(err, &validationErr) {
 			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, domain.ErrExternalIDTaken) || errors.Is(err, domain.ErrProjectArchived) {
+			statusCode = http.StatusConflict
+		} else if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
 		}
 		c.JSON(statusCode, gin.H{
 			"status":  "error",
@@ -91,7 +231,7 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{
 		"status":  "success",
 		"message": "task created successfully",
-		"data":    task,
+		"data":    NewTaskResponse(task),
 	})
 }
 
@@ -113,13 +253,22 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 		Description: reqDTO.Description,
 		DueDate:     reqDTO.DueDate,
 		Status:      reqDTO.Status,
+		Priority:    reqDTO.Priority,
+		ParentID:    reqDTO.ParentID,
+		ProjectID:   reqDTO.ProjectID,
+		Tags:        reqDTO.Tags,
 	}
 
-	task, err := h.taskUseCase.UpdateTask(id, req)
+	task, err := h.taskUseCase.UpdateTask(c.Request.Context(), id, req)
 	if err != nil {
 		statusCode := http.StatusNotFound
-		if err.Error() == "invalid task ID format" || err.Error() == "invalid status" {
+		var validationErr *domain.ValidationError
+		if err.Error() == "invalid task ID format" || err.Error() == "invalid status" || errors.As(err, &validationErr) {
 			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, domain.ErrConflict) || errors.Is(err, domain.ErrOpenSubtasks) || errors.Is(err, domain.ErrBlocked) || errors.Is(err, domain.ErrProjectArchived) {
+			statusCode = http.StatusConflict
+		} else if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
 		}
 		c.JSON(statusCode, gin.H{
 			"status":  "error",
@@ -131,18 +280,22 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "task updated successfully",
-		"data":    task,
+		"data":    NewTaskResponse(task),
 	})
 }
 
 func (h *TaskHandler) DeleteTask(c *gin.Context) {
 	id := c.Param("id")
 
-	err := h.taskUseCase.DeleteTask(id)
+	err := h.taskUseCase.DeleteTask(c.Request.Context(), id)
 	if err != nil {
 		statusCode := http.StatusNotFound
 		if err.Error() == "invalid task ID format" {
 			statusCode = http.StatusBadRequest
+		} else if errors.Is(err, domain.ErrProjectArchived) {
+			statusCode = http.StatusConflict
+		} else if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
 		}
 		c.JSON(statusCode, gin.H{
 			"status":  "error",
@@ -153,7 +306,180 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
-		"message": "task deleted successfully",
+		"message": "task moved to trash",
 	})
 }
 
+func (h *TaskHandler) GetTrash(c *gin.Context) {
+	tasks, err := h.taskUseCase.GetTrash(c.Request.Context())
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+		c.JSON(statusCode, gin.H{
+			"status":  "error",
+			"message": "failed to retrieve trash",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewTaskResponses(tasks),
+		"count":  len(tasks),
+	})
+}
+
+func (h *TaskHandler) RestoreTask(c *gin.Context) {
+	id := c.Param("id")
+
+	task, err := h.taskUseCase.RestoreTask(c.Request.Context(), id)
+	if err != nil {
+		statusCode := http.StatusNotFound
+		if err.Error() == "invalid task ID format" {
+			statusCode = http.StatusBadRequest
+		} else if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+		c.JSON(statusCode, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "task restored successfully",
+		"data":    NewTaskResponse(task),
+	})
+}
+
+func (h *TaskHandler) PurgeTask(c *gin.Context) {
+	id := c.Param("id")
+
+	err := h.taskUseCase.PurgeTask(c.Request.Context(), id)
+	if err != nil {
+		statusCode := http.StatusNotFound
+		if err.Error() == "invalid task ID format" {
+			statusCode = http.StatusBadRequest
+		} else if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+		c.JSON(statusCode, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "task permanently deleted",
+	})
+}
+
+func (h *TaskHandler) EmptyTrash(c *gin.Context) {
+	purged, err := h.taskUseCase.EmptyTrash(c.Request.Context())
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+		c.JSON(statusCode, gin.H{
+			"status":  "error",
+			"message": "failed to empty trash",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "trash emptied",
+		"count":   purged,
+	})
+}
+
+func (h *TaskHandler) BatchTasks(c *gin.Context) {
+	var reqDTO BatchRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	ops := make([]domain.BatchOperation, 0, len(reqDTO.Operations))
+	for _, opDTO := range reqDTO.Operations {
+		op := domain.BatchOperation{Op: opDTO.Op, ID: opDTO.ID}
+		switch opDTO.Op {
+		case domain.BatchCreate:
+			op.Create = domain.CreateTaskRequest{
+				Title:       opDTO.Task.Title,
+				Description: opDTO.Task.Description,
+				DueDate:     opDTO.Task.DueDate,
+				Status:      opDTO.Task.Status,
+				Priority:    opDTO.Task.Priority,
+				ParentID:    opDTO.Task.ParentID,
+				ProjectID:   opDTO.Task.ProjectID,
+				Tags:        opDTO.Task.Tags,
+			}
+		case domain.BatchUpdate:
+			op.Update = domain.UpdateTaskRequest{
+				Title:       opDTO.Task.Title,
+				Description: opDTO.Task.Description,
+				DueDate:     opDTO.Task.DueDate,
+				Status:      opDTO.Task.Status,
+				Priority:    opDTO.Task.Priority,
+				ParentID:    opDTO.Task.ParentID,
+				ProjectID:   opDTO.Task.ProjectID,
+				Tags:        opDTO.Task.Tags,
+			}
+		}
+		ops = append(ops, op)
+	}
+
+	results, err := h.taskUseCase.ExecuteBatch(c.Request.Context(), ops, reqDTO.Atomic)
+	if errors.Is(err, domain.ErrBatchAborted) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    NewBatchResultResponses(results),
+		})
+		return
+	}
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			statusCode = http.StatusBadRequest
+		} else if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+		c.JSON(statusCode, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != nil {
+			failed++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"message":   "batch processed",
+		"data":      NewBatchResultResponses(results),
+		"succeeded": len(results) - failed,
+		"failed":    failed,
+	})
+}
@@ -0,0 +1,277 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"task9/domain"
+	"task9/usecase"
+	"time"
+)
+
+const (
+	formatCSV    = "csv"
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+)
+
+// transferContentTypes maps each transfer format to the media type of an
+// export. Imports also accept the media types in importFormats.
+var transferContentTypes = map[string]string{
+	formatCSV:    "text/csv; charset=utf-8",
+	formatJSON:   "application/json; charset=utf-8",
+	formatNDJSON: "application/x-ndjson",
+}
+
+var importFormats = map[string]string{
+	"text/csv":             formatCSV,
+	"application/json":     formatJSON,
+	"application/x-ndjson": formatNDJSON,
+	"application/ndjson":   formatNDJSON,
+}
+
+// taskRecordColumns is the CSV header. Tags are comma-separated within
+// their cell; tag names cannot contain commas.
+var taskRecordColumns = []string{
+	"id", "external_id", "title", "description", "due_date", "status",
+	"priority", "parent_id", "project_id", "tags", "recurrence", "created_at", "updated_at",
+}
+
+// errInvalidImport marks an import body that cannot be read any further,
+// as opposed to a single bad row.
+var errInvalidImport = errors.New("invalid import file")
+
+// taskRecordEncoder writes an export in one of the transfer formats. Output
+// is buffered; Close writes what is left.
+type taskRecordEncoder struct {
+	format string
+	w      *bufio.Writer
+	csv    *csv.Writer
+	count  int
+}
+
+func newTaskRecordEncoder(format string, w io.Writer) *taskRecordEncoder {
+	e := &taskRecordEncoder{format: format, w: bufio.NewWriter(w)}
+	switch format {
+	case formatCSV:
+		e.csv = csv.NewWriter(e.w)
+		e.csv.Write(taskRecordColumns)
+	case formatJSON:
+		e.w.WriteByte('[')
+	}
+	return e
+}
+
+func (e *taskRecordEncoder) Encode(record TaskRecord) error {
+	e.count++
+	if e.format == formatCSV {
+		return e.csv.Write([]string{
+			record.ID, record.ExternalID, record.Title, record.Description,
+			record.DueDate, record.Status, record.Priority, record.ParentID, record.ProjectID,
+			strings.Join(record.Tags, ","), record.Recurrence, record.CreatedAt, record.UpdatedAt,
+		})
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if e.format == formatJSON && e.count > 1 {
+		e.w.WriteByte(',')
+	}
+	e.w.Write(data)
+	if e.format == formatNDJSON {
+		e.w.WriteByte('\n')
+	}
+	return nil
+}
+
+func (e *taskRecordEncoder) Close() error {
+	switch e.format {
+	case formatCSV:
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	case formatJSON:
+		e.w.WriteString("]\n")
+	}
+	return e.w.Flush()
+}
+
+// newImportSource reads the rows of an import body one at a time. A row that
+// cannot be turned into a task is reported with a validation error; a body
+// that cannot be read any further yields errInvalidImport or the read error.
+func newImportSource(format string, body io.Reader) usecase.ImportSource {
+	switch format {
+	case formatCSV:
+		return csvImportSource(body)
+	case formatNDJSON:
+		return ndjsonImportSource(body)
+	default:
+		return jsonImportSource(body)
+	}
+}
+
+// csvImportSource matches columns by their name in the header row, so their
+// order does not matter and unknown columns are ignored. An empty cell is
+// the same as a missing field.
+func csvImportSource(body io.Reader) usecase.ImportSource {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	var columns map[string]int
+
+	return func() (domain.CreateTaskRequest, error) {
+		if columns == nil {
+			header, err := reader.Read()
+			if err != nil {
+				return domain.CreateTaskRequest{}, csvError(err)
+			}
+			columns = map[string]int{}
+			for i, name := range header {
+				// Spreadsheets often start the file with a byte order mark.
+				name = strings.TrimPrefix(name, "\ufeff")
+				columns[strings.ToLower(strings.TrimSpace(name))] = i
+			}
+		}
+
+		row, err := reader.Read()
+		if err != nil {
+			return domain.CreateTaskRequest{}, csvError(err)
+		}
+		cell := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return row[i]
+			}
+			return ""
+		}
+
+		record := TaskRecord{
+			ExternalID:  cell("external_id"),
+			Title:       cell("title"),
+			Description: cell("description"),
+			DueDate:     cell("due_date"),
+			Status:      strings.TrimSpace(cell("status")),
+			Priority:    strings.TrimSpace(cell("priority")),
+			ParentID:    strings.TrimSpace(cell("parent_id")),
+			ProjectID:   strings.TrimSpace(cell("project_id")),
+			Recurrence:  strings.TrimSpace(cell("recurrence")),
+		}
+		if tags := cell("tags"); strings.TrimSpace(tags) != "" {
+			record.Tags = strings.Split(tags, ",")
+		}
+		return record.toRequest()
+	}
+}
+
+func csvError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("%w: %v", errInvalidImport, parseErr)
+	}
+	return err
+}
+
+// jsonImportSource reads an array of task records element by element.
+func jsonImportSource(body io.Reader) usecase.ImportSource {
+	decoder := json.NewDecoder(body)
+	started := false
+
+	return func() (domain.CreateTaskRequest, error) {
+		if !started {
+			started = true
+			token, err := decoder.Token()
+			if err != nil {
+				return domain.CreateTaskRequest{}, jsonError(err)
+			}
+			if delim, ok := token.(json.Delim); !ok || delim != '[' {
+				return domain.CreateTaskRequest{}, fmt.Errorf("%w: expected an array of tasks", errInvalidImport)
+			}
+		}
+
+		if !decoder.More() {
+			if _, err := decoder.Token(); err != nil {
+				return domain.CreateTaskRequest{}, jsonError(err)
+			}
+			return domain.CreateTaskRequest{}, io.EOF
+		}
+		var record TaskRecord
+		if err := decoder.Decode(&record); err != nil {
+			// A value of the wrong type is read past, so the next row can
+			// still be decoded.
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &typeErr) {
+				return domain.CreateTaskRequest{ExternalID: record.ExternalID}, domain.NewValidationError(err.Error())
+			}
+			return domain.CreateTaskRequest{}, jsonError(err)
+		}
+		return record.toRequest()
+	}
+}
+
+func jsonError(err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %v", errInvalidImport, err)
+	}
+	return err
+}
+
+// ndjsonImportSource reads one task record per line. Lines stand on their
+// own, so a malformed line only rejects its row. Blank lines are skipped.
+func ndjsonImportSource(body io.Reader) usecase.ImportSource {
+	reader := bufio.NewReader(body)
+
+	return func() (domain.CreateTaskRequest, error) {
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil && (err != io.EOF || len(line) == 0) {
+				return domain.CreateTaskRequest{}, err
+			}
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+
+			var record TaskRecord
+			if err := json.Unmarshal(line, &record); err != nil {
+				return domain.CreateTaskRequest{ExternalID: record.ExternalID}, domain.NewValidationError("invalid JSON: " + err.Error())
+			}
+			return record.toRequest()
+		}
+	}
+}
+
+// toRequest turns an imported record into a create request. The request is
+// returned along with a validation error so that the row can be reported by
+// its external ID.
+func (r TaskRecord) toRequest() (domain.CreateTaskRequest, error) {
+	req := domain.CreateTaskRequest{
+		ExternalID:  strings.TrimSpace(r.ExternalID),
+		Title:       r.Title,
+		Description: r.Description,
+		Status:      r.Status,
+		Priority:    r.Priority,
+		ParentID:    r.ParentID,
+		ProjectID:   r.ProjectID,
+		Tags:        r.Tags,
+		Recurrence:  r.Recurrence,
+	}
+	if dueDate := strings.TrimSpace(r.DueDate); dueDate != "" {
+		parsed, err := time.Parse(time.RFC3339, dueDate)
+		if err != nil {
+			parsed, err = time.Parse(time.DateOnly, dueDate)
+		}
+		if err != nil {
+			return req, domain.NewValidationError("due_date must be an RFC 3339 time or a YYYY-MM-DD date")
+		}
+		req.DueDate = parsed
+	}
+	return req, nil
+}
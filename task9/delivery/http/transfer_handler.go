@@ -0,0 +1,191 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"task9/domain"
+	"task9/usecase"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TransferHandler exports tasks to files and imports them back. Both run
+// for as long as the data takes to go through, so the server's read and
+// write timeouts are applied to each read and write instead of to the
+// whole request.
+type TransferHandler struct {
+	taskUseCase  *usecase.TaskUseCase
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+func NewTransferHandler(taskUseCase *usecase.TaskUseCase, readTimeout, writeTimeout time.Duration) *TransferHandler {
+	return &TransferHandler{taskUseCase: taskUseCase, readTimeout: readTimeout, writeTimeout: writeTimeout}
+}
+
+// ExportTasks streams the tasks matching the query as they are read. An
+// error before the first task is answered as usual; after that the
+// connection is cut, so that the client cannot mistake a partial export for
+// a complete one.
+func (h *TransferHandler) ExportTasks(c *gin.Context) {
+	var query ExportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid query parameters",
+			"error":   err.Error(),
+		})
+		return
+	}
+	format := query.Format
+	if format == "" {
+		format = formatJSON
+	}
+
+	filter := domain.TaskFilter{TagMatch: query.TagMatch}
+	if query.Tags != "" {
+		filter.Tags = strings.Split(query.Tags, ",")
+	}
+
+	var encoder *taskRecordEncoder
+	start := func() {
+		c.Header("Content-Type", transferContentTypes[format])
+		c.Header("Content-Disposition", `attachment; filename="tasks.`+format+`"`)
+		c.Status(http.StatusOK)
+		encoder = newTaskRecordEncoder(format, &deadlineWriter{w: c.Writer, controller: http.NewResponseController(c.Writer), timeout: h.writeTimeout})
+	}
+
+	err := h.taskUseCase.ExportTasks(c.Request.Context(), filter, func(task domain.Task) error {
+		if encoder == nil {
+			start()
+		}
+		return encoder.Encode(NewTaskRecord(task))
+	})
+	if err != nil && encoder == nil {
+		statusCode := http.StatusInternalServerError
+		var validationErr *domain.ValidationError
+		if errors.As(err, &validationErr) {
+			statusCode = http.StatusBadRequest
+		} else if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+		c.JSON(statusCode, gin.H{
+			"status":  "error",
+			"message": "failed to export tasks",
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err == nil {
+		if encoder == nil {
+			start()
+		}
+		err = encoder.Close()
+	}
+	if err != nil {
+		log.Printf("export tasks: %v", err)
+		abortResponse(c)
+	}
+}
+
+// ImportTasks reads the body row by row and answers with a report of the
+// rows that failed. The rows are written as they are read, so if the body
+// turns out to be unreadable half-way, the report of the rows before is
+// sent along with the error.
+func (h *TransferHandler) ImportTasks(c *gin.Context) {
+	var query ImportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid query parameters",
+			"error":   err.Error(),
+		})
+		return
+	}
+	format := query.Format
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+		format = importFormats[mediaType]
+	}
+	if format == "" {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"status":  "error",
+			"message": "import format must be csv, json or ndjson, given by the format parameter or the Content-Type",
+		})
+		return
+	}
+
+	body := &deadlineReader{body: c.Request.Body, controller: http.NewResponseController(c.Writer), timeout: h.readTimeout}
+	report, err := h.taskUseCase.ImportTasks(c.Request.Context(), newImportSource(format, body), domain.ImportOptions{
+		DryRun: query.DryRun,
+		Upsert: query.Upsert,
+	})
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		var maxBytesErr *http.MaxBytesError
+		if errors.Is(err, errInvalidImport) {
+			statusCode = http.StatusBadRequest
+		} else if errors.As(err, &maxBytesErr) {
+			statusCode = http.StatusRequestEntityTooLarge
+		} else if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+		c.JSON(statusCode, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+			"data":    NewImportReportResponse(report, query.DryRun),
+		})
+		return
+	}
+
+	message := "import processed"
+	if query.DryRun {
+		message = "import checked, nothing was written"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": message,
+		"data":    NewImportReportResponse(report, query.DryRun),
+	})
+}
+
+// deadlineWriter gives every write a deadline of its own.
+type deadlineWriter struct {
+	w          io.Writer
+	controller *http.ResponseController
+	timeout    time.Duration
+}
+
+func (w *deadlineWriter) Write(p []byte) (int, error) {
+	if err := w.controller.SetWriteDeadline(deadlineAfter(w.timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
+
+// deadlineReader gives every read of a request body a deadline of its own.
+type deadlineReader struct {
+	body       io.Reader
+	controller *http.ResponseController
+	timeout    time.Duration
+}
+
+func (r *deadlineReader) Read(p []byte) (int, error) {
+	if err := r.controller.SetReadDeadline(deadlineAfter(r.timeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return 0, err
+	}
+	return r.body.Read(p)
+}
+
+// abortResponse cuts the connection of a response that failed after it was
+// started.
+func abortResponse(c *gin.Context) {
+	if conn, _, err := http.NewResponseController(c.Writer).Hijack(); err == nil {
+		conn.Close()
+	}
+}
@@ -0,0 +1,182 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"task9/domain"
+	"task9/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookUseCase *usecase.WebhookUseCase
+}
+
+func NewWebhookHandler(webhookUseCase *usecase.WebhookUseCase) *WebhookHandler {
+	return &WebhookHandler{webhookUseCase: webhookUseCase}
+}
+
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	subscriptions, err := h.webhookUseCase.ListWebhooks(c.Request.Context())
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewWebhookResponses(subscriptions),
+		"count":  len(subscriptions),
+	})
+}
+
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	subscription, err := h.webhookUseCase.GetWebhook(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewWebhookResponse(subscription),
+	})
+}
+
+// CreateWebhook is the only response that carries the secret, so that a
+// generated one can be handed to the receiver.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var reqDTO WebhookRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	subscription, err := h.webhookUseCase.CreateWebhook(c.Request.Context(), newDomainWebhookRequest(reqDTO))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	response := NewWebhookResponse(subscription)
+	response.Secret = subscription.Secret
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "webhook created successfully",
+		"data":    response,
+	})
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	var reqDTO WebhookRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	subscription, err := h.webhookUseCase.UpdateWebhook(c.Request.Context(), c.Param("id"), newDomainWebhookRequest(reqDTO))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "webhook updated successfully",
+		"data":    NewWebhookResponse(subscription),
+	})
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	if err := h.webhookUseCase.DeleteWebhook(c.Request.Context(), c.Param("id")); err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "webhook deleted successfully",
+	})
+}
+
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	query := DeliveryQuery{Page: 1, PageSize: 20}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid query parameters",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	deliveries, total, err := h.webhookUseCase.ListDeliveries(c.Request.Context(), c.Param("id"), query.Status, query.Page, query.PageSize)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "success",
+		"data":      NewWebhookDeliveryResponses(deliveries),
+		"count":     len(deliveries),
+		"page":      query.Page,
+		"page_size": query.PageSize,
+		"total":     total,
+	})
+}
+
+func (h *WebhookHandler) RetryDelivery(c *gin.Context) {
+	delivery, err := h.webhookUseCase.RetryDelivery(c.Request.Context(), c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "delivery queued for retry",
+		"data":    NewWebhookDeliveryResponse(delivery),
+	})
+}
+
+func newDomainWebhookRequest(reqDTO WebhookRequest) domain.WebhookRequest {
+	return domain.WebhookRequest{
+		URL:    reqDTO.URL,
+		Events: reqDTO.Events,
+		Secret: reqDTO.Secret,
+		Active: reqDTO.Active,
+	}
+}
+
+func respondWebhookError(c *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	var validationErr *domain.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		statusCode = http.StatusBadRequest
+	case err.Error() == "invalid webhook ID format", err.Error() == "invalid delivery ID format":
+		statusCode = http.StatusBadRequest
+	case err.Error() == "webhook not found", err.Error() == "delivery not found":
+		statusCode = http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		statusCode = http.StatusConflict
+	default:
+		if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+	}
+	c.JSON(statusCode, gin.H{
+		"status":  "error",
+		"message": err.Error(),
+	})
+}
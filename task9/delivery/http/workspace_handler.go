@@ -0,0 +1,169 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"task9/domain"
+	"task9/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WorkspaceHandler struct {
+	workspaceUseCase *usecase.WorkspaceUseCase
+}
+
+func NewWorkspaceHandler(workspaceUseCase *usecase.WorkspaceUseCase) *WorkspaceHandler {
+	return &WorkspaceHandler{workspaceUseCase: workspaceUseCase}
+}
+
+func (h *WorkspaceHandler) ListWorkspaces(c *gin.Context) {
+	workspaces, err := h.workspaceUseCase.ListWorkspaces(c.Request.Context())
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewWorkspaceResponses(workspaces),
+		"count":  len(workspaces),
+	})
+}
+
+func (h *WorkspaceHandler) CreateWorkspace(c *gin.Context) {
+	var reqDTO WorkspaceRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	workspace, err := h.workspaceUseCase.CreateWorkspace(c.Request.Context(), reqDTO.Name)
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "workspace created successfully",
+		"data":    NewWorkspaceResponse(workspace),
+	})
+}
+
+func (h *WorkspaceHandler) GetWorkspace(c *gin.Context) {
+	workspace, err := h.workspaceUseCase.GetWorkspace(c.Request.Context(), c.Param("wid"))
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewWorkspaceResponse(workspace),
+	})
+}
+
+func (h *WorkspaceHandler) ListMembers(c *gin.Context) {
+	members, err := h.workspaceUseCase.ListMembers(c.Request.Context(), c.Param("wid"))
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   NewMemberResponses(members),
+		"count":  len(members),
+	})
+}
+
+func (h *WorkspaceHandler) AddMember(c *gin.Context) {
+	var reqDTO MemberRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	membership, err := h.workspaceUseCase.AddMember(c.Request.Context(), c.Param("wid"), domain.MemberRequest{
+		Username: reqDTO.Username,
+		Role:     reqDTO.Role,
+	})
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"message": "member added successfully",
+		"data":    NewMemberResponse(membership),
+	})
+}
+
+func (h *WorkspaceHandler) UpdateMember(c *gin.Context) {
+	var reqDTO MemberRoleRequest
+	if err := c.ShouldBindJSON(&reqDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":  "error",
+			"message": "invalid request body",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	membership, err := h.workspaceUseCase.UpdateMember(c.Request.Context(), c.Param("wid"), c.Param("user_id"), reqDTO.Role)
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "member updated successfully",
+		"data":    NewMemberResponse(membership),
+	})
+}
+
+func (h *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	if err := h.workspaceUseCase.RemoveMember(c.Request.Context(), c.Param("wid"), c.Param("user_id")); err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "member removed successfully",
+	})
+}
+
+func respondWorkspaceError(c *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	var validationErr *domain.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, domain.ErrNotWorkspaceAdmin):
+		statusCode = http.StatusForbidden
+	case errors.Is(err, domain.ErrAlreadyMember), errors.Is(err, domain.ErrLastWorkspaceAdmin):
+		statusCode = http.StatusConflict
+	case err.Error() == "workspace not found", err.Error() == "member not found", err.Error() == "user not found":
+		statusCode = http.StatusNotFound
+	default:
+		if status, ok := contextErrorStatus(err); ok {
+			statusCode = status
+		}
+	}
+	c.JSON(statusCode, gin.H{
+		"status":  "error",
+		"message": err.Error(),
+	})
+}
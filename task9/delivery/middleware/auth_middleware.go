@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"task9/domain"
 	"task9/infrastructure"
 
 	"github.com/gin-gonic/gin"
@@ -49,14 +50,67 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
-		c.Set("user_id", claims["user_id"])
-		c.Set("username", claims["username"])
-		c.Set("role", claims["role"])
+		setActor(c, claims)
+		c.Next()
+	}
+}
 
+// RequireStreamAuth is RequireAuth for the task streams. Browsers cannot send
+// the Authorization header with EventSource or WebSocket, so without one it
+// takes a stream token from POST /auth/stream-token, in the stream_token
+// query parameter or cookie. Only stream tokens are accepted there, so that
+// the long-lived tokens never appear in URLs.
+func (m *AuthMiddleware) RequireStreamAuth() gin.HandlerFunc {
+	requireAuth := m.RequireAuth()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			requireAuth(c)
+			return
+		}
+
+		token := c.Query("stream_token")
+		if token == "" {
+			token, _ = c.Cookie("stream_token")
+		}
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"status":  "error",
+				"message": "authorization header or stream token required",
+			})
+			return
+		}
+
+		claims, err := m.tokenGenerator.ValidateStream(token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"status":  "error",
+				"message": "invalid or expired stream token",
+			})
+			return
+		}
+
+		setActor(c, claims)
 		c.Next()
 	}
 }
 
+// setActor stores the user of a validated token for the handlers and the
+// use cases.
+func setActor(c *gin.Context, claims map[string]interface{}) {
+	c.Set("user_id", claims["user_id"])
+	c.Set("username", claims["username"])
+	c.Set("role", claims["role"])
+
+	userID, _ := claims["user_id"].(string)
+	username, _ := claims["username"].(string)
+	role, _ := claims["role"].(string)
+	c.Request = c.Request.WithContext(domain.WithActor(c.Request.Context(), domain.Actor{
+		UserID:   userID,
+		Username: username,
+		Role:     role,
+	}))
+}
+
 func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
@@ -82,3 +136,35 @@ func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
 	}
 }
 
+// RequireWorkspace admits members of the workspace in the :wid path
+// parameter and scopes the request to it with domain.WithWorkspace. It must
+// run after RequireAuth. Within the workspace the member's role there
+// replaces the global role, so that RequireAdmin and the use cases check
+// the workspace role. Workspaces the user does not belong to are answered
+// with 404, as if they did not exist.
+func (m *AuthMiddleware) RequireWorkspace(workspaces domain.WorkspaceRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		actor := domain.ActorFrom(ctx)
+		membership, err := workspaces.GetMember(ctx, c.Param("wid"), actor.UserID)
+		if err != nil {
+			statusCode := http.StatusInternalServerError
+			message := "failed to check workspace membership"
+			if err.Error() == "member not found" {
+				statusCode = http.StatusNotFound
+				message = "workspace not found"
+			}
+			c.AbortWithStatusJSON(statusCode, gin.H{
+				"status":  "error",
+				"message": message,
+			})
+			return
+		}
+
+		actor.Role = membership.Role
+		c.Set("role", membership.Role)
+		c.Request = c.Request.WithContext(domain.WithWorkspace(domain.WithActor(ctx, actor), membership.WorkspaceID))
+
+		c.Next()
+	}
+}
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"task9/domain"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// Idempotency makes requests sent with an Idempotency-Key header safe to
+// retry. The first request with a key runs as usual and its response is
+// kept for ttl; a retry with the same key and the same method, path and body
+// gets that response again, marked with Idempotent-Replayed, without running.
+// Keys are per user, so it must run after RequireAuth. A key reused for a
+// different request is answered with 422, and a retry while the first
+// request is still running with 409. Server errors are not kept, so that the
+// request can be retried.
+//
+// The body is read into memory to fingerprint it, so the route needs a body
+// limit. Safe methods and requests without the header pass through, as does
+// everything when ttl is zero.
+func Idempotency(repo domain.IdempotencyRepository, ttl, lockTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || ttl <= 0 || isSafeMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if !validIdempotencyKey(key) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Idempotency-Key must be at most 255 printable ASCII characters",
+			})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			statusCode := http.StatusBadRequest
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				statusCode = http.StatusRequestEntityTooLarge
+			}
+			c.AbortWithStatusJSON(statusCode, gin.H{
+				"status":  "error",
+				"message": "failed to read request body",
+				"error":   err.Error(),
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		record := domain.IdempotencyRecord{
+			Key:         domain.ActorFrom(c.Request.Context()).UserID + ":" + key,
+			Fingerprint: requestFingerprint(c.Request, body),
+			ExpiresAt:   now.Add(lockTimeout),
+		}
+		stored, reserved, err := repo.Reserve(c.Request.Context(), record, now)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "failed to check the idempotency key",
+				"error":   err.Error(),
+			})
+			return
+		}
+		if !reserved {
+			switch {
+			case stored.Fingerprint != record.Fingerprint:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"status":  "error",
+					"message": "Idempotency-Key was already used for a different request",
+				})
+			case stored.Status == 0:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"status":  "error",
+					"message": "a request with this Idempotency-Key is still in progress",
+				})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(stored.Status, stored.ContentType, stored.Body)
+				c.Abort()
+			}
+			return
+		}
+		// Only this reservation may be completed or released by this request.
+		record = stored
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// The request context may be over by now, but the outcome still has
+		// to be stored.
+		ctx := context.WithoutCancel(c.Request.Context())
+		if !recorder.Written() || recorder.Status() >= http.StatusInternalServerError {
+			if err := repo.Release(ctx, record); err != nil {
+				log.Printf("release idempotency key: %v", err)
+			}
+			return
+		}
+		record.Status = recorder.Status()
+		record.ContentType = recorder.Header().Get("Content-Type")
+		record.Body = recorder.body.Bytes()
+		record.ExpiresAt = time.Now().Add(ttl)
+		if err := repo.Complete(ctx, record); err != nil {
+			log.Printf("store idempotent response: %v", err)
+		}
+	}
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestFingerprint identifies what a request asks for, so that a key
+// reused for something else is told apart from a retry.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response body as it is written.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(p []byte) (int, error) {
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
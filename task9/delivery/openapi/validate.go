@@ -0,0 +1,184 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateRequests rejects JSON bodies that do not match the request schema
+// of the matched route before they reach the handler. Routes that are not in
+// the document, or that declare no JSON body, pass through untouched.
+func ValidateRequests(doc *Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		op, ok := doc.Operation(c.Request.Method, c.FullPath())
+		if !ok || op.RequestBody == nil {
+			c.Next()
+			return
+		}
+		media, ok := op.RequestBody.Content["application/json"]
+		if !ok || media.Schema == nil {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "invalid request body",
+				"error":   err.Error(),
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var value interface{}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "invalid request body",
+				"error":   err.Error(),
+			})
+			return
+		}
+
+		if problems := doc.Validate(media.Schema, value); len(problems) > 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "request does not match the API specification",
+				"error":   strings.Join(problems, "; "),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Validate checks a decoded JSON value against schema and returns one message
+// per violation.
+func (d *Document) Validate(schema *Schema, value interface{}) []string {
+	var problems []string
+	d.validate(schema, value, "body", &problems)
+	return problems
+}
+
+func (d *Document) validate(schema *Schema, value interface{}, path string, problems *[]string) {
+	if schema.Ref != "" {
+		resolved, ok := d.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+		if !ok {
+			return
+		}
+		schema = resolved
+	}
+	if value == nil {
+		return
+	}
+
+	report := func(format string, args ...interface{}) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			report("must be an object")
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				*problems = append(*problems, path+"."+name+": is required")
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := schema.Properties[name]; ok {
+				d.validate(prop, obj[name], path+"."+name, problems)
+			} else if schema.AdditionalProperties != nil {
+				d.validate(schema.AdditionalProperties, obj[name], path+"."+name, problems)
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			report("must be an array")
+			return
+		}
+		if schema.MinItems != nil && len(items) < *schema.MinItems {
+			report("must contain at least %d items", *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+			report("must contain at most %d items", *schema.MaxItems)
+		}
+		if schema.Items != nil {
+			for i, item := range items {
+				d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			report("must be a string")
+			return
+		}
+		if schema.MinLength != nil && len(s) < *schema.MinLength {
+			report("must be at least %d characters", *schema.MinLength)
+		}
+		if schema.MaxLength != nil && len(s) > *schema.MaxLength {
+			report("must be at most %d characters", *schema.MaxLength)
+		}
+		if len(schema.Enum) > 0 && s != "" && !contains(schema.Enum, s) {
+			report("must be one of: %s", strings.Join(schema.Enum, ", "))
+		}
+		if schema.Format == "date-time" && s != "" {
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				report("must be an RFC3339 date-time")
+			}
+		}
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			report("must be a %s", schema.Type)
+			return
+		}
+		f, err := n.Float64()
+		if err != nil || (schema.Type == "integer" && strings.ContainsAny(n.String(), ".eE")) {
+			report("must be a %s", schema.Type)
+			return
+		}
+		if schema.Minimum != nil && f < *schema.Minimum {
+			report("must be at least %v", *schema.Minimum)
+		}
+		if schema.Maximum != nil && f > *schema.Maximum {
+			report("must be at most %v", *schema.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			report("must be a boolean")
+		}
+	}
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,829 @@
+package delivery
+
+import (
+	"strings"
+	"task9/delivery/http"
+	"task9/delivery/openapi"
+)
+
+type errorEnvelope struct {
+	Status  string `json:"status" binding:"required" enum:"error"`
+	Message string `json:"message" binding:"required"`
+	Error   string `json:"error,omitempty"`
+}
+
+type messageEnvelope struct {
+	Status  string `json:"status" binding:"required" enum:"success"`
+	Message string `json:"message" binding:"required"`
+}
+
+type apiInfo struct {
+	Message string `json:"message"`
+	Version string `json:"version"`
+}
+
+type access int
+
+const (
+	public access = iota
+	authenticated
+	adminOnly
+)
+
+// OpenAPISpec describes every route registered by SetupRouter. Request and
+// response schemas are generated from the DTOs in delivery/http.
+func OpenAPISpec() *openapi.Document {
+	doc := openapi.New(
+		"Task Management API",
+		"1.0.0",
+		"Task management REST API with JWT authentication and role-based authorization.",
+	)
+	doc.Components.SecuritySchemes["bearerAuth"] = openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+		Description:  "Token returned by POST /auth/login",
+	}
+	doc.Tags = []openapi.Tag{
+		{Name: "meta", Description: "API metadata"},
+		{Name: "auth", Description: "Registration, login and user roles"},
+		{Name: "workspaces", Description: "Workspaces holding tasks, tags and projects, and their members"},
+		{Name: "tasks", Description: "Task management"},
+		{Name: "trash", Description: "Deleted tasks awaiting restore or purge"},
+		{Name: "comments", Description: "Discussion threads on tasks"},
+		{Name: "attachments", Description: "Files attached to tasks"},
+		{Name: "tags", Description: "Tag catalogue used to label and filter tasks"},
+		{Name: "projects", Description: "Projects grouping the tasks of a workspace"},
+		{Name: "webhooks", Description: "Signed HTTP callbacks for task and user events"},
+		{Name: "calendar", Description: "iCalendar feeds of task due dates"},
+	}
+
+	errorSchema := doc.Register("ErrorResponse", errorEnvelope{})
+	messageSchema := doc.Register("MessageResponse", messageEnvelope{})
+	taskSchema := doc.Register("Task", http.TaskResponse{})
+	userSchema := doc.Register("User", http.UserResponse{})
+	loginSchema := doc.Register("LoginResult", http.LoginResponse{})
+	historySchema := doc.Register("HistoryEntry", http.HistoryEntryResponse{})
+	commentSchema := doc.Register("Comment", http.CommentResponse{})
+	commentRequestSchema := doc.Register("CommentRequest", http.CommentRequest{})
+	attachmentSchema := doc.Register("Attachment", http.AttachmentResponse{})
+	tagSchema := doc.Register("Tag", http.TagResponse{})
+	tagRequestSchema := doc.Register("TagRequest", http.TagRequest{})
+	projectSchema := doc.Register("Project", http.ProjectResponse{})
+	projectRequestSchema := doc.Register("ProjectRequest", http.ProjectRequest{})
+	projectStatsSchema := doc.Register("ProjectStats", http.ProjectStatsResponse{})
+	taskStatsSchema := doc.Register("TaskStats", http.TaskStatsResponse{})
+	taskEventSchema := doc.Register("TaskEvent", http.TaskEventResponse{})
+	taskRecordSchema := doc.Register("TaskRecord", http.TaskRecord{})
+	importReportSchema := doc.Register("ImportReport", http.ImportReportResponse{})
+	webhookSchema := doc.Register("Webhook", http.WebhookResponse{})
+	webhookRequestSchema := doc.Register("WebhookRequest", http.WebhookRequest{})
+	deliverySchema := doc.Register("WebhookDelivery", http.WebhookDeliveryResponse{})
+	createTaskSchema := doc.Register("CreateTaskRequest", http.CreateTaskRequest{})
+	updateTaskSchema := doc.Register("UpdateTaskRequest", http.UpdateTaskRequest{})
+	checklistItemSchema := doc.Register("ChecklistItemRequest", http.ChecklistItemRequest{})
+	checklistOrderSchema := doc.Register("ChecklistOrderRequest", http.ChecklistOrderRequest{})
+	dependencySchema := doc.Register("DependencyRequest", http.DependencyRequest{})
+	recurrenceSchema := doc.Register("RecurrenceRequest", http.RecurrenceRequest{})
+	doc.Register("DependencyNode", http.DependencyNodeResponse{})
+	graphSchema := doc.Register("DependencyGraph", http.DependencyGraphResponse{})
+	batchSchema := doc.Register("BatchRequest", http.BatchRequest{})
+	batchResultSchema := doc.Register("BatchResult", http.BatchResultResponse{})
+	registerSchema := doc.Register("RegisterRequest", http.RegisterRequest{})
+	loginRequestSchema := doc.Register("LoginRequest", http.LoginRequest{})
+	promoteSchema := doc.Register("PromoteRequest", http.PromoteRequest{})
+	calendarTokenSchema := doc.Register("CalendarToken", http.CalendarTokenResponse{})
+	streamTokenSchema := doc.Register("StreamToken", http.StreamTokenResponse{})
+	workspaceSchema := doc.Register("Workspace", http.WorkspaceResponse{})
+	workspaceRequestSchema := doc.Register("WorkspaceRequest", http.WorkspaceRequest{})
+	memberSchema := doc.Register("Member", http.MemberResponse{})
+	memberRequestSchema := doc.Register("MemberRequest", http.MemberRequest{})
+	memberRoleSchema := doc.Register("MemberRoleRequest", http.MemberRoleRequest{})
+
+	errorResponse := func(description string) openapi.Response {
+		return openapi.JSONResponse(errorSchema, description)
+	}
+	operation := func(id, summary, tag string, level access) *openapi.Operation {
+		op := &openapi.Operation{
+			OperationID: id,
+			Summary:     summary,
+			Tags:        []string{tag},
+			Responses: map[string]openapi.Response{
+				"500": errorResponse("Internal server error"),
+			},
+		}
+		if level != public {
+			op.Security = []map[string][]string{{"bearerAuth": {}}}
+			op.Responses["401"] = errorResponse("Missing or invalid token")
+			op.Responses["504"] = errorResponse("Request timed out")
+		}
+		if level == adminOnly {
+			op.Responses["403"] = errorResponse("Admin access required")
+		}
+		return op
+	}
+
+	op := operation("getAPIInfo", "API information", "meta", public)
+	op.Responses["200"] = openapi.JSONResponse(doc.SchemaOf(apiInfo{}), "API name and version")
+	doc.Add("GET", "/", op)
+
+	op = operation("getOpenAPI", "This OpenAPI document", "meta", public)
+	op.Responses["200"] = openapi.JSONResponse(&openapi.Schema{Type: "object"}, "OpenAPI 3 document")
+	doc.Add("GET", "/openapi.json", op)
+
+	op = operation("register", "Register a user", "auth", public)
+	op.Description = "The first user registered becomes an admin."
+	op.RequestBody = openapi.JSONBody(registerSchema, "New account credentials")
+	op.Responses["201"] = openapi.JSONResponse(envelope(userSchema, true), "User registered")
+	op.Responses["400"] = errorResponse("Invalid request body or weak password")
+	op.Responses["409"] = errorResponse("Username already exists")
+	doc.Add("POST", "/auth/register", op)
+
+	op = operation("login", "Log in and obtain a JWT", "auth", public)
+	op.RequestBody = openapi.JSONBody(loginRequestSchema, "Account credentials")
+	op.Responses["200"] = openapi.JSONResponse(envelope(loginSchema, false), "Token issued")
+	op.Responses["400"] = errorResponse("Invalid request body")
+	op.Responses["401"] = errorResponse("Invalid credentials")
+	doc.Add("POST", "/auth/login", op)
+
+	op = operation("promoteUser", "Promote a user to admin", "auth", adminOnly)
+	op.RequestBody = openapi.JSONBody(promoteSchema, "User to promote")
+	op.Responses["200"] = openapi.JSONResponse(messageSchema, "User promoted")
+	op.Responses["400"] = errorResponse("Invalid request body")
+	op.Responses["404"] = errorResponse("User not found")
+	doc.Add("POST", "/promote", op)
+
+	op = operation("issueStreamToken", "Obtain a token for the task streams", "auth", authenticated)
+	op.Description = "Returns a token that expires after auth.stream_token_ttl and also sets it as the stream_token cookie. " +
+		"The task streams accept it in the stream_token query parameter or the cookie; no other endpoint accepts it."
+	op.Responses["200"] = openapi.JSONResponse(envelope(streamTokenSchema, false), "Stream token issued")
+	doc.Add("POST", "/auth/stream-token", op)
+
+	op = operation("listWorkspaces", "List your workspaces", "workspaces", authenticated)
+	op.Description = "Workspaces are ordered by name, each with your role in it."
+	op.Responses["200"] = openapi.JSONResponse(listEnvelope(workspaceSchema), "Workspaces")
+	doc.Add("GET", "/workspaces", op)
+
+	op = operation("createWorkspace", "Create a workspace", "workspaces", authenticated)
+	op.Description = "You become the workspace's first admin."
+	op.RequestBody = openapi.JSONBody(workspaceRequestSchema, "Workspace to create")
+	op.Responses["201"] = openapi.JSONResponse(envelope(workspaceSchema, true), "Workspace created")
+	op.Responses["400"] = errorResponse("Invalid request body or name")
+	doc.Add("POST", "/workspaces", op)
+
+	op = operation("getWorkspace", "Get a workspace", "workspaces", authenticated)
+	op.Responses["200"] = openapi.JSONResponse(envelope(workspaceSchema, false), "Workspace with your role in it")
+	doc.Add("GET", "/workspaces/:wid", op)
+
+	op = operation("listMembers", "List a workspace's members", "workspaces", authenticated)
+	op.Responses["200"] = openapi.JSONResponse(listEnvelope(memberSchema), "Members, ordered by username")
+	doc.Add("GET", "/workspaces/:wid/members", op)
+
+	op = operation("addMember", "Add a user to a workspace", "workspaces", adminOnly)
+	op.Description = "role defaults to member."
+	op.RequestBody = openapi.JSONBody(memberRequestSchema, "User to add")
+	op.Responses["201"] = openapi.JSONResponse(envelope(memberSchema, true), "Member added")
+	op.Responses["400"] = errorResponse("Invalid request body or role")
+	op.Responses["404"] = errorResponse("User not found")
+	op.Responses["409"] = errorResponse("The user is already a member")
+	doc.Add("POST", "/workspaces/:wid/members", op)
+
+	op = operation("updateMember", "Change a member's role", "workspaces", adminOnly)
+	op.RequestBody = openapi.JSONBody(memberRoleSchema, "New role")
+	op.Responses["200"] = openapi.JSONResponse(envelope(memberSchema, true), "Member updated")
+	op.Responses["400"] = errorResponse("Invalid request body or role")
+	op.Responses["404"] = errorResponse("Member not found")
+	op.Responses["409"] = errorResponse("The workspace would be left without an admin")
+	doc.Add("PUT", "/workspaces/:wid/members/:user_id", op)
+
+	op = operation("removeMember", "Remove a member from a workspace", "workspaces", authenticated)
+	op.Description = "Admins can remove any member; members can leave by removing themselves."
+	op.Responses["200"] = openapi.JSONResponse(messageSchema, "Member removed")
+	op.Responses["403"] = errorResponse("Only workspace admins can remove other members")
+	op.Responses["404"] = errorResponse("Member not found")
+	op.Responses["409"] = errorResponse("The workspace would be left without an admin")
+	doc.Add("DELETE", "/workspaces/:wid/members/:user_id", op)
+
+	op = operation("listTasks", "List tasks", "tasks", authenticated)
+	op.Parameters = []openapi.Parameter{
+		{Name: "tags", In: "query", Description: "Comma-separated tag names to filter by", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "tag_match", In: "query", Description: "any (default) matches tasks with at least one of the tags, all requires every tag", Schema: &openapi.Schema{Type: "string", Enum: []string{"any", "all"}}},
+		{Name: "sort", In: "query", Description: "smart ranks open tasks by priority, overdue-ness and due-date proximity, most pressing first; completed tasks come last", Schema: &openapi.Schema{Type: "string", Enum: []string{"smart"}}},
+	}
+	op.Responses["200"] = openapi.JSONResponse(listEnvelope(taskSchema), "Tasks")
+	op.Responses["400"] = errorResponse("Invalid query parameters")
+	doc.Add("GET", "/workspaces/:wid/tasks", op)
+
+	op = operation("getTaskStats", "Summarise tasks for a dashboard", "tasks", authenticated)
+	op.Description = "Counts the tasks outside the trash by status, the open ones that are overdue or due this week (Monday to Sunday, UTC), " +
+		"and the tasks created and completed on each day of the range. A task completes whenever its status is set to completed, " +
+		"so one reopened and completed again counts twice. average_seconds_to_complete is the mean time from creation over those completions."
+	op.Parameters = []openapi.Parameter{
+		{Name: "project_id", In: "query", Description: "Only count the tasks of this project", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "tags", In: "query", Description: "Comma-separated tag names to filter by", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "tag_match", In: "query", Description: "any (default) matches tasks with at least one of the tags, all requires every tag", Schema: &openapi.Schema{Type: "string", Enum: []string{"any", "all"}}},
+		{Name: "from", In: "query", Description: "First day of the daily counts (default 29 days before to)", Schema: &openapi.Schema{Type: "string", Format: "date"}},
+		{Name: "to", In: "query", Description: "Last day of the daily counts (default today); the range spans at most 366 days", Schema: &openapi.Schema{Type: "string", Format: "date"}},
+	}
+	op.Responses["200"] = openapi.JSONResponse(envelope(taskStatsSchema, false), "Task statistics")
+	op.Responses["400"] = errorResponse("Invalid query parameters or range")
+	doc.Add("GET", "/workspaces/:wid/tasks/stats", op)
+
+	lastEventIDParameter := openapi.Parameter{Name: "last_event_id", In: "query", Description: "Resume after this event ID", Schema: &openapi.Schema{Type: "string"}}
+	streamTokenParameter := openapi.Parameter{Name: "stream_token", In: "query", Description: "Token from POST /auth/stream-token, for clients that cannot send the Authorization header", Schema: &openapi.Schema{Type: "string"}}
+
+	op = operation("streamTasks", "Stream task events (Server-Sent Events)", "tasks", authenticated)
+	op.Description = "Pushes a task.created, task.updated, task.deleted or task.restored event for every change to a task, " +
+		"with the event type as the SSE event name and a TaskEvent as data. Reconnecting clients resume with the Last-Event-ID " +
+		"header or last_event_id; a reset event means events were missed and tasks should be reloaded. " +
+		"Deletions carry the task only for admins."
+	op.Parameters = []openapi.Parameter{
+		{Name: "Last-Event-ID", In: "header", Description: "Resume after this event ID", Schema: &openapi.Schema{Type: "string"}},
+		lastEventIDParameter,
+		streamTokenParameter,
+	}
+	op.Responses["200"] = openapi.Response{
+		Description: "Event stream",
+		Content:     map[string]openapi.MediaType{"text/event-stream": {Schema: taskEventSchema}},
+	}
+	delete(op.Responses, "504")
+	doc.Add("GET", "/workspaces/:wid/tasks/stream", op)
+
+	op = operation("streamTasksWebSocket", "Stream task events (WebSocket)", "tasks", authenticated)
+	op.Description = "Upgrades to a WebSocket that carries the events of GET /workspaces/{wid}/tasks/stream as JSON TaskEvent text messages."
+	op.Parameters = []openapi.Parameter{lastEventIDParameter, streamTokenParameter}
+	op.Responses["101"] = openapi.Response{Description: "Switching to the WebSocket protocol"}
+	op.Responses["400"] = openapi.Response{Description: "Not a WebSocket handshake"}
+	op.Responses["403"] = openapi.Response{Description: "Origin is not this API or in stream.allowed_origins"}
+	delete(op.Responses, "504")
+	doc.Add("GET", "/workspaces/:wid/tasks/stream/ws", op)
+
+	csvTable := &openapi.Schema{Type: "string", Description: "A header row naming the TaskRecord fields, then one row per task; tags are comma-separated"}
+	taskRecords := &openapi.Schema{Type: "array", Items: taskRecordSchema}
+	ndjsonRecords := &openapi.Schema{Type: "string", Description: "One TaskRecord per line"}
+
+	op = operation("exportTasks", "Export tasks as CSV, JSON or NDJSON", "tasks", authenticated)
+	op.Description = "Streams the tasks matching the filters in storage order, as they are read. " +
+		"If the export fails after it has started, the connection is cut rather than the response ended."
+	op.Parameters = []openapi.Parameter{
+		{Name: "format", In: "query", Description: "File format (default json)", Schema: &openapi.Schema{Type: "string", Enum: []string{"csv", "json", "ndjson"}}},
+		{Name: "tags", In: "query", Description: "Comma-separated tag names to filter by", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "tag_match", In: "query", Description: "any (default) matches tasks with at least one of the tags, all requires every tag", Schema: &openapi.Schema{Type: "string", Enum: []string{"any", "all"}}},
+	}
+	op.Responses["200"] = openapi.Response{
+		Description: "Exported tasks",
+		Content: map[string]openapi.MediaType{
+			"text/csv":             {Schema: csvTable},
+			"application/json":     {Schema: taskRecords},
+			"application/x-ndjson": {Schema: ndjsonRecords},
+		},
+	}
+	op.Responses["400"] = errorResponse("Invalid query parameters")
+	delete(op.Responses, "504")
+	doc.Add("GET", "/workspaces/:wid/tasks/export", op)
+
+	importReportEnvelope := envelope(importReportSchema, true)
+	op = operation("importTasks", "Import tasks from CSV, JSON or NDJSON", "tasks", adminOnly)
+	op.Description = "Reads the body row by row and creates a task per row under the rules of POST /workspaces/{wid}/tasks/batch. " +
+		"A row whose external_id is taken fails, unless upsert is set, in which case it updates that task like PUT /workspaces/{wid}/tasks/{id}. " +
+		"Rows are written one by one and failed rows are listed in the report. " +
+		"The body may be up to limits.max_import_bytes."
+	op.Parameters = []openapi.Parameter{
+		{Name: "format", In: "query", Description: "File format; defaults to the one given by Content-Type", Schema: &openapi.Schema{Type: "string", Enum: []string{"csv", "json", "ndjson"}}},
+		{Name: "dry_run", In: "query", Description: "Check every row without writing anything", Schema: &openapi.Schema{Type: "boolean"}},
+		{Name: "upsert", In: "query", Description: "Update the task with a row's external_id instead of failing the row", Schema: &openapi.Schema{Type: "boolean"}},
+	}
+	op.RequestBody = &openapi.RequestBody{
+		Description: "Tasks to import, in the format of GET /workspaces/{wid}/tasks/export",
+		Required:    true,
+		Content: map[string]openapi.MediaType{
+			"text/csv":             {Schema: csvTable},
+			"application/json":     {Schema: taskRecords},
+			"application/x-ndjson": {Schema: ndjsonRecords},
+		},
+	}
+	op.Responses["200"] = openapi.JSONResponse(importReportEnvelope, "Import report")
+	op.Responses["400"] = errorResponse("Invalid query parameters or unreadable file; data holds the report of the rows before")
+	op.Responses["413"] = errorResponse("Body larger than limits.max_import_bytes")
+	op.Responses["415"] = errorResponse("Unknown import format")
+	delete(op.Responses, "504")
+	doc.Add("POST", "/workspaces/:wid/tasks/import", op)
+
+	op = operation("getTask", "Get a task", "tasks", authenticated)
+	op.Responses["200"] = openapi.JSONResponse(envelope(taskSchema, false), "Task")
+	op.Responses["400"] = errorResponse("Invalid task ID format")
+	op.Responses["404"] = errorResponse("Task not found")
+	doc.Add("GET", "/workspaces/:wid/tasks/:id", op)
+
+	op = operation("getTaskHistory", "List a task's change history", "tasks", authenticated)
+	op.Description = "Entries are newest first. Each records who made the change, when, and the old and new value of every changed field."
+	op.Parameters = []openapi.Parameter{
+		{Name: "page", In: "query", Description: "Page number, starting at 1", Schema: &openapi.Schema{Type: "integer", Minimum: float(1)}},
+		{Name: "page_size", In: "query", Description: "Entries per page (default 20)", Schema: &openapi.Schema{Type: "integer", Minimum: float(1), Maximum: float(100)}},
+	}
+	historyEnvelope := listEnvelope(historySchema)
+	for _, name := range []string{"page", "page_size", "total"} {
+		historyEnvelope.Properties[name] = &openapi.Schema{Type: "integer"}
+		historyEnvelope.Required = append(historyEnvelope.Required, name)
+	}
+	op.Responses["200"] = openapi.JSONResponse(historyEnvelope, "History page")
+	op.Responses["400"] = errorResponse("Invalid task ID format or pagination parameters")
+	op.Responses["404"] = errorResponse("Task not found")
+	doc.Add("GET", "/workspaces/:wid/tasks/:id/history", op)
+
+	op = operation("listSubtasks", "List a task's direct subtasks", "tasks", authenticated)
+	op.Responses["200"] = openapi.JSONResponse(listEnvelope(taskSchema), "Subtasks")
+	op.Responses["400"] = errorResponse("Invalid task ID format")
+	op.Responses["404"] = errorResponse("Task not found")
+	doc.Add("GET", "/workspaces/:wid/tasks/:id/subtasks", op)
+
+	op = operation("getDependencyGraph", "Get a task's dependency tree", "tasks", authenticated)
+	op.Description = "upstream holds the tasks blocking this one, each with its own blockers as children; downstream holds the tasks it blocks. Both are followed at most 10 levels deep."
+	op.Responses["200"] = openapi.JSONResponse(envelope(graphSchema, false), "Dependency trees")
+	op.Responses["400"] = errorResponse("Invalid task ID format")
+	op.Responses["404"] = errorResponse("Task not found")
+	doc.Add("GET", "/workspaces/:wid/tasks/:id/graph", op)
+
+	op = operation("listComments", "List a task's comments", "comments", authenticated)
+	op.Responses["200"] = openapi.JSONResponse(listEnvelope(commentSchema), "Comments, oldest first")
+	op.Responses["400"] = errorResponse("Invalid task ID format")
+	op.Responses["404"] = errorResponse("Task not found")
+	doc.Add("GET", "/workspaces/:wid/tasks/:id/comments", op)
+
+	op = operation("addComment", "Comment on a task", "comments", authenticated)
+	op.RequestBody = openapi.JSONBody(commentRequestSchema, "Comment text, at most limits.max_comment_length characters")
+	op.Responses["201"] = openapi.JSONResponse(envelope(commentSchema, true), "Comment added")
+	op.Responses["400"] = errorResponse("Invalid request body or task ID format")
+	op.Responses["404"] = errorResponse("Task not found")
+	doc.Add("POST", "/workspaces/:wid/tasks/:id/comments", op)
+
+	op = operation("editComment", "Edit your own comment", "comments", authenticated)
+	op.RequestBody = openapi.JSONBody(commentRequestSchema, "New comment text")
+	op.Responses["200"] = openapi.JSONResponse(envelope(commentSchema, true), "Comment updated")
+	op.Responses["400"] = errorResponse("Invalid request body or ID format")
+	op.Responses["403"] = errorResponse("Not the author of the comment")
+	op.Responses["404"] = errorResponse("Task or comment not found")
+	doc.Add("PUT", "/workspaces/:wid/tasks/:id/comments/:comment_id", op)
+
+	op = operation("deleteComment", "Delete a comment", "comments", authenticated)
+	op.Description = "Authors can delete their own comments; admins can delete any comment."
+	op.Responses["200"] = openapi.JSONResponse(messageSchema, "Comment deleted")
+	op.Responses["400"] = errorResponse("Invalid ID format")
+	op.Responses["403"] = errorResponse("Not the author of the comment")
+	op.Responses["404"] = errorResponse("Task or comment not found")
+	doc.Add("DELETE", "/workspaces/:wid/tasks/:id/comments/:comment_id", op)
+
+	op = operation("listAttachments", "List a task's attachments", "attachments", authenticated)
+	op.Responses["200"] = openapi.JSONResponse(listEnvelope(attachmentSchema), "Attachments, oldest first")
+	op.Responses["400"] = errorResponse("Invalid task ID format")
+	op.Responses["404"] = errorResponse("Task not found")
+	doc.Add("GET", "/workspaces/:wid/tasks/:id/attachments", op)
+
+	op = operation("uploadAttachment", "Attach a file to a task", "attachments", authenticated)
+	op.Description = "The file is streamed to storage as it arrives. It may be up to attachments.max_bytes and must have one of " +
+		"attachments.allowed_types; the type is taken from the part's Content-Type, or from the file name's extension when " +
+		"that is missing or application/octet-stream."
+	op.RequestBody = &openapi.RequestBody{
+		Description: "The file in a part named file",
+		Required:    true,
+		Content: map[string]openapi.MediaType{
+			"multipart/form-data": {Schema: &openapi.Schema{
+				Type:       "object",
+				Properties: map[string]*openapi.Schema{"file": {Type: "string", Format: "binary"}},
+				Required:   []string{"file"},
+			}},
+		},
+	}
+	op.Responses["201"] = openapi.JSONResponse(envelope(attachmentSchema, true), "Attachment uploaded")
+	op.Responses["400"] = errorResponse("No file part, an empty file name or an invalid task ID format")
+	op.Responses["404"] = errorResponse("Task not found")
+	op.Responses["413"] = errorResponse("File larger than attachments.max_bytes")
+	op.Responses["415"] = errorResponse("Not multipart/form-data, or a file type that is not allowed")
+	delete(op.Responses, "504")
+	doc.Add("POST", "/workspaces/:wid/tasks/:id/attachments", op)
+
+	op = operation("downloadAttachment", "Download an attachment", "attachments", authenticated)
+	op.Description = "Sent as a download with the attachment's content type. " +
+		"If the download fails after it has started, the connection is cut rather than the response ended."
+	op.Responses["200"] = openapi.Response{
+		Description: "File content",
+		Content:     map[string]openapi.MediaType{"application/octet-stream": {Schema: &openapi.Schema{Type: "string", Format: "binary"}}},
+	}
+	op.Responses["400"] = errorResponse("Invalid ID format")
+	op.Responses["404"] = errorResponse("Task or attachment not found")
+	delete(op.Responses, "504")
+	doc.Add("GET", "/workspaces/:wid/tasks/:id/attachments/:attachment_id", op)
+
+	op = operation("deleteAttachment", "Delete an attachment", "attachments", authenticated)
+	op.Description = "Uploaders can delete their own attachments; admins can delete any attachment."
+	op.Responses["200"] = openapi.JSONResponse(messageSchema, "Attachment deleted")
+	op.Responses["400"] = errorResponse("Invalid ID format")
+	op.Responses["403"] = errorResponse("Not the uploader of the attachment")
+	op.Responses["404"] = errorResponse("Task or attachment not found")
+	doc.Add("DELETE", "/workspaces/:wid/tasks/:id/attachments/:attachment_id", op)
+
+	op = operation("listTags", "List the tag catalogue", "tags", authenticated)
+	op.Description = "Tags are ordered by name. usage_count is the number of tasks outside the trash carrying the tag."
+	op.Responses["200"] = openapi.JSONResponse(listEnvelope(tagSchema), "Tags")
+	doc.Add("GET", "/workspaces/:wid/tags", op)
+
+	op = operation("createTag", "Add a tag to the catalogue", "tags", adminOnly)
+	op.Description = "Names are stored in lower case, at most 32 characters and without commas. color is an optional #rrggbb value."
+	op.RequestBody = openapi.JSONBody(tagRequestSchema, "Tag to create")
+	op.Responses["201"] = openapi.JSONResponse(envelope(tagSchema, true), "Tag created")
+	op.Responses["400"] = errorResponse("Invalid request body, name or color")
+	op.Responses["409"] = errorResponse("Tag already exists")
+	doc.Add("POST", "/workspaces/:wid/tags", op)
+
+	op = operation("updateTag", "Edit or rename a tag", "tags", adminOnly)
+	op.Description = "Empty fields keep their current value. Renaming a tag renames it on every task that carries it."
+	op.RequestBody = openapi.JSONBody(tagRequestSchema, "Fields to change")
+	op.Responses["200"] = openapi.JSONResponse(envelope(tagSchema, true), "Tag updated")
+	op.Responses["400"] = errorResponse("Invalid request body, name or color")
+	op.Responses["404"] = errorResponse("Tag not found")
+	op.Responses["409"] = errorResponse("A tag with the new name already exists, or a task of an archived project carries the tag")
+	doc.Add("PUT", "/workspaces/:wid/tags/:name", op)
+
+	op = operation("deleteTag", "Delete a tag", "tags", adminOnly)
+	op.Description = "The tag is also removed from every task that carries it."
+	op.Responses["200"] = openapi.JSONResponse(messageSchema, "Tag deleted")
+	op.Responses["404"] = errorResponse("Tag not found")
+	op.Responses["409"] = errorResponse("A task of an archived project carries the tag")
+	doc.Add("DELETE", "/workspaces/:wid/tags/:name", op)
+
+	op = operation("listProjects", "List projects", "projects", authenticated)
+	op.Description = "Projects are ordered by name."
+	op.Parameters = []openapi.Parameter{
+		{Name: "archived", In: "query", Description: "Only list archived (true) or active (false) projects", Schema: &openapi.Schema{Type: "boolean"}},
+	}
+	op.Responses["200"] = openapi.JSONResponse(listEnvelope(projectSchema), "Projects")
+	op.Responses["400"] = errorResponse("Invalid query parameters")
+	doc.Add("GET", "/workspaces/:wid/projects", op)
+
+	op = operation("createProject", "Create a project", "projects", adminOnly)
+	op.Description = "name is required, at most 100 characters. The caller becomes the owner. " +
+		"default_status (default pending) is the status tasks created in the project start with when they do not give one."
+	op.RequestBody = openapi.JSONBody(projectRequestSchema, "Project to create")
+	op.Responses["201"] = openapi.JSONResponse(envelope(projectSchema, true), "Project created")
+	op.Responses["400"] = errorResponse("Invalid request body, name or default status")
+	doc.Add("POST", "/workspaces/:wid/projects", op)
+
+	op = operation("getProject", "Get a project", "projects", authenticated)
+	op.Responses["200"] = openapi.JSONResponse(envelope(projectSchema, false), "Project")
+	op.Responses["400"] = errorResponse("Invalid project ID format")
+	op.Responses["404"] = errorResponse("Project not found")
+	doc.Add("GET", "/workspaces/:wid/projects/:project_id", op)
+
+	op = operation("updateProject", "Edit, archive or unarchive a project", "projects", adminOnly)
+	op.Description = "Omitted fields keep their current value. While a project is archived its tasks are read-only: " +
+		"they cannot be created in or moved into it, changed or deleted. Comments and attachments are still allowed."
+	op.RequestBody = openapi.JSONBody(projectRequestSchema, "Fields to change")
+	op.Responses["200"] = openapi.JSONResponse(envelope(projectSchema, true), "Project updated")
+	op.Responses["400"] = errorResponse("Invalid project ID, request body, name or default status")
+	op.Responses["404"] = errorResponse("Project not found")
+	doc.Add("PUT", "/workspaces/:wid/projects/:project_id", op)
+
+	op = operation("deleteProject", "Delete a project", "projects", adminOnly)
+	op.Description = "The project's tasks, including those in the trash, are kept and leave the project."
+	op.Responses["200"] = openapi.JSONResponse(messageSchema, "Project deleted")
+	op.Responses["400"] = errorResponse("Invalid project ID format")
+	op.Responses["404"] = errorResponse("Project not found")
+	doc.Add("DELETE", "/workspaces/:wid/projects/:project_id", op)
+
+	op = operation("listProjectTasks", "List a project's tasks", "projects", authenticated)
+	op.Description = "Filters and orders the tasks of the project like GET /workspaces/{wid}/tasks."
+	op.Parameters = []openapi.Parameter{
+		{Name: "tags", In: "query", Description: "Comma-separated tag names to filter by", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "tag_match", In: "query", Description: "any (default) matches tasks with at least one of the tags, all requires every tag", Schema: &openapi.Schema{Type: "string", Enum: []string{"any", "all"}}},
+		{Name: "sort", In: "query", Description: "smart ranks open tasks by priority, overdue-ness and due-date proximity, most pressing first; completed tasks come last", Schema: &openapi.Schema{Type: "string", Enum: []string{"smart"}}},
+	}
+	op.Responses["200"] = openapi.JSONResponse(listEnvelope(taskSchema), "Tasks")
+	op.Responses["400"] = errorResponse("Invalid project ID format or query parameters")
+	op.Responses["404"] = errorResponse("Project not found")
+	doc.Add("GET", "/workspaces/:wid/projects/:project_id/tasks", op)
+
+	op = operation("getProjectStats", "Count a project's tasks", "projects", authenticated)
+	op.Description = "Counts the project's tasks outside the trash by status and priority. overdue counts the open tasks whose due date has passed."
+	op.Responses["200"] = openapi.JSONResponse(envelope(projectStatsSchema, false), "Task counts")
+	op.Responses["400"] = errorResponse("Invalid project ID format")
+	op.Responses["404"] = errorResponse("Project not found")
+	doc.Add("GET", "/workspaces/:wid/projects/:project_id/stats", op)
+
+	op = operation("getCalendarFeed", "Get a calendar feed of the tasks' due dates", "calendar", public)
+	op.Description = "Renders every task with a due date as an RFC 5545 VEVENT, or a VTODO with type=todo. " +
+		"feed is the token from POST /calendar/token followed by .ics; the token is the only credential."
+	op.Parameters = []openapi.Parameter{
+		{Name: "feed", In: "path", Required: true, Description: "Feed token followed by .ics", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "type", In: "query", Description: "Component per task (default event)", Schema: &openapi.Schema{Type: "string", Enum: []string{"event", "todo"}}},
+	}
+	op.Responses["200"] = openapi.Response{
+		Description: "iCalendar data",
+		Content: map[string]openapi.MediaType{
+			"text/calendar": {Schema: &openapi.Schema{Type: "string"}},
+		},
+	}
+	op.Responses["400"] = errorResponse("Invalid query parameters")
+	op.Responses["404"] = errorResponse("Unknown or revoked feed token")
+	op.Responses["504"] = errorResponse("Request timed out")
+	doc.Add("GET", "/calendar/:feed", op)
+
+	op = operation("rotateCalendarToken", "Issue a new calendar feed token", "calendar", authenticated)
+	op.Description = "The previous token of the user stops working. The token is only returned here."
+	op.Responses["200"] = openapi.JSONResponse(envelope(calendarTokenSchema, true), "New token and the feed's path")
+	doc.Add("POST", "/calendar/token", op)
+
+	op = operation("disableCalendarFeed", "Turn the user's calendar feed off", "calendar", authenticated)
+	op.Responses["200"] = openapi.JSONResponse(messageSchema, "Feed turned off")
+	doc.Add("DELETE", "/calendar/token", op)
+
+	op = operation("listWebhooks", "List webhook subscriptions", "webhooks", adminOnly)
+	op.Responses["200"] = openapi.JSONResponse(listEnvelope(webhookSchema), "Webhooks")
+	doc.Add("GET", "/webhooks", op)
+
+	op = operation("createWebhook", "Subscribe a URL to events", "webhooks", adminOnly)
+	op.Description = "url and events are required. events lists event types such as task.created, or * for all of them. " +
+		"Without a secret one is generated; the secret is only returned in this response. Every delivery is signed with it " +
+		"in the X-Webhook-Signature header as sha256=<hex HMAC-SHA256 of the body>."
+	op.RequestBody = openapi.JSONBody(webhookRequestSchema, "Webhook to create")
+	op.Responses["201"] = openapi.JSONResponse(envelope(webhookSchema, true), "Webhook created")
+	op.Responses["400"] = errorResponse("Invalid request body, URL, event or secret")
+	doc.Add("POST", "/webhooks", op)
+
+	op = operation("getWebhook", "Get a webhook subscription", "webhooks", adminOnly)
+	op.Responses["200"] = openapi.JSONResponse(envelope(webhookSchema, false), "Webhook")
+	op.Responses["400"] = errorResponse("Invalid webhook ID format")
+	op.Responses["404"] = errorResponse("Webhook not found")
+	doc.Add("GET", "/webhooks/:id", op)
+
+	op = operation("updateWebhook", "Edit a webhook subscription", "webhooks", adminOnly)
+	op.Description = "Omitted fields keep their current value. Set active to false to pause a webhook; its queued deliveries then become dead."
+	op.RequestBody = openapi.JSONBody(webhookRequestSchema, "Fields to change")
+	op.Responses["200"] = openapi.JSONResponse(envelope(webhookSchema, true), "Webhook updated")
+	op.Responses["400"] = errorResponse("Invalid webhook ID, request body, URL, event or secret")
+	op.Responses["404"] = errorResponse("Webhook not found")
+	doc.Add("PUT", "/webhooks/:id", op)
+
+	op = operation("deleteWebhook", "Delete a webhook subscription", "webhooks", adminOnly)
+	op.Description = "The webhook's delivery log is deleted with it."
+	op.Responses["200"] = openapi.JSONResponse(messageSchema, "Webhook deleted")
+	op.Responses["400"] = errorResponse("Invalid webhook ID format")
+	op.Responses["404"] = errorResponse("Webhook not found")
+	doc.Add("DELETE", "/webhooks/:id", op)
+
+	op = operation("listWebhookDeliveries", "List a webhook's deliveries", "webhooks", adminOnly)
+	op.Description = "Deliveries are newest first, each with its payload, attempt count and the outcome of the last attempt."
+	op.Parameters = []openapi.Parameter{
+		{Name: "status", In: "query", Description: "Only list deliveries in this state", Schema: &openapi.Schema{Type: "string", Enum: []string{"pending", "succeeded", "dead"}}},
+		{Name: "page", In: "query", Description: "Page number, starting at 1", Schema: &openapi.Schema{Type: "integer", Minimum: float(1)}},
+		{Name: "page_size", In: "query", Description: "Deliveries per page (default 20)", Schema: &openapi.Schema{Type: "integer", Minimum: float(1), Maximum: float(100)}},
+	}
+	deliveryEnvelope := listEnvelope(deliverySchema)
+	for _, name := range []string{"page", "page_size", "total"} {
+		deliveryEnvelope.Properties[name] = &openapi.Schema{Type: "integer"}
+		deliveryEnvelope.Required = append(deliveryEnvelope.Required, name)
+	}
+	op.Responses["200"] = openapi.JSONResponse(deliveryEnvelope, "Delivery page")
+	op.Responses["400"] = errorResponse("Invalid webhook ID format or query parameters")
+	op.Responses["404"] = errorResponse("Webhook not found")
+	doc.Add("GET", "/webhooks/:id/deliveries", op)
+
+	op = operation("retryWebhookDelivery", "Retry a dead delivery", "webhooks", adminOnly)
+	op.Description = "Queues the delivery again with a fresh set of attempts."
+	op.Responses["200"] = openapi.JSONResponse(envelope(deliverySchema, true), "Delivery queued")
+	op.Responses["400"] = errorResponse("Invalid ID format or the delivery is not dead")
+	op.Responses["404"] = errorResponse("Webhook or delivery not found")
+	op.Responses["409"] = errorResponse("The delivery changed state while it was being retried")
+	doc.Add("POST", "/webhooks/:id/deliveries/:delivery_id/retry", op)
+
+	op = operation("getDebugVars", "Runtime metrics", "meta", adminOnly)
+	op.Description = "The expvar variables, among them task_cache with the hits and misses of the task cache."
+	op.Responses["200"] = openapi.JSONResponse(&openapi.Schema{Type: "object"}, "Variables by name")
+	doc.Add("GET", "/debug/vars", op)
+
+	op = operation("createTask", "Create a task", "tasks", adminOnly)
+	op.RequestBody = openapi.JSONBody(createTaskSchema, "Task to create")
+	op.Responses["201"] = openapi.JSONResponse(envelope(taskSchema, true), "Task created")
+	op.Responses["400"] = errorResponse("Invalid request body, unknown tag or unknown project")
+	op.Responses["409"] = errorResponse("external_id is already used by another task, or the project is archived")
+	doc.Add("POST", "/workspaces/:wid/tasks", op)
+
+	op = operation("batchTasks", "Apply create, update and delete operations in bulk", "tasks", adminOnly)
+	op.Description = "Returns one result per operation. With atomic set, the batch runs in a MongoDB transaction and either every operation is applied or none is."
+	op.RequestBody = openapi.JSONBody(batchSchema, "Operations to apply, at most limits.max_batch_size")
+	batchEnvelope := envelope(&openapi.Schema{Type: "array", Items: batchResultSchema}, true)
+	batchEnvelope.Properties["succeeded"] = &openapi.Schema{Type: "integer"}
+	batchEnvelope.Properties["failed"] = &openapi.Schema{Type: "integer"}
+	op.Responses["200"] = openapi.JSONResponse(batchEnvelope, "Per-operation results")
+	op.Responses["400"] = errorResponse("Invalid request body or batch too large")
+	op.Responses["422"] = openapi.JSONResponse(&openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"status":  {Type: "string", Enum: []string{"error"}},
+			"message": {Type: "string"},
+			"data":    {Type: "array", Items: batchResultSchema},
+		},
+		Required: []string{"status", "message", "data"},
+	}, "Atomic batch rolled back; results show which operations failed")
+	doc.Add("POST", "/workspaces/:wid/tasks/batch", op)
+
+	op = operation("updateTask", "Update a task", "tasks", adminOnly)
+	op.Description = "Only the fields that are present and non-empty are changed. An empty tags array removes every tag."
+	op.RequestBody = openapi.JSONBody(updateTaskSchema, "Fields to change")
+	op.Responses["200"] = openapi.JSONResponse(envelope(taskSchema, true), "Task updated")
+	op.Responses["400"] = errorResponse("Invalid request body or task ID format")
+	op.Responses["404"] = errorResponse("Task not found")
+	op.Responses["409"] = errorResponse("Task kept changing concurrently, is blocked by unfinished tasks, has open subtasks or checklist items and tasks.completion_policy is block, or its project or the target project is archived")
+	doc.Add("PUT", "/workspaces/:wid/tasks/:id", op)
+
+	op = operation("deleteTask", "Move a task to the trash", "tasks", adminOnly)
+	op.Description = "The task is hidden from every other endpoint until it is restored, and is purged automatically after the configured retention period."
+	op.Responses["200"] = openapi.JSONResponse(messageSchema, "Task moved to trash")
+	op.Responses["400"] = errorResponse("Invalid task ID format")
+	op.Responses["404"] = errorResponse("Task not found")
+	op.Responses["409"] = errorResponse("The task's project is archived")
+	doc.Add("DELETE", "/workspaces/:wid/tasks/:id", op)
+
+	op = operation("addChecklistItem", "Add an item to a task's checklist", "tasks", adminOnly)
+	op.RequestBody = openapi.JSONBody(checklistItemSchema, "Item text, at most limits.max_title_length characters")
+	op.Responses["201"] = openapi.JSONResponse(envelope(taskSchema, true), "Item added; returns the task")
+	op.Responses["400"] = errorResponse("Invalid request body, task ID format or checklist full")
+	op.Responses["404"] = errorResponse("Task not found")
+	op.Responses["409"] = errorResponse("Task kept changing concurrently, or its project is archived")
+	doc.Add("POST", "/workspaces/:wid/tasks/:id/checklist", op)
+
+	op = operation("reorderChecklist", "Reorder a task's checklist", "tasks", adminOnly)
+	op.RequestBody = openapi.JSONBody(checklistOrderSchema, "Every item ID exactly once, in the new order")
+	op.Responses["200"] = openapi.JSONResponse(envelope(taskSchema, true), "Checklist reordered; returns the task")
+	op.Responses["400"] = errorResponse("Invalid request body, task ID format or item list")
+	op.Responses["404"] = errorResponse("Task not found")
+	op.Responses["409"] = errorResponse("Task kept changing concurrently, or its project is archived")
+	doc.Add("PUT", "/workspaces/:wid/tasks/:id/checklist/order", op)
+
+	op = operation("toggleChecklistItem", "Check or uncheck a checklist item", "tasks", adminOnly)
+	op.Responses["200"] = openapi.JSONResponse(envelope(taskSchema, true), "Item toggled; returns the task")
+	op.Responses["400"] = errorResponse("Invalid task ID format")
+	op.Responses["404"] = errorResponse("Task or checklist item not found")
+	op.Responses["409"] = errorResponse("Task kept changing concurrently, or its project is archived")
+	doc.Add("POST", "/workspaces/:wid/tasks/:id/checklist/:item_id/toggle", op)
+
+	op = operation("removeChecklistItem", "Remove a checklist item", "tasks", adminOnly)
+	op.Responses["200"] = openapi.JSONResponse(envelope(taskSchema, true), "Item removed; returns the task")
+	op.Responses["400"] = errorResponse("Invalid task ID format")
+	op.Responses["404"] = errorResponse("Task or checklist item not found")
+	op.Responses["409"] = errorResponse("Task kept changing concurrently, or its project is archived")
+	doc.Add("DELETE", "/workspaces/:wid/tasks/:id/checklist/:item_id", op)
+
+	op = operation("addDependency", "Mark a task as blocked by another task", "tasks", adminOnly)
+	op.Description = "A blocked task cannot move to in_progress or completed until every blocker is completed. Links that would create a cycle are rejected."
+	op.RequestBody = openapi.JSONBody(dependencySchema, "ID of the blocking task")
+	op.Responses["200"] = openapi.JSONResponse(envelope(taskSchema, true), "Dependency added; returns the blocked task")
+	op.Responses["400"] = errorResponse("Invalid request body, task ID format, unknown blocker or cycle")
+	op.Responses["404"] = errorResponse("Task not found")
+	op.Responses["409"] = errorResponse("Task kept changing concurrently, or its project is archived")
+	doc.Add("POST", "/workspaces/:wid/tasks/:id/dependencies", op)
+
+	op = operation("removeDependency", "Remove a blocker from a task", "tasks", adminOnly)
+	op.Responses["200"] = openapi.JSONResponse(envelope(taskSchema, true), "Dependency removed; returns the task")
+	op.Responses["400"] = errorResponse("Invalid task ID format")
+	op.Responses["404"] = errorResponse("Task or dependency not found")
+	op.Responses["409"] = errorResponse("Task kept changing concurrently, or its project is archived")
+	doc.Add("DELETE", "/workspaces/:wid/tasks/:id/dependencies/:blocker_id", op)
+
+	op = operation("setRecurrence", "Make a task repeat or change how its series repeats", "tasks", adminOnly)
+	op.Description = "Takes an RFC 5545 RRULE with FREQ (DAILY, WEEKLY, MONTHLY or YEARLY), INTERVAL, BYDAY, COUNT and UNTIL. The rule applies to the latest occurrence of the series and counts from its due date. Next occurrences are created in the background once an occurrence is completed or overdue."
+	op.RequestBody = openapi.JSONBody(recurrenceSchema, "Recurrence rule, e.g. FREQ=WEEKLY;BYDAY=MO,TH")
+	op.Responses["200"] = openapi.JSONResponse(envelope(taskSchema, true), "Recurrence set; returns the latest occurrence")
+	op.Responses["400"] = errorResponse("Invalid request body, task ID format or rule, or the task has no due date")
+	op.Responses["404"] = errorResponse("Task not found")
+	op.Responses["409"] = errorResponse("Task kept changing concurrently, or its project is archived")
+	doc.Add("PUT", "/workspaces/:wid/tasks/:id/recurrence", op)
+
+	op = operation("stopRecurrence", "Stop a recurring series", "tasks", adminOnly)
+	op.Description = "No further occurrences are created. Existing occurrences are kept."
+	op.Responses["200"] = openapi.JSONResponse(envelope(taskSchema, true), "Series stopped; returns the latest occurrence")
+	op.Responses["400"] = errorResponse("Invalid task ID format or task is not recurring")
+	op.Responses["404"] = errorResponse("Task not found")
+	op.Responses["409"] = errorResponse("Task kept changing concurrently, or its project is archived")
+	doc.Add("DELETE", "/workspaces/:wid/tasks/:id/recurrence", op)
+
+	op = operation("listTrash", "List tasks in the trash", "trash", adminOnly)
+	op.Responses["200"] = openapi.JSONResponse(listEnvelope(taskSchema), "Trashed tasks")
+	doc.Add("GET", "/workspaces/:wid/tasks/trash", op)
+
+	op = operation("restoreTask", "Restore a task from the trash", "trash", adminOnly)
+	op.Responses["200"] = openapi.JSONResponse(envelope(taskSchema, true), "Task restored")
+	op.Responses["400"] = errorResponse("Invalid task ID format")
+	op.Responses["404"] = errorResponse("Task not found in trash")
+	doc.Add("POST", "/workspaces/:wid/tasks/:id/restore", op)
+
+	op = operation("emptyTrash", "Permanently delete every task in the trash", "trash", adminOnly)
+	countEnvelope := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"status":  {Type: "string", Enum: []string{"success"}},
+			"message": {Type: "string"},
+			"count":   {Type: "integer"},
+		},
+		Required: []string{"status", "message", "count"},
+	}
+	op.Responses["200"] = openapi.JSONResponse(countEnvelope, "Number of tasks purged")
+	doc.Add("DELETE", "/workspaces/:wid/tasks/trash", op)
+
+	op = operation("purgeTask", "Permanently delete a task from the trash", "trash", adminOnly)
+	op.Responses["200"] = openapi.JSONResponse(messageSchema, "Task purged")
+	op.Responses["400"] = errorResponse("Invalid task ID format")
+	op.Responses["404"] = errorResponse("Task not found in trash")
+	doc.Add("DELETE", "/workspaces/:wid/tasks/trash/:id", op)
+
+	addWorkspaceResponses(doc, errorResponse)
+	addIdempotencyKeys(doc, errorResponse)
+	return doc
+}
+
+// addWorkspaceResponses documents how operations inside a workspace answer
+// users who are not its members, and that admin there means workspace admin.
+func addWorkspaceResponses(doc *openapi.Document, errorResponse func(string) openapi.Response) {
+	for path, item := range doc.Paths {
+		if !strings.HasPrefix(path, "/workspaces/{wid}") {
+			continue
+		}
+		for _, op := range item {
+			if existing, ok := op.Responses["404"]; ok {
+				existing.Description += "; or not a member of the workspace"
+				op.Responses["404"] = existing
+			} else {
+				op.Responses["404"] = errorResponse("Workspace not found or not a member of it")
+			}
+			if existing, ok := op.Responses["403"]; ok && existing.Description == "Admin access required" {
+				op.Responses["403"] = errorResponse("Workspace admin access required")
+			}
+		}
+	}
+}
+
+// streamedOperations read their body as it arrives, so they do not take an
+// Idempotency-Key.
+var streamedOperations = map[string]bool{"importTasks": true, "uploadAttachment": true}
+
+// addIdempotencyKeys documents the Idempotency-Key header on every
+// authenticated operation that changes something.
+func addIdempotencyKeys(doc *openapi.Document, errorResponse func(string) openapi.Response) {
+	responses := map[string]string{
+		"409": "A request with the same Idempotency-Key is still running",
+		"422": "The Idempotency-Key was already used for a different request",
+	}
+	for _, item := range doc.Paths {
+		for method, op := range item {
+			if method == "get" || op.Security == nil || streamedOperations[op.OperationID] {
+				continue
+			}
+			op.Parameters = append(op.Parameters, openapi.Parameter{
+				Name:        "Idempotency-Key",
+				In:          "header",
+				Description: "Unique key of this request; a retry with the same key gets the first response again, with an Idempotent-Replayed header",
+				Schema:      &openapi.Schema{Type: "string", MaxLength: integer(255)},
+			})
+			for status, description := range responses {
+				if existing, ok := op.Responses[status]; ok {
+					existing.Description += "; or " + strings.ToLower(description[:1]) + description[1:]
+					op.Responses[status] = existing
+				} else {
+					op.Responses[status] = errorResponse(description)
+				}
+			}
+		}
+	}
+}
+
+// envelope builds the {"status": "success", "data": ...} wrapper shared by
+// the handlers, optionally with the human readable message field.
+func envelope(data *openapi.Schema, withMessage bool) *openapi.Schema {
+	schema := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"status": {Type: "string", Enum: []string{"success"}},
+			"data":   data,
+		},
+		Required: []string{"status", "data"},
+	}
+	if withMessage {
+		schema.Properties["message"] = &openapi.Schema{Type: "string"}
+		schema.Required = append(schema.Required, "message")
+	}
+	return schema
+}
+
+func listEnvelope(item *openapi.Schema) *openapi.Schema {
+	schema := envelope(&openapi.Schema{Type: "array", Items: item}, false)
+	schema.Properties["count"] = &openapi.Schema{Type: "integer"}
+	schema.Required = append(schema.Required, "count")
+	return schema
+}
+
+func float(f float64) *float64 {
+	return &f
+}
+
+func integer(n int) *int {
+	return &n
+}
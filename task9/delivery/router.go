@@ -1,30 +1,38 @@
 package delivery
 
 import (
+	"expvar"
+	"task9/config"
 	"task9/delivery/http"
 	"task9/delivery/middleware"
-	"task9/infrastructure"
-	"task9/repository"
-	"task9/usecase"
+	"task9/delivery/openapi"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter() *gin.Engine {
+// SetupRouter registers every route, served by the use cases in services,
+// which the background jobs share.
+func SetupRouter(cfg *config.Config, services *Services) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.Default()
 
-	taskRepo := repository.NewTaskRepositoryMongo(infrastructure.TaskCollection)
-	taskUseCase := usecase.NewTaskUseCase(taskRepo)
-	taskHandler := http.NewTaskHandler(taskUseCase)
+	webhookHandler := http.NewWebhookHandler(services.Webhooks)
+	taskHandler := http.NewTaskHandler(services.Tasks)
+	streamHandler := http.NewStreamHandler(services.Hub, cfg.Stream.Heartbeat, cfg.Server.WriteTimeout, cfg.Stream.AllowedOrigins)
+	transferHandler := http.NewTransferHandler(services.Tasks, cfg.Server.ReadTimeout, cfg.Server.WriteTimeout)
+	tagHandler := http.NewTagHandler(services.Tags)
+	projectHandler := http.NewProjectHandler(services.Projects, services.Tasks)
+	commentHandler := http.NewCommentHandler(services.Comments)
+	attachmentHandler := http.NewAttachmentHandler(services.Attachments, cfg.Server.ReadTimeout, cfg.Server.WriteTimeout)
+	authHandler := http.NewAuthHandler(services.Auth)
+	workspaceHandler := http.NewWorkspaceHandler(services.Workspaces)
+	calendarHandler := http.NewCalendarHandler(services.Calendar, cfg.Calendar.Name, cfg.Calendar.UIDDomain)
+	workspaceRepo := services.WorkspaceRepo
 
-	userRepo := repository.NewUserRepositoryMongo(infrastructure.UserCollection)
-	passwordHasher := infrastructure.NewBcryptHasher()
-	tokenGenerator := infrastructure.NewJWTGenerator()
-	authUseCase := usecase.NewAuthUseCase(userRepo, passwordHasher, tokenGenerator)
-	authHandler := http.NewAuthHandler(authUseCase)
+	authMiddleware := middleware.NewAuthMiddleware(services.TokenGenerator)
 
-	authMiddleware := middleware.NewAuthMiddleware(tokenGenerator)
+	spec := OpenAPISpec()
+	validate := openapi.ValidateRequests(spec)
 
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -33,28 +41,134 @@ func SetupRouter() *gin.Engine {
 		})
 	})
 
+	r.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(200, spec)
+	})
+
+	deadline := middleware.Deadline(cfg.Server.RequestTimeout)
+	idempotency := middleware.Idempotency(services.IdempotencyRepo, cfg.Idempotency.TTL, cfg.Idempotency.LockTimeout)
+	maxBodySize := middleware.MaxBodySize(cfg.Limits.MaxRequestBodyBytes)
+
 	auth := r.Group("/auth")
+	auth.Use(maxBodySize, deadline, validate)
 	{
 		auth.POST("/register", authHandler.Register)
 		auth.POST("/login", authHandler.Login)
 	}
 
+	// Calendar apps cannot log in, so feeds are found by the secret token in
+	// their URL.
+	calendar := r.Group("/calendar")
+	calendar.Use(deadline)
+	{
+		calendar.GET("/:feed", calendarHandler.GetFeed)
+	}
+
+	// Streams stay open for as long as the client listens, and exports,
+	// imports and attachment content for as long as their data takes, so
+	// they have no deadline. Imports and uploads have body limits of their
+	// own; the upload limit leaves room for the multipart framing around the
+	// file.
+	// Browsers open the task streams without an Authorization header, so
+	// these also take a stream token.
+	live := r.Group("/workspaces/:wid")
+	live.Use(authMiddleware.RequireStreamAuth(), authMiddleware.RequireWorkspace(workspaceRepo))
+	{
+		live.GET("/tasks/stream", streamHandler.StreamTasks)
+		live.GET("/tasks/stream/ws", streamHandler.StreamTasksWebSocket)
+	}
+
+	stream := r.Group("/workspaces/:wid")
+	stream.Use(authMiddleware.RequireAuth(), authMiddleware.RequireWorkspace(workspaceRepo))
+	{
+		stream.GET("/tasks/export", transferHandler.ExportTasks)
+		stream.POST("/tasks/import", authMiddleware.RequireAdmin(), middleware.MaxBodySize(cfg.Limits.MaxImportBytes), transferHandler.ImportTasks)
+		stream.POST("/tasks/:id/attachments", middleware.MaxBodySize(cfg.Attachments.MaxBytes+1<<20), attachmentHandler.UploadAttachment)
+		stream.GET("/tasks/:id/attachments/:attachment_id", attachmentHandler.DownloadAttachment)
+	}
+
+	// Retries of changes are made safe by Idempotency-Key. It reads the whole
+	// body, so it is left off the streamed routes above.
 	protected := r.Group("/")
-	protected.Use(authMiddleware.RequireAuth())
+	protected.Use(maxBodySize, deadline, authMiddleware.RequireAuth(), idempotency)
 	{
-		protected.GET("/tasks", taskHandler.GetAllTasks)
-		protected.GET("/tasks/:id", taskHandler.GetTaskByID)
+		protected.POST("/auth/stream-token", authHandler.IssueStreamToken)
+		protected.GET("/workspaces", workspaceHandler.ListWorkspaces)
+		protected.POST("/workspaces", validate, workspaceHandler.CreateWorkspace)
+		protected.POST("/calendar/token", calendarHandler.RotateFeedToken)
+		protected.DELETE("/calendar/token", calendarHandler.DisableFeed)
+
+		// Tasks, tags, projects and their comments and attachments live in a
+		// workspace. Within it, admin means an admin of the workspace.
+		workspace := protected.Group("/workspaces/:wid")
+		workspace.Use(authMiddleware.RequireWorkspace(workspaceRepo))
+		{
+			workspace.GET("", workspaceHandler.GetWorkspace)
+			workspace.GET("/members", workspaceHandler.ListMembers)
+			workspace.DELETE("/members/:user_id", workspaceHandler.RemoveMember)
+			workspace.GET("/tasks", taskHandler.GetAllTasks)
+			workspace.GET("/tasks/stats", taskHandler.GetTaskStats)
+			workspace.GET("/tasks/:id", taskHandler.GetTaskByID)
+			workspace.GET("/tasks/:id/history", taskHandler.GetTaskHistory)
+			workspace.GET("/tasks/:id/subtasks", taskHandler.GetSubtasks)
+			workspace.GET("/tasks/:id/graph", taskHandler.GetDependencyGraph)
+			workspace.GET("/tasks/:id/comments", commentHandler.ListComments)
+			workspace.POST("/tasks/:id/comments", validate, commentHandler.AddComment)
+			workspace.PUT("/tasks/:id/comments/:comment_id", validate, commentHandler.EditComment)
+			workspace.DELETE("/tasks/:id/comments/:comment_id", commentHandler.DeleteComment)
+			workspace.GET("/tasks/:id/attachments", attachmentHandler.ListAttachments)
+			workspace.DELETE("/tasks/:id/attachments/:attachment_id", attachmentHandler.DeleteAttachment)
+			workspace.GET("/tags", tagHandler.ListTags)
+			workspace.GET("/projects", projectHandler.ListProjects)
+			workspace.GET("/projects/:project_id", projectHandler.GetProject)
+			workspace.GET("/projects/:project_id/tasks", projectHandler.ListProjectTasks)
+			workspace.GET("/projects/:project_id/stats", projectHandler.GetProjectStats)
+
+			workspaceAdmin := workspace.Group("/")
+			workspaceAdmin.Use(authMiddleware.RequireAdmin(), validate)
+			{
+				workspaceAdmin.POST("/members", workspaceHandler.AddMember)
+				workspaceAdmin.PUT("/members/:user_id", workspaceHandler.UpdateMember)
+				workspaceAdmin.POST("/tasks", taskHandler.CreateTask)
+				workspaceAdmin.POST("/tasks/batch", taskHandler.BatchTasks)
+				workspaceAdmin.PUT("/tasks/:id", taskHandler.UpdateTask)
+				workspaceAdmin.DELETE("/tasks/:id", taskHandler.DeleteTask)
+				workspaceAdmin.POST("/tasks/:id/checklist", taskHandler.AddChecklistItem)
+				workspaceAdmin.PUT("/tasks/:id/checklist/order", taskHandler.ReorderChecklist)
+				workspaceAdmin.POST("/tasks/:id/checklist/:item_id/toggle", taskHandler.ToggleChecklistItem)
+				workspaceAdmin.DELETE("/tasks/:id/checklist/:item_id", taskHandler.RemoveChecklistItem)
+				workspaceAdmin.POST("/tasks/:id/dependencies", taskHandler.AddDependency)
+				workspaceAdmin.DELETE("/tasks/:id/dependencies/:blocker_id", taskHandler.RemoveDependency)
+				workspaceAdmin.PUT("/tasks/:id/recurrence", taskHandler.SetRecurrence)
+				workspaceAdmin.DELETE("/tasks/:id/recurrence", taskHandler.StopRecurrence)
+				workspaceAdmin.GET("/tasks/trash", taskHandler.GetTrash)
+				workspaceAdmin.POST("/tasks/:id/restore", taskHandler.RestoreTask)
+				workspaceAdmin.DELETE("/tasks/trash", taskHandler.EmptyTrash)
+				workspaceAdmin.DELETE("/tasks/trash/:id", taskHandler.PurgeTask)
+				workspaceAdmin.POST("/tags", tagHandler.CreateTag)
+				workspaceAdmin.PUT("/tags/:name", tagHandler.UpdateTag)
+				workspaceAdmin.DELETE("/tags/:name", tagHandler.DeleteTag)
+				workspaceAdmin.POST("/projects", projectHandler.CreateProject)
+				workspaceAdmin.PUT("/projects/:project_id", projectHandler.UpdateProject)
+				workspaceAdmin.DELETE("/projects/:project_id", projectHandler.DeleteProject)
+			}
+		}
 
 		admin := protected.Group("/")
-		admin.Use(authMiddleware.RequireAdmin())
+		admin.Use(authMiddleware.RequireAdmin(), validate)
 		{
-			admin.POST("/tasks", taskHandler.CreateTask)
-			admin.PUT("/tasks/:id", taskHandler.UpdateTask)
-			admin.DELETE("/tasks/:id", taskHandler.DeleteTask)
 			admin.POST("/promote", authHandler.PromoteUser)
+			admin.GET("/webhooks", webhookHandler.ListWebhooks)
+			admin.POST("/webhooks", webhookHandler.CreateWebhook)
+			admin.GET("/webhooks/:id", webhookHandler.GetWebhook)
+			admin.PUT("/webhooks/:id", webhookHandler.UpdateWebhook)
+			admin.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+			admin.GET("/webhooks/:id/deliveries", webhookHandler.ListDeliveries)
+			admin.POST("/webhooks/:id/deliveries/:delivery_id/retry", webhookHandler.RetryDelivery)
+			// Runtime metrics, such as the task cache hits and misses.
+			admin.GET("/debug/vars", gin.WrapH(expvar.Handler()))
 		}
 	}
 
 	return r
 }
-
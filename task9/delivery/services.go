@@ -0,0 +1,98 @@
+package delivery
+
+import (
+	"task9/config"
+	"task9/domain"
+	"task9/infrastructure"
+	"task9/repository"
+	"task9/usecase"
+)
+
+// Services holds the use cases and the repositories shared by the HTTP API
+// and the background jobs. main builds them once with NewServices, so that
+// the jobs change tasks with the same limits, tags, project guard and event
+// publishers as the API.
+type Services struct {
+	Hub *usecase.EventHub
+
+	Tasks       *usecase.TaskUseCase
+	Comments    *usecase.CommentUseCase
+	Attachments *usecase.AttachmentUseCase
+	Tags        *usecase.TagUseCase
+	Projects    *usecase.ProjectUseCase
+	Auth        *usecase.AuthUseCase
+	Workspaces  *usecase.WorkspaceUseCase
+	Calendar    *usecase.CalendarUseCase
+	Webhooks    *usecase.WebhookUseCase
+
+	TaskRepo        domain.TaskRepository
+	WorkspaceRepo   domain.WorkspaceRepository
+	IdempotencyRepo domain.IdempotencyRepository
+	TokenGenerator  *infrastructure.JWTGenerator
+}
+
+// NewServices wires the use cases to the MongoDB repositories. Task events
+// are published to hub, which feeds the task streams, and attachment content
+// is kept in blobs. Task lookups by ID are read through taskCache; nil
+// leaves them uncached.
+func NewServices(cfg *config.Config, hub *usecase.EventHub, blobs domain.BlobStore, taskCache domain.TaskCache) *Services {
+	webhookUseCase := usecase.NewWebhookUseCase(
+		repository.NewWebhookRepositoryMongo(infrastructure.WebhookCollection, cfg.Database.QueryTimeout),
+		repository.NewWebhookDeliveryRepositoryMongo(infrastructure.WebhookDeliveryCollection, cfg.Database.QueryTimeout),
+		infrastructure.NewWebhookClient(cfg.Webhooks.Timeout),
+		usecase.WithWebhookRetries(cfg.Webhooks.MaxAttempts, cfg.Webhooks.Backoff),
+		usecase.WithWebhookLease(cfg.Webhooks.Lease),
+		usecase.WithWebhookBatchSize(cfg.Webhooks.BatchSize))
+
+	taskRepo := repository.NewTaskRepositoryMongo(infrastructure.TaskCollection, cfg.Database.QueryTimeout, cfg.Tasks.MaxHistoryEntries)
+	if taskCache != nil {
+		taskRepo = repository.NewCachedTaskRepository(taskRepo, taskCache)
+	}
+	commentRepo := repository.NewCommentRepositoryMongo(infrastructure.CommentCollection, cfg.Database.QueryTimeout)
+	tagRepo := repository.NewTagRepositoryMongo(infrastructure.TagCollection, cfg.Database.QueryTimeout)
+	attachmentRepo := repository.NewAttachmentRepositoryMongo(infrastructure.AttachmentCollection, cfg.Database.QueryTimeout)
+	projectRepo := repository.NewProjectRepositoryMongo(infrastructure.ProjectCollection, cfg.Database.QueryTimeout)
+	projects := usecase.NewProjectGuard(projectRepo)
+	taskUseCase := usecase.NewTaskUseCase(taskRepo, usecase.WithTaskLimits(domain.TaskLimits{
+		MaxTitleLength:       cfg.Limits.MaxTitleLength,
+		MaxDescriptionLength: cfg.Limits.MaxDescriptionLength,
+		MaxBatchSize:         cfg.Limits.MaxBatchSize,
+		MaxChecklistItems:    cfg.Limits.MaxChecklistItems,
+	}), usecase.WithComments(commentRepo), usecase.WithAttachments(attachmentRepo, blobs), usecase.WithCompletionPolicy(domain.CompletionPolicy(cfg.Tasks.CompletionPolicy)), usecase.WithTags(tagRepo), usecase.WithProjects(projects), usecase.WithEvents(webhookUseCase), usecase.WithEvents(hub))
+
+	commentUseCase := usecase.NewCommentUseCase(commentRepo, taskRepo, usecase.WithCommentLimits(domain.CommentLimits{
+		MaxBodyLength: cfg.Limits.MaxCommentLength,
+	}), usecase.WithCommentProjects(projects))
+
+	attachmentUseCase := usecase.NewAttachmentUseCase(attachmentRepo, taskRepo, blobs, usecase.WithAttachmentLimits(domain.AttachmentLimits{
+		MaxBytes:     cfg.Attachments.MaxBytes,
+		AllowedTypes: cfg.Attachments.AllowedTypes,
+	}), usecase.WithAttachmentProjects(projects))
+
+	userRepo := repository.NewUserRepositoryMongo(infrastructure.UserCollection, cfg.Database.QueryTimeout)
+	passwordHasher := infrastructure.NewBcryptHasher(cfg.Password.BcryptCost)
+	tokenGenerator := infrastructure.NewJWTGenerator(cfg.Auth)
+	authUseCase := usecase.NewAuthUseCase(userRepo, passwordHasher, tokenGenerator, usecase.WithPasswordPolicy(domain.PasswordPolicy{
+		MinLength: cfg.Password.MinLength,
+	}), usecase.WithUserEvents(webhookUseCase))
+	workspaceRepo := repository.NewWorkspaceRepositoryMongo(infrastructure.WorkspaceCollection, infrastructure.MemberCollection, cfg.Database.QueryTimeout)
+
+	return &Services{
+		Hub: hub,
+
+		Tasks:       taskUseCase,
+		Comments:    commentUseCase,
+		Attachments: attachmentUseCase,
+		Tags:        usecase.NewTagUseCase(tagRepo, taskRepo, usecase.WithTagProjects(projects), usecase.WithTagEvents(webhookUseCase), usecase.WithTagEvents(hub)),
+		Projects:    usecase.NewProjectUseCase(projectRepo, taskRepo, usecase.WithProjectEvents(webhookUseCase), usecase.WithProjectEvents(hub)),
+		Auth:        authUseCase,
+		Workspaces:  usecase.NewWorkspaceUseCase(workspaceRepo, userRepo),
+		Calendar:    usecase.NewCalendarUseCase(userRepo, taskRepo, workspaceRepo),
+		Webhooks:    webhookUseCase,
+
+		TaskRepo:        taskRepo,
+		WorkspaceRepo:   workspaceRepo,
+		IdempotencyRepo: repository.NewIdempotencyRepositoryMongo(infrastructure.IdempotencyCollection, cfg.Database.QueryTimeout),
+		TokenGenerator:  tokenGenerator,
+	}
+}
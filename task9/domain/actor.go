@@ -0,0 +1,23 @@
+package domain
+
+import "context"
+
+// Actor is the authenticated user a request is made on behalf of.
+type Actor struct {
+	UserID   string
+	Username string
+	Role     string
+}
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor stored by WithActor, or the zero Actor for
+// unauthenticated contexts such as background jobs.
+func ActorFrom(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
@@ -0,0 +1,15 @@
+package domain
+
+// DependencyNode is one task in a dependency tree. In an upstream tree its
+// children are the tasks blocking it; in a downstream tree they are the tasks
+// it blocks.
+type DependencyNode struct {
+	Task     Task
+	Children []DependencyNode
+}
+
+type DependencyGraph struct {
+	Task       Task
+	Upstream   []DependencyNode
+	Downstream []DependencyNode
+}
@@ -1,22 +1,281 @@
 package domain
 
-import "time"
+import (
+	"io"
+	"time"
+)
 
 type Task struct {
-	ID          string
-	Title       string
+	ID           string
+	WorkspaceID  string
+	Title        string
+	Description  string
+	DueDate      time.Time
+	Status       string
+	Priority     string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    time.Time
+	DeletedBy    string
+	ParentID     string
+	ProjectID    string
+	Checklist    []ChecklistItem
+	BlockedBy    []string
+	Tags         []string
+	Recurrence   *Recurrence
+	CommentCount int
+	Subtasks     SubtaskCount
+	// ExternalID identifies the task in another system, e.g. the spreadsheet
+	// it was imported from. It is optional and unique among tasks, including
+	// those in the trash.
+	ExternalID string
+}
+
+type ChecklistItem struct {
+	ID   string
+	Text string
+	Done bool
+}
+
+// Recurrence links a task to a repeating series. Rule is an RRULE in
+// canonical form whose occurrences are counted from Start. SeriesID is the ID
+// of the task that started the series and NextID that of the occurrence
+// generated after this one. The series goes on from its latest occurrence,
+// the one without a NextID, and has stopped when that one's Rule is empty.
+type Recurrence struct {
+	Rule     string
+	SeriesID string
+	Start    time.Time
+	NextID   string
+}
+
+// IsActive reports whether a next occurrence is still to be generated from
+// this task.
+func (r *Recurrence) IsActive() bool {
+	return r != nil && r.Rule != "" && r.NextID == ""
+}
+
+// SubtaskCount summarises the direct subtasks of a task that are not in the
+// trash.
+type SubtaskCount struct {
+	Total     int
+	Completed int
+}
+
+// Tag is an entry in the tag catalogue of a workspace. Tasks refer to tags
+// by name.
+type Tag struct {
+	Name        string
+	Color       string
 	Description string
-	DueDate     time.Time
-	Status      string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UsageCount  int
+}
+
+// Project groups tasks of a workspace. DefaultStatus is the status tasks
+// created in the project start with. The tasks of an archived project are
+// read-only.
+type Project struct {
+	ID            string
+	WorkspaceID   string
+	Name          string
+	Description   string
+	OwnerID       string
+	Owner         string
+	Archived      bool
+	DefaultStatus string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// ProjectStats summarises the live tasks of a project. Overdue counts the
+// open tasks whose due date has passed.
+type ProjectStats struct {
+	Total      int
+	ByStatus   map[string]int
+	ByPriority map[string]int
+	Overdue    int
+}
+
+const (
+	ReminderDueSoon = "due_soon"
+	ReminderOverdue = "overdue"
+)
+
+// Reminder tells that a task is due within Window (ReminderDueSoon) or has
+// passed its due date (ReminderOverdue). Key identifies it across runs and
+// replicas: there is one per task, kind, window and due date, so moving the
+// due date brings a fresh set of reminders.
+type Reminder struct {
+	Key     string
+	TaskID  string
+	Title   string
+	Kind    string
+	Window  time.Duration
+	DueDate time.Time
+}
+
+const (
+	EventTaskCreated    = "task.created"
+	EventTaskUpdated    = "task.updated"
+	EventTaskCompleted  = "task.completed"
+	EventTaskDeleted    = "task.deleted"
+	EventTaskRestored   = "task.restored"
+	EventUserRegistered = "user.registered"
+	EventUserPromoted   = "user.promoted"
+)
+
+// EventTypes lists every event a webhook can subscribe to.
+var EventTypes = []string{
+	EventTaskCreated, EventTaskUpdated, EventTaskCompleted, EventTaskDeleted, EventTaskRestored,
+	EventUserRegistered, EventUserPromoted,
+}
+
+// Event is something that happened to a task or a user. Task events carry
+// the task as it is after the change, and updates also the changed fields.
+type Event struct {
+	ID         string
+	Type       string
+	OccurredAt time.Time
+	Actor      Actor
+	Task       *Task
+	Changes    []FieldChange
+	User       *User
+}
+
+// WebhookSubscription sends the events listed in Events, or all of them for
+// "*", to URL. Payloads are signed with Secret.
+type WebhookSubscription struct {
+	ID        string
+	URL       string
+	Events    []string
+	Secret    string
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Wants reports whether the subscription is active and asks for eventType.
+func (s WebhookSubscription) Wants(eventType string) bool {
+	if !s.Active {
+		return false
+	}
+	for _, event := range s.Events {
+		if event == "*" || event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	WebhookPending   = "pending"
+	WebhookSucceeded = "succeeded"
+	WebhookDead      = "dead"
+)
+
+// WebhookDelivery is one event queued for one subscription. It stays
+// WebhookPending through failed attempts, retried from NextAttemptAt on,
+// until it succeeds or runs out of attempts and is WebhookDead.
+type WebhookDelivery struct {
+	ID             string
+	SubscriptionID string
+	EventID        string
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeliveredAt    time.Time
+}
+
+type Comment struct {
+	ID          string
+	WorkspaceID string
+	TaskID      string
+	AuthorID    string
+	Author      string
+	Body        string
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
 
+// Attachment describes a file attached to a task. The file itself is kept in
+// a BlobStore under BlobKey.
+type Attachment struct {
+	ID          string
+	WorkspaceID string
+	TaskID      string
+	Filename    string
+	ContentType string
+	Size        int64
+	BlobKey     string
+	UploaderID  string
+	Uploader    string
+	CreatedAt   time.Time
+}
+
+// AttachmentUpload is a file as it is uploaded. Body is read once, while the
+// file arrives.
+type AttachmentUpload struct {
+	Filename    string
+	ContentType string
+	Body        io.Reader
+}
+
+// IdempotencyRecord is a request that was sent with an Idempotency-Key.
+// Status is zero while the request runs; once it is done the record holds
+// the response, to be replayed to retries until ExpiresAt. Token tells
+// apart successive reservations of the same key.
+type IdempotencyRecord struct {
+	Key         string
+	Token       string
+	Fingerprint string
+	Status      int
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+const (
+	WorkspaceAdmin  = "admin"
+	WorkspaceMember = "member"
+)
+
+// Workspace isolates the tasks and tags of one team from those of others.
+// Role is the acting user's role in it, filled in when a user's workspaces
+// are listed.
+type Workspace struct {
+	ID        string
+	Name      string
+	CreatedAt time.Time
+	Role      string
+}
+
+// Membership gives a user a role in a workspace: WorkspaceAdmin or
+// WorkspaceMember. Within the workspace the role takes the place of the
+// user's global role.
+type Membership struct {
+	WorkspaceID string
+	UserID      string
+	Username    string
+	Role        string
+	CreatedAt   time.Time
+}
+
 type User struct {
 	ID       string
 	Username string
 	Password string
 	Role     string
+	// CalendarTokenHash is the SHA-256 hash of the token in the user's
+	// calendar feed URL, hex-encoded. It is empty while the feed is off.
+	CalendarTokenHash string
 }
 
 type RegisterRequest struct {
@@ -34,16 +293,121 @@ type CreateTaskRequest struct {
 	Description string
 	DueDate     time.Time
 	Status      string
+	Priority    string
+	ParentID    string
+	// ProjectID is optional. Without a Status the task starts with the
+	// project's default status.
+	ProjectID string
+	Tags      []string
+	// Recurrence is an optional RRULE that makes the task repeat from its
+	// due date.
+	Recurrence string
+	// ExternalID is optional and must not be used by another task.
+	ExternalID string
 }
 
+// UpdateTaskRequest changes the non-empty fields. A nil Tags leaves the tags
+// alone, while an empty non-nil Tags removes them all.
 type UpdateTaskRequest struct {
 	Title       string
 	Description string
 	DueDate     time.Time
 	Status      string
+	Priority    string
+	ParentID    string
+	ProjectID   string
+	Tags        []string
+}
+
+// TagRequest creates or edits a catalogue tag. On edit, an empty Name keeps
+// the current name.
+type TagRequest struct {
+	Name        string
+	Color       string
+	Description string
+}
+
+// ProjectRequest creates or edits a project. On edit, empty fields and a nil
+// Archived keep their current value.
+type ProjectRequest struct {
+	Name          string
+	Description   string
+	DefaultStatus string
+	Archived      *bool
+}
+
+// WebhookRequest creates or edits a webhook subscription. On edit, empty
+// fields, a nil Events and a nil Active keep their current value. A new
+// subscription is active unless Active says otherwise.
+type WebhookRequest struct {
+	URL    string
+	Events []string
+	Secret string
+	Active *bool
+}
+
+// MemberRequest adds a user to a workspace by username, or changes the role
+// of a member. An empty Role means WorkspaceMember.
+type MemberRequest struct {
+	Username string
+	Role     string
 }
 
 type PromoteRequest struct {
 	Username string
 }
 
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+type BatchOperation struct {
+	Op     string
+	ID     string
+	Create CreateTaskRequest
+	Update UpdateTaskRequest
+}
+
+type BatchResult struct {
+	Op    string
+	ID    string
+	Task  *Task
+	Error error
+}
+
+// TaskWrite is a single prepared write handed to TaskRepository.BulkWrite.
+// Update writes only change the non-empty fields of Task, like Update does.
+// The repository fills in History.Changes for updates and deletes.
+type TaskWrite struct {
+	Op      string
+	ID      string
+	Task    Task
+	History HistoryEntry
+}
+
+// ImportOptions control a task import. In a dry run every row is checked but
+// nothing is written. With Upsert, a row whose ExternalID belongs to an
+// existing task updates that task instead of being rejected.
+type ImportOptions struct {
+	DryRun bool
+	Upsert bool
+}
+
+// ImportReport sums up a task import. Created and Updated count the rows
+// that were written, or in a dry run would have been.
+type ImportReport struct {
+	Rows    int
+	Created int
+	Updated int
+	Errors  []ImportRowError
+}
+
+// ImportRowError tells why a row was not imported. Rows are counted from 1
+// in the order they appear, not counting a CSV header.
+type ImportRowError struct {
+	Row        int
+	ExternalID string
+	Err        error
+}
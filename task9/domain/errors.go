@@ -0,0 +1,47 @@
+package domain
+
+import "errors"
+
+var ErrBatchAborted = errors.New("batch aborted, no operations were applied")
+
+var ErrConflict = errors.New("task was modified concurrently, retry the request")
+
+var ErrOpenSubtasks = errors.New("task has open subtasks or checklist items")
+
+var ErrBlocked = errors.New("task is blocked by unfinished tasks")
+
+var ErrExternalIDTaken = errors.New("external_id is already used by another task")
+
+var ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+
+var ErrForbidden = errors.New("you can only change your own comments")
+
+var ErrNotUploader = errors.New("you can only delete attachments you uploaded")
+
+var ErrAttachmentTooLarge = errors.New("attachment is too large")
+
+var ErrAttachmentType = errors.New("attachments of this type are not allowed")
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+var ErrProjectArchived = errors.New("project is archived, its tasks are read-only")
+
+var ErrAlreadyMember = errors.New("user is already a member of the workspace")
+
+var ErrNotWorkspaceAdmin = errors.New("only workspace admins can remove other members")
+
+var ErrLastWorkspaceAdmin = errors.New("a workspace must keep at least one admin")
+
+// ValidationError marks an error caused by bad client input, so handlers can
+// answer with 400 without matching on the message text.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func NewValidationError(message string) error {
+	return &ValidationError{Message: message}
+}
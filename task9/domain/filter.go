@@ -0,0 +1,47 @@
+package domain
+
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// TaskFilter narrows and orders a task listing. The zero value matches every
+// task, in storage order.
+type TaskFilter struct {
+	// ProjectID limits the listing to the tasks of one project.
+	ProjectID string
+	Tags      []string
+	// TagMatch is TagMatchAny (the default) to match tasks carrying at
+	// least one of Tags, or TagMatchAll to require every one of them.
+	TagMatch string
+	// Sort is empty for storage order or SortSmart.
+	Sort string
+}
+
+// IsEmpty reports whether the filter matches every task.
+func (f TaskFilter) IsEmpty() bool {
+	return f.ProjectID == "" && len(f.Tags) == 0
+}
+
+// Matches reports whether task passes the filter.
+func (f TaskFilter) Matches(task Task) bool {
+	if f.ProjectID != "" && task.ProjectID != f.ProjectID {
+		return false
+	}
+	if len(f.Tags) == 0 {
+		return true
+	}
+	has := make(map[string]bool, len(task.Tags))
+	for _, tag := range task.Tags {
+		has[tag] = true
+	}
+	for _, tag := range f.Tags {
+		if has[tag] && f.TagMatch != TagMatchAll {
+			return true
+		}
+		if !has[tag] && f.TagMatch == TagMatchAll {
+			return false
+		}
+	}
+	return f.TagMatch == TagMatchAll
+}
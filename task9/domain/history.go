@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"reflect"
+	"time"
+)
+
+const (
+	HistoryCreate  = "create"
+	HistoryUpdate  = "update"
+	HistoryDelete  = "delete"
+	HistoryRestore = "restore"
+)
+
+// HistoryEntry records one change to a task. Field names in Changes are the
+// JSON/BSON names (title, due_date, ...); Old is nil for a create.
+type HistoryEntry struct {
+	Action  string
+	ActorID string
+	Actor   string
+	At      time.Time
+	Changes []FieldChange
+}
+
+type FieldChange struct {
+	Field string
+	Old   interface{}
+	New   interface{}
+}
+
+// TaskRevision is a task as a change across many tasks left it, with the
+// changes recorded in its history.
+type TaskRevision struct {
+	Task    Task
+	Changes []FieldChange
+}
+
+func NewHistoryEntry(actor Actor, action string, changes []FieldChange) HistoryEntry {
+	return HistoryEntry{
+		Action:  action,
+		ActorID: actor.UserID,
+		Actor:   actor.Username,
+		At:      time.Now(),
+		Changes: changes,
+	}
+}
+
+// TaskChanges lists the tracked fields that differ between before and after.
+// Empty strings, zero times and empty lists are reported as nil.
+func TaskChanges(before, after Task) []FieldChange {
+	var changes []FieldChange
+	add := func(field string, old, new interface{}) {
+		if !reflect.DeepEqual(old, new) {
+			changes = append(changes, FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	add("title", stringValue(before.Title), stringValue(after.Title))
+	add("description", stringValue(before.Description), stringValue(after.Description))
+	add("due_date", timeValue(before.DueDate), timeValue(after.DueDate))
+	add("status", stringValue(before.Status), stringValue(after.Status))
+	add("priority", stringValue(before.Priority), stringValue(after.Priority))
+	add("parent_id", stringValue(before.ParentID), stringValue(after.ParentID))
+	add("project_id", stringValue(before.ProjectID), stringValue(after.ProjectID))
+	add("checklist", checklistValue(before.Checklist), checklistValue(after.Checklist))
+	add("blocked_by", stringsValue(before.BlockedBy), stringsValue(after.BlockedBy))
+	add("tags", stringsValue(before.Tags), stringsValue(after.Tags))
+	add("recurrence", recurrenceValue(before.Recurrence), recurrenceValue(after.Recurrence))
+	return changes
+}
+
+func stringValue(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func timeValue(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Truncate(time.Millisecond)
+}
+
+func checklistValue(items []ChecklistItem) interface{} {
+	if len(items) == 0 {
+		return nil
+	}
+	return items
+}
+
+func stringsValue(values []string) interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// recurrenceValue tracks the rule only: the series link of a task never
+// changes and NextID is set by the generator outside of Update.
+func recurrenceValue(r *Recurrence) interface{} {
+	if r == nil {
+		return nil
+	}
+	return stringValue(r.Rule)
+}
@@ -1,19 +1,283 @@
 package domain
 
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// TaskRepository persists tasks. Every task it returns has Subtasks filled in.
+// Through a context scoped by WithWorkspace it only sees the tasks of that
+// workspace, and tasks it creates belong to it; otherwise a created task
+// keeps its WorkspaceID. ExternalIDs are unique within a workspace.
 type TaskRepository interface {
-	GetAll() ([]Task, error)
-	GetByID(id string) (Task, error)
-	Create(task Task) (Task, error)
-	Update(id string, task Task) (Task, error)
-	Delete(id string) error
+	GetAll(ctx context.Context) ([]Task, error)
+	Find(ctx context.Context, filter TaskFilter) ([]Task, error)
+	// Each calls fn for every task matching filter, in storage order, without
+	// holding them all in memory. It stops at the first error fn returns and
+	// returns it. Subtask counts are not filled in.
+	Each(ctx context.Context, filter TaskFilter, fn func(Task) error) error
+	GetByID(ctx context.Context, id string) (Task, error)
+	// GetByExternalID also finds a task in the trash, which still holds its
+	// external ID.
+	GetByExternalID(ctx context.Context, externalID string) (Task, error)
+	// GetByIDs returns the tasks among ids that exist; unknown and malformed
+	// IDs are skipped.
+	GetByIDs(ctx context.Context, ids []string) ([]Task, error)
+	// GetSubtasks returns the tasks whose ParentID is one of parentIDs.
+	GetSubtasks(ctx context.Context, parentIDs []string) ([]Task, error)
+	// GetBlockedBy returns the tasks whose BlockedBy lists one of blockerIDs.
+	GetBlockedBy(ctx context.Context, blockerIDs []string) ([]Task, error)
+	// GetDueBetween returns the live tasks that are not completed and have a
+	// due date at or after from and before to.
+	GetDueBetween(ctx context.Context, from, to time.Time) ([]Task, error)
+	// GetRecurring returns the live tasks whose recurrence is active and
+	// that are completed or due before dueBefore, i.e. that are ready for
+	// their next occurrence.
+	GetRecurring(ctx context.Context, dueBefore time.Time) ([]Task, error)
+	// Create, Update, Delete and Restore record entry in the task's history
+	// in the same write as the change itself. A created task with a
+	// Recurrence but no SeriesID starts a series of its own. Create returns
+	// ErrExternalIDTaken if another task has the same ExternalID, and Update
+	// never changes ExternalID.
+	Create(ctx context.Context, task Task, entry HistoryEntry) (Task, error)
+	// CreateOccurrence creates task as the occurrence following previousID
+	// and sets previousID's Recurrence.NextID to it. It returns ErrConflict
+	// if previousID is no longer active, e.g. because another process got
+	// there first.
+	CreateOccurrence(ctx context.Context, previousID string, task Task, entry HistoryEntry) (Task, error)
+	// Update only applies if every field in entry.Changes still holds its Old
+	// value, and returns ErrConflict otherwise. Empty fields of task and a
+	// nil Checklist, BlockedBy, Tags or Recurrence are left untouched, and
+	// Update never changes Recurrence.NextID.
+	Update(ctx context.Context, id string, task Task, entry HistoryEntry) (Task, error)
+	// Delete moves a task to the trash. Trashed tasks are hidden from every
+	// other method except GetDeleted, GetDeletedByID, GetByExternalID,
+	// GetHistory, Restore and Purge.
+	Delete(ctx context.Context, id string, entry HistoryEntry) error
+	GetDeleted(ctx context.Context) ([]Task, error)
+	// GetDeletedByID returns a task in the trash, and "task not found" for
+	// any other task.
+	GetDeletedByID(ctx context.Context, id string) (Task, error)
+	Restore(ctx context.Context, id string, entry HistoryEntry) (Task, error)
+	// GetHistory returns a page of history entries, newest first, and the
+	// total number of entries. Only the newest entries are kept when the
+	// repository caps the history.
+	GetHistory(ctx context.Context, id string, offset, limit int) ([]HistoryEntry, int, error)
+	Purge(ctx context.Context, id string) error
+	// PurgeDeleted permanently removes tasks trashed before the cutoff and
+	// returns their IDs.
+	PurgeDeleted(ctx context.Context, before time.Time) ([]string, error)
+	// BulkWrite applies writes in one round trip and returns one error slot
+	// per write (nil on success). An update or delete whose task changed
+	// after it was checked fails with ErrConflict, like Update. IDs of
+	// created tasks are stored back into writes[i].ID and writes[i].Task.ID.
+	BulkWrite(ctx context.Context, writes []TaskWrite) ([]error, error)
+	// BulkWriteAtomic is BulkWrite inside a transaction: if any write fails
+	// nothing is applied and ErrBatchAborted is returned with the item errors.
+	BulkWriteAtomic(ctx context.Context, writes []TaskWrite) ([]error, error)
+	// CountTags returns how many live tasks carry each tag.
+	CountTags(ctx context.Context) (map[string]int, error)
+	// RenameTag replaces oldName with newName on every task and RemoveTag
+	// takes name off every task, trashed tasks included. Each task changed
+	// gets entry, with its own Changes, in its history in the same write.
+	// Both return the tasks changed.
+	RenameTag(ctx context.Context, oldName, newName string, entry HistoryEntry) ([]TaskRevision, error)
+	RemoveTag(ctx context.Context, name string, entry HistoryEntry) ([]TaskRevision, error)
+	// RemoveProject takes every task, including those in the trash, out of
+	// the project, recording entry like RenameTag, and returns the tasks
+	// changed.
+	RemoveProject(ctx context.Context, projectID string, entry HistoryEntry) ([]TaskRevision, error)
+	// Stats summarises the live tasks matching query.Filter. Completions
+	// are read from the history, so a task reopened and completed again
+	// counts twice.
+	Stats(ctx context.Context, query TaskStatsQuery) (TaskStats, error)
+}
+
+// TaskCache keeps copies of tasks by ID in front of a TaskRepository. It is
+// only an optimisation: it may drop entries at any time, and a cache backed
+// by another system reports its failures as misses, so that lookups fall
+// back to the repository.
+type TaskCache interface {
+	Get(ctx context.Context, id string) (Task, bool)
+	Set(ctx context.Context, id string, task Task)
+	Delete(ctx context.Context, ids ...string)
+	Clear(ctx context.Context)
+}
+
+// CommentRepository, like AttachmentRepository and TagRepository, is scoped
+// to the workspace of the context in the same way as TaskRepository.
+type CommentRepository interface {
+	Create(ctx context.Context, comment Comment) (Comment, error)
+	GetByID(ctx context.Context, id string) (Comment, error)
+	// ListByTask returns the task's comments, oldest first.
+	ListByTask(ctx context.Context, taskID string) ([]Comment, error)
+	UpdateBody(ctx context.Context, id string, body string) (Comment, error)
+	Delete(ctx context.Context, id string) error
+	// CountByTasks returns the number of comments per task ID. Tasks without
+	// comments are left out of the map.
+	CountByTasks(ctx context.Context, taskIDs []string) (map[string]int, error)
+	DeleteByTasks(ctx context.Context, taskIDs []string) (int64, error)
+}
+
+// AttachmentRepository stores attachment metadata; the contents are in a
+// BlobStore.
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment Attachment) (Attachment, error)
+	GetByID(ctx context.Context, id string) (Attachment, error)
+	// ListByTask returns the task's attachments, oldest first.
+	ListByTask(ctx context.Context, taskID string) ([]Attachment, error)
+	Delete(ctx context.Context, id string) error
+	// DeleteByTasks removes the attachments of the given tasks and returns
+	// them, so that their blobs can be removed too.
+	DeleteByTasks(ctx context.Context, taskIDs []string) ([]Attachment, error)
+}
+
+// BlobStore keeps file contents under keys chosen by the caller: lower-case
+// letters and digits in segments separated by slashes. Contents are streamed
+// in and out, so stores backed by object storage such as S3 fit as well as
+// the local filesystem.
+type BlobStore interface {
+	// Put stores everything read from r under key and returns its size. When
+	// Put fails, including because r fails, nothing is kept.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Open returns ErrBlobNotFound for a key that is not stored.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes key. Removing a key that is not stored is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// TagRepository stores the tag catalogues. Names are unique within a
+// workspace.
+type TagRepository interface {
+	// List returns every tag ordered by name.
+	List(ctx context.Context) ([]Tag, error)
+	GetByName(ctx context.Context, name string) (Tag, error)
+	Create(ctx context.Context, tag Tag) (Tag, error)
+	// Update replaces the tag called name, which may rename it.
+	Update(ctx context.Context, name string, tag Tag) (Tag, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// ProjectRepository stores projects, scoped like TaskRepository.
+type ProjectRepository interface {
+	// List returns every project ordered by name.
+	List(ctx context.Context) ([]Project, error)
+	GetByID(ctx context.Context, id string) (Project, error)
+	Create(ctx context.Context, project Project) (Project, error)
+	Update(ctx context.Context, id string, project Project) (Project, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// WebhookRepository stores webhook subscriptions.
+type WebhookRepository interface {
+	List(ctx context.Context) ([]WebhookSubscription, error)
+	GetByID(ctx context.Context, id string) (WebhookSubscription, error)
+	Create(ctx context.Context, subscription WebhookSubscription) (WebhookSubscription, error)
+	Update(ctx context.Context, id string, subscription WebhookSubscription) (WebhookSubscription, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// WebhookDeliveryRepository is the queue and log of webhook deliveries.
+// Sending is guarded by a lease so that only one replica attempts a given
+// delivery at a time.
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, deliveries []WebhookDelivery) error
+	GetByID(ctx context.Context, id string) (WebhookDelivery, error)
+	// ClaimNext leases the oldest pending delivery due at now to owner until
+	// now+lease. It returns false when there is none.
+	ClaimNext(ctx context.Context, now time.Time, owner string, lease time.Duration) (WebhookDelivery, bool, error)
+	// Record writes the outcome of an attempt on a delivery claimed by owner
+	// and gives up the lease.
+	Record(ctx context.Context, delivery WebhookDelivery, owner string) error
+	// Requeue makes a dead delivery pending again, due at now, with its
+	// attempts reset. It returns ErrConflict if the delivery is not dead.
+	Requeue(ctx context.Context, id string, now time.Time) (WebhookDelivery, error)
+	// ListBySubscription returns one page of a subscription's deliveries,
+	// newest first, optionally only those with status, and the total count.
+	ListBySubscription(ctx context.Context, subscriptionID, status string, skip, limit int) ([]WebhookDelivery, int, error)
+	DeleteBySubscription(ctx context.Context, subscriptionID string) (int64, error)
+}
+
+// EventPublisher is told about every change to tasks and users.
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// WebhookSender posts a delivery's payload, signed with the subscription's
+// secret, and returns the response status. An error means the attempt failed,
+// including answers other than 2xx.
+type WebhookSender interface {
+	Send(ctx context.Context, subscription WebhookSubscription, delivery WebhookDelivery) (int, error)
+}
+
+// ReminderRepository tracks which reminders have been delivered. Delivery is
+// guarded by a lease so that only one replica sends a given reminder.
+type ReminderRepository interface {
+	// Claim takes the lease on reminder for owner until now+lease. It returns
+	// false when the reminder was already sent, has been released after
+	// maxAttempts failed deliveries, or another owner holds an unexpired
+	// lease. A maxAttempts of 0 means no limit.
+	Claim(ctx context.Context, reminder Reminder, owner string, now time.Time, lease time.Duration, maxAttempts int) (bool, error)
+	// MarkSent records the delivery of a reminder claimed by owner.
+	MarkSent(ctx context.Context, key, owner string, at time.Time) error
+	// Release gives up owner's lease after a failed delivery and counts the
+	// attempt, so the next scan can try again.
+	Release(ctx context.Context, key, owner string, cause error) error
+}
+
+// Notifier delivers reminders, e.g. by mail or to a webhook.
+type Notifier interface {
+	Notify(ctx context.Context, reminder Reminder) error
+}
+
+// IdempotencyRepository remembers requests sent with an Idempotency-Key so
+// that retries are answered without running them again. Expired records
+// count as absent.
+type IdempotencyRepository interface {
+	// Reserve stores record for a request that is about to run and returns
+	// it with a new Token. If an unexpired record with the same key exists,
+	// that record is returned with false instead.
+	Reserve(ctx context.Context, record IdempotencyRecord, now time.Time) (IdempotencyRecord, bool, error)
+	// Complete stores the response in the reservation of record.Key with
+	// record.Token and sets how long it is kept.
+	Complete(ctx context.Context, record IdempotencyRecord) error
+	// Release drops the reservation of record.Key with record.Token if its
+	// request did not complete, so that it can be retried at once. A newer
+	// reservation of the key, made after this one expired, is left alone.
+	Release(ctx context.Context, record IdempotencyRecord) error
+}
+
+// WorkspaceRepository stores workspaces and their members. A user has at
+// most one membership per workspace.
+type WorkspaceRepository interface {
+	Create(ctx context.Context, workspace Workspace) (Workspace, error)
+	GetByID(ctx context.Context, id string) (Workspace, error)
+	// ListForUser returns the workspaces userID belongs to, ordered by name,
+	// with Role set to the user's role.
+	ListForUser(ctx context.Context, userID string) ([]Workspace, error)
+	// AddMember returns ErrAlreadyMember if the user already belongs to the
+	// workspace.
+	AddMember(ctx context.Context, membership Membership) (Membership, error)
+	GetMember(ctx context.Context, workspaceID, userID string) (Membership, error)
+	// ListMembers returns the members of a workspace, ordered by username.
+	ListMembers(ctx context.Context, workspaceID string) ([]Membership, error)
+	UpdateMemberRole(ctx context.Context, workspaceID, userID, role string) (Membership, error)
+	RemoveMember(ctx context.Context, workspaceID, userID string) error
 }
 
 type UserRepository interface {
-	Create(user User) (User, error)
-	GetByUsername(username string) (User, error)
-	GetByID(id string) (User, error)
-	UpdateRole(username string, role string) error
-	IsFirstUser() (bool, error)
+	Create(ctx context.Context, user User) (User, error)
+	GetByUsername(ctx context.Context, username string) (User, error)
+	GetByID(ctx context.Context, id string) (User, error)
+	UpdateRole(ctx context.Context, username string, role string) error
+	IsFirstUser(ctx context.Context) (bool, error)
+	// SetCalendarTokenHash replaces the user's calendar token hash; an empty
+	// hash turns the feed off.
+	SetCalendarTokenHash(ctx context.Context, id string, hash string) error
+	// GetByCalendarTokenHash returns "user not found" for an empty or unknown
+	// hash.
+	GetByCalendarTokenHash(ctx context.Context, hash string) (User, error)
 }
 
 type PasswordHasher interface {
@@ -23,6 +287,6 @@ type PasswordHasher interface {
 
 type TokenGenerator interface {
 	Generate(userID, username, role string) (string, error)
+	GenerateStream(userID, username, role string) (string, time.Time, error)
 	Validate(tokenString string) (map[string]interface{}, error)
 }
-
@@ -0,0 +1,51 @@
+package domain
+
+import "strings"
+
+type PasswordPolicy struct {
+	MinLength int
+}
+
+type TaskLimits struct {
+	MaxTitleLength       int
+	MaxDescriptionLength int
+	MaxBatchSize         int
+	MaxChecklistItems    int
+}
+
+type CommentLimits struct {
+	MaxBodyLength int
+}
+
+// AttachmentLimits restricts uploads. AllowedTypes lists media types such as
+// "application/pdf", or a type with any subtype such as "image/*".
+type AttachmentLimits struct {
+	MaxBytes     int64
+	AllowedTypes []string
+}
+
+// Allows reports whether a media type, without parameters, is allowed.
+func (l AttachmentLimits) Allows(mediaType string) bool {
+	for _, allowed := range l.AllowedTypes {
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// CompletionPolicy decides what happens when a task is completed while some
+// of its subtasks or checklist items are still open.
+type CompletionPolicy string
+
+const (
+	// CompletionBlock rejects the update with ErrOpenSubtasks.
+	CompletionBlock CompletionPolicy = "block"
+	// CompletionCascade completes the open subtasks and checks off the
+	// remaining checklist items along with the task.
+	CompletionCascade CompletionPolicy = "cascade"
+)
@@ -0,0 +1,28 @@
+package domain
+
+// Progress returns the percentage of the task's checklist items and direct
+// subtasks that are done. ok is false when the task has neither.
+func (t Task) Progress() (percent int, ok bool) {
+	total := len(t.Checklist) + t.Subtasks.Total
+	if total == 0 {
+		return 0, false
+	}
+	return t.Done() * 100 / total, true
+}
+
+// Done counts the checked checklist items and completed direct subtasks.
+func (t Task) Done() int {
+	done := t.Subtasks.Completed
+	for _, item := range t.Checklist {
+		if item.Done {
+			done++
+		}
+	}
+	return done
+}
+
+// HasOpenItems reports whether a checklist item or direct subtask is still
+// open.
+func (t Task) HasOpenItems() bool {
+	return t.Done() < len(t.Checklist)+t.Subtasks.Total
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+const (
+	// SortSmart orders tasks by Score, most pressing first.
+	SortSmart = "smart"
+
+	// dueSoonWindow is how far ahead an upcoming due date starts to count.
+	dueSoonWindow = 7 * 24 * time.Hour
+	// maxOverdueDays caps how much lateness can add to the score.
+	maxOverdueDays = 10
+)
+
+var priorityWeights = map[string]float64{
+	PriorityLow:    1,
+	PriorityMedium: 2,
+	PriorityHigh:   3,
+	PriorityUrgent: 4,
+}
+
+func IsValidPriority(priority string) bool {
+	_, ok := priorityWeights[priority]
+	return ok
+}
+
+// EffectivePriority is the task's priority, or PriorityMedium for tasks
+// created before priorities existed.
+func (t Task) EffectivePriority() string {
+	if t.Priority == "" {
+		return PriorityMedium
+	}
+	return t.Priority
+}
+
+// Score rates how pressing an open task is at now. Each priority level is
+// worth 10 points. An overdue task gets 20 points plus one for each day or
+// part of a day late, up to maxOverdueDays, so an overdue medium task
+// outranks an urgent one with no deadline from the moment it is late. A
+// task due within dueSoonWindow gets up to 10 points, more the closer it
+// is.
+func (t Task) Score(now time.Time) float64 {
+	score := 10 * priorityWeights[t.EffectivePriority()]
+	if t.DueDate.IsZero() {
+		return score
+	}
+
+	until := t.DueDate.Sub(now)
+	switch {
+	case until < 0:
+		days := math.Ceil(float64(-until) / float64(24*time.Hour))
+		score += 20 + min(days, maxOverdueDays)
+	case until < dueSoonWindow:
+		score += 10 * (1 - float64(until)/float64(dueSoonWindow))
+	}
+	return score
+}
+
+// SortTasksSmart orders tasks with the highest Score first. Completed tasks
+// come last. Ties go to the earlier due date, then to the older task.
+func SortTasksSmart(tasks []Task, now time.Time) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if doneA, doneB := a.Status == "completed", b.Status == "completed"; doneA != doneB {
+			return doneB
+		}
+		if scoreA, scoreB := a.Score(now), b.Score(now); scoreA != scoreB {
+			return scoreA > scoreB
+		}
+		if !a.DueDate.Equal(b.DueDate) {
+			return !a.DueDate.IsZero() && (b.DueDate.IsZero() || a.DueDate.Before(b.DueDate))
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+}
@@ -1,13 +1,17 @@
 package repository
 
-import "task9/domain/entity"
+import (
+	"context"
+	"task9/domain/entity"
+)
 
 type TaskRepository interface {
-	Create(task *entity.Task) error
-	FindByID(id string) (*entity.Task, error)
-	FindAll() ([]*entity.Task, error)
-	Update(task *entity.Task) error
-	Delete(id string) error
+	Create(ctx context.Context, task *entity.Task) error
+	FindByID(ctx context.Context, id string) (*entity.Task, error)
+	// FindAll returns the tasks that can be decoded even when others
+	// cannot. The error then joins one error per undecodable task, so a
+	// non-nil error does not mean the list is empty.
+	FindAll(ctx context.Context) ([]*entity.Task, error)
+	Update(ctx context.Context, task *entity.Task) error
+	Delete(ctx context.Context, id string) error
 }
-
-
@@ -1,13 +1,14 @@
 package repository
 
-import "task9/domain/entity"
+import (
+	"context"
+	"task9/domain/entity"
+)
 
 type UserRepository interface {
-	Create(user *entity.User) error
-	FindByUsername(username string) (*entity.User, error)
-	FindByID(id string) (*entity.User, error)
-	Update(user *entity.User) error
-	Count() (int64, error)
+	Create(ctx context.Context, user *entity.User) error
+	FindByUsername(ctx context.Context, username string) (*entity.User, error)
+	FindByID(ctx context.Context, id string) (*entity.User, error)
+	Update(ctx context.Context, user *entity.User) error
+	Count(ctx context.Context) (int64, error)
 }
-
-
@@ -0,0 +1,75 @@
+package domain
+
+import "time"
+
+// TaskStatsQuery selects the live tasks TaskStats summarises. From and To
+// are the first and last day of the daily counts, as midnight UTC. Now
+// decides which open tasks are overdue and which week is the current one.
+type TaskStatsQuery struct {
+	Filter TaskFilter
+	From   time.Time
+	To     time.Time
+	Now    time.Time
+}
+
+// Until is the end of the last day of the query, exclusive.
+func (q TaskStatsQuery) Until() time.Time {
+	return q.To.AddDate(0, 0, 1)
+}
+
+// Week returns the start and the exclusive end of the week, Monday to
+// Sunday in UTC, that holds Now.
+func (q TaskStatsQuery) Week() (time.Time, time.Time) {
+	day := StartOfDay(q.Now)
+	offset := (int(day.Weekday()) + 6) % 7
+	start := day.AddDate(0, 0, -offset)
+	return start, start.AddDate(0, 0, 7)
+}
+
+// StartOfDay returns midnight UTC of the day holding t.
+func StartOfDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// TaskStats summarises the live tasks matching a TaskStatsQuery. Overdue
+// counts the open tasks whose due date has passed and DueThisWeek the open
+// tasks due in the current week, overdue ones included. Daily has one entry
+// per day of the query, oldest first. A task counts as completed on each day
+// its status was set to completed, and AverageTimeToComplete is the mean
+// time from creation to those completions; it is zero without any.
+type TaskStats struct {
+	Total                 int
+	ByStatus              map[string]int
+	Overdue               int
+	DueThisWeek           int
+	Daily                 []DailyTaskCount
+	AverageTimeToComplete time.Duration
+}
+
+type DailyTaskCount struct {
+	Day       time.Time
+	Created   int
+	Completed int
+}
+
+// NewTaskStats returns empty stats with a zero entry for every day of query.
+func NewTaskStats(query TaskStatsQuery) TaskStats {
+	stats := TaskStats{ByStatus: map[string]int{}}
+	for day := query.From; day.Before(query.Until()); day = day.AddDate(0, 0, 1) {
+		stats.Daily = append(stats.Daily, DailyTaskCount{Day: day})
+	}
+	return stats
+}
+
+// DayOf returns the entry of Daily for the day holding t, or nil when t is
+// outside the query.
+func (s TaskStats) DayOf(t time.Time) *DailyTaskCount {
+	if len(s.Daily) == 0 || t.Before(s.Daily[0].Day) {
+		return nil
+	}
+	i := int(StartOfDay(t).Sub(s.Daily[0].Day) / (24 * time.Hour))
+	if i >= len(s.Daily) {
+		return nil
+	}
+	return &s.Daily[i]
+}
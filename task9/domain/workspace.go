@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoWorkspace is returned by repositories of workspace data for a context
+// that is neither scoped to a workspace nor marked with AllWorkspaces.
+var ErrNoWorkspace = errors.New("no workspace in context")
+
+type workspaceKey struct{}
+
+type allWorkspacesKey struct{}
+
+// WithWorkspace scopes ctx to a workspace. Repositories of workspace data
+// only read and write that workspace's records through a scoped context.
+func WithWorkspace(ctx context.Context, workspaceID string) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, workspaceID)
+}
+
+// AllWorkspaces lets ctx reach the records of every workspace. It is meant
+// for background jobs and maintenance; request paths are always scoped with
+// WithWorkspace, which takes precedence.
+func AllWorkspaces(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allWorkspacesKey{}, true)
+}
+
+// WorkspaceFrom returns the workspace stored by WithWorkspace.
+func WorkspaceFrom(ctx context.Context) (string, bool) {
+	workspaceID, ok := ctx.Value(workspaceKey{}).(string)
+	return workspaceID, ok
+}
+
+// WorkspaceScope returns the workspace ctx is scoped to, or "" for a context
+// marked with AllWorkspaces. Any other context, including one scoped to an
+// empty workspace ID, gets ErrNoWorkspace, so that a path that forgets to
+// scope its context fails instead of reaching every workspace.
+func WorkspaceScope(ctx context.Context) (string, error) {
+	if workspaceID, ok := WorkspaceFrom(ctx); ok {
+		if workspaceID == "" {
+			return "", ErrNoWorkspace
+		}
+		return workspaceID, nil
+	}
+	if all, _ := ctx.Value(allWorkspacesKey{}).(bool); all {
+		return "", nil
+	}
+	return "", ErrNoWorkspace
+}
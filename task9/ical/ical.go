@@ -0,0 +1,130 @@
+// Package ical writes iCalendar (RFC 5545) data: content lines folded at 75
+// octets, escaped text values and UTC date-times.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest a content line may be, not counting the line
+// break. Longer lines are folded onto continuation lines that start with a
+// space.
+const maxLineOctets = 75
+
+// Encoder writes content lines. Errors are sticky: after the first failed
+// write nothing more is written, and Flush returns the error.
+type Encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// Begin starts a component such as VCALENDAR or VTODO.
+func (e *Encoder) Begin(component string) {
+	e.Property("BEGIN", component)
+}
+
+func (e *Encoder) End(component string) {
+	e.Property("END", component)
+}
+
+// Property writes a property whose value is already in iCalendar form, such
+// as a STATUS or an integer. name may carry parameters, as in
+// "DTSTART;VALUE=DATE".
+func (e *Encoder) Property(name, value string) {
+	e.writeLine(name + ":" + value)
+}
+
+// Text writes a TEXT property, escaping the value.
+func (e *Encoder) Text(name, value string) {
+	e.Property(name, EscapeText(value))
+}
+
+// TextList writes a property whose value is a list of TEXT values, such as
+// CATEGORIES. Nothing is written for an empty list.
+func (e *Encoder) TextList(name string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	escaped := make([]string, len(values))
+	for i, value := range values {
+		escaped[i] = EscapeText(value)
+	}
+	e.Property(name, strings.Join(escaped, ","))
+}
+
+// Time writes a DATE-TIME property in UTC, whatever t's location, so that
+// the calendar needs no VTIMEZONE and clients show it in their own zone.
+func (e *Encoder) Time(name string, t time.Time) {
+	e.Property(name, FormatTime(t))
+}
+
+func (e *Encoder) Flush() error {
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// writeLine folds line and writes it with its CRLF. Folds never split a
+// UTF-8 sequence.
+func (e *Encoder) writeLine(line string) {
+	if e.err != nil {
+		return
+	}
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		e.write(line[:cut])
+		e.write("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length.
+		limit = maxLineOctets - 1
+	}
+	e.write(line)
+	e.write("\r\n")
+}
+
+func (e *Encoder) write(s string) {
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s)
+	}
+}
+
+// FormatTime formats t as a UTC DATE-TIME, such as 20300501T090000Z.
+func FormatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// EscapeText escapes a TEXT value. Line breaks become \n; other control
+// characters are not allowed in TEXT and are dropped.
+func EscapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == ';' || r == ',':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n' || r == '\r':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -4,7 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"time"
+
+	"task9/config"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -15,13 +16,31 @@ var (
 	Database       *mongo.Database
 	TaskCollection *mongo.Collection
 	UserCollection *mongo.Collection
+
+	CommentCollection  *mongo.Collection
+	TagCollection      *mongo.Collection
+	ReminderCollection *mongo.Collection
+
+	AttachmentCollection  *mongo.Collection
+	IdempotencyCollection *mongo.Collection
+
+	WorkspaceCollection *mongo.Collection
+	MemberCollection    *mongo.Collection
+	ProjectCollection   *mongo.Collection
+
+	WebhookCollection         *mongo.Collection
+	WebhookDeliveryCollection *mongo.Collection
+
+	connectTimeout = config.Default().Database.ConnectTimeout
 )
 
-func ConnectDB(uri string, dbName string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// ConnectDB connects to MongoDB. Indexes are left to the migrations, see
+// Migrate.
+func ConnectDB(cfg config.DatabaseConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
 	defer cancel()
 
-	clientOptions := options.Client().ApplyURI(uri)
+	clientOptions := options.Client().ApplyURI(cfg.URI)
 
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
@@ -34,9 +53,20 @@ func ConnectDB(uri string, dbName string) error {
 	}
 
 	Client = client
-	Database = client.Database(dbName)
+	Database = client.Database(cfg.Name)
 	TaskCollection = Database.Collection("tasks")
 	UserCollection = Database.Collection("users")
+	CommentCollection = Database.Collection("comments")
+	TagCollection = Database.Collection("tags")
+	ReminderCollection = Database.Collection("reminders")
+	AttachmentCollection = Database.Collection("attachments")
+	IdempotencyCollection = Database.Collection("idempotency_keys")
+	WorkspaceCollection = Database.Collection("workspaces")
+	MemberCollection = Database.Collection("workspace_members")
+	ProjectCollection = Database.Collection("projects")
+	WebhookCollection = Database.Collection("webhooks")
+	WebhookDeliveryCollection = Database.Collection("webhook_deliveries")
+	connectTimeout = cfg.ConnectTimeout
 
 	log.Println("Successfully connected to MongoDB!")
 	return nil
@@ -44,10 +74,9 @@ func ConnectDB(uri string, dbName string) error {
 
 func DisconnectDB() error {
 	if Client != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
 		defer cancel()
 		return Client.Disconnect(ctx)
 	}
 	return nil
 }
-
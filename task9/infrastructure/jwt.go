@@ -1,22 +1,25 @@
 package infrastructure
 
 import (
-	"os"
 	"time"
 
+	"task9/config"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// streamScope marks stream tokens, which Validate refuses so that a token
+// leaked through a stream URL cannot be used on the rest of the API.
+const streamScope = "stream"
+
 type JWTGenerator struct {
-	secretKey string
+	secretKey      string
+	tokenTTL       time.Duration
+	streamTokenTTL time.Duration
 }
 
-func NewJWTGenerator() *JWTGenerator {
-	secretKey := os.Getenv("JWT_SECRET")
-	if secretKey == "" {
-		secretKey = "your-secret-key-change-in-production"
-	}
-	return &JWTGenerator{secretKey: secretKey}
+func NewJWTGenerator(cfg config.AuthConfig) *JWTGenerator {
+	return &JWTGenerator{secretKey: cfg.JWTSecret, tokenTTL: cfg.TokenTTL, streamTokenTTL: cfg.StreamTokenTTL}
 }
 
 func (j *JWTGenerator) Generate(userID, username, role string) (string, error) {
@@ -24,7 +27,7 @@ func (j *JWTGenerator) Generate(userID, username, role string) (string, error) {
 		"user_id":  userID,
 		"username": username,
 		"role":     role,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
+		"exp":      time.Now().Add(j.tokenTTL).Unix(),
 		"iat":      time.Now().Unix(),
 	}
 
@@ -32,7 +35,37 @@ func (j *JWTGenerator) Generate(userID, username, role string) (string, error) {
 	return token.SignedString([]byte(j.secretKey))
 }
 
+// GenerateStream issues a short-lived token that only ValidateStream
+// accepts, and returns when it expires.
+func (j *JWTGenerator) GenerateStream(userID, username, role string) (string, time.Time, error) {
+	expiresAt := time.Now().Add(j.streamTokenTTL)
+	claims := jwt.MapClaims{
+		"user_id":  userID,
+		"username": username,
+		"role":     role,
+		"scope":    streamScope,
+		"exp":      expiresAt.Unix(),
+		"iat":      time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(j.secretKey))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
 func (j *JWTGenerator) Validate(tokenString string) (map[string]interface{}, error) {
+	return j.validate(tokenString, "")
+}
+
+// ValidateStream accepts only tokens from GenerateStream.
+func (j *JWTGenerator) ValidateStream(tokenString string) (map[string]interface{}, error) {
+	return j.validate(tokenString, streamScope)
+}
+
+func (j *JWTGenerator) validate(tokenString, scope string) (map[string]interface{}, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, jwt.ErrSignatureInvalid
@@ -45,6 +78,9 @@ func (j *JWTGenerator) Validate(tokenString string) (map[string]interface{}, err
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		if tokenScope, _ := claims["scope"].(string); tokenScope != scope {
+			return nil, jwt.ErrTokenInvalidClaims
+		}
 		result := make(map[string]interface{})
 		result["user_id"] = claims["user_id"]
 		result["username"] = claims["username"]
@@ -54,4 +90,3 @@ func (j *JWTGenerator) Validate(tokenString string) (map[string]interface{}, err
 
 	return nil, jwt.ErrSignatureInvalid
 }
-
@@ -0,0 +1,124 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migration is one versioned change to the indexes or the shape of the
+// stored documents. Up must be safe to run again: a migration that failed
+// halfway is run from the start, and another replica may be running it at
+// the same time.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// MigrationStatus is a migration and, once it has been applied, when.
+type MigrationStatus struct {
+	Migration
+	AppliedAt time.Time
+}
+
+func (s MigrationStatus) Applied() bool {
+	return !s.AppliedAt.IsZero()
+}
+
+// Migrator applies migrations in version order and records every applied
+// version in the schema_migrations collection.
+type Migrator struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+	migrations []Migration
+}
+
+// NewMigrator checks that migrations are ordered by strictly increasing
+// version.
+func NewMigrator(db *mongo.Database, migrations []Migration) (*Migrator, error) {
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version <= migrations[i-1].Version {
+			return nil, fmt.Errorf("migration %d must have a higher version than migration %d", migrations[i].Version, migrations[i-1].Version)
+		}
+	}
+	return &Migrator{db: db, collection: db.Collection("schema_migrations"), migrations: migrations}, nil
+}
+
+// Status lists every known migration in order with when it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	cursor, err := m.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	applied := map[int]time.Time{}
+	for cursor.Next(ctx) {
+		var record struct {
+			Version   int       `bson:"_id"`
+			AppliedAt time.Time `bson:"applied_at"`
+		}
+		if err := cursor.Decode(&record); err != nil {
+			return nil, err
+		}
+		applied[record.Version] = record.AppliedAt
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, MigrationStatus{Migration: migration, AppliedAt: applied[migration.Version]})
+	}
+	return statuses, nil
+}
+
+// Up applies the pending migrations in version order and returns them. It
+// stops at the first one that fails, and returns the ones applied before it.
+// With dryRun nothing is applied and the pending migrations are returned.
+func (m *Migrator) Up(ctx context.Context, dryRun bool) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, status := range statuses {
+		if !status.Applied() {
+			pending = append(pending, status.Migration)
+		}
+	}
+	if dryRun {
+		return pending, nil
+	}
+
+	for i, migration := range pending {
+		if err := migration.Up(ctx, m.db); err != nil {
+			return pending[:i], fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+		_, err := m.collection.InsertOne(ctx, bson.M{
+			"_id":         migration.Version,
+			"description": migration.Description,
+			"applied_at":  time.Now(),
+		})
+		// A duplicate means another replica applied it meanwhile.
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return pending[:i], fmt.Errorf("migration %d (%s): failed to record: %w", migration.Version, migration.Description, err)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate runs the migrations the application relies on against Database.
+func Migrate(ctx context.Context, dryRun bool) ([]Migration, error) {
+	migrator, err := NewMigrator(Database, Migrations)
+	if err != nil {
+		return nil, err
+	}
+	return migrator.Up(ctx, dryRun)
+}
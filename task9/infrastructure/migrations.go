@@ -0,0 +1,268 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"task9/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migrations are the schema changes of the application, oldest first. Add
+// new ones at the end with the next version; never change or remove one
+// that has been released.
+var Migrations = []Migration{
+	{Version: 1, Description: "create the indexes of tasks, tags, members, comments and the queues", Up: createInitialIndexes},
+	{Version: 2, Description: "make usernames unique", Up: createUsernameIndex},
+	{Version: 3, Description: "index tasks by status and due date", Up: createTaskStatusIndex},
+	{Version: 4, Description: "add a text index over task titles and descriptions", Up: createTaskTextIndex},
+	{Version: 5, Description: "fill in the string fields older tasks and users lack", Up: backfillStringFields},
+	{Version: 6, Description: "move the data written before workspaces into a default workspace", Up: backfillDefaultWorkspace},
+}
+
+func createInitialIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("comments").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("attachments").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("idempotency_keys").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+	// External IDs and tag names used to be unique across all tasks and
+	// tags; now they are unique within a workspace.
+	if err := dropIndex(ctx, db.Collection("tasks"), "external_id_1"); err != nil {
+		return err
+	}
+	if err := dropIndex(ctx, db.Collection("tags"), "name_1"); err != nil {
+		return err
+	}
+	_, err = db.Collection("tasks").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "workspace_id", Value: 1}}},
+		{Keys: bson.D{{Key: "parent_id", Value: 1}}},
+		{Keys: bson.D{{Key: "project_id", Value: 1}}},
+		{Keys: bson.D{{Key: "blocked_by", Value: 1}}},
+		{Keys: bson.D{{Key: "tags", Value: 1}}},
+		{Keys: bson.D{{Key: "recurrence.rule", Value: 1}, {Key: "recurrence.next_id", Value: 1}}},
+		{Keys: bson.D{{Key: "due_date", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "workspace_id", Value: 1}, {Key: "external_id", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"external_id": bson.M{"$type": "string"}}),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("tags").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "workspace_id", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("projects").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "workspace_id", Value: 1}, {Key: "name", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("workspace_members").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "workspace_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "calendar_token_hash", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"calendar_token_hash": bson.M{"$type": "string"}}),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("webhook_deliveries").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "subscription_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+// createUsernameIndex fails while two users share a username; rename one of
+// them and run the migrations again.
+func createUsernameIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "username", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func createTaskStatusIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("tasks").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "due_date", Value: 1}},
+	})
+	return err
+}
+
+func createTaskTextIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("tasks").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}},
+		Options: options.Index().SetName("tasks_text").SetWeights(bson.D{{Key: "title", Value: 5}, {Key: "description", Value: 1}}),
+	})
+	return err
+}
+
+// backfillStringFields gives documents written by early versions the string
+// fields that readers expect to be present.
+func backfillStringFields(ctx context.Context, db *mongo.Database) error {
+	defaults := []struct {
+		collection string
+		field      string
+		value      string
+	}{
+		{"tasks", "title", ""},
+		{"tasks", "description", ""},
+		{"tasks", "status", "pending"},
+		{"users", "role", "user"},
+	}
+	for _, d := range defaults {
+		_, err := db.Collection(d.collection).UpdateMany(ctx,
+			bson.M{d.field: bson.M{"$not": bson.M{"$type": "string"}}},
+			bson.M{"$set": bson.M{d.field: d.value}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// workspaceCollections hold the documents that belong to a workspace.
+var workspaceCollections = []string{"tasks", "comments", "tags", "attachments", "projects"}
+
+// backfillDefaultWorkspace moves the documents written before workspaces
+// existed, which have no workspace_id and which repositories no longer
+// return, into a workspace named Default, and makes every user a member of
+// it. Admins become its admins. A database without such documents is left
+// alone, however many users it has.
+func backfillDefaultWorkspace(ctx context.Context, db *mongo.Database) error {
+	unscoped := bson.M{"workspace_id": bson.M{"$in": bson.A{nil, ""}}}
+	needed := false
+	for _, name := range workspaceCollections {
+		n, err := db.Collection(name).CountDocuments(ctx, unscoped, options.Count().SetLimit(1))
+		if err != nil {
+			return err
+		}
+		needed = needed || n > 0
+	}
+	if !needed {
+		return nil
+	}
+
+	workspaceID, err := defaultWorkspace(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := db.Collection("users").Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var user struct {
+			ID       primitive.ObjectID `bson:"_id"`
+			Username string             `bson:"username"`
+			Role     string             `bson:"role"`
+		}
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		role := domain.WorkspaceMember
+		if user.Role == "admin" {
+			role = domain.WorkspaceAdmin
+		}
+		_, err := db.Collection("workspace_members").UpdateOne(ctx,
+			bson.M{"workspace_id": workspaceID, "user_id": user.ID.Hex()},
+			bson.M{"$setOnInsert": bson.M{"username": user.Username, "role": role, "created_at": time.Now()}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for _, name := range workspaceCollections {
+		_, err := db.Collection(name).UpdateMany(ctx, unscoped, bson.M{"$set": bson.M{"workspace_id": workspaceID}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultWorkspace returns the ID of the workspace marked default, creating
+// it first if needed. A unique index on the mark keeps replicas running the
+// migration together from creating two.
+func defaultWorkspace(ctx context.Context, db *mongo.Database) (string, error) {
+	workspaces := db.Collection("workspaces")
+	_, err := workspaces.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "default", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"default": true}),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	_, err = workspaces.UpdateOne(ctx,
+		bson.M{"default": true},
+		bson.M{"$setOnInsert": bson.M{"name": "Default", "created_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return "", err
+	}
+
+	var workspace struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := workspaces.FindOne(ctx, bson.M{"default": true}).Decode(&workspace); err != nil {
+		return "", err
+	}
+	return workspace.ID.Hex(), nil
+}
+
+// dropIndex removes an index that is no longer wanted. An index or
+// collection that does not exist is not an error.
+func dropIndex(ctx context.Context, collection *mongo.Collection, name string) error {
+	_, err := collection.Indexes().DropOne(ctx, name)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound") {
+		return nil
+	}
+	return err
+}
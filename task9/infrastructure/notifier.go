@@ -0,0 +1,197 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"task9/config"
+	"task9/domain"
+)
+
+// NewNotifier builds the reminder notifier selected in cfg.
+func NewNotifier(cfg config.RemindersConfig) (domain.Notifier, error) {
+	switch cfg.Notifier {
+	case "log":
+		return NewLogNotifier(log.Default()), nil
+	case "file":
+		return NewFileNotifier(cfg.File), nil
+	case "webhook":
+		return NewWebhookNotifier(cfg.WebhookURL, &http.Client{Timeout: 10 * time.Second}), nil
+	case "smtp":
+		return NewSMTPNotifier(cfg.SMTP), nil
+	default:
+		return nil, fmt.Errorf("unknown reminder notifier %q", cfg.Notifier)
+	}
+}
+
+// reminderPayload is the JSON form of a reminder used by the file outbox and
+// the webhook.
+type reminderPayload struct {
+	Event   string    `json:"event"`
+	Key     string    `json:"key"`
+	Kind    string    `json:"kind"`
+	TaskID  string    `json:"task_id"`
+	Title   string    `json:"title"`
+	DueDate time.Time `json:"due_date"`
+	Window  string    `json:"window,omitempty"`
+	Message string    `json:"message"`
+}
+
+func newReminderPayload(reminder domain.Reminder) reminderPayload {
+	payload := reminderPayload{
+		Event:   "task.reminder",
+		Key:     reminder.Key,
+		Kind:    reminder.Kind,
+		TaskID:  reminder.TaskID,
+		Title:   reminder.Title,
+		DueDate: reminder.DueDate.UTC(),
+		Message: reminderMessage(reminder),
+	}
+	if reminder.Kind == domain.ReminderDueSoon {
+		payload.Window = formatWindow(reminder.Window)
+	}
+	return payload
+}
+
+func reminderMessage(reminder domain.Reminder) string {
+	due := reminder.DueDate.UTC().Format(time.RFC3339)
+	if reminder.Kind == domain.ReminderOverdue {
+		return fmt.Sprintf("Task %q was due at %s and is overdue.", reminder.Title, due)
+	}
+	return fmt.Sprintf("Task %q is due within %s, at %s.", reminder.Title, formatWindow(reminder.Window), due)
+}
+
+// formatWindow prints whole hours as "24h" rather than "24h0m0s".
+func formatWindow(window time.Duration) string {
+	if window > 0 && window%time.Hour == 0 {
+		return fmt.Sprintf("%dh", window/time.Hour)
+	}
+	return window.String()
+}
+
+// LogNotifier writes reminders to a logger, which is enough to see them in
+// development.
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, reminder domain.Reminder) error {
+	n.logger.Printf("reminder for task %s: %s", reminder.TaskID, reminderMessage(reminder))
+	return nil
+}
+
+// FileNotifier appends each reminder as a JSON line to an outbox file, for
+// another process to pick up.
+type FileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) Notify(ctx context.Context, reminder domain.Reminder) error {
+	line, err := json.Marshal(newReminderPayload(reminder))
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WebhookNotifier POSTs each reminder as JSON to a URL. Any status other
+// than 2xx counts as a failed delivery.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, client *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: client}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, reminder domain.Reminder) error {
+	body, err := json.Marshal(newReminderPayload(reminder))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// SMTPNotifier mails each reminder to a fixed list of recipients.
+type SMTPNotifier struct {
+	cfg config.SMTPConfig
+}
+
+func NewSMTPNotifier(cfg config.SMTPConfig) *SMTPNotifier {
+	return &SMTPNotifier{cfg: cfg}
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, reminder domain.Reminder) error {
+	var auth smtp.Auth
+	if n.cfg.Username != "" {
+		host, _, err := net.SplitHostPort(n.cfg.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, host)
+	}
+
+	subject := fmt.Sprintf("Reminder: %s", reminder.Title)
+	if reminder.Kind == domain.ReminderOverdue {
+		subject = fmt.Sprintf("Overdue: %s", reminder.Title)
+	}
+	message := strings.Join([]string{
+		"From: " + n.cfg.From,
+		"To: " + strings.Join(n.cfg.To, ", "),
+		"Subject: " + subject,
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		reminderMessage(reminder),
+		"",
+	}, "\r\n")
+
+	return smtp.SendMail(n.cfg.Addr, auth, n.cfg.From, n.cfg.To, []byte(message))
+}
@@ -2,14 +2,16 @@ package infrastructure
 
 import "golang.org/x/crypto/bcrypt"
 
-type BcryptHasher struct{}
+type BcryptHasher struct {
+	cost int
+}
 
-func NewBcryptHasher() *BcryptHasher {
-	return &BcryptHasher{}
+func NewBcryptHasher(cost int) *BcryptHasher {
+	return &BcryptHasher{cost: cost}
 }
 
 func (b *BcryptHasher) Hash(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
 	if err != nil {
 		return "", err
 	}
@@ -20,4 +22,3 @@ func (b *BcryptHasher) Compare(hashedPassword, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil
 }
-
@@ -0,0 +1,167 @@
+// Package repository holds the entity-based MongoDB repositories of
+// domain/repository and the typed documents they store. The server does not
+// use them: it is wired to the repositories in task9/repository, which
+// store domain.Task and domain.User instead.
+package repository
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"task9/domain/entity"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Stored documents carry the version of their layout in schema_version.
+// Documents written before the field existed have version 0 and get
+// defaults for the fields they lack while decoding.
+const (
+	taskSchemaVersion = 1
+	userSchemaVersion = 1
+)
+
+// MalformedDocuments counts the documents that could not be decoded, by
+// collection. It is published through expvar as "malformed_documents".
+var MalformedDocuments = expvar.NewMap("malformed_documents")
+
+// MalformedDocumentError reports a stored document that cannot be turned
+// into an entity. ID is empty when the document has no _id. Lookups of a
+// single document return it; lists return the documents that decode along
+// with one of these for each that does not, so callers must check the error
+// with errors.As rather than discard the list.
+type MalformedDocumentError struct {
+	Collection string
+	ID         string
+	Err        error
+}
+
+func (e *MalformedDocumentError) Error() string {
+	return fmt.Sprintf("malformed document %q in %s: %v", e.ID, e.Collection, e.Err)
+}
+
+func (e *MalformedDocumentError) Unwrap() error {
+	return e.Err
+}
+
+// malformed counts raw as malformed and describes why.
+func malformed(collection string, raw bson.Raw, err error) error {
+	MalformedDocuments.Add(collection, 1)
+	var id string
+	if value, lookupErr := raw.LookupErr("_id"); lookupErr == nil {
+		if oid, ok := value.ObjectIDOK(); ok {
+			id = oid.Hex()
+		} else if str, ok := value.StringValueOK(); ok {
+			id = str
+		} else {
+			id = value.String()
+		}
+	}
+	return &MalformedDocumentError{Collection: collection, ID: id, Err: err}
+}
+
+type taskDocument struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	SchemaVersion int                `bson:"schema_version"`
+	Title         string             `bson:"title"`
+	Description   string             `bson:"description"`
+	DueDate       time.Time          `bson:"due_date"`
+	Status        string             `bson:"status"`
+	CreatedAt     time.Time          `bson:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at"`
+}
+
+func newTaskDocument(id primitive.ObjectID, task *entity.Task) taskDocument {
+	return taskDocument{
+		ID:            id,
+		SchemaVersion: taskSchemaVersion,
+		Title:         task.Title,
+		Description:   task.Description,
+		DueDate:       task.DueDate,
+		Status:        task.Status,
+		CreatedAt:     task.CreatedAt,
+		UpdatedAt:     task.UpdatedAt,
+	}
+}
+
+// DecodeTask turns a stored task into an entity. Missing and null fields
+// get their defaults; fields of the wrong type, a missing _id and a schema
+// version newer than this build knows give a *MalformedDocumentError.
+func DecodeTask(raw bson.Raw) (*entity.Task, error) {
+	var doc taskDocument
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, malformed("tasks", raw, err)
+	}
+	if doc.ID.IsZero() {
+		return nil, malformed("tasks", raw, errors.New("_id is missing"))
+	}
+	if doc.SchemaVersion > taskSchemaVersion {
+		return nil, malformed("tasks", raw, fmt.Errorf("schema version %d is newer than %d", doc.SchemaVersion, taskSchemaVersion))
+	}
+
+	if doc.Status == "" {
+		doc.Status = "pending"
+	}
+	if doc.UpdatedAt.IsZero() {
+		doc.UpdatedAt = doc.CreatedAt
+	}
+	return &entity.Task{
+		ID:          doc.ID.Hex(),
+		Title:       doc.Title,
+		Description: doc.Description,
+		DueDate:     doc.DueDate,
+		Status:      doc.Status,
+		CreatedAt:   doc.CreatedAt,
+		UpdatedAt:   doc.UpdatedAt,
+	}, nil
+}
+
+type userDocument struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	SchemaVersion int                `bson:"schema_version"`
+	Username      string             `bson:"username"`
+	Password      string             `bson:"password"`
+	Role          string             `bson:"role"`
+}
+
+func newUserDocument(id primitive.ObjectID, user *entity.User) userDocument {
+	return userDocument{
+		ID:            id,
+		SchemaVersion: userSchemaVersion,
+		Username:      user.Username,
+		Password:      user.Password,
+		Role:          user.Role,
+	}
+}
+
+// DecodeUser turns a stored user into an entity. A user without a username
+// or password hash cannot sign in and is malformed; a missing role defaults
+// to "user".
+func DecodeUser(raw bson.Raw) (*entity.User, error) {
+	var doc userDocument
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, malformed("users", raw, err)
+	}
+	switch {
+	case doc.ID.IsZero():
+		return nil, malformed("users", raw, errors.New("_id is missing"))
+	case doc.SchemaVersion > userSchemaVersion:
+		return nil, malformed("users", raw, fmt.Errorf("schema version %d is newer than %d", doc.SchemaVersion, userSchemaVersion))
+	case doc.Username == "":
+		return nil, malformed("users", raw, errors.New("username is missing"))
+	case doc.Password == "":
+		return nil, malformed("users", raw, errors.New("password is missing"))
+	}
+
+	if doc.Role == "" {
+		doc.Role = "user"
+	}
+	return &entity.User{
+		ID:       doc.ID.Hex(),
+		Username: doc.Username,
+		Password: doc.Password,
+		Role:     doc.Role,
+	}, nil
+}
@@ -14,46 +14,37 @@ import (
 
 type taskRepository struct {
 	collection *mongo.Collection
+	timeout    time.Duration
 }
 
-func NewTaskRepository(collection *mongo.Collection) repository.TaskRepository {
+func NewTaskRepository(collection *mongo.Collection, timeout time.Duration) repository.TaskRepository {
 	return &taskRepository{
 		collection: collection,
+		timeout:    timeout,
 	}
 }
 
-func (r *taskRepository) Create(task *entity.Task) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *taskRepository) Create(ctx context.Context, task *entity.Task) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	objectID := primitive.NewObjectID()
 	task.ID = objectID.Hex()
 
-	doc := bson.M{
-		"_id":        objectID,
-		"title":      task.Title,
-		"description": task.Description,
-		"due_date":   task.DueDate,
-		"status":     task.Status,
-		"created_at": task.CreatedAt,
-		"updated_at": task.UpdatedAt,
-	}
-
-	_, err := r.collection.InsertOne(ctx, doc)
+	_, err := r.collection.InsertOne(ctx, newTaskDocument(objectID, task))
 	return err
 }
 
-func (r *taskRepository) FindByID(id string) (*entity.Task, error) {
+func (r *taskRepository) FindByID(ctx context.Context, id string) (*entity.Task, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, errors.New("invalid task ID format")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	var doc bson.M
-	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&doc)
+	raw, err := r.collection.FindOne(ctx, bson.M{"_id": objectID}).Raw()
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, errors.New("task not found")
@@ -61,11 +52,11 @@ func (r *taskRepository) FindByID(id string) (*entity.Task, error) {
 		return nil, err
 	}
 
-	return r.toEntity(doc), nil
+	return DecodeTask(raw)
 }
 
-func (r *taskRepository) FindAll() ([]*entity.Task, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *taskRepository) FindAll(ctx context.Context) ([]*entity.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	cursor, err := r.collection.Find(ctx, bson.M{})
@@ -74,35 +65,44 @@ func (r *taskRepository) FindAll() ([]*entity.Task, error) {
 	}
 	defer cursor.Close(ctx)
 
+	// Malformed documents do not hide the others: the tasks that decode are
+	// returned together with a *MalformedDocumentError for each one that
+	// does not, joined into one error.
 	var tasks []*entity.Task
+	var errs []error
 	for cursor.Next(ctx) {
-		var doc bson.M
-		if err := cursor.Decode(&doc); err != nil {
+		task, err := DecodeTask(cursor.Current)
+		if err != nil {
+			errs = append(errs, err)
 			continue
 		}
-		tasks = append(tasks, r.toEntity(doc))
+		tasks = append(tasks, task)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
 	}
 
-	return tasks, nil
+	return tasks, errors.Join(errs...)
 }
 
-func (r *taskRepository) Update(task *entity.Task) error {
+func (r *taskRepository) Update(ctx context.Context, task *entity.Task) error {
 	objectID, err := primitive.ObjectIDFromHex(task.ID)
 	if err != nil {
 		return errors.New("invalid task ID format")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	filter := bson.M{"_id": objectID}
 	update := bson.M{
 		"$set": bson.M{
-			"title":       task.Title,
-			"description": task.Description,
-			"due_date":    task.DueDate,
-			"status":      task.Status,
-			"updated_at":  task.UpdatedAt,
+			"title":          task.Title,
+			"description":    task.Description,
+			"due_date":       task.DueDate,
+			"status":         task.Status,
+			"updated_at":     task.UpdatedAt,
+			"schema_version": taskSchemaVersion,
 		},
 	}
 
@@ -118,13 +118,13 @@ func (r *taskRepository) Update(task *entity.Task) error {
 	return nil
 }
 
-func (r *taskRepository) Delete(id string) error {
+func (r *taskRepository) Delete(ctx context.Context, id string) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return errors.New("invalid task ID format")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
@@ -138,35 +138,3 @@ func (r *taskRepository) Delete(id string) error {
 
 	return nil
 }
-
-func (r *taskRepository) toEntity(doc bson.M) *entity.Task {
-	id := doc["_id"].(primitive.ObjectID).Hex()
-	
-	var dueDate, createdAt, updatedAt time.Time
-	if dt, ok := doc["due_date"].(primitive.DateTime); ok {
-		dueDate = dt.Time()
-	} else if t, ok := doc["due_date"].(time.Time); ok {
-		dueDate = t
-	}
-	if dt, ok := doc["created_at"].(primitive.DateTime); ok {
-		createdAt = dt.Time()
-	} else if t, ok := doc["created_at"].(time.Time); ok {
-		createdAt = t
-	}
-	if dt, ok := doc["updated_at"].(primitive.DateTime); ok {
-		updatedAt = dt.Time()
-	} else if t, ok := doc["updated_at"].(time.Time); ok {
-		updatedAt = t
-	}
-	
-	return &entity.Task{
-		ID:          id,
-		Title:       doc["title"].(string),
-		Description: doc["description"].(string),
-		DueDate:     dueDate,
-		Status:      doc["status"].(string),
-		CreatedAt:   createdAt,
-		UpdatedAt:   updatedAt,
-	}
-}
-
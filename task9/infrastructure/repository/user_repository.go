@@ -14,38 +14,32 @@ import (
 
 type userRepository struct {
 	collection *mongo.Collection
+	timeout    time.Duration
 }
 
-func NewUserRepository(collection *mongo.Collection) repository.UserRepository {
+func NewUserRepository(collection *mongo.Collection, timeout time.Duration) repository.UserRepository {
 	return &userRepository{
 		collection: collection,
+		timeout:    timeout,
 	}
 }
 
-func (r *userRepository) Create(user *entity.User) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	objectID := primitive.NewObjectID()
 	user.ID = objectID.Hex()
 
-	doc := bson.M{
-		"_id":      objectID,
-		"username": user.Username,
-		"password": user.Password,
-		"role":     user.Role,
-	}
-
-	_, err := r.collection.InsertOne(ctx, doc)
+	_, err := r.collection.InsertOne(ctx, newUserDocument(objectID, user))
 	return err
 }
 
-func (r *userRepository) FindByUsername(username string) (*entity.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	var doc bson.M
-	err := r.collection.FindOne(ctx, bson.M{"username": username}).Decode(&doc)
+	raw, err := r.collection.FindOne(ctx, bson.M{"username": username}).Raw()
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, errors.New("user not found")
@@ -53,20 +47,19 @@ func (r *userRepository) FindByUsername(username string) (*entity.User, error) {
 		return nil, err
 	}
 
-	return r.toEntity(doc), nil
+	return DecodeUser(raw)
 }
 
-func (r *userRepository) FindByID(id string) (*entity.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id string) (*entity.User, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, errors.New("invalid user ID format")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	var doc bson.M
-	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&doc)
+	raw, err := r.collection.FindOne(ctx, bson.M{"_id": objectID}).Raw()
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, errors.New("user not found")
@@ -74,24 +67,25 @@ func (r *userRepository) FindByID(id string) (*entity.User, error) {
 		return nil, err
 	}
 
-	return r.toEntity(doc), nil
+	return DecodeUser(raw)
 }
 
-func (r *userRepository) Update(user *entity.User) error {
+func (r *userRepository) Update(ctx context.Context, user *entity.User) error {
 	objectID, err := primitive.ObjectIDFromHex(user.ID)
 	if err != nil {
 		return errors.New("invalid user ID format")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	filter := bson.M{"_id": objectID}
 	update := bson.M{
 		"$set": bson.M{
-			"username": user.Username,
-			"password": user.Password,
-			"role":     user.Role,
+			"username":       user.Username,
+			"password":       user.Password,
+			"role":           user.Role,
+			"schema_version": userSchemaVersion,
 		},
 	}
 
@@ -107,21 +101,9 @@ func (r *userRepository) Update(user *entity.User) error {
 	return nil
 }
 
-func (r *userRepository) Count() (int64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *userRepository) Count(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	return r.collection.CountDocuments(ctx, bson.M{})
 }
-
-func (r *userRepository) toEntity(doc bson.M) *entity.User {
-	id := doc["_id"].(primitive.ObjectID).Hex()
-	return &entity.User{
-		ID:       id,
-		Username: doc["username"].(string),
-		Password: doc["password"].(string),
-		Role:     doc["role"].(string),
-	}
-}
-
-
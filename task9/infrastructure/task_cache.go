@@ -0,0 +1,112 @@
+package infrastructure
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"task9/config"
+	"task9/domain"
+)
+
+// NewTaskCache builds the task cache selected in cfg, or returns nil when
+// cfg turns the cache off.
+func NewTaskCache(cfg config.CacheConfig) (domain.TaskCache, error) {
+	if cfg.TTL <= 0 {
+		return nil, nil
+	}
+	switch cfg.Store {
+	case "memory":
+		return NewMemoryTaskCache(cfg.Size, cfg.TTL), nil
+	default:
+		return nil, fmt.Errorf("unknown cache store %q", cfg.Store)
+	}
+}
+
+// MemoryTaskCache keeps up to size tasks in process memory, each for ttl.
+// When it is full, the least recently used task makes room.
+type MemoryTaskCache struct {
+	mu   sync.Mutex
+	size int
+	ttl  time.Duration
+	// order holds the entries, most recently used first.
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type taskCacheEntry struct {
+	id      string
+	task    domain.Task
+	expires time.Time
+}
+
+func NewMemoryTaskCache(size int, ttl time.Duration) *MemoryTaskCache {
+	return &MemoryTaskCache{size: size, ttl: ttl, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (c *MemoryTaskCache) Get(ctx context.Context, id string) (domain.Task, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[id]
+	if !ok {
+		return domain.Task{}, false
+	}
+	entry := element.Value.(*taskCacheEntry)
+	if !time.Now().Before(entry.expires) {
+		c.remove(element)
+		return domain.Task{}, false
+	}
+	c.order.MoveToFront(element)
+	return entry.task, true
+}
+
+func (c *MemoryTaskCache) Set(ctx context.Context, id string, task domain.Task) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(c.ttl)
+	if element, ok := c.entries[id]; ok {
+		element.Value = &taskCacheEntry{id: id, task: task, expires: expires}
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[id] = c.order.PushFront(&taskCacheEntry{id: id, task: task, expires: expires})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *MemoryTaskCache) Delete(ctx context.Context, ids ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, id := range ids {
+		if element, ok := c.entries[id]; ok {
+			c.remove(element)
+		}
+	}
+}
+
+func (c *MemoryTaskCache) Clear(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = map[string]*list.Element{}
+}
+
+// Len returns the number of tasks held, expired ones included until they
+// are looked up or make room.
+func (c *MemoryTaskCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *MemoryTaskCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*taskCacheEntry).id)
+}
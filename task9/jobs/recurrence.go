@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"task9/domain"
+	"time"
+)
+
+type OccurrenceGenerator interface {
+	GenerateOccurrences(ctx context.Context, now time.Time) (int, error)
+}
+
+// GenerateOccurrences returns a job that creates the next occurrence of
+// recurring tasks that were completed or have become overdue, in every
+// workspace.
+func GenerateOccurrences(tasks OccurrenceGenerator) func(context.Context) error {
+	return func(ctx context.Context) error {
+		created, err := tasks.GenerateOccurrences(domain.AllWorkspaces(ctx), time.Now())
+		if created > 0 {
+			log.Printf("created %d recurring task occurrence(s)", created)
+		}
+		return err
+	}
+}
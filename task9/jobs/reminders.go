@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"task9/domain"
+	"time"
+)
+
+type ReminderSender interface {
+	SendDueReminders(ctx context.Context, now time.Time) (int, error)
+}
+
+// SendReminders returns a job that delivers due-date reminders for the tasks
+// of every workspace.
+func SendReminders(reminders ReminderSender) func(context.Context) error {
+	return func(ctx context.Context) error {
+		sent, err := reminders.SendDueReminders(domain.AllWorkspaces(ctx), time.Now())
+		if sent > 0 {
+			log.Printf("sent %d task reminder(s)", sent)
+		}
+		return err
+	}
+}
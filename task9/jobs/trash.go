@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"task9/domain"
+	"time"
+)
+
+type TrashPurger interface {
+	PurgeExpired(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+// PurgeTrash returns a job that permanently removes tasks that have been in
+// the trash for longer than retention, in every workspace.
+func PurgeTrash(tasks TrashPurger, retention time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		purged, err := tasks.PurgeExpired(domain.AllWorkspaces(ctx), retention)
+		if err != nil {
+			return err
+		}
+		if purged > 0 {
+			log.Printf("purged %d task(s) from the trash", purged)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,22 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+type WebhookDeliverer interface {
+	DeliverPending(ctx context.Context, now time.Time) (int, error)
+}
+
+// DeliverWebhooks returns a job that sends queued webhook deliveries.
+func DeliverWebhooks(webhooks WebhookDeliverer) func(context.Context) error {
+	return func(ctx context.Context) error {
+		delivered, err := webhooks.DeliverPending(ctx, time.Now())
+		if delivered > 0 {
+			log.Printf("delivered %d webhook event(s)", delivered)
+		}
+		return err
+	}
+}
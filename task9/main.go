@@ -1,37 +1,121 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"task9/config"
 	"task9/delivery"
 	"task9/infrastructure"
+	"task9/jobs"
+	"task9/repository"
+	"task9/usecase"
 )
 
 func main() {
-	mongoURI := os.Getenv("MONGODB_URI")
-	if mongoURI == "" {
-		mongoURI = "mongodb://localhost:27017"
-	}
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to the YAML configuration file")
+	flag.Parse()
 
-	dbName := os.Getenv("MONGODB_DB")
-	if dbName == "" {
-		dbName = "task_manager"
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	fmt.Println("Connecting to MongoDB...")
-	if err := infrastructure.ConnectDB(mongoURI, dbName); err != nil {
+	if err := infrastructure.ConnectDB(cfg.Database); err != nil {
 		log.Fatal("Failed to connect to MongoDB:", err)
 	}
 	defer infrastructure.DisconnectDB()
 
-	r := delivery.SetupRouter()
+	// "migrate" manages the schema and exits instead of starting the server.
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(context.Background(), flag.Args()[1:], os.Stdout); err != nil {
+			infrastructure.DisconnectDB()
+			log.Fatal(err)
+		}
+		return
+	}
+	if cfg.Database.MigrateOnStartup {
+		applied, err := infrastructure.Migrate(context.Background(), false)
+		for _, migration := range applied {
+			log.Printf("Applied migration %d: %s", migration.Version, migration.Description)
+		}
+		if err != nil {
+			log.Fatal("Failed to migrate the database:", err)
+		}
+	}
+
+	hub := usecase.NewEventHub(usecase.WithEventHistory(cfg.Stream.History), usecase.WithSubscriberBuffer(cfg.Stream.Buffer))
+	blobs, err := infrastructure.NewBlobStore(cfg.Attachments)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// The server and the background jobs share the task cache, so that the
+	// jobs' changes reach the server's readers at once.
+	taskCache, err := infrastructure.NewTaskCache(cfg.Cache)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// The jobs use the same use cases as the API, so they apply the same
+	// rules and publish the same events.
+	services := delivery.NewServices(cfg, hub, blobs, taskCache)
+	r := delivery.SetupRouter(cfg, services)
+
+	server := &http.Server{
+		Addr:         cfg.Server.Address,
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+	// Open task streams would otherwise hold up the shutdown until it times out.
+	server.RegisterOnShutdown(hub.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.Webhooks.DeliveryInterval > 0 {
+		go jobs.Every(ctx, "webhooks", cfg.Webhooks.DeliveryInterval, jobs.DeliverWebhooks(services.Webhooks))
+	}
+	if cfg.Trash.Retention > 0 {
+		go jobs.Every(ctx, "trash-purge", cfg.Trash.PurgeInterval, jobs.PurgeTrash(services.Tasks, cfg.Trash.Retention))
+	}
+	if cfg.Recurrence.GenerateInterval > 0 {
+		go jobs.Every(ctx, "recurrence", cfg.Recurrence.GenerateInterval, jobs.GenerateOccurrences(services.Tasks))
+	}
+	if cfg.Reminders.Interval > 0 {
+		notifier, err := infrastructure.NewNotifier(cfg.Reminders)
+		if err != nil {
+			log.Fatal(err)
+		}
+		reminderRepo := repository.NewReminderRepositoryMongo(infrastructure.ReminderCollection, cfg.Database.QueryTimeout)
+		reminderUseCase := usecase.NewReminderUseCase(services.TaskRepo, reminderRepo, notifier,
+			usecase.WithReminderWindows(cfg.Reminders.Windows...),
+			usecase.WithOverdueReminders(cfg.Reminders.Overdue),
+			usecase.WithOverdueLookback(cfg.Reminders.OverdueLookback),
+			usecase.WithReminderLease(cfg.Reminders.Lease),
+			usecase.WithReminderMaxAttempts(cfg.Reminders.MaxAttempts))
+		go jobs.Every(ctx, "reminders", cfg.Reminders.Interval, jobs.SendReminders(reminderUseCase))
+	}
+
+	go func() {
+		fmt.Printf("Server starting on %s\n", cfg.Server.Address)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
 
-	port := ":8080"
-	fmt.Printf("Server starting on port %s\n", port)
-	fmt.Println("API endpoints available at http://localhost:8080/tasks")
+	<-ctx.Done()
+	fmt.Println("Shutting down server...")
 
-	if err := r.Run(port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Server shutdown error:", err)
 	}
 }
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"task9/infrastructure"
+)
+
+const migrateUsage = `usage: migrate [up] [-dry-run] | migrate status
+  up        apply the pending migrations (the default)
+  -dry-run  list the migrations up would apply without applying them
+  status    list every migration and when it was applied`
+
+// runMigrate runs the migrate command with the arguments that follow it.
+func runMigrate(ctx context.Context, args []string, out io.Writer) error {
+	command := "up"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	dryRun := flags.Bool("dry-run", false, "")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch command {
+	case "up":
+		migrations, err := infrastructure.Migrate(ctx, *dryRun)
+		verb := "Applied"
+		if *dryRun {
+			verb = "Would apply"
+		}
+		for _, migration := range migrations {
+			fmt.Fprintf(out, "%s migration %d: %s\n", verb, migration.Version, migration.Description)
+		}
+		if err == nil && len(migrations) == 0 {
+			fmt.Fprintln(out, "The database is up to date.")
+		}
+		return err
+	case "status":
+		if *dryRun {
+			return errors.New(migrateUsage)
+		}
+		migrator, err := infrastructure.NewMigrator(infrastructure.Database, infrastructure.Migrations)
+		if err != nil {
+			return err
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied() {
+				state = "applied " + status.AppliedAt.UTC().Format("2006-01-02T15:04:05Z")
+			}
+			fmt.Fprintf(out, "%4d  %-28s  %s\n", status.Version, state, status.Description)
+		}
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
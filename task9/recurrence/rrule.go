@@ -0,0 +1,341 @@
+// Package recurrence evaluates the subset of RFC 5545 recurrence rules used
+// for repeating tasks: FREQ, INTERVAL, BYDAY, COUNT and UNTIL.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxPeriods bounds how many days, weeks, months or years are scanned for an
+// occurrence, so rules that rarely or never match cannot loop forever.
+const maxPeriods = 50000
+
+// WeekdayNum is a BYDAY entry such as MO, 2TU or -1FR. N is zero when the
+// entry has no ordinal.
+type WeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// Rule is a parsed recurrence rule. Occurrences are counted from a start
+// time (DTSTART in RFC 5545) that is passed to Next and Occurrences, and keep
+// its time of day and location.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []WeekdayNum
+	Count    int
+	Until    time.Time
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", with or
+// without the "RRULE:" prefix. Parts other than FREQ, INTERVAL, BYDAY, COUNT
+// and UNTIL are rejected rather than silently ignored.
+func Parse(s string) (Rule, error) {
+	s = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "RRULE:")
+	if s == "" {
+		return Rule{}, errors.New("recurrence rule is empty")
+	}
+
+	rule := Rule{Interval: 1}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			return Rule{}, fmt.Errorf("malformed rule part %q", part)
+		}
+		if seen[name] {
+			return Rule{}, fmt.Errorf("%s is given more than once", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			rule.Freq = Frequency(value)
+			if rule.Freq != Daily && rule.Freq != Weekly && rule.Freq != Monthly && rule.Freq != Yearly {
+				err = fmt.Errorf("unsupported FREQ %s", value)
+			}
+		case "INTERVAL":
+			rule.Interval, err = positive(name, value)
+		case "COUNT":
+			rule.Count, err = positive(name, value)
+		case "UNTIL":
+			rule.Until, err = parseUntil(value)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		default:
+			err = fmt.Errorf("unsupported rule part %s", name)
+		}
+		if err != nil {
+			return Rule{}, err
+		}
+	}
+
+	if rule.Freq == "" {
+		return Rule{}, errors.New("FREQ is required")
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return Rule{}, errors.New("COUNT and UNTIL cannot both be given")
+	}
+	if rule.Freq == Daily || rule.Freq == Weekly {
+		for _, day := range rule.ByDay {
+			if day.N != 0 {
+				return Rule{}, errors.New("BYDAY ordinals are only allowed with MONTHLY or YEARLY")
+			}
+		}
+	}
+	return rule, nil
+}
+
+func positive(name, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return n, nil
+}
+
+// parseUntil accepts a UTC date-time (20241231T235959Z) or a date
+// (20241231), which covers the whole day.
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("UNTIL must look like 20241231 or 20241231T235959Z")
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY entry %q", entry)
+		}
+		weekday, ok := weekdayCodes[entry[len(entry)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY entry %q", entry)
+		}
+		day := WeekdayNum{Weekday: weekday}
+		if ordinal := entry[:len(entry)-2]; ordinal != "" {
+			n, err := strconv.Atoi(ordinal)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("invalid BYDAY entry %q", entry)
+			}
+			day.N = n
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// String formats the rule in canonical form, without the "RRULE:" prefix.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+func (d WeekdayNum) String() string {
+	code := strings.ToUpper(d.Weekday.String()[:2])
+	if d.N == 0 {
+		return code
+	}
+	return strconv.Itoa(d.N) + code
+}
+
+// Next returns the first occurrence strictly after after, for a series that
+// starts at start. ok is false once the series has ended through COUNT or
+// UNTIL.
+func (r Rule) Next(start, after time.Time) (next time.Time, ok bool) {
+	r.walk(start, func(t time.Time) bool {
+		if t.After(after) {
+			next, ok = t, true
+			return false
+		}
+		return true
+	})
+	return next, ok
+}
+
+// Occurrences returns up to limit occurrences of a series starting at start.
+func (r Rule) Occurrences(start time.Time, limit int) []time.Time {
+	var occurrences []time.Time
+	if limit <= 0 {
+		return occurrences
+	}
+	r.walk(start, func(t time.Time) bool {
+		occurrences = append(occurrences, t)
+		return len(occurrences) < limit
+	})
+	return occurrences
+}
+
+// walk calls visit with each occurrence in order until visit returns false
+// or the series ends. As in RFC 5545, the start counts as the first
+// occurrence even when the rule would not produce it.
+func (r Rule) walk(start time.Time, visit func(t time.Time) bool) {
+	if (!r.Until.IsZero() && start.After(r.Until)) || !visit(start) || r.Count == 1 {
+		return
+	}
+
+	interval := max(r.Interval, 1)
+	n := 1
+	for period := 0; period < maxPeriods; period++ {
+		for _, t := range r.candidates(start, period*interval) {
+			if !t.After(start) {
+				continue
+			}
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return
+			}
+			n++
+			if !visit(t) {
+				return
+			}
+			if r.Count > 0 && n >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// candidates lists the occurrences in the period offset periods after the
+// one containing start, in chronological order.
+func (r Rule) candidates(start time.Time, offset int) []time.Time {
+	y, m, d := start.Date()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	}
+
+	switch r.Freq {
+	case Daily:
+		t := at(y, m, d+offset)
+		if len(r.ByDay) > 0 && !r.hasWeekday(t.Weekday()) {
+			return nil
+		}
+		return []time.Time{t}
+	case Weekly:
+		// Weeks start on Monday, the RFC 5545 default for WKST.
+		monday := at(y, m, d-(int(start.Weekday())+6)%7+7*offset)
+		if len(r.ByDay) == 0 {
+			return []time.Time{at(y, m, d+7*offset)}
+		}
+		var days []time.Time
+		for i := 0; i < 7; i++ {
+			if t := monday.AddDate(0, 0, i); r.hasWeekday(t.Weekday()) {
+				days = append(days, t)
+			}
+		}
+		return days
+	case Monthly:
+		first := at(y, m+time.Month(offset), 1)
+		if len(r.ByDay) == 0 {
+			return sameDay(first, d, first.Month())
+		}
+		return r.byDayIn(first, first.AddDate(0, 1, 0))
+	case Yearly:
+		first := at(y+offset, time.January, 1)
+		if len(r.ByDay) == 0 {
+			return sameDay(at(y+offset, m, 1), d, m)
+		}
+		return r.byDayIn(first, first.AddDate(1, 0, 0))
+	}
+	return nil
+}
+
+// sameDay returns day in the month of first, or nothing when the month is
+// too short, as RFC 5545 ignores invalid dates such as 31 April.
+func sameDay(first time.Time, day int, month time.Month) []time.Time {
+	t := first.AddDate(0, 0, day-1)
+	if t.Month() != month {
+		return nil
+	}
+	return []time.Time{t}
+}
+
+// byDayIn expands BYDAY within [from, to). Plain weekdays match every such
+// day; ordinals pick the nth one, counted from the end when negative.
+func (r Rule) byDayIn(from, to time.Time) []time.Time {
+	matches := map[time.Weekday][]time.Time{}
+	for t := from; t.Before(to); t = t.AddDate(0, 0, 1) {
+		matches[t.Weekday()] = append(matches[t.Weekday()], t)
+	}
+
+	seen := map[time.Time]bool{}
+	var days []time.Time
+	add := func(t time.Time) {
+		if !seen[t] {
+			seen[t] = true
+			days = append(days, t)
+		}
+	}
+	for _, day := range r.ByDay {
+		all := matches[day.Weekday]
+		switch {
+		case day.N == 0:
+			for _, t := range all {
+				add(t)
+			}
+		case day.N > 0 && day.N <= len(all):
+			add(all[day.N-1])
+		case day.N < 0 && -day.N <= len(all):
+			add(all[len(all)+day.N])
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+func (r Rule) hasWeekday(weekday time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
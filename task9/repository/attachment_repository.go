@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"task9/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AttachmentRepositoryMongo struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewAttachmentRepositoryMongo(collection *mongo.Collection, timeout time.Duration) domain.AttachmentRepository {
+	return &AttachmentRepositoryMongo{collection: collection, timeout: timeout}
+}
+
+func (r *AttachmentRepositoryMongo) Create(ctx context.Context, attachment domain.Attachment) (domain.Attachment, error) {
+	taskID, err := primitive.ObjectIDFromHex(attachment.TaskID)
+	if err != nil {
+		return domain.Attachment{}, errors.New("invalid task ID format")
+	}
+
+	attachment.WorkspaceID, err = workspaceFor(ctx, attachment.WorkspaceID)
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+	objectID := primitive.NewObjectID()
+	attachment.ID = objectID.Hex()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.collection.InsertOne(ctx, bson.M{
+		"_id":          objectID,
+		"workspace_id": attachment.WorkspaceID,
+		"task_id":      taskID,
+		"filename":     attachment.Filename,
+		"content_type": attachment.ContentType,
+		"size":         attachment.Size,
+		"blob_key":     attachment.BlobKey,
+		"uploader_id":  attachment.UploaderID,
+		"uploader":     attachment.Uploader,
+		"created_at":   attachment.CreatedAt,
+	})
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+
+	return attachment, nil
+}
+
+func (r *AttachmentRepositoryMongo) GetByID(ctx context.Context, id string) (domain.Attachment, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Attachment{}, errors.New("invalid attachment ID format")
+	}
+	filter, err := inWorkspace(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var doc bson.M
+	err = r.collection.FindOne(ctx, filter).Decode(&doc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return domain.Attachment{}, errors.New("attachment not found")
+		}
+		return domain.Attachment{}, err
+	}
+
+	return r.mapToDomain(doc), nil
+}
+
+func (r *AttachmentRepositoryMongo) ListByTask(ctx context.Context, taskID string) ([]domain.Attachment, error) {
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		return nil, errors.New("invalid task ID format")
+	}
+	filter, err := inWorkspace(ctx, bson.M{"task_id": objectID})
+	if err != nil {
+		return nil, err
+	}
+	return r.find(ctx, filter)
+}
+
+func (r *AttachmentRepositoryMongo) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid attachment ID format")
+	}
+	filter, err := inWorkspace(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("attachment not found")
+	}
+	return nil
+}
+
+func (r *AttachmentRepositoryMongo) DeleteByTasks(ctx context.Context, taskIDs []string) ([]domain.Attachment, error) {
+	objectIDs := taskObjectIDs(taskIDs)
+	if len(objectIDs) == 0 {
+		return nil, nil
+	}
+	filter, err := inWorkspace(ctx, bson.M{"task_id": bson.M{"$in": objectIDs}})
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := r.find(ctx, filter)
+	if err != nil || len(attachments) == 0 {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	ids := make([]primitive.ObjectID, 0, len(attachments))
+	for _, attachment := range attachments {
+		id, _ := primitive.ObjectIDFromHex(attachment.ID)
+		ids = append(ids, id)
+	}
+	if _, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (r *AttachmentRepositoryMongo) find(ctx context.Context, filter bson.M) ([]domain.Attachment, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	attachments := []domain.Attachment{}
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, r.mapToDomain(doc))
+	}
+
+	return attachments, cursor.Err()
+}
+
+func (r *AttachmentRepositoryMongo) mapToDomain(doc bson.M) domain.Attachment {
+	attachment := domain.Attachment{}
+	if id, ok := doc["_id"].(primitive.ObjectID); ok {
+		attachment.ID = id.Hex()
+	}
+	if workspaceID, ok := doc["workspace_id"].(string); ok {
+		attachment.WorkspaceID = workspaceID
+	}
+	if taskID, ok := doc["task_id"].(primitive.ObjectID); ok {
+		attachment.TaskID = taskID.Hex()
+	}
+	if filename, ok := doc["filename"].(string); ok {
+		attachment.Filename = filename
+	}
+	if contentType, ok := doc["content_type"].(string); ok {
+		attachment.ContentType = contentType
+	}
+	if size, ok := doc["size"].(int64); ok {
+		attachment.Size = size
+	}
+	if blobKey, ok := doc["blob_key"].(string); ok {
+		attachment.BlobKey = blobKey
+	}
+	if uploaderID, ok := doc["uploader_id"].(string); ok {
+		attachment.UploaderID = uploaderID
+	}
+	if uploader, ok := doc["uploader"].(string); ok {
+		attachment.Uploader = uploader
+	}
+	if createdAt, ok := doc["created_at"].(primitive.DateTime); ok {
+		attachment.CreatedAt = createdAt.Time()
+	}
+	return attachment
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"task9/domain"
+)
+
+// AttachmentRepositoryMemory keeps attachment metadata in process memory. It
+// is meant for tests and local development without MongoDB.
+type AttachmentRepositoryMemory struct {
+	mu          sync.RWMutex
+	attachments map[string]domain.Attachment
+	nextID      int
+}
+
+func NewAttachmentRepositoryMemory() domain.AttachmentRepository {
+	return &AttachmentRepositoryMemory{attachments: map[string]domain.Attachment{}}
+}
+
+func (r *AttachmentRepositoryMemory) Create(ctx context.Context, attachment domain.Attachment) (domain.Attachment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	workspaceID, err := workspaceFor(ctx, attachment.WorkspaceID)
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+	r.nextID++
+	attachment.ID = strconv.Itoa(r.nextID)
+	attachment.WorkspaceID = workspaceID
+	r.attachments[attachment.ID] = attachment
+	return attachment, nil
+}
+
+func (r *AttachmentRepositoryMemory) GetByID(ctx context.Context, id string) (domain.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	attachment, ok := r.attachments[id]
+	if !ok || !visibleIn(ctx, attachment.WorkspaceID) {
+		return domain.Attachment{}, errors.New("attachment not found")
+	}
+	return attachment, nil
+}
+
+func (r *AttachmentRepositoryMemory) ListByTask(ctx context.Context, taskID string) ([]domain.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.find(ctx, func(attachment domain.Attachment) bool {
+		return attachment.TaskID == taskID
+	}), nil
+}
+
+func (r *AttachmentRepositoryMemory) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attachment, ok := r.attachments[id]; !ok || !visibleIn(ctx, attachment.WorkspaceID) {
+		return errors.New("attachment not found")
+	}
+	delete(r.attachments, id)
+	return nil
+}
+
+func (r *AttachmentRepositoryMemory) DeleteByTasks(ctx context.Context, taskIDs []string) ([]domain.Attachment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := map[string]bool{}
+	for _, id := range taskIDs {
+		purged[id] = true
+	}
+	deleted := r.find(ctx, func(attachment domain.Attachment) bool {
+		return purged[attachment.TaskID]
+	})
+	for _, attachment := range deleted {
+		delete(r.attachments, attachment.ID)
+	}
+	return deleted, nil
+}
+
+// find returns the matching attachments of the workspace of ctx, oldest
+// first. The caller holds the lock.
+func (r *AttachmentRepositoryMemory) find(ctx context.Context, match func(domain.Attachment) bool) []domain.Attachment {
+	attachments := []domain.Attachment{}
+	for _, attachment := range r.attachments {
+		if visibleIn(ctx, attachment.WorkspaceID) && match(attachment) {
+			attachments = append(attachments, attachment)
+		}
+	}
+	sort.Slice(attachments, func(i, j int) bool {
+		if !attachments[i].CreatedAt.Equal(attachments[j].CreatedAt) {
+			return attachments[i].CreatedAt.Before(attachments[j].CreatedAt)
+		}
+		return idLess(attachments[i].ID, attachments[j].ID)
+	})
+	return attachments
+}
@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"task9/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CommentRepositoryMongo struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewCommentRepositoryMongo(collection *mongo.Collection, timeout time.Duration) domain.CommentRepository {
+	return &CommentRepositoryMongo{collection: collection, timeout: timeout}
+}
+
+func (r *CommentRepositoryMongo) Create(ctx context.Context, comment domain.Comment) (domain.Comment, error) {
+	taskID, err := primitive.ObjectIDFromHex(comment.TaskID)
+	if err != nil {
+		return domain.Comment{}, errors.New("invalid task ID format")
+	}
+
+	comment.WorkspaceID, err = workspaceFor(ctx, comment.WorkspaceID)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	objectID := primitive.NewObjectID()
+	comment.ID = objectID.Hex()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.collection.InsertOne(ctx, bson.M{
+		"_id":          objectID,
+		"workspace_id": comment.WorkspaceID,
+		"task_id":      taskID,
+		"author_id":    comment.AuthorID,
+		"author":       comment.Author,
+		"body":         comment.Body,
+		"created_at":   comment.CreatedAt,
+		"updated_at":   comment.UpdatedAt,
+	})
+	if err != nil {
+		return domain.Comment{}, err
+	}
+
+	return comment, nil
+}
+
+func (r *CommentRepositoryMongo) GetByID(ctx context.Context, id string) (domain.Comment, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Comment{}, errors.New("invalid comment ID format")
+	}
+
+	filter, err := inWorkspace(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return domain.Comment{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var commentDoc bson.M
+	err = r.collection.FindOne(ctx, filter).Decode(&commentDoc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return domain.Comment{}, errors.New("comment not found")
+		}
+		return domain.Comment{}, err
+	}
+
+	return r.mapToDomain(commentDoc), nil
+}
+
+func (r *CommentRepositoryMongo) ListByTask(ctx context.Context, taskID string) ([]domain.Comment, error) {
+	objectID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		return nil, errors.New("invalid task ID format")
+	}
+
+	filter, err := inWorkspace(ctx, bson.M{"task_id": objectID})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	comments := []domain.Comment{}
+	for cursor.Next(ctx) {
+		var commentDoc bson.M
+		if err := cursor.Decode(&commentDoc); err != nil {
+			continue
+		}
+		comments = append(comments, r.mapToDomain(commentDoc))
+	}
+
+	return comments, cursor.Err()
+}
+
+func (r *CommentRepositoryMongo) UpdateBody(ctx context.Context, id string, body string) (domain.Comment, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Comment{}, errors.New("invalid comment ID format")
+	}
+
+	filter, err := inWorkspace(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return domain.Comment{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := r.collection.FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{"$set": bson.M{"body": body, "updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+
+	if result.Err() != nil {
+		if result.Err() == mongo.ErrNoDocuments {
+			return domain.Comment{}, errors.New("comment not found")
+		}
+		return domain.Comment{}, result.Err()
+	}
+
+	var commentDoc bson.M
+	if err := result.Decode(&commentDoc); err != nil {
+		return domain.Comment{}, err
+	}
+
+	return r.mapToDomain(commentDoc), nil
+}
+
+func (r *CommentRepositoryMongo) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid comment ID format")
+	}
+
+	filter, err := inWorkspace(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return errors.New("comment not found")
+	}
+
+	return nil
+}
+
+func (r *CommentRepositoryMongo) CountByTasks(ctx context.Context, taskIDs []string) (map[string]int, error) {
+	counts := map[string]int{}
+	objectIDs := taskObjectIDs(taskIDs)
+	if len(objectIDs) == 0 {
+		return counts, nil
+	}
+
+	filter, err := inWorkspace(ctx, bson.M{"task_id": bson.M{"$in": objectIDs}})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": "$task_id", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			TaskID primitive.ObjectID `bson:"_id"`
+			Count  int                `bson:"count"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		counts[doc.TaskID.Hex()] = doc.Count
+	}
+
+	return counts, cursor.Err()
+}
+
+func (r *CommentRepositoryMongo) DeleteByTasks(ctx context.Context, taskIDs []string) (int64, error) {
+	objectIDs := taskObjectIDs(taskIDs)
+	if len(objectIDs) == 0 {
+		return 0, nil
+	}
+
+	filter, err := inWorkspace(ctx, bson.M{"task_id": bson.M{"$in": objectIDs}})
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+// taskObjectIDs converts task IDs for a $in filter, skipping malformed ones
+// since they cannot match any stored comment.
+func taskObjectIDs(ids []string) []primitive.ObjectID {
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objectIDs = append(objectIDs, objectID)
+		}
+	}
+	return objectIDs
+}
+
+func (r *CommentRepositoryMongo) mapToDomain(doc bson.M) domain.Comment {
+	comment := domain.Comment{}
+	if id, ok := doc["_id"].(primitive.ObjectID); ok {
+		comment.ID = id.Hex()
+	}
+	if workspaceID, ok := doc["workspace_id"].(string); ok {
+		comment.WorkspaceID = workspaceID
+	}
+	if taskID, ok := doc["task_id"].(primitive.ObjectID); ok {
+		comment.TaskID = taskID.Hex()
+	}
+	if authorID, ok := doc["author_id"].(string); ok {
+		comment.AuthorID = authorID
+	}
+	if author, ok := doc["author"].(string); ok {
+		comment.Author = author
+	}
+	if body, ok := doc["body"].(string); ok {
+		comment.Body = body
+	}
+	if createdAt, ok := doc["created_at"].(primitive.DateTime); ok {
+		comment.CreatedAt = createdAt.Time()
+	}
+	if updatedAt, ok := doc["updated_at"].(primitive.DateTime); ok {
+		comment.UpdatedAt = updatedAt.Time()
+	}
+	return comment
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"task9/domain"
+	"time"
+)
+
+// CommentRepositoryMemory keeps comments in process memory. It is meant for
+// tests and local development without MongoDB.
+type CommentRepositoryMemory struct {
+	mu       sync.RWMutex
+	comments map[string]domain.Comment
+	nextID   int
+}
+
+func NewCommentRepositoryMemory() domain.CommentRepository {
+	return &CommentRepositoryMemory{comments: map[string]domain.Comment{}}
+}
+
+func (r *CommentRepositoryMemory) Create(ctx context.Context, comment domain.Comment) (domain.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	workspaceID, err := workspaceFor(ctx, comment.WorkspaceID)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	r.nextID++
+	comment.ID = strconv.Itoa(r.nextID)
+	comment.WorkspaceID = workspaceID
+	r.comments[comment.ID] = comment
+	return comment, nil
+}
+
+func (r *CommentRepositoryMemory) GetByID(ctx context.Context, id string) (domain.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comment, ok := r.comments[id]
+	if !ok || !visibleIn(ctx, comment.WorkspaceID) {
+		return domain.Comment{}, errors.New("comment not found")
+	}
+	return comment, nil
+}
+
+func (r *CommentRepositoryMemory) ListByTask(ctx context.Context, taskID string) ([]domain.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comments := []domain.Comment{}
+	for _, comment := range r.comments {
+		if comment.TaskID == taskID && visibleIn(ctx, comment.WorkspaceID) {
+			comments = append(comments, comment)
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+		}
+		return idLess(comments[i].ID, comments[j].ID)
+	})
+	return comments, nil
+}
+
+func (r *CommentRepositoryMemory) UpdateBody(ctx context.Context, id string, body string) (domain.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	comment, ok := r.comments[id]
+	if !ok || !visibleIn(ctx, comment.WorkspaceID) {
+		return domain.Comment{}, errors.New("comment not found")
+	}
+	comment.Body = body
+	comment.UpdatedAt = time.Now()
+	r.comments[id] = comment
+	return comment, nil
+}
+
+func (r *CommentRepositoryMemory) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if comment, ok := r.comments[id]; !ok || !visibleIn(ctx, comment.WorkspaceID) {
+		return errors.New("comment not found")
+	}
+	delete(r.comments, id)
+	return nil
+}
+
+func (r *CommentRepositoryMemory) CountByTasks(ctx context.Context, taskIDs []string) (map[string]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(taskIDs))
+	for _, id := range taskIDs {
+		wanted[id] = true
+	}
+
+	counts := map[string]int{}
+	for _, comment := range r.comments {
+		if wanted[comment.TaskID] && visibleIn(ctx, comment.WorkspaceID) {
+			counts[comment.TaskID]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *CommentRepositoryMemory) DeleteByTasks(ctx context.Context, taskIDs []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wanted := make(map[string]bool, len(taskIDs))
+	for _, id := range taskIDs {
+		wanted[id] = true
+	}
+
+	var deleted int64
+	for id, comment := range r.comments {
+		if wanted[comment.TaskID] && visibleIn(ctx, comment.WorkspaceID) {
+			delete(r.comments, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// idLess orders the numeric IDs handed out by the in-memory repositories.
+func idLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
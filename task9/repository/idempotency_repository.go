@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"task9/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IdempotencyRepositoryMongo keeps one document per key. A TTL index on
+// expires_at removes old documents; until it does, they are overwritten by
+// the next reservation of their key, which gets a new token.
+type IdempotencyRepositoryMongo struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewIdempotencyRepositoryMongo(collection *mongo.Collection, timeout time.Duration) domain.IdempotencyRepository {
+	return &IdempotencyRepositoryMongo{collection: collection, timeout: timeout}
+}
+
+// Reserve upserts the record on condition that the stored one has expired.
+// When an unexpired one exists, the upsert collides with it on _id and it is
+// read back instead.
+func (r *IdempotencyRepositoryMongo) Reserve(ctx context.Context, record domain.IdempotencyRecord, now time.Time) (domain.IdempotencyRecord, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	record.Token = primitive.NewObjectID().Hex()
+	record.Status = 0
+	record.ContentType = ""
+	record.Body = nil
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": record.Key, "expires_at": bson.M{"$lte": now}},
+		bson.M{
+			"$set": bson.M{
+				"token":       record.Token,
+				"fingerprint": record.Fingerprint,
+				"status":      0,
+				"expires_at":  record.ExpiresAt,
+			},
+			"$unset": bson.M{"content_type": "", "body": ""},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err == nil {
+		return record, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return domain.IdempotencyRecord{}, false, err
+	}
+
+	var doc bson.M
+	if err := r.collection.FindOne(ctx, bson.M{"_id": record.Key}).Decode(&doc); err != nil {
+		return domain.IdempotencyRecord{}, false, err
+	}
+	return r.mapToDomain(doc), false, nil
+}
+
+func (r *IdempotencyRepositoryMongo) Complete(ctx context.Context, record domain.IdempotencyRecord) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": record.Key, "token": record.Token, "status": 0},
+		bson.M{"$set": bson.M{
+			"status":       record.Status,
+			"content_type": record.ContentType,
+			"body":         record.Body,
+			"expires_at":   record.ExpiresAt,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("idempotency reservation lost")
+	}
+	return nil
+}
+
+func (r *IdempotencyRepositoryMongo) Release(ctx context.Context, record domain.IdempotencyRecord) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": record.Key, "token": record.Token, "status": 0})
+	return err
+}
+
+func (r *IdempotencyRepositoryMongo) mapToDomain(doc bson.M) domain.IdempotencyRecord {
+	record := domain.IdempotencyRecord{}
+	if key, ok := doc["_id"].(string); ok {
+		record.Key = key
+	}
+	if token, ok := doc["token"].(string); ok {
+		record.Token = token
+	}
+	if fingerprint, ok := doc["fingerprint"].(string); ok {
+		record.Fingerprint = fingerprint
+	}
+	switch status := doc["status"].(type) {
+	case int32:
+		record.Status = int(status)
+	case int64:
+		record.Status = int(status)
+	}
+	if contentType, ok := doc["content_type"].(string); ok {
+		record.ContentType = contentType
+	}
+	if body, ok := doc["body"].(primitive.Binary); ok {
+		record.Body = body.Data
+	}
+	if expiresAt, ok := doc["expires_at"].(primitive.DateTime); ok {
+		record.ExpiresAt = expiresAt.Time()
+	}
+	return record
+}
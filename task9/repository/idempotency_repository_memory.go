@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"task9/domain"
+	"time"
+)
+
+// IdempotencyRepositoryMemory keeps idempotency records in process memory,
+// for tests and for running a single replica without MongoDB. Expired
+// records are dropped as new ones are reserved.
+type IdempotencyRepositoryMemory struct {
+	mu        sync.Mutex
+	records   map[string]domain.IdempotencyRecord
+	nextToken int
+}
+
+func NewIdempotencyRepositoryMemory() domain.IdempotencyRepository {
+	return &IdempotencyRepositoryMemory{records: map[string]domain.IdempotencyRecord{}}
+}
+
+func (r *IdempotencyRepositoryMemory) Reserve(ctx context.Context, record domain.IdempotencyRecord, now time.Time) (domain.IdempotencyRecord, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, stored := range r.records {
+		if !stored.ExpiresAt.After(now) {
+			delete(r.records, key)
+		}
+	}
+	if stored, ok := r.records[record.Key]; ok {
+		return stored, false, nil
+	}
+	r.nextToken++
+	record.Token = strconv.Itoa(r.nextToken)
+	record.Status = 0
+	record.ContentType = ""
+	record.Body = nil
+	r.records[record.Key] = record
+	return record, true, nil
+}
+
+func (r *IdempotencyRepositoryMemory) Complete(ctx context.Context, record domain.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.records[record.Key]
+	if !ok || stored.Token != record.Token || stored.Status != 0 {
+		return errors.New("idempotency reservation lost")
+	}
+	r.records[record.Key] = record
+	return nil
+}
+
+func (r *IdempotencyRepositoryMemory) Release(ctx context.Context, record domain.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.records[record.Key]; ok && stored.Token == record.Token && stored.Status == 0 {
+		delete(r.records, record.Key)
+	}
+	return nil
+}
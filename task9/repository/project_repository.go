@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"task9/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ProjectRepositoryMongo struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewProjectRepositoryMongo(collection *mongo.Collection, timeout time.Duration) domain.ProjectRepository {
+	return &ProjectRepositoryMongo{collection: collection, timeout: timeout}
+}
+
+func (r *ProjectRepositoryMongo) List(ctx context.Context) ([]domain.Project, error) {
+	filter, err := inWorkspace(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	projects := []domain.Project{}
+	for cursor.Next(ctx) {
+		var projectDoc bson.M
+		if err := cursor.Decode(&projectDoc); err != nil {
+			return nil, err
+		}
+		projects = append(projects, r.mapToDomain(projectDoc))
+	}
+
+	return projects, cursor.Err()
+}
+
+func (r *ProjectRepositoryMongo) GetByID(ctx context.Context, id string) (domain.Project, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Project{}, errors.New("invalid project ID format")
+	}
+
+	filter, err := inWorkspace(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return domain.Project{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var projectDoc bson.M
+	err = r.collection.FindOne(ctx, filter).Decode(&projectDoc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return domain.Project{}, errors.New("project not found")
+		}
+		return domain.Project{}, err
+	}
+
+	return r.mapToDomain(projectDoc), nil
+}
+
+func (r *ProjectRepositoryMongo) Create(ctx context.Context, project domain.Project) (domain.Project, error) {
+	workspaceID, err := workspaceFor(ctx, project.WorkspaceID)
+	if err != nil {
+		return domain.Project{}, err
+	}
+	objectID := primitive.NewObjectID()
+	project.ID = objectID.Hex()
+	project.WorkspaceID = workspaceID
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.collection.InsertOne(ctx, bson.M{
+		"_id":            objectID,
+		"workspace_id":   project.WorkspaceID,
+		"name":           project.Name,
+		"description":    project.Description,
+		"owner_id":       project.OwnerID,
+		"owner":          project.Owner,
+		"archived":       project.Archived,
+		"default_status": project.DefaultStatus,
+		"created_at":     project.CreatedAt,
+		"updated_at":     project.UpdatedAt,
+	})
+	if err != nil {
+		return domain.Project{}, err
+	}
+
+	return project, nil
+}
+
+func (r *ProjectRepositoryMongo) Update(ctx context.Context, id string, project domain.Project) (domain.Project, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Project{}, errors.New("invalid project ID format")
+	}
+
+	filter, err := inWorkspace(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return domain.Project{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := r.collection.FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{"$set": bson.M{
+			"name":           project.Name,
+			"description":    project.Description,
+			"archived":       project.Archived,
+			"default_status": project.DefaultStatus,
+			"updated_at":     project.UpdatedAt,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+
+	if result.Err() != nil {
+		if result.Err() == mongo.ErrNoDocuments {
+			return domain.Project{}, errors.New("project not found")
+		}
+		return domain.Project{}, result.Err()
+	}
+
+	var projectDoc bson.M
+	if err := result.Decode(&projectDoc); err != nil {
+		return domain.Project{}, err
+	}
+
+	return r.mapToDomain(projectDoc), nil
+}
+
+func (r *ProjectRepositoryMongo) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid project ID format")
+	}
+
+	filter, err := inWorkspace(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return errors.New("project not found")
+	}
+
+	return nil
+}
+
+func (r *ProjectRepositoryMongo) mapToDomain(doc bson.M) domain.Project {
+	project := domain.Project{}
+	if id, ok := doc["_id"].(primitive.ObjectID); ok {
+		project.ID = id.Hex()
+	}
+	if workspaceID, ok := doc["workspace_id"].(string); ok {
+		project.WorkspaceID = workspaceID
+	}
+	if name, ok := doc["name"].(string); ok {
+		project.Name = name
+	}
+	if description, ok := doc["description"].(string); ok {
+		project.Description = description
+	}
+	if ownerID, ok := doc["owner_id"].(string); ok {
+		project.OwnerID = ownerID
+	}
+	if owner, ok := doc["owner"].(string); ok {
+		project.Owner = owner
+	}
+	if archived, ok := doc["archived"].(bool); ok {
+		project.Archived = archived
+	}
+	if defaultStatus, ok := doc["default_status"].(string); ok {
+		project.DefaultStatus = defaultStatus
+	}
+	if createdAt, ok := doc["created_at"].(primitive.DateTime); ok {
+		project.CreatedAt = createdAt.Time()
+	}
+	if updatedAt, ok := doc["updated_at"].(primitive.DateTime); ok {
+		project.UpdatedAt = updatedAt.Time()
+	}
+	return project
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"task9/domain"
+)
+
+// ProjectRepositoryMemory keeps projects in process memory. It is meant for
+// tests and local development without MongoDB.
+type ProjectRepositoryMemory struct {
+	mu       sync.RWMutex
+	projects map[string]domain.Project
+	nextID   int
+}
+
+func NewProjectRepositoryMemory() domain.ProjectRepository {
+	return &ProjectRepositoryMemory{projects: map[string]domain.Project{}}
+}
+
+func (r *ProjectRepositoryMemory) List(ctx context.Context) ([]domain.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	projects := []domain.Project{}
+	for _, project := range r.projects {
+		if visibleIn(ctx, project.WorkspaceID) {
+			projects = append(projects, project)
+		}
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].Name != projects[j].Name {
+			return projects[i].Name < projects[j].Name
+		}
+		return idLess(projects[i].ID, projects[j].ID)
+	})
+	return projects, nil
+}
+
+func (r *ProjectRepositoryMemory) GetByID(ctx context.Context, id string) (domain.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.projects[id]
+	if !ok || !visibleIn(ctx, project.WorkspaceID) {
+		return domain.Project{}, errors.New("project not found")
+	}
+	return project, nil
+}
+
+func (r *ProjectRepositoryMemory) Create(ctx context.Context, project domain.Project) (domain.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	workspaceID, err := workspaceFor(ctx, project.WorkspaceID)
+	if err != nil {
+		return domain.Project{}, err
+	}
+	r.nextID++
+	project.ID = strconv.Itoa(r.nextID)
+	project.WorkspaceID = workspaceID
+	r.projects[project.ID] = project
+	return project, nil
+}
+
+func (r *ProjectRepositoryMemory) Update(ctx context.Context, id string, project domain.Project) (domain.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.projects[id]
+	if !ok || !visibleIn(ctx, stored.WorkspaceID) {
+		return domain.Project{}, errors.New("project not found")
+	}
+	stored.Name = project.Name
+	stored.Description = project.Description
+	stored.Archived = project.Archived
+	stored.DefaultStatus = project.DefaultStatus
+	stored.UpdatedAt = project.UpdatedAt
+	r.projects[id] = stored
+	return stored, nil
+}
+
+func (r *ProjectRepositoryMemory) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if project, ok := r.projects[id]; !ok || !visibleIn(ctx, project.WorkspaceID) {
+		return errors.New("project not found")
+	}
+	delete(r.projects, id)
+	return nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"task9/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReminderRepositoryMongo keeps one document per reminder, keyed by
+// Reminder.Key. A document without sent_at is a delivery in progress or one
+// that failed, and is owned by whoever holds an unexpired lease on it.
+type ReminderRepositoryMongo struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewReminderRepositoryMongo(collection *mongo.Collection, timeout time.Duration) domain.ReminderRepository {
+	return &ReminderRepositoryMongo{collection: collection, timeout: timeout}
+}
+
+// Claim upserts the reminder on condition that it is unsent, has attempts
+// left and its lease is free or already ours. When the document exists but
+// does not match, the upsert collides with it on _id, which means someone
+// else has it or it is done.
+func (r *ReminderRepositoryMongo) Claim(ctx context.Context, reminder domain.Reminder, owner string, now time.Time, lease time.Duration, maxAttempts int) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":     reminder.Key,
+		"sent_at": nil,
+		"$or": bson.A{
+			bson.M{"lease_until": bson.M{"$lt": now}},
+			bson.M{"owner": owner},
+		},
+	}
+	if maxAttempts > 0 {
+		filter["attempts"] = bson.M{"$not": bson.M{"$gte": maxAttempts}}
+	}
+	_, err := r.collection.UpdateOne(ctx,
+		filter,
+		bson.M{
+			"$set": bson.M{"owner": owner, "lease_until": now.Add(lease)},
+			"$setOnInsert": bson.M{
+				"task_id":  reminder.TaskID,
+				"kind":     reminder.Kind,
+				"window":   reminder.Window.String(),
+				"due_date": reminder.DueDate,
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *ReminderRepositoryMongo) MarkSent(ctx context.Context, key, owner string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": key, "owner": owner},
+		bson.M{"$set": bson.M{"sent_at": at}, "$unset": bson.M{"lease_until": "", "last_error": ""}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("reminder lease lost")
+	}
+	return nil
+}
+
+func (r *ReminderRepositoryMongo) Release(ctx context.Context, key, owner string, cause error) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	set := bson.M{"lease_until": time.Time{}}
+	if cause != nil {
+		set["last_error"] = cause.Error()
+	}
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": key, "owner": owner, "sent_at": nil},
+		bson.M{"$set": set, "$inc": bson.M{"attempts": 1}},
+	)
+	return err
+}
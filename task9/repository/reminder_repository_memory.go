@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"task9/domain"
+	"time"
+)
+
+// ReminderRepositoryMemory tracks reminder deliveries in process memory,
+// with the same lease rules as ReminderRepositoryMongo. It is meant for tests
+// and local development without MongoDB.
+type ReminderRepositoryMemory struct {
+	mu        sync.Mutex
+	reminders map[string]*memoryReminder
+}
+
+type memoryReminder struct {
+	owner      string
+	leaseUntil time.Time
+	sentAt     time.Time
+	attempts   int
+}
+
+func NewReminderRepositoryMemory() domain.ReminderRepository {
+	return &ReminderRepositoryMemory{reminders: map[string]*memoryReminder{}}
+}
+
+func (r *ReminderRepositoryMemory) Claim(ctx context.Context, reminder domain.Reminder, owner string, now time.Time, lease time.Duration, maxAttempts int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.reminders[reminder.Key]
+	if !ok {
+		stored = &memoryReminder{}
+		r.reminders[reminder.Key] = stored
+	}
+	if !stored.sentAt.IsZero() || (maxAttempts > 0 && stored.attempts >= maxAttempts) ||
+		(stored.owner != owner && !stored.leaseUntil.Before(now)) {
+		return false, nil
+	}
+	stored.owner = owner
+	stored.leaseUntil = now.Add(lease)
+	return true, nil
+}
+
+func (r *ReminderRepositoryMemory) MarkSent(ctx context.Context, key, owner string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.reminders[key]
+	if !ok || stored.owner != owner {
+		return errors.New("reminder lease lost")
+	}
+	stored.sentAt = at
+	stored.leaseUntil = time.Time{}
+	return nil
+}
+
+func (r *ReminderRepositoryMemory) Release(ctx context.Context, key, owner string, cause error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.reminders[key]; ok && stored.owner == owner && stored.sentAt.IsZero() {
+		stored.leaseUntil = time.Time{}
+		stored.attempts++
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"task9/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TagRepositoryMongo struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewTagRepositoryMongo(collection *mongo.Collection, timeout time.Duration) domain.TagRepository {
+	return &TagRepositoryMongo{collection: collection, timeout: timeout}
+}
+
+func (r *TagRepositoryMongo) List(ctx context.Context) ([]domain.Tag, error) {
+	filter, err := inWorkspace(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tags := []domain.Tag{}
+	for cursor.Next(ctx) {
+		var tagDoc bson.M
+		if err := cursor.Decode(&tagDoc); err != nil {
+			return nil, err
+		}
+		tags = append(tags, r.mapToDomain(tagDoc))
+	}
+
+	return tags, cursor.Err()
+}
+
+func (r *TagRepositoryMongo) GetByName(ctx context.Context, name string) (domain.Tag, error) {
+	filter, err := inWorkspace(ctx, bson.M{"name": name})
+	if err != nil {
+		return domain.Tag{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var tagDoc bson.M
+	err = r.collection.FindOne(ctx, filter).Decode(&tagDoc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return domain.Tag{}, errors.New("tag not found")
+		}
+		return domain.Tag{}, err
+	}
+
+	return r.mapToDomain(tagDoc), nil
+}
+
+// Create adds tag to the catalogue of the workspace of ctx. Tags have no
+// workspace of their own to fall back on, so ctx must be scoped to one.
+func (r *TagRepositoryMongo) Create(ctx context.Context, tag domain.Tag) (domain.Tag, error) {
+	workspaceID, err := workspaceFor(ctx, "")
+	if err == nil && workspaceID == "" {
+		err = domain.ErrNoWorkspace
+	}
+	if err != nil {
+		return domain.Tag{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.collection.InsertOne(ctx, r.mapToDocument(workspaceID, tag))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.Tag{}, errors.New("tag already exists")
+		}
+		return domain.Tag{}, err
+	}
+
+	return tag, nil
+}
+
+func (r *TagRepositoryMongo) Update(ctx context.Context, name string, tag domain.Tag) (domain.Tag, error) {
+	filter, err := inWorkspace(ctx, bson.M{"name": name})
+	if err != nil {
+		return domain.Tag{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := r.collection.FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{"$set": bson.M{
+			"name":        tag.Name,
+			"color":       tag.Color,
+			"description": tag.Description,
+			"updated_at":  tag.UpdatedAt,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+
+	if result.Err() != nil {
+		if result.Err() == mongo.ErrNoDocuments {
+			return domain.Tag{}, errors.New("tag not found")
+		}
+		if mongo.IsDuplicateKeyError(result.Err()) {
+			return domain.Tag{}, errors.New("tag already exists")
+		}
+		return domain.Tag{}, result.Err()
+	}
+
+	var tagDoc bson.M
+	if err := result.Decode(&tagDoc); err != nil {
+		return domain.Tag{}, err
+	}
+
+	return r.mapToDomain(tagDoc), nil
+}
+
+func (r *TagRepositoryMongo) Delete(ctx context.Context, name string) error {
+	filter, err := inWorkspace(ctx, bson.M{"name": name})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return errors.New("tag not found")
+	}
+
+	return nil
+}
+
+func (r *TagRepositoryMongo) mapToDomain(doc bson.M) domain.Tag {
+	tag := domain.Tag{}
+	if name, ok := doc["name"].(string); ok {
+		tag.Name = name
+	}
+	if color, ok := doc["color"].(string); ok {
+		tag.Color = color
+	}
+	if description, ok := doc["description"].(string); ok {
+		tag.Description = description
+	}
+	if createdAt, ok := doc["created_at"].(primitive.DateTime); ok {
+		tag.CreatedAt = createdAt.Time()
+	}
+	if updatedAt, ok := doc["updated_at"].(primitive.DateTime); ok {
+		tag.UpdatedAt = updatedAt.Time()
+	}
+	return tag
+}
+
+func (r *TagRepositoryMongo) mapToDocument(workspaceID string, tag domain.Tag) bson.M {
+	return bson.M{
+		"workspace_id": workspaceID,
+		"name":         tag.Name,
+		"color":        tag.Color,
+		"description":  tag.Description,
+		"created_at":   tag.CreatedAt,
+		"updated_at":   tag.UpdatedAt,
+	}
+}
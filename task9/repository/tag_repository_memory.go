@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"task9/domain"
+)
+
+// TagRepositoryMemory keeps the tag catalogues in process memory, one per
+// workspace. It is meant for tests and local development without MongoDB.
+type TagRepositoryMemory struct {
+	mu   sync.RWMutex
+	tags map[string]map[string]domain.Tag
+}
+
+func NewTagRepositoryMemory() domain.TagRepository {
+	return &TagRepositoryMemory{tags: map[string]map[string]domain.Tag{}}
+}
+
+// catalogue returns the tags of the workspace of ctx, creating the map when
+// create is set. Like TagRepositoryMongo, it needs a ctx scoped to a
+// workspace. The caller must hold the lock.
+func (r *TagRepositoryMemory) catalogue(ctx context.Context, create bool) (map[string]domain.Tag, error) {
+	workspaceID, err := workspaceFor(ctx, "")
+	if err == nil && workspaceID == "" {
+		err = domain.ErrNoWorkspace
+	}
+	if err != nil {
+		return nil, err
+	}
+	tags := r.tags[workspaceID]
+	if tags == nil && create {
+		tags = map[string]domain.Tag{}
+		r.tags[workspaceID] = tags
+	}
+	return tags, nil
+}
+
+func (r *TagRepositoryMemory) List(ctx context.Context) ([]domain.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	catalogue, err := r.catalogue(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]domain.Tag, 0, len(catalogue))
+	for _, tag := range catalogue {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+func (r *TagRepositoryMemory) GetByName(ctx context.Context, name string) (domain.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	catalogue, err := r.catalogue(ctx, false)
+	if err != nil {
+		return domain.Tag{}, err
+	}
+	tag, ok := catalogue[name]
+	if !ok {
+		return domain.Tag{}, errors.New("tag not found")
+	}
+	return tag, nil
+}
+
+func (r *TagRepositoryMemory) Create(ctx context.Context, tag domain.Tag) (domain.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	catalogue, err := r.catalogue(ctx, true)
+	if err != nil {
+		return domain.Tag{}, err
+	}
+	if _, ok := catalogue[tag.Name]; ok {
+		return domain.Tag{}, errors.New("tag already exists")
+	}
+	catalogue[tag.Name] = tag
+	return tag, nil
+}
+
+func (r *TagRepositoryMemory) Update(ctx context.Context, name string, tag domain.Tag) (domain.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	catalogue, err := r.catalogue(ctx, false)
+	if err != nil {
+		return domain.Tag{}, err
+	}
+	current, ok := catalogue[name]
+	if !ok {
+		return domain.Tag{}, errors.New("tag not found")
+	}
+	if _, ok := catalogue[tag.Name]; ok && tag.Name != name {
+		return domain.Tag{}, errors.New("tag already exists")
+	}
+
+	tag.CreatedAt = current.CreatedAt
+	delete(catalogue, name)
+	catalogue[tag.Name] = tag
+	return tag, nil
+}
+
+func (r *TagRepositoryMemory) Delete(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	catalogue, err := r.catalogue(ctx, false)
+	if err != nil {
+		return err
+	}
+	if _, ok := catalogue[name]; !ok {
+		return errors.New("tag not found")
+	}
+	delete(catalogue, name)
+	return nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"strconv"
 	"task9/domain"
 	"time"
 
@@ -13,18 +14,163 @@ import (
 )
 
 type TaskRepositoryMongo struct {
-	collection *mongo.Collection
+	collection   *mongo.Collection
+	timeout      time.Duration
+	historyLimit int
 }
 
-func NewTaskRepositoryMongo(collection *mongo.Collection) domain.TaskRepository {
-	return &TaskRepositoryMongo{collection: collection}
+// NewTaskRepositoryMongo keeps at most historyLimit entries in the history
+// embedded in each task, dropping the oldest, so that a task changed often
+// does not grow towards the document size limit. Zero keeps every entry.
+func NewTaskRepositoryMongo(collection *mongo.Collection, timeout time.Duration, historyLimit int) domain.TaskRepository {
+	return &TaskRepositoryMongo{collection: collection, timeout: timeout, historyLimit: historyLimit}
 }
 
-func (r *TaskRepositoryMongo) GetAll() ([]domain.Task, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *TaskRepositoryMongo) GetAll(ctx context.Context) ([]domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	cursor, err := r.collection.Find(ctx, bson.M{})
+	return r.find(ctx, notDeleted(bson.M{}))
+}
+
+func (r *TaskRepositoryMongo) Find(ctx context.Context, filter domain.TaskFilter) ([]domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.find(ctx, notDeleted(mapFilter(filter)))
+}
+
+// Each applies the repository timeout to every round trip rather than to
+// the whole iteration, since fn may take a while, e.g. to write each task
+// to a slow client.
+func (r *TaskRepositoryMongo) Each(ctx context.Context, filter domain.TaskFilter, fn func(domain.Task) error) error {
+	scoped, err := inWorkspace(ctx, notDeleted(mapFilter(filter)))
+	if err != nil {
+		return err
+	}
+	findCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	cursor, err := r.collection.Find(findCtx, scoped,
+		options.Find().SetProjection(taskProjection).SetSort(bson.M{"_id": 1}))
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for {
+		nextCtx, cancel := context.WithTimeout(ctx, r.timeout)
+		ok := cursor.Next(nextCtx)
+		cancel()
+		if !ok {
+			return cursor.Err()
+		}
+
+		var taskDoc bson.M
+		if err := cursor.Decode(&taskDoc); err != nil {
+			return err
+		}
+		if err := fn(r.mapToDomain(taskDoc)); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *TaskRepositoryMongo) GetDeleted(ctx context.Context) ([]domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.find(ctx, bson.M{"deleted_at": bson.M{"$ne": nil}})
+}
+
+func (r *TaskRepositoryMongo) GetDeletedByID(ctx context.Context, id string) (domain.Task, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Task{}, errors.New("invalid task ID format")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tasks, err := r.find(ctx, bson.M{"_id": objectID, "deleted_at": bson.M{"$ne": nil}})
+	if err != nil {
+		return domain.Task{}, err
+	}
+	if len(tasks) == 0 {
+		return domain.Task{}, errors.New("task not found")
+	}
+	return tasks[0], nil
+}
+
+func (r *TaskRepositoryMongo) GetByIDs(ctx context.Context, ids []string) ([]domain.Task, error) {
+	var objectIDs []primitive.ObjectID
+	for _, id := range ids {
+		if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objectIDs = append(objectIDs, objectID)
+		}
+	}
+	if len(objectIDs) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.find(ctx, notDeleted(bson.M{"_id": bson.M{"$in": objectIDs}}))
+}
+
+func (r *TaskRepositoryMongo) GetSubtasks(ctx context.Context, parentIDs []string) ([]domain.Task, error) {
+	if len(parentIDs) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.find(ctx, notDeleted(bson.M{"parent_id": bson.M{"$in": parentIDs}}))
+}
+
+func (r *TaskRepositoryMongo) GetBlockedBy(ctx context.Context, blockerIDs []string) ([]domain.Task, error) {
+	if len(blockerIDs) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.find(ctx, notDeleted(bson.M{"blocked_by": bson.M{"$in": blockerIDs}}))
+}
+
+func (r *TaskRepositoryMongo) GetDueBetween(ctx context.Context, from, to time.Time) ([]domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.find(ctx, notDeleted(bson.M{
+		"status":   bson.M{"$ne": "completed"},
+		"due_date": bson.M{"$gt": time.Time{}, "$gte": from, "$lt": to},
+	}))
+}
+
+func (r *TaskRepositoryMongo) GetRecurring(ctx context.Context, dueBefore time.Time) ([]domain.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.find(ctx, notDeleted(bson.M{
+		"recurrence.rule":    bson.M{"$nin": bson.A{nil, ""}},
+		"recurrence.next_id": bson.M{"$in": bson.A{nil, ""}},
+		"$or": bson.A{
+			bson.M{"status": "completed"},
+			bson.M{"due_date": bson.M{"$lt": dueBefore}},
+		},
+	}))
+}
+
+// find returns the tasks matching filter in the workspace of ctx.
+func (r *TaskRepositoryMongo) find(ctx context.Context, filter bson.M) ([]domain.Task, error) {
+	filter, err := inWorkspace(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(taskProjection))
 	if err != nil {
 		return nil, err
 	}
@@ -40,20 +186,46 @@ func (r *TaskRepositoryMongo) GetAll() ([]domain.Task, error) {
 		tasks = append(tasks, task)
 	}
 
-	return tasks, nil
+	return r.withSubtaskCounts(ctx, tasks)
 }
 
-func (r *TaskRepositoryMongo) GetByID(id string) (domain.Task, error) {
+func (r *TaskRepositoryMongo) GetByID(ctx context.Context, id string) (domain.Task, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return domain.Task{}, errors.New("invalid task ID format")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	filter, err := inWorkspace(ctx, notDeleted(bson.M{"_id": objectID}))
+	if err != nil {
+		return domain.Task{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var taskDoc bson.M
+	err = r.collection.FindOne(ctx, filter, options.FindOne().SetProjection(taskProjection)).Decode(&taskDoc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return domain.Task{}, errors.New("task not found")
+		}
+		return domain.Task{}, err
+	}
+
+	return r.withSubtaskCount(ctx, r.mapToDomain(taskDoc))
+}
+
+func (r *TaskRepositoryMongo) GetByExternalID(ctx context.Context, externalID string) (domain.Task, error) {
+	filter, err := inWorkspace(ctx, bson.M{"external_id": externalID})
+	if err != nil {
+		return domain.Task{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	var taskDoc bson.M
-	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&taskDoc)
+	err = r.collection.FindOne(ctx, filter, options.FindOne().SetProjection(taskProjection)).Decode(&taskDoc)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return domain.Task{}, errors.New("task not found")
@@ -61,64 +233,115 @@ func (r *TaskRepositoryMongo) GetByID(id string) (domain.Task, error) {
 		return domain.Task{}, err
 	}
 
-	return r.mapToDomain(taskDoc), nil
+	return r.withSubtaskCount(ctx, r.mapToDomain(taskDoc))
 }
 
-func (r *TaskRepositoryMongo) Create(task domain.Task) (domain.Task, error) {
+func (r *TaskRepositoryMongo) Create(ctx context.Context, task domain.Task, entry domain.HistoryEntry) (domain.Task, error) {
+	workspaceID, err := workspaceFor(ctx, task.WorkspaceID)
+	if err != nil {
+		return domain.Task{}, err
+	}
 	objectID := primitive.NewObjectID()
 	task.ID = objectID.Hex()
+	task.WorkspaceID = workspaceID
+	task = startSeries(task)
 
 	doc := r.mapToDocument(task)
 	doc["_id"] = objectID
+	doc["history"] = bson.A{r.mapHistoryToDocument(entry)}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	_, err := r.collection.InsertOne(ctx, doc)
+	_, err = r.collection.InsertOne(ctx, doc)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.Task{}, domain.ErrExternalIDTaken
+		}
 		return domain.Task{}, err
 	}
 
 	return task, nil
 }
 
-func (r *TaskRepositoryMongo) Update(id string, task domain.Task) (domain.Task, error) {
-	objectID, err := primitive.ObjectIDFromHex(id)
+// CreateOccurrence claims previousID by setting its next_id before inserting
+// the new task, so two generators can never both continue the same series.
+// The claim is released again if the insert fails.
+func (r *TaskRepositoryMongo) CreateOccurrence(ctx context.Context, previousID string, task domain.Task, entry domain.HistoryEntry) (domain.Task, error) {
+	previousObjectID, err := primitive.ObjectIDFromHex(previousID)
 	if err != nil {
 		return domain.Task{}, errors.New("invalid task ID format")
 	}
+	task.WorkspaceID, err = workspaceFor(ctx, task.WorkspaceID)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	filter, err := inWorkspace(ctx, notDeleted(bson.M{
+		"_id":                previousObjectID,
+		"recurrence.rule":    bson.M{"$nin": bson.A{nil, ""}},
+		"recurrence.next_id": bson.M{"$in": bson.A{nil, ""}},
+	}))
+	if err != nil {
+		return domain.Task{}, err
+	}
+	objectID := primitive.NewObjectID()
+	task.ID = objectID.Hex()
+	task = startSeries(task)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	update := bson.M{}
-	if task.Title != "" {
-		update["title"] = task.Title
+	claim, err := r.collection.UpdateOne(ctx,
+		filter,
+		bson.M{"$set": bson.M{"recurrence.next_id": task.ID}},
+	)
+	if err != nil {
+		return domain.Task{}, err
 	}
-	if task.Description != "" {
-		update["description"] = task.Description
+	if claim.MatchedCount == 0 {
+		return domain.Task{}, r.missingOrConflict(ctx, previousObjectID)
 	}
-	if !task.DueDate.IsZero() {
-		update["due_date"] = task.DueDate
+
+	doc := r.mapToDocument(task)
+	doc["_id"] = objectID
+	doc["history"] = bson.A{r.mapHistoryToDocument(entry)}
+	if _, err := r.collection.InsertOne(ctx, doc); err != nil {
+		_, _ = r.collection.UpdateOne(ctx,
+			bson.M{"_id": previousObjectID, "recurrence.next_id": task.ID},
+			bson.M{"$unset": bson.M{"recurrence.next_id": ""}},
+		)
+		return domain.Task{}, err
 	}
-	if task.Status != "" {
-		update["status"] = task.Status
+
+	return task, nil
+}
+
+func (r *TaskRepositoryMongo) Update(ctx context.Context, id string, task domain.Task, entry domain.HistoryEntry) (domain.Task, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Task{}, errors.New("invalid task ID format")
 	}
-	update["updated_at"] = time.Now()
+	filter, err := inWorkspace(ctx, notDeleted(bson.M{"_id": objectID}))
+	if err != nil {
+		return domain.Task{}, err
+	}
+	filter = unchanged(filter, entry.Changes)
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
 
-	filter := bson.M{"_id": objectID}
-	updateDoc := bson.M{"$set": update}
+	updateDoc := r.withHistory(bson.M{"$set": r.mapToUpdate(task)}, entry)
 
 	result := r.collection.FindOneAndUpdate(
 		ctx,
 		filter,
 		updateDoc,
-		options.FindOneAndUpdate().SetReturnDocument(options.After),
+		options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(taskProjection),
 	)
 
 	if result.Err() != nil {
 		if result.Err() == mongo.ErrNoDocuments {
-			return domain.Task{}, errors.New("task not found")
+			return domain.Task{}, r.missingOrConflict(ctx, objectID)
 		}
 		return domain.Task{}, result.Err()
 	}
@@ -128,71 +351,1205 @@ func (r *TaskRepositoryMongo) Update(id string, task domain.Task) (domain.Task,
 		return domain.Task{}, err
 	}
 
-	return r.mapToDomain(taskDoc), nil
+	return r.withSubtaskCount(ctx, r.mapToDomain(taskDoc))
 }
 
-func (r *TaskRepositoryMongo) Delete(id string) error {
+func (r *TaskRepositoryMongo) Delete(ctx context.Context, id string, entry domain.HistoryEntry) error {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return errors.New("invalid task ID format")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	filter, err := inWorkspace(ctx, notDeleted(bson.M{"_id": objectID}))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	result, err := r.collection.UpdateOne(ctx, filter, r.withHistory(bson.M{"$set": trashFields(entry)}, entry))
 	if err != nil {
 		return err
 	}
 
-	if result.DeletedCount == 0 {
+	if result.MatchedCount == 0 {
 		return errors.New("task not found")
 	}
 
 	return nil
 }
 
-func (r *TaskRepositoryMongo) mapToDomain(doc bson.M) domain.Task {
-	task := domain.Task{}
-	if id, ok := doc["_id"].(primitive.ObjectID); ok {
-		task.ID = id.Hex()
+func (r *TaskRepositoryMongo) Restore(ctx context.Context, id string, entry domain.HistoryEntry) (domain.Task, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Task{}, errors.New("invalid task ID format")
 	}
-	if title, ok := doc["title"].(string); ok {
-		task.Title = title
+
+	filter, err := inWorkspace(ctx, bson.M{"_id": objectID, "deleted_at": bson.M{"$ne": nil}})
+	if err != nil {
+		return domain.Task{}, err
 	}
-	if desc, ok := doc["description"].(string); ok {
-		task.Description = desc
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := r.collection.FindOneAndUpdate(
+		ctx,
+		filter,
+		r.withHistory(bson.M{
+			"$unset": bson.M{"deleted_at": "", "deleted_by": ""},
+			"$set":   bson.M{"updated_at": entry.At},
+		}, entry),
+		options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(taskProjection),
+	)
+
+	if result.Err() != nil {
+		if result.Err() == mongo.ErrNoDocuments {
+			return domain.Task{}, errors.New("task not found in trash")
+		}
+		return domain.Task{}, result.Err()
 	}
-	if dueDate, ok := doc["due_date"].(primitive.DateTime); ok {
-		task.DueDate = dueDate.Time()
-	} else if dueDate, ok := doc["due_date"].(time.Time); ok {
-		task.DueDate = dueDate
+
+	var taskDoc bson.M
+	if err := result.Decode(&taskDoc); err != nil {
+		return domain.Task{}, err
 	}
-	if status, ok := doc["status"].(string); ok {
-		task.Status = status
+
+	return r.withSubtaskCount(ctx, r.mapToDomain(taskDoc))
+}
+
+func (r *TaskRepositoryMongo) GetHistory(ctx context.Context, id string, offset, limit int) ([]domain.HistoryEntry, int, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, 0, errors.New("invalid task ID format")
 	}
-	if createdAt, ok := doc["created_at"].(primitive.DateTime); ok {
-		task.CreatedAt = createdAt.Time()
-	} else if createdAt, ok := doc["created_at"].(time.Time); ok {
-		task.CreatedAt = createdAt
+
+	filter, err := inWorkspace(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return nil, 0, err
 	}
-	if updatedAt, ok := doc["updated_at"].(primitive.DateTime); ok {
-		task.UpdatedAt = updatedAt.Time()
-	} else if updatedAt, ok := doc["updated_at"].(time.Time); ok {
-		task.UpdatedAt = updatedAt
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	history := bson.M{"$ifNull": bson.A{"$history", bson.A{}}}
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$project", Value: bson.M{
+			"total":   bson.M{"$size": history},
+			"history": bson.M{"$slice": bson.A{history, offset, limit}},
+		}}},
+	})
+	if err != nil {
+		return nil, 0, err
 	}
-	return task
+	defer cursor.Close(ctx)
+
+	if !cursor.Next(ctx) {
+		if err := cursor.Err(); err != nil {
+			return nil, 0, err
+		}
+		return nil, 0, errors.New("task not found")
+	}
+
+	var doc struct {
+		Total   int      `bson:"total"`
+		History []bson.M `bson:"history"`
+	}
+	if err := cursor.Decode(&doc); err != nil {
+		return nil, 0, err
+	}
+
+	entries := make([]domain.HistoryEntry, 0, len(doc.History))
+	for _, entryDoc := range doc.History {
+		entries = append(entries, r.mapHistoryToDomain(entryDoc))
+	}
+
+	return entries, doc.Total, nil
 }
 
-func (r *TaskRepositoryMongo) mapToDocument(task domain.Task) bson.M {
-	doc := bson.M{
-		"title":       task.Title,
-		"description": task.Description,
-		"due_date":    task.DueDate,
-		"status":      task.Status,
-		"created_at":  task.CreatedAt,
-		"updated_at":  task.UpdatedAt,
+func (r *TaskRepositoryMongo) Purge(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid task ID format")
 	}
-	return doc
+
+	filter, err := inWorkspace(ctx, bson.M{"_id": objectID, "deleted_at": bson.M{"$ne": nil}})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return errors.New("task not found in trash")
+	}
+
+	return nil
+}
+
+// PurgeDeleted removes the expired tasks one by one so that only tasks that
+// were actually removed are reported, even if one is restored meanwhile.
+func (r *TaskRepositoryMongo) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	filter, err := inWorkspace(ctx, bson.M{"deleted_at": bson.M{"$ne": nil, "$lt": before}})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	var purged []string
+	for _, doc := range docs {
+		filter["_id"] = doc.ID
+		result, err := r.collection.DeleteOne(ctx, filter)
+		if err != nil {
+			return purged, err
+		}
+		if result.DeletedCount > 0 {
+			purged = append(purged, doc.ID.Hex())
+		}
+	}
+
+	return purged, nil
+}
+
+func (r *TaskRepositoryMongo) BulkWrite(ctx context.Context, writes []domain.TaskWrite) ([]error, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.bulkWrite(ctx, writes, false)
 }
 
+func (r *TaskRepositoryMongo) BulkWriteAtomic(ctx context.Context, writes []domain.TaskWrite) ([]error, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	var itemErrs []error
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		errs, err := r.bulkWrite(sc, writes, true)
+		itemErrs = errs
+		if err != nil {
+			return nil, err
+		}
+		for _, itemErr := range errs {
+			if itemErr != nil {
+				return nil, domain.ErrBatchAborted
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		for i := range writes {
+			if writes[i].Op == domain.BatchCreate {
+				writes[i].ID = ""
+				writes[i].Task.ID = ""
+			}
+		}
+		if errors.Is(err, domain.ErrBatchAborted) {
+			return itemErrs, domain.ErrBatchAborted
+		}
+		return itemErrs, err
+	}
+
+	return itemErrs, nil
+}
+
+// bulkWrite resolves and checks every write up front so that each one gets
+// its own error, then sends the valid ones in a single BulkWrite. In ordered
+// mode nothing is sent when any write is already known to fail.
+func (r *TaskRepositoryMongo) bulkWrite(ctx context.Context, writes []domain.TaskWrite, ordered bool) ([]error, error) {
+	errs := make([]error, len(writes))
+	// The workspace of ctx is checked once here, so the inWorkspace and
+	// workspaceFor calls below cannot fail.
+	if _, err := domain.WorkspaceScope(ctx); err != nil {
+		return errs, err
+	}
+	objectIDs := make([]primitive.ObjectID, len(writes))
+	var lookup []primitive.ObjectID
+
+	for i, w := range writes {
+		switch w.Op {
+		case domain.BatchCreate:
+			objectIDs[i] = primitive.NewObjectID()
+		case domain.BatchUpdate, domain.BatchDelete:
+			objectID, err := primitive.ObjectIDFromHex(w.ID)
+			if err != nil {
+				errs[i] = errors.New("invalid task ID format")
+				continue
+			}
+			objectIDs[i] = objectID
+			lookup = append(lookup, objectID)
+		default:
+			errs[i] = errors.New("unknown operation")
+		}
+	}
+
+	existing, err := r.existingTasks(ctx, lookup)
+	if err != nil {
+		return errs, err
+	}
+
+	batchID := primitive.NewObjectID().Hex()
+	var models []mongo.WriteModel
+	var modelIndex []int
+	for i, w := range writes {
+		if errs[i] != nil {
+			continue
+		}
+
+		switch w.Op {
+		case domain.BatchCreate:
+			task := w.Task
+			task.ID = objectIDs[i].Hex()
+			task.WorkspaceID, _ = workspaceFor(ctx, task.WorkspaceID)
+			doc := r.mapToDocument(startSeries(task))
+			doc["_id"] = objectIDs[i]
+			doc["history"] = bson.A{r.mapHistoryToDocument(w.History)}
+			models = append(models, mongo.NewInsertOneModel().SetDocument(doc))
+		case domain.BatchUpdate:
+			current, ok := existing[objectIDs[i]]
+			if !ok {
+				errs[i] = errors.New("task not found")
+				continue
+			}
+			writes[i].History.Changes = domain.TaskChanges(current, mergeUpdate(current, w.Task))
+			filter, _ := inWorkspace(ctx, notDeleted(bson.M{"_id": objectIDs[i]}))
+			update := r.withHistory(bson.M{"$set": r.mapToUpdate(w.Task)}, writes[i].History)
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(unchanged(filter, writes[i].History.Changes)).
+				SetUpdate(withWriteID(update, writeID(batchID, i))))
+		case domain.BatchDelete:
+			if _, ok := existing[objectIDs[i]]; !ok {
+				errs[i] = errors.New("task not found")
+				continue
+			}
+			filter, _ := inWorkspace(ctx, notDeleted(bson.M{"_id": objectIDs[i]}))
+			update := r.withHistory(bson.M{"$set": trashFields(w.History)}, w.History)
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(filter).
+				SetUpdate(withWriteID(update, writeID(batchID, i))))
+		}
+		modelIndex = append(modelIndex, i)
+	}
+
+	if ordered && len(modelIndex) != len(writes) {
+		return errs, nil
+	}
+
+	if len(models) > 0 {
+		result, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(ordered))
+		var bulkErr mongo.BulkWriteException
+		if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 {
+			for _, writeErr := range bulkErr.WriteErrors {
+				errs[modelIndex[writeErr.Index]] = errors.New(writeErr.Message)
+			}
+		} else if err != nil {
+			return errs, err
+		}
+		// An ordered write stops at its first error, so the writes after it
+		// did not match anything because they never ran.
+		if err == nil || !ordered {
+			if err := r.markUnmatched(ctx, writes, objectIDs, errs, batchID, result.MatchedCount); err != nil {
+				return errs, err
+			}
+		}
+	}
+
+	for i := range writes {
+		if errs[i] == nil && writes[i].Op == domain.BatchCreate {
+			writes[i].ID = objectIDs[i].Hex()
+			writes[i].Task.ID = writes[i].ID
+			writes[i].Task.WorkspaceID, _ = workspaceFor(ctx, writes[i].Task.WorkspaceID)
+			writes[i].Task = startSeries(writes[i].Task)
+		}
+	}
+
+	return errs, nil
+}
+
+// markUnmatched sets the error of every update and delete of a bulk write
+// that matched no task, because the task changed or went away after
+// existingTasks read it. BulkWrite only reports how many tasks matched in
+// all, so when some are missing the write IDs left on the tasks tell which.
+func (r *TaskRepositoryMongo) markUnmatched(ctx context.Context, writes []domain.TaskWrite, objectIDs []primitive.ObjectID, errs []error, batchID string, matched int64) error {
+	var sent []int
+	for i, w := range writes {
+		if errs[i] == nil && w.Op != domain.BatchCreate {
+			sent = append(sent, i)
+		}
+	}
+	if int64(len(sent)) == matched {
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(sent))
+	writeIDs := make(bson.A, 0, len(sent))
+	for _, i := range sent {
+		ids = append(ids, objectIDs[i])
+		writeIDs = append(writeIDs, writeID(batchID, i))
+	}
+	cursor, err := r.collection.Find(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "write_ids": bson.M{"$in": writeIDs}},
+		options.Find().SetProjection(bson.M{"write_ids": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	applied := map[string]bool{}
+	for cursor.Next(ctx) {
+		var doc struct {
+			WriteIDs []string `bson:"write_ids"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		for _, id := range doc.WriteIDs {
+			applied[id] = true
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	for _, i := range sent {
+		if applied[writeID(batchID, i)] {
+			continue
+		}
+		err := r.missingOrConflict(ctx, objectIDs[i])
+		if err != domain.ErrConflict && err.Error() != "task not found" {
+			return err
+		}
+		errs[i] = err
+	}
+	return nil
+}
+
+// writeID names the i-th write of a bulk write.
+func writeID(batchID string, i int) string {
+	return batchID + ":" + strconv.Itoa(i)
+}
+
+// maxWriteIDs is how many bulk writes a task remembers, enough for
+// markUnmatched to find its own among those that raced with it.
+const maxWriteIDs = 16
+
+// withWriteID makes update leave id on the task it changes.
+func withWriteID(update bson.M, id string) bson.M {
+	push, _ := update["$push"].(bson.M)
+	if push == nil {
+		push = bson.M{}
+	}
+	push["write_ids"] = bson.M{"$each": bson.A{id}, "$slice": -maxWriteIDs}
+	update["$push"] = push
+	return update
+}
+
+func (r *TaskRepositoryMongo) existingTasks(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]domain.Task, error) {
+	existing := map[primitive.ObjectID]domain.Task{}
+	if len(ids) == 0 {
+		return existing, nil
+	}
+
+	filter, err := inWorkspace(ctx, notDeleted(bson.M{"_id": bson.M{"$in": ids}}))
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(taskProjection))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var taskDoc bson.M
+		if err := cursor.Decode(&taskDoc); err != nil {
+			return nil, err
+		}
+		if id, ok := taskDoc["_id"].(primitive.ObjectID); ok {
+			existing[id] = r.mapToDomain(taskDoc)
+		}
+	}
+
+	return existing, cursor.Err()
+}
+
+func (r *TaskRepositoryMongo) CountTags(ctx context.Context) (map[string]int, error) {
+	filter, err := inWorkspace(ctx, notDeleted(bson.M{"tags.0": bson.M{"$exists": true}}))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := map[string]int{}
+	for cursor.Next(ctx) {
+		var doc struct {
+			Tag   string `bson:"_id"`
+			Count int    `bson:"count"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		counts[doc.Tag] = doc.Count
+	}
+
+	return counts, cursor.Err()
+}
+
+// RenameTag also renames the tag on trashed tasks so a restore brings them
+// back with the current name. Tasks that already carry newName just lose
+// oldName.
+func (r *TaskRepositoryMongo) RenameTag(ctx context.Context, oldName, newName string, entry domain.HistoryEntry) ([]domain.TaskRevision, error) {
+	return r.revise(ctx, bson.M{"tags": oldName}, entry, func(task domain.Task) domain.Task {
+		task.Tags = renameTag(task.Tags, oldName, newName)
+		return task
+	})
+}
+
+func (r *TaskRepositoryMongo) RemoveTag(ctx context.Context, name string, entry domain.HistoryEntry) ([]domain.TaskRevision, error) {
+	return r.revise(ctx, bson.M{"tags": name}, entry, func(task domain.Task) domain.Task {
+		task.Tags = removeTag(task.Tags, name)
+		return task
+	})
+}
+
+func (r *TaskRepositoryMongo) RemoveProject(ctx context.Context, projectID string, entry domain.HistoryEntry) ([]domain.TaskRevision, error) {
+	return r.revise(ctx, bson.M{"project_id": projectID}, entry, func(task domain.Task) domain.Task {
+		if task.ProjectID == projectID {
+			task.ProjectID = ""
+		}
+		return task
+	})
+}
+
+// Stats computes every figure in one aggregation, with a $facet each. Days
+// are grouped as UTC dates, matching domain.StartOfDay.
+func (r *TaskRepositoryMongo) Stats(ctx context.Context, query domain.TaskStatsQuery) (domain.TaskStats, error) {
+	filter, err := inWorkspace(ctx, notDeleted(mapFilter(query.Filter)))
+	if err != nil {
+		return domain.TaskStats{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	weekStart, weekEnd := query.Week()
+	inRange := bson.M{"$gte": query.From, "$lt": query.Until()}
+	completion := bson.M{"$elemMatch": bson.M{"field": "status", "new": "completed"}}
+	day := func(field string) bson.M {
+		return bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": field}}
+	}
+	count := bson.M{"$count": "count"}
+
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$facet", Value: bson.M{
+			"by_status": bson.A{
+				bson.M{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+			},
+			"overdue": bson.A{
+				bson.M{"$match": bson.M{
+					"status":   bson.M{"$ne": "completed"},
+					"due_date": bson.M{"$gt": time.Time{}, "$lt": query.Now},
+				}},
+				count,
+			},
+			"due_this_week": bson.A{
+				bson.M{"$match": bson.M{
+					"status":   bson.M{"$ne": "completed"},
+					"due_date": bson.M{"$gte": weekStart, "$lt": weekEnd},
+				}},
+				count,
+			},
+			"created": bson.A{
+				bson.M{"$match": bson.M{"created_at": inRange}},
+				bson.M{"$group": bson.M{"_id": day("$created_at"), "count": bson.M{"$sum": 1}}},
+			},
+			"completed": bson.A{
+				bson.M{"$match": bson.M{"history": bson.M{"$elemMatch": bson.M{"at": inRange, "changes": completion}}}},
+				bson.M{"$unwind": "$history"},
+				bson.M{"$match": bson.M{"history.at": inRange, "history.changes": completion}},
+				bson.M{"$group": bson.M{
+					"_id":   day("$history.at"),
+					"count": bson.M{"$sum": 1},
+					"time":  bson.M{"$sum": bson.M{"$subtract": bson.A{"$history.at", "$created_at"}}},
+				}},
+			},
+		}}},
+	})
+	if err != nil {
+		return domain.TaskStats{}, err
+	}
+	defer cursor.Close(ctx)
+
+	type dayCount struct {
+		Day   string `bson:"_id"`
+		Count int    `bson:"count"`
+		// Time is the sum of the times to complete, in milliseconds.
+		Time int64 `bson:"time"`
+	}
+	var facets struct {
+		ByStatus []struct {
+			Status string `bson:"_id"`
+			Count  int    `bson:"count"`
+		} `bson:"by_status"`
+		Overdue     []dayCount `bson:"overdue"`
+		DueThisWeek []dayCount `bson:"due_this_week"`
+		Created     []dayCount `bson:"created"`
+		Completed   []dayCount `bson:"completed"`
+	}
+	if !cursor.Next(ctx) {
+		return domain.TaskStats{}, cursor.Err()
+	}
+	if err := cursor.Decode(&facets); err != nil {
+		return domain.TaskStats{}, err
+	}
+
+	stats := domain.NewTaskStats(query)
+	for _, group := range facets.ByStatus {
+		stats.Total += group.Count
+		stats.ByStatus[group.Status] += group.Count
+	}
+	if len(facets.Overdue) > 0 {
+		stats.Overdue = facets.Overdue[0].Count
+	}
+	if len(facets.DueThisWeek) > 0 {
+		stats.DueThisWeek = facets.DueThisWeek[0].Count
+	}
+	for _, group := range facets.Created {
+		if day := stats.DayOf(parseDay(group.Day)); day != nil {
+			day.Created = group.Count
+		}
+	}
+	var completions int
+	var completionTime int64
+	for _, group := range facets.Completed {
+		if day := stats.DayOf(parseDay(group.Day)); day != nil {
+			day.Completed = group.Count
+		}
+		completions += group.Count
+		completionTime += group.Time
+	}
+	if completions > 0 {
+		stats.AverageTimeToComplete = time.Duration(completionTime/int64(completions)) * time.Millisecond
+	}
+	return stats, nil
+}
+
+// parseDay reads a date grouped by $dateToString; an unreadable one is the
+// zero time, which falls outside every stats range.
+func parseDay(s string) time.Time {
+	day, _ := time.Parse("2006-01-02", s)
+	return day
+}
+
+func (r *TaskRepositoryMongo) withSubtaskCount(ctx context.Context, task domain.Task) (domain.Task, error) {
+	tasks, err := r.withSubtaskCounts(ctx, []domain.Task{task})
+	if err != nil {
+		return domain.Task{}, err
+	}
+	return tasks[0], nil
+}
+
+// withSubtaskCounts fills in how many live subtasks each task has and how
+// many of them are completed.
+func (r *TaskRepositoryMongo) withSubtaskCounts(ctx context.Context, tasks []domain.Task) ([]domain.Task, error) {
+	if len(tasks) == 0 {
+		return tasks, nil
+	}
+
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+
+	filter, err := inWorkspace(ctx, notDeleted(bson.M{"parent_id": bson.M{"$in": ids}}))
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$parent_id",
+			"total": bson.M{"$sum": 1},
+			"completed": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$eq": bson.A{"$status", "completed"}}, 1, 0},
+			}},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := map[string]domain.SubtaskCount{}
+	for cursor.Next(ctx) {
+		var doc struct {
+			ParentID  string `bson:"_id"`
+			Total     int    `bson:"total"`
+			Completed int    `bson:"completed"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		counts[doc.ParentID] = domain.SubtaskCount{Total: doc.Total, Completed: doc.Completed}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range tasks {
+		tasks[i].Subtasks = counts[tasks[i].ID]
+	}
+	return tasks, nil
+}
+
+// missingOrConflict explains why a conditional update matched nothing: the
+// task is gone, or one of the fields it was conditioned on has changed.
+func (r *TaskRepositoryMongo) missingOrConflict(ctx context.Context, objectID primitive.ObjectID) error {
+	filter, err := inWorkspace(ctx, notDeleted(bson.M{"_id": objectID}))
+	if err != nil {
+		return err
+	}
+	count, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("task not found")
+	}
+	return domain.ErrConflict
+}
+
+func (r *TaskRepositoryMongo) mapToDomain(doc bson.M) domain.Task {
+	task := domain.Task{}
+	if id, ok := doc["_id"].(primitive.ObjectID); ok {
+		task.ID = id.Hex()
+	}
+	if workspaceID, ok := doc["workspace_id"].(string); ok {
+		task.WorkspaceID = workspaceID
+	}
+	if title, ok := doc["title"].(string); ok {
+		task.Title = title
+	}
+	if desc, ok := doc["description"].(string); ok {
+		task.Description = desc
+	}
+	if dueDate, ok := doc["due_date"].(primitive.DateTime); ok {
+		task.DueDate = dueDate.Time()
+	} else if dueDate, ok := doc["due_date"].(time.Time); ok {
+		task.DueDate = dueDate
+	}
+	if status, ok := doc["status"].(string); ok {
+		task.Status = status
+	}
+	if priority, ok := doc["priority"].(string); ok {
+		task.Priority = priority
+	}
+	if createdAt, ok := doc["created_at"].(primitive.DateTime); ok {
+		task.CreatedAt = createdAt.Time()
+	} else if createdAt, ok := doc["created_at"].(time.Time); ok {
+		task.CreatedAt = createdAt
+	}
+	if updatedAt, ok := doc["updated_at"].(primitive.DateTime); ok {
+		task.UpdatedAt = updatedAt.Time()
+	} else if updatedAt, ok := doc["updated_at"].(time.Time); ok {
+		task.UpdatedAt = updatedAt
+	}
+	if deletedAt, ok := doc["deleted_at"].(primitive.DateTime); ok {
+		task.DeletedAt = deletedAt.Time()
+	} else if deletedAt, ok := doc["deleted_at"].(time.Time); ok {
+		task.DeletedAt = deletedAt
+	}
+	if deletedBy, ok := doc["deleted_by"].(string); ok {
+		task.DeletedBy = deletedBy
+	}
+	if parentID, ok := doc["parent_id"].(string); ok {
+		task.ParentID = parentID
+	}
+	if projectID, ok := doc["project_id"].(string); ok {
+		task.ProjectID = projectID
+	}
+	if externalID, ok := doc["external_id"].(string); ok {
+		task.ExternalID = externalID
+	}
+	task.Checklist = mapChecklistToDomain(doc["checklist"])
+	task.BlockedBy = mapStringsToDomain(doc["blocked_by"])
+	task.Tags = mapStringsToDomain(doc["tags"])
+	task.Recurrence = mapRecurrenceToDomain(doc["recurrence"])
+	return task
+}
+
+func mapRecurrenceToDomain(v interface{}) *domain.Recurrence {
+	doc, ok := v.(bson.M)
+	if !ok {
+		return nil
+	}
+	recurrence := &domain.Recurrence{}
+	recurrence.Rule, _ = doc["rule"].(string)
+	recurrence.SeriesID, _ = doc["series_id"].(string)
+	recurrence.NextID, _ = doc["next_id"].(string)
+	if start, ok := doc["start"].(primitive.DateTime); ok {
+		recurrence.Start = start.Time()
+	} else if start, ok := doc["start"].(time.Time); ok {
+		recurrence.Start = start
+	}
+	return recurrence
+}
+
+// startSeries makes a task with a Recurrence but no SeriesID the first
+// occurrence of its own series. The Recurrence is copied so the caller's
+// value is left alone.
+func startSeries(task domain.Task) domain.Task {
+	if task.Recurrence == nil || task.Recurrence.SeriesID != "" {
+		return task
+	}
+	recurrence := *task.Recurrence
+	recurrence.SeriesID = task.ID
+	task.Recurrence = &recurrence
+	return task
+}
+
+// mapFilter translates a TaskFilter into a query. Tags are matched through
+// the multikey index on tags.
+func mapFilter(filter domain.TaskFilter) bson.M {
+	query := bson.M{}
+	if filter.ProjectID != "" {
+		query["project_id"] = filter.ProjectID
+	}
+	if len(filter.Tags) > 0 {
+		if filter.TagMatch == domain.TagMatchAll {
+			query["tags"] = bson.M{"$all": filter.Tags}
+		} else {
+			query["tags"] = bson.M{"$in": filter.Tags}
+		}
+	}
+	return query
+}
+
+// taskProjection leaves the embedded history out of task reads.
+var taskProjection = bson.M{"history": 0}
+
+// unchanged narrows filter to documents whose changed fields still hold the
+// old values the history entry was computed from, so the recorded diff always
+// matches what the write replaced. The recurrence change is the rule.
+func unchanged(filter bson.M, changes []domain.FieldChange) bson.M {
+	for _, change := range changes {
+		field := change.Field
+		if field == "recurrence" {
+			field = "recurrence.rule"
+		}
+		if change.Old == nil {
+			filter[field] = bson.M{"$in": bson.A{nil, "", time.Time{}, bson.A{}}}
+		} else {
+			filter[field] = documentValue(change.Old)
+		}
+	}
+	return filter
+}
+
+// reviseAttempts bounds how often revise reads a task again after another
+// request changed it first.
+const reviseAttempts = 3
+
+// revise applies change to every task matching filter, trashed ones
+// included, with one write per task so that each records its own changes
+// in its history. Like Update, a write only applies if the fields it
+// changes still hold what was read; a task changed in between is read and
+// changed again, and left alone once change no longer alters it.
+func (r *TaskRepositoryMongo) revise(ctx context.Context, filter bson.M, entry domain.HistoryEntry, change func(domain.Task) domain.Task) ([]domain.TaskRevision, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tasks, err := r.find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []domain.TaskRevision
+	for _, task := range tasks {
+		revision, err := r.reviseTask(ctx, task, entry, change)
+		if err != nil {
+			return revisions, err
+		}
+		if len(revision.Changes) > 0 {
+			revisions = append(revisions, revision)
+		}
+	}
+	return revisions, nil
+}
+
+func (r *TaskRepositoryMongo) reviseTask(ctx context.Context, task domain.Task, entry domain.HistoryEntry, change func(domain.Task) domain.Task) (domain.TaskRevision, error) {
+	objectID, err := primitive.ObjectIDFromHex(task.ID)
+	if err != nil {
+		return domain.TaskRevision{}, err
+	}
+	for attempt := 0; attempt < reviseAttempts; attempt++ {
+		entry.Changes = domain.TaskChanges(task, change(copyTask(task)))
+		if len(entry.Changes) == 0 {
+			return domain.TaskRevision{}, nil
+		}
+		set := bson.M{"updated_at": entry.At}
+		for _, c := range entry.Changes {
+			set[c.Field] = documentValue(c.New)
+		}
+
+		var taskDoc bson.M
+		err := r.collection.FindOneAndUpdate(ctx,
+			unchanged(bson.M{"_id": objectID}, entry.Changes),
+			r.withHistory(bson.M{"$set": set}, entry),
+			options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(taskProjection),
+		).Decode(&taskDoc)
+		if err == nil {
+			revised, err := r.withSubtaskCount(ctx, r.mapToDomain(taskDoc))
+			return domain.TaskRevision{Task: revised, Changes: entry.Changes}, err
+		}
+		if err != mongo.ErrNoDocuments {
+			return domain.TaskRevision{}, err
+		}
+
+		err = r.collection.FindOne(ctx, bson.M{"_id": objectID}, options.FindOne().SetProjection(taskProjection)).Decode(&taskDoc)
+		if err == mongo.ErrNoDocuments {
+			return domain.TaskRevision{}, nil
+		}
+		if err != nil {
+			return domain.TaskRevision{}, err
+		}
+		task = r.mapToDomain(taskDoc)
+	}
+	return domain.TaskRevision{}, domain.ErrConflict
+}
+
+// renameTag replaces oldName with newName in tags, dropping it instead
+// when tags already holds newName.
+func renameTag(tags []string, oldName, newName string) []string {
+	if !containsString(tags, oldName) {
+		return tags
+	}
+	renamed := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		switch {
+		case tag != oldName:
+			renamed = append(renamed, tag)
+		case !containsString(tags, newName):
+			renamed = append(renamed, newName)
+		}
+	}
+	return renamed
+}
+
+func removeTag(tags []string, name string) []string {
+	if !containsString(tags, name) {
+		return tags
+	}
+	kept := []string{}
+	for _, tag := range tags {
+		if tag != name {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
+
+// withHistory adds entry to the front of the task's history as part of
+// update, so both are written atomically, and drops the entries beyond
+// historyLimit. Updates that change nothing are not recorded.
+func (r *TaskRepositoryMongo) withHistory(update bson.M, entry domain.HistoryEntry) bson.M {
+	if entry.Action == domain.HistoryUpdate && len(entry.Changes) == 0 {
+		return update
+	}
+	push := bson.M{
+		"$each":     bson.A{r.mapHistoryToDocument(entry)},
+		"$position": 0,
+	}
+	if r.historyLimit > 0 {
+		push["$slice"] = r.historyLimit
+	}
+	update["$push"] = bson.M{"history": push}
+	return update
+}
+
+func mergeUpdate(task domain.Task, update domain.Task) domain.Task {
+	if update.Title != "" {
+		task.Title = update.Title
+	}
+	if update.Description != "" {
+		task.Description = update.Description
+	}
+	if !update.DueDate.IsZero() {
+		task.DueDate = update.DueDate
+	}
+	if update.Status != "" {
+		task.Status = update.Status
+	}
+	if update.Priority != "" {
+		task.Priority = update.Priority
+	}
+	if update.ParentID != "" {
+		task.ParentID = update.ParentID
+	}
+	if update.ProjectID != "" {
+		task.ProjectID = update.ProjectID
+	}
+	if update.Tags != nil {
+		task.Tags = update.Tags
+	}
+	if update.Recurrence != nil {
+		recurrence := *update.Recurrence
+		recurrence.NextID = ""
+		if task.Recurrence != nil {
+			recurrence.NextID = task.Recurrence.NextID
+		}
+		task.Recurrence = &recurrence
+	}
+	return task
+}
+
+// notDeleted narrows filter to tasks that are not in the trash. A null match
+// also covers documents written before soft delete existed.
+func notDeleted(filter bson.M) bson.M {
+	filter["deleted_at"] = nil
+	return filter
+}
+
+func trashFields(entry domain.HistoryEntry) bson.M {
+	return bson.M{
+		"deleted_at": entry.At,
+		"deleted_by": entry.Actor,
+		"updated_at": entry.At,
+	}
+}
+
+// mapToUpdate returns the $set document for a partial update: empty fields
+// are left untouched and updated_at is always refreshed.
+func (r *TaskRepositoryMongo) mapToUpdate(task domain.Task) bson.M {
+	update := bson.M{}
+	if task.Title != "" {
+		update["title"] = task.Title
+	}
+	if task.Description != "" {
+		update["description"] = task.Description
+	}
+	if !task.DueDate.IsZero() {
+		update["due_date"] = task.DueDate
+	}
+	if task.Status != "" {
+		update["status"] = task.Status
+	}
+	if task.Priority != "" {
+		update["priority"] = task.Priority
+	}
+	if task.ParentID != "" {
+		update["parent_id"] = task.ParentID
+	}
+	if task.ProjectID != "" {
+		update["project_id"] = task.ProjectID
+	}
+	if task.Checklist != nil {
+		update["checklist"] = mapChecklistToDocument(task.Checklist)
+	}
+	if task.BlockedBy != nil {
+		update["blocked_by"] = task.BlockedBy
+	}
+	if task.Tags != nil {
+		update["tags"] = task.Tags
+	}
+	if task.Recurrence != nil {
+		// Dotted paths keep a next_id set concurrently by CreateOccurrence.
+		update["recurrence.rule"] = task.Recurrence.Rule
+		update["recurrence.series_id"] = task.Recurrence.SeriesID
+		update["recurrence.start"] = task.Recurrence.Start
+	}
+	update["updated_at"] = time.Now()
+	return update
+}
+
+func (r *TaskRepositoryMongo) mapToDocument(task domain.Task) bson.M {
+	doc := bson.M{
+		"workspace_id": task.WorkspaceID,
+		"title":        task.Title,
+		"description":  task.Description,
+		"due_date":     task.DueDate,
+		"status":       task.Status,
+		"priority":     task.Priority,
+		"created_at":   task.CreatedAt,
+		"updated_at":   task.UpdatedAt,
+		"parent_id":    task.ParentID,
+		"project_id":   task.ProjectID,
+		"checklist":    mapChecklistToDocument(task.Checklist),
+		"blocked_by":   task.BlockedBy,
+		"tags":         task.Tags,
+	}
+	// Tasks without an external ID leave the field out, so that the unique
+	// index on it only covers the tasks that have one.
+	if task.ExternalID != "" {
+		doc["external_id"] = task.ExternalID
+	}
+	if task.Recurrence != nil {
+		doc["recurrence"] = bson.M{
+			"rule":      task.Recurrence.Rule,
+			"series_id": task.Recurrence.SeriesID,
+			"start":     task.Recurrence.Start,
+			"next_id":   task.Recurrence.NextID,
+		}
+	}
+	return doc
+}
+
+func (r *TaskRepositoryMongo) mapHistoryToDocument(entry domain.HistoryEntry) bson.M {
+	changes := bson.A{}
+	for _, change := range entry.Changes {
+		changes = append(changes, bson.M{
+			"field": change.Field,
+			"old":   documentValue(change.Old),
+			"new":   documentValue(change.New),
+		})
+	}
+	return bson.M{
+		"action":   entry.Action,
+		"actor_id": entry.ActorID,
+		"actor":    entry.Actor,
+		"at":       entry.At,
+		"changes":  changes,
+	}
+}
+
+func (r *TaskRepositoryMongo) mapHistoryToDomain(doc bson.M) domain.HistoryEntry {
+	entry := domain.HistoryEntry{}
+	if action, ok := doc["action"].(string); ok {
+		entry.Action = action
+	}
+	if actorID, ok := doc["actor_id"].(string); ok {
+		entry.ActorID = actorID
+	}
+	if actor, ok := doc["actor"].(string); ok {
+		entry.Actor = actor
+	}
+	if at, ok := doc["at"].(primitive.DateTime); ok {
+		entry.At = at.Time()
+	}
+	if changes, ok := doc["changes"].(bson.A); ok {
+		for _, c := range changes {
+			changeDoc, ok := c.(bson.M)
+			if !ok {
+				continue
+			}
+			field, _ := changeDoc["field"].(string)
+			entry.Changes = append(entry.Changes, domain.FieldChange{
+				Field: field,
+				Old:   historyValue(field, changeDoc["old"]),
+				New:   historyValue(field, changeDoc["new"]),
+			})
+		}
+	}
+	return entry
+}
+
+func historyValue(field string, v interface{}) interface{} {
+	if dt, ok := v.(primitive.DateTime); ok {
+		return dt.Time().UTC()
+	}
+	if v == nil {
+		return nil
+	}
+	switch field {
+	case "checklist":
+		return mapChecklistToDomain(v)
+	case "blocked_by", "tags":
+		return mapStringsToDomain(v)
+	}
+	return v
+}
+
+func mapStringsToDomain(v interface{}) []string {
+	values, ok := v.(bson.A)
+	if !ok {
+		return nil
+	}
+	var result []string
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// documentValue converts history values that have no natural BSON form.
+func documentValue(v interface{}) interface{} {
+	if items, ok := v.([]domain.ChecklistItem); ok {
+		return mapChecklistToDocument(items)
+	}
+	return v
+}
+
+// mapChecklistToDocument keeps the item field order fixed so stored
+// checklists can be matched for equality in update preconditions.
+func mapChecklistToDocument(items []domain.ChecklistItem) bson.A {
+	doc := bson.A{}
+	for _, item := range items {
+		doc = append(doc, bson.D{
+			{Key: "id", Value: item.ID},
+			{Key: "text", Value: item.Text},
+			{Key: "done", Value: item.Done},
+		})
+	}
+	return doc
+}
+
+func mapChecklistToDomain(v interface{}) []domain.ChecklistItem {
+	docs, ok := v.(bson.A)
+	if !ok {
+		return nil
+	}
+	var items []domain.ChecklistItem
+	for _, d := range docs {
+		itemDoc, ok := d.(bson.M)
+		if !ok {
+			continue
+		}
+		item := domain.ChecklistItem{}
+		item.ID, _ = itemDoc["id"].(string)
+		item.Text, _ = itemDoc["text"].(string)
+		item.Done, _ = itemDoc["done"].(bool)
+		items = append(items, item)
+	}
+	return items
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"task9/domain"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// TaskCacheMetrics counts the GetByID calls of CachedTaskRepository that
+// were answered from the cache ("hits") and those that were not ("misses").
+// It is published through expvar as "task_cache".
+var TaskCacheMetrics = expvar.NewMap("task_cache")
+
+// CachedTaskRepository answers GetByID from a TaskCache and passes every
+// other read on to the repository it wraps. Writes through it drop the
+// tasks they change from the cache, along with the parents whose subtask
+// counts they change. Writes through another CachedTaskRepository, e.g. on
+// another replica, are seen once the cached copies expire.
+//
+// Every method that changes tasks must be overridden here, since the
+// embedded repository knows nothing of the cache.
+type CachedTaskRepository struct {
+	domain.TaskRepository
+	cache   domain.TaskCache
+	flights singleflight.Group
+
+	// mu orders storing a lookup against invalidations. Each invalidation
+	// bumps epoch, and a lookup that overlapped one does not store what it
+	// read, since that may predate the write.
+	mu    sync.Mutex
+	epoch uint64
+}
+
+func NewCachedTaskRepository(inner domain.TaskRepository, cache domain.TaskCache) domain.TaskRepository {
+	return &CachedTaskRepository{TaskRepository: inner, cache: cache}
+}
+
+// GetByID shares one read of the wrapped repository among the concurrent
+// misses for a task. The read runs without the callers' cancellation, so a
+// caller that gives up does not fail the others; it is still bounded by the
+// wrapped repository's timeout.
+func (r *CachedTaskRepository) GetByID(ctx context.Context, id string) (domain.Task, error) {
+	// Lookups scoped to different workspaces may have different answers, so
+	// they do not share a read; unscoped ones are refused before they can
+	// join one.
+	workspaceID, err := domain.WorkspaceScope(ctx)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	if task, ok := r.cache.Get(ctx, id); ok && visibleIn(ctx, task.WorkspaceID) {
+		TaskCacheMetrics.Add("hits", 1)
+		return copyTask(task), nil
+	}
+	TaskCacheMetrics.Add("misses", 1)
+
+	key := workspaceID + "/" + id
+	detached := context.WithoutCancel(ctx)
+	flight := r.flights.DoChan(key, func() (interface{}, error) {
+		r.mu.Lock()
+		epoch := r.epoch
+		r.mu.Unlock()
+
+		task, err := r.TaskRepository.GetByID(detached, id)
+		if err != nil {
+			return domain.Task{}, err
+		}
+		r.mu.Lock()
+		if r.epoch == epoch {
+			r.cache.Set(detached, id, copyTask(task))
+		}
+		r.mu.Unlock()
+		return task, nil
+	})
+
+	select {
+	case result := <-flight:
+		if result.Err != nil {
+			return domain.Task{}, result.Err
+		}
+		return copyTask(result.Val.(domain.Task)), nil
+	case <-ctx.Done():
+		return domain.Task{}, ctx.Err()
+	}
+}
+
+func (r *CachedTaskRepository) Create(ctx context.Context, task domain.Task, entry domain.HistoryEntry) (domain.Task, error) {
+	created, err := r.TaskRepository.Create(ctx, task, entry)
+	if err == nil {
+		r.invalidate(ctx, created.ParentID)
+	}
+	return created, err
+}
+
+func (r *CachedTaskRepository) CreateOccurrence(ctx context.Context, previousID string, task domain.Task, entry domain.HistoryEntry) (domain.Task, error) {
+	created, err := r.TaskRepository.CreateOccurrence(ctx, previousID, task, entry)
+	r.invalidate(ctx, previousID, task.ParentID)
+	return created, err
+}
+
+// Update also drops the task when it fails with ErrConflict, so that the
+// retry reads the current version.
+func (r *CachedTaskRepository) Update(ctx context.Context, id string, task domain.Task, entry domain.HistoryEntry) (domain.Task, error) {
+	updated, err := r.TaskRepository.Update(ctx, id, task, entry)
+	ids := []string{id, updated.ParentID}
+	for _, change := range entry.Changes {
+		if change.Field == "parent_id" {
+			old, _ := change.Old.(string)
+			ids = append(ids, old)
+		}
+	}
+	r.invalidate(ctx, ids...)
+	return updated, err
+}
+
+// Delete looks up the parent before the task goes to the trash, as the
+// parent's subtask count changes with it.
+func (r *CachedTaskRepository) Delete(ctx context.Context, id string, entry domain.HistoryEntry) error {
+	var parentID string
+	if task, err := r.GetByID(ctx, id); err == nil {
+		parentID = task.ParentID
+	}
+	err := r.TaskRepository.Delete(ctx, id, entry)
+	r.invalidate(ctx, id, parentID)
+	return err
+}
+
+func (r *CachedTaskRepository) Restore(ctx context.Context, id string, entry domain.HistoryEntry) (domain.Task, error) {
+	restored, err := r.TaskRepository.Restore(ctx, id, entry)
+	r.invalidate(ctx, id, restored.ParentID)
+	return restored, err
+}
+
+func (r *CachedTaskRepository) Purge(ctx context.Context, id string) error {
+	err := r.TaskRepository.Purge(ctx, id)
+	r.invalidate(ctx, id)
+	return err
+}
+
+func (r *CachedTaskRepository) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	ids, err := r.TaskRepository.PurgeDeleted(ctx, before)
+	r.invalidate(ctx, ids...)
+	return ids, err
+}
+
+// Batches, imports and the tag and project changes below touch many tasks at
+// once and are rare, so they empty the cache rather than track each task.
+
+func (r *CachedTaskRepository) BulkWrite(ctx context.Context, writes []domain.TaskWrite) ([]error, error) {
+	defer r.invalidateAll(ctx)
+	return r.TaskRepository.BulkWrite(ctx, writes)
+}
+
+func (r *CachedTaskRepository) BulkWriteAtomic(ctx context.Context, writes []domain.TaskWrite) ([]error, error) {
+	defer r.invalidateAll(ctx)
+	return r.TaskRepository.BulkWriteAtomic(ctx, writes)
+}
+
+func (r *CachedTaskRepository) RenameTag(ctx context.Context, oldName, newName string, entry domain.HistoryEntry) ([]domain.TaskRevision, error) {
+	defer r.invalidateAll(ctx)
+	return r.TaskRepository.RenameTag(ctx, oldName, newName, entry)
+}
+
+func (r *CachedTaskRepository) RemoveTag(ctx context.Context, name string, entry domain.HistoryEntry) ([]domain.TaskRevision, error) {
+	defer r.invalidateAll(ctx)
+	return r.TaskRepository.RemoveTag(ctx, name, entry)
+}
+
+func (r *CachedTaskRepository) RemoveProject(ctx context.Context, projectID string, entry domain.HistoryEntry) ([]domain.TaskRevision, error) {
+	defer r.invalidateAll(ctx)
+	return r.TaskRepository.RemoveProject(ctx, projectID, entry)
+}
+
+// invalidate drops the tasks among ids from the cache; empty IDs are
+// skipped. It runs after the write, whether or not the write succeeded, as
+// a failed write may still have been applied.
+func (r *CachedTaskRepository) invalidate(ctx context.Context, ids ...string) {
+	var drop []string
+	for _, id := range ids {
+		if id != "" {
+			drop = append(drop, id)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.epoch++
+	r.cache.Delete(context.WithoutCancel(ctx), drop...)
+}
+
+func (r *CachedTaskRepository) invalidateAll(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.epoch++
+	r.cache.Clear(context.WithoutCancel(ctx))
+}
@@ -0,0 +1,611 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"task9/domain"
+	"time"
+)
+
+// TaskRepositoryMemory keeps tasks and their history in process memory. It
+// applies the same rules as TaskRepositoryMongo, including the trash and the
+// update preconditions, and is meant for tests and local development without
+// MongoDB.
+type TaskRepositoryMemory struct {
+	mu     sync.RWMutex
+	tasks  map[string]*memoryTask
+	nextID int
+}
+
+type memoryTask struct {
+	task domain.Task
+	// history is kept newest first.
+	history []domain.HistoryEntry
+}
+
+func NewTaskRepositoryMemory() domain.TaskRepository {
+	return &TaskRepositoryMemory{tasks: map[string]*memoryTask{}}
+}
+
+func (r *TaskRepositoryMemory) GetAll(ctx context.Context) ([]domain.Task, error) {
+	return r.Find(ctx, domain.TaskFilter{})
+}
+
+func (r *TaskRepositoryMemory) Find(ctx context.Context, filter domain.TaskFilter) ([]domain.Task, error) {
+	return r.find(ctx, func(task domain.Task) bool {
+		return task.DeletedAt.IsZero() && filter.Matches(task)
+	})
+}
+
+// Each iterates over a snapshot, so fn may call back into the repository.
+func (r *TaskRepositoryMemory) Each(ctx context.Context, filter domain.TaskFilter, fn func(domain.Task) error) error {
+	tasks, err := r.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if err := fn(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *TaskRepositoryMemory) GetDeleted(ctx context.Context) ([]domain.Task, error) {
+	return r.find(ctx, func(task domain.Task) bool {
+		return !task.DeletedAt.IsZero()
+	})
+}
+
+func (r *TaskRepositoryMemory) GetDeletedByID(ctx context.Context, id string) (domain.Task, error) {
+	tasks, err := r.find(ctx, func(task domain.Task) bool {
+		return !task.DeletedAt.IsZero() && task.ID == id
+	})
+	if err != nil {
+		return domain.Task{}, err
+	}
+	if len(tasks) == 0 {
+		return domain.Task{}, errors.New("task not found")
+	}
+	return tasks[0], nil
+}
+
+func (r *TaskRepositoryMemory) GetByIDs(ctx context.Context, ids []string) ([]domain.Task, error) {
+	wanted := stringSet(ids)
+	return r.find(ctx, func(task domain.Task) bool {
+		return task.DeletedAt.IsZero() && wanted[task.ID]
+	})
+}
+
+func (r *TaskRepositoryMemory) GetSubtasks(ctx context.Context, parentIDs []string) ([]domain.Task, error) {
+	wanted := stringSet(parentIDs)
+	return r.find(ctx, func(task domain.Task) bool {
+		return task.DeletedAt.IsZero() && wanted[task.ParentID]
+	})
+}
+
+func (r *TaskRepositoryMemory) GetBlockedBy(ctx context.Context, blockerIDs []string) ([]domain.Task, error) {
+	wanted := stringSet(blockerIDs)
+	return r.find(ctx, func(task domain.Task) bool {
+		if !task.DeletedAt.IsZero() {
+			return false
+		}
+		for _, id := range task.BlockedBy {
+			if wanted[id] {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *TaskRepositoryMemory) GetDueBetween(ctx context.Context, from, to time.Time) ([]domain.Task, error) {
+	return r.find(ctx, func(task domain.Task) bool {
+		return task.DeletedAt.IsZero() && task.Status != "completed" &&
+			!task.DueDate.IsZero() && !task.DueDate.Before(from) && task.DueDate.Before(to)
+	})
+}
+
+func (r *TaskRepositoryMemory) GetRecurring(ctx context.Context, dueBefore time.Time) ([]domain.Task, error) {
+	return r.find(ctx, func(task domain.Task) bool {
+		return task.DeletedAt.IsZero() && task.Recurrence.IsActive() &&
+			(task.Status == "completed" || task.DueDate.Before(dueBefore))
+	})
+}
+
+// find returns the tasks that match in the workspace of ctx. Like the
+// MongoDB repository, it refuses a ctx that inWorkspace would refuse.
+func (r *TaskRepositoryMemory) find(ctx context.Context, match func(domain.Task) bool) ([]domain.Task, error) {
+	if _, err := domain.WorkspaceScope(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := []domain.Task{}
+	for _, stored := range r.tasks {
+		if visibleIn(ctx, stored.task.WorkspaceID) && match(stored.task) {
+			tasks = append(tasks, r.withSubtaskCount(stored.task))
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return idLess(tasks[i].ID, tasks[j].ID)
+	})
+	return tasks, nil
+}
+
+func (r *TaskRepositoryMemory) GetByID(ctx context.Context, id string) (domain.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.live(ctx, id)
+	if !ok {
+		return domain.Task{}, errors.New("task not found")
+	}
+	return r.withSubtaskCount(stored.task), nil
+}
+
+func (r *TaskRepositoryMemory) GetByExternalID(ctx context.Context, externalID string) (domain.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.byExternalID(ctx, externalID)
+	if !ok {
+		return domain.Task{}, errors.New("task not found")
+	}
+	return r.withSubtaskCount(stored.task), nil
+}
+
+func (r *TaskRepositoryMemory) Create(ctx context.Context, task domain.Task, entry domain.HistoryEntry) (domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, taken := r.byExternalID(ctx, task.ExternalID); taken {
+		return domain.Task{}, domain.ErrExternalIDTaken
+	}
+	return r.create(ctx, task, entry)
+}
+
+func (r *TaskRepositoryMemory) CreateOccurrence(ctx context.Context, previousID string, task domain.Task, entry domain.HistoryEntry) (domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous, ok := r.live(ctx, previousID)
+	if !ok {
+		return domain.Task{}, errors.New("task not found")
+	}
+	if !previous.task.Recurrence.IsActive() {
+		return domain.Task{}, domain.ErrConflict
+	}
+
+	created, err := r.create(ctx, task, entry)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	recurrence := *previous.task.Recurrence
+	recurrence.NextID = created.ID
+	previous.task.Recurrence = &recurrence
+	return created, nil
+}
+
+func (r *TaskRepositoryMemory) Update(ctx context.Context, id string, task domain.Task, entry domain.HistoryEntry) (domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.live(ctx, id)
+	if !ok {
+		return domain.Task{}, errors.New("task not found")
+	}
+	if !holdsOldValues(stored.task, entry.Changes) {
+		return domain.Task{}, domain.ErrConflict
+	}
+
+	stored.task = applyUpdate(stored.task, task)
+	stored.record(entry)
+	return r.withSubtaskCount(stored.task), nil
+}
+
+func (r *TaskRepositoryMemory) Delete(ctx context.Context, id string, entry domain.HistoryEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.live(ctx, id)
+	if !ok {
+		return errors.New("task not found")
+	}
+	stored.trash(entry)
+	return nil
+}
+
+func (r *TaskRepositoryMemory) Restore(ctx context.Context, id string, entry domain.HistoryEntry) (domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.tasks[id]
+	if !ok || stored.task.DeletedAt.IsZero() || !visibleIn(ctx, stored.task.WorkspaceID) {
+		return domain.Task{}, errors.New("task not found in trash")
+	}
+	stored.task.DeletedAt = time.Time{}
+	stored.task.DeletedBy = ""
+	stored.task.UpdatedAt = entry.At
+	stored.record(entry)
+	return r.withSubtaskCount(stored.task), nil
+}
+
+func (r *TaskRepositoryMemory) GetHistory(ctx context.Context, id string, offset, limit int) ([]domain.HistoryEntry, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.tasks[id]
+	if !ok || !visibleIn(ctx, stored.task.WorkspaceID) {
+		return nil, 0, errors.New("task not found")
+	}
+
+	total := len(stored.history)
+	start := min(max(offset, 0), total)
+	end := min(start+max(limit, 0), total)
+	return append([]domain.HistoryEntry{}, stored.history[start:end]...), total, nil
+}
+
+func (r *TaskRepositoryMemory) Purge(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.tasks[id]
+	if !ok || stored.task.DeletedAt.IsZero() || !visibleIn(ctx, stored.task.WorkspaceID) {
+		return errors.New("task not found in trash")
+	}
+	delete(r.tasks, id)
+	return nil
+}
+
+func (r *TaskRepositoryMemory) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged []string
+	for id, stored := range r.tasks {
+		if !stored.task.DeletedAt.IsZero() && stored.task.DeletedAt.Before(before) && visibleIn(ctx, stored.task.WorkspaceID) {
+			delete(r.tasks, id)
+			purged = append(purged, id)
+		}
+	}
+	sort.Slice(purged, func(i, j int) bool {
+		return idLess(purged[i], purged[j])
+	})
+	return purged, nil
+}
+
+func (r *TaskRepositoryMemory) BulkWrite(ctx context.Context, writes []domain.TaskWrite) ([]error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.bulkWrite(ctx, writes), nil
+}
+
+// BulkWriteAtomic applies the batch to a copy of the store and keeps the
+// copy only when every write succeeds, so that a write may depend on an
+// earlier one in the same batch, as in a MongoDB transaction.
+func (r *TaskRepositoryMemory) BulkWriteAtomic(ctx context.Context, writes []domain.TaskWrite) ([]error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	original := r.tasks
+	r.tasks = make(map[string]*memoryTask, len(original))
+	for id, stored := range original {
+		copied := *stored
+		// Clipped so that recording history on the copy never writes into
+		// the original's backing array.
+		copied.history = slices.Clip(stored.history)
+		r.tasks[id] = &copied
+	}
+
+	errs := r.bulkWrite(ctx, writes)
+	for _, err := range errs {
+		if err != nil {
+			r.tasks = original
+			return errs, domain.ErrBatchAborted
+		}
+	}
+	return errs, nil
+}
+
+func (r *TaskRepositoryMemory) bulkWrite(ctx context.Context, writes []domain.TaskWrite) []error {
+	errs := make([]error, len(writes))
+	for i, w := range writes {
+		if errs[i] = r.checkWrite(ctx, w); errs[i] != nil {
+			continue
+		}
+
+		switch w.Op {
+		case domain.BatchCreate:
+			created, err := r.create(ctx, w.Task, w.History)
+			if err != nil {
+				errs[i] = err
+				continue
+			}
+			writes[i].ID = created.ID
+			writes[i].Task.ID = created.ID
+			writes[i].Task.WorkspaceID = created.WorkspaceID
+		case domain.BatchUpdate:
+			stored := r.tasks[w.ID]
+			writes[i].History.Changes = domain.TaskChanges(stored.task, mergeUpdate(stored.task, w.Task))
+			stored.task = applyUpdate(stored.task, w.Task)
+			stored.record(writes[i].History)
+		case domain.BatchDelete:
+			r.tasks[w.ID].trash(w.History)
+		}
+	}
+	return errs
+}
+
+func (r *TaskRepositoryMemory) checkWrite(ctx context.Context, w domain.TaskWrite) error {
+	switch w.Op {
+	case domain.BatchCreate:
+		if _, taken := r.byExternalID(ctx, w.Task.ExternalID); taken {
+			return domain.ErrExternalIDTaken
+		}
+		return nil
+	case domain.BatchUpdate, domain.BatchDelete:
+		if _, ok := r.live(ctx, w.ID); !ok {
+			return errors.New("task not found")
+		}
+		return nil
+	default:
+		return errors.New("unknown operation")
+	}
+}
+
+func (r *TaskRepositoryMemory) CountTags(ctx context.Context) (map[string]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := map[string]int{}
+	for _, stored := range r.tasks {
+		if !stored.task.DeletedAt.IsZero() || !visibleIn(ctx, stored.task.WorkspaceID) {
+			continue
+		}
+		for _, tag := range stored.task.Tags {
+			counts[tag]++
+		}
+	}
+	return counts, nil
+}
+
+// RenameTag also renames the tag on trashed tasks, like the Mongo version.
+func (r *TaskRepositoryMemory) RenameTag(ctx context.Context, oldName, newName string, entry domain.HistoryEntry) ([]domain.TaskRevision, error) {
+	return r.revise(ctx, entry, func(task domain.Task) domain.Task {
+		task.Tags = renameTag(task.Tags, oldName, newName)
+		return task
+	}), nil
+}
+
+func (r *TaskRepositoryMemory) RemoveTag(ctx context.Context, name string, entry domain.HistoryEntry) ([]domain.TaskRevision, error) {
+	return r.revise(ctx, entry, func(task domain.Task) domain.Task {
+		task.Tags = removeTag(task.Tags, name)
+		return task
+	}), nil
+}
+
+// revise applies change to every task, trashed ones included, and records
+// entry with the changes of each task it alters.
+func (r *TaskRepositoryMemory) revise(ctx context.Context, entry domain.HistoryEntry, change func(domain.Task) domain.Task) []domain.TaskRevision {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var revisions []domain.TaskRevision
+	for _, stored := range r.tasks {
+		if !visibleIn(ctx, stored.task.WorkspaceID) {
+			continue
+		}
+		revised := change(copyTask(stored.task))
+		entry.Changes = domain.TaskChanges(stored.task, revised)
+		if len(entry.Changes) == 0 {
+			continue
+		}
+		revised.UpdatedAt = entry.At
+		stored.task = revised
+		stored.record(entry)
+		revisions = append(revisions, domain.TaskRevision{Task: r.withSubtaskCount(stored.task), Changes: entry.Changes})
+	}
+	return revisions
+}
+
+func (r *TaskRepositoryMemory) RemoveProject(ctx context.Context, projectID string, entry domain.HistoryEntry) ([]domain.TaskRevision, error) {
+	return r.revise(ctx, entry, func(task domain.Task) domain.Task {
+		if task.ProjectID == projectID {
+			task.ProjectID = ""
+		}
+		return task
+	}), nil
+}
+
+func (r *TaskRepositoryMemory) Stats(ctx context.Context, query domain.TaskStatsQuery) (domain.TaskStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := domain.NewTaskStats(query)
+	weekStart, weekEnd := query.Week()
+	var completions int
+	var completionTime time.Duration
+	for _, stored := range r.tasks {
+		task := stored.task
+		if !task.DeletedAt.IsZero() || !visibleIn(ctx, task.WorkspaceID) || !query.Filter.Matches(task) {
+			continue
+		}
+		stats.Total++
+		stats.ByStatus[task.Status]++
+		if task.Status != "completed" && !task.DueDate.IsZero() {
+			if task.DueDate.Before(query.Now) {
+				stats.Overdue++
+			}
+			if !task.DueDate.Before(weekStart) && task.DueDate.Before(weekEnd) {
+				stats.DueThisWeek++
+			}
+		}
+		if day := stats.DayOf(task.CreatedAt); day != nil {
+			day.Created++
+		}
+		for _, entry := range stored.history {
+			day := stats.DayOf(entry.At)
+			if day == nil || !completes(entry) {
+				continue
+			}
+			day.Completed++
+			completions++
+			completionTime += entry.At.Sub(task.CreatedAt)
+		}
+	}
+	if completions > 0 {
+		stats.AverageTimeToComplete = completionTime / time.Duration(completions)
+	}
+	return stats, nil
+}
+
+// completes reports whether entry set the task's status to completed.
+func completes(entry domain.HistoryEntry) bool {
+	for _, change := range entry.Changes {
+		if change.Field == "status" && change.New == "completed" {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *TaskRepositoryMemory) create(ctx context.Context, task domain.Task, entry domain.HistoryEntry) (domain.Task, error) {
+	workspaceID, err := workspaceFor(ctx, task.WorkspaceID)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	r.nextID++
+	task.ID = strconv.Itoa(r.nextID)
+	task.WorkspaceID = workspaceID
+	task = copyTask(startSeries(task))
+	r.tasks[task.ID] = &memoryTask{task: task, history: []domain.HistoryEntry{entry}}
+	return copyTask(task), nil
+}
+
+func (r *TaskRepositoryMemory) live(ctx context.Context, id string) (*memoryTask, bool) {
+	stored, ok := r.tasks[id]
+	if !ok || !stored.task.DeletedAt.IsZero() || !visibleIn(ctx, stored.task.WorkspaceID) {
+		return nil, false
+	}
+	return stored, true
+}
+
+// byExternalID finds the task, live or trashed, with the given external ID
+// in the workspace of ctx. An empty ID belongs to no task. The caller must
+// hold the lock.
+func (r *TaskRepositoryMemory) byExternalID(ctx context.Context, externalID string) (*memoryTask, bool) {
+	if externalID == "" {
+		return nil, false
+	}
+	for _, stored := range r.tasks {
+		if stored.task.ExternalID == externalID && visibleIn(ctx, stored.task.WorkspaceID) {
+			return stored, true
+		}
+	}
+	return nil, false
+}
+
+// withSubtaskCount returns a copy of task with its live subtasks counted.
+// The caller must hold the lock.
+func (r *TaskRepositoryMemory) withSubtaskCount(task domain.Task) domain.Task {
+	task = copyTask(task)
+	task.Subtasks = domain.SubtaskCount{}
+	for _, stored := range r.tasks {
+		if stored.task.ParentID != task.ID || !stored.task.DeletedAt.IsZero() {
+			continue
+		}
+		task.Subtasks.Total++
+		if stored.task.Status == "completed" {
+			task.Subtasks.Completed++
+		}
+	}
+	return task
+}
+
+// record adds entry to the front of the history. Updates that change
+// nothing are not recorded.
+func (t *memoryTask) record(entry domain.HistoryEntry) {
+	if entry.Action == domain.HistoryUpdate && len(entry.Changes) == 0 {
+		return
+	}
+	t.history = append([]domain.HistoryEntry{entry}, t.history...)
+}
+
+func (t *memoryTask) trash(entry domain.HistoryEntry) {
+	t.task.DeletedAt = entry.At
+	t.task.DeletedBy = entry.Actor
+	t.task.UpdatedAt = entry.At
+	t.record(entry)
+}
+
+// holdsOldValues reports whether every changed field of task still holds the
+// Old value its change was computed from.
+func holdsOldValues(task domain.Task, changes []domain.FieldChange) bool {
+	current := map[string]interface{}{}
+	for _, change := range domain.TaskChanges(domain.Task{}, task) {
+		current[change.Field] = change.New
+	}
+	for _, change := range changes {
+		if !reflect.DeepEqual(current[change.Field], change.Old) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyUpdate is the in-memory counterpart of mapToUpdate.
+func applyUpdate(task domain.Task, update domain.Task) domain.Task {
+	task = mergeUpdate(task, update)
+	if update.Checklist != nil {
+		task.Checklist = update.Checklist
+	}
+	if update.BlockedBy != nil {
+		task.BlockedBy = update.BlockedBy
+	}
+	task.UpdatedAt = time.Now()
+	return copyTask(task)
+}
+
+// copyTask detaches the task's lists so stored tasks cannot be changed
+// through values handed to or returned from the repository.
+func copyTask(task domain.Task) domain.Task {
+	if task.Checklist != nil {
+		task.Checklist = append([]domain.ChecklistItem{}, task.Checklist...)
+	}
+	if task.BlockedBy != nil {
+		task.BlockedBy = append([]string{}, task.BlockedBy...)
+	}
+	if task.Tags != nil {
+		task.Tags = append([]string{}, task.Tags...)
+	}
+	if task.Recurrence != nil {
+		recurrence := *task.Recurrence
+		task.Recurrence = &recurrence
+	}
+	return task
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -13,14 +13,15 @@ import (
 
 type UserRepositoryMongo struct {
 	collection *mongo.Collection
+	timeout    time.Duration
 }
 
-func NewUserRepositoryMongo(collection *mongo.Collection) domain.UserRepository {
-	return &UserRepositoryMongo{collection: collection}
+func NewUserRepositoryMongo(collection *mongo.Collection, timeout time.Duration) domain.UserRepository {
+	return &UserRepositoryMongo{collection: collection, timeout: timeout}
 }
 
-func (r *UserRepositoryMongo) Create(user domain.User) (domain.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *UserRepositoryMongo) Create(ctx context.Context, user domain.User) (domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	var existingUser domain.User
@@ -39,6 +40,10 @@ func (r *UserRepositoryMongo) Create(user domain.User) (domain.User, error) {
 	doc["_id"] = objectID
 
 	_, err = r.collection.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		// Another registration took the name since the check above.
+		return domain.User{}, errors.New("username already exists")
+	}
 	if err != nil {
 		return domain.User{}, err
 	}
@@ -46,8 +51,8 @@ func (r *UserRepositoryMongo) Create(user domain.User) (domain.User, error) {
 	return user, nil
 }
 
-func (r *UserRepositoryMongo) GetByUsername(username string) (domain.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *UserRepositoryMongo) GetByUsername(ctx context.Context, username string) (domain.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	var userDoc bson.M
@@ -62,13 +67,13 @@ func (r *UserRepositoryMongo) GetByUsername(username string) (domain.User, error
 	return r.mapToDomain(userDoc), nil
 }
 
-func (r *UserRepositoryMongo) GetByID(id string) (domain.User, error) {
+func (r *UserRepositoryMongo) GetByID(ctx context.Context, id string) (domain.User, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return domain.User{}, errors.New("invalid user ID format")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	var userDoc bson.M
@@ -83,8 +88,8 @@ func (r *UserRepositoryMongo) GetByID(id string) (domain.User, error) {
 	return r.mapToDomain(userDoc), nil
 }
 
-func (r *UserRepositoryMongo) UpdateRole(username string, role string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *UserRepositoryMongo) UpdateRole(ctx context.Context, username string, role string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	filter := bson.M{"username": username}
@@ -102,8 +107,8 @@ func (r *UserRepositoryMongo) UpdateRole(username string, role string) error {
 	return nil
 }
 
-func (r *UserRepositoryMongo) IsFirstUser() (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *UserRepositoryMongo) IsFirstUser(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	count, err := r.collection.CountDocuments(ctx, bson.M{})
@@ -113,6 +118,51 @@ func (r *UserRepositoryMongo) IsFirstUser() (bool, error) {
 	return count == 0, nil
 }
 
+func (r *UserRepositoryMongo) SetCalendarTokenHash(ctx context.Context, id string, hash string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid user ID format")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// The field is removed rather than emptied, so that the unique index only
+	// covers the users whose feed is on.
+	update := bson.M{"$unset": bson.M{"calendar_token_hash": ""}}
+	if hash != "" {
+		update = bson.M{"$set": bson.M{"calendar_token_hash": hash}}
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+func (r *UserRepositoryMongo) GetByCalendarTokenHash(ctx context.Context, hash string) (domain.User, error) {
+	if hash == "" {
+		return domain.User{}, errors.New("user not found")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var userDoc bson.M
+	err := r.collection.FindOne(ctx, bson.M{"calendar_token_hash": hash}).Decode(&userDoc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return domain.User{}, errors.New("user not found")
+		}
+		return domain.User{}, err
+	}
+
+	return r.mapToDomain(userDoc), nil
+}
+
 func (r *UserRepositoryMongo) mapToDomain(doc bson.M) domain.User {
 	user := domain.User{}
 	if id, ok := doc["_id"].(primitive.ObjectID); ok {
@@ -127,6 +177,9 @@ func (r *UserRepositoryMongo) mapToDomain(doc bson.M) domain.User {
 	if role, ok := doc["role"].(string); ok {
 		user.Role = role
 	}
+	if hash, ok := doc["calendar_token_hash"].(string); ok {
+		user.CalendarTokenHash = hash
+	}
 	return user
 }
 
@@ -138,4 +191,3 @@ func (r *UserRepositoryMongo) mapToDocument(user domain.User) bson.M {
 	}
 	return doc
 }
-
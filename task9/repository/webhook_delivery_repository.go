@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"task9/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WebhookDeliveryRepositoryMongo keeps one document per delivery. A pending
+// delivery is free to claim once lease_until has passed; new and released
+// deliveries have a zero lease_until.
+type WebhookDeliveryRepositoryMongo struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewWebhookDeliveryRepositoryMongo(collection *mongo.Collection, timeout time.Duration) domain.WebhookDeliveryRepository {
+	return &WebhookDeliveryRepositoryMongo{collection: collection, timeout: timeout}
+}
+
+func (r *WebhookDeliveryRepositoryMongo) Create(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		subscriptionID, err := primitive.ObjectIDFromHex(delivery.SubscriptionID)
+		if err != nil {
+			return errors.New("invalid webhook ID format")
+		}
+		doc := r.mapToDocument(delivery)
+		doc["_id"] = primitive.NewObjectID()
+		doc["subscription_id"] = subscriptionID
+		doc["lease_until"] = time.Time{}
+		docs = append(docs, doc)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.collection.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *WebhookDeliveryRepositoryMongo) GetByID(ctx context.Context, id string) (domain.WebhookDelivery, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.WebhookDelivery{}, errors.New("invalid delivery ID format")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var deliveryDoc bson.M
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&deliveryDoc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return domain.WebhookDelivery{}, errors.New("delivery not found")
+		}
+		return domain.WebhookDelivery{}, err
+	}
+
+	return r.mapToDomain(deliveryDoc), nil
+}
+
+func (r *WebhookDeliveryRepositoryMongo) ClaimNext(ctx context.Context, now time.Time, owner string, lease time.Duration) (domain.WebhookDelivery, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"status":          domain.WebhookPending,
+			"next_attempt_at": bson.M{"$lte": now},
+			"lease_until":     bson.M{"$lt": now},
+		},
+		bson.M{"$set": bson.M{"owner": owner, "lease_until": now.Add(lease)}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "next_attempt_at", Value: 1}, {Key: "_id", Value: 1}}).
+			SetReturnDocument(options.After),
+	)
+	if result.Err() == mongo.ErrNoDocuments {
+		return domain.WebhookDelivery{}, false, nil
+	}
+	if result.Err() != nil {
+		return domain.WebhookDelivery{}, false, result.Err()
+	}
+
+	var deliveryDoc bson.M
+	if err := result.Decode(&deliveryDoc); err != nil {
+		return domain.WebhookDelivery{}, false, err
+	}
+	return r.mapToDomain(deliveryDoc), true, nil
+}
+
+func (r *WebhookDeliveryRepositoryMongo) Record(ctx context.Context, delivery domain.WebhookDelivery, owner string) error {
+	objectID, err := primitive.ObjectIDFromHex(delivery.ID)
+	if err != nil {
+		return errors.New("invalid delivery ID format")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": objectID, "owner": owner, "status": domain.WebhookPending},
+		bson.M{"$set": bson.M{
+			"status":           delivery.Status,
+			"attempts":         delivery.Attempts,
+			"next_attempt_at":  delivery.NextAttemptAt,
+			"last_status_code": delivery.LastStatusCode,
+			"last_error":       delivery.LastError,
+			"updated_at":       delivery.UpdatedAt,
+			"delivered_at":     delivery.DeliveredAt,
+			"lease_until":      time.Time{},
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("webhook delivery lease lost")
+	}
+	return nil
+}
+
+func (r *WebhookDeliveryRepositoryMongo) Requeue(ctx context.Context, id string, now time.Time) (domain.WebhookDelivery, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.WebhookDelivery{}, errors.New("invalid delivery ID format")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": objectID, "status": domain.WebhookDead},
+		bson.M{"$set": bson.M{
+			"status":          domain.WebhookPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"updated_at":      now,
+			"lease_until":     time.Time{},
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+	if result.Err() == mongo.ErrNoDocuments {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return domain.WebhookDelivery{}, err
+		}
+		return domain.WebhookDelivery{}, domain.ErrConflict
+	}
+	if result.Err() != nil {
+		return domain.WebhookDelivery{}, result.Err()
+	}
+
+	var deliveryDoc bson.M
+	if err := result.Decode(&deliveryDoc); err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	return r.mapToDomain(deliveryDoc), nil
+}
+
+func (r *WebhookDeliveryRepositoryMongo) ListBySubscription(ctx context.Context, subscriptionID, status string, skip, limit int) ([]domain.WebhookDelivery, int, error) {
+	objectID, err := primitive.ObjectIDFromHex(subscriptionID)
+	if err != nil {
+		return nil, 0, errors.New("invalid webhook ID format")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter := bson.M{"subscription_id": objectID}
+	if status != "" {
+		filter["status"] = status
+	}
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []domain.WebhookDelivery{}
+	for cursor.Next(ctx) {
+		var deliveryDoc bson.M
+		if err := cursor.Decode(&deliveryDoc); err != nil {
+			return nil, 0, err
+		}
+		deliveries = append(deliveries, r.mapToDomain(deliveryDoc))
+	}
+
+	return deliveries, int(total), cursor.Err()
+}
+
+func (r *WebhookDeliveryRepositoryMongo) DeleteBySubscription(ctx context.Context, subscriptionID string) (int64, error) {
+	objectID, err := primitive.ObjectIDFromHex(subscriptionID)
+	if err != nil {
+		return 0, errors.New("invalid webhook ID format")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"subscription_id": objectID})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *WebhookDeliveryRepositoryMongo) mapToDocument(delivery domain.WebhookDelivery) bson.M {
+	return bson.M{
+		"event_id":         delivery.EventID,
+		"event_type":       delivery.EventType,
+		"payload":          string(delivery.Payload),
+		"status":           delivery.Status,
+		"attempts":         delivery.Attempts,
+		"next_attempt_at":  delivery.NextAttemptAt,
+		"last_status_code": delivery.LastStatusCode,
+		"last_error":       delivery.LastError,
+		"created_at":       delivery.CreatedAt,
+		"updated_at":       delivery.UpdatedAt,
+		"delivered_at":     delivery.DeliveredAt,
+	}
+}
+
+func (r *WebhookDeliveryRepositoryMongo) mapToDomain(doc bson.M) domain.WebhookDelivery {
+	delivery := domain.WebhookDelivery{}
+	if id, ok := doc["_id"].(primitive.ObjectID); ok {
+		delivery.ID = id.Hex()
+	}
+	if subscriptionID, ok := doc["subscription_id"].(primitive.ObjectID); ok {
+		delivery.SubscriptionID = subscriptionID.Hex()
+	}
+	if eventID, ok := doc["event_id"].(string); ok {
+		delivery.EventID = eventID
+	}
+	if eventType, ok := doc["event_type"].(string); ok {
+		delivery.EventType = eventType
+	}
+	if payload, ok := doc["payload"].(string); ok {
+		delivery.Payload = []byte(payload)
+	}
+	if status, ok := doc["status"].(string); ok {
+		delivery.Status = status
+	}
+	if attempts, ok := doc["attempts"].(int32); ok {
+		delivery.Attempts = int(attempts)
+	}
+	if attempts, ok := doc["attempts"].(int64); ok {
+		delivery.Attempts = int(attempts)
+	}
+	if nextAttemptAt, ok := doc["next_attempt_at"].(primitive.DateTime); ok {
+		delivery.NextAttemptAt = nextAttemptAt.Time()
+	}
+	if statusCode, ok := doc["last_status_code"].(int32); ok {
+		delivery.LastStatusCode = int(statusCode)
+	}
+	if statusCode, ok := doc["last_status_code"].(int64); ok {
+		delivery.LastStatusCode = int(statusCode)
+	}
+	if lastError, ok := doc["last_error"].(string); ok {
+		delivery.LastError = lastError
+	}
+	if createdAt, ok := doc["created_at"].(primitive.DateTime); ok {
+		delivery.CreatedAt = createdAt.Time()
+	}
+	if updatedAt, ok := doc["updated_at"].(primitive.DateTime); ok {
+		delivery.UpdatedAt = updatedAt.Time()
+	}
+	if deliveredAt, ok := doc["delivered_at"].(primitive.DateTime); ok {
+		delivery.DeliveredAt = deliveredAt.Time()
+	}
+	return delivery
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"task9/domain"
+	"time"
+)
+
+// WebhookDeliveryRepositoryMemory keeps webhook deliveries in process memory,
+// with the same lease rules as WebhookDeliveryRepositoryMongo. It is meant
+// for tests and local development without MongoDB.
+type WebhookDeliveryRepositoryMemory struct {
+	mu         sync.Mutex
+	deliveries map[string]*memoryDelivery
+	nextID     int
+}
+
+type memoryDelivery struct {
+	delivery   domain.WebhookDelivery
+	owner      string
+	leaseUntil time.Time
+}
+
+func NewWebhookDeliveryRepositoryMemory() domain.WebhookDeliveryRepository {
+	return &WebhookDeliveryRepositoryMemory{deliveries: map[string]*memoryDelivery{}}
+}
+
+func (r *WebhookDeliveryRepositoryMemory) Create(ctx context.Context, deliveries []domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, delivery := range deliveries {
+		r.nextID++
+		delivery.ID = strconv.Itoa(r.nextID)
+		delivery.Payload = append([]byte(nil), delivery.Payload...)
+		r.deliveries[delivery.ID] = &memoryDelivery{delivery: delivery}
+	}
+	return nil
+}
+
+func (r *WebhookDeliveryRepositoryMemory) GetByID(ctx context.Context, id string) (domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.deliveries[id]
+	if !ok {
+		return domain.WebhookDelivery{}, errors.New("delivery not found")
+	}
+	return stored.delivery, nil
+}
+
+func (r *WebhookDeliveryRepositoryMemory) ClaimNext(ctx context.Context, now time.Time, owner string, lease time.Duration) (domain.WebhookDelivery, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var next *memoryDelivery
+	for _, stored := range r.deliveries {
+		if stored.delivery.Status != domain.WebhookPending || stored.delivery.NextAttemptAt.After(now) || !stored.leaseUntil.Before(now) {
+			continue
+		}
+		if next == nil || stored.delivery.NextAttemptAt.Before(next.delivery.NextAttemptAt) ||
+			(stored.delivery.NextAttemptAt.Equal(next.delivery.NextAttemptAt) && idLess(stored.delivery.ID, next.delivery.ID)) {
+			next = stored
+		}
+	}
+	if next == nil {
+		return domain.WebhookDelivery{}, false, nil
+	}
+	next.owner = owner
+	next.leaseUntil = now.Add(lease)
+	return next.delivery, true, nil
+}
+
+func (r *WebhookDeliveryRepositoryMemory) Record(ctx context.Context, delivery domain.WebhookDelivery, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.deliveries[delivery.ID]
+	if !ok || stored.owner != owner || stored.delivery.Status != domain.WebhookPending {
+		return errors.New("webhook delivery lease lost")
+	}
+	stored.delivery.Status = delivery.Status
+	stored.delivery.Attempts = delivery.Attempts
+	stored.delivery.NextAttemptAt = delivery.NextAttemptAt
+	stored.delivery.LastStatusCode = delivery.LastStatusCode
+	stored.delivery.LastError = delivery.LastError
+	stored.delivery.UpdatedAt = delivery.UpdatedAt
+	stored.delivery.DeliveredAt = delivery.DeliveredAt
+	stored.leaseUntil = time.Time{}
+	return nil
+}
+
+func (r *WebhookDeliveryRepositoryMemory) Requeue(ctx context.Context, id string, now time.Time) (domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.deliveries[id]
+	if !ok {
+		return domain.WebhookDelivery{}, errors.New("delivery not found")
+	}
+	if stored.delivery.Status != domain.WebhookDead {
+		return domain.WebhookDelivery{}, domain.ErrConflict
+	}
+	stored.delivery.Status = domain.WebhookPending
+	stored.delivery.Attempts = 0
+	stored.delivery.NextAttemptAt = now
+	stored.delivery.UpdatedAt = now
+	stored.leaseUntil = time.Time{}
+	return stored.delivery, nil
+}
+
+func (r *WebhookDeliveryRepositoryMemory) ListBySubscription(ctx context.Context, subscriptionID, status string, skip, limit int) ([]domain.WebhookDelivery, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deliveries := []domain.WebhookDelivery{}
+	for _, stored := range r.deliveries {
+		if stored.delivery.SubscriptionID == subscriptionID && (status == "" || stored.delivery.Status == status) {
+			deliveries = append(deliveries, stored.delivery)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return idLess(deliveries[j].ID, deliveries[i].ID)
+	})
+
+	total := len(deliveries)
+	if skip >= total {
+		return []domain.WebhookDelivery{}, total, nil
+	}
+	end := skip + limit
+	if end > total {
+		end = total
+	}
+	return deliveries[skip:end], total, nil
+}
+
+func (r *WebhookDeliveryRepositoryMemory) DeleteBySubscription(ctx context.Context, subscriptionID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, stored := range r.deliveries {
+		if stored.delivery.SubscriptionID == subscriptionID {
+			delete(r.deliveries, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"task9/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WebhookRepositoryMongo struct {
+	collection *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewWebhookRepositoryMongo(collection *mongo.Collection, timeout time.Duration) domain.WebhookRepository {
+	return &WebhookRepositoryMongo{collection: collection, timeout: timeout}
+}
+
+func (r *WebhookRepositoryMongo) List(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	subscriptions := []domain.WebhookSubscription{}
+	for cursor.Next(ctx) {
+		var webhookDoc bson.M
+		if err := cursor.Decode(&webhookDoc); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, r.mapToDomain(webhookDoc))
+	}
+
+	return subscriptions, cursor.Err()
+}
+
+func (r *WebhookRepositoryMongo) GetByID(ctx context.Context, id string) (domain.WebhookSubscription, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.WebhookSubscription{}, errors.New("invalid webhook ID format")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var webhookDoc bson.M
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&webhookDoc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return domain.WebhookSubscription{}, errors.New("webhook not found")
+		}
+		return domain.WebhookSubscription{}, err
+	}
+
+	return r.mapToDomain(webhookDoc), nil
+}
+
+func (r *WebhookRepositoryMongo) Create(ctx context.Context, subscription domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	objectID := primitive.NewObjectID()
+	subscription.ID = objectID.Hex()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	doc := r.mapToDocument(subscription)
+	doc["_id"] = objectID
+	if _, err := r.collection.InsertOne(ctx, doc); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+
+	return subscription, nil
+}
+
+func (r *WebhookRepositoryMongo) Update(ctx context.Context, id string, subscription domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.WebhookSubscription{}, errors.New("invalid webhook ID format")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	update := r.mapToDocument(subscription)
+	delete(update, "created_at")
+	result := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": objectID},
+		bson.M{"$set": update},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+
+	if result.Err() != nil {
+		if result.Err() == mongo.ErrNoDocuments {
+			return domain.WebhookSubscription{}, errors.New("webhook not found")
+		}
+		return domain.WebhookSubscription{}, result.Err()
+	}
+
+	var webhookDoc bson.M
+	if err := result.Decode(&webhookDoc); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+
+	return r.mapToDomain(webhookDoc), nil
+}
+
+func (r *WebhookRepositoryMongo) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid webhook ID format")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return errors.New("webhook not found")
+	}
+
+	return nil
+}
+
+func (r *WebhookRepositoryMongo) mapToDocument(subscription domain.WebhookSubscription) bson.M {
+	events := subscription.Events
+	if events == nil {
+		events = []string{}
+	}
+	return bson.M{
+		"url":        subscription.URL,
+		"events":     events,
+		"secret":     subscription.Secret,
+		"active":     subscription.Active,
+		"created_at": subscription.CreatedAt,
+		"updated_at": subscription.UpdatedAt,
+	}
+}
+
+func (r *WebhookRepositoryMongo) mapToDomain(doc bson.M) domain.WebhookSubscription {
+	subscription := domain.WebhookSubscription{}
+	if id, ok := doc["_id"].(primitive.ObjectID); ok {
+		subscription.ID = id.Hex()
+	}
+	if url, ok := doc["url"].(string); ok {
+		subscription.URL = url
+	}
+	subscription.Events = mapStringsToDomain(doc["events"])
+	if secret, ok := doc["secret"].(string); ok {
+		subscription.Secret = secret
+	}
+	if active, ok := doc["active"].(bool); ok {
+		subscription.Active = active
+	}
+	if createdAt, ok := doc["created_at"].(primitive.DateTime); ok {
+		subscription.CreatedAt = createdAt.Time()
+	}
+	if updatedAt, ok := doc["updated_at"].(primitive.DateTime); ok {
+		subscription.UpdatedAt = updatedAt.Time()
+	}
+	return subscription
+}
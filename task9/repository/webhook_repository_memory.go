@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"task9/domain"
+)
+
+// WebhookRepositoryMemory keeps webhook subscriptions in process memory. It
+// is meant for tests and local development without MongoDB.
+type WebhookRepositoryMemory struct {
+	mu            sync.RWMutex
+	subscriptions map[string]domain.WebhookSubscription
+	nextID        int
+}
+
+func NewWebhookRepositoryMemory() domain.WebhookRepository {
+	return &WebhookRepositoryMemory{subscriptions: map[string]domain.WebhookSubscription{}}
+}
+
+func (r *WebhookRepositoryMemory) List(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscriptions := []domain.WebhookSubscription{}
+	for _, subscription := range r.subscriptions {
+		subscriptions = append(subscriptions, copySubscription(subscription))
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return idLess(subscriptions[i].ID, subscriptions[j].ID)
+	})
+	return subscriptions, nil
+}
+
+func (r *WebhookRepositoryMemory) GetByID(ctx context.Context, id string) (domain.WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscription, ok := r.subscriptions[id]
+	if !ok {
+		return domain.WebhookSubscription{}, errors.New("webhook not found")
+	}
+	return copySubscription(subscription), nil
+}
+
+func (r *WebhookRepositoryMemory) Create(ctx context.Context, subscription domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	subscription.ID = strconv.Itoa(r.nextID)
+	r.subscriptions[subscription.ID] = copySubscription(subscription)
+	return subscription, nil
+}
+
+func (r *WebhookRepositoryMemory) Update(ctx context.Context, id string, subscription domain.WebhookSubscription) (domain.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.subscriptions[id]
+	if !ok {
+		return domain.WebhookSubscription{}, errors.New("webhook not found")
+	}
+	subscription.ID = id
+	subscription.CreatedAt = current.CreatedAt
+	r.subscriptions[id] = copySubscription(subscription)
+	return subscription, nil
+}
+
+func (r *WebhookRepositoryMemory) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subscriptions[id]; !ok {
+		return errors.New("webhook not found")
+	}
+	delete(r.subscriptions, id)
+	return nil
+}
+
+func copySubscription(subscription domain.WebhookSubscription) domain.WebhookSubscription {
+	subscription.Events = append([]string(nil), subscription.Events...)
+	return subscription
+}
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"task9/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WorkspaceRepositoryMongo keeps workspaces in one collection and their
+// members in another, with one document per membership.
+type WorkspaceRepositoryMongo struct {
+	workspaces *mongo.Collection
+	members    *mongo.Collection
+	timeout    time.Duration
+}
+
+func NewWorkspaceRepositoryMongo(workspaces, members *mongo.Collection, timeout time.Duration) domain.WorkspaceRepository {
+	return &WorkspaceRepositoryMongo{workspaces: workspaces, members: members, timeout: timeout}
+}
+
+func (r *WorkspaceRepositoryMongo) Create(ctx context.Context, workspace domain.Workspace) (domain.Workspace, error) {
+	objectID := primitive.NewObjectID()
+	workspace.ID = objectID.Hex()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.workspaces.InsertOne(ctx, bson.M{
+		"_id":        objectID,
+		"name":       workspace.Name,
+		"created_at": workspace.CreatedAt,
+	})
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+
+	return workspace, nil
+}
+
+func (r *WorkspaceRepositoryMongo) GetByID(ctx context.Context, id string) (domain.Workspace, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.Workspace{}, errors.New("workspace not found")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var workspaceDoc bson.M
+	err = r.workspaces.FindOne(ctx, bson.M{"_id": objectID}).Decode(&workspaceDoc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return domain.Workspace{}, errors.New("workspace not found")
+		}
+		return domain.Workspace{}, err
+	}
+
+	return r.mapToDomain(workspaceDoc), nil
+}
+
+func (r *WorkspaceRepositoryMongo) ListForUser(ctx context.Context, userID string) ([]domain.Workspace, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	memberships, err := r.findMembers(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	roles := map[string]string{}
+	ids := make([]primitive.ObjectID, 0, len(memberships))
+	for _, membership := range memberships {
+		if objectID, err := primitive.ObjectIDFromHex(membership.WorkspaceID); err == nil {
+			roles[membership.WorkspaceID] = membership.Role
+			ids = append(ids, objectID)
+		}
+	}
+
+	workspaces := []domain.Workspace{}
+	if len(ids) == 0 {
+		return workspaces, nil
+	}
+	cursor, err := r.workspaces.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var workspaceDoc bson.M
+		if err := cursor.Decode(&workspaceDoc); err != nil {
+			return nil, err
+		}
+		workspace := r.mapToDomain(workspaceDoc)
+		workspace.Role = roles[workspace.ID]
+		workspaces = append(workspaces, workspace)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(workspaces, func(i, j int) bool {
+		if workspaces[i].Name != workspaces[j].Name {
+			return workspaces[i].Name < workspaces[j].Name
+		}
+		return workspaces[i].ID < workspaces[j].ID
+	})
+	return workspaces, nil
+}
+
+func (r *WorkspaceRepositoryMongo) AddMember(ctx context.Context, membership domain.Membership) (domain.Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.members.InsertOne(ctx, bson.M{
+		"workspace_id": membership.WorkspaceID,
+		"user_id":      membership.UserID,
+		"username":     membership.Username,
+		"role":         membership.Role,
+		"created_at":   membership.CreatedAt,
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return domain.Membership{}, domain.ErrAlreadyMember
+		}
+		return domain.Membership{}, err
+	}
+
+	return membership, nil
+}
+
+func (r *WorkspaceRepositoryMongo) GetMember(ctx context.Context, workspaceID, userID string) (domain.Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var memberDoc bson.M
+	err := r.members.FindOne(ctx, bson.M{"workspace_id": workspaceID, "user_id": userID}).Decode(&memberDoc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return domain.Membership{}, errors.New("member not found")
+		}
+		return domain.Membership{}, err
+	}
+
+	return r.mapMemberToDomain(memberDoc), nil
+}
+
+func (r *WorkspaceRepositoryMongo) ListMembers(ctx context.Context, workspaceID string) ([]domain.Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.findMembers(ctx, bson.M{"workspace_id": workspaceID})
+}
+
+func (r *WorkspaceRepositoryMongo) UpdateMemberRole(ctx context.Context, workspaceID, userID, role string) (domain.Membership, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result := r.members.FindOneAndUpdate(
+		ctx,
+		bson.M{"workspace_id": workspaceID, "user_id": userID},
+		bson.M{"$set": bson.M{"role": role}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+
+	if result.Err() != nil {
+		if result.Err() == mongo.ErrNoDocuments {
+			return domain.Membership{}, errors.New("member not found")
+		}
+		return domain.Membership{}, result.Err()
+	}
+
+	var memberDoc bson.M
+	if err := result.Decode(&memberDoc); err != nil {
+		return domain.Membership{}, err
+	}
+
+	return r.mapMemberToDomain(memberDoc), nil
+}
+
+func (r *WorkspaceRepositoryMongo) RemoveMember(ctx context.Context, workspaceID, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.members.DeleteOne(ctx, bson.M{"workspace_id": workspaceID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return errors.New("member not found")
+	}
+
+	return nil
+}
+
+func (r *WorkspaceRepositoryMongo) findMembers(ctx context.Context, filter bson.M) ([]domain.Membership, error) {
+	cursor, err := r.members.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "username", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	members := []domain.Membership{}
+	for cursor.Next(ctx) {
+		var memberDoc bson.M
+		if err := cursor.Decode(&memberDoc); err != nil {
+			return nil, err
+		}
+		members = append(members, r.mapMemberToDomain(memberDoc))
+	}
+
+	return members, cursor.Err()
+}
+
+func (r *WorkspaceRepositoryMongo) mapToDomain(doc bson.M) domain.Workspace {
+	workspace := domain.Workspace{}
+	if id, ok := doc["_id"].(primitive.ObjectID); ok {
+		workspace.ID = id.Hex()
+	}
+	if name, ok := doc["name"].(string); ok {
+		workspace.Name = name
+	}
+	if createdAt, ok := doc["created_at"].(primitive.DateTime); ok {
+		workspace.CreatedAt = createdAt.Time()
+	}
+	return workspace
+}
+
+func (r *WorkspaceRepositoryMongo) mapMemberToDomain(doc bson.M) domain.Membership {
+	membership := domain.Membership{}
+	if workspaceID, ok := doc["workspace_id"].(string); ok {
+		membership.WorkspaceID = workspaceID
+	}
+	if userID, ok := doc["user_id"].(string); ok {
+		membership.UserID = userID
+	}
+	if username, ok := doc["username"].(string); ok {
+		membership.Username = username
+	}
+	if role, ok := doc["role"].(string); ok {
+		membership.Role = role
+	}
+	if createdAt, ok := doc["created_at"].(primitive.DateTime); ok {
+		membership.CreatedAt = createdAt.Time()
+	}
+	return membership
+}
+
+// inWorkspace narrows filter to the workspace of ctx. Every query on
+// workspace data goes through it, and it refuses a ctx that is neither
+// scoped to a workspace nor marked with domain.AllWorkspaces.
+func inWorkspace(ctx context.Context, filter bson.M) (bson.M, error) {
+	workspaceID, err := domain.WorkspaceScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if workspaceID != "" {
+		filter["workspace_id"] = workspaceID
+	}
+	return filter, nil
+}
+
+// workspaceFor returns the workspace a record written through ctx belongs
+// to: that of ctx, or, for a ctx marked with domain.AllWorkspaces, the one
+// the record already names.
+func workspaceFor(ctx context.Context, workspaceID string) (string, error) {
+	scoped, err := domain.WorkspaceScope(ctx)
+	if err != nil {
+		return "", err
+	}
+	if scoped != "" {
+		return scoped, nil
+	}
+	return workspaceID, nil
+}
+
+// visibleIn is the in-memory counterpart of inWorkspace: it reports whether
+// a record of workspaceID may be seen through ctx. Nothing is visible
+// through a ctx that inWorkspace would refuse.
+func visibleIn(ctx context.Context, workspaceID string) bool {
+	scoped, err := domain.WorkspaceScope(ctx)
+	return err == nil && (scoped == "" || scoped == workspaceID)
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"task9/domain"
+)
+
+// WorkspaceRepositoryMemory keeps workspaces and their members in process
+// memory. It is meant for tests and local development without MongoDB.
+type WorkspaceRepositoryMemory struct {
+	mu         sync.RWMutex
+	workspaces map[string]domain.Workspace
+	// members is keyed by workspace ID, then user ID.
+	members map[string]map[string]domain.Membership
+	nextID  int
+}
+
+func NewWorkspaceRepositoryMemory() domain.WorkspaceRepository {
+	return &WorkspaceRepositoryMemory{
+		workspaces: map[string]domain.Workspace{},
+		members:    map[string]map[string]domain.Membership{},
+	}
+}
+
+func (r *WorkspaceRepositoryMemory) Create(ctx context.Context, workspace domain.Workspace) (domain.Workspace, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	workspace.ID = strconv.Itoa(r.nextID)
+	workspace.Role = ""
+	r.workspaces[workspace.ID] = workspace
+	return workspace, nil
+}
+
+func (r *WorkspaceRepositoryMemory) GetByID(ctx context.Context, id string) (domain.Workspace, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	workspace, ok := r.workspaces[id]
+	if !ok {
+		return domain.Workspace{}, errors.New("workspace not found")
+	}
+	return workspace, nil
+}
+
+func (r *WorkspaceRepositoryMemory) ListForUser(ctx context.Context, userID string) ([]domain.Workspace, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	workspaces := []domain.Workspace{}
+	for workspaceID, members := range r.members {
+		membership, ok := members[userID]
+		if !ok {
+			continue
+		}
+		workspace := r.workspaces[workspaceID]
+		workspace.Role = membership.Role
+		workspaces = append(workspaces, workspace)
+	}
+	sort.Slice(workspaces, func(i, j int) bool {
+		if workspaces[i].Name != workspaces[j].Name {
+			return workspaces[i].Name < workspaces[j].Name
+		}
+		return idLess(workspaces[i].ID, workspaces[j].ID)
+	})
+	return workspaces, nil
+}
+
+func (r *WorkspaceRepositoryMemory) AddMember(ctx context.Context, membership domain.Membership) (domain.Membership, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	members := r.members[membership.WorkspaceID]
+	if members == nil {
+		members = map[string]domain.Membership{}
+		r.members[membership.WorkspaceID] = members
+	}
+	if _, ok := members[membership.UserID]; ok {
+		return domain.Membership{}, domain.ErrAlreadyMember
+	}
+	members[membership.UserID] = membership
+	return membership, nil
+}
+
+func (r *WorkspaceRepositoryMemory) GetMember(ctx context.Context, workspaceID, userID string) (domain.Membership, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	membership, ok := r.members[workspaceID][userID]
+	if !ok {
+		return domain.Membership{}, errors.New("member not found")
+	}
+	return membership, nil
+}
+
+func (r *WorkspaceRepositoryMemory) ListMembers(ctx context.Context, workspaceID string) ([]domain.Membership, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	members := []domain.Membership{}
+	for _, membership := range r.members[workspaceID] {
+		members = append(members, membership)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Username < members[j].Username
+	})
+	return members, nil
+}
+
+func (r *WorkspaceRepositoryMemory) UpdateMemberRole(ctx context.Context, workspaceID, userID, role string) (domain.Membership, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	membership, ok := r.members[workspaceID][userID]
+	if !ok {
+		return domain.Membership{}, errors.New("member not found")
+	}
+	membership.Role = role
+	r.members[workspaceID][userID] = membership
+	return membership, nil
+}
+
+func (r *WorkspaceRepositoryMemory) RemoveMember(ctx context.Context, workspaceID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.members[workspaceID][userID]; !ok {
+		return errors.New("member not found")
+	}
+	delete(r.members[workspaceID], userID)
+	return nil
+}
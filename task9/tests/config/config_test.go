@@ -0,0 +1,199 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"task9/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := config.Load("")
+
+	require.NoError(t, err)
+	assert.Equal(t, ":8080", cfg.Server.Address)
+	assert.Equal(t, "task_manager", cfg.Database.Name)
+	assert.Equal(t, 10*time.Second, cfg.Database.QueryTimeout)
+	assert.True(t, cfg.Database.MigrateOnStartup)
+	assert.Equal(t, 24*time.Hour, cfg.Auth.TokenTTL)
+	assert.Equal(t, time.Minute, cfg.Auth.StreamTokenTTL)
+	assert.Equal(t, 6, cfg.Password.MinLength)
+	assert.Equal(t, 30*24*time.Hour, cfg.Trash.Retention)
+	assert.Equal(t, "block", cfg.Tasks.CompletionPolicy)
+	assert.Equal(t, 500, cfg.Tasks.MaxHistoryEntries)
+	assert.Equal(t, time.Minute, cfg.Recurrence.GenerateInterval)
+	assert.Equal(t, []time.Duration{24 * time.Hour, time.Hour}, cfg.Reminders.Windows)
+	assert.True(t, cfg.Reminders.Overdue)
+	assert.Equal(t, 24*time.Hour, cfg.Reminders.OverdueLookback)
+	assert.Equal(t, 5, cfg.Reminders.MaxAttempts)
+	assert.Equal(t, "log", cfg.Reminders.Notifier)
+	assert.Equal(t, 8, cfg.Webhooks.MaxAttempts)
+	assert.Equal(t, 30*time.Second, cfg.Webhooks.Backoff)
+	assert.Equal(t, 64, cfg.Stream.Buffer)
+	assert.Equal(t, int64(10<<20), cfg.Limits.MaxImportBytes)
+	assert.Equal(t, "task-manager", cfg.Calendar.UIDDomain)
+	assert.Equal(t, "local", cfg.Attachments.Store)
+	assert.Equal(t, int64(25<<20), cfg.Attachments.MaxBytes)
+	assert.Equal(t, 24*time.Hour, cfg.Idempotency.TTL)
+	assert.Equal(t, "memory", cfg.Cache.Store)
+	assert.Equal(t, 10000, cfg.Cache.Size)
+	assert.Equal(t, 5*time.Second, cfg.Cache.TTL)
+}
+
+func TestLoad_File(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  address: ":9090"
+database:
+  uri: "mongodb://db:27017"
+  name: "tasks_prod"
+  query_timeout: 3s
+auth:
+  jwt_secret: "from-file"
+  token_ttl: 2h
+password:
+  min_length: 10
+`)
+
+	cfg, err := config.Load(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, ":9090", cfg.Server.Address)
+	assert.Equal(t, "mongodb://db:27017", cfg.Database.URI)
+	assert.Equal(t, "tasks_prod", cfg.Database.Name)
+	assert.Equal(t, 3*time.Second, cfg.Database.QueryTimeout)
+	assert.Equal(t, 10*time.Second, cfg.Database.ConnectTimeout)
+	assert.Equal(t, "from-file", cfg.Auth.JWTSecret)
+	assert.Equal(t, 2*time.Hour, cfg.Auth.TokenTTL)
+	assert.Equal(t, 10, cfg.Password.MinLength)
+}
+
+func TestLoad_EnvironmentOverridesFile(t *testing.T) {
+	path := writeConfigFile(t, `
+database:
+  name: "from_file"
+auth:
+  jwt_secret: "from-file"
+`)
+	t.Setenv("MONGODB_DB", "from_env")
+	t.Setenv("JWT_SECRET", "from-env")
+	t.Setenv("MONGODB_QUERY_TIMEOUT", "500ms")
+	t.Setenv("MONGODB_MIGRATE_ON_STARTUP", "false")
+	t.Setenv("REMINDERS_WINDOWS", "48h, 30m")
+	t.Setenv("REMINDERS_OVERDUE", "false")
+	t.Setenv("REMINDERS_SMTP_TO", "a@example.com,b@example.com")
+	t.Setenv("WEBHOOKS_MAX_ATTEMPTS", "3")
+	t.Setenv("CACHE_TTL", "0")
+
+	cfg, err := config.Load(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, "from_env", cfg.Database.Name)
+	assert.Equal(t, "from-env", cfg.Auth.JWTSecret)
+	assert.Equal(t, 500*time.Millisecond, cfg.Database.QueryTimeout)
+	assert.False(t, cfg.Database.MigrateOnStartup)
+	assert.Equal(t, []time.Duration{48 * time.Hour, 30 * time.Minute}, cfg.Reminders.Windows)
+	assert.False(t, cfg.Reminders.Overdue)
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, cfg.Reminders.SMTP.To)
+	assert.Equal(t, 3, cfg.Webhooks.MaxAttempts)
+	assert.Zero(t, cfg.Cache.TTL)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Run("missing file", func(t *testing.T) {
+		_, err := config.Load(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.Error(t, err)
+	})
+
+	t.Run("malformed yaml", func(t *testing.T) {
+		path := writeConfigFile(t, "server: [unclosed")
+		_, err := config.Load(path)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid environment value", func(t *testing.T) {
+		t.Setenv("JWT_TOKEN_TTL", "forever")
+		_, err := config.Load("")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "JWT_TOKEN_TTL")
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("default configuration is valid", func(t *testing.T) {
+		assert.NoError(t, config.Default().Validate())
+	})
+
+	t.Run("reports every problem", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Server.Address = ""
+		cfg.Database.URI = "localhost:27017"
+		cfg.Auth.JWTSecret = ""
+		cfg.Auth.StreamTokenTTL = 0
+		cfg.Password.BcryptCost = 99
+		cfg.Tasks.CompletionPolicy = "ignore"
+		cfg.Tasks.MaxHistoryEntries = -1
+		cfg.Recurrence.GenerateInterval = -time.Second
+		cfg.Reminders.Notifier = "smtp"
+		cfg.Reminders.MaxAttempts = 0
+		cfg.Webhooks.MaxAttempts = 0
+		cfg.Webhooks.Lease = cfg.Webhooks.Timeout
+		cfg.Stream.Buffer = 0
+		cfg.Stream.AllowedOrigins = []string{"app.example.com"}
+		cfg.Limits.MaxImportBytes = 0
+		cfg.Calendar.UIDDomain = "tasks@example.com"
+		cfg.Attachments.Store = "ftp"
+		cfg.Attachments.AllowedTypes = []string{"pdf"}
+		cfg.Idempotency.LockTimeout = 0
+		cfg.Cache.Size = 0
+
+		err := cfg.Validate()
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "server.address")
+		assert.Contains(t, err.Error(), "database.uri")
+		assert.Contains(t, err.Error(), "auth.jwt_secret")
+		assert.Contains(t, err.Error(), "auth.stream_token_ttl")
+		assert.Contains(t, err.Error(), "password.bcrypt_cost")
+		assert.Contains(t, err.Error(), "tasks.completion_policy")
+		assert.Contains(t, err.Error(), "tasks.max_history_entries")
+		assert.Contains(t, err.Error(), "recurrence.generate_interval")
+		assert.Contains(t, err.Error(), "reminders.smtp.addr")
+		assert.Contains(t, err.Error(), "reminders.smtp.to")
+		assert.Contains(t, err.Error(), "reminders.max_attempts")
+		assert.Contains(t, err.Error(), "webhooks.max_attempts")
+		assert.Contains(t, err.Error(), "webhooks.lease")
+		assert.Contains(t, err.Error(), "stream.buffer")
+		assert.Contains(t, err.Error(), "stream.allowed_origins")
+		assert.Contains(t, err.Error(), "limits.max_import_bytes")
+		assert.Contains(t, err.Error(), "calendar.uid_domain")
+		assert.Contains(t, err.Error(), "attachments.store")
+		assert.Contains(t, err.Error(), "attachments.allowed_types")
+		assert.Contains(t, err.Error(), "idempotency.lock_timeout")
+		assert.Contains(t, err.Error(), "cache.size")
+	})
+
+	t.Run("reminder settings are ignored while reminders are off", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Reminders.Interval = 0
+		cfg.Reminders.Notifier = "pager"
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("cache settings are ignored while the cache is off", func(t *testing.T) {
+		cfg := config.Default()
+		cfg.Cache.TTL = 0
+		cfg.Cache.Store = "redis"
+		assert.NoError(t, cfg.Validate())
+	})
+}
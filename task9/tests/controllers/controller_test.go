@@ -34,7 +34,7 @@ func TestTaskHandler_GetAllTasks(t *testing.T) {
 			{ID: "2", Title: "Task 2", Status: "completed"},
 		}
 
-		mockTaskRepo.On("GetAll").Return(expectedTasks, nil)
+		mockTaskRepo.On("GetAll", mock.Anything).Return(expectedTasks, nil)
 
 		router := setupTestRouter()
 		router.GET("/tasks", taskHandler.GetAllTasks)
@@ -52,7 +52,7 @@ func TestTaskHandler_GetAllTasks(t *testing.T) {
 	})
 
 	t.Run("internal server error", func(t *testing.T) {
-		mockTaskRepo.On("GetAll").Return([]domain.Task{}, errors.New("database error"))
+		mockTaskRepo.On("GetAll", mock.Anything).Return([]domain.Task{}, errors.New("database error"))
 
 		router := setupTestRouter()
 		router.GET("/tasks", taskHandler.GetAllTasks)
@@ -79,7 +79,7 @@ func TestTaskHandler_GetTaskByID(t *testing.T) {
 			Status: "pending",
 		}
 
-		mockTaskRepo.On("GetByID", "123").Return(expectedTask, nil)
+		mockTaskRepo.On("GetByID", mock.Anything, "123").Return(expectedTask, nil)
 
 		router := setupTestRouter()
 		router.GET("/tasks/:id", taskHandler.GetTaskByID)
@@ -97,7 +97,7 @@ func TestTaskHandler_GetTaskByID(t *testing.T) {
 	})
 
 	t.Run("task not found", func(t *testing.T) {
-		mockTaskRepo.On("GetByID", "999").Return(domain.Task{}, errors.New("task not found"))
+		mockTaskRepo.On("GetByID", mock.Anything, "999").Return(domain.Task{}, errors.New("task not found"))
 
 		router := setupTestRouter()
 		router.GET("/tasks/:id", taskHandler.GetTaskByID)
@@ -112,7 +112,7 @@ func TestTaskHandler_GetTaskByID(t *testing.T) {
 	})
 
 	t.Run("invalid ID format", func(t *testing.T) {
-		mockTaskRepo.On("GetByID", "invalid").Return(domain.Task{}, errors.New("invalid task ID format"))
+		mockTaskRepo.On("GetByID", mock.Anything, "invalid").Return(domain.Task{}, errors.New("invalid task ID format"))
 
 		router := setupTestRouter()
 		router.GET("/tasks/:id", taskHandler.GetTaskByID)
@@ -142,7 +142,7 @@ func TestTaskHandler_CreateTask(t *testing.T) {
 
 		jsonBody, _ := json.Marshal(reqBody)
 
-		mockTaskRepo.On("Create", mock.AnythingOfType("domain.Task")).Return(domain.Task{
+		mockTaskRepo.On("Create", mock.Anything, mock.AnythingOfType("domain.Task"), mock.AnythingOfType("domain.HistoryEntry")).Return(domain.Task{
 			ID:     "123",
 			Title:  "New Task",
 			Status: "pending",
@@ -206,8 +206,8 @@ func TestAuthHandler_Register(t *testing.T) {
 
 		jsonBody, _ := json.Marshal(reqBody)
 
-		mockUserRepo.On("IsFirstUser").Return(true, nil)
-		mockUserRepo.On("Create", mock.AnythingOfType("domain.User")).Return(domain.User{
+		mockUserRepo.On("IsFirstUser", mock.Anything).Return(true, nil)
+		mockUserRepo.On("Create", mock.Anything, mock.AnythingOfType("domain.User")).Return(domain.User{
 			ID:       "123",
 			Username: "newuser",
 			Role:     "admin",
@@ -260,7 +260,7 @@ func TestAuthHandler_Login(t *testing.T) {
 		jsonBody, _ := json.Marshal(reqBody)
 
 		hashedPassword, _ := setupPasswordHasher().Hash("password123")
-		mockUserRepo.On("GetByUsername", "testuser").Return(domain.User{
+		mockUserRepo.On("GetByUsername", mock.Anything, "testuser").Return(domain.User{
 			ID:       "123",
 			Username: "testuser",
 			Password: hashedPassword,
@@ -292,7 +292,7 @@ func TestAuthHandler_Login(t *testing.T) {
 		jsonBody, _ := json.Marshal(reqBody)
 
 		hashedPassword, _ := setupPasswordHasher().Hash("correctpassword")
-		mockUserRepo.On("GetByUsername", "testuser").Return(domain.User{
+		mockUserRepo.On("GetByUsername", mock.Anything, "testuser").Return(domain.User{
 			ID:       "123",
 			Username: "testuser",
 			Password: hashedPassword,
@@ -353,4 +353,3 @@ func (m *mockTokenGenerator) Validate(tokenString string) (map[string]interface{
 		"role":     "user",
 	}, nil
 }
-
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"task9/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskChanges(t *testing.T) {
+	due := time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC)
+
+	t.Run("only changed fields are listed", func(t *testing.T) {
+		before := domain.Task{Title: "Task", Status: "pending", DueDate: due}
+		after := before
+		after.Status = "completed"
+		after.DueDate = due.Add(time.Hour)
+
+		changes := domain.TaskChanges(before, after)
+
+		assert.Equal(t, []domain.FieldChange{
+			{Field: "due_date", Old: due, New: due.Add(time.Hour)},
+			{Field: "status", Old: "pending", New: "completed"},
+		}, changes)
+	})
+
+	t.Run("empty values are nil", func(t *testing.T) {
+		changes := domain.TaskChanges(domain.Task{}, domain.Task{Title: "Task"})
+
+		assert.Equal(t, []domain.FieldChange{{Field: "title", Old: nil, New: "Task"}}, changes)
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		task := domain.Task{Title: "Task", DueDate: due.In(time.FixedZone("EAT", 3*3600))}
+
+		assert.Empty(t, domain.TaskChanges(task, domain.Task{Title: "Task", DueDate: due}))
+	})
+}
+
+func TestTaskProgress(t *testing.T) {
+	t.Run("counts checklist items and subtasks together", func(t *testing.T) {
+		task := domain.Task{
+			Checklist: []domain.ChecklistItem{{ID: "a", Done: true}, {ID: "b"}},
+			Subtasks:  domain.SubtaskCount{Total: 2, Completed: 1},
+		}
+
+		percent, ok := task.Progress()
+
+		assert.True(t, ok)
+		assert.Equal(t, 50, percent)
+		assert.True(t, task.HasOpenItems())
+	})
+
+	t.Run("no items", func(t *testing.T) {
+		_, ok := domain.Task{}.Progress()
+
+		assert.False(t, ok)
+		assert.False(t, domain.Task{}.HasOpenItems())
+	})
+
+	t.Run("checklist changes are tracked", func(t *testing.T) {
+		before := domain.Task{Checklist: []domain.ChecklistItem{{ID: "a", Text: "Write"}}}
+		after := domain.Task{Checklist: []domain.ChecklistItem{{ID: "a", Text: "Write", Done: true}}}
+
+		changes := domain.TaskChanges(before, after)
+
+		assert.Len(t, changes, 1)
+		assert.Equal(t, "checklist", changes[0].Field)
+		assert.Empty(t, domain.TaskChanges(before, before))
+	})
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"task9/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskScore(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("priority without a due date", func(t *testing.T) {
+		assert.Equal(t, 10.0, domain.Task{Priority: domain.PriorityLow}.Score(now))
+		assert.Equal(t, 40.0, domain.Task{Priority: domain.PriorityUrgent}.Score(now))
+		assert.Equal(t, 20.0, domain.Task{}.Score(now), "unset priority counts as medium")
+	})
+
+	t.Run("due date proximity", func(t *testing.T) {
+		farOff := domain.Task{Priority: domain.PriorityMedium, DueDate: now.Add(30 * 24 * time.Hour)}
+		halfWeek := domain.Task{Priority: domain.PriorityMedium, DueDate: now.Add(84 * time.Hour)}
+		assert.Equal(t, 20.0, farOff.Score(now))
+		assert.InDelta(t, 25.0, halfWeek.Score(now), 0.001)
+	})
+
+	t.Run("overdue tasks gain a point per day up to a cap", func(t *testing.T) {
+		threeDays := domain.Task{Priority: domain.PriorityMedium, DueDate: now.Add(-3 * 24 * time.Hour)}
+		longAgo := domain.Task{Priority: domain.PriorityMedium, DueDate: now.Add(-90 * 24 * time.Hour)}
+		assert.Equal(t, 43.0, threeDays.Score(now))
+		assert.Equal(t, 50.0, longAgo.Score(now))
+	})
+
+	t.Run("an overdue medium task outranks an urgent one without a deadline", func(t *testing.T) {
+		urgent := domain.Task{Priority: domain.PriorityUrgent}
+		justLate := domain.Task{Priority: domain.PriorityMedium, DueDate: now.Add(-time.Minute)}
+		assert.Equal(t, 41.0, justLate.Score(now), "part of a day late counts as a day")
+		assert.Greater(t, justLate.Score(now), urgent.Score(now))
+	})
+}
+
+func TestSortTasksSmart(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tasks := []domain.Task{
+		{ID: "done", Priority: domain.PriorityUrgent, Status: "completed", DueDate: now.Add(-time.Hour)},
+		{ID: "low", Priority: domain.PriorityLow},
+		{ID: "urgent", Priority: domain.PriorityUrgent},
+		{ID: "overdue", Priority: domain.PriorityMedium, Status: "pending", DueDate: now.Add(-48 * time.Hour)},
+		{ID: "high-later", Priority: domain.PriorityHigh, DueDate: now.Add(20 * 24 * time.Hour)},
+		{ID: "high-sooner", Priority: domain.PriorityHigh, DueDate: now.Add(10 * 24 * time.Hour)},
+	}
+
+	domain.SortTasksSmart(tasks, now)
+
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	assert.Equal(t, []string{"overdue", "urgent", "high-sooner", "high-later", "low", "done"}, ids)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	delivery "task9/delivery/http"
+	"task9/delivery/middleware"
+	"task9/domain"
+	"task9/infrastructure"
+	"task9/repository"
+	"task9/usecase"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAttachmentRouter(t *testing.T) (*gin.Engine, string) {
+	gin.SetMode(gin.TestMode)
+	taskRepo := repository.NewTaskRepositoryMemory()
+	task, err := usecase.NewTaskUseCase(taskRepo).CreateTask(domain.WithWorkspace(context.Background(), "team"), domain.CreateTaskRequest{Title: "Ship"})
+	require.NoError(t, err)
+
+	attachments := usecase.NewAttachmentUseCase(repository.NewAttachmentRepositoryMemory(), taskRepo, infrastructure.NewLocalBlobStore(t.TempDir()),
+		usecase.WithAttachmentLimits(domain.AttachmentLimits{MaxBytes: 16, AllowedTypes: []string{"text/plain"}}))
+	handler := delivery.NewAttachmentHandler(attachments, time.Second, time.Second)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		ctx := domain.WithWorkspace(c.Request.Context(), "team")
+		c.Request = c.Request.WithContext(domain.WithActor(ctx, domain.Actor{UserID: "1", Username: "alice", Role: "user"}))
+	})
+	router.GET("/tasks/:id/attachments", handler.ListAttachments)
+	router.POST("/tasks/:id/attachments", middleware.MaxBodySize(1<<20), handler.UploadAttachment)
+	router.GET("/tasks/:id/attachments/:attachment_id", handler.DownloadAttachment)
+	router.DELETE("/tasks/:id/attachments/:attachment_id", handler.DeleteAttachment)
+	return router, task.ID
+}
+
+func uploadFile(t *testing.T, router *gin.Engine, taskID, filename, contentType, content string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	require.NoError(t, form.WriteField("note", "skipped"))
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := form.CreatePart(header)
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks/"+taskID+"/attachments", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAttachmentHandler_UploadAndDownload(t *testing.T) {
+	router, taskID := setupAttachmentRouter(t)
+
+	w := uploadFile(t, router, taskID, "résumé.txt", "text/plain", "hello")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data delivery.AttachmentResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "résumé.txt", created.Data.Filename)
+	assert.Equal(t, int64(5), created.Data.Size)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/"+taskID+"/attachments", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"count":1`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/"+taskID+"/attachments/"+created.Data.ID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello", w.Body.String())
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, "5", w.Header().Get("Content-Length"))
+	assert.Equal(t, "attachment; filename*=utf-8''r%C3%A9sum%C3%A9.txt", w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "sandbox", w.Header().Get("Content-Security-Policy"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/tasks/"+taskID+"/attachments/"+created.Data.ID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/"+taskID+"/attachments/"+created.Data.ID, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAttachmentHandler_UploadErrors(t *testing.T) {
+	router, taskID := setupAttachmentRouter(t)
+
+	tests := []struct {
+		name        string
+		taskID      string
+		filename    string
+		contentType string
+		content     string
+		want        int
+	}{
+		{"too large", taskID, "big.txt", "text/plain", strings.Repeat("x", 17), http.StatusRequestEntityTooLarge},
+		{"type not allowed", taskID, "page.html", "text/html", "<p>", http.StatusUnsupportedMediaType},
+		{"missing task", "999", "a.txt", "text/plain", "hi", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := uploadFile(t, router, tt.taskID, tt.filename, tt.contentType, tt.content)
+			assert.Equal(t, tt.want, w.Code, w.Body.String())
+		})
+	}
+
+	t.Run("not multipart", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/tasks/"+taskID+"/attachments", strings.NewReader("hello"))
+		req.Header.Set("Content-Type", "text/plain")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("no file part", func(t *testing.T) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		require.NoError(t, form.WriteField("note", "no file"))
+		require.NoError(t, form.Close())
+		req := httptest.NewRequest(http.MethodPost, "/tasks/"+taskID+"/attachments", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
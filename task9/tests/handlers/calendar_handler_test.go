@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	delivery "task9/delivery/http"
+	"task9/domain"
+	"task9/repository"
+	"task9/tests/mocks"
+	"task9/usecase"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// assertGolden compares got with testdata/name, or rewrites the file with
+// -update.
+func assertGolden(t *testing.T, name string, got []byte) {
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func calendarTasks() []domain.Task {
+	created := time.Date(2030, 4, 1, 8, 0, 0, 0, time.UTC)
+	updated := time.Date(2030, 4, 2, 17, 45, 0, 0, time.UTC)
+	berlin := time.FixedZone("CEST", 2*60*60)
+	return []domain.Task{
+		{
+			ID:          "6650a1b2c3d4e5f601234567",
+			Title:       "Release notes; draft, review",
+			Description: "Collect the changes\nfrom C:\\changelog",
+			DueDate:     time.Date(2030, 5, 1, 9, 30, 0, 0, berlin),
+			Status:      "in_progress",
+			Priority:    "high",
+			Tags:        []string{"docs", "release"},
+			CreatedAt:   created,
+			UpdatedAt:   updated,
+		},
+		{
+			ID:        "6650a1b2c3d4e5f601234568",
+			Title:     "Übersetzungen für die Veröffentlichung prüfen und an alle Teams schicken 🚀",
+			DueDate:   time.Date(2030, 5, 2, 0, 0, 0, 0, time.UTC),
+			Status:    "completed",
+			Priority:  "low",
+			ParentID:  "6650a1b2c3d4e5f601234567",
+			CreatedAt: created,
+			UpdatedAt: updated,
+		},
+		{
+			ID:        "6650a1b2c3d4e5f601234569",
+			Title:     "Someday",
+			Status:    "pending",
+			Priority:  "medium",
+			CreatedAt: created,
+			UpdatedAt: updated,
+		},
+		{
+			ID:        "6650a1b2c3d4e5f60123456a",
+			Title:     "Renew certificates",
+			DueDate:   time.Date(2030, 5, 3, 12, 0, 0, 0, time.UTC),
+			Status:    "pending",
+			Priority:  "urgent",
+			CreatedAt: created,
+			UpdatedAt: updated,
+		},
+	}
+}
+
+func TestCalendarFeed_Write(t *testing.T) {
+	each := func(fn func(domain.Task) error) error {
+		for _, task := range calendarTasks() {
+			if err := fn(task); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for name, feed := range map[string]delivery.CalendarFeed{
+		"calendar_events.ics": {Name: "Team, tasks", UIDDomain: "tasks.example.com"},
+		"calendar_todos.ics":  {Name: "Team, tasks", UIDDomain: "tasks.example.com", Todos: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, feed.Write(&out, each))
+			assertGolden(t, name, out.Bytes())
+
+			for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\r\n"), "\r\n") {
+				assert.LessOrEqual(t, len(line), 75, line)
+			}
+		})
+	}
+
+	t.Run("stops on errors", func(t *testing.T) {
+		broken := errors.New("cursor closed")
+		err := delivery.CalendarFeed{Name: "Tasks", UIDDomain: "tasks"}.Write(&bytes.Buffer{}, func(func(domain.Task) error) error {
+			return broken
+		})
+		assert.ErrorIs(t, err, broken)
+	})
+}
+
+func TestCalendarHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	userRepo := new(mocks.MockUserRepository)
+	taskRepo := repository.NewTaskRepositoryMemory()
+	workspaceRepo := repository.NewWorkspaceRepositoryMemory()
+	workspace, err := workspaceRepo.Create(ctx, domain.Workspace{Name: "Team"})
+	require.NoError(t, err)
+	_, err = workspaceRepo.AddMember(ctx, domain.Membership{WorkspaceID: workspace.ID, UserID: "u1", Username: "alice", Role: domain.WorkspaceAdmin})
+	require.NoError(t, err)
+	task, err := usecase.NewTaskUseCase(taskRepo).CreateTask(domain.WithWorkspace(ctx, workspace.ID), domain.CreateTaskRequest{Title: "Ship it", DueDate: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	handler := delivery.NewCalendarHandler(usecase.NewCalendarUseCase(userRepo, taskRepo, workspaceRepo), "Tasks", "tasks")
+	router := gin.New()
+	router.GET("/calendar/:feed", handler.GetFeed)
+	loggedIn := router.Group("/", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(domain.WithActor(c.Request.Context(), domain.Actor{UserID: "u1", Username: "alice"}))
+	})
+	loggedIn.POST("/calendar/token", handler.RotateFeedToken)
+	loggedIn.DELETE("/calendar/token", handler.DisableFeed)
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	userRepo.On("SetCalendarTokenHash", mock.Anything, "u1", mock.AnythingOfType("string")).Return(nil)
+	w := serve(http.MethodPost, "/calendar/token")
+	require.Equal(t, http.StatusOK, w.Code)
+	var rotated struct {
+		Data delivery.CalendarTokenResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+	token := rotated.Data.Token
+	assert.Len(t, token, 64)
+	assert.Equal(t, "/calendar/"+token+".ics", rotated.Data.Path)
+
+	sum := sha256.Sum256([]byte(token))
+	userRepo.AssertCalled(t, "SetCalendarTokenHash", mock.Anything, "u1", hex.EncodeToString(sum[:]))
+	userRepo.On("GetByCalendarTokenHash", mock.Anything, hex.EncodeToString(sum[:])).Return(domain.User{ID: "u1"}, nil)
+	userRepo.On("GetByCalendarTokenHash", mock.Anything, mock.Anything).Return(domain.User{}, errors.New("user not found"))
+
+	t.Run("serves the feed for the token", func(t *testing.T) {
+		w := serve(http.MethodGet, rotated.Data.Path+"?type=todo")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+		assert.True(t, strings.HasPrefix(w.Body.String(), "BEGIN:VCALENDAR\r\n"))
+		assert.True(t, strings.HasSuffix(w.Body.String(), "END:VCALENDAR\r\n"))
+		assert.Contains(t, w.Body.String(), "BEGIN:VTODO\r\nUID:"+task.ID+"@tasks\r\n")
+		assert.Contains(t, w.Body.String(), "SUMMARY:Ship it\r\n")
+	})
+
+	t.Run("rejects other feeds", func(t *testing.T) {
+		for _, target := range []string{"/calendar/" + strings.Repeat("0", 64) + ".ics", "/calendar/" + token, "/calendar/.ics"} {
+			assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, target).Code, target)
+		}
+		assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, rotated.Data.Path+"?type=journal").Code)
+	})
+
+	t.Run("turns the feed off", func(t *testing.T) {
+		w := serve(http.MethodDelete, "/calendar/token")
+		assert.Equal(t, http.StatusOK, w.Code)
+		userRepo.AssertCalled(t, "SetCalendarTokenHash", mock.Anything, "u1", "")
+	})
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task9/config"
+	delivery "task9/delivery/http"
+	"task9/delivery/middleware"
+	"task9/domain"
+	"task9/infrastructure"
+	"task9/usecase"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func setupStreamServer(t *testing.T, heartbeat time.Duration) (*httptest.Server, *usecase.EventHub, *infrastructure.JWTGenerator) {
+	gin.SetMode(gin.TestMode)
+	tokenGenerator := infrastructure.NewJWTGenerator(config.Default().Auth)
+	hub := usecase.NewEventHub()
+	handler := delivery.NewStreamHandler(hub, heartbeat, time.Second, []string{"https://app.example.com"})
+
+	router := gin.New()
+	router.Use(middleware.NewAuthMiddleware(tokenGenerator).RequireStreamAuth(), func(c *gin.Context) {
+		c.Request = c.Request.WithContext(domain.WithWorkspace(c.Request.Context(), "team"))
+	})
+	router.GET("/tasks/stream", handler.StreamTasks)
+	router.GET("/tasks/stream/ws", handler.StreamTasksWebSocket)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(func() {
+		hub.Close()
+		server.Close()
+	})
+	return server, hub, tokenGenerator
+}
+
+func publish(t *testing.T, hub *usecase.EventHub, eventType, taskID string) {
+	require.NoError(t, hub.Publish(context.Background(), domain.Event{Type: eventType, Task: &domain.Task{ID: taskID, WorkspaceID: "team", Title: "Task " + taskID, Status: "pending"}}))
+}
+
+// sseEvent reads the stream up to the end of the next event, skipping
+// keep-alive comments.
+func sseEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	for {
+		if fields := sseBlock(t, reader); fields[""] != "keep-alive" {
+			return fields
+		}
+	}
+}
+
+// sseBlock reads the stream up to the next blank line.
+func sseBlock(t *testing.T, reader *bufio.Reader) map[string]string {
+	fields := map[string]string{}
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return fields
+		}
+		name, value, _ := strings.Cut(line, ":")
+		fields[name] = strings.TrimPrefix(value, " ")
+	}
+}
+
+func TestStreamHandler_StreamTasks(t *testing.T) {
+	server, hub, tokenGenerator := setupStreamServer(t, 50*time.Millisecond)
+	token, err := tokenGenerator.Generate("2", "bob", "user")
+	require.NoError(t, err)
+
+	connect := func(t *testing.T, lastEventID string) *bufio.Reader {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/tasks/stream", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		reader := bufio.NewReader(resp.Body)
+		assert.Equal(t, "3000", sseEvent(t, reader)["retry"])
+		return reader
+	}
+
+	reader := connect(t, "")
+	publish(t, hub, domain.EventTaskCreated, "1")
+	publish(t, hub, domain.EventTaskDeleted, "1")
+
+	created := sseEvent(t, reader)
+	assert.Equal(t, domain.EventTaskCreated, created["event"])
+	var data delivery.TaskEventResponse
+	require.NoError(t, json.Unmarshal([]byte(created["data"]), &data))
+	assert.Equal(t, created["id"], data.ID)
+	assert.Equal(t, "Task 1", data.Task.Title)
+
+	deleted := sseEvent(t, reader)
+	assert.Equal(t, domain.EventTaskDeleted, deleted["event"])
+	assert.NotContains(t, deleted["data"], "Task 1", "users who are not admins do not see the trash")
+
+	assert.Equal(t, "keep-alive", sseBlock(t, reader)[""], "an idle stream sends keep-alive comments")
+
+	t.Run("resumes after Last-Event-ID", func(t *testing.T) {
+		resumed := connect(t, created["id"])
+		assert.Equal(t, deleted["id"], sseEvent(t, resumed)["id"])
+	})
+
+	t.Run("asks for a reload when events were lost", func(t *testing.T) {
+		resumed := connect(t, "unknown-1")
+		assert.Equal(t, "reset", sseEvent(t, resumed)["event"])
+	})
+
+	t.Run("requires a token", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/tasks/stream")
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestStreamHandler_StreamTasksWebSocket(t *testing.T) {
+	server, hub, tokenGenerator := setupStreamServer(t, time.Minute)
+	token, err := tokenGenerator.Generate("1", "admin", "admin")
+	require.NoError(t, err)
+
+	watcher, _, _ := hub.Subscribe(domain.WithWorkspace(context.Background(), "team"), "")
+	defer watcher.Close()
+	publish(t, hub, domain.EventTaskCreated, "1")
+	publish(t, hub, domain.EventTaskUpdated, "1")
+	first := <-watcher.Events()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/tasks/stream/ws?last_event_id=" + first.ID
+	cfg, err := websocket.NewConfig(wsURL, server.URL)
+	require.NoError(t, err)
+	cfg.Header = http.Header{"Authorization": {"Bearer " + token}}
+	conn, err := websocket.DialConfig(cfg)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var event delivery.TaskEventResponse
+	require.NoError(t, websocket.JSON.Receive(conn, &event))
+	assert.Equal(t, domain.EventTaskUpdated, event.Type, "the backlog comes first")
+
+	publish(t, hub, domain.EventTaskDeleted, "1")
+	require.NoError(t, websocket.JSON.Receive(conn, &event))
+	assert.Equal(t, domain.EventTaskDeleted, event.Type)
+	require.NotNil(t, event.Task, "admins see deleted tasks")
+	assert.Equal(t, "1", event.Task.ID)
+}
+
+func TestStreamHandler_StreamTokens(t *testing.T) {
+	server, _, tokenGenerator := setupStreamServer(t, time.Minute)
+	streamToken, _, err := tokenGenerator.GenerateStream("2", "bob", "user")
+	require.NoError(t, err)
+	loginToken, err := tokenGenerator.Generate("2", "bob", "user")
+	require.NoError(t, err)
+
+	get := func(t *testing.T, path string, cookie *http.Cookie) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("in the query", func(t *testing.T) {
+		resp := get(t, "/tasks/stream?stream_token="+streamToken, nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("in a cookie", func(t *testing.T) {
+		resp := get(t, "/tasks/stream", &http.Cookie{Name: "stream_token", Value: streamToken})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("login tokens stay out of URLs", func(t *testing.T) {
+		resp := get(t, "/tasks/stream?stream_token="+loginToken, nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("no token", func(t *testing.T) {
+		resp := get(t, "/tasks/stream", nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestStreamHandler_WebSocketOrigin(t *testing.T) {
+	server, _, tokenGenerator := setupStreamServer(t, time.Minute)
+	streamToken, _, err := tokenGenerator.GenerateStream("2", "bob", "user")
+	require.NoError(t, err)
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/tasks/stream/ws"
+
+	dial := func(origin string) error {
+		cfg, err := websocket.NewConfig(wsURL, origin)
+		require.NoError(t, err)
+		cfg.Header = http.Header{"Cookie": {"stream_token=" + streamToken}}
+		conn, err := websocket.DialConfig(cfg)
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	assert.NoError(t, dial(server.URL), "the API's own origin")
+	assert.NoError(t, dial("https://app.example.com"), "an allowed origin")
+	assert.Error(t, dial("https://evil.example.com"), "pages on other sites cannot use the cookie")
+}
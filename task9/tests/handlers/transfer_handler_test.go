@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	delivery "task9/delivery/http"
+	"task9/domain"
+	"task9/repository"
+	"task9/usecase"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTransferRouter(t *testing.T) (*gin.Engine, *usecase.TaskUseCase) {
+	gin.SetMode(gin.TestMode)
+	tasks := usecase.NewTaskUseCase(repository.NewTaskRepositoryMemory())
+	handler := delivery.NewTransferHandler(tasks, time.Second, time.Second)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(domain.WithWorkspace(c.Request.Context(), "team"))
+	})
+	router.GET("/tasks/export", handler.ExportTasks)
+	router.POST("/tasks/import", handler.ImportTasks)
+	return router, tasks
+}
+
+type importResponse struct {
+	Status  string                        `json:"status"`
+	Message string                        `json:"message"`
+	Data    delivery.ImportReportResponse `json:"data"`
+}
+
+func importTasks(t *testing.T, router *gin.Engine, query, contentType, body string) (int, importResponse) {
+	req := httptest.NewRequest(http.MethodPost, "/tasks/import"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response importResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), w.Body.String())
+	return w.Code, response
+}
+
+func exportTasks(t *testing.T, router *gin.Engine, query string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/export"+query, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	return w
+}
+
+func TestTransferHandler_ExportTasks(t *testing.T) {
+	router, tasks := setupTransferRouter(t)
+	ctx := domain.WithWorkspace(context.Background(), "team")
+	due := time.Date(2030, 5, 1, 9, 0, 0, 0, time.UTC)
+	_, err := tasks.CreateTask(ctx, domain.CreateTaskRequest{ExternalID: "sheet-1", Title: "Write, then review", DueDate: due, Tags: []string{"docs", "backend"}, Recurrence: "FREQ=WEEKLY"})
+	require.NoError(t, err)
+	_, err = tasks.CreateTask(ctx, domain.CreateTaskRequest{Title: "Ship", Description: "line one\nline two", DueDate: due, Priority: "high"})
+	require.NoError(t, err)
+
+	t.Run("csv", func(t *testing.T) {
+		w := exportTasks(t, router, "?format=csv")
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="tasks.csv"`, w.Header().Get("Content-Disposition"))
+
+		rows, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 3)
+		assert.Equal(t, []string{"id", "external_id", "title", "description", "due_date", "status", "priority", "parent_id", "project_id", "tags", "recurrence", "created_at", "updated_at"}, rows[0])
+		assert.Equal(t, []string{"sheet-1", "Write, then review", "", "2030-05-01T09:00:00Z", "pending", "medium", "", "", "docs,backend", "FREQ=WEEKLY"}, rows[1][1:11])
+		assert.Equal(t, "line one\nline two", rows[2][3])
+	})
+
+	t.Run("json", func(t *testing.T) {
+		w := exportTasks(t, router, "?tags=docs")
+		assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+		var records []delivery.TaskRecord
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &records))
+		require.Len(t, records, 1)
+		assert.Equal(t, "sheet-1", records[0].ExternalID)
+		assert.Equal(t, []string{"docs", "backend"}, records[0].Tags)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		w := exportTasks(t, router, "?format=ndjson")
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+		var titles []string
+		scanner := bufio.NewScanner(w.Body)
+		for scanner.Scan() {
+			var record delivery.TaskRecord
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+			titles = append(titles, record.Title)
+		}
+		assert.Equal(t, []string{"Write, then review", "Ship"}, titles)
+	})
+
+	t.Run("nothing to export", func(t *testing.T) {
+		w := exportTasks(t, router, "?tags=missing")
+		assert.JSONEq(t, "[]", w.Body.String())
+	})
+
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks/export?format=xlsx", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestTransferHandler_ImportTasks(t *testing.T) {
+	t.Run("an export can be imported again", func(t *testing.T) {
+		source, sourceTasks := setupTransferRouter(t)
+		due := time.Now().Add(24 * time.Hour)
+		for _, title := range []string{"A", "B"} {
+			_, err := sourceTasks.CreateTask(domain.WithWorkspace(context.Background(), "team"), domain.CreateTaskRequest{ExternalID: "ext-" + title, Title: title, DueDate: due})
+			require.NoError(t, err)
+		}
+
+		for _, format := range []string{"csv", "json", "ndjson"} {
+			exported := exportTasks(t, source, "?format="+format).Body.String()
+			target, _ := setupTransferRouter(t)
+
+			code, response := importTasks(t, target, "?format="+format, "", exported)
+			require.Equal(t, http.StatusOK, code, format)
+			assert.Equal(t, 2, response.Data.Created, format)
+
+			code, response = importTasks(t, target, "?upsert=true&format="+format, "", exported)
+			require.Equal(t, http.StatusOK, code, format)
+			assert.Equal(t, 2, response.Data.Updated, format)
+			assert.Empty(t, response.Data.Errors, format)
+		}
+	})
+
+	t.Run("reports the rows that fail", func(t *testing.T) {
+		router, tasks := setupTransferRouter(t)
+		body := "\ufeffTitle,Due_Date,Status,Notes\n" +
+			"Plain date,2030-05-01,,ignored\n" +
+			"No date,,,\n" +
+			"Bad date,tomorrow,,\n" +
+			"Bad status,2030-05-01,done,\n"
+
+		code, response := importTasks(t, router, "", "text/csv", body)
+
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, delivery.ImportReportResponse{
+			Rows:    4,
+			Created: 1,
+			Failed:  3,
+			Errors: []delivery.ImportRowErrorResponse{
+				{Row: 2, Error: "due_date is required"},
+				{Row: 3, Error: "due_date must be an RFC 3339 time or a YYYY-MM-DD date"},
+				{Row: 4, Error: "invalid status"},
+			},
+		}, response.Data)
+
+		all, err := tasks.GetAllTasks(domain.WithWorkspace(context.Background(), "team"))
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+		assert.Equal(t, time.Date(2030, 5, 1, 0, 0, 0, 0, time.UTC), all[0].DueDate)
+	})
+
+	t.Run("a bad ndjson line only fails its row", func(t *testing.T) {
+		router, _ := setupTransferRouter(t)
+		body := `{"external_id":"a","title":"A","due_date":"2030-05-01"}` + "\n" +
+			`{"external_id":"b","title":` + "\n\n" +
+			`{"external_id":"c","title":"C","due_date":"2030-05-01","tags":"x"}` + "\n" +
+			`{"external_id":"d","title":"D","due_date":"2030-05-01"}`
+
+		code, response := importTasks(t, router, "?dry_run=true", "application/x-ndjson", body)
+
+		require.Equal(t, http.StatusOK, code)
+		assert.True(t, response.Data.DryRun)
+		assert.Equal(t, 4, response.Data.Rows)
+		assert.Equal(t, 2, response.Data.Created)
+		require.Len(t, response.Data.Errors, 2)
+		assert.Equal(t, 2, response.Data.Errors[0].Row)
+		assert.Equal(t, 3, response.Data.Errors[1].Row)
+		assert.Equal(t, "c", response.Data.Errors[1].ExternalID)
+	})
+
+	t.Run("a broken file ends the import", func(t *testing.T) {
+		router, tasks := setupTransferRouter(t)
+		body := `[{"title":"A","due_date":"2030-05-01"}, {"title":"B", "due_date": 2030-05-01}]`
+
+		code, response := importTasks(t, router, "", "application/json", body)
+
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Contains(t, response.Message, "invalid import file")
+		assert.Equal(t, 1, response.Data.Created, "the rows before are reported")
+		all, err := tasks.GetAllTasks(domain.WithWorkspace(context.Background(), "team"))
+		require.NoError(t, err)
+		assert.Len(t, all, 1)
+	})
+
+	t.Run("needs a known format", func(t *testing.T) {
+		router, _ := setupTransferRouter(t)
+		req := httptest.NewRequest(http.MethodPost, "/tasks/import", strings.NewReader("<tasks/>"))
+		req.Header.Set("Content-Type", "application/xml")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+}
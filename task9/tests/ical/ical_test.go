@@ -0,0 +1,94 @@
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"task9/ical"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// assertGolden compares got with testdata/name, or rewrites the file with
+// -update.
+func assertGolden(t *testing.T, name string, got []byte) {
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestEncoder(t *testing.T) {
+	var out bytes.Buffer
+	e := ical.NewEncoder(&out)
+	berlin := time.FixedZone("CEST", 2*60*60)
+
+	e.Begin("VCALENDAR")
+	e.Property("VERSION", "2.0")
+	e.Text("SUMMARY", `Plan Q3; review budget, roadmap \ hiring`)
+	e.Text("DESCRIPTION", "First line\r\nSecond line\nThird\tline\x00\x07 with controls dropped")
+	e.Text("DESCRIPTION", strings.Repeat("0123456789", 20))
+	e.Text("SUMMARY", "Grüße aus Köln – "+strings.Repeat("äöü", 20)+" 🎉🎉🎉")
+	e.TextList("CATEGORIES", []string{"docs", "a,b", "semi;colon"})
+	e.TextList("CATEGORIES", nil)
+	e.Time("DTSTART", time.Date(2030, 5, 1, 9, 30, 0, 0, berlin))
+	e.Time("DUE", time.Date(2030, 12, 31, 23, 59, 59, 0, time.UTC))
+	e.End("VCALENDAR")
+	require.NoError(t, e.Flush())
+
+	assertGolden(t, "encoder.ics", out.Bytes())
+	assertContentLines(t, out.Bytes())
+}
+
+// assertContentLines checks that every line ends with CRLF, is valid UTF-8
+// and is at most 75 octets long.
+func assertContentLines(t *testing.T, data []byte) {
+	require.True(t, bytes.HasSuffix(data, []byte("\r\n")))
+	for i, line := range strings.Split(strings.TrimSuffix(string(data), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, "line %d: %q", i+1, line)
+		assert.True(t, utf8.ValidString(line), "line %d is cut inside a character", i+1)
+		assert.NotContains(t, line, "\n", "line %d", i+1)
+	}
+}
+
+func TestEncoder_Unfolds(t *testing.T) {
+	value := "Grüße " + strings.Repeat("x😀y", 40)
+	var out bytes.Buffer
+	e := ical.NewEncoder(&out)
+	e.Text("SUMMARY", value)
+	require.NoError(t, e.Flush())
+
+	unfolded := strings.ReplaceAll(out.String(), "\r\n ", "")
+	assert.Equal(t, "SUMMARY:"+value+"\r\n", unfolded)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestEncoder_KeepsTheFirstError(t *testing.T) {
+	e := ical.NewEncoder(failingWriter{})
+	for i := 0; i < 200; i++ {
+		e.Text("DESCRIPTION", strings.Repeat("x", 100))
+	}
+	assert.EqualError(t, e.Flush(), "disk full")
+}
+
+func TestEscapeText(t *testing.T) {
+	assert.Equal(t, `a\, b\; c\\d\ne`, ical.EscapeText("a, b; c\\d\ne"))
+	assert.Equal(t, "20300501T073000Z", ical.FormatTime(time.Date(2030, 5, 1, 9, 30, 0, 0, time.FixedZone("CEST", 2*60*60))))
+}
@@ -0,0 +1,77 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"task9/config"
+	"task9/domain"
+	"task9/infrastructure"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalBlobStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := infrastructure.NewLocalBlobStore(dir)
+
+	n, err := store.Put(ctx, "tasks/1/abc", strings.NewReader("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	content, err := store.Open(ctx, "tasks/1/abc")
+	require.NoError(t, err)
+	data, err := io.ReadAll(content)
+	require.NoError(t, err)
+	require.NoError(t, content.Close())
+	assert.Equal(t, "hello", string(data))
+
+	require.NoError(t, store.Delete(ctx, "tasks/1/abc"))
+	_, err = store.Open(ctx, "tasks/1/abc")
+	assert.ErrorIs(t, err, domain.ErrBlobNotFound)
+	assert.NoError(t, store.Delete(ctx, "tasks/1/abc"), "deleting a missing blob is not an error")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "emptied directories are removed")
+}
+
+func TestLocalBlobStore_FailedPutLeavesNothing(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := infrastructure.NewLocalBlobStore(dir)
+	broken := io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(errors.New("connection reset")))
+
+	_, err := store.Put(ctx, "tasks/1/abc", broken)
+	assert.EqualError(t, err, "connection reset")
+
+	_, err = store.Open(ctx, "tasks/1/abc")
+	assert.ErrorIs(t, err, domain.ErrBlobNotFound)
+	entries, err := os.ReadDir(filepath.Join(dir, "tasks", "1"))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the temporary file is removed")
+}
+
+func TestLocalBlobStore_RejectsKeysOutsideTheStore(t *testing.T) {
+	store := infrastructure.NewLocalBlobStore(t.TempDir())
+	for _, key := range []string{"", "../secret", "/etc/passwd", "tasks//1", "Tasks/1"} {
+		_, err := store.Put(context.Background(), key, strings.NewReader("x"))
+		assert.Error(t, err, key)
+	}
+}
+
+func TestNewBlobStore(t *testing.T) {
+	store, err := infrastructure.NewBlobStore(config.AttachmentsConfig{Store: "local", Dir: t.TempDir()})
+	require.NoError(t, err)
+	assert.IsType(t, &infrastructure.LocalBlobStore{}, store)
+
+	_, err = infrastructure.NewBlobStore(config.AttachmentsConfig{Store: "s3"})
+	assert.EqualError(t, err, `unknown attachment store "s3"`)
+}
@@ -1,15 +1,16 @@
 package infrastructure
 
 import (
-	"os"
+	"task9/config"
 	"task9/infrastructure"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestJWTGenerator_Generate(t *testing.T) {
-	generator := infrastructure.NewJWTGenerator()
+	generator := infrastructure.NewJWTGenerator(config.Default().Auth)
 
 	userID := "507f1f77bcf86cd799439011"
 	username := "testuser"
@@ -22,7 +23,7 @@ func TestJWTGenerator_Generate(t *testing.T) {
 }
 
 func TestJWTGenerator_Validate(t *testing.T) {
-	generator := infrastructure.NewJWTGenerator()
+	generator := infrastructure.NewJWTGenerator(config.Default().Auth)
 
 	userID := "507f1f77bcf86cd799439011"
 	username := "testuser"
@@ -56,9 +57,28 @@ func TestJWTGenerator_Validate(t *testing.T) {
 	})
 }
 
+func TestJWTGenerator_StreamTokens(t *testing.T) {
+	generator := infrastructure.NewJWTGenerator(config.Default().Auth)
+
+	streamToken, expiresAt, err := generator.GenerateStream("507f1f77bcf86cd799439011", "testuser", "user")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(config.Default().Auth.StreamTokenTTL), expiresAt, time.Second)
+
+	claims, err := generator.ValidateStream(streamToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "testuser", claims["username"])
+
+	_, err = generator.Validate(streamToken)
+	assert.Error(t, err, "stream tokens only open the streams")
+
+	token, err := generator.Generate("507f1f77bcf86cd799439011", "testuser", "user")
+	assert.NoError(t, err)
+	_, err = generator.ValidateStream(token)
+	assert.Error(t, err, "login tokens are not accepted in stream URLs")
+}
+
 func TestJWTGenerator_DifferentSecrets(t *testing.T) {
-	os.Setenv("JWT_SECRET", "secret1")
-	generator1 := infrastructure.NewJWTGenerator()
+	generator1 := infrastructure.NewJWTGenerator(config.AuthConfig{JWTSecret: "secret1", TokenTTL: time.Hour})
 
 	userID := "507f1f77bcf86cd799439011"
 	username := "testuser"
@@ -67,17 +87,14 @@ func TestJWTGenerator_DifferentSecrets(t *testing.T) {
 	token, err := generator1.Generate(userID, username, role)
 	assert.NoError(t, err)
 
-	os.Setenv("JWT_SECRET", "secret2")
-	generator2 := infrastructure.NewJWTGenerator()
+	generator2 := infrastructure.NewJWTGenerator(config.AuthConfig{JWTSecret: "secret2", TokenTTL: time.Hour})
 
 	_, err = generator2.Validate(token)
 	assert.Error(t, err)
-
-	os.Unsetenv("JWT_SECRET")
 }
 
 func TestJWTGenerator_ExpiredToken(t *testing.T) {
-	generator := infrastructure.NewJWTGenerator()
+	generator := infrastructure.NewJWTGenerator(config.Default().Auth)
 
 	userID := "507f1f77bcf86cd799439011"
 	username := "testuser"
@@ -90,4 +107,3 @@ func TestJWTGenerator_ExpiredToken(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, claims)
 }
-
@@ -0,0 +1,26 @@
+package infrastructure
+
+import (
+	"task9/infrastructure"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations_AreOrdered(t *testing.T) {
+	require.NotEmpty(t, infrastructure.Migrations)
+	for i, migration := range infrastructure.Migrations {
+		assert.Equal(t, i+1, migration.Version, "versions are numbered from 1 without gaps")
+		assert.NotEmpty(t, migration.Description, migration.Version)
+		assert.NotNil(t, migration.Up, migration.Version)
+	}
+}
+
+func TestNewMigrator_RejectsUnorderedVersions(t *testing.T) {
+	_, err := infrastructure.NewMigrator(nil, []infrastructure.Migration{{Version: 2}, {Version: 1}})
+	assert.EqualError(t, err, "migration 1 must have a higher version than migration 2")
+
+	_, err = infrastructure.NewMigrator(nil, []infrastructure.Migration{{Version: 1}, {Version: 1}})
+	assert.Error(t, err)
+}
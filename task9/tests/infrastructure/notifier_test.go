@@ -0,0 +1,149 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"task9/config"
+	"task9/domain"
+	"task9/infrastructure"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testReminder = domain.Reminder{
+	Key:     "1:due_soon:1h0m0s:1704103200000",
+	TaskID:  "1",
+	Title:   "Ship release",
+	Kind:    domain.ReminderDueSoon,
+	Window:  time.Hour,
+	DueDate: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+}
+
+func TestLogNotifier(t *testing.T) {
+	var buf bytes.Buffer
+	notifier := infrastructure.NewLogNotifier(log.New(&buf, "", 0))
+
+	require.NoError(t, notifier.Notify(context.Background(), testReminder))
+	assert.Equal(t, "reminder for task 1: Task \"Ship release\" is due within 1h, at 2024-01-01T10:00:00Z.\n", buf.String())
+}
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	notifier := infrastructure.NewFileNotifier(path)
+
+	require.NoError(t, notifier.Notify(context.Background(), testReminder))
+	overdue := testReminder
+	overdue.Kind = domain.ReminderOverdue
+	require.NoError(t, notifier.Notify(context.Background(), overdue))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &payload))
+	assert.Equal(t, "task.reminder", payload["event"])
+	assert.Equal(t, "due_soon", payload["kind"])
+	assert.Equal(t, "1h", payload["window"])
+	assert.Equal(t, "2024-01-01T10:00:00Z", payload["due_date"])
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &payload))
+	assert.Equal(t, "overdue", payload["kind"])
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received map[string]interface{}
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	notifier := infrastructure.NewWebhookNotifier(server.URL, server.Client())
+	require.NoError(t, notifier.Notify(context.Background(), testReminder))
+	assert.Equal(t, "1", received["task_id"])
+
+	status = http.StatusBadGateway
+	assert.EqualError(t, notifier.Notify(context.Background(), testReminder), "webhook answered 502 Bad Gateway")
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	messages := make(chan string, 1)
+	go serveSMTP(listener, messages)
+
+	notifier := infrastructure.NewSMTPNotifier(config.SMTPConfig{
+		Addr: listener.Addr().String(),
+		From: "tasks@example.com",
+		To:   []string{"team@example.com"},
+	})
+	require.NoError(t, notifier.Notify(context.Background(), testReminder))
+
+	select {
+	case message := <-messages:
+		assert.Contains(t, message, "To: team@example.com")
+		assert.Contains(t, message, "Subject: Reminder: Ship release")
+		assert.Contains(t, message, "is due within 1h")
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+// serveSMTP accepts one connection and speaks just enough SMTP for
+// net/smtp.SendMail, sending the message data to messages.
+func serveSMTP(listener net.Listener, messages chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ready")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch command := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case command == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			messages <- data.String()
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
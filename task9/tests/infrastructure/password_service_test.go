@@ -1,6 +1,7 @@
 package infrastructure
 
 import (
+	"task9/config"
 	"task9/infrastructure"
 	"testing"
 
@@ -8,7 +9,7 @@ import (
 )
 
 func TestBcryptHasher_Hash(t *testing.T) {
-	hasher := infrastructure.NewBcryptHasher()
+	hasher := infrastructure.NewBcryptHasher(config.Default().Password.BcryptCost)
 
 	password := "testpassword123"
 	hashed, err := hasher.Hash(password)
@@ -19,7 +20,7 @@ func TestBcryptHasher_Hash(t *testing.T) {
 }
 
 func TestBcryptHasher_Compare(t *testing.T) {
-	hasher := infrastructure.NewBcryptHasher()
+	hasher := infrastructure.NewBcryptHasher(config.Default().Password.BcryptCost)
 
 	password := "testpassword123"
 	hashed, err := hasher.Hash(password)
@@ -42,7 +43,7 @@ func TestBcryptHasher_Compare(t *testing.T) {
 }
 
 func TestBcryptHasher_HashDifferentPasswords(t *testing.T) {
-	hasher := infrastructure.NewBcryptHasher()
+	hasher := infrastructure.NewBcryptHasher(config.Default().Password.BcryptCost)
 
 	password1 := "password1"
 	password2 := "password2"
@@ -56,7 +57,7 @@ func TestBcryptHasher_HashDifferentPasswords(t *testing.T) {
 }
 
 func TestBcryptHasher_HashSamePasswordDifferentHashes(t *testing.T) {
-	hasher := infrastructure.NewBcryptHasher()
+	hasher := infrastructure.NewBcryptHasher(config.Default().Password.BcryptCost)
 
 	password := "samepassword"
 	hashed1, err1 := hasher.Hash(password)
@@ -66,4 +67,3 @@ func TestBcryptHasher_HashSamePasswordDifferentHashes(t *testing.T) {
 	assert.NoError(t, err2)
 	assert.NotEqual(t, hashed1, hashed2)
 }
-
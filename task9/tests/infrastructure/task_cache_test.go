@@ -0,0 +1,81 @@
+package infrastructure
+
+import (
+	"context"
+	"task9/config"
+	"task9/domain"
+	"task9/infrastructure"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryTaskCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("evicts the least recently used task", func(t *testing.T) {
+		cache := infrastructure.NewMemoryTaskCache(2, time.Minute)
+		cache.Set(ctx, "1", domain.Task{ID: "1"})
+		cache.Set(ctx, "2", domain.Task{ID: "2"})
+		_, ok := cache.Get(ctx, "1")
+		require.True(t, ok)
+
+		cache.Set(ctx, "3", domain.Task{ID: "3"})
+		assert.Equal(t, 2, cache.Len())
+		_, ok = cache.Get(ctx, "2")
+		assert.False(t, ok, "2 was used least recently")
+		_, ok = cache.Get(ctx, "1")
+		assert.True(t, ok)
+
+		cache.Set(ctx, "1", domain.Task{ID: "1", Title: "replaced"})
+		got, ok := cache.Get(ctx, "1")
+		assert.True(t, ok)
+		assert.Equal(t, "replaced", got.Title)
+		assert.Equal(t, 2, cache.Len(), "replacing a task does not take more room")
+	})
+
+	t.Run("tasks expire after the TTL", func(t *testing.T) {
+		cache := infrastructure.NewMemoryTaskCache(10, 20*time.Millisecond)
+		cache.Set(ctx, "1", domain.Task{ID: "1"})
+		_, ok := cache.Get(ctx, "1")
+		require.True(t, ok)
+
+		time.Sleep(30 * time.Millisecond)
+		_, ok = cache.Get(ctx, "1")
+		assert.False(t, ok)
+		assert.Zero(t, cache.Len(), "expired tasks are dropped when looked up")
+	})
+
+	t.Run("delete and clear", func(t *testing.T) {
+		cache := infrastructure.NewMemoryTaskCache(10, time.Minute)
+		for _, id := range []string{"1", "2", "3"} {
+			cache.Set(ctx, id, domain.Task{ID: id})
+		}
+
+		cache.Delete(ctx, "1", "2", "missing")
+		_, ok := cache.Get(ctx, "1")
+		assert.False(t, ok)
+		_, ok = cache.Get(ctx, "3")
+		assert.True(t, ok)
+
+		cache.Clear(ctx)
+		assert.Zero(t, cache.Len())
+		_, ok = cache.Get(ctx, "3")
+		assert.False(t, ok)
+	})
+}
+
+func TestNewTaskCache(t *testing.T) {
+	cache, err := infrastructure.NewTaskCache(config.Default().Cache)
+	require.NoError(t, err)
+	assert.IsType(t, &infrastructure.MemoryTaskCache{}, cache)
+
+	cache, err = infrastructure.NewTaskCache(config.CacheConfig{Store: "memory", Size: 10})
+	require.NoError(t, err)
+	assert.Nil(t, cache, "a zero TTL turns the cache off")
+
+	_, err = infrastructure.NewTaskCache(config.CacheConfig{Store: "redis", Size: 10, TTL: time.Second})
+	assert.EqualError(t, err, `unknown cache store "redis"`)
+}
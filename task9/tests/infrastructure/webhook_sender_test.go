@@ -0,0 +1,68 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"task9/domain"
+	"task9/infrastructure"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookClient_Send(t *testing.T) {
+	subscription := domain.WebhookSubscription{ID: "1", Secret: "0123456789abcdef"}
+	delivery := domain.WebhookDelivery{
+		ID:        "7",
+		EventType: domain.EventTaskCreated,
+		Payload:   []byte(`{"id":"e1","type":"task.created"}`),
+		Attempts:  2,
+	}
+
+	t.Run("posts the signed payload", func(t *testing.T) {
+		var received *http.Request
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+		subscription.URL = server.URL
+
+		status, err := infrastructure.NewWebhookClient(time.Second).Send(context.Background(), subscription, delivery)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, status)
+		assert.Equal(t, http.MethodPost, received.Method)
+		assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+		assert.Equal(t, "task.created", received.Header.Get(infrastructure.WebhookEventHeader))
+		assert.Equal(t, "7", received.Header.Get(infrastructure.WebhookDeliveryHeader))
+		assert.Equal(t, "2", received.Header.Get(infrastructure.WebhookAttemptHeader))
+		assert.Equal(t, delivery.Payload, body)
+
+		mac := hmac.New(sha256.New, []byte(subscription.Secret))
+		mac.Write(body)
+		assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), received.Header.Get(infrastructure.WebhookSignatureHeader))
+	})
+
+	t.Run("fails on a non-2xx answer", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		subscription.URL = server.URL
+
+		status, err := infrastructure.NewWebhookClient(time.Second).Send(context.Background(), subscription, delivery)
+
+		assert.EqualError(t, err, "webhook answered 503 Service Unavailable")
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+	})
+}
@@ -0,0 +1,111 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"task9/jobs"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePurger struct {
+	retention time.Duration
+	err       error
+}
+
+func (f *fakePurger) PurgeExpired(ctx context.Context, retention time.Duration) (int64, error) {
+	f.retention = retention
+	return 2, f.err
+}
+
+func TestPurgeTrash(t *testing.T) {
+	purger := &fakePurger{}
+
+	err := jobs.PurgeTrash(purger, 72*time.Hour)(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 72*time.Hour, purger.retention)
+
+	purger.err = errors.New("database error")
+	assert.EqualError(t, jobs.PurgeTrash(purger, time.Hour)(context.Background()), "database error")
+}
+
+type fakeGenerator struct {
+	now time.Time
+}
+
+func (f *fakeGenerator) GenerateOccurrences(ctx context.Context, now time.Time) (int, error) {
+	f.now = now
+	return 1, errors.New("task 1: database error")
+}
+
+func TestGenerateOccurrences(t *testing.T) {
+	generator := &fakeGenerator{}
+
+	err := jobs.GenerateOccurrences(generator)(context.Background())
+
+	assert.EqualError(t, err, "task 1: database error", "errors of single tasks are reported")
+	assert.WithinDuration(t, time.Now(), generator.now, time.Second)
+}
+
+type fakeSender struct {
+	now time.Time
+}
+
+func (f *fakeSender) SendDueReminders(ctx context.Context, now time.Time) (int, error) {
+	f.now = now
+	return 3, nil
+}
+
+func TestSendReminders(t *testing.T) {
+	sender := &fakeSender{}
+
+	err := jobs.SendReminders(sender)(context.Background())
+
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), sender.now, time.Second)
+}
+
+type fakeDeliverer struct {
+	now time.Time
+}
+
+func (f *fakeDeliverer) DeliverPending(ctx context.Context, now time.Time) (int, error) {
+	f.now = now
+	return 1, errors.New("delivery 1: webhook delivery lease lost")
+}
+
+func TestDeliverWebhooks(t *testing.T) {
+	deliverer := &fakeDeliverer{}
+
+	err := jobs.DeliverWebhooks(deliverer)(context.Background())
+
+	assert.EqualError(t, err, "delivery 1: webhook delivery lease lost")
+	assert.WithinDuration(t, time.Now(), deliverer.now, time.Second)
+}
+
+func TestEvery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs atomic.Int32
+	done := make(chan struct{})
+
+	go func() {
+		jobs.Every(ctx, "test", 5*time.Millisecond, func(context.Context) error {
+			if runs.Add(1) == 3 {
+				cancel()
+			}
+			return errors.New("keeps running after errors")
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job did not stop after the context was cancelled")
+	}
+	assert.GreaterOrEqual(t, runs.Load(), int32(3))
+}
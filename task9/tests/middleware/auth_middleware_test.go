@@ -1,14 +1,19 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"task9/config"
 	"task9/delivery/middleware"
+	"task9/domain"
 	"task9/infrastructure"
+	"task9/repository"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupRouter() *gin.Engine {
@@ -17,7 +22,7 @@ func setupRouter() *gin.Engine {
 }
 
 func TestAuthMiddleware_RequireAuth(t *testing.T) {
-	tokenGenerator := infrastructure.NewJWTGenerator()
+	tokenGenerator := infrastructure.NewJWTGenerator(config.Default().Auth)
 	authMiddleware := middleware.NewAuthMiddleware(tokenGenerator)
 
 	t.Run("valid token", func(t *testing.T) {
@@ -93,8 +98,28 @@ func TestAuthMiddleware_RequireAuth(t *testing.T) {
 	})
 }
 
+func TestAuthMiddleware_RequireAuthStoresActor(t *testing.T) {
+	tokenGenerator := infrastructure.NewJWTGenerator(config.Default().Auth)
+	authMiddleware := middleware.NewAuthMiddleware(tokenGenerator)
+	token, _ := tokenGenerator.Generate("123", "testuser", "admin")
+
+	var actor domain.Actor
+	router := setupRouter()
+	router.Use(authMiddleware.RequireAuth())
+	router.GET("/test", func(c *gin.Context) {
+		actor = domain.ActorFrom(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, domain.Actor{UserID: "123", Username: "testuser", Role: "admin"}, actor)
+}
+
 func TestAuthMiddleware_RequireAdmin(t *testing.T) {
-	tokenGenerator := infrastructure.NewJWTGenerator()
+	tokenGenerator := infrastructure.NewJWTGenerator(config.Default().Auth)
 	authMiddleware := middleware.NewAuthMiddleware(tokenGenerator)
 
 	t.Run("admin access", func(t *testing.T) {
@@ -151,3 +176,53 @@ func TestAuthMiddleware_RequireAdmin(t *testing.T) {
 	})
 }
 
+func TestAuthMiddleware_RequireWorkspace(t *testing.T) {
+	ctx := context.Background()
+	tokenGenerator := infrastructure.NewJWTGenerator(config.Default().Auth)
+	authMiddleware := middleware.NewAuthMiddleware(tokenGenerator)
+	workspaces := repository.NewWorkspaceRepositoryMemory()
+	team, err := workspaces.Create(ctx, domain.Workspace{Name: "Team"})
+	require.NoError(t, err)
+	_, err = workspaces.AddMember(ctx, domain.Membership{WorkspaceID: team.ID, UserID: "1", Username: "alice", Role: domain.WorkspaceAdmin})
+	require.NoError(t, err)
+	_, err = workspaces.AddMember(ctx, domain.Membership{WorkspaceID: team.ID, UserID: "2", Username: "bob", Role: domain.WorkspaceMember})
+	require.NoError(t, err)
+
+	var actor domain.Actor
+	var workspaceID string
+	router := setupRouter()
+	scoped := router.Group("/workspaces/:wid", authMiddleware.RequireAuth(), authMiddleware.RequireWorkspace(workspaces))
+	scoped.GET("/tasks", func(c *gin.Context) {
+		actor = domain.ActorFrom(c.Request.Context())
+		workspaceID, _ = domain.WorkspaceFrom(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	scoped.POST("/tasks", authMiddleware.RequireAdmin(), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	serve := func(method, target, userID, role string) int {
+		token, _ := tokenGenerator.Generate(userID, "someone", role)
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("scopes members to the workspace", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("GET", "/workspaces/"+team.ID+"/tasks", "2", "admin"))
+		assert.Equal(t, team.ID, workspaceID)
+		assert.Equal(t, domain.WorkspaceMember, actor.Role, "the workspace role replaces the global one")
+	})
+
+	t.Run("admin means workspace admin", func(t *testing.T) {
+		assert.Equal(t, http.StatusCreated, serve("POST", "/workspaces/"+team.ID+"/tasks", "1", "user"))
+		assert.Equal(t, http.StatusForbidden, serve("POST", "/workspaces/"+team.ID+"/tasks", "2", "admin"))
+	})
+
+	t.Run("hides workspaces from non-members", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve("GET", "/workspaces/"+team.ID+"/tasks", "3", "admin"))
+		assert.Equal(t, http.StatusNotFound, serve("GET", "/workspaces/missing/tasks", "1", "admin"))
+	})
+}
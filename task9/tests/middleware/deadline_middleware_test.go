@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"task9/delivery/middleware"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadline(t *testing.T) {
+	t.Run("request context carries the deadline", func(t *testing.T) {
+		router := setupRouter()
+		router.Use(middleware.Deadline(time.Second))
+		router.GET("/test", func(c *gin.Context) {
+			deadline, ok := c.Request.Context().Deadline()
+			assert.True(t, ok)
+			assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+			c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		})
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("slow handler times out", func(t *testing.T) {
+		router := setupRouter()
+		router.Use(middleware.Deadline(20 * time.Millisecond))
+		router.GET("/slow", func(c *gin.Context) {
+			<-c.Request.Context().Done()
+		})
+
+		req := httptest.NewRequest("GET", "/slow", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	})
+
+	t.Run("client cancellation propagates", func(t *testing.T) {
+		router := setupRouter()
+		router.Use(middleware.Deadline(time.Minute))
+		cancelled := false
+		router.GET("/test", func(c *gin.Context) {
+			<-c.Request.Context().Done()
+			cancelled = true
+		})
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		ctx, cancel := context.WithCancel(req.Context())
+		cancel()
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req.WithContext(ctx))
+
+		assert.True(t, cancelled)
+	})
+}
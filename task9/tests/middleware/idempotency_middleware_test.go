@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"task9/delivery/middleware"
+	"task9/domain"
+	"task9/repository"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupIdempotencyRouter counts the requests that reach POST /tasks. The
+// user comes from the X-User header in place of RequireAuth.
+func setupIdempotencyRouter(repo domain.IdempotencyRepository, ttl time.Duration) (*gin.Engine, *int) {
+	router := setupRouter()
+	calls := 0
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(domain.WithActor(c.Request.Context(), domain.Actor{UserID: c.GetHeader("X-User")}))
+	})
+	router.Use(middleware.Idempotency(repo, ttl, time.Minute))
+	router.POST("/tasks", func(c *gin.Context) {
+		calls++
+		if c.Query("fail") != "" {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "error"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"status": "success", "id": strconv.Itoa(calls)})
+	})
+	return router, &calls
+}
+
+func postTask(router *gin.Engine, user, key, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("X-User", user)
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	t.Run("a retry replays the first response", func(t *testing.T) {
+		router, calls := setupIdempotencyRouter(repository.NewIdempotencyRepositoryMemory(), time.Hour)
+
+		first := postTask(router, "1", "abc", "/tasks", `{"title":"Ship"}`)
+		second := postTask(router, "1", "abc", "/tasks", `{"title":"Ship"}`)
+
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, first.Header().Get("Content-Type"), second.Header().Get("Content-Type"))
+		assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	})
+
+	t.Run("a key reused for a different request is rejected", func(t *testing.T) {
+		router, calls := setupIdempotencyRouter(repository.NewIdempotencyRepositoryMemory(), time.Hour)
+
+		postTask(router, "1", "abc", "/tasks", `{"title":"Ship"}`)
+		w := postTask(router, "1", "abc", "/tasks", `{"title":"Something else"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		w = postTask(router, "1", "abc", "/tasks?notify=1", `{"title":"Ship"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, 1, *calls)
+	})
+
+	t.Run("keys are per user", func(t *testing.T) {
+		router, calls := setupIdempotencyRouter(repository.NewIdempotencyRepositoryMemory(), time.Hour)
+
+		postTask(router, "1", "abc", "/tasks", `{"title":"Ship"}`)
+		w := postTask(router, "2", "abc", "/tasks", `{"title":"Ship"}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("a retry while the request runs is rejected", func(t *testing.T) {
+		router := setupRouter()
+		router.Use(middleware.Idempotency(repository.NewIdempotencyRepositoryMemory(), time.Hour, time.Minute))
+		var retry *httptest.ResponseRecorder
+		router.POST("/tasks", func(c *gin.Context) {
+			if retry == nil {
+				retry = postTask(router, "", "abc", "/tasks", `{}`)
+			}
+			c.JSON(http.StatusCreated, gin.H{"status": "success"})
+		})
+
+		w := postTask(router, "", "abc", "/tasks", `{}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		require.NotNil(t, retry)
+		assert.Equal(t, http.StatusConflict, retry.Code)
+	})
+
+	t.Run("server errors are not kept", func(t *testing.T) {
+		router, calls := setupIdempotencyRouter(repository.NewIdempotencyRepositoryMemory(), time.Hour)
+
+		w := postTask(router, "1", "abc", "/tasks?fail=1", `{}`)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		w = postTask(router, "1", "abc", "/tasks?fail=1", `{}`)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("a request that outlived its lock leaves the retry's reservation alone", func(t *testing.T) {
+		router := setupRouter()
+		router.Use(middleware.Idempotency(repository.NewIdempotencyRepositoryMemory(), time.Hour, 10*time.Millisecond))
+		retryStarted, finishRetry := make(chan struct{}), make(chan struct{})
+		var retry *httptest.ResponseRecorder
+		retryDone := make(chan struct{})
+		calls := 0
+		router.POST("/tasks", func(c *gin.Context) {
+			calls++
+			if calls == 1 {
+				time.Sleep(20 * time.Millisecond)
+				go func() {
+					defer close(retryDone)
+					retry = postTask(router, "", "abc", "/tasks", `{}`)
+				}()
+				<-retryStarted
+				c.JSON(http.StatusInternalServerError, gin.H{"status": "error"})
+				return
+			}
+			close(retryStarted)
+			<-finishRetry
+			c.JSON(http.StatusCreated, gin.H{"status": "success"})
+		})
+
+		w := postTask(router, "", "abc", "/tasks", `{}`)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		w = postTask(router, "", "abc", "/tasks", `{}`)
+		assert.Equal(t, http.StatusConflict, w.Code, "the failed request released only its own reservation")
+
+		close(finishRetry)
+		<-retryDone
+		assert.Equal(t, http.StatusCreated, retry.Code)
+		w = postTask(router, "", "abc", "/tasks", `{}`)
+		assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+	})
+
+	t.Run("responses expire", func(t *testing.T) {
+		router, calls := setupIdempotencyRouter(repository.NewIdempotencyRepositoryMemory(), time.Millisecond)
+
+		postTask(router, "1", "abc", "/tasks", `{}`)
+		time.Sleep(5 * time.Millisecond)
+		w := postTask(router, "1", "abc", "/tasks", `{}`)
+		assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("requests without a key pass through", func(t *testing.T) {
+		router, calls := setupIdempotencyRouter(repository.NewIdempotencyRepositoryMemory(), time.Hour)
+
+		postTask(router, "1", "", "/tasks", `{}`)
+		postTask(router, "1", "", "/tasks", `{}`)
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("invalid keys are rejected", func(t *testing.T) {
+		router, calls := setupIdempotencyRouter(repository.NewIdempotencyRepositoryMemory(), time.Hour)
+
+		for _, key := range []string{strings.Repeat("k", 256), "café"} {
+			w := postTask(router, "1", key, "/tasks", `{}`)
+			assert.Equal(t, http.StatusBadRequest, w.Code, key)
+		}
+		assert.Equal(t, 0, *calls)
+	})
+}
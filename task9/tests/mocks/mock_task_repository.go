@@ -1,7 +1,9 @@
 package mocks
 
 import (
+	"context"
 	"task9/domain"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -10,28 +12,137 @@ type MockTaskRepository struct {
 	mock.Mock
 }
 
-func (m *MockTaskRepository) GetAll() ([]domain.Task, error) {
-	args := m.Called()
+func (m *MockTaskRepository) GetAll(ctx context.Context) ([]domain.Task, error) {
+	args := m.Called(ctx)
 	return args.Get(0).([]domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepository) GetByID(id string) (domain.Task, error) {
-	args := m.Called(id)
+func (m *MockTaskRepository) Find(ctx context.Context, filter domain.TaskFilter) ([]domain.Task, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetByID(ctx context.Context, id string) (domain.Task, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) Each(ctx context.Context, filter domain.TaskFilter, fn func(domain.Task) error) error {
+	args := m.Called(ctx, filter, fn)
+	return args.Error(0)
+}
+
+func (m *MockTaskRepository) GetByExternalID(ctx context.Context, externalID string) (domain.Task, error) {
+	args := m.Called(ctx, externalID)
+	return args.Get(0).(domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetByIDs(ctx context.Context, ids []string) ([]domain.Task, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetSubtasks(ctx context.Context, parentIDs []string) ([]domain.Task, error) {
+	args := m.Called(ctx, parentIDs)
+	return args.Get(0).([]domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetBlockedBy(ctx context.Context, blockerIDs []string) ([]domain.Task, error) {
+	args := m.Called(ctx, blockerIDs)
+	return args.Get(0).([]domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) Create(ctx context.Context, task domain.Task, entry domain.HistoryEntry) (domain.Task, error) {
+	args := m.Called(ctx, task, entry)
 	return args.Get(0).(domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepository) Create(task domain.Task) (domain.Task, error) {
-	args := m.Called(task)
+func (m *MockTaskRepository) Update(ctx context.Context, id string, task domain.Task, entry domain.HistoryEntry) (domain.Task, error) {
+	args := m.Called(ctx, id, task, entry)
 	return args.Get(0).(domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepository) Update(id string, task domain.Task) (domain.Task, error) {
-	args := m.Called(id, task)
+func (m *MockTaskRepository) Delete(ctx context.Context, id string, entry domain.HistoryEntry) error {
+	args := m.Called(ctx, id, entry)
+	return args.Error(0)
+}
+
+func (m *MockTaskRepository) GetDeletedByID(ctx context.Context, id string) (domain.Task, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetDeleted(ctx context.Context) ([]domain.Task, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) Restore(ctx context.Context, id string, entry domain.HistoryEntry) (domain.Task, error) {
+	args := m.Called(ctx, id, entry)
 	return args.Get(0).(domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepository) Delete(id string) error {
-	args := m.Called(id)
+func (m *MockTaskRepository) GetHistory(ctx context.Context, id string, offset, limit int) ([]domain.HistoryEntry, int, error) {
+	args := m.Called(ctx, id, offset, limit)
+	return args.Get(0).([]domain.HistoryEntry), args.Int(1), args.Error(2)
+}
+
+func (m *MockTaskRepository) Purge(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockTaskRepository) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTaskRepository) BulkWrite(ctx context.Context, writes []domain.TaskWrite) ([]error, error) {
+	args := m.Called(ctx, writes)
+	return args.Get(0).([]error), args.Error(1)
+}
+
+func (m *MockTaskRepository) BulkWriteAtomic(ctx context.Context, writes []domain.TaskWrite) ([]error, error) {
+	args := m.Called(ctx, writes)
+	return args.Get(0).([]error), args.Error(1)
+}
+
+func (m *MockTaskRepository) CountTags(ctx context.Context) (map[string]int, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
+func (m *MockTaskRepository) RenameTag(ctx context.Context, oldName, newName string, entry domain.HistoryEntry) ([]domain.TaskRevision, error) {
+	args := m.Called(ctx, oldName, newName, entry)
+	return args.Get(0).([]domain.TaskRevision), args.Error(1)
+}
+
+func (m *MockTaskRepository) RemoveTag(ctx context.Context, name string, entry domain.HistoryEntry) ([]domain.TaskRevision, error) {
+	args := m.Called(ctx, name, entry)
+	return args.Get(0).([]domain.TaskRevision), args.Error(1)
+}
+
+func (m *MockTaskRepository) RemoveProject(ctx context.Context, projectID string, entry domain.HistoryEntry) ([]domain.TaskRevision, error) {
+	args := m.Called(ctx, projectID, entry)
+	return args.Get(0).([]domain.TaskRevision), args.Error(1)
+}
+
+func (m *MockTaskRepository) Stats(ctx context.Context, query domain.TaskStatsQuery) (domain.TaskStats, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(domain.TaskStats), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetRecurring(ctx context.Context, dueBefore time.Time) ([]domain.Task, error) {
+	args := m.Called(ctx, dueBefore)
+	return args.Get(0).([]domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) CreateOccurrence(ctx context.Context, previousID string, task domain.Task, entry domain.HistoryEntry) (domain.Task, error) {
+	args := m.Called(ctx, previousID, task, entry)
+	return args.Get(0).(domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetDueBetween(ctx context.Context, from, to time.Time) ([]domain.Task, error) {
+	args := m.Called(ctx, from, to)
+	return args.Get(0).([]domain.Task), args.Error(1)
+}
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"task9/domain"
 
 	"github.com/stretchr/testify/mock"
@@ -10,28 +11,37 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) Create(user domain.User) (domain.User, error) {
-	args := m.Called(user)
+func (m *MockUserRepository) Create(ctx context.Context, user domain.User) (domain.User, error) {
+	args := m.Called(ctx, user)
 	return args.Get(0).(domain.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByUsername(username string) (domain.User, error) {
-	args := m.Called(username)
+func (m *MockUserRepository) GetByUsername(ctx context.Context, username string) (domain.User, error) {
+	args := m.Called(ctx, username)
 	return args.Get(0).(domain.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByID(id string) (domain.User, error) {
-	args := m.Called(id)
+func (m *MockUserRepository) GetByID(ctx context.Context, id string) (domain.User, error) {
+	args := m.Called(ctx, id)
 	return args.Get(0).(domain.User), args.Error(1)
 }
 
-func (m *MockUserRepository) UpdateRole(username string, role string) error {
-	args := m.Called(username, role)
+func (m *MockUserRepository) UpdateRole(ctx context.Context, username string, role string) error {
+	args := m.Called(ctx, username, role)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) IsFirstUser() (bool, error) {
-	args := m.Called()
+func (m *MockUserRepository) IsFirstUser(ctx context.Context) (bool, error) {
+	args := m.Called(ctx)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockUserRepository) SetCalendarTokenHash(ctx context.Context, id string, hash string) error {
+	args := m.Called(ctx, id, hash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByCalendarTokenHash(ctx context.Context, hash string) (domain.User, error) {
+	args := m.Called(ctx, hash)
+	return args.Get(0).(domain.User), args.Error(1)
+}
@@ -0,0 +1,123 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"task9/config"
+	"task9/delivery"
+	"task9/delivery/openapi"
+	"task9/infrastructure"
+	"task9/usecase"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPISpec_CoversEveryRoute(t *testing.T) {
+	cfg := config.Default()
+	router := delivery.SetupRouter(cfg, delivery.NewServices(cfg, usecase.NewEventHub(), infrastructure.NewLocalBlobStore(t.TempDir()), nil))
+	spec := delivery.OpenAPISpec()
+
+	registered := map[string]bool{}
+	for _, route := range router.Routes() {
+		registered[route.Method+" "+openapi.PathFromGin(route.Path)] = true
+		_, ok := spec.Operation(route.Method, route.Path)
+		assert.True(t, ok, "route %s %s is missing from the OpenAPI spec", route.Method, route.Path)
+	}
+
+	for path, item := range spec.Paths {
+		for method := range item {
+			key := strings.ToUpper(method) + " " + path
+			assert.True(t, registered[key], "spec documents %s which is not registered", key)
+		}
+	}
+}
+
+func TestOpenAPISpec_SchemasFollowDTOs(t *testing.T) {
+	spec := delivery.OpenAPISpec()
+
+	create := spec.Components.Schemas["CreateTaskRequest"]
+	require.NotNil(t, create)
+	assert.ElementsMatch(t, []string{"title", "due_date"}, create.Required)
+	assert.Equal(t, []string{"pending", "in_progress", "completed"}, create.Properties["status"].Enum)
+	assert.Equal(t, "date-time", create.Properties["due_date"].Format)
+
+	task := spec.Components.Schemas["Task"]
+	require.NotNil(t, task)
+	for _, field := range []string{"id", "title", "description", "due_date", "status", "created_at", "updated_at"} {
+		assert.Contains(t, task.Properties, field)
+	}
+
+	user := spec.Components.Schemas["User"]
+	require.NotNil(t, user)
+	assert.NotContains(t, user.Properties, "password")
+
+	assert.Contains(t, spec.Components.Schemas, "ErrorResponse")
+	assert.Contains(t, spec.Components.SecuritySchemes, "bearerAuth")
+}
+
+func TestOpenAPISpec_Served(t *testing.T) {
+	cfg := config.Default()
+	router := delivery.SetupRouter(cfg, delivery.NewServices(cfg, usecase.NewEventHub(), infrastructure.NewLocalBlobStore(t.TempDir()), nil))
+
+	req := httptest.NewRequest("GET", "/openapi.json", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "3.0.3", body["openapi"])
+	assert.Contains(t, body["paths"], "/workspaces/{wid}/tasks/{id}")
+}
+
+func TestValidateRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	spec := delivery.OpenAPISpec()
+
+	router := gin.New()
+	router.Use(openapi.ValidateRequests(spec))
+	router.POST("/workspaces/:wid/tasks", func(c *gin.Context) {
+		var body map[string]interface{}
+		require.NoError(t, c.ShouldBindJSON(&body))
+		c.JSON(http.StatusCreated, gin.H{"status": "success"})
+	})
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/workspaces/w1/tasks", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("valid body reaches the handler", func(t *testing.T) {
+		w := send(`{"title": "Task", "due_date": "2030-01-01T00:00:00Z"}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("missing required field", func(t *testing.T) {
+		w := send(`{"due_date": "2030-01-01T00:00:00Z"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "body.title: is required")
+	})
+
+	t.Run("wrong type and enum", func(t *testing.T) {
+		w := send(`{"title": 5, "due_date": "tomorrow", "status": "done"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "body.title: must be a string")
+		assert.Contains(t, w.Body.String(), "body.due_date: must be an RFC3339 date-time")
+		assert.Contains(t, w.Body.String(), "body.status: must be one of")
+	})
+
+	t.Run("malformed json", func(t *testing.T) {
+		w := send(`{"title": `)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,124 @@
+package recurrence
+
+import (
+	"task9/recurrence"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dates(times []time.Time) []string {
+	out := make([]string, len(times))
+	for i, t := range times {
+		out[i] = t.Format("2006-01-02 Mon")
+	}
+	return out
+}
+
+func TestParse(t *testing.T) {
+	t.Run("round trips to canonical form", func(t *testing.T) {
+		rule, err := recurrence.Parse("RRULE:freq=weekly;byday=mo,we;interval=2;until=20241231")
+		require.NoError(t, err)
+		assert.Equal(t, recurrence.Weekly, rule.Freq)
+		assert.Equal(t, 2, rule.Interval)
+		assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20241231T235959Z", rule.String())
+	})
+
+	t.Run("ordinals", func(t *testing.T) {
+		rule, err := recurrence.Parse("FREQ=MONTHLY;BYDAY=-1FR,2TU")
+		require.NoError(t, err)
+		assert.Equal(t, []recurrence.WeekdayNum{{N: -1, Weekday: time.Friday}, {N: 2, Weekday: time.Tuesday}}, rule.ByDay)
+	})
+
+	for _, input := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=x",
+		"FREQ=DAILY;COUNT=2;UNTIL=20241231",
+		"FREQ=DAILY;BYMONTH=1",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;UNTIL=tomorrow",
+	} {
+		_, err := recurrence.Parse(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestRuleOccurrences(t *testing.T) {
+	// Wednesday 3 January 2024, 09:30.
+	start := time.Date(2024, 1, 3, 9, 30, 0, 0, time.UTC)
+	occurrences := func(rule string, limit int) []string {
+		r, err := recurrence.Parse(rule)
+		require.NoError(t, err)
+		return dates(r.Occurrences(start, limit))
+	}
+
+	t.Run("daily with interval", func(t *testing.T) {
+		assert.Equal(t, []string{"2024-01-03 Wed", "2024-01-06 Sat", "2024-01-09 Tue"}, occurrences("FREQ=DAILY;INTERVAL=3", 3))
+	})
+
+	t.Run("daily limited to weekdays", func(t *testing.T) {
+		assert.Equal(t, []string{"2024-01-03 Wed", "2024-01-04 Thu", "2024-01-05 Fri", "2024-01-08 Mon"}, occurrences("FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", 4))
+	})
+
+	t.Run("every other week on Monday and Friday", func(t *testing.T) {
+		assert.Equal(t,
+			[]string{"2024-01-03 Wed", "2024-01-05 Fri", "2024-01-15 Mon", "2024-01-19 Fri", "2024-01-29 Mon"},
+			occurrences("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", 5))
+	})
+
+	t.Run("monthly skips months without the day", func(t *testing.T) {
+		r, err := recurrence.Parse("FREQ=MONTHLY")
+		require.NoError(t, err)
+		jan31 := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, []string{"2024-01-31 Wed", "2024-03-31 Sun", "2024-05-31 Fri"}, dates(r.Occurrences(jan31, 3)))
+	})
+
+	t.Run("last Friday of the month", func(t *testing.T) {
+		assert.Equal(t, []string{"2024-01-03 Wed", "2024-01-26 Fri", "2024-02-23 Fri", "2024-03-29 Fri"}, occurrences("FREQ=MONTHLY;BYDAY=-1FR", 4))
+	})
+
+	t.Run("yearly on 29 February", func(t *testing.T) {
+		r, err := recurrence.Parse("FREQ=YEARLY")
+		require.NoError(t, err)
+		leap := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, []string{"2024-02-29 Thu", "2028-02-29 Tue"}, dates(r.Occurrences(leap, 2)))
+	})
+
+	t.Run("count includes the start", func(t *testing.T) {
+		assert.Equal(t, []string{"2024-01-03 Wed", "2024-01-10 Wed", "2024-01-17 Wed"}, occurrences("FREQ=WEEKLY;COUNT=3", 10))
+	})
+
+	t.Run("until is inclusive", func(t *testing.T) {
+		assert.Equal(t, []string{"2024-01-03 Wed", "2024-01-04 Thu", "2024-01-05 Fri"}, occurrences("FREQ=DAILY;UNTIL=20240105", 10))
+	})
+
+	t.Run("time of day is kept", func(t *testing.T) {
+		r, err := recurrence.Parse("FREQ=WEEKLY;BYDAY=FR")
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2024, 1, 5, 9, 30, 0, 0, time.UTC), r.Occurrences(start, 2)[1])
+	})
+}
+
+func TestRuleNext(t *testing.T) {
+	start := time.Date(2024, 1, 3, 9, 30, 0, 0, time.UTC)
+	rule, err := recurrence.Parse("FREQ=WEEKLY;COUNT=3")
+	require.NoError(t, err)
+
+	next, ok := rule.Next(start, start)
+	assert.True(t, ok)
+	assert.Equal(t, start.AddDate(0, 0, 7), next)
+
+	next, ok = rule.Next(start, start.AddDate(0, 0, 8))
+	assert.True(t, ok)
+	assert.Equal(t, start.AddDate(0, 0, 14), next)
+
+	_, ok = rule.Next(start, start.AddDate(0, 0, 14))
+	assert.False(t, ok, "the series ends after three occurrences")
+}
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+	"task9/domain"
+	"task9/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommentRepositoryMemory(t *testing.T) {
+	ctx := domain.WithWorkspace(context.Background(), "team")
+	repo := repository.NewCommentRepositoryMemory()
+	now := time.Now()
+
+	first, err := repo.Create(ctx, domain.Comment{TaskID: "task-1", Body: "first", CreatedAt: now})
+	require.NoError(t, err)
+	second, err := repo.Create(ctx, domain.Comment{TaskID: "task-1", Body: "second", CreatedAt: now})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, domain.Comment{TaskID: "task-2", Body: "other", CreatedAt: now.Add(-time.Hour)})
+	require.NoError(t, err)
+
+	t.Run("list is ordered and scoped to the task", func(t *testing.T) {
+		comments, err := repo.ListByTask(ctx, "task-1")
+		require.NoError(t, err)
+		require.Len(t, comments, 2)
+		assert.Equal(t, first.ID, comments[0].ID)
+		assert.Equal(t, second.ID, comments[1].ID)
+	})
+
+	t.Run("update body", func(t *testing.T) {
+		updated, err := repo.UpdateBody(ctx, first.ID, "edited")
+		require.NoError(t, err)
+		assert.Equal(t, "edited", updated.Body)
+		assert.True(t, updated.UpdatedAt.After(first.UpdatedAt))
+
+		_, err = repo.UpdateBody(ctx, "missing", "edited")
+		assert.EqualError(t, err, "comment not found")
+	})
+
+	t.Run("counts per task", func(t *testing.T) {
+		counts, err := repo.CountByTasks(ctx, []string{"task-1", "task-2", "task-3"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"task-1": 2, "task-2": 1}, counts)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, repo.Delete(ctx, second.ID))
+		_, err := repo.GetByID(ctx, second.ID)
+		assert.EqualError(t, err, "comment not found")
+		assert.EqualError(t, repo.Delete(ctx, second.ID), "comment not found")
+	})
+
+	t.Run("delete by tasks", func(t *testing.T) {
+		deleted, err := repo.DeleteByTasks(ctx, []string{"task-1", "task-2"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+
+		counts, err := repo.CountByTasks(ctx, []string{"task-1", "task-2"})
+		require.NoError(t, err)
+		assert.Empty(t, counts)
+	})
+}
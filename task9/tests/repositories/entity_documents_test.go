@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"task9/domain/entity"
+	"task9/infrastructure/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// documentFixture is one stored document from testdata and either the
+// entity it decodes to or the start of the error it gives.
+type documentFixture[T any] struct {
+	Name     string          `json:"name"`
+	Document json.RawMessage `json:"document"`
+	Want     *T              `json:"want"`
+	Error    string          `json:"error"`
+}
+
+func loadDocumentFixtures[T any](t *testing.T, name string) []documentFixture[T] {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	var fixtures []documentFixture[T]
+	require.NoError(t, json.Unmarshal(content, &fixtures))
+	require.NotEmpty(t, fixtures)
+	return fixtures
+}
+
+func (f documentFixture[T]) raw(t *testing.T) bson.Raw {
+	t.Helper()
+	var raw bson.Raw
+	require.NoError(t, bson.UnmarshalExtJSON(f.Document, false, &raw))
+	return raw
+}
+
+// assertMalformed checks err against the fixture and that the document was
+// counted in collection.
+func assertMalformed(t *testing.T, collection string, before int64, err error, want string) {
+	t.Helper()
+	var malformed *repository.MalformedDocumentError
+	require.ErrorAs(t, err, &malformed)
+	assert.Equal(t, collection, malformed.Collection)
+	assert.Contains(t, err.Error(), want)
+	assert.Equal(t, before+1, malformedCount(collection))
+}
+
+func malformedCount(collection string) int64 {
+	value := repository.MalformedDocuments.Get(collection)
+	if value == nil {
+		return 0
+	}
+	n, _ := strconv.ParseInt(value.String(), 10, 64)
+	return n
+}
+
+func TestDecodeTask_Fixtures(t *testing.T) {
+	type want struct {
+		ID          string    `json:"id"`
+		Title       string    `json:"title"`
+		Description string    `json:"description"`
+		DueDate     time.Time `json:"due_date"`
+		Status      string    `json:"status"`
+		CreatedAt   time.Time `json:"created_at"`
+		UpdatedAt   time.Time `json:"updated_at"`
+	}
+
+	for _, fixture := range loadDocumentFixtures[want](t, "task_documents.json") {
+		t.Run(fixture.Name, func(t *testing.T) {
+			before := malformedCount("tasks")
+			task, err := repository.DecodeTask(fixture.raw(t))
+
+			if fixture.Want == nil {
+				assert.Nil(t, task)
+				assertMalformed(t, "tasks", before, err, fixture.Error)
+				return
+			}
+			require.NoError(t, err)
+			w := fixture.Want
+			assert.Equal(t, &entity.Task{
+				ID:          w.ID,
+				Title:       w.Title,
+				Description: w.Description,
+				DueDate:     w.DueDate,
+				Status:      w.Status,
+				CreatedAt:   w.CreatedAt,
+				UpdatedAt:   w.UpdatedAt,
+			}, normalizeTask(task))
+			assert.Equal(t, before, malformedCount("tasks"))
+		})
+	}
+}
+
+func TestDecodeUser_Fixtures(t *testing.T) {
+	type want struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Role     string `json:"role"`
+	}
+
+	for _, fixture := range loadDocumentFixtures[want](t, "user_documents.json") {
+		t.Run(fixture.Name, func(t *testing.T) {
+			before := malformedCount("users")
+			user, err := repository.DecodeUser(fixture.raw(t))
+
+			if fixture.Want == nil {
+				assert.Nil(t, user)
+				assertMalformed(t, "users", before, err, fixture.Error)
+				return
+			}
+			require.NoError(t, err)
+			w := fixture.Want
+			assert.Equal(t, &entity.User{ID: w.ID, Username: w.Username, Password: w.Password, Role: w.Role}, user)
+			assert.Equal(t, before, malformedCount("users"))
+		})
+	}
+}
+
+// normalizeTask puts the decoded times in UTC so they compare equal to the
+// fixture times.
+func normalizeTask(task *entity.Task) *entity.Task {
+	copied := *task
+	copied.DueDate = copied.DueDate.UTC()
+	copied.CreatedAt = copied.CreatedAt.UTC()
+	copied.UpdatedAt = copied.UpdatedAt.UTC()
+	return &copied
+}
+
+func TestTaskRepository_FindAllReportsMalformedDocuments(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("decoded tasks come with an error per malformed document", func(mt *mtest.T) {
+		fixtures := loadDocumentFixtures[struct{}](t, "task_documents.json")
+		var docs []bson.D
+		var decodable, malformed int
+		for _, fixture := range fixtures {
+			var doc bson.D
+			require.NoError(t, bson.Unmarshal(fixture.raw(t), &doc))
+			docs = append(docs, doc)
+			if fixture.Want == nil {
+				malformed++
+			} else {
+				decodable++
+			}
+		}
+		require.NotZero(t, malformed)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "task_manager.tasks", mtest.FirstBatch, docs...),
+		)
+		before := malformedCount("tasks")
+
+		tasks, err := repository.NewTaskRepository(mt.Coll, time.Second).FindAll(context.Background())
+
+		assert.Len(t, tasks, decodable)
+		require.Error(t, err)
+		joined, ok := err.(interface{ Unwrap() []error })
+		require.True(t, ok, "one error per malformed document")
+		require.Len(t, joined.Unwrap(), malformed)
+		for _, err := range joined.Unwrap() {
+			var malformedErr *repository.MalformedDocumentError
+			assert.ErrorAs(t, err, &malformedErr)
+		}
+		assert.Equal(t, before+int64(malformed), malformedCount("tasks"))
+	})
+}
@@ -0,0 +1,231 @@
+package repositories
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"task9/domain"
+	"task9/infrastructure"
+	"task9/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTaskRepository counts the GetByID calls that reach the repository
+// it wraps, each of which takes delay.
+type countingTaskRepository struct {
+	domain.TaskRepository
+	delay time.Duration
+	calls atomic.Int64
+}
+
+func (r *countingTaskRepository) GetByID(ctx context.Context, id string) (domain.Task, error) {
+	r.calls.Add(1)
+	time.Sleep(r.delay)
+	return r.TaskRepository.GetByID(ctx, id)
+}
+
+func cacheMetric(name string) int64 {
+	if v, ok := repository.TaskCacheMetrics.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func TestCachedTaskRepository(t *testing.T) {
+	ctx := domain.WithWorkspace(context.Background(), "team")
+	entry := func(action string, changes []domain.FieldChange) domain.HistoryEntry {
+		return domain.HistoryEntry{Action: action, Actor: "alice", At: time.Now(), Changes: changes}
+	}
+	setup := func() (*countingTaskRepository, domain.TaskRepository) {
+		inner := &countingTaskRepository{TaskRepository: repository.NewTaskRepositoryMemory()}
+		return inner, repository.NewCachedTaskRepository(inner, infrastructure.NewMemoryTaskCache(100, time.Minute))
+	}
+
+	t.Run("repeated lookups are answered from the cache", func(t *testing.T) {
+		inner, repo := setup()
+		task, err := repo.Create(ctx, domain.Task{Title: "A", Tags: []string{"x"}}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+		hits, misses := cacheMetric("hits"), cacheMetric("misses")
+
+		for i := 0; i < 3; i++ {
+			got, err := repo.GetByID(ctx, task.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "A", got.Title)
+			got.Tags[0] = "changed"
+		}
+		assert.Equal(t, int64(1), inner.calls.Load())
+		assert.Equal(t, hits+2, cacheMetric("hits"))
+		assert.Equal(t, misses+1, cacheMetric("misses"))
+
+		got, err := repo.GetByID(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"x"}, got.Tags, "changing a returned task does not change the cached one")
+	})
+
+	t.Run("writes drop the task and its parent", func(t *testing.T) {
+		_, repo := setup()
+		parent, err := repo.Create(ctx, domain.Task{Title: "parent"}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+		_, err = repo.GetByID(ctx, parent.ID)
+		require.NoError(t, err)
+
+		child, err := repo.Create(ctx, domain.Task{Title: "child", ParentID: parent.ID}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+		got, err := repo.GetByID(ctx, parent.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, got.Subtasks.Total)
+
+		_, err = repo.GetByID(ctx, child.ID)
+		require.NoError(t, err)
+		changes := []domain.FieldChange{{Field: "title", Old: "child", New: "renamed"}}
+		_, err = repo.Update(ctx, child.ID, domain.Task{Title: "renamed"}, entry(domain.HistoryUpdate, changes))
+		require.NoError(t, err)
+		got, err = repo.GetByID(ctx, child.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "renamed", got.Title)
+
+		require.NoError(t, repo.Delete(ctx, child.ID, entry(domain.HistoryDelete, nil)))
+		_, err = repo.GetByID(ctx, child.ID)
+		assert.EqualError(t, err, "task not found")
+		got, err = repo.GetByID(ctx, parent.ID)
+		require.NoError(t, err)
+		assert.Zero(t, got.Subtasks.Total)
+
+		_, err = repo.Restore(ctx, child.ID, entry(domain.HistoryRestore, nil))
+		require.NoError(t, err)
+		got, err = repo.GetByID(ctx, parent.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, got.Subtasks.Total)
+	})
+
+	t.Run("a conflicting update drops the stale copy", func(t *testing.T) {
+		inner, repo := setup()
+		task, err := repo.Create(ctx, domain.Task{Title: "A"}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+		_, err = repo.GetByID(ctx, task.ID)
+		require.NoError(t, err)
+
+		// Another replica changes the task behind this cache's back.
+		changes := []domain.FieldChange{{Field: "title", Old: "A", New: "B"}}
+		_, err = inner.Update(ctx, task.ID, domain.Task{Title: "B"}, entry(domain.HistoryUpdate, changes))
+		require.NoError(t, err)
+		got, err := repo.GetByID(ctx, task.ID)
+		require.NoError(t, err)
+		require.Equal(t, "A", got.Title)
+
+		changes = []domain.FieldChange{{Field: "title", Old: "A", New: "C"}}
+		_, err = repo.Update(ctx, task.ID, domain.Task{Title: "C"}, entry(domain.HistoryUpdate, changes))
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		got, err = repo.GetByID(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "B", got.Title)
+	})
+
+	t.Run("cached tasks stay in their workspace", func(t *testing.T) {
+		_, repo := setup()
+		task, err := repo.Create(ctx, domain.Task{Title: "A"}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+		_, err = repo.GetByID(ctx, task.ID)
+		require.NoError(t, err)
+
+		_, err = repo.GetByID(domain.WithWorkspace(ctx, "other"), task.ID)
+		assert.EqualError(t, err, "task not found")
+	})
+
+	t.Run("concurrent misses share one lookup", func(t *testing.T) {
+		inner, repo := setup()
+		inner.delay = 20 * time.Millisecond
+		task, err := repo.Create(ctx, domain.Task{Title: "A"}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				got, err := repo.GetByID(ctx, task.ID)
+				assert.NoError(t, err)
+				assert.Equal(t, task.ID, got.ID)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int64(1), inner.calls.Load())
+	})
+
+	t.Run("a caller that gives up does not fail the lookup", func(t *testing.T) {
+		inner, repo := setup()
+		inner.delay = 20 * time.Millisecond
+		task, err := repo.Create(ctx, domain.Task{Title: "A"}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+
+		short, cancel := context.WithTimeout(ctx, time.Millisecond)
+		defer cancel()
+		_, err = repo.GetByID(short, task.ID)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		_, err = repo.GetByID(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), inner.calls.Load())
+	})
+
+	t.Run("bulk changes empty the cache", func(t *testing.T) {
+		_, repo := setup()
+		task, err := repo.Create(ctx, domain.Task{Title: "A", Tags: []string{"x"}}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+		_, err = repo.GetByID(ctx, task.ID)
+		require.NoError(t, err)
+
+		_, err = repo.RenameTag(ctx, "x", "y", entry(domain.HistoryUpdate, nil))
+		require.NoError(t, err)
+		got, err := repo.GetByID(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"y"}, got.Tags)
+	})
+}
+
+// BenchmarkCachedTaskRepository_GetByID compares lookups against a store that
+// takes 200µs per read, roughly a round trip to a nearby MongoDB, with and
+// without the cache in front of it.
+func BenchmarkCachedTaskRepository_GetByID(b *testing.B) {
+	ctx := domain.WithWorkspace(context.Background(), "team")
+	setup := func(b *testing.B, cached bool) (domain.TaskRepository, []string) {
+		inner := &countingTaskRepository{TaskRepository: repository.NewTaskRepositoryMemory(), delay: 200 * time.Microsecond}
+		var repo domain.TaskRepository = inner
+		if cached {
+			repo = repository.NewCachedTaskRepository(inner, infrastructure.NewMemoryTaskCache(1000, time.Minute))
+		}
+		ids := make([]string, 100)
+		for i := range ids {
+			task, err := repo.Create(ctx, domain.Task{Title: "task"}, domain.HistoryEntry{Action: domain.HistoryCreate})
+			require.NoError(b, err)
+			ids[i] = task.ID
+		}
+		return repo, ids
+	}
+
+	for _, cached := range []bool{false, true} {
+		name := "uncached"
+		if cached {
+			name = "cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			repo, ids := setup(b, cached)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					if _, err := repo.GetByID(ctx, ids[i%len(ids)]); err != nil {
+						b.Error(err)
+						return
+					}
+					i++
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"task9/domain"
+	"task9/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskRepositoryMemory(t *testing.T) {
+	ctx := domain.WithWorkspace(context.Background(), "team")
+	now := time.Now()
+	entry := func(action string, changes []domain.FieldChange) domain.HistoryEntry {
+		return domain.HistoryEntry{Action: action, Actor: "alice", At: now, Changes: changes}
+	}
+
+	t.Run("update checks the old values", func(t *testing.T) {
+		repo := repository.NewTaskRepositoryMemory()
+		task, err := repo.Create(ctx, domain.Task{Title: "A", Status: "pending"}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+
+		stale := []domain.FieldChange{{Field: "title", Old: "B", New: "C"}}
+		_, err = repo.Update(ctx, task.ID, domain.Task{Title: "C"}, entry(domain.HistoryUpdate, stale))
+		assert.ErrorIs(t, err, domain.ErrConflict)
+
+		changes := []domain.FieldChange{{Field: "title", Old: "A", New: "C"}}
+		updated, err := repo.Update(ctx, task.ID, domain.Task{Title: "C"}, entry(domain.HistoryUpdate, changes))
+		require.NoError(t, err)
+		assert.Equal(t, "C", updated.Title)
+		assert.Equal(t, "pending", updated.Status)
+
+		history, total, err := repo.GetHistory(ctx, task.ID, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		assert.Equal(t, domain.HistoryUpdate, history[0].Action)
+	})
+
+	t.Run("trash, restore and purge", func(t *testing.T) {
+		repo := repository.NewTaskRepositoryMemory()
+		parent, err := repo.Create(ctx, domain.Task{Title: "parent"}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+		child, err := repo.Create(ctx, domain.Task{Title: "child", ParentID: parent.ID, Status: "completed"}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+
+		got, err := repo.GetByID(ctx, parent.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.SubtaskCount{Total: 1, Completed: 1}, got.Subtasks)
+
+		require.NoError(t, repo.Delete(ctx, child.ID, entry(domain.HistoryDelete, nil)))
+		_, err = repo.GetByID(ctx, child.ID)
+		assert.EqualError(t, err, "task not found")
+		got, err = repo.GetByID(ctx, parent.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, got.Subtasks.Total)
+
+		restored, err := repo.Restore(ctx, child.ID, entry(domain.HistoryRestore, nil))
+		require.NoError(t, err)
+		assert.True(t, restored.DeletedAt.IsZero())
+		assert.EqualError(t, repo.Purge(ctx, child.ID), "task not found in trash")
+
+		require.NoError(t, repo.Delete(ctx, child.ID, entry(domain.HistoryDelete, nil)))
+		purged, err := repo.PurgeDeleted(ctx, now.Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, []string{child.ID}, purged)
+	})
+
+	t.Run("atomic batch applies nothing on failure", func(t *testing.T) {
+		repo := repository.NewTaskRepositoryMemory()
+		writes := []domain.TaskWrite{
+			{Op: domain.BatchCreate, Task: domain.Task{Title: "new"}, History: entry(domain.HistoryCreate, nil)},
+			{Op: domain.BatchDelete, ID: "missing", History: entry(domain.HistoryDelete, nil)},
+		}
+
+		errs, err := repo.BulkWriteAtomic(ctx, writes)
+		assert.ErrorIs(t, err, domain.ErrBatchAborted)
+		assert.NoError(t, errs[0])
+		assert.EqualError(t, errs[1], "task not found")
+		tasks, err := repo.GetAll(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, tasks)
+
+		errs, err = repo.BulkWrite(ctx, writes)
+		require.NoError(t, err)
+		assert.NoError(t, errs[0])
+		assert.NotEmpty(t, writes[0].ID)
+	})
+
+	t.Run("atomic batch rolls back writes that depend on each other", func(t *testing.T) {
+		repo := repository.NewTaskRepositoryMemory()
+		x, err := repo.Create(ctx, domain.Task{Title: "x"}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+		writes := []domain.TaskWrite{
+			{Op: domain.BatchCreate, Task: domain.Task{Title: "a"}, History: entry(domain.HistoryCreate, nil)},
+			{Op: domain.BatchDelete, ID: x.ID, History: entry(domain.HistoryDelete, nil)},
+			{Op: domain.BatchUpdate, ID: x.ID, Task: domain.Task{Title: "renamed"}, History: entry(domain.HistoryUpdate, nil)},
+		}
+
+		errs, err := repo.BulkWriteAtomic(ctx, writes)
+		assert.ErrorIs(t, err, domain.ErrBatchAborted)
+		assert.EqualError(t, errs[2], "task not found", "the update sees the delete before it")
+		tasks, err := repo.GetAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1, "neither the create nor the delete is kept")
+		assert.Equal(t, x.ID, tasks[0].ID)
+		_, total, err := repo.GetHistory(ctx, x.ID, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+
+		writes = []domain.TaskWrite{
+			{Op: domain.BatchUpdate, ID: x.ID, Task: domain.Task{Title: "renamed"}, History: entry(domain.HistoryUpdate, nil)},
+			{Op: domain.BatchDelete, ID: x.ID, History: entry(domain.HistoryDelete, nil)},
+		}
+		errs, err = repo.BulkWriteAtomic(ctx, writes)
+		require.NoError(t, err)
+		assert.Equal(t, []error{nil, nil}, errs)
+		deleted, err := repo.GetDeleted(ctx)
+		require.NoError(t, err)
+		require.Len(t, deleted, 1)
+		assert.Equal(t, "renamed", deleted[0].Title)
+	})
+
+	t.Run("trashed tasks are found by ID", func(t *testing.T) {
+		repo := repository.NewTaskRepositoryMemory()
+		live, err := repo.Create(ctx, domain.Task{Title: "live"}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+		trashed, err := repo.Create(ctx, domain.Task{Title: "trashed"}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+		require.NoError(t, repo.Delete(ctx, trashed.ID, entry(domain.HistoryDelete, nil)))
+
+		got, err := repo.GetDeletedByID(ctx, trashed.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "trashed", got.Title)
+		_, err = repo.GetDeletedByID(ctx, live.ID)
+		assert.EqualError(t, err, "task not found")
+		_, err = repo.GetDeletedByID(domain.WithWorkspace(context.Background(), "other"), trashed.ID)
+		assert.EqualError(t, err, "task not found")
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		repo := repository.NewTaskRepositoryMemory()
+		a, err := repo.Create(ctx, domain.Task{Title: "a", Tags: []string{"x", "y"}}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+		b, err := repo.Create(ctx, domain.Task{Title: "b", Tags: []string{"x"}}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+		trashed, err := repo.Create(ctx, domain.Task{Title: "c", Tags: []string{"x"}}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+		require.NoError(t, repo.Delete(ctx, trashed.ID, entry(domain.HistoryDelete, nil)))
+
+		tasks, err := repo.Find(ctx, domain.TaskFilter{Tags: []string{"x", "y"}, TagMatch: domain.TagMatchAll})
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, a.ID, tasks[0].ID)
+
+		counts, err := repo.CountTags(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"x": 2, "y": 1}, counts)
+
+		renamed, err := repo.RenameTag(ctx, "x", "y", entry(domain.HistoryUpdate, nil))
+		require.NoError(t, err)
+		assert.Len(t, renamed, 3)
+		got, err := repo.GetByID(ctx, a.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"y"}, got.Tags)
+		got, err = repo.GetByID(ctx, b.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"y"}, got.Tags)
+
+		history, _, err := repo.GetHistory(ctx, a.ID, 0, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.FieldChange{{Field: "tags", Old: []string{"x", "y"}, New: []string{"y"}}}, history[0].Changes)
+		history, _, err = repo.GetHistory(ctx, b.ID, 0, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.FieldChange{{Field: "tags", Old: []string{"x"}, New: []string{"y"}}}, history[0].Changes)
+
+		removed, err := repo.RemoveTag(ctx, "y", entry(domain.HistoryUpdate, nil))
+		require.NoError(t, err)
+		assert.Len(t, removed, 3)
+		history, _, err = repo.GetHistory(ctx, b.ID, 0, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.FieldChange{{Field: "tags", Old: []string{"y"}, New: nil}}, history[0].Changes)
+		counts, err = repo.CountTags(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, counts)
+	})
+
+	t.Run("external IDs are unique, including in the trash", func(t *testing.T) {
+		repo := repository.NewTaskRepositoryMemory()
+		task, err := repo.Create(ctx, domain.Task{Title: "A", ExternalID: "row-1"}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+		_, err = repo.Create(ctx, domain.Task{Title: "B"}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err, "tasks without an external ID do not collide")
+		_, err = repo.Create(ctx, domain.Task{Title: "C"}, entry(domain.HistoryCreate, nil))
+		require.NoError(t, err)
+
+		require.NoError(t, repo.Delete(ctx, task.ID, entry(domain.HistoryDelete, nil)))
+		_, err = repo.Create(ctx, domain.Task{Title: "A again", ExternalID: "row-1"}, entry(domain.HistoryCreate, nil))
+		assert.ErrorIs(t, err, domain.ErrExternalIDTaken)
+
+		got, err := repo.GetByExternalID(ctx, "row-1")
+		require.NoError(t, err)
+		assert.Equal(t, task.ID, got.ID)
+		assert.False(t, got.DeletedAt.IsZero())
+		_, err = repo.GetByExternalID(ctx, "row-2")
+		assert.EqualError(t, err, "task not found")
+	})
+
+	t.Run("each visits the live tasks in order", func(t *testing.T) {
+		repo := repository.NewTaskRepositoryMemory()
+		for _, title := range []string{"A", "B", "C"} {
+			_, err := repo.Create(ctx, domain.Task{Title: title, Tags: []string{"x"}}, entry(domain.HistoryCreate, nil))
+			require.NoError(t, err)
+		}
+		require.NoError(t, repo.Delete(ctx, "2", entry(domain.HistoryDelete, nil)))
+
+		var titles []string
+		err := repo.Each(ctx, domain.TaskFilter{Tags: []string{"x"}}, func(task domain.Task) error {
+			titles = append(titles, task.Title)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"A", "C"}, titles)
+
+		stop := errors.New("stop")
+		visited := 0
+		err = repo.Each(ctx, domain.TaskFilter{}, func(domain.Task) error {
+			visited++
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, visited)
+	})
+}
+
+func TestTaskRepositoryMemory_Stats(t *testing.T) {
+	repo := repository.NewTaskRepositoryMemory()
+	ctx := domain.WithWorkspace(context.Background(), "team")
+	day := func(d, hour int) time.Time {
+		return time.Date(2026, time.October, d, hour, 0, 0, 0, time.UTC)
+	}
+	create := func(ctx context.Context, task domain.Task) domain.Task {
+		changes := []domain.FieldChange{{Field: "status", New: task.Status}}
+		created, err := repo.Create(ctx, task, domain.HistoryEntry{Action: domain.HistoryCreate, At: task.CreatedAt, Changes: changes})
+		require.NoError(t, err)
+		return created
+	}
+
+	create(ctx, domain.Task{Title: "Overdue", Status: "pending", DueDate: day(13, 9), CreatedAt: day(10, 9)})
+	create(ctx, domain.Task{Title: "This week", Status: "in_progress", DueDate: day(16, 9), CreatedAt: day(11, 8), ProjectID: "p1"})
+	late := create(ctx, domain.Task{Title: "Done later", Status: "pending", DueDate: day(13, 9), CreatedAt: day(9, 12)})
+	_, err := repo.Update(ctx, late.ID, domain.Task{Status: "completed"}, domain.HistoryEntry{
+		Action: domain.HistoryUpdate, At: day(12, 12),
+		Changes: []domain.FieldChange{{Field: "status", Old: "pending", New: "completed"}},
+	})
+	require.NoError(t, err)
+	create(ctx, domain.Task{Title: "Done at once", Status: "completed", CreatedAt: day(12, 0)})
+	trashed := create(ctx, domain.Task{Title: "Trashed", Status: "pending", CreatedAt: day(13, 10)})
+	require.NoError(t, repo.Delete(ctx, trashed.ID, domain.HistoryEntry{Action: domain.HistoryDelete, At: day(13, 11)}))
+	create(domain.WithWorkspace(context.Background(), "other"), domain.Task{Title: "Elsewhere", Status: "pending", DueDate: day(13, 9), CreatedAt: day(12, 9)})
+	create(ctx, domain.Task{Title: "No due date", Status: "pending", CreatedAt: day(14, 10)})
+
+	query := domain.TaskStatsQuery{From: day(10, 0), To: day(14, 0), Now: day(14, 12)}
+	stats, err := repo.Stats(ctx, query)
+	require.NoError(t, err)
+	assert.Equal(t, domain.TaskStats{
+		Total:       5,
+		ByStatus:    map[string]int{"pending": 2, "in_progress": 1, "completed": 2},
+		Overdue:     1,
+		DueThisWeek: 2,
+		Daily: []domain.DailyTaskCount{
+			{Day: day(10, 0), Created: 1},
+			{Day: day(11, 0), Created: 1},
+			{Day: day(12, 0), Created: 1, Completed: 2},
+			{Day: day(13, 0)},
+			{Day: day(14, 0), Created: 1},
+		},
+		AverageTimeToComplete: 36 * time.Hour,
+	}, stats)
+
+	query.Filter = domain.TaskFilter{ProjectID: "p1"}
+	stats, err = repo.Stats(ctx, query)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Total)
+	assert.Equal(t, 1, stats.DueThisWeek)
+	assert.Zero(t, stats.AverageTimeToComplete)
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+	"task9/domain"
+	"task9/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestTaskRepositoryMongo_HistoryLimit(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := domain.WithWorkspace(context.Background(), "team")
+
+	mt.Run("updates drop the history beyond the limit", func(mt *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: bson.D{{Key: "_id", Value: id}, {Key: "title", Value: "B"}}}),
+			mtest.CreateCursorResponse(0, "task_manager.tasks", mtest.FirstBatch),
+		)
+		repo := repository.NewTaskRepositoryMongo(mt.Coll, time.Second, 50)
+
+		_, err := repo.Update(ctx, id.Hex(), domain.Task{Title: "B"}, domain.HistoryEntry{
+			Action:  domain.HistoryUpdate,
+			At:      time.Now(),
+			Changes: []domain.FieldChange{{Field: "title", Old: "A", New: "B"}},
+		})
+		require.NoError(t, err)
+
+		started := mt.GetStartedEvent()
+		require.NotNil(t, started)
+		require.Equal(t, "findAndModify", started.CommandName)
+		push := started.Command.Lookup("update", "$push", "history").Document()
+		assert.Equal(t, int32(0), push.Lookup("$position").Int32())
+		assert.Equal(t, int32(50), push.Lookup("$slice").Int32())
+	})
+}
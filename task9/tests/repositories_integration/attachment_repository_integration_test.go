@@ -0,0 +1,99 @@
+package repositories_integration
+
+import (
+	"context"
+	"os"
+	"task9/config"
+	"task9/domain"
+	"task9/infrastructure"
+	"task9/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func setupTestAttachmentDB(t *testing.T) (*mongo.Collection, func()) {
+	mongoURI := os.Getenv("MONGODB_URI")
+	if mongoURI == "" {
+		mongoURI = "mongodb://localhost:27017"
+	}
+
+	err := infrastructure.ConnectDB(config.DatabaseConfig{
+		URI:            mongoURI,
+		Name:           "task_manager_test",
+		ConnectTimeout: 10 * time.Second,
+	})
+	require.NoError(t, err)
+	_, err = infrastructure.Migrate(context.Background(), false)
+	require.NoError(t, err)
+
+	collection := infrastructure.AttachmentCollection
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		collection.DeleteMany(ctx, bson.M{})
+		infrastructure.DisconnectDB()
+	}
+
+	return collection, cleanup
+}
+
+func TestAttachmentRepository_Integration(t *testing.T) {
+	collection, cleanup := setupTestAttachmentDB(t)
+	defer cleanup()
+
+	attachmentRepo := repository.NewAttachmentRepositoryMongo(collection, 10*time.Second)
+	ctx := domain.WithWorkspace(context.Background(), "team")
+	taskID := primitive.NewObjectID().Hex()
+	otherTaskID := primitive.NewObjectID().Hex()
+
+	t.Run("Create, get and list", func(t *testing.T) {
+		now := time.Now().UTC().Truncate(time.Millisecond)
+		first, err := attachmentRepo.Create(ctx, domain.Attachment{TaskID: taskID, Filename: "a.pdf", ContentType: "application/pdf", Size: 42, BlobKey: "tasks/a", UploaderID: "1", Uploader: "alice", CreatedAt: now})
+		require.NoError(t, err)
+		_, err = attachmentRepo.Create(ctx, domain.Attachment{TaskID: taskID, Filename: "b.png", BlobKey: "tasks/b", CreatedAt: now.Add(time.Second)})
+		require.NoError(t, err)
+		_, err = attachmentRepo.Create(ctx, domain.Attachment{TaskID: otherTaskID, Filename: "c.txt", BlobKey: "tasks/c", CreatedAt: now})
+		require.NoError(t, err)
+
+		got, err := attachmentRepo.GetByID(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, first, got)
+
+		attachments, err := attachmentRepo.ListByTask(ctx, taskID)
+		require.NoError(t, err)
+		require.Len(t, attachments, 2)
+		assert.Equal(t, "a.pdf", attachments[0].Filename)
+		assert.Equal(t, "b.png", attachments[1].Filename)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		attachment, err := attachmentRepo.Create(ctx, domain.Attachment{TaskID: taskID, Filename: "d.txt", BlobKey: "tasks/d", CreatedAt: time.Now()})
+		require.NoError(t, err)
+
+		require.NoError(t, attachmentRepo.Delete(ctx, attachment.ID))
+		_, err = attachmentRepo.GetByID(ctx, attachment.ID)
+		assert.EqualError(t, err, "attachment not found")
+		assert.EqualError(t, attachmentRepo.Delete(ctx, attachment.ID), "attachment not found")
+	})
+
+	t.Run("Delete by tasks returns the blob keys", func(t *testing.T) {
+		deleted, err := attachmentRepo.DeleteByTasks(ctx, []string{taskID, otherTaskID})
+		require.NoError(t, err)
+		keys := []string{}
+		for _, attachment := range deleted {
+			keys = append(keys, attachment.BlobKey)
+		}
+		assert.ElementsMatch(t, []string{"tasks/a", "tasks/b", "tasks/c"}, keys)
+
+		attachments, err := attachmentRepo.ListByTask(ctx, taskID)
+		require.NoError(t, err)
+		assert.Empty(t, attachments)
+	})
+}
@@ -0,0 +1,94 @@
+package repositories_integration
+
+import (
+	"context"
+	"os"
+	"task9/config"
+	"task9/domain"
+	"task9/infrastructure"
+	"task9/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func setupTestCommentDB(t *testing.T) (*mongo.Collection, func()) {
+	mongoURI := os.Getenv("MONGODB_URI")
+	if mongoURI == "" {
+		mongoURI = "mongodb://localhost:27017"
+	}
+
+	err := infrastructure.ConnectDB(config.DatabaseConfig{
+		URI:            mongoURI,
+		Name:           "task_manager_test",
+		ConnectTimeout: 10 * time.Second,
+	})
+	require.NoError(t, err)
+	_, err = infrastructure.Migrate(context.Background(), false)
+	require.NoError(t, err)
+
+	collection := infrastructure.CommentCollection
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		collection.DeleteMany(ctx, bson.M{})
+		infrastructure.DisconnectDB()
+	}
+
+	return collection, cleanup
+}
+
+func TestCommentRepository_Integration(t *testing.T) {
+	collection, cleanup := setupTestCommentDB(t)
+	defer cleanup()
+
+	commentRepo := repository.NewCommentRepositoryMongo(collection, 10*time.Second)
+	ctx := domain.WithWorkspace(context.Background(), "team")
+	taskID := primitive.NewObjectID().Hex()
+	otherTaskID := primitive.NewObjectID().Hex()
+
+	t.Run("Create, list and count", func(t *testing.T) {
+		now := time.Now()
+		first, err := commentRepo.Create(ctx, domain.Comment{TaskID: taskID, AuthorID: "1", Author: "alice", Body: "first", CreatedAt: now, UpdatedAt: now})
+		require.NoError(t, err)
+		_, err = commentRepo.Create(ctx, domain.Comment{TaskID: taskID, AuthorID: "2", Author: "bob", Body: "second", CreatedAt: now.Add(time.Second), UpdatedAt: now})
+		require.NoError(t, err)
+		_, err = commentRepo.Create(ctx, domain.Comment{TaskID: otherTaskID, Body: "other", CreatedAt: now, UpdatedAt: now})
+		require.NoError(t, err)
+
+		comments, err := commentRepo.ListByTask(ctx, taskID)
+		require.NoError(t, err)
+		require.Len(t, comments, 2)
+		assert.Equal(t, first.ID, comments[0].ID)
+		assert.Equal(t, "alice", comments[0].Author)
+
+		counts, err := commentRepo.CountByTasks(ctx, []string{taskID, otherTaskID})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{taskID: 2, otherTaskID: 1}, counts)
+	})
+
+	t.Run("Update and delete", func(t *testing.T) {
+		comment, err := commentRepo.Create(ctx, domain.Comment{TaskID: taskID, Body: "draft", CreatedAt: time.Now(), UpdatedAt: time.Now()})
+		require.NoError(t, err)
+
+		updated, err := commentRepo.UpdateBody(ctx, comment.ID, "final")
+		require.NoError(t, err)
+		assert.Equal(t, "final", updated.Body)
+
+		require.NoError(t, commentRepo.Delete(ctx, comment.ID))
+		_, err = commentRepo.GetByID(ctx, comment.ID)
+		assert.EqualError(t, err, "comment not found")
+	})
+
+	t.Run("Delete by tasks", func(t *testing.T) {
+		deleted, err := commentRepo.DeleteByTasks(ctx, []string{taskID, otherTaskID})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), deleted)
+	})
+}
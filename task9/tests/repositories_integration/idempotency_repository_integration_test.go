@@ -0,0 +1,134 @@
+package repositories_integration
+
+import (
+	"context"
+	"os"
+	"task9/config"
+	"task9/domain"
+	"task9/infrastructure"
+	"task9/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func setupTestIdempotencyDB(t *testing.T) (*mongo.Collection, func()) {
+	mongoURI := os.Getenv("MONGODB_URI")
+	if mongoURI == "" {
+		mongoURI = "mongodb://localhost:27017"
+	}
+
+	err := infrastructure.ConnectDB(config.DatabaseConfig{
+		URI:            mongoURI,
+		Name:           "task_manager_test",
+		ConnectTimeout: 10 * time.Second,
+	})
+	require.NoError(t, err)
+	_, err = infrastructure.Migrate(context.Background(), false)
+	require.NoError(t, err)
+
+	collection := infrastructure.IdempotencyCollection
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		collection.DeleteMany(ctx, bson.M{})
+		infrastructure.DisconnectDB()
+	}
+
+	return collection, cleanup
+}
+
+func TestIdempotencyRepository_Integration(t *testing.T) {
+	collection, cleanup := setupTestIdempotencyDB(t)
+	defer cleanup()
+
+	repo := repository.NewIdempotencyRepositoryMongo(collection, 10*time.Second)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	t.Run("Reserve, complete and replay", func(t *testing.T) {
+		record, reserved, err := repo.Reserve(ctx, domain.IdempotencyRecord{Key: "1:create", Fingerprint: "f1", ExpiresAt: now.Add(time.Minute)}, now)
+		require.NoError(t, err)
+		assert.True(t, reserved)
+		assert.NotEmpty(t, record.Token)
+
+		running, reserved, err := repo.Reserve(ctx, record, now)
+		require.NoError(t, err)
+		assert.False(t, reserved)
+		assert.Equal(t, 0, running.Status)
+
+		record.Status = 201
+		record.ContentType = "application/json; charset=utf-8"
+		record.Body = []byte(`{"status":"success"}`)
+		record.ExpiresAt = now.Add(time.Hour)
+		require.NoError(t, repo.Complete(ctx, record))
+
+		stored, reserved, err := repo.Reserve(ctx, domain.IdempotencyRecord{Key: "1:create", Fingerprint: "f2", ExpiresAt: now.Add(time.Minute)}, now)
+		require.NoError(t, err)
+		assert.False(t, reserved)
+		assert.Equal(t, "f1", stored.Fingerprint)
+		assert.Equal(t, 201, stored.Status)
+		assert.Equal(t, record.ContentType, stored.ContentType)
+		assert.Equal(t, record.Body, stored.Body)
+		assert.WithinDuration(t, record.ExpiresAt, stored.ExpiresAt, 0)
+	})
+
+	t.Run("Expired records are replaced", func(t *testing.T) {
+		record := domain.IdempotencyRecord{Key: "1:old", Fingerprint: "f1", ExpiresAt: now.Add(time.Minute)}
+		_, reserved, err := repo.Reserve(ctx, record, now)
+		require.NoError(t, err)
+		require.True(t, reserved)
+
+		_, reserved, err = repo.Reserve(ctx, domain.IdempotencyRecord{Key: "1:old", Fingerprint: "f2", ExpiresAt: now.Add(3 * time.Minute)}, now.Add(2*time.Minute))
+		require.NoError(t, err)
+		assert.True(t, reserved)
+	})
+
+	t.Run("Release drops only running requests", func(t *testing.T) {
+		request := domain.IdempotencyRecord{Key: "1:release", Fingerprint: "f1", ExpiresAt: now.Add(time.Minute)}
+		record, _, err := repo.Reserve(ctx, request, now)
+		require.NoError(t, err)
+		require.NoError(t, repo.Release(ctx, record))
+
+		record, reserved, err := repo.Reserve(ctx, request, now)
+		require.NoError(t, err)
+		assert.True(t, reserved)
+
+		record.Status = 200
+		require.NoError(t, repo.Complete(ctx, record))
+		require.NoError(t, repo.Release(ctx, record))
+		_, reserved, err = repo.Reserve(ctx, request, now)
+		require.NoError(t, err)
+		assert.False(t, reserved)
+		assert.EqualError(t, repo.Complete(ctx, record), "idempotency reservation lost")
+	})
+
+	t.Run("A stale reservation cannot touch the one that replaced it", func(t *testing.T) {
+		request := domain.IdempotencyRecord{Key: "1:slow", Fingerprint: "f1", ExpiresAt: now.Add(time.Minute)}
+		stale, reserved, err := repo.Reserve(ctx, request, now)
+		require.NoError(t, err)
+		require.True(t, reserved)
+
+		// The first request outlives its lock and a retry reserves the key.
+		request.ExpiresAt = now.Add(3 * time.Minute)
+		current, reserved, err := repo.Reserve(ctx, request, now.Add(2*time.Minute))
+		require.NoError(t, err)
+		require.True(t, reserved)
+		assert.NotEqual(t, stale.Token, current.Token)
+
+		require.NoError(t, repo.Release(ctx, stale))
+		stale.Status = 500
+		assert.EqualError(t, repo.Complete(ctx, stale), "idempotency reservation lost")
+
+		running, reserved, err := repo.Reserve(ctx, request, now.Add(2*time.Minute))
+		require.NoError(t, err)
+		assert.False(t, reserved, "the retry still holds the key")
+		assert.Equal(t, current.Token, running.Token)
+		assert.Equal(t, 0, running.Status)
+	})
+}
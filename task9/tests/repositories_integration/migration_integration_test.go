@@ -0,0 +1,197 @@
+package repositories_integration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"task9/config"
+	"task9/domain"
+	"task9/infrastructure"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func setupTestMigrationDB(t *testing.T) (*mongo.Database, func()) {
+	mongoURI := os.Getenv("MONGODB_URI")
+	if mongoURI == "" {
+		mongoURI = "mongodb://localhost:27017"
+	}
+
+	err := infrastructure.ConnectDB(config.DatabaseConfig{
+		URI:            mongoURI,
+		Name:           "task_manager_test",
+		ConnectTimeout: 10 * time.Second,
+	})
+	require.NoError(t, err)
+
+	db := infrastructure.Database.Client().Database("task_manager_migrations_test")
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		db.Drop(ctx)
+		infrastructure.DisconnectDB()
+	}
+
+	return db, cleanup
+}
+
+func TestMigrator_Integration(t *testing.T) {
+	db, cleanup := setupTestMigrationDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	var ran []int
+	migration := func(version int) infrastructure.Migration {
+		return infrastructure.Migration{Version: version, Description: "step", Up: func(ctx context.Context, db *mongo.Database) error {
+			ran = append(ran, version)
+			return nil
+		}}
+	}
+	migrator, err := infrastructure.NewMigrator(db, []infrastructure.Migration{migration(1), migration(2)})
+	require.NoError(t, err)
+
+	t.Run("dry run applies nothing", func(t *testing.T) {
+		pending, err := migrator.Up(ctx, true)
+		require.NoError(t, err)
+		assert.Len(t, pending, 2)
+		assert.Empty(t, ran)
+
+		statuses, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		for _, status := range statuses {
+			assert.False(t, status.Applied())
+		}
+	})
+
+	t.Run("up applies and records each migration once", func(t *testing.T) {
+		applied, err := migrator.Up(ctx, false)
+		require.NoError(t, err)
+		assert.Len(t, applied, 2)
+		assert.Equal(t, []int{1, 2}, ran)
+
+		applied, err = migrator.Up(ctx, false)
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+		assert.Equal(t, []int{1, 2}, ran)
+
+		statuses, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+		assert.True(t, statuses[1].Applied())
+		assert.WithinDuration(t, time.Now(), statuses[1].AppliedAt, 5*time.Second)
+	})
+
+	t.Run("a failing migration stops the run", func(t *testing.T) {
+		failing := infrastructure.Migration{Version: 3, Description: "broken", Up: func(context.Context, *mongo.Database) error {
+			return errors.New("boom")
+		}}
+		migrator, err := infrastructure.NewMigrator(db, []infrastructure.Migration{migration(1), migration(2), failing, migration(4)})
+		require.NoError(t, err)
+
+		applied, err := migrator.Up(ctx, false)
+		assert.EqualError(t, err, "migration 3 (broken): boom")
+		assert.Empty(t, applied)
+
+		statuses, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		assert.False(t, statuses[2].Applied())
+		assert.False(t, statuses[3].Applied())
+	})
+
+	t.Run("the application migrations create the indexes", func(t *testing.T) {
+		migrator, err := infrastructure.NewMigrator(db, infrastructure.Migrations)
+		require.NoError(t, err)
+		_, err = db.Collection("schema_migrations").DeleteMany(ctx, bson.M{})
+		require.NoError(t, err)
+		_, err = db.Collection("tasks").InsertOne(ctx, bson.M{"title": "Old"})
+		require.NoError(t, err)
+
+		applied, err := migrator.Up(ctx, false)
+		require.NoError(t, err)
+		assert.Len(t, applied, len(infrastructure.Migrations))
+
+		_, err = db.Collection("users").InsertMany(ctx, []interface{}{bson.M{"username": "alice"}, bson.M{"username": "alice"}})
+		assert.True(t, mongo.IsDuplicateKeyError(err), "usernames are unique")
+
+		var task bson.M
+		require.NoError(t, db.Collection("tasks").FindOne(ctx, bson.M{"$text": bson.M{"$search": "old"}}).Decode(&task))
+		assert.Equal(t, "pending", task["status"])
+	})
+
+	t.Run("data written before workspaces moves to a default workspace", func(t *testing.T) {
+		for _, name := range []string{"users", "workspaces", "workspace_members", "tasks", "comments", "tags", "attachments", "projects"} {
+			_, err := db.Collection(name).DeleteMany(ctx, bson.M{})
+			require.NoError(t, err)
+		}
+		users, err := db.Collection("users").InsertMany(ctx, []interface{}{
+			bson.M{"username": "root", "role": "admin"},
+			bson.M{"username": "alice", "role": "user"},
+		})
+		require.NoError(t, err)
+		for _, name := range []string{"tasks", "comments", "tags", "attachments", "projects"} {
+			_, err := db.Collection(name).InsertMany(ctx, []interface{}{bson.M{"name": "old"}, bson.M{"name": "new", "workspace_id": "team"}})
+			require.NoError(t, err)
+		}
+		backfill := infrastructure.Migrations[5]
+		require.Equal(t, 6, backfill.Version)
+
+		require.NoError(t, backfill.Up(ctx, db))
+		require.NoError(t, backfill.Up(ctx, db), "running it again changes nothing")
+
+		var workspaces []bson.M
+		cursor, err := db.Collection("workspaces").Find(ctx, bson.M{})
+		require.NoError(t, err)
+		require.NoError(t, cursor.All(ctx, &workspaces))
+		require.Len(t, workspaces, 1)
+		assert.Equal(t, "Default", workspaces[0]["name"])
+		workspaceID := workspaces[0]["_id"].(primitive.ObjectID).Hex()
+
+		roles := map[string]string{}
+		var members []bson.M
+		cursor, err = db.Collection("workspace_members").Find(ctx, bson.M{"workspace_id": workspaceID})
+		require.NoError(t, err)
+		require.NoError(t, cursor.All(ctx, &members))
+		for _, member := range members {
+			roles[member["user_id"].(string)] = member["role"].(string)
+		}
+		assert.Equal(t, map[string]string{
+			users.InsertedIDs[0].(primitive.ObjectID).Hex(): domain.WorkspaceAdmin,
+			users.InsertedIDs[1].(primitive.ObjectID).Hex(): domain.WorkspaceMember,
+		}, roles)
+
+		for _, name := range []string{"tasks", "comments", "tags", "attachments", "projects"} {
+			var old, current bson.M
+			require.NoError(t, db.Collection(name).FindOne(ctx, bson.M{"name": "old"}).Decode(&old))
+			assert.Equal(t, workspaceID, old["workspace_id"], name)
+			require.NoError(t, db.Collection(name).FindOne(ctx, bson.M{"name": "new"}).Decode(&current))
+			assert.Equal(t, "team", current["workspace_id"], "%s already in a workspace stay there", name)
+		}
+	})
+
+	t.Run("a database already in workspaces is left alone", func(t *testing.T) {
+		for _, name := range []string{"users", "workspaces", "workspace_members", "tasks", "comments", "tags", "attachments", "projects"} {
+			_, err := db.Collection(name).DeleteMany(ctx, bson.M{})
+			require.NoError(t, err)
+		}
+		_, err := db.Collection("users").InsertOne(ctx, bson.M{"username": "root", "role": "admin"})
+		require.NoError(t, err)
+		for _, name := range []string{"tasks", "comments", "tags", "attachments", "projects"} {
+			_, err := db.Collection(name).InsertOne(ctx, bson.M{"name": "new", "workspace_id": "team"})
+			require.NoError(t, err)
+		}
+
+		require.NoError(t, infrastructure.Migrations[5].Up(ctx, db))
+
+		for _, name := range []string{"workspaces", "workspace_members"} {
+			count, err := db.Collection(name).CountDocuments(ctx, bson.M{})
+			require.NoError(t, err)
+			assert.Zero(t, count, "no default workspace or memberships are created in %s", name)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package repositories_integration
+
+import (
+	"context"
+	"os"
+	"task9/config"
+	"task9/domain"
+	"task9/infrastructure"
+	"task9/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func setupTestProjectDB(t *testing.T) func() {
+	mongoURI := os.Getenv("MONGODB_URI")
+	if mongoURI == "" {
+		mongoURI = "mongodb://localhost:27017"
+	}
+
+	err := infrastructure.ConnectDB(config.DatabaseConfig{
+		URI:            mongoURI,
+		Name:           "task_manager_test",
+		ConnectTimeout: 10 * time.Second,
+	})
+	require.NoError(t, err)
+	_, err = infrastructure.Migrate(context.Background(), false)
+	require.NoError(t, err)
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		infrastructure.ProjectCollection.DeleteMany(ctx, bson.M{})
+		infrastructure.TaskCollection.DeleteMany(ctx, bson.M{})
+		infrastructure.DisconnectDB()
+	}
+
+	return cleanup
+}
+
+func TestProjectRepository_Integration(t *testing.T) {
+	cleanup := setupTestProjectDB(t)
+	defer cleanup()
+
+	projectRepo := repository.NewProjectRepositoryMongo(infrastructure.ProjectCollection, 10*time.Second)
+	taskRepo := repository.NewTaskRepositoryMongo(infrastructure.TaskCollection, 10*time.Second, 0)
+	teamCtx := domain.WithWorkspace(context.Background(), "team")
+	otherCtx := domain.WithWorkspace(context.Background(), "other")
+	now := time.Now()
+
+	beta, err := projectRepo.Create(teamCtx, domain.Project{Name: "Beta", OwnerID: "1", Owner: "alice", DefaultStatus: "pending", CreatedAt: now, UpdatedAt: now})
+	require.NoError(t, err)
+	assert.Equal(t, "team", beta.WorkspaceID)
+	_, err = projectRepo.Create(teamCtx, domain.Project{Name: "Alpha", DefaultStatus: "pending", CreatedAt: now, UpdatedAt: now})
+	require.NoError(t, err)
+
+	t.Run("List and GetByID", func(t *testing.T) {
+		projects, err := projectRepo.List(teamCtx)
+		require.NoError(t, err)
+		require.Len(t, projects, 2)
+		assert.Equal(t, "Alpha", projects[0].Name)
+		assert.Equal(t, "Beta", projects[1].Name)
+
+		found, err := projectRepo.GetByID(teamCtx, beta.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", found.Owner)
+		assert.WithinDuration(t, now, found.CreatedAt, time.Second)
+
+		_, err = projectRepo.GetByID(otherCtx, beta.ID)
+		assert.EqualError(t, err, "project not found")
+		_, err = projectRepo.GetByID(teamCtx, "not-an-id")
+		assert.EqualError(t, err, "invalid project ID format")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		beta.Archived = true
+		beta.DefaultStatus = "in_progress"
+		updated, err := projectRepo.Update(teamCtx, beta.ID, beta)
+		require.NoError(t, err)
+		assert.True(t, updated.Archived)
+		assert.Equal(t, "in_progress", updated.DefaultStatus)
+		assert.Equal(t, "alice", updated.Owner, "the owner is kept")
+
+		_, err = projectRepo.Update(otherCtx, beta.ID, beta)
+		assert.EqualError(t, err, "project not found")
+	})
+
+	t.Run("Delete detaches tasks", func(t *testing.T) {
+		task, err := taskRepo.Create(teamCtx, domain.Task{Title: "In project", ProjectID: beta.ID, CreatedAt: now}, domain.HistoryEntry{Action: domain.HistoryCreate, At: now})
+		require.NoError(t, err)
+		found, err := taskRepo.Find(teamCtx, domain.TaskFilter{ProjectID: beta.ID})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, task.ID, found[0].ID)
+
+		require.NoError(t, projectRepo.Delete(teamCtx, beta.ID))
+		assert.EqualError(t, projectRepo.Delete(teamCtx, beta.ID), "project not found")
+
+		changed, err := taskRepo.RemoveProject(teamCtx, beta.ID, domain.HistoryEntry{Action: domain.HistoryUpdate, Actor: "alice", At: now})
+		require.NoError(t, err)
+		require.Len(t, changed, 1)
+		assert.Empty(t, changed[0].Task.ProjectID)
+		reloaded, err := taskRepo.GetByID(teamCtx, task.ID)
+		require.NoError(t, err)
+		assert.Empty(t, reloaded.ProjectID)
+		entries, _, err := taskRepo.GetHistory(teamCtx, task.ID, 0, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.FieldChange{{Field: "project_id", Old: beta.ID, New: nil}}, entries[0].Changes)
+	})
+}
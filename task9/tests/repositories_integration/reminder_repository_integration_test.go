@@ -0,0 +1,101 @@
+package repositories_integration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"task9/config"
+	"task9/domain"
+	"task9/infrastructure"
+	"task9/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func setupTestReminderDB(t *testing.T) (*mongo.Collection, func()) {
+	mongoURI := os.Getenv("MONGODB_URI")
+	if mongoURI == "" {
+		mongoURI = "mongodb://localhost:27017"
+	}
+
+	err := infrastructure.ConnectDB(config.DatabaseConfig{
+		URI:            mongoURI,
+		Name:           "task_manager_test",
+		ConnectTimeout: 10 * time.Second,
+	})
+	require.NoError(t, err)
+	_, err = infrastructure.Migrate(context.Background(), false)
+	require.NoError(t, err)
+
+	collection := infrastructure.ReminderCollection
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		collection.DeleteMany(ctx, bson.M{})
+		infrastructure.DisconnectDB()
+	}
+
+	return collection, cleanup
+}
+
+func TestReminderRepository_Integration(t *testing.T) {
+	collection, cleanup := setupTestReminderDB(t)
+	defer cleanup()
+
+	reminderRepo := repository.NewReminderRepositoryMongo(collection, 10*time.Second)
+	ctx := context.Background()
+	now := time.Now()
+	reminder := domain.Reminder{Key: "1:due_soon:1h0m0s:1", TaskID: "1", Kind: domain.ReminderDueSoon, Window: time.Hour, DueDate: now.Add(time.Hour)}
+
+	t.Run("Claim is exclusive while the lease holds", func(t *testing.T) {
+		claimed, err := reminderRepo.Claim(ctx, reminder, "a", now, time.Minute, 0)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+
+		claimed, err = reminderRepo.Claim(ctx, reminder, "b", now.Add(30*time.Second), time.Minute, 0)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+
+		claimed, err = reminderRepo.Claim(ctx, reminder, "b", now.Add(2*time.Minute), time.Minute, 0)
+		require.NoError(t, err)
+		assert.True(t, claimed, "an expired lease can be taken over")
+
+		assert.EqualError(t, reminderRepo.MarkSent(ctx, reminder.Key, "a", now), "reminder lease lost")
+	})
+
+	t.Run("Release and MarkSent", func(t *testing.T) {
+		require.NoError(t, reminderRepo.Release(ctx, reminder.Key, "b", errors.New("connection refused")))
+
+		claimed, err := reminderRepo.Claim(ctx, reminder, "a", now.Add(2*time.Minute), time.Minute, 0)
+		require.NoError(t, err)
+		assert.True(t, claimed, "a released reminder is free again")
+		require.NoError(t, reminderRepo.MarkSent(ctx, reminder.Key, "a", now))
+
+		claimed, err = reminderRepo.Claim(ctx, reminder, "a", now.Add(time.Hour), time.Minute, 0)
+		require.NoError(t, err)
+		assert.False(t, claimed, "a sent reminder is never claimed again")
+	})
+
+	t.Run("Claim stops after maxAttempts failures", func(t *testing.T) {
+		failing := domain.Reminder{Key: "2:overdue:1", TaskID: "2", Kind: domain.ReminderOverdue, DueDate: now}
+		for i := 0; i < 2; i++ {
+			claimed, err := reminderRepo.Claim(ctx, failing, "a", now, time.Minute, 2)
+			require.NoError(t, err)
+			require.True(t, claimed)
+			require.NoError(t, reminderRepo.Release(ctx, failing.Key, "a", errors.New("connection refused")))
+		}
+
+		claimed, err := reminderRepo.Claim(ctx, failing, "a", now, time.Minute, 2)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+		claimed, err = reminderRepo.Claim(ctx, failing, "a", now, time.Minute, 0)
+		require.NoError(t, err)
+		assert.True(t, claimed, "without a limit it is tried again")
+	})
+}
@@ -2,7 +2,9 @@ package repositories_integration
 
 import (
 	"context"
+	"errors"
 	"os"
+	"task9/config"
 	"task9/domain"
 	"task9/infrastructure"
 	"task9/repository"
@@ -15,13 +17,19 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func setupTestDB(t *testing.T) (*mongo.Collection, func()) {
+func setupTestDB(t testing.TB) (*mongo.Collection, func()) {
 	mongoURI := os.Getenv("MONGODB_URI")
 	if mongoURI == "" {
 		mongoURI = "mongodb://localhost:27017"
 	}
 
-	err := infrastructure.ConnectDB(mongoURI, "task_manager_test")
+	err := infrastructure.ConnectDB(config.DatabaseConfig{
+		URI:            mongoURI,
+		Name:           "task_manager_test",
+		ConnectTimeout: 10 * time.Second,
+	})
+	require.NoError(t, err)
+	_, err = infrastructure.Migrate(context.Background(), false)
 	require.NoError(t, err)
 
 	collection := infrastructure.TaskCollection
@@ -40,7 +48,8 @@ func TestTaskRepository_Integration(t *testing.T) {
 	collection, cleanup := setupTestDB(t)
 	defer cleanup()
 
-	taskRepo := repository.NewTaskRepositoryMongo(collection)
+	taskRepo := repository.NewTaskRepositoryMongo(collection, 10*time.Second, 0)
+	ctx := domain.WithWorkspace(context.Background(), "team")
 
 	t.Run("Create and Get task", func(t *testing.T) {
 		task := domain.Task{
@@ -52,12 +61,12 @@ func TestTaskRepository_Integration(t *testing.T) {
 			UpdatedAt:   time.Now(),
 		}
 
-		createdTask, err := taskRepo.Create(task)
+		createdTask, err := taskRepo.Create(ctx, task, domain.HistoryEntry{Action: domain.HistoryCreate})
 		require.NoError(t, err)
 		assert.NotEmpty(t, createdTask.ID)
 		assert.Equal(t, "Integration Test Task", createdTask.Title)
 
-		retrievedTask, err := taskRepo.GetByID(createdTask.ID)
+		retrievedTask, err := taskRepo.GetByID(ctx, createdTask.ID)
 		require.NoError(t, err)
 		assert.Equal(t, createdTask.ID, retrievedTask.ID)
 		assert.Equal(t, "Integration Test Task", retrievedTask.Title)
@@ -82,13 +91,13 @@ func TestTaskRepository_Integration(t *testing.T) {
 			UpdatedAt:   time.Now(),
 		}
 
-		_, err := taskRepo.Create(task1)
+		_, err := taskRepo.Create(ctx, task1, domain.HistoryEntry{Action: domain.HistoryCreate})
 		require.NoError(t, err)
 
-		_, err = taskRepo.Create(task2)
+		_, err = taskRepo.Create(ctx, task2, domain.HistoryEntry{Action: domain.HistoryCreate})
 		require.NoError(t, err)
 
-		tasks, err := taskRepo.GetAll()
+		tasks, err := taskRepo.GetAll(ctx)
 		require.NoError(t, err)
 		assert.GreaterOrEqual(t, len(tasks), 2)
 	})
@@ -103,19 +112,21 @@ func TestTaskRepository_Integration(t *testing.T) {
 			UpdatedAt:   time.Now(),
 		}
 
-		createdTask, err := taskRepo.Create(task)
+		createdTask, err := taskRepo.Create(ctx, task, domain.HistoryEntry{Action: domain.HistoryCreate})
 		require.NoError(t, err)
 
 		updatedTask := createdTask
 		updatedTask.Title = "Updated Title"
 		updatedTask.Status = "completed"
 
-		result, err := taskRepo.Update(createdTask.ID, updatedTask)
+		result, err := taskRepo.Update(ctx, createdTask.ID, updatedTask, domain.HistoryEntry{Action: domain.HistoryUpdate})
 		require.NoError(t, err)
 		assert.Equal(t, "Updated Title", result.Title)
 		assert.Equal(t, "completed", result.Status)
 	})
 
+	deletedBy := domain.NewHistoryEntry(domain.Actor{Username: "admin"}, domain.HistoryDelete, nil)
+
 	t.Run("Delete task", func(t *testing.T) {
 		task := domain.Task{
 			Title:       "Task to Delete",
@@ -126,19 +137,338 @@ func TestTaskRepository_Integration(t *testing.T) {
 			UpdatedAt:   time.Now(),
 		}
 
-		createdTask, err := taskRepo.Create(task)
+		createdTask, err := taskRepo.Create(ctx, task, domain.HistoryEntry{Action: domain.HistoryCreate})
 		require.NoError(t, err)
 
-		err = taskRepo.Delete(createdTask.ID)
+		err = taskRepo.Delete(ctx, createdTask.ID, deletedBy)
 		require.NoError(t, err)
 
-		_, err = taskRepo.GetByID(createdTask.ID)
+		_, err = taskRepo.GetByID(ctx, createdTask.ID)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "not found")
 	})
 
+	t.Run("Trash, restore and purge", func(t *testing.T) {
+		task := domain.Task{
+			Title:     "Task to Trash",
+			DueDate:   time.Now().Add(24 * time.Hour),
+			Status:    "pending",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+
+		createdTask, err := taskRepo.Create(ctx, task, domain.HistoryEntry{Action: domain.HistoryCreate})
+		require.NoError(t, err)
+		require.NoError(t, taskRepo.Delete(ctx, createdTask.ID, deletedBy))
+
+		trash, err := taskRepo.GetDeleted(ctx)
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		assert.Equal(t, "admin", trash[0].DeletedBy)
+		assert.False(t, trash[0].DeletedAt.IsZero())
+		trashed, err := taskRepo.GetDeletedByID(ctx, createdTask.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "admin", trashed.DeletedBy)
+
+		err = taskRepo.Delete(ctx, createdTask.ID, deletedBy)
+		assert.EqualError(t, err, "task not found")
+
+		restored, err := taskRepo.Restore(ctx, createdTask.ID, domain.HistoryEntry{Action: domain.HistoryRestore, At: time.Now()})
+		require.NoError(t, err)
+		assert.True(t, restored.DeletedAt.IsZero())
+
+		_, err = taskRepo.GetByID(ctx, createdTask.ID)
+		require.NoError(t, err)
+
+		err = taskRepo.Purge(ctx, createdTask.ID)
+		assert.EqualError(t, err, "task not found in trash")
+
+		require.NoError(t, taskRepo.Delete(ctx, createdTask.ID, deletedBy))
+		purged, err := taskRepo.PurgeDeleted(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), purged)
+
+		purged, err = taskRepo.PurgeDeleted(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+	})
+
+	t.Run("History is written with each change", func(t *testing.T) {
+		task := domain.Task{
+			Title:     "Tracked Task",
+			DueDate:   time.Now().Add(24 * time.Hour),
+			Status:    "pending",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		actor := domain.Actor{UserID: "1", Username: "admin"}
+
+		createdTask, err := taskRepo.Create(ctx, task, domain.NewHistoryEntry(actor, domain.HistoryCreate, domain.TaskChanges(domain.Task{}, task)))
+		require.NoError(t, err)
+
+		updated := createdTask
+		updated.Status = "completed"
+		changes := domain.TaskChanges(createdTask, updated)
+		_, err = taskRepo.Update(ctx, createdTask.ID, updated, domain.NewHistoryEntry(actor, domain.HistoryUpdate, changes))
+		require.NoError(t, err)
+
+		_, err = taskRepo.Update(ctx, createdTask.ID, updated, domain.NewHistoryEntry(actor, domain.HistoryUpdate, changes))
+		assert.ErrorIs(t, err, domain.ErrConflict)
+
+		entries, total, err := taskRepo.GetHistory(ctx, createdTask.ID, 0, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		require.Len(t, entries, 1)
+		assert.Equal(t, domain.HistoryUpdate, entries[0].Action)
+		assert.Equal(t, "admin", entries[0].Actor)
+		assert.Equal(t, []domain.FieldChange{{Field: "status", Old: "pending", New: "completed"}}, entries[0].Changes)
+
+		entries, _, err = taskRepo.GetHistory(ctx, createdTask.ID, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, domain.HistoryCreate, entries[0].Action)
+	})
+
+	t.Run("History limit", func(t *testing.T) {
+		limited := repository.NewTaskRepositoryMongo(collection, 10*time.Second, 2)
+		task, err := limited.Create(ctx, domain.Task{Title: "Busy", Status: "pending"}, domain.HistoryEntry{Action: domain.HistoryCreate})
+		require.NoError(t, err)
+		for _, status := range []string{"in_progress", "completed"} {
+			changes := []domain.FieldChange{{Field: "status", Old: task.Status, New: status}}
+			task.Status = status
+			_, err = limited.Update(ctx, task.ID, task, domain.HistoryEntry{Action: domain.HistoryUpdate, Changes: changes})
+			require.NoError(t, err)
+		}
+
+		entries, total, err := limited.GetHistory(ctx, task.ID, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		require.Len(t, entries, 2)
+		assert.Equal(t, "completed", entries[0].Changes[0].New)
+		assert.Equal(t, "in_progress", entries[1].Changes[0].New)
+	})
+
+	t.Run("Subtasks and checklist", func(t *testing.T) {
+		now := time.Now()
+		parent, err := taskRepo.Create(ctx, domain.Task{Title: "Parent", Status: "pending", CreatedAt: now, UpdatedAt: now}, domain.HistoryEntry{Action: domain.HistoryCreate})
+		require.NoError(t, err)
+		for _, status := range []string{"pending", "completed"} {
+			_, err := taskRepo.Create(ctx, domain.Task{Title: "Child", Status: status, ParentID: parent.ID, CreatedAt: now, UpdatedAt: now}, domain.HistoryEntry{Action: domain.HistoryCreate})
+			require.NoError(t, err)
+		}
+
+		retrieved, err := taskRepo.GetByID(ctx, parent.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.SubtaskCount{Total: 2, Completed: 1}, retrieved.Subtasks)
+
+		subtasks, err := taskRepo.GetSubtasks(ctx, []string{parent.ID})
+		require.NoError(t, err)
+		assert.Len(t, subtasks, 2)
+
+		checklist := []domain.ChecklistItem{{ID: "a", Text: "Draft"}}
+		changes := domain.TaskChanges(retrieved, domain.Task{Title: retrieved.Title, Status: retrieved.Status, Checklist: checklist})
+		updated, err := taskRepo.Update(ctx, parent.ID, domain.Task{Checklist: checklist}, domain.NewHistoryEntry(domain.Actor{}, domain.HistoryUpdate, changes))
+		require.NoError(t, err)
+		assert.Equal(t, checklist, updated.Checklist)
+
+		done := []domain.ChecklistItem{{ID: "a", Text: "Draft", Done: true}}
+		changes = domain.TaskChanges(domain.Task{Checklist: checklist}, domain.Task{Checklist: done})
+		_, err = taskRepo.Update(ctx, parent.ID, domain.Task{Checklist: done}, domain.NewHistoryEntry(domain.Actor{}, domain.HistoryUpdate, changes))
+		require.NoError(t, err)
+
+		_, err = taskRepo.Update(ctx, parent.ID, domain.Task{Checklist: done}, domain.NewHistoryEntry(domain.Actor{}, domain.HistoryUpdate, changes))
+		assert.ErrorIs(t, err, domain.ErrConflict)
+
+		entries, _, err := taskRepo.GetHistory(ctx, parent.ID, 0, 1)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, []domain.FieldChange{{Field: "checklist", Old: checklist, New: done}}, entries[0].Changes)
+	})
+
+	t.Run("Dependencies", func(t *testing.T) {
+		now := time.Now()
+		blocker, err := taskRepo.Create(ctx, domain.Task{Title: "Blocker", Status: "pending", CreatedAt: now, UpdatedAt: now}, domain.HistoryEntry{Action: domain.HistoryCreate})
+		require.NoError(t, err)
+		blocked, err := taskRepo.Create(ctx, domain.Task{Title: "Blocked", Status: "pending", CreatedAt: now, UpdatedAt: now}, domain.HistoryEntry{Action: domain.HistoryCreate})
+		require.NoError(t, err)
+
+		changes := domain.TaskChanges(blocked, domain.Task{Title: blocked.Title, Status: blocked.Status, BlockedBy: []string{blocker.ID}})
+		updated, err := taskRepo.Update(ctx, blocked.ID, domain.Task{BlockedBy: []string{blocker.ID}}, domain.NewHistoryEntry(domain.Actor{}, domain.HistoryUpdate, changes))
+		require.NoError(t, err)
+		assert.Equal(t, []string{blocker.ID}, updated.BlockedBy)
+
+		dependents, err := taskRepo.GetBlockedBy(ctx, []string{blocker.ID})
+		require.NoError(t, err)
+		require.Len(t, dependents, 1)
+		assert.Equal(t, blocked.ID, dependents[0].ID)
+
+		tasks, err := taskRepo.GetByIDs(ctx, []string{blocker.ID, blocked.ID, "invalid_id"})
+		require.NoError(t, err)
+		assert.Len(t, tasks, 2)
+
+		entries, _, err := taskRepo.GetHistory(ctx, blocked.ID, 0, 1)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, []domain.FieldChange{{Field: "blocked_by", Old: nil, New: []string{blocker.ID}}}, entries[0].Changes)
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		now := time.Now()
+		both, err := taskRepo.Create(ctx, domain.Task{Title: "Both", Status: "pending", Tags: []string{"api", "ops"}, CreatedAt: now, UpdatedAt: now}, domain.HistoryEntry{Action: domain.HistoryCreate})
+		require.NoError(t, err)
+		api, err := taskRepo.Create(ctx, domain.Task{Title: "API", Status: "pending", Tags: []string{"api"}, CreatedAt: now, UpdatedAt: now}, domain.HistoryEntry{Action: domain.HistoryCreate})
+		require.NoError(t, err)
+
+		tasks, err := taskRepo.Find(ctx, domain.TaskFilter{Tags: []string{"api", "ops"}, TagMatch: domain.TagMatchAll})
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, both.ID, tasks[0].ID)
+
+		tasks, err = taskRepo.Find(ctx, domain.TaskFilter{Tags: []string{"api", "ops"}})
+		require.NoError(t, err)
+		assert.Len(t, tasks, 2)
+
+		renamed, err := taskRepo.RenameTag(ctx, "api", "ops", domain.HistoryEntry{Action: domain.HistoryUpdate, Actor: "alice", At: now})
+		require.NoError(t, err)
+		assert.Len(t, renamed, 2)
+
+		got, err := taskRepo.GetByID(ctx, both.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ops"}, got.Tags)
+		got, err = taskRepo.GetByID(ctx, api.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ops"}, got.Tags)
+
+		entries, _, err := taskRepo.GetHistory(ctx, api.ID, 0, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", entries[0].Actor)
+		assert.Equal(t, []domain.FieldChange{{Field: "tags", Old: []string{"api"}, New: []string{"ops"}}}, entries[0].Changes)
+
+		counts, err := taskRepo.CountTags(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, counts["ops"])
+
+		removed, err := taskRepo.RemoveTag(ctx, "ops", domain.HistoryEntry{Action: domain.HistoryUpdate, At: now})
+		require.NoError(t, err)
+		assert.Len(t, removed, 2)
+	})
+
+	t.Run("Recurrence", func(t *testing.T) {
+		now := time.Now()
+		due := now.Add(-time.Hour).Truncate(time.Millisecond).UTC()
+		head, err := taskRepo.Create(ctx, domain.Task{Title: "Daily", Status: "pending", DueDate: due, Recurrence: &domain.Recurrence{Rule: "FREQ=DAILY", Start: due}, CreatedAt: now, UpdatedAt: now}, domain.HistoryEntry{Action: domain.HistoryCreate})
+		require.NoError(t, err)
+		assert.Equal(t, head.ID, head.Recurrence.SeriesID)
+
+		recurring, err := taskRepo.GetRecurring(ctx, now)
+		require.NoError(t, err)
+		require.Len(t, recurring, 1)
+		assert.Equal(t, "FREQ=DAILY", recurring[0].Recurrence.Rule)
+		assert.Equal(t, head.ID, recurring[0].Recurrence.SeriesID)
+		assert.True(t, due.Equal(recurring[0].Recurrence.Start))
+
+		next := domain.Task{Title: "Daily", Status: "pending", DueDate: due.AddDate(0, 0, 1), Recurrence: &domain.Recurrence{Rule: "FREQ=DAILY", SeriesID: head.ID, Start: due}}
+		created, err := taskRepo.CreateOccurrence(ctx, head.ID, next, domain.HistoryEntry{Action: domain.HistoryCreate})
+		require.NoError(t, err)
+		_, err = taskRepo.CreateOccurrence(ctx, head.ID, next, domain.HistoryEntry{Action: domain.HistoryCreate})
+		assert.ErrorIs(t, err, domain.ErrConflict)
+
+		got, err := taskRepo.GetByID(ctx, head.ID)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, got.Recurrence.NextID)
+		recurring, err = taskRepo.GetRecurring(ctx, now)
+		require.NoError(t, err)
+		assert.Empty(t, recurring)
+
+		stop := []domain.FieldChange{{Field: "recurrence", Old: "FREQ=DAILY", New: nil}}
+		updated, err := taskRepo.Update(ctx, created.ID, domain.Task{Recurrence: &domain.Recurrence{SeriesID: head.ID, Start: due}}, domain.HistoryEntry{Action: domain.HistoryUpdate, Changes: stop})
+		require.NoError(t, err)
+		assert.Empty(t, updated.Recurrence.Rule)
+		_, err = taskRepo.Update(ctx, created.ID, domain.Task{Recurrence: &domain.Recurrence{SeriesID: head.ID, Start: due}}, domain.HistoryEntry{Action: domain.HistoryUpdate, Changes: stop})
+		assert.ErrorIs(t, err, domain.ErrConflict)
+	})
+
+	t.Run("External IDs and Each", func(t *testing.T) {
+		due := time.Now().Add(24 * time.Hour)
+		created, err := taskRepo.Create(ctx, domain.Task{ExternalID: "sheet-1", Title: "Imported", DueDate: due, Status: "pending", Priority: "medium"}, domain.HistoryEntry{Action: domain.HistoryCreate})
+		require.NoError(t, err)
+		_, err = taskRepo.Create(ctx, domain.Task{ExternalID: "sheet-1", Title: "Again", DueDate: due, Status: "pending", Priority: "medium"}, domain.HistoryEntry{Action: domain.HistoryCreate})
+		assert.ErrorIs(t, err, domain.ErrExternalIDTaken)
+		for i := 0; i < 2; i++ {
+			_, err = taskRepo.Create(ctx, domain.Task{Title: "No external ID", DueDate: due, Status: "pending", Priority: "medium"}, domain.HistoryEntry{Action: domain.HistoryCreate})
+			require.NoError(t, err, "tasks without an external ID do not collide")
+		}
+
+		require.NoError(t, taskRepo.Delete(ctx, created.ID, domain.NewHistoryEntry(domain.Actor{Username: "admin"}, domain.HistoryDelete, nil)))
+		got, err := taskRepo.GetByExternalID(ctx, "sheet-1")
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, got.ID)
+		assert.False(t, got.DeletedAt.IsZero())
+		_, err = taskRepo.GetByExternalID(ctx, "sheet-2")
+		assert.EqualError(t, err, "task not found")
+
+		var ids []string
+		require.NoError(t, taskRepo.Each(ctx, domain.TaskFilter{}, func(task domain.Task) error {
+			ids = append(ids, task.ID)
+			return nil
+		}))
+		assert.NotContains(t, ids, created.ID)
+		all, err := taskRepo.GetAll(ctx)
+		require.NoError(t, err)
+		assert.Len(t, ids, len(all))
+
+		stop := errors.New("stop")
+		visited := 0
+		err = taskRepo.Each(ctx, domain.TaskFilter{}, func(domain.Task) error {
+			visited++
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, visited)
+	})
+
+	t.Run("Bulk writes report updates that lose a race", func(t *testing.T) {
+		task, err := taskRepo.Create(ctx, domain.Task{Title: "Contested", Status: "pending"}, domain.HistoryEntry{Action: domain.HistoryCreate})
+		require.NoError(t, err)
+		// Both updates are conditioned on the title read before the batch, so
+		// the second finds it already changed by the first.
+		race := func() []domain.TaskWrite {
+			return []domain.TaskWrite{
+				{Op: domain.BatchUpdate, ID: task.ID, Task: domain.Task{Title: "First"}, History: domain.HistoryEntry{Action: domain.HistoryUpdate}},
+				{Op: domain.BatchUpdate, ID: task.ID, Task: domain.Task{Title: "Second"}, History: domain.HistoryEntry{Action: domain.HistoryUpdate}},
+			}
+		}
+
+		errs, err := taskRepo.BulkWriteAtomic(ctx, race())
+		assert.ErrorIs(t, err, domain.ErrBatchAborted)
+		assert.NoError(t, errs[0])
+		assert.ErrorIs(t, errs[1], domain.ErrConflict)
+		got, err := taskRepo.GetByID(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Contested", got.Title, "nothing is applied")
+
+		errs, err = taskRepo.BulkWrite(ctx, race())
+		require.NoError(t, err)
+		assert.NoError(t, errs[0])
+		assert.ErrorIs(t, errs[1], domain.ErrConflict)
+		got, err = taskRepo.GetByID(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "First", got.Title)
+	})
+
+	t.Run("Queries without a workspace are refused", func(t *testing.T) {
+		_, err := taskRepo.GetAll(context.Background())
+		assert.ErrorIs(t, err, domain.ErrNoWorkspace)
+		_, err = taskRepo.GetByID(context.Background(), "507f1f77bcf86cd799439011")
+		assert.ErrorIs(t, err, domain.ErrNoWorkspace)
+
+		all, err := taskRepo.GetAll(domain.AllWorkspaces(context.Background()))
+		require.NoError(t, err)
+		assert.NotEmpty(t, all, "jobs opt in to every workspace")
+	})
+
 	t.Run("GetByID with invalid ID", func(t *testing.T) {
-		_, err := taskRepo.GetByID("invalid_id")
+		_, err := taskRepo.GetByID(ctx, "invalid_id")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid task ID format")
 	})
@@ -149,9 +479,98 @@ func TestTaskRepository_Integration(t *testing.T) {
 			Status: "pending",
 		}
 
-		_, err := taskRepo.Update("507f1f77bcf86cd799439011", task)
+		_, err := taskRepo.Update(ctx, "507f1f77bcf86cd799439011", task, domain.HistoryEntry{Action: domain.HistoryUpdate})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "not found")
 	})
 }
 
+// TestTaskRepository_Stats_Integration runs the scenario of
+// TestTaskRepositoryMemory_Stats against the aggregation pipeline.
+func TestTaskRepository_Stats_Integration(t *testing.T) {
+	collection, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := repository.NewTaskRepositoryMongo(collection, 10*time.Second, 0)
+	ctx := domain.WithWorkspace(context.Background(), "team")
+	day := func(d, hour int) time.Time {
+		return time.Date(2026, time.October, d, hour, 0, 0, 0, time.UTC)
+	}
+	create := func(ctx context.Context, task domain.Task) domain.Task {
+		changes := []domain.FieldChange{{Field: "status", New: task.Status}}
+		created, err := repo.Create(ctx, task, domain.HistoryEntry{Action: domain.HistoryCreate, At: task.CreatedAt, Changes: changes})
+		require.NoError(t, err)
+		return created
+	}
+
+	create(ctx, domain.Task{Title: "Overdue", Status: "pending", DueDate: day(13, 9), CreatedAt: day(10, 9)})
+	create(ctx, domain.Task{Title: "This week", Status: "in_progress", DueDate: day(16, 9), CreatedAt: day(11, 8), ProjectID: "p1"})
+	late := create(ctx, domain.Task{Title: "Done later", Status: "pending", DueDate: day(13, 9), CreatedAt: day(9, 12)})
+	_, err := repo.Update(ctx, late.ID, domain.Task{Status: "completed"}, domain.HistoryEntry{
+		Action: domain.HistoryUpdate, At: day(12, 12),
+		Changes: []domain.FieldChange{{Field: "status", Old: "pending", New: "completed"}},
+	})
+	require.NoError(t, err)
+	create(ctx, domain.Task{Title: "Done at once", Status: "completed", CreatedAt: day(12, 0)})
+	trashed := create(ctx, domain.Task{Title: "Trashed", Status: "pending", CreatedAt: day(13, 10)})
+	require.NoError(t, repo.Delete(ctx, trashed.ID, domain.HistoryEntry{Action: domain.HistoryDelete, At: day(13, 11)}))
+	create(domain.WithWorkspace(context.Background(), "other"), domain.Task{Title: "Elsewhere", Status: "pending", DueDate: day(13, 9), CreatedAt: day(12, 9)})
+	create(ctx, domain.Task{Title: "No due date", Status: "pending", CreatedAt: day(14, 10)})
+
+	query := domain.TaskStatsQuery{From: day(10, 0), To: day(14, 0), Now: day(14, 12)}
+	stats, err := repo.Stats(ctx, query)
+	require.NoError(t, err)
+	assert.Equal(t, domain.TaskStats{
+		Total:       5,
+		ByStatus:    map[string]int{"pending": 2, "in_progress": 1, "completed": 2},
+		Overdue:     1,
+		DueThisWeek: 2,
+		Daily: []domain.DailyTaskCount{
+			{Day: day(10, 0), Created: 1},
+			{Day: day(11, 0), Created: 1},
+			{Day: day(12, 0), Created: 1, Completed: 2},
+			{Day: day(13, 0)},
+			{Day: day(14, 0), Created: 1},
+		},
+		AverageTimeToComplete: 36 * time.Hour,
+	}, stats)
+
+	query.Filter = domain.TaskFilter{ProjectID: "p1"}
+	stats, err = repo.Stats(ctx, query)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Total)
+	assert.Equal(t, 1, stats.DueThisWeek)
+	assert.Zero(t, stats.AverageTimeToComplete)
+}
+
+func BenchmarkTaskRepository_GetByID_Integration(b *testing.B) {
+	collection, cleanup := setupTestDB(b)
+	defer cleanup()
+
+	ctx := domain.WithWorkspace(context.Background(), "team")
+	mongoRepo := repository.NewTaskRepositoryMongo(collection, 10*time.Second, 0)
+	ids := make([]string, 100)
+	for i := range ids {
+		task, err := mongoRepo.Create(ctx, domain.Task{Title: "Benchmark Task", Status: "pending"}, domain.HistoryEntry{Action: domain.HistoryCreate})
+		require.NoError(b, err)
+		ids[i] = task.ID
+	}
+
+	for name, repo := range map[string]domain.TaskRepository{
+		"uncached": mongoRepo,
+		"cached":   repository.NewCachedTaskRepository(mongoRepo, infrastructure.NewMemoryTaskCache(1000, time.Minute)),
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					if _, err := repo.GetByID(ctx, ids[i%len(ids)]); err != nil {
+						b.Error(err)
+						return
+					}
+					i++
+				}
+			})
+		})
+	}
+}
@@ -3,6 +3,7 @@ package repositories_integration
 import (
 	"context"
 	"os"
+	"task9/config"
 	"task9/domain"
 	"task9/infrastructure"
 	"task9/repository"
@@ -21,7 +22,13 @@ func setupTestUserDB(t *testing.T) (*mongo.Collection, func()) {
 		mongoURI = "mongodb://localhost:27017"
 	}
 
-	err := infrastructure.ConnectDB(mongoURI, "task_manager_test")
+	err := infrastructure.ConnectDB(config.DatabaseConfig{
+		URI:            mongoURI,
+		Name:           "task_manager_test",
+		ConnectTimeout: 10 * time.Second,
+	})
+	require.NoError(t, err)
+	_, err = infrastructure.Migrate(context.Background(), false)
 	require.NoError(t, err)
 
 	collection := infrastructure.UserCollection
@@ -40,7 +47,8 @@ func TestUserRepository_Integration(t *testing.T) {
 	collection, cleanup := setupTestUserDB(t)
 	defer cleanup()
 
-	userRepo := repository.NewUserRepositoryMongo(collection)
+	userRepo := repository.NewUserRepositoryMongo(collection, 10*time.Second)
+	ctx := context.Background()
 
 	t.Run("Create and GetByUsername user", func(t *testing.T) {
 		user := domain.User{
@@ -49,19 +57,19 @@ func TestUserRepository_Integration(t *testing.T) {
 			Role:     "user",
 		}
 
-		createdUser, err := userRepo.Create(user)
+		createdUser, err := userRepo.Create(ctx, user)
 		require.NoError(t, err)
 		assert.NotEmpty(t, createdUser.ID)
 		assert.Equal(t, "integration_user", createdUser.Username)
 
-		retrievedUser, err := userRepo.GetByUsername("integration_user")
+		retrievedUser, err := userRepo.GetByUsername(ctx, "integration_user")
 		require.NoError(t, err)
 		assert.Equal(t, createdUser.ID, retrievedUser.ID)
 		assert.Equal(t, "integration_user", retrievedUser.Username)
 	})
 
 	t.Run("IsFirstUser - true when empty", func(t *testing.T) {
-		isFirst, err := userRepo.IsFirstUser()
+		isFirst, err := userRepo.IsFirstUser(ctx)
 		require.NoError(t, err)
 		assert.True(t, isFirst)
 	})
@@ -73,10 +81,10 @@ func TestUserRepository_Integration(t *testing.T) {
 			Role:     "user",
 		}
 
-		_, err := userRepo.Create(user)
+		_, err := userRepo.Create(ctx, user)
 		require.NoError(t, err)
 
-		isFirst, err := userRepo.IsFirstUser()
+		isFirst, err := userRepo.IsFirstUser(ctx)
 		require.NoError(t, err)
 		assert.False(t, isFirst)
 	})
@@ -88,14 +96,14 @@ func TestUserRepository_Integration(t *testing.T) {
 			Role:     "user",
 		}
 
-		createdUser, err := userRepo.Create(user)
+		createdUser, err := userRepo.Create(ctx, user)
 		require.NoError(t, err)
 		assert.Equal(t, "user", createdUser.Role)
 
-		err = userRepo.UpdateRole("user_to_promote", "admin")
+		err = userRepo.UpdateRole(ctx, "user_to_promote", "admin")
 		require.NoError(t, err)
 
-		updatedUser, err := userRepo.GetByUsername("user_to_promote")
+		updatedUser, err := userRepo.GetByUsername(ctx, "user_to_promote")
 		require.NoError(t, err)
 		assert.Equal(t, "admin", updatedUser.Role)
 	})
@@ -107,15 +115,35 @@ func TestUserRepository_Integration(t *testing.T) {
 			Role:     "user",
 		}
 
-		createdUser, err := userRepo.Create(user)
+		createdUser, err := userRepo.Create(ctx, user)
 		require.NoError(t, err)
 
-		retrievedUser, err := userRepo.GetByID(createdUser.ID)
+		retrievedUser, err := userRepo.GetByID(ctx, createdUser.ID)
 		require.NoError(t, err)
 		assert.Equal(t, createdUser.ID, retrievedUser.ID)
 		assert.Equal(t, "user_by_id", retrievedUser.Username)
 	})
 
+	t.Run("Calendar token hash", func(t *testing.T) {
+		first, err := userRepo.Create(ctx, domain.User{Username: "calendar_user", Password: "hashed_password", Role: "user"})
+		require.NoError(t, err)
+		second, err := userRepo.Create(ctx, domain.User{Username: "calendar_user_2", Password: "hashed_password", Role: "user"})
+		require.NoError(t, err)
+
+		require.NoError(t, userRepo.SetCalendarTokenHash(ctx, first.ID, "hash-1"))
+		found, err := userRepo.GetByCalendarTokenHash(ctx, "hash-1")
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, found.ID)
+		assert.Equal(t, "hash-1", found.CalendarTokenHash)
+
+		require.NoError(t, userRepo.SetCalendarTokenHash(ctx, first.ID, ""))
+		require.NoError(t, userRepo.SetCalendarTokenHash(ctx, second.ID, ""), "several users can have their feed off")
+		for _, hash := range []string{"hash-1", ""} {
+			_, err = userRepo.GetByCalendarTokenHash(ctx, hash)
+			assert.EqualError(t, err, "user not found")
+		}
+	})
+
 	t.Run("Create duplicate username", func(t *testing.T) {
 		user := domain.User{
 			Username: "duplicate_user",
@@ -123,30 +151,29 @@ func TestUserRepository_Integration(t *testing.T) {
 			Role:     "user",
 		}
 
-		_, err := userRepo.Create(user)
+		_, err := userRepo.Create(ctx, user)
 		require.NoError(t, err)
 
-		_, err = userRepo.Create(user)
+		_, err = userRepo.Create(ctx, user)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "username already exists")
 	})
 
 	t.Run("GetByUsername - user not found", func(t *testing.T) {
-		_, err := userRepo.GetByUsername("nonexistent_user")
+		_, err := userRepo.GetByUsername(ctx, "nonexistent_user")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "user not found")
 	})
 
 	t.Run("GetByID with invalid ID", func(t *testing.T) {
-		_, err := userRepo.GetByID("invalid_id")
+		_, err := userRepo.GetByID(ctx, "invalid_id")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid user ID format")
 	})
 
 	t.Run("UpdateRole - user not found", func(t *testing.T) {
-		err := userRepo.UpdateRole("nonexistent_user", "admin")
+		err := userRepo.UpdateRole(ctx, "nonexistent_user", "admin")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "user not found")
 	})
 }
-
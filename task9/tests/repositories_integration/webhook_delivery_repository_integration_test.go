@@ -0,0 +1,122 @@
+package repositories_integration
+
+import (
+	"context"
+	"os"
+	"task9/config"
+	"task9/domain"
+	"task9/infrastructure"
+	"task9/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func setupTestWebhookDeliveryDB(t *testing.T) (*mongo.Collection, func()) {
+	mongoURI := os.Getenv("MONGODB_URI")
+	if mongoURI == "" {
+		mongoURI = "mongodb://localhost:27017"
+	}
+
+	err := infrastructure.ConnectDB(config.DatabaseConfig{
+		URI:            mongoURI,
+		Name:           "task_manager_test",
+		ConnectTimeout: 10 * time.Second,
+	})
+	require.NoError(t, err)
+	_, err = infrastructure.Migrate(context.Background(), false)
+	require.NoError(t, err)
+
+	collection := infrastructure.WebhookDeliveryCollection
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		collection.DeleteMany(ctx, bson.M{})
+		infrastructure.DisconnectDB()
+	}
+
+	return collection, cleanup
+}
+
+func TestWebhookDeliveryRepository_Integration(t *testing.T) {
+	collection, cleanup := setupTestWebhookDeliveryDB(t)
+	defer cleanup()
+
+	deliveryRepo := repository.NewWebhookDeliveryRepositoryMongo(collection, 10*time.Second)
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+	subscriptionID := primitive.NewObjectID().Hex()
+
+	require.NoError(t, deliveryRepo.Create(ctx, []domain.WebhookDelivery{{
+		SubscriptionID: subscriptionID,
+		EventID:        "e1",
+		EventType:      domain.EventTaskCreated,
+		Payload:        []byte(`{"id":"e1"}`),
+		Status:         domain.WebhookPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}}))
+
+	var delivery domain.WebhookDelivery
+
+	t.Run("ClaimNext is exclusive while the lease holds", func(t *testing.T) {
+		var ok bool
+		var err error
+		delivery, ok, err = deliveryRepo.ClaimNext(ctx, now, "a", time.Minute)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, subscriptionID, delivery.SubscriptionID)
+		assert.Equal(t, []byte(`{"id":"e1"}`), delivery.Payload)
+
+		_, ok, err = deliveryRepo.ClaimNext(ctx, now.Add(30*time.Second), "b", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("Record needs the lease", func(t *testing.T) {
+		delivery.Attempts = 1
+		delivery.Status = domain.WebhookDead
+		delivery.LastError = "gave up"
+
+		assert.EqualError(t, deliveryRepo.Record(ctx, delivery, "b"), "webhook delivery lease lost")
+		require.NoError(t, deliveryRepo.Record(ctx, delivery, "a"))
+
+		stored, err := deliveryRepo.GetByID(ctx, delivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.WebhookDead, stored.Status)
+		assert.Equal(t, 1, stored.Attempts)
+		assert.True(t, stored.DeliveredAt.IsZero())
+	})
+
+	t.Run("Requeue only takes dead deliveries", func(t *testing.T) {
+		requeued, err := deliveryRepo.Requeue(ctx, delivery.ID, now)
+		require.NoError(t, err)
+		assert.Equal(t, domain.WebhookPending, requeued.Status)
+		assert.Zero(t, requeued.Attempts)
+
+		_, err = deliveryRepo.Requeue(ctx, delivery.ID, now)
+		assert.ErrorIs(t, err, domain.ErrConflict)
+
+		_, ok, err := deliveryRepo.ClaimNext(ctx, now, "b", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("ListBySubscription and DeleteBySubscription", func(t *testing.T) {
+		deliveries, total, err := deliveryRepo.ListBySubscription(ctx, subscriptionID, domain.WebhookPending, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Len(t, deliveries, 1)
+
+		deleted, err := deliveryRepo.DeleteBySubscription(ctx, subscriptionID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+	})
+}
@@ -0,0 +1,133 @@
+package repositories_integration
+
+import (
+	"context"
+	"os"
+	"task9/config"
+	"task9/domain"
+	"task9/infrastructure"
+	"task9/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func setupTestWorkspaceDB(t *testing.T) func() {
+	mongoURI := os.Getenv("MONGODB_URI")
+	if mongoURI == "" {
+		mongoURI = "mongodb://localhost:27017"
+	}
+
+	err := infrastructure.ConnectDB(config.DatabaseConfig{
+		URI:            mongoURI,
+		Name:           "task_manager_test",
+		ConnectTimeout: 10 * time.Second,
+	})
+	require.NoError(t, err)
+	_, err = infrastructure.Migrate(context.Background(), false)
+	require.NoError(t, err)
+
+	cleanup := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		infrastructure.WorkspaceCollection.DeleteMany(ctx, bson.M{})
+		infrastructure.MemberCollection.DeleteMany(ctx, bson.M{})
+		infrastructure.TaskCollection.DeleteMany(ctx, bson.M{})
+		infrastructure.TagCollection.DeleteMany(ctx, bson.M{})
+		infrastructure.DisconnectDB()
+	}
+
+	return cleanup
+}
+
+func TestWorkspaceRepository_Integration(t *testing.T) {
+	cleanup := setupTestWorkspaceDB(t)
+	defer cleanup()
+
+	workspaceRepo := repository.NewWorkspaceRepositoryMongo(infrastructure.WorkspaceCollection, infrastructure.MemberCollection, 10*time.Second)
+	ctx := context.Background()
+	now := time.Now()
+
+	team, err := workspaceRepo.Create(ctx, domain.Workspace{Name: "Team", CreatedAt: now})
+	require.NoError(t, err)
+	other, err := workspaceRepo.Create(ctx, domain.Workspace{Name: "Another", CreatedAt: now})
+	require.NoError(t, err)
+
+	t.Run("Members", func(t *testing.T) {
+		_, err := workspaceRepo.AddMember(ctx, domain.Membership{WorkspaceID: team.ID, UserID: "1", Username: "alice", Role: domain.WorkspaceAdmin, CreatedAt: now})
+		require.NoError(t, err)
+		_, err = workspaceRepo.AddMember(ctx, domain.Membership{WorkspaceID: team.ID, UserID: "2", Username: "bob", Role: domain.WorkspaceMember, CreatedAt: now})
+		require.NoError(t, err)
+		_, err = workspaceRepo.AddMember(ctx, domain.Membership{WorkspaceID: other.ID, UserID: "1", Username: "alice", Role: domain.WorkspaceMember, CreatedAt: now})
+		require.NoError(t, err)
+
+		_, err = workspaceRepo.AddMember(ctx, domain.Membership{WorkspaceID: team.ID, UserID: "2", Username: "bob", Role: domain.WorkspaceAdmin, CreatedAt: now})
+		assert.ErrorIs(t, err, domain.ErrAlreadyMember)
+
+		members, err := workspaceRepo.ListMembers(ctx, team.ID)
+		require.NoError(t, err)
+		require.Len(t, members, 2)
+		assert.Equal(t, "alice", members[0].Username)
+		assert.Equal(t, "bob", members[1].Username)
+
+		updated, err := workspaceRepo.UpdateMemberRole(ctx, team.ID, "2", domain.WorkspaceAdmin)
+		require.NoError(t, err)
+		assert.Equal(t, domain.WorkspaceAdmin, updated.Role)
+
+		require.NoError(t, workspaceRepo.RemoveMember(ctx, team.ID, "2"))
+		_, err = workspaceRepo.GetMember(ctx, team.ID, "2")
+		assert.EqualError(t, err, "member not found")
+	})
+
+	t.Run("ListForUser", func(t *testing.T) {
+		workspaces, err := workspaceRepo.ListForUser(ctx, "1")
+		require.NoError(t, err)
+		require.Len(t, workspaces, 2)
+		assert.Equal(t, "Another", workspaces[0].Name)
+		assert.Equal(t, domain.WorkspaceMember, workspaces[0].Role)
+		assert.Equal(t, "Team", workspaces[1].Name)
+		assert.Equal(t, domain.WorkspaceAdmin, workspaces[1].Role)
+
+		_, err = workspaceRepo.GetByID(ctx, "not-an-id")
+		assert.EqualError(t, err, "workspace not found")
+	})
+
+	t.Run("Tasks and tags are scoped", func(t *testing.T) {
+		taskRepo := repository.NewTaskRepositoryMongo(infrastructure.TaskCollection, 10*time.Second, 0)
+		tagRepo := repository.NewTagRepositoryMongo(infrastructure.TagCollection, 10*time.Second)
+		teamCtx := domain.WithWorkspace(ctx, team.ID)
+		otherCtx := domain.WithWorkspace(ctx, other.ID)
+
+		task, err := taskRepo.Create(teamCtx, domain.Task{Title: "Team task", ExternalID: "JIRA-1", CreatedAt: now}, domain.HistoryEntry{Action: domain.HistoryCreate, At: now})
+		require.NoError(t, err)
+		assert.Equal(t, team.ID, task.WorkspaceID)
+		_, err = taskRepo.Create(otherCtx, domain.Task{Title: "Other task", ExternalID: "JIRA-1", CreatedAt: now}, domain.HistoryEntry{Action: domain.HistoryCreate, At: now})
+		require.NoError(t, err, "external IDs are unique per workspace")
+
+		_, err = taskRepo.GetByID(otherCtx, task.ID)
+		assert.EqualError(t, err, "task not found")
+		assert.EqualError(t, taskRepo.Delete(otherCtx, task.ID, domain.HistoryEntry{Action: domain.HistoryDelete, At: now}), "task not found")
+		found, err := taskRepo.GetByExternalID(teamCtx, "JIRA-1")
+		require.NoError(t, err)
+		assert.Equal(t, task.ID, found.ID)
+
+		_, err = taskRepo.GetAll(ctx)
+		assert.ErrorIs(t, err, domain.ErrNoWorkspace, "unscoped contexts are refused")
+		all, err := taskRepo.GetAll(domain.AllWorkspaces(ctx))
+		require.NoError(t, err)
+		assert.Len(t, all, 2, "jobs reach every workspace")
+
+		for _, scoped := range []context.Context{teamCtx, otherCtx} {
+			_, err := tagRepo.Create(scoped, domain.Tag{Name: "backend", CreatedAt: now})
+			require.NoError(t, err, "tag names are unique per workspace")
+		}
+		_, err = tagRepo.Create(teamCtx, domain.Tag{Name: "backend", CreatedAt: now})
+		assert.Error(t, err)
+		tags, err := tagRepo.List(teamCtx)
+		require.NoError(t, err)
+		assert.Len(t, tags, 1)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"task9/config"
 	"task9/delivery/http"
 	"task9/delivery/middleware"
 	"task9/infrastructure"
@@ -26,8 +27,8 @@ func setupTestRouterWithMocks() *gin.Engine {
 	taskHandler := http.NewTaskHandler(taskUseCase)
 
 	mockUserRepo := new(mocks.MockUserRepository)
-	passwordHasher := infrastructure.NewBcryptHasher()
-	tokenGenerator := infrastructure.NewJWTGenerator()
+	passwordHasher := infrastructure.NewBcryptHasher(config.Default().Password.BcryptCost)
+	tokenGenerator := infrastructure.NewJWTGenerator(config.Default().Auth)
 	authUseCase := usecase.NewAuthUseCase(mockUserRepo, passwordHasher, tokenGenerator)
 	authHandler := http.NewAuthHandler(authUseCase)
 
@@ -99,7 +100,7 @@ func TestRouter_PublicEndpoints(t *testing.T) {
 
 func TestRouter_AuthenticatedEndpoints(t *testing.T) {
 	router := setupTestRouterWithMocks()
-	tokenGenerator := infrastructure.NewJWTGenerator()
+	tokenGenerator := infrastructure.NewJWTGenerator(config.Default().Auth)
 
 	t.Run("GET /tasks without token", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/tasks", nil)
@@ -125,7 +126,7 @@ func TestRouter_AuthenticatedEndpoints(t *testing.T) {
 
 func TestRouter_AdminEndpoints(t *testing.T) {
 	router := setupTestRouterWithMocks()
-	tokenGenerator := infrastructure.NewJWTGenerator()
+	tokenGenerator := infrastructure.NewJWTGenerator(config.Default().Auth)
 
 	t.Run("POST /tasks as user (forbidden)", func(t *testing.T) {
 		token, _ := tokenGenerator.Generate("123", "testuser", "user")
@@ -167,4 +168,3 @@ func TestRouter_AdminEndpoints(t *testing.T) {
 		assert.True(t, w.Code == http.StatusCreated || w.Code == http.StatusBadRequest || w.Code == http.StatusInternalServerError)
 	})
 }
-
@@ -0,0 +1,128 @@
+package usecases
+
+import (
+	"io"
+	"strings"
+	"task9/domain"
+	"task9/infrastructure"
+	"task9/repository"
+	"task9/usecase"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type attachmentFixture struct {
+	attachments *usecase.AttachmentUseCase
+	tasks       *usecase.TaskUseCase
+	blobs       domain.BlobStore
+	taskID      string
+}
+
+func newAttachmentFixture(t *testing.T) attachmentFixture {
+	taskRepo := repository.NewTaskRepositoryMemory()
+	attachmentRepo := repository.NewAttachmentRepositoryMemory()
+	blobs := infrastructure.NewLocalBlobStore(t.TempDir())
+	tasks := usecase.NewTaskUseCase(taskRepo, usecase.WithAttachments(attachmentRepo, blobs))
+	task, err := tasks.CreateTask(alice, domain.CreateTaskRequest{Title: "Ship"})
+	require.NoError(t, err)
+
+	return attachmentFixture{
+		attachments: usecase.NewAttachmentUseCase(attachmentRepo, taskRepo, blobs, usecase.WithAttachmentLimits(domain.AttachmentLimits{
+			MaxBytes:     10,
+			AllowedTypes: []string{"image/*", "text/plain"},
+		})),
+		tasks:  tasks,
+		blobs:  blobs,
+		taskID: task.ID,
+	}
+}
+
+func upload(filename, contentType, body string) domain.AttachmentUpload {
+	return domain.AttachmentUpload{Filename: filename, ContentType: contentType, Body: strings.NewReader(body)}
+}
+
+func TestAttachmentUseCase_UploadAttachment(t *testing.T) {
+	f := newAttachmentFixture(t)
+
+	t.Run("stores the content and records the uploader", func(t *testing.T) {
+		attachment, err := f.attachments.UploadAttachment(alice, f.taskID, upload(`C:\Users\alice\notes.txt`, "text/plain; charset=utf-8", "hello"))
+		require.NoError(t, err)
+		assert.Equal(t, "notes.txt", attachment.Filename)
+		assert.Equal(t, "text/plain", attachment.ContentType)
+		assert.Equal(t, int64(5), attachment.Size)
+		assert.Equal(t, "alice", attachment.Uploader)
+
+		got, content, err := f.attachments.OpenAttachment(bob, f.taskID, attachment.ID)
+		require.NoError(t, err)
+		defer content.Close()
+		data, err := io.ReadAll(content)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+		assert.Equal(t, attachment, got)
+	})
+
+	t.Run("falls back to the extension for the type", func(t *testing.T) {
+		attachment, err := f.attachments.UploadAttachment(alice, f.taskID, upload("photo.png", "application/octet-stream", "png"))
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", attachment.ContentType)
+	})
+
+	t.Run("rejects types that are not allowed", func(t *testing.T) {
+		_, err := f.attachments.UploadAttachment(alice, f.taskID, upload("run.sh", "", "echo"))
+		assert.ErrorIs(t, err, domain.ErrAttachmentType)
+	})
+
+	t.Run("rejects files over the size limit", func(t *testing.T) {
+		before, err := f.attachments.ListAttachments(alice, f.taskID)
+		require.NoError(t, err)
+
+		_, err = f.attachments.UploadAttachment(alice, f.taskID, upload("big.txt", "text/plain", strings.Repeat("x", 11)))
+		assert.ErrorIs(t, err, domain.ErrAttachmentTooLarge)
+
+		after, err := f.attachments.ListAttachments(alice, f.taskID)
+		require.NoError(t, err)
+		assert.Len(t, after, len(before))
+	})
+
+	t.Run("requires a file name", func(t *testing.T) {
+		var validationErr *domain.ValidationError
+		_, err := f.attachments.UploadAttachment(alice, f.taskID, upload(" ", "text/plain", "hi"))
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("task must exist", func(t *testing.T) {
+		_, err := f.attachments.UploadAttachment(alice, "999", upload("a.txt", "text/plain", "hi"))
+		assert.EqualError(t, err, "task not found")
+	})
+}
+
+func TestAttachmentUseCase_DeleteAttachment(t *testing.T) {
+	f := newAttachmentFixture(t)
+	attachment, err := f.attachments.UploadAttachment(alice, f.taskID, upload("a.txt", "text/plain", "hi"))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, f.attachments.DeleteAttachment(bob, f.taskID, attachment.ID), domain.ErrNotUploader)
+	require.NoError(t, f.attachments.DeleteAttachment(admin, f.taskID, attachment.ID))
+
+	_, _, err = f.attachments.OpenAttachment(alice, f.taskID, attachment.ID)
+	assert.EqualError(t, err, "attachment not found")
+	_, err = f.blobs.Open(alice, attachment.BlobKey)
+	assert.ErrorIs(t, err, domain.ErrBlobNotFound)
+}
+
+func TestTaskUseCase_PurgeTaskRemovesAttachments(t *testing.T) {
+	f := newAttachmentFixture(t)
+	attachment, err := f.attachments.UploadAttachment(alice, f.taskID, upload("a.txt", "text/plain", "hi"))
+	require.NoError(t, err)
+
+	require.NoError(t, f.tasks.DeleteTask(admin, f.taskID))
+	content, err := f.blobs.Open(alice, attachment.BlobKey)
+	require.NoError(t, err, "trashed tasks keep their attachments")
+	require.NoError(t, content.Close())
+
+	require.NoError(t, f.tasks.PurgeTask(admin, f.taskID))
+	_, err = f.blobs.Open(alice, attachment.BlobKey)
+	assert.ErrorIs(t, err, domain.ErrBlobNotFound)
+}
@@ -0,0 +1,75 @@
+package usecases
+
+import (
+	"errors"
+	"task9/domain"
+	"task9/repository"
+	"task9/tests/mocks"
+	"task9/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarUseCase(t *testing.T) {
+	userRepo := new(mocks.MockUserRepository)
+	taskRepo := repository.NewTaskRepositoryMemory()
+	workspaceRepo := repository.NewWorkspaceRepositoryMemory()
+	team, err := workspaceRepo.Create(alice, domain.Workspace{Name: "Team"})
+	require.NoError(t, err)
+	other, err := workspaceRepo.Create(alice, domain.Workspace{Name: "Other"})
+	require.NoError(t, err)
+	_, err = workspaceRepo.AddMember(alice, domain.Membership{WorkspaceID: team.ID, UserID: "1", Role: domain.WorkspaceMember})
+	require.NoError(t, err)
+
+	for _, task := range []domain.Task{
+		{Title: "Due", DueDate: time.Now().Add(time.Hour), Status: "pending"},
+		{Title: "No due date", Status: "pending"},
+		{Title: "Trashed", DueDate: time.Now().Add(time.Hour), Status: "pending"},
+		{Title: "Elsewhere", DueDate: time.Now().Add(time.Hour), Status: "pending"},
+	} {
+		ctx := domain.WithWorkspace(alice, team.ID)
+		if task.Title == "Elsewhere" {
+			ctx = domain.WithWorkspace(alice, other.ID)
+		}
+		created, err := taskRepo.Create(ctx, task, domain.HistoryEntry{Action: domain.HistoryCreate})
+		require.NoError(t, err)
+		if task.Title == "Trashed" {
+			require.NoError(t, taskRepo.Delete(ctx, created.ID, domain.HistoryEntry{Action: domain.HistoryDelete, At: time.Now()}))
+		}
+	}
+	calendar := usecase.NewCalendarUseCase(userRepo, taskRepo, workspaceRepo)
+
+	var hashes []string
+	userRepo.On("SetCalendarTokenHash", mock.Anything, "1", mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { hashes = append(hashes, args.String(2)) }).
+		Return(nil)
+	first, err := calendar.RotateFeedToken(alice)
+	require.NoError(t, err)
+	second, err := calendar.RotateFeedToken(alice)
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+	require.Len(t, hashes, 2)
+	assert.NotEqual(t, hashes[0], hashes[1])
+	assert.NotContains(t, hashes, second, "only the hash is stored")
+
+	userRepo.On("GetByCalendarTokenHash", mock.Anything, hashes[1]).Return(domain.User{ID: "1"}, nil)
+	userRepo.On("GetByCalendarTokenHash", mock.Anything, mock.Anything).Return(domain.User{}, errors.New("user not found"))
+
+	var titles []string
+	err = calendar.Feed(bob, second, func(task domain.Task) error {
+		titles = append(titles, task.Title)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Due"}, titles, "only tasks of the user's workspaces are in the feed")
+
+	for _, token := range []string{first, ""} {
+		err = calendar.Feed(bob, token, func(domain.Task) error { return nil })
+		assert.ErrorIs(t, err, domain.ErrCalendarFeedNotFound)
+	}
+}
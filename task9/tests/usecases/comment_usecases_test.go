@@ -0,0 +1,147 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"task9/domain"
+	"task9/repository"
+	"task9/tests/mocks"
+	"task9/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// The actors act in the "team" workspace, as requests do once the workspace
+// middleware has run.
+var (
+	team  = domain.WithWorkspace(context.Background(), "team")
+	alice = domain.WithActor(team, domain.Actor{UserID: "1", Username: "alice", Role: "user"})
+	bob   = domain.WithActor(team, domain.Actor{UserID: "2", Username: "bob", Role: "user"})
+	admin = domain.WithActor(team, domain.Actor{UserID: "3", Username: "root", Role: "admin"})
+)
+
+func newCommentUseCase(limits domain.CommentLimits) (*usecase.CommentUseCase, domain.CommentRepository, *mocks.MockTaskRepository) {
+	mockTaskRepo := new(mocks.MockTaskRepository)
+	mockTaskRepo.On("GetByID", mock.Anything, "task-1").Return(domain.Task{ID: "task-1"}, nil)
+	mockTaskRepo.On("GetByID", mock.Anything, "missing").Return(domain.Task{}, errors.New("task not found"))
+	commentRepo := repository.NewCommentRepositoryMemory()
+	return usecase.NewCommentUseCase(commentRepo, mockTaskRepo, usecase.WithCommentLimits(limits)), commentRepo, mockTaskRepo
+}
+
+func TestCommentUseCase_AddComment(t *testing.T) {
+	commentUseCase, _, _ := newCommentUseCase(domain.CommentLimits{MaxBodyLength: 10})
+
+	t.Run("records the author", func(t *testing.T) {
+		comment, err := commentUseCase.AddComment(alice, "task-1", "  looks good ")
+
+		require.NoError(t, err)
+		assert.Equal(t, "1", comment.AuthorID)
+		assert.Equal(t, "alice", comment.Author)
+		assert.Equal(t, "looks good", comment.Body)
+	})
+
+	t.Run("validates the body", func(t *testing.T) {
+		var validationErr *domain.ValidationError
+
+		_, err := commentUseCase.AddComment(alice, "task-1", "   ")
+		assert.ErrorAs(t, err, &validationErr)
+
+		_, err = commentUseCase.AddComment(alice, "task-1", strings.Repeat("x", 11))
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("task must exist", func(t *testing.T) {
+		_, err := commentUseCase.AddComment(alice, "missing", "hello")
+		assert.EqualError(t, err, "task not found")
+	})
+}
+
+func TestCommentUseCase_EditAndDelete(t *testing.T) {
+	commentUseCase, _, _ := newCommentUseCase(domain.CommentLimits{})
+	comment, err := commentUseCase.AddComment(alice, "task-1", "first draft")
+	require.NoError(t, err)
+
+	t.Run("only the author can edit", func(t *testing.T) {
+		_, err := commentUseCase.EditComment(bob, "task-1", comment.ID, "hijacked")
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+
+		_, err = commentUseCase.EditComment(admin, "task-1", comment.ID, "moderated")
+		assert.ErrorIs(t, err, domain.ErrForbidden)
+
+		edited, err := commentUseCase.EditComment(alice, "task-1", comment.ID, "final")
+		require.NoError(t, err)
+		assert.Equal(t, "final", edited.Body)
+	})
+
+	t.Run("comment must belong to the task", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		mockTaskRepo.On("GetByID", mock.Anything, "task-2").Return(domain.Task{ID: "task-2"}, nil)
+		commentRepo := repository.NewCommentRepositoryMemory()
+		other := usecase.NewCommentUseCase(commentRepo, mockTaskRepo)
+		created, err := commentRepo.Create(team, domain.Comment{TaskID: "task-1", AuthorID: "1"})
+		require.NoError(t, err)
+
+		err = other.DeleteComment(alice, "task-2", created.ID)
+		assert.EqualError(t, err, "comment not found")
+	})
+
+	t.Run("other users cannot delete, admins can", func(t *testing.T) {
+		assert.ErrorIs(t, commentUseCase.DeleteComment(bob, "task-1", comment.ID), domain.ErrForbidden)
+		assert.NoError(t, commentUseCase.DeleteComment(admin, "task-1", comment.ID))
+
+		comments, err := commentUseCase.ListComments(alice, "task-1")
+		require.NoError(t, err)
+		assert.Empty(t, comments)
+	})
+}
+
+func TestTaskUseCase_Comments(t *testing.T) {
+	t.Run("task reads include comment counts", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		commentRepo := repository.NewCommentRepositoryMemory()
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo, usecase.WithComments(commentRepo))
+
+		mockTaskRepo.On("GetAll", mock.Anything).Return([]domain.Task{{ID: "task-1"}, {ID: "task-2"}}, nil)
+		for i := 0; i < 2; i++ {
+			_, err := commentRepo.Create(team, domain.Comment{TaskID: "task-1", CreatedAt: time.Now()})
+			require.NoError(t, err)
+		}
+
+		tasks, err := taskUseCase.GetAllTasks(team)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, tasks[0].CommentCount)
+		assert.Equal(t, 0, tasks[1].CommentCount)
+	})
+
+	t.Run("purging a task removes its comments", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		commentRepo := repository.NewCommentRepositoryMemory()
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo, usecase.WithComments(commentRepo))
+
+		_, err := commentRepo.Create(team, domain.Comment{TaskID: "task-1"})
+		require.NoError(t, err)
+		_, err = commentRepo.Create(team, domain.Comment{TaskID: "task-2"})
+		require.NoError(t, err)
+		mockTaskRepo.On("Delete", mock.Anything, "task-1", mock.Anything).Return(nil)
+		mockTaskRepo.On("PurgeDeleted", mock.Anything, mock.Anything).Return([]string{"task-1"}, nil)
+
+		require.NoError(t, taskUseCase.DeleteTask(admin, "task-1"))
+		counts, err := commentRepo.CountByTasks(team, []string{"task-1"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, counts["task-1"], "comments survive while the task is in the trash")
+
+		purged, err := taskUseCase.EmptyTrash(admin)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+
+		counts, err = commentRepo.CountByTasks(team, []string{"task-1", "task-2"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"task-2": 1}, counts)
+	})
+}
@@ -0,0 +1,205 @@
+package usecases
+
+import (
+	"context"
+	"task9/domain"
+	"task9/repository"
+	"task9/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Subscriptions through these contexts see the events of every workspace.
+var (
+	adminCtx = domain.AllWorkspaces(domain.WithActor(context.Background(), domain.Actor{UserID: "1", Username: "admin", Role: "admin"}))
+	userCtx  = domain.AllWorkspaces(domain.WithActor(context.Background(), domain.Actor{UserID: "2", Username: "bob", Role: "user"}))
+)
+
+func publishTaskEvent(t *testing.T, hub *usecase.EventHub, eventType, taskID string) {
+	require.NoError(t, hub.Publish(context.Background(), domain.Event{Type: eventType, Task: &domain.Task{ID: taskID, Title: "Task " + taskID}}))
+}
+
+func receive(t *testing.T, subscription *usecase.EventSubscription) usecase.StreamEvent {
+	select {
+	case event, ok := <-subscription.Events():
+		require.True(t, ok, "subscription ended")
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return usecase.StreamEvent{}
+	}
+}
+
+func TestEventHub_Publish(t *testing.T) {
+	t.Run("delivers task events to every subscriber", func(t *testing.T) {
+		hub := usecase.NewEventHub()
+		first, _, _ := hub.Subscribe(adminCtx, "")
+		defer first.Close()
+		second, _, _ := hub.Subscribe(userCtx, "")
+		defer second.Close()
+
+		publishTaskEvent(t, hub, domain.EventTaskCreated, "1")
+		publishTaskEvent(t, hub, domain.EventTaskCompleted, "1")
+		require.NoError(t, hub.Publish(context.Background(), domain.Event{Type: domain.EventUserRegistered, User: &domain.User{ID: "3"}}))
+		publishTaskEvent(t, hub, domain.EventTaskUpdated, "1")
+
+		for _, subscription := range []*usecase.EventSubscription{first, second} {
+			created := receive(t, subscription)
+			assert.Equal(t, domain.EventTaskCreated, created.Type)
+			assert.Equal(t, "Task 1", created.Task.Title)
+			updated := receive(t, subscription)
+			assert.Equal(t, domain.EventTaskUpdated, updated.Type, "completions and user events are not streamed")
+			assert.NotEqual(t, created.ID, updated.ID)
+		}
+	})
+
+	t.Run("hides deleted tasks from users who are not admins", func(t *testing.T) {
+		hub := usecase.NewEventHub()
+		admin, _, _ := hub.Subscribe(adminCtx, "")
+		defer admin.Close()
+		user, _, _ := hub.Subscribe(userCtx, "")
+		defer user.Close()
+
+		publishTaskEvent(t, hub, domain.EventTaskDeleted, "1")
+
+		assert.Equal(t, "Task 1", receive(t, admin).Task.Title)
+		deleted := receive(t, user)
+		assert.Equal(t, "1", deleted.TaskID)
+		assert.Nil(t, deleted.Task)
+	})
+
+	t.Run("drops subscribers that fall behind", func(t *testing.T) {
+		hub := usecase.NewEventHub(usecase.WithSubscriberBuffer(2))
+		slow, _, _ := hub.Subscribe(adminCtx, "")
+		defer slow.Close()
+
+		for _, id := range []string{"1", "2", "3"} {
+			publishTaskEvent(t, hub, domain.EventTaskCreated, id)
+		}
+
+		assert.Equal(t, "1", receive(t, slow).TaskID)
+		last := receive(t, slow)
+		_, ok := <-slow.Events()
+		assert.False(t, ok)
+		assert.True(t, slow.Lagged())
+
+		resumed, backlog, reset := hub.Subscribe(adminCtx, last.ID)
+		defer resumed.Close()
+		assert.False(t, reset)
+		require.Len(t, backlog, 1)
+		assert.Equal(t, "3", backlog[0].TaskID)
+	})
+
+	t.Run("Close ends every subscription", func(t *testing.T) {
+		hub := usecase.NewEventHub()
+		subscription, _, _ := hub.Subscribe(adminCtx, "")
+
+		hub.Close()
+
+		_, ok := <-subscription.Events()
+		assert.False(t, ok)
+		assert.False(t, subscription.Lagged())
+		subscription.Close()
+	})
+
+	t.Run("keeps events within their workspace", func(t *testing.T) {
+		hub := usecase.NewEventHub()
+		team, _, _ := hub.Subscribe(domain.WithWorkspace(userCtx, "team"), "")
+		defer team.Close()
+		everywhere, _, _ := hub.Subscribe(adminCtx, "")
+		defer everywhere.Close()
+		nowhere, _, _ := hub.Subscribe(context.Background(), "")
+		defer nowhere.Close()
+
+		for _, workspaceID := range []string{"other", "team"} {
+			require.NoError(t, hub.Publish(context.Background(), domain.Event{
+				Type: domain.EventTaskCreated,
+				Task: &domain.Task{ID: workspaceID, WorkspaceID: workspaceID},
+			}))
+		}
+
+		event := receive(t, team)
+		assert.Equal(t, "team", event.TaskID)
+		assert.Equal(t, "team", event.Task.WorkspaceID)
+		first := receive(t, everywhere)
+		assert.Equal(t, "other", first.TaskID, "subscribers marked with AllWorkspaces see every workspace")
+		select {
+		case event := <-nowhere.Events():
+			t.Fatalf("unscoped subscriber received %s", event.TaskID)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		resumed, backlog, _ := hub.Subscribe(domain.WithWorkspace(userCtx, "team"), first.ID)
+		resumed.Close()
+		require.Len(t, backlog, 1)
+		assert.Equal(t, "team", backlog[0].TaskID)
+
+		resumed, backlog, _ = hub.Subscribe(domain.WithWorkspace(userCtx, "other"), first.ID)
+		resumed.Close()
+		assert.Empty(t, backlog, "the backlog is filtered too")
+	})
+}
+
+func TestEventHub_Resume(t *testing.T) {
+	hub := usecase.NewEventHub(usecase.WithEventHistory(2))
+	watcher, _, _ := hub.Subscribe(adminCtx, "")
+	defer watcher.Close()
+	for _, id := range []string{"1", "2", "3", "4"} {
+		publishTaskEvent(t, hub, domain.EventTaskCreated, id)
+	}
+	var ids []string
+	for range 4 {
+		ids = append(ids, receive(t, watcher).ID)
+	}
+
+	t.Run("returns the events after the last one seen", func(t *testing.T) {
+		subscription, backlog, reset := hub.Subscribe(userCtx, ids[1])
+		defer subscription.Close()
+
+		assert.False(t, reset)
+		require.Len(t, backlog, 2)
+		assert.Equal(t, "3", backlog[0].TaskID)
+		assert.Equal(t, "4", backlog[1].TaskID)
+	})
+
+	t.Run("nothing to catch up on", func(t *testing.T) {
+		subscription, backlog, reset := hub.Subscribe(userCtx, ids[3])
+		defer subscription.Close()
+
+		assert.False(t, reset)
+		assert.Empty(t, backlog)
+	})
+
+	t.Run("resets when events were lost", func(t *testing.T) {
+		for name, id := range map[string]string{
+			"left the history":     ids[0],
+			"from another process": "deadbeef-2",
+			"malformed":            "yesterday",
+		} {
+			subscription, backlog, reset := hub.Subscribe(userCtx, id)
+			assert.True(t, reset, name)
+			assert.Empty(t, backlog, name)
+			subscription.Close()
+		}
+	})
+}
+
+func TestTaskUseCase_StreamsEvents(t *testing.T) {
+	hub := usecase.NewEventHub()
+	webhooks := newWebhookUseCase(&fakeWebhookSender{})
+	subscription, err := webhooks.CreateWebhook(adminCtx, domain.WebhookRequest{URL: "https://example.com", Events: []string{"*"}})
+	require.NoError(t, err)
+	tasks := usecase.NewTaskUseCase(repository.NewTaskRepositoryMemory(), usecase.WithEvents(webhooks), usecase.WithEvents(hub))
+
+	stream, _, _ := hub.Subscribe(adminCtx, "")
+	defer stream.Close()
+
+	task, err := tasks.CreateTask(adminCtx, domain.CreateTaskRequest{Title: "Live", DueDate: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	assert.Equal(t, task.ID, receive(t, stream).TaskID)
+	assert.Equal(t, []string{domain.EventTaskCreated}, eventTypes(deliveredEvents(t, webhooks, subscription.ID)), "every publisher gets the event")
+}
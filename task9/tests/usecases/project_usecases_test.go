@@ -0,0 +1,241 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+	"task9/domain"
+	"task9/infrastructure"
+	"task9/repository"
+	"task9/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newProjectFixture(t *testing.T) (*usecase.ProjectUseCase, *usecase.TaskUseCase, domain.TaskRepository) {
+	t.Helper()
+	projectRepo := repository.NewProjectRepositoryMemory()
+	taskRepo := repository.NewTaskRepositoryMemory()
+	return usecase.NewProjectUseCase(projectRepo, taskRepo), usecase.NewTaskUseCase(taskRepo, usecase.WithProjects(usecase.NewProjectGuard(projectRepo))), taskRepo
+}
+
+func TestProjectUseCase(t *testing.T) {
+	ctx := domain.WithWorkspace(alice, "team")
+	due := time.Now().Add(24 * time.Hour)
+	archived, active := true, false
+
+	t.Run("projects are owned by their creator and validated", func(t *testing.T) {
+		projects, _, _ := newProjectFixture(t)
+
+		project, err := projects.CreateProject(ctx, domain.ProjectRequest{Name: "  Launch ", Description: "Website launch"})
+		require.NoError(t, err)
+		assert.Equal(t, "Launch", project.Name)
+		assert.Equal(t, "team", project.WorkspaceID)
+		assert.Equal(t, "1", project.OwnerID)
+		assert.Equal(t, "alice", project.Owner)
+		assert.Equal(t, "pending", project.DefaultStatus)
+
+		_, err = projects.CreateProject(ctx, domain.ProjectRequest{Name: " "})
+		assert.EqualError(t, err, "project name is required")
+		_, err = projects.CreateProject(ctx, domain.ProjectRequest{Name: "Ops", DefaultStatus: "done"})
+		var validationErr *domain.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+
+		_, err = projects.GetProject(domain.WithWorkspace(alice, "other"), project.ID)
+		assert.EqualError(t, err, "project not found")
+	})
+
+	t.Run("tasks start with the project's default status", func(t *testing.T) {
+		projects, tasks, _ := newProjectFixture(t)
+		project, err := projects.CreateProject(ctx, domain.ProjectRequest{Name: "Sprint", DefaultStatus: "in_progress"})
+		require.NoError(t, err)
+
+		task, err := tasks.CreateTask(ctx, domain.CreateTaskRequest{Title: "Plan", DueDate: due, ProjectID: project.ID})
+		require.NoError(t, err)
+		assert.Equal(t, project.ID, task.ProjectID)
+		assert.Equal(t, "in_progress", task.Status)
+
+		task, err = tasks.CreateTask(ctx, domain.CreateTaskRequest{Title: "Review", DueDate: due, ProjectID: project.ID, Status: "pending"})
+		require.NoError(t, err)
+		assert.Equal(t, "pending", task.Status)
+
+		_, err = tasks.CreateTask(ctx, domain.CreateTaskRequest{Title: "Lost", DueDate: due, ProjectID: "404"})
+		assert.EqualError(t, err, "project not found")
+	})
+
+	t.Run("tasks of an archived project are read-only", func(t *testing.T) {
+		projects, tasks, _ := newProjectFixture(t)
+		project, err := projects.CreateProject(ctx, domain.ProjectRequest{Name: "Old"})
+		require.NoError(t, err)
+		other, err := projects.CreateProject(ctx, domain.ProjectRequest{Name: "New"})
+		require.NoError(t, err)
+		task, err := tasks.CreateTask(ctx, domain.CreateTaskRequest{Title: "Legacy", DueDate: due, ProjectID: project.ID})
+		require.NoError(t, err)
+		loose, err := tasks.CreateTask(ctx, domain.CreateTaskRequest{Title: "Loose", DueDate: due})
+		require.NoError(t, err)
+
+		project, err = projects.UpdateProject(ctx, project.ID, domain.ProjectRequest{Archived: &archived})
+		require.NoError(t, err)
+		assert.True(t, project.Archived)
+		assert.Equal(t, "Old", project.Name, "omitted fields are kept")
+
+		_, err = tasks.UpdateTask(ctx, task.ID, domain.UpdateTaskRequest{Title: "Changed"})
+		assert.ErrorIs(t, err, domain.ErrProjectArchived)
+		_, err = tasks.UpdateTask(ctx, task.ID, domain.UpdateTaskRequest{ProjectID: other.ID})
+		assert.ErrorIs(t, err, domain.ErrProjectArchived, "tasks cannot be moved out")
+		_, err = tasks.AddChecklistItem(ctx, task.ID, "Step")
+		assert.ErrorIs(t, err, domain.ErrProjectArchived)
+		assert.ErrorIs(t, tasks.DeleteTask(ctx, task.ID), domain.ErrProjectArchived)
+		_, err = tasks.UpdateTask(ctx, loose.ID, domain.UpdateTaskRequest{ProjectID: project.ID})
+		assert.ErrorIs(t, err, domain.ErrProjectArchived, "tasks cannot be moved in")
+		_, err = tasks.CreateTask(ctx, domain.CreateTaskRequest{Title: "New", DueDate: due, ProjectID: project.ID})
+		assert.ErrorIs(t, err, domain.ErrProjectArchived)
+
+		results, err := tasks.ExecuteBatch(ctx, []domain.BatchOperation{
+			{Op: domain.BatchUpdate, ID: task.ID, Update: domain.UpdateTaskRequest{Status: "completed"}},
+			{Op: domain.BatchDelete, ID: task.ID},
+		}, false)
+		require.NoError(t, err)
+		for _, result := range results {
+			assert.ErrorIs(t, result.Error, domain.ErrProjectArchived)
+		}
+
+		_, err = projects.UpdateProject(ctx, project.ID, domain.ProjectRequest{Archived: &active})
+		require.NoError(t, err)
+		updated, err := tasks.UpdateTask(ctx, task.ID, domain.UpdateTaskRequest{ProjectID: other.ID})
+		require.NoError(t, err)
+		assert.Equal(t, other.ID, updated.ProjectID)
+	})
+
+	t.Run("comments, attachments and restores of an archived project are refused", func(t *testing.T) {
+		projectRepo := repository.NewProjectRepositoryMemory()
+		taskRepo := repository.NewTaskRepositoryMemory()
+		attachmentRepo := repository.NewAttachmentRepositoryMemory()
+		blobs := infrastructure.NewLocalBlobStore(t.TempDir())
+		guard := usecase.NewProjectGuard(projectRepo)
+		projects := usecase.NewProjectUseCase(projectRepo, taskRepo)
+		tasks := usecase.NewTaskUseCase(taskRepo, usecase.WithProjects(guard))
+		comments := usecase.NewCommentUseCase(repository.NewCommentRepositoryMemory(), taskRepo, usecase.WithCommentProjects(guard))
+		attachments := usecase.NewAttachmentUseCase(attachmentRepo, taskRepo, blobs,
+			usecase.WithAttachmentLimits(domain.AttachmentLimits{AllowedTypes: []string{"text/plain"}}), usecase.WithAttachmentProjects(guard))
+
+		project, err := projects.CreateProject(ctx, domain.ProjectRequest{Name: "Old"})
+		require.NoError(t, err)
+		task, err := tasks.CreateTask(ctx, domain.CreateTaskRequest{Title: "Legacy", DueDate: due, ProjectID: project.ID})
+		require.NoError(t, err)
+		trashed, err := tasks.CreateTask(ctx, domain.CreateTaskRequest{Title: "Trashed", DueDate: due, ProjectID: project.ID})
+		require.NoError(t, err)
+		require.NoError(t, tasks.DeleteTask(ctx, trashed.ID))
+		comment, err := comments.AddComment(ctx, task.ID, "before")
+		require.NoError(t, err)
+		attachment, err := attachments.UploadAttachment(ctx, task.ID, domain.AttachmentUpload{Filename: "a.txt", Body: strings.NewReader("a")})
+		require.NoError(t, err)
+
+		_, err = projects.UpdateProject(ctx, project.ID, domain.ProjectRequest{Archived: &archived})
+		require.NoError(t, err)
+
+		_, err = comments.AddComment(ctx, task.ID, "after")
+		assert.ErrorIs(t, err, domain.ErrProjectArchived)
+		_, err = comments.EditComment(ctx, task.ID, comment.ID, "edited")
+		assert.ErrorIs(t, err, domain.ErrProjectArchived)
+		assert.ErrorIs(t, comments.DeleteComment(ctx, task.ID, comment.ID), domain.ErrProjectArchived)
+		_, err = attachments.UploadAttachment(ctx, task.ID, domain.AttachmentUpload{Filename: "b.txt", Body: strings.NewReader("b")})
+		assert.ErrorIs(t, err, domain.ErrProjectArchived)
+		assert.ErrorIs(t, attachments.DeleteAttachment(ctx, task.ID, attachment.ID), domain.ErrProjectArchived)
+		_, err = tasks.RestoreTask(ctx, trashed.ID)
+		assert.ErrorIs(t, err, domain.ErrProjectArchived)
+
+		listed, err := comments.ListComments(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Len(t, listed, 1, "reads still work")
+
+		_, err = projects.UpdateProject(ctx, project.ID, domain.ProjectRequest{Archived: &active})
+		require.NoError(t, err)
+		_, err = tasks.RestoreTask(ctx, trashed.ID)
+		assert.NoError(t, err)
+		assert.NoError(t, comments.DeleteComment(ctx, task.ID, comment.ID))
+	})
+
+	t.Run("listing, stats and deletion", func(t *testing.T) {
+		projects, tasks, taskRepo := newProjectFixture(t)
+		project, err := projects.CreateProject(ctx, domain.ProjectRequest{Name: "Beta"})
+		require.NoError(t, err)
+		_, err = projects.CreateProject(ctx, domain.ProjectRequest{Name: "Alpha", Archived: &archived})
+		require.NoError(t, err)
+
+		listed, err := projects.ListProjects(ctx, nil)
+		require.NoError(t, err)
+		require.Len(t, listed, 2)
+		assert.Equal(t, "Alpha", listed[0].Name)
+		listed, err = projects.ListProjects(ctx, &active)
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		assert.Equal(t, "Beta", listed[0].Name)
+
+		for _, req := range []domain.CreateTaskRequest{
+			{Title: "Late", DueDate: time.Now().Add(-time.Hour), Priority: "high"},
+			{Title: "Done late", DueDate: time.Now().Add(-time.Hour), Status: "completed"},
+			{Title: "Soon", DueDate: due},
+		} {
+			req.ProjectID = project.ID
+			_, err := tasks.CreateTask(ctx, req)
+			require.NoError(t, err)
+		}
+		trashed, err := tasks.CreateTask(ctx, domain.CreateTaskRequest{Title: "Trashed", DueDate: due, ProjectID: project.ID})
+		require.NoError(t, err)
+		require.NoError(t, tasks.DeleteTask(ctx, trashed.ID))
+		_, err = tasks.CreateTask(ctx, domain.CreateTaskRequest{Title: "Elsewhere", DueDate: due})
+		require.NoError(t, err)
+
+		found, err := tasks.FindTasks(ctx, domain.TaskFilter{ProjectID: project.ID})
+		require.NoError(t, err)
+		assert.Len(t, found, 3)
+
+		stats, err := projects.ProjectStats(ctx, project.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.ProjectStats{
+			Total:      3,
+			ByStatus:   map[string]int{"pending": 2, "completed": 1},
+			ByPriority: map[string]int{"high": 1, "medium": 2},
+			Overdue:    1,
+		}, stats)
+
+		require.NoError(t, projects.DeleteProject(ctx, project.ID))
+		_, err = projects.ProjectStats(ctx, project.ID)
+		assert.EqualError(t, err, "project not found")
+		all, err := taskRepo.GetAll(domain.AllWorkspaces(context.Background()))
+		require.NoError(t, err)
+		for _, task := range all {
+			assert.Empty(t, task.ProjectID, task.Title)
+		}
+		restored, err := tasks.RestoreTask(ctx, trashed.ID)
+		require.NoError(t, err)
+		assert.Empty(t, restored.ProjectID, "tasks in the trash leave the project too")
+	})
+
+	t.Run("tasks leaving a deleted project record it and publish it", func(t *testing.T) {
+		projectRepo := repository.NewProjectRepositoryMemory()
+		taskRepo := repository.NewTaskRepositoryMemory()
+		hub := usecase.NewEventHub()
+		projects := usecase.NewProjectUseCase(projectRepo, taskRepo, usecase.WithProjectEvents(hub))
+		tasks := usecase.NewTaskUseCase(taskRepo, usecase.WithProjects(usecase.NewProjectGuard(projectRepo)))
+		project, err := projects.CreateProject(ctx, domain.ProjectRequest{Name: "Gone"})
+		require.NoError(t, err)
+		task, err := tasks.CreateTask(ctx, domain.CreateTaskRequest{Title: "Kept", DueDate: due, ProjectID: project.ID})
+		require.NoError(t, err)
+		stream, _, _ := hub.Subscribe(ctx, "")
+		defer stream.Close()
+
+		require.NoError(t, projects.DeleteProject(ctx, project.ID))
+
+		entries, _, err := tasks.GetHistory(ctx, task.ID, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", entries[0].Actor)
+		assert.Equal(t, []domain.FieldChange{{Field: "project_id", Old: project.ID, New: nil}}, entries[0].Changes)
+		event := receive(t, stream)
+		assert.Equal(t, domain.EventTaskUpdated, event.Type)
+		assert.Equal(t, task.ID, event.TaskID)
+	})
+}
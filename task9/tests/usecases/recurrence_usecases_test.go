@@ -0,0 +1,145 @@
+package usecases
+
+import (
+	"context"
+	"task9/domain"
+	"task9/repository"
+	"task9/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskUseCase_Recurrence(t *testing.T) {
+	ctx := team
+	// GenerateOccurrences runs as a job, over every workspace.
+	jobCtx := domain.AllWorkspaces(context.Background())
+	// Monday 1 January 2024, 09:00.
+	due := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("create validates and normalizes the rule", func(t *testing.T) {
+		taskUseCase := usecase.NewTaskUseCase(repository.NewTaskRepositoryMemory())
+
+		task, err := taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "standup", DueDate: due, Recurrence: "rrule:freq=weekly;byday=mo,we"})
+		require.NoError(t, err)
+		require.NotNil(t, task.Recurrence)
+		assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,WE", task.Recurrence.Rule)
+		assert.Equal(t, task.ID, task.Recurrence.SeriesID)
+		assert.Equal(t, due, task.Recurrence.Start)
+
+		var validationErr *domain.ValidationError
+		_, err = taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "standup", DueDate: due, Recurrence: "FREQ=HOURLY"})
+		assert.ErrorAs(t, err, &validationErr)
+		_, err = taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "standup", Recurrence: "FREQ=DAILY"})
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("completing an occurrence creates the next one", func(t *testing.T) {
+		taskUseCase := usecase.NewTaskUseCase(repository.NewTaskRepositoryMemory())
+		first, err := taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "standup", DueDate: due, Priority: domain.PriorityHigh, Recurrence: "FREQ=WEEKLY;BYDAY=MO,WE"})
+		require.NoError(t, err)
+		first, err = taskUseCase.AddChecklistItem(ctx, first.ID, "notes")
+		require.NoError(t, err)
+		_, err = taskUseCase.ToggleChecklistItem(ctx, first.ID, first.Checklist[0].ID)
+		require.NoError(t, err)
+
+		now := due.Add(-time.Hour)
+		created, err := taskUseCase.GenerateOccurrences(jobCtx, now)
+		require.NoError(t, err)
+		assert.Zero(t, created, "open tasks that are not due yet are left alone")
+
+		_, err = taskUseCase.UpdateTask(ctx, first.ID, domain.UpdateTaskRequest{Status: "completed"})
+		require.NoError(t, err)
+		created, err = taskUseCase.GenerateOccurrences(jobCtx, now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, created)
+
+		first, err = taskUseCase.GetTaskByID(ctx, first.ID)
+		require.NoError(t, err)
+		require.NotEmpty(t, first.Recurrence.NextID)
+		next, err := taskUseCase.GetTaskByID(ctx, first.Recurrence.NextID)
+		require.NoError(t, err)
+		assert.Equal(t, "pending", next.Status)
+		assert.Equal(t, domain.PriorityHigh, next.Priority)
+		assert.Equal(t, due.AddDate(0, 0, 2), next.DueDate)
+		assert.Equal(t, first.ID, next.Recurrence.SeriesID)
+		require.Len(t, next.Checklist, 1)
+		assert.False(t, next.Checklist[0].Done)
+
+		created, err = taskUseCase.GenerateOccurrences(jobCtx, now)
+		require.NoError(t, err)
+		assert.Zero(t, created, "an occurrence is only continued once")
+	})
+
+	t.Run("overdue occurrences skip missed dates", func(t *testing.T) {
+		taskUseCase := usecase.NewTaskUseCase(repository.NewTaskRepositoryMemory())
+		first, err := taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "backup", DueDate: due, Recurrence: "FREQ=DAILY"})
+		require.NoError(t, err)
+
+		created, err := taskUseCase.GenerateOccurrences(jobCtx, due.AddDate(0, 0, 3).Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, created)
+
+		first, err = taskUseCase.GetTaskByID(ctx, first.ID)
+		require.NoError(t, err)
+		next, err := taskUseCase.GetTaskByID(ctx, first.Recurrence.NextID)
+		require.NoError(t, err)
+		assert.Equal(t, due.AddDate(0, 0, 4), next.DueDate)
+	})
+
+	t.Run("a series that has run out is stopped", func(t *testing.T) {
+		taskUseCase := usecase.NewTaskUseCase(repository.NewTaskRepositoryMemory())
+		first, err := taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "onboarding", DueDate: due, Recurrence: "FREQ=DAILY;COUNT=2"})
+		require.NoError(t, err)
+
+		created, err := taskUseCase.GenerateOccurrences(jobCtx, due.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, created)
+		first, err = taskUseCase.GetTaskByID(ctx, first.ID)
+		require.NoError(t, err)
+
+		created, err = taskUseCase.GenerateOccurrences(jobCtx, due.AddDate(0, 0, 1).Add(time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, created)
+		last, err := taskUseCase.GetTaskByID(ctx, first.Recurrence.NextID)
+		require.NoError(t, err)
+		assert.Empty(t, last.Recurrence.Rule)
+		assert.Empty(t, last.Recurrence.NextID)
+	})
+
+	t.Run("edit and stop apply to the latest occurrence", func(t *testing.T) {
+		taskUseCase := usecase.NewTaskUseCase(repository.NewTaskRepositoryMemory())
+		first, err := taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "report", DueDate: due})
+		require.NoError(t, err)
+
+		first, err = taskUseCase.SetRecurrence(ctx, first.ID, "FREQ=MONTHLY")
+		require.NoError(t, err)
+		assert.Equal(t, first.ID, first.Recurrence.SeriesID)
+		_, err = taskUseCase.GenerateOccurrences(jobCtx, due.Add(time.Hour))
+		require.NoError(t, err)
+
+		latest, err := taskUseCase.SetRecurrence(ctx, first.ID, "FREQ=MONTHLY;BYDAY=-1FR")
+		require.NoError(t, err)
+		assert.NotEqual(t, first.ID, latest.ID)
+		assert.Equal(t, "FREQ=MONTHLY;BYDAY=-1FR", latest.Recurrence.Rule)
+		assert.Equal(t, due.AddDate(0, 1, 0), latest.Recurrence.Start)
+
+		entries, _, err := taskUseCase.GetHistory(ctx, latest.ID, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.FieldChange{{Field: "recurrence", Old: "FREQ=MONTHLY", New: "FREQ=MONTHLY;BYDAY=-1FR"}}, entries[0].Changes)
+
+		stopped, err := taskUseCase.StopRecurrence(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, latest.ID, stopped.ID)
+		assert.Empty(t, stopped.Recurrence.Rule)
+
+		_, err = taskUseCase.StopRecurrence(ctx, first.ID)
+		var validationErr *domain.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		created, err := taskUseCase.GenerateOccurrences(jobCtx, due.AddDate(1, 0, 0))
+		require.NoError(t, err)
+		assert.Zero(t, created)
+	})
+}
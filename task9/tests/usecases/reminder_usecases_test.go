@@ -0,0 +1,194 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"task9/domain"
+	"task9/repository"
+	"task9/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeNotifier struct {
+	mu   sync.Mutex
+	sent []domain.Reminder
+	err  error
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, reminder domain.Reminder) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, reminder)
+	return nil
+}
+
+func TestReminderUseCase_SendDueReminders(t *testing.T) {
+	ctx := team
+	// SendDueReminders runs as a job, over every workspace.
+	jobCtx := domain.AllWorkspaces(context.Background())
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	newTask := func(t *testing.T, taskRepo domain.TaskRepository, title string, due time.Time, status string) domain.Task {
+		task, err := taskRepo.Create(ctx, domain.Task{Title: title, DueDate: due, Status: status}, domain.HistoryEntry{})
+		require.NoError(t, err)
+		return task
+	}
+
+	t.Run("picks the narrowest window and overdue tasks", func(t *testing.T) {
+		taskRepo := repository.NewTaskRepositoryMemory()
+		soon := newTask(t, taskRepo, "soon", now.Add(30*time.Minute), "pending")
+		tomorrow := newTask(t, taskRepo, "tomorrow", now.Add(20*time.Hour), "pending")
+		late := newTask(t, taskRepo, "late", now.Add(-time.Hour), "in_progress")
+		newTask(t, taskRepo, "later", now.Add(48*time.Hour), "pending")
+		newTask(t, taskRepo, "done", now.Add(-time.Hour), "completed")
+		newTask(t, taskRepo, "undated", time.Time{}, "pending")
+
+		notifier := &fakeNotifier{}
+		reminders := usecase.NewReminderUseCase(taskRepo, repository.NewReminderRepositoryMemory(), notifier)
+
+		sent, err := reminders.SendDueReminders(jobCtx, now)
+		require.NoError(t, err)
+		assert.Equal(t, 3, sent)
+
+		byTask := map[string]domain.Reminder{}
+		for _, reminder := range notifier.sent {
+			byTask[reminder.TaskID] = reminder
+		}
+		assert.Equal(t, domain.ReminderDueSoon, byTask[soon.ID].Kind)
+		assert.Equal(t, time.Hour, byTask[soon.ID].Window)
+		assert.Equal(t, 24*time.Hour, byTask[tomorrow.ID].Window)
+		assert.Equal(t, domain.ReminderOverdue, byTask[late.ID].Kind)
+
+		sent, err = reminders.SendDueReminders(jobCtx, now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Zero(t, sent, "each reminder is sent only once")
+
+		sent, err = reminders.SendDueReminders(jobCtx, now.Add(19*time.Hour+30*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 2, sent, "the first task is now overdue and the second one is in its 1h window")
+		for _, reminder := range notifier.sent[3:] {
+			byTask[reminder.TaskID] = reminder
+		}
+		assert.Equal(t, domain.ReminderOverdue, byTask[soon.ID].Kind)
+		assert.Equal(t, time.Hour, byTask[tomorrow.ID].Window)
+	})
+
+	t.Run("overdue reminders can be turned off", func(t *testing.T) {
+		taskRepo := repository.NewTaskRepositoryMemory()
+		newTask(t, taskRepo, "late", now.Add(-time.Hour), "pending")
+
+		notifier := &fakeNotifier{}
+		reminders := usecase.NewReminderUseCase(taskRepo, repository.NewReminderRepositoryMemory(), notifier, usecase.WithOverdueReminders(false))
+
+		sent, err := reminders.SendDueReminders(jobCtx, now)
+		require.NoError(t, err)
+		assert.Zero(t, sent)
+	})
+
+	t.Run("tasks overdue for longer than the lookback are left alone", func(t *testing.T) {
+		taskRepo := repository.NewTaskRepositoryMemory()
+		newTask(t, taskRepo, "forgotten", now.Add(-48*time.Hour), "pending")
+		recent := newTask(t, taskRepo, "recent", now.Add(-time.Hour), "pending")
+
+		notifier := &fakeNotifier{}
+		reminders := usecase.NewReminderUseCase(taskRepo, repository.NewReminderRepositoryMemory(), notifier)
+		sent, err := reminders.SendDueReminders(jobCtx, now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		assert.Equal(t, recent.ID, notifier.sent[0].TaskID)
+
+		notifier = &fakeNotifier{}
+		reminders = usecase.NewReminderUseCase(taskRepo, repository.NewReminderRepositoryMemory(), notifier, usecase.WithOverdueLookback(72*time.Hour))
+		sent, err = reminders.SendDueReminders(jobCtx, now)
+		require.NoError(t, err)
+		assert.Equal(t, 2, sent)
+	})
+
+	t.Run("failed deliveries are retried", func(t *testing.T) {
+		taskRepo := repository.NewTaskRepositoryMemory()
+		newTask(t, taskRepo, "soon", now.Add(30*time.Minute), "pending")
+
+		notifier := &fakeNotifier{err: errors.New("connection refused")}
+		reminders := usecase.NewReminderUseCase(taskRepo, repository.NewReminderRepositoryMemory(), notifier)
+
+		sent, err := reminders.SendDueReminders(jobCtx, now)
+		assert.ErrorContains(t, err, "connection refused")
+		assert.Zero(t, sent)
+
+		notifier.err = nil
+		sent, err = reminders.SendDueReminders(jobCtx, now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+	})
+
+	t.Run("a reminder is given up after the attempt limit", func(t *testing.T) {
+		taskRepo := repository.NewTaskRepositoryMemory()
+		newTask(t, taskRepo, "soon", now.Add(30*time.Minute), "pending")
+
+		notifier := &fakeNotifier{err: errors.New("connection refused")}
+		reminders := usecase.NewReminderUseCase(taskRepo, repository.NewReminderRepositoryMemory(), notifier, usecase.WithReminderMaxAttempts(2))
+		for i := 0; i < 2; i++ {
+			_, err := reminders.SendDueReminders(jobCtx, now.Add(time.Duration(i)*time.Minute))
+			assert.ErrorContains(t, err, "connection refused")
+		}
+
+		notifier.err = nil
+		sent, err := reminders.SendDueReminders(jobCtx, now.Add(2*time.Minute))
+		require.NoError(t, err)
+		assert.Zero(t, sent)
+		assert.Empty(t, notifier.sent)
+	})
+
+	t.Run("replicas share the deliveries", func(t *testing.T) {
+		taskRepo := repository.NewTaskRepositoryMemory()
+		reminderRepo := repository.NewReminderRepositoryMemory()
+		for i := 0; i < 10; i++ {
+			newTask(t, taskRepo, "soon", now.Add(30*time.Minute), "pending")
+		}
+
+		notifier := &fakeNotifier{}
+		var wg sync.WaitGroup
+		for _, owner := range []string{"a", "b", "c"} {
+			reminders := usecase.NewReminderUseCase(taskRepo, reminderRepo, notifier, usecase.WithReminderOwner(owner))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := reminders.SendDueReminders(jobCtx, now)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		assert.Len(t, notifier.sent, 10)
+	})
+
+	t.Run("an expired lease is taken over", func(t *testing.T) {
+		taskRepo := repository.NewTaskRepositoryMemory()
+		reminderRepo := repository.NewReminderRepositoryMemory()
+		task := newTask(t, taskRepo, "soon", now.Add(30*time.Minute), "pending")
+
+		// A replica that claimed the reminder and died before sending it.
+		claimed, err := reminderRepo.Claim(ctx, domain.Reminder{Key: task.ID + ":due_soon:1h0m0s:" + strconv.FormatInt(task.DueDate.UnixMilli(), 10)}, "crashed", now, 5*time.Minute, 0)
+		require.NoError(t, err)
+		require.True(t, claimed)
+
+		notifier := &fakeNotifier{}
+		reminders := usecase.NewReminderUseCase(taskRepo, reminderRepo, notifier, usecase.WithReminderOwner("survivor"))
+
+		sent, err := reminders.SendDueReminders(jobCtx, now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Zero(t, sent, "the lease is still held")
+
+		sent, err = reminders.SendDueReminders(jobCtx, now.Add(6*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+	})
+}
@@ -0,0 +1,214 @@
+package usecases
+
+import (
+	"task9/domain"
+	"task9/repository"
+	"task9/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTagFixture(t *testing.T) (*usecase.TagUseCase, *usecase.TaskUseCase) {
+	t.Helper()
+	tagRepo := repository.NewTagRepositoryMemory()
+	taskRepo := repository.NewTaskRepositoryMemory()
+	tagUseCase := usecase.NewTagUseCase(tagRepo, taskRepo)
+	for _, name := range []string{"backend", "urgent", "docs"} {
+		_, err := tagUseCase.CreateTag(team, domain.TagRequest{Name: name})
+		require.NoError(t, err)
+	}
+	return tagUseCase, usecase.NewTaskUseCase(taskRepo, usecase.WithTags(tagRepo))
+}
+
+func TestTagUseCase(t *testing.T) {
+	ctx := team
+	due := time.Now().Add(24 * time.Hour)
+
+	t.Run("names are normalized and validated", func(t *testing.T) {
+		tagUseCase, _ := newTagFixture(t)
+
+		tag, err := tagUseCase.CreateTag(ctx, domain.TagRequest{Name: "  Frontend ", Color: "#1E90FF"})
+		require.NoError(t, err)
+		assert.Equal(t, "frontend", tag.Name)
+		assert.Equal(t, "#1e90ff", tag.Color)
+
+		_, err = tagUseCase.CreateTag(ctx, domain.TagRequest{Name: "FRONTEND"})
+		assert.EqualError(t, err, "tag already exists")
+
+		for _, req := range []domain.TagRequest{{Name: " "}, {Name: "a,b"}, {Name: "ok", Color: "blue"}} {
+			_, err = tagUseCase.CreateTag(ctx, req)
+			var validationErr *domain.ValidationError
+			assert.ErrorAs(t, err, &validationErr, "%+v", req)
+		}
+	})
+
+	t.Run("tasks only accept catalogue tags", func(t *testing.T) {
+		_, taskUseCase := newTagFixture(t)
+
+		task, err := taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "API", DueDate: due, Tags: []string{"Backend", "urgent", "backend"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"backend", "urgent"}, task.Tags)
+
+		_, err = taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "API", DueDate: due, Tags: []string{"missing"}})
+		assert.EqualError(t, err, "unknown tag: missing")
+	})
+
+	t.Run("update keeps, replaces or clears tags", func(t *testing.T) {
+		_, taskUseCase := newTagFixture(t)
+		task, err := taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "API", DueDate: due, Tags: []string{"backend"}})
+		require.NoError(t, err)
+
+		task, err = taskUseCase.UpdateTask(ctx, task.ID, domain.UpdateTaskRequest{Title: "API v2"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"backend"}, task.Tags)
+
+		task, err = taskUseCase.UpdateTask(ctx, task.ID, domain.UpdateTaskRequest{Tags: []string{"docs"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"docs"}, task.Tags)
+
+		task, err = taskUseCase.UpdateTask(ctx, task.ID, domain.UpdateTaskRequest{Tags: []string{}})
+		require.NoError(t, err)
+		assert.Empty(t, task.Tags)
+
+		entries, _, err := taskUseCase.GetHistory(ctx, task.ID, 1, 1)
+		require.NoError(t, err)
+		require.Len(t, entries[0].Changes, 1)
+		assert.Equal(t, domain.FieldChange{Field: "tags", Old: []string{"docs"}, New: nil}, entries[0].Changes[0])
+	})
+
+	t.Run("filter by any or all tags", func(t *testing.T) {
+		_, taskUseCase := newTagFixture(t)
+		both, err := taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "both", DueDate: due, Tags: []string{"backend", "urgent"}})
+		require.NoError(t, err)
+		one, err := taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "one", DueDate: due, Tags: []string{"backend"}})
+		require.NoError(t, err)
+		_, err = taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "none", DueDate: due})
+		require.NoError(t, err)
+
+		tasks, err := taskUseCase.FindTasks(ctx, domain.TaskFilter{Tags: []string{"URGENT", "backend"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{both.ID, one.ID}, taskIDs(tasks))
+
+		tasks, err = taskUseCase.FindTasks(ctx, domain.TaskFilter{Tags: []string{"urgent", "backend"}, TagMatch: domain.TagMatchAll})
+		require.NoError(t, err)
+		assert.Equal(t, []string{both.ID}, taskIDs(tasks))
+
+		tasks, err = taskUseCase.FindTasks(ctx, domain.TaskFilter{})
+		require.NoError(t, err)
+		assert.Len(t, tasks, 3)
+
+		_, err = taskUseCase.FindTasks(ctx, domain.TaskFilter{Tags: []string{"docs"}, TagMatch: "some"})
+		var validationErr *domain.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("rename follows through to tasks and counts", func(t *testing.T) {
+		tagUseCase, taskUseCase := newTagFixture(t)
+		renamed, err := taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "a", DueDate: due, Tags: []string{"backend"}})
+		require.NoError(t, err)
+		merged, err := taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "b", DueDate: due, Tags: []string{"backend", "urgent"}})
+		require.NoError(t, err)
+
+		_, err = tagUseCase.UpdateTag(ctx, "backend", domain.TagRequest{Name: "urgent"})
+		assert.EqualError(t, err, "tag already exists")
+
+		tag, err := tagUseCase.UpdateTag(ctx, "backend", domain.TagRequest{Name: "server", Description: "Server work"})
+		require.NoError(t, err)
+		assert.Equal(t, "server", tag.Name)
+		assert.Equal(t, "Server work", tag.Description)
+		assert.Equal(t, 2, tag.UsageCount)
+
+		task, err := taskUseCase.GetTaskByID(ctx, renamed.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"server"}, task.Tags)
+		task, err = taskUseCase.GetTaskByID(ctx, merged.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"server", "urgent"}, task.Tags)
+
+		tags, err := tagUseCase.ListTags(ctx)
+		require.NoError(t, err)
+		counts := map[string]int{}
+		for _, tag := range tags {
+			counts[tag.Name] = tag.UsageCount
+		}
+		assert.Equal(t, map[string]int{"docs": 0, "server": 2, "urgent": 1}, counts)
+	})
+
+	t.Run("rename is recorded in the history of each task and published", func(t *testing.T) {
+		tagRepo := repository.NewTagRepositoryMemory()
+		taskRepo := repository.NewTaskRepositoryMemory()
+		hub := usecase.NewEventHub()
+		tagUseCase := usecase.NewTagUseCase(tagRepo, taskRepo, usecase.WithTagEvents(hub))
+		taskUseCase := usecase.NewTaskUseCase(taskRepo, usecase.WithTags(tagRepo))
+		_, err := tagUseCase.CreateTag(alice, domain.TagRequest{Name: "backend"})
+		require.NoError(t, err)
+		task, err := taskUseCase.CreateTask(alice, domain.CreateTaskRequest{Title: "a", DueDate: due, Tags: []string{"backend"}})
+		require.NoError(t, err)
+		stream, _, _ := hub.Subscribe(alice, "")
+		defer stream.Close()
+
+		_, err = tagUseCase.UpdateTag(alice, "backend", domain.TagRequest{Name: "server"})
+		require.NoError(t, err)
+
+		entries, _, err := taskUseCase.GetHistory(alice, task.ID, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "alice", entries[0].Actor)
+		assert.Equal(t, []domain.FieldChange{{Field: "tags", Old: []string{"backend"}, New: []string{"server"}}}, entries[0].Changes)
+		event := receive(t, stream)
+		assert.Equal(t, domain.EventTaskUpdated, event.Type)
+		assert.Equal(t, []string{"server"}, event.Task.Tags)
+	})
+
+	t.Run("tags of archived projects cannot be renamed or deleted", func(t *testing.T) {
+		tagRepo := repository.NewTagRepositoryMemory()
+		taskRepo := repository.NewTaskRepositoryMemory()
+		projectRepo := repository.NewProjectRepositoryMemory()
+		guard := usecase.NewProjectGuard(projectRepo)
+		tagUseCase := usecase.NewTagUseCase(tagRepo, taskRepo, usecase.WithTagProjects(guard))
+		taskUseCase := usecase.NewTaskUseCase(taskRepo, usecase.WithTags(tagRepo), usecase.WithProjects(guard))
+		projects := usecase.NewProjectUseCase(projectRepo, taskRepo)
+		_, err := tagUseCase.CreateTag(alice, domain.TagRequest{Name: "backend"})
+		require.NoError(t, err)
+		project, err := projects.CreateProject(alice, domain.ProjectRequest{Name: "Old"})
+		require.NoError(t, err)
+		task, err := taskUseCase.CreateTask(alice, domain.CreateTaskRequest{Title: "a", DueDate: due, Tags: []string{"backend"}, ProjectID: project.ID})
+		require.NoError(t, err)
+		archived := true
+		_, err = projects.UpdateProject(alice, project.ID, domain.ProjectRequest{Archived: &archived})
+		require.NoError(t, err)
+
+		_, err = tagUseCase.UpdateTag(alice, "backend", domain.TagRequest{Name: "server"})
+		assert.ErrorIs(t, err, domain.ErrProjectArchived)
+		assert.ErrorIs(t, tagUseCase.DeleteTag(alice, "backend"), domain.ErrProjectArchived)
+
+		task, err = taskUseCase.GetTaskByID(alice, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"backend"}, task.Tags)
+		_, err = tagRepo.GetByName(alice, "backend")
+		assert.NoError(t, err, "the catalogue is left alone")
+	})
+
+	t.Run("delete removes the tag from tasks", func(t *testing.T) {
+		tagUseCase, taskUseCase := newTagFixture(t)
+		task, err := taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "a", DueDate: due, Tags: []string{"docs", "urgent"}})
+		require.NoError(t, err)
+
+		require.NoError(t, tagUseCase.DeleteTag(ctx, "Docs"))
+		assert.EqualError(t, tagUseCase.DeleteTag(ctx, "docs"), "tag not found")
+
+		task, err = taskUseCase.GetTaskByID(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"urgent"}, task.Tags)
+	})
+}
+
+func taskIDs(tasks []domain.Task) []string {
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	return ids
+}
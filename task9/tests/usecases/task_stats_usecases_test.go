@@ -0,0 +1,62 @@
+package usecases
+
+import (
+	"task9/domain"
+	"task9/repository"
+	"task9/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskUseCase_TaskStats(t *testing.T) {
+	ctx := domain.WithWorkspace(alice, "team")
+	today := domain.StartOfDay(time.Now())
+
+	t.Run("defaults to the last 30 days and reads completions from the history", func(t *testing.T) {
+		tasks := usecase.NewTaskUseCase(repository.NewTaskRepositoryMemory())
+		done, err := tasks.CreateTask(ctx, domain.CreateTaskRequest{Title: "Ship", DueDate: time.Now().Add(time.Hour)})
+		require.NoError(t, err)
+		_, err = tasks.UpdateTask(ctx, done.ID, domain.UpdateTaskRequest{Status: "completed"})
+		require.NoError(t, err)
+		_, err = tasks.CreateTask(ctx, domain.CreateTaskRequest{Title: "Late", DueDate: time.Now().Add(-time.Hour), Tags: []string{"Ops"}})
+		require.NoError(t, err)
+		_, err = tasks.CreateTask(domain.WithWorkspace(alice, "other"), domain.CreateTaskRequest{Title: "Elsewhere", DueDate: time.Now()})
+		require.NoError(t, err)
+
+		stats, err := tasks.TaskStats(ctx, domain.TaskFilter{}, time.Time{}, time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, stats.Total)
+		assert.Equal(t, map[string]int{"pending": 1, "completed": 1}, stats.ByStatus)
+		assert.Equal(t, 1, stats.Overdue)
+		require.Len(t, stats.Daily, 30)
+		assert.Equal(t, today.AddDate(0, 0, -29), stats.Daily[0].Day)
+		assert.Equal(t, domain.DailyTaskCount{Day: today, Created: 2, Completed: 1}, stats.Daily[29])
+		assert.Less(t, stats.AverageTimeToComplete, time.Minute)
+
+		stats, err = tasks.TaskStats(ctx, domain.TaskFilter{Tags: []string{"ops"}}, today, today.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, stats.Total, "tag names are matched case-insensitively")
+		assert.Len(t, stats.Daily, 1)
+	})
+
+	t.Run("validates the range and the filter", func(t *testing.T) {
+		tasks := usecase.NewTaskUseCase(repository.NewTaskRepositoryMemory())
+		var validationErr *domain.ValidationError
+
+		_, err := tasks.TaskStats(ctx, domain.TaskFilter{}, today, today.AddDate(0, 0, -1))
+		assert.EqualError(t, err, "from must not be after to")
+		assert.ErrorAs(t, err, &validationErr)
+
+		_, err = tasks.TaskStats(ctx, domain.TaskFilter{}, today.AddDate(0, 0, -366), today)
+		assert.EqualError(t, err, "the range can span at most 366 days")
+		stats, err := tasks.TaskStats(ctx, domain.TaskFilter{}, today.AddDate(0, 0, -365), today)
+		require.NoError(t, err)
+		assert.Len(t, stats.Daily, 366)
+
+		_, err = tasks.TaskStats(ctx, domain.TaskFilter{TagMatch: "some"}, time.Time{}, time.Time{})
+		assert.ErrorAs(t, err, &validationErr)
+	})
+}
@@ -0,0 +1,170 @@
+package usecases
+
+import (
+	"errors"
+	"io"
+	"task9/domain"
+	"task9/repository"
+	"task9/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type importRow struct {
+	req domain.CreateTaskRequest
+	err error
+}
+
+// importRows serves rows as an import source. A row with an error is handed
+// out as is.
+func importRows(rows ...importRow) usecase.ImportSource {
+	return func() (domain.CreateTaskRequest, error) {
+		if len(rows) == 0 {
+			return domain.CreateTaskRequest{}, io.EOF
+		}
+		row := rows[0]
+		rows = rows[1:]
+		return row.req, row.err
+	}
+}
+
+func TestTaskUseCase_ExportTasks(t *testing.T) {
+	ctx := team
+	due := time.Now().Add(24 * time.Hour)
+	tasks := usecase.NewTaskUseCase(repository.NewTaskRepositoryMemory())
+	for _, req := range []domain.CreateTaskRequest{
+		{Title: "A", DueDate: due, Tags: []string{"backend"}},
+		{Title: "B", DueDate: due},
+		{Title: "C", DueDate: due, Tags: []string{"Backend", "docs"}},
+	} {
+		_, err := tasks.CreateTask(ctx, req)
+		require.NoError(t, err)
+	}
+
+	var titles []string
+	err := tasks.ExportTasks(ctx, domain.TaskFilter{Tags: []string{" BACKEND"}}, func(task domain.Task) error {
+		titles = append(titles, task.Title)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A", "C"}, titles, "tags are matched like everywhere else")
+
+	for _, filter := range []domain.TaskFilter{{Sort: domain.SortSmart}, {TagMatch: "some"}} {
+		err = tasks.ExportTasks(ctx, filter, func(domain.Task) error { return nil })
+		var validationErr *domain.ValidationError
+		assert.ErrorAs(t, err, &validationErr, "%+v", filter)
+	}
+}
+
+func TestTaskUseCase_ImportTasks(t *testing.T) {
+	ctx := team
+	due := time.Now().Add(24 * time.Hour)
+
+	t.Run("creates a task per row and reports the rows that fail", func(t *testing.T) {
+		repo := repository.NewTaskRepositoryMemory()
+		tasks := usecase.NewTaskUseCase(repo)
+
+		report, err := tasks.ImportTasks(ctx, importRows(
+			importRow{req: domain.CreateTaskRequest{ExternalID: "a", Title: "A", DueDate: due, Recurrence: "FREQ=WEEKLY"}},
+			importRow{req: domain.CreateTaskRequest{ExternalID: "b", DueDate: due}},
+			importRow{req: domain.CreateTaskRequest{ExternalID: "c"}, err: domain.NewValidationError("bad due_date")},
+			importRow{req: domain.CreateTaskRequest{Title: "D", DueDate: due, Status: "done"}},
+			importRow{req: domain.CreateTaskRequest{ExternalID: "a", Title: "A twice", DueDate: due}},
+			importRow{req: domain.CreateTaskRequest{Title: "F", DueDate: due}},
+		), domain.ImportOptions{})
+
+		require.NoError(t, err)
+		assert.Equal(t, 6, report.Rows)
+		assert.Equal(t, 2, report.Created)
+		assert.Equal(t, 0, report.Updated)
+		require.Len(t, report.Errors, 4)
+		assert.Equal(t, domain.ImportRowError{Row: 2, ExternalID: "b", Err: domain.NewValidationError("title is required")}, report.Errors[0])
+		assert.Equal(t, domain.ImportRowError{Row: 3, ExternalID: "c", Err: domain.NewValidationError("bad due_date")}, report.Errors[1])
+		assert.EqualError(t, report.Errors[2].Err, "invalid status")
+		assert.Equal(t, 5, report.Errors[3].Row)
+		assert.ErrorIs(t, report.Errors[3].Err, domain.ErrExternalIDTaken)
+
+		imported, err := repo.GetByExternalID(ctx, "a")
+		require.NoError(t, err)
+		assert.Equal(t, "A", imported.Title)
+		require.NotNil(t, imported.Recurrence)
+		assert.Equal(t, "FREQ=WEEKLY", imported.Recurrence.Rule)
+		all, err := tasks.GetAllTasks(ctx)
+		require.NoError(t, err)
+		assert.Len(t, all, 2)
+	})
+
+	t.Run("upsert updates the task with the external ID", func(t *testing.T) {
+		repo := repository.NewTaskRepositoryMemory()
+		tasks := usecase.NewTaskUseCase(repo)
+		_, err := tasks.ImportTasks(ctx, importRows(
+			importRow{req: domain.CreateTaskRequest{ExternalID: "a", Title: "A", Description: "kept", DueDate: due}},
+			importRow{req: domain.CreateTaskRequest{ExternalID: "gone", Title: "Gone", DueDate: due}},
+		), domain.ImportOptions{})
+		require.NoError(t, err)
+		gone, err := repo.GetByExternalID(ctx, "gone")
+		require.NoError(t, err)
+		require.NoError(t, tasks.DeleteTask(ctx, gone.ID))
+
+		report, err := tasks.ImportTasks(ctx, importRows(
+			importRow{req: domain.CreateTaskRequest{ExternalID: "a", Title: "A renamed", Status: "in_progress"}},
+			importRow{req: domain.CreateTaskRequest{ExternalID: "b", Title: "B", DueDate: due}},
+			importRow{req: domain.CreateTaskRequest{ExternalID: "gone", Title: "Back"}},
+		), domain.ImportOptions{Upsert: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Updated)
+		require.Len(t, report.Errors, 1)
+		assert.Equal(t, 3, report.Errors[0].Row)
+		assert.EqualError(t, report.Errors[0].Err, "the task with this external_id is in the trash")
+
+		updated, err := repo.GetByExternalID(ctx, "a")
+		require.NoError(t, err)
+		assert.Equal(t, "A renamed", updated.Title)
+		assert.Equal(t, "kept", updated.Description, "empty fields keep their value")
+		assert.Equal(t, "in_progress", updated.Status)
+	})
+
+	t.Run("a dry run checks every row and writes nothing", func(t *testing.T) {
+		repo := repository.NewTaskRepositoryMemory()
+		tasks := usecase.NewTaskUseCase(repo)
+		existing, err := tasks.CreateTask(ctx, domain.CreateTaskRequest{ExternalID: "a", Title: "A", DueDate: due})
+		require.NoError(t, err)
+
+		report, err := tasks.ImportTasks(ctx, importRows(
+			importRow{req: domain.CreateTaskRequest{ExternalID: "a", Title: "A renamed", Priority: "urgent"}},
+			importRow{req: domain.CreateTaskRequest{ExternalID: "a", Priority: "whenever"}},
+			importRow{req: domain.CreateTaskRequest{Title: "B", DueDate: due}},
+		), domain.ImportOptions{DryRun: true, Upsert: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Updated)
+		require.Len(t, report.Errors, 1)
+		assert.Equal(t, 2, report.Errors[0].Row)
+
+		all, err := tasks.GetAllTasks(ctx)
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+		assert.Equal(t, existing.Title, all[0].Title)
+	})
+
+	t.Run("stops when the source fails", func(t *testing.T) {
+		tasks := usecase.NewTaskUseCase(repository.NewTaskRepositoryMemory())
+		broken := errors.New("unexpected end of file")
+
+		report, err := tasks.ImportTasks(ctx, importRows(
+			importRow{req: domain.CreateTaskRequest{Title: "A", DueDate: due}},
+			importRow{err: broken},
+			importRow{req: domain.CreateTaskRequest{Title: "C", DueDate: due}},
+		), domain.ImportOptions{})
+
+		assert.ErrorIs(t, err, broken)
+		assert.Equal(t, 1, report.Rows)
+		assert.Equal(t, 1, report.Created, "rows before the failure stay imported")
+	})
+}
@@ -1,8 +1,10 @@
 package usecases
 
 import (
+	"context"
 	"errors"
 	"task9/domain"
+	"task9/repository"
 	"task9/tests/mocks"
 	"task9/usecase"
 	"testing"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTaskUseCase_GetAllTasks(t *testing.T) {
@@ -22,9 +25,9 @@ func TestTaskUseCase_GetAllTasks(t *testing.T) {
 			{ID: "2", Title: "Task 2", Status: "completed"},
 		}
 
-		mockTaskRepo.On("GetAll").Return(expectedTasks, nil)
+		mockTaskRepo.On("GetAll", mock.Anything).Return(expectedTasks, nil)
 
-		tasks, err := taskUseCase.GetAllTasks()
+		tasks, err := taskUseCase.GetAllTasks(context.Background())
 
 		assert.NoError(t, err)
 		assert.Len(t, tasks, 2)
@@ -33,9 +36,9 @@ func TestTaskUseCase_GetAllTasks(t *testing.T) {
 	})
 
 	t.Run("empty list", func(t *testing.T) {
-		mockTaskRepo.On("GetAll").Return([]domain.Task{}, nil)
+		mockTaskRepo.On("GetAll", mock.Anything).Return([]domain.Task{}, nil)
 
-		tasks, err := taskUseCase.GetAllTasks()
+		tasks, err := taskUseCase.GetAllTasks(context.Background())
 
 		assert.NoError(t, err)
 		assert.Empty(t, tasks)
@@ -43,9 +46,9 @@ func TestTaskUseCase_GetAllTasks(t *testing.T) {
 	})
 
 	t.Run("repository error", func(t *testing.T) {
-		mockTaskRepo.On("GetAll").Return([]domain.Task{}, errors.New("database error"))
+		mockTaskRepo.On("GetAll", mock.Anything).Return([]domain.Task{}, errors.New("database error"))
 
-		_, err := taskUseCase.GetAllTasks()
+		_, err := taskUseCase.GetAllTasks(context.Background())
 
 		assert.Error(t, err)
 		mockTaskRepo.AssertExpectations(t)
@@ -63,9 +66,9 @@ func TestTaskUseCase_GetTaskByID(t *testing.T) {
 			Status: "pending",
 		}
 
-		mockTaskRepo.On("GetByID", "123").Return(expectedTask, nil)
+		mockTaskRepo.On("GetByID", mock.Anything, "123").Return(expectedTask, nil)
 
-		task, err := taskUseCase.GetTaskByID("123")
+		task, err := taskUseCase.GetTaskByID(context.Background(), "123")
 
 		assert.NoError(t, err)
 		assert.Equal(t, "Test Task", task.Title)
@@ -73,9 +76,9 @@ func TestTaskUseCase_GetTaskByID(t *testing.T) {
 	})
 
 	t.Run("task not found", func(t *testing.T) {
-		mockTaskRepo.On("GetByID", "999").Return(domain.Task{}, errors.New("task not found"))
+		mockTaskRepo.On("GetByID", mock.Anything, "999").Return(domain.Task{}, errors.New("task not found"))
 
-		_, err := taskUseCase.GetTaskByID("999")
+		_, err := taskUseCase.GetTaskByID(context.Background(), "999")
 
 		assert.Error(t, err)
 		mockTaskRepo.AssertExpectations(t)
@@ -93,13 +96,13 @@ func TestTaskUseCase_CreateTask(t *testing.T) {
 			DueDate:     time.Now().Add(24 * time.Hour),
 		}
 
-		mockTaskRepo.On("Create", mock.AnythingOfType("domain.Task")).Return(domain.Task{
+		mockTaskRepo.On("Create", mock.Anything, mock.AnythingOfType("domain.Task"), mock.AnythingOfType("domain.HistoryEntry")).Return(domain.Task{
 			ID:     "123",
 			Title:  "New Task",
 			Status: "pending",
 		}, nil)
 
-		task, err := taskUseCase.CreateTask(req)
+		task, err := taskUseCase.CreateTask(context.Background(), req)
 
 		assert.NoError(t, err)
 		assert.Equal(t, "pending", task.Status)
@@ -114,13 +117,13 @@ func TestTaskUseCase_CreateTask(t *testing.T) {
 			Status:      "in_progress",
 		}
 
-		mockTaskRepo.On("Create", mock.AnythingOfType("domain.Task")).Return(domain.Task{
+		mockTaskRepo.On("Create", mock.Anything, mock.AnythingOfType("domain.Task"), mock.AnythingOfType("domain.HistoryEntry")).Return(domain.Task{
 			ID:     "123",
 			Title:  "New Task",
 			Status: "in_progress",
 		}, nil)
 
-		task, err := taskUseCase.CreateTask(req)
+		task, err := taskUseCase.CreateTask(context.Background(), req)
 
 		assert.NoError(t, err)
 		assert.Equal(t, "in_progress", task.Status)
@@ -135,7 +138,7 @@ func TestTaskUseCase_CreateTask(t *testing.T) {
 			Status:      "invalid_status",
 		}
 
-		_, err := taskUseCase.CreateTask(req)
+		_, err := taskUseCase.CreateTask(context.Background(), req)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid status")
@@ -158,14 +161,14 @@ func TestTaskUseCase_UpdateTask(t *testing.T) {
 			Status: "completed",
 		}
 
-		mockTaskRepo.On("GetByID", "123").Return(existingTask, nil)
-		mockTaskRepo.On("Update", "123", mock.AnythingOfType("domain.Task")).Return(domain.Task{
+		mockTaskRepo.On("GetByID", mock.Anything, "123").Return(existingTask, nil)
+		mockTaskRepo.On("Update", mock.Anything, "123", mock.AnythingOfType("domain.Task"), mock.AnythingOfType("domain.HistoryEntry")).Return(domain.Task{
 			ID:     "123",
 			Title:  "New Title",
 			Status: "completed",
 		}, nil)
 
-		task, err := taskUseCase.UpdateTask("123", req)
+		task, err := taskUseCase.UpdateTask(context.Background(), "123", req)
 
 		assert.NoError(t, err)
 		assert.Equal(t, "New Title", task.Title)
@@ -178,9 +181,9 @@ func TestTaskUseCase_UpdateTask(t *testing.T) {
 			Title: "New Title",
 		}
 
-		mockTaskRepo.On("GetByID", "999").Return(domain.Task{}, errors.New("task not found"))
+		mockTaskRepo.On("GetByID", mock.Anything, "999").Return(domain.Task{}, errors.New("task not found"))
 
-		_, err := taskUseCase.UpdateTask("999", req)
+		_, err := taskUseCase.UpdateTask(context.Background(), "999", req)
 
 		assert.Error(t, err)
 		mockTaskRepo.AssertExpectations(t)
@@ -197,9 +200,9 @@ func TestTaskUseCase_UpdateTask(t *testing.T) {
 			Status: "invalid_status",
 		}
 
-		mockTaskRepo.On("GetByID", "123").Return(existingTask, nil)
+		mockTaskRepo.On("GetByID", mock.Anything, "123").Return(existingTask, nil)
 
-		_, err := taskUseCase.UpdateTask("123", req)
+		_, err := taskUseCase.UpdateTask(context.Background(), "123", req)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid status")
@@ -211,21 +214,732 @@ func TestTaskUseCase_DeleteTask(t *testing.T) {
 	taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
 
 	t.Run("successful deletion", func(t *testing.T) {
-		mockTaskRepo.On("Delete", "123").Return(nil)
+		mockTaskRepo.On("Delete", mock.Anything, "123", mock.MatchedBy(func(entry domain.HistoryEntry) bool {
+			return entry.Action == domain.HistoryDelete && entry.Actor == "admin" && entry.ActorID == "1"
+		})).Return(nil)
 
-		err := taskUseCase.DeleteTask("123")
+		ctx := domain.WithActor(context.Background(), domain.Actor{UserID: "1", Username: "admin", Role: "admin"})
+		err := taskUseCase.DeleteTask(ctx, "123")
 
 		assert.NoError(t, err)
 		mockTaskRepo.AssertExpectations(t)
 	})
 
 	t.Run("task not found", func(t *testing.T) {
-		mockTaskRepo.On("Delete", "999").Return(errors.New("task not found"))
+		mockTaskRepo.On("Delete", mock.Anything, "999", mock.AnythingOfType("domain.HistoryEntry")).Return(errors.New("task not found"))
 
-		err := taskUseCase.DeleteTask("999")
+		err := taskUseCase.DeleteTask(context.Background(), "999")
 
 		assert.Error(t, err)
 		mockTaskRepo.AssertExpectations(t)
 	})
 }
 
+func TestTaskUseCase_Trash(t *testing.T) {
+	t.Run("restore", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("Restore", mock.Anything, "123", mock.AnythingOfType("domain.HistoryEntry")).Return(domain.Task{ID: "123", Status: "pending"}, nil)
+
+		task, err := taskUseCase.RestoreTask(context.Background(), "123")
+
+		assert.NoError(t, err)
+		assert.True(t, task.DeletedAt.IsZero())
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("purge expired uses the retention cutoff", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		retention := 48 * time.Hour
+		mockTaskRepo.On("PurgeDeleted", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+			return time.Since(before.Add(retention)) < time.Minute
+		})).Return([]string{"1", "2", "3"}, nil)
+
+		purged, err := taskUseCase.PurgeExpired(context.Background(), retention)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), purged)
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("batch delete records the actor", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("BulkWrite", mock.Anything, mock.MatchedBy(func(writes []domain.TaskWrite) bool {
+			return len(writes) == 1 && writes[0].History.Actor == "admin"
+		})).Return([]error{nil}, nil)
+
+		ctx := domain.WithActor(context.Background(), domain.Actor{Username: "admin"})
+		_, err := taskUseCase.ExecuteBatch(ctx, []domain.BatchOperation{{Op: domain.BatchDelete, ID: "1"}}, false)
+
+		assert.NoError(t, err)
+		mockTaskRepo.AssertExpectations(t)
+	})
+}
+
+func TestTaskUseCase_History(t *testing.T) {
+	t.Run("update records the field diff and actor", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		existingTask := domain.Task{ID: "123", Title: "Old Title", Status: "pending"}
+		mockTaskRepo.On("GetByID", mock.Anything, "123").Return(existingTask, nil)
+		mockTaskRepo.On("Update", mock.Anything, "123", mock.AnythingOfType("domain.Task"), mock.MatchedBy(func(entry domain.HistoryEntry) bool {
+			return entry.Action == domain.HistoryUpdate && entry.Actor == "alice" &&
+				assert.ObjectsAreEqual([]domain.FieldChange{{Field: "status", Old: "pending", New: "completed"}}, entry.Changes)
+		})).Return(domain.Task{ID: "123", Status: "completed"}, nil)
+
+		ctx := domain.WithActor(context.Background(), domain.Actor{UserID: "7", Username: "alice"})
+		_, err := taskUseCase.UpdateTask(ctx, "123", domain.UpdateTaskRequest{Title: "Old Title", Status: "completed"})
+
+		assert.NoError(t, err)
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("update retries after a conflict", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("GetByID", mock.Anything, "123").Return(domain.Task{ID: "123", Status: "pending"}, nil).Twice()
+		mockTaskRepo.On("Update", mock.Anything, "123", mock.Anything, mock.Anything).Return(domain.Task{}, domain.ErrConflict).Once()
+		mockTaskRepo.On("Update", mock.Anything, "123", mock.Anything, mock.Anything).Return(domain.Task{ID: "123", Status: "completed"}, nil).Once()
+
+		task, err := taskUseCase.UpdateTask(context.Background(), "123", domain.UpdateTaskRequest{Status: "completed"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "completed", task.Status)
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("update gives up after repeated conflicts", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("GetByID", mock.Anything, "123").Return(domain.Task{ID: "123", Status: "pending"}, nil)
+		mockTaskRepo.On("Update", mock.Anything, "123", mock.Anything, mock.Anything).Return(domain.Task{}, domain.ErrConflict)
+
+		_, err := taskUseCase.UpdateTask(context.Background(), "123", domain.UpdateTaskRequest{Status: "completed"})
+
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		mockTaskRepo.AssertNumberOfCalls(t, "Update", 3)
+	})
+
+	t.Run("create records every set field", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("Create", mock.Anything, mock.AnythingOfType("domain.Task"), mock.MatchedBy(func(entry domain.HistoryEntry) bool {
+			fields := map[string]bool{}
+			for _, change := range entry.Changes {
+				fields[change.Field] = change.Old == nil
+			}
+			return entry.Action == domain.HistoryCreate &&
+				assert.ObjectsAreEqual(map[string]bool{"title": true, "due_date": true, "status": true, "priority": true}, fields)
+		})).Return(domain.Task{ID: "123"}, nil)
+
+		_, err := taskUseCase.CreateTask(context.Background(), domain.CreateTaskRequest{Title: "Task", DueDate: time.Now()})
+
+		assert.NoError(t, err)
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("history pages are translated to offsets", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("GetHistory", mock.Anything, "123", 20, 10).Return([]domain.HistoryEntry{{Action: domain.HistoryUpdate}}, 21, nil)
+
+		entries, total, err := taskUseCase.GetHistory(context.Background(), "123", 3, 10)
+
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, 21, total)
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid page size", func(t *testing.T) {
+		taskUseCase := usecase.NewTaskUseCase(new(mocks.MockTaskRepository))
+
+		_, _, err := taskUseCase.GetHistory(context.Background(), "123", 1, 500)
+
+		var validationErr *domain.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+}
+
+func TestTaskUseCase_PropagatesContext(t *testing.T) {
+	mockTaskRepo := new(mocks.MockTaskRepository)
+	taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	sameCtx := mock.MatchedBy(func(c context.Context) bool {
+		return c.Value(ctxKey{}) == "request"
+	})
+
+	mockTaskRepo.On("GetByID", sameCtx, "123").Return(domain.Task{ID: "123", Status: "pending"}, nil)
+	mockTaskRepo.On("Update", sameCtx, "123", mock.AnythingOfType("domain.Task"), mock.AnythingOfType("domain.HistoryEntry")).Return(domain.Task{ID: "123"}, nil)
+
+	_, err := taskUseCase.UpdateTask(ctx, "123", domain.UpdateTaskRequest{Title: "New Title"})
+
+	assert.NoError(t, err)
+	mockTaskRepo.AssertExpectations(t)
+}
+
+func TestTaskUseCase_ExecuteBatch(t *testing.T) {
+	dueDate := time.Now().Add(24 * time.Hour)
+
+	t.Run("rejects batches over the size limit", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo, usecase.WithTaskLimits(domain.TaskLimits{MaxBatchSize: 1}))
+
+		ops := []domain.BatchOperation{
+			{Op: domain.BatchDelete, ID: "1"},
+			{Op: domain.BatchDelete, ID: "2"},
+		}
+		_, err := taskUseCase.ExecuteBatch(context.Background(), ops, false)
+
+		var validationErr *domain.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+		mockTaskRepo.AssertNotCalled(t, "BulkWrite", mock.Anything, mock.Anything)
+	})
+
+	t.Run("reports per-item errors", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("BulkWrite", mock.Anything, mock.AnythingOfType("[]domain.TaskWrite")).
+			Run(func(args mock.Arguments) {
+				writes := args.Get(1).([]domain.TaskWrite)
+				writes[0].ID = "new-id"
+				writes[0].Task.ID = "new-id"
+			}).
+			Return([]error{nil, errors.New("task not found")}, nil)
+
+		ops := []domain.BatchOperation{
+			{Op: domain.BatchCreate, Create: domain.CreateTaskRequest{Title: "Task", DueDate: dueDate}},
+			{Op: domain.BatchCreate, Create: domain.CreateTaskRequest{DueDate: dueDate}},
+			{Op: domain.BatchDelete, ID: "999"},
+		}
+		results, err := taskUseCase.ExecuteBatch(context.Background(), ops, false)
+
+		assert.NoError(t, err)
+		assert.Len(t, results, 3)
+		assert.NoError(t, results[0].Error)
+		assert.Equal(t, "new-id", results[0].ID)
+		assert.Equal(t, "pending", results[0].Task.Status)
+		assert.Error(t, results[1].Error)
+		assert.EqualError(t, results[2].Error, "task not found")
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("atomic batch with an invalid operation is not written", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		ops := []domain.BatchOperation{
+			{Op: domain.BatchDelete, ID: "1"},
+			{Op: domain.BatchUpdate, ID: "2", Update: domain.UpdateTaskRequest{Status: "invalid"}},
+		}
+		results, err := taskUseCase.ExecuteBatch(context.Background(), ops, true)
+
+		assert.ErrorIs(t, err, domain.ErrBatchAborted)
+		assert.ErrorIs(t, results[0].Error, domain.ErrBatchAborted)
+		assert.EqualError(t, results[1].Error, "invalid status")
+		mockTaskRepo.AssertNotCalled(t, "BulkWriteAtomic", mock.Anything, mock.Anything)
+	})
+
+	t.Run("atomic batch rolled back by the repository", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("BulkWriteAtomic", mock.Anything, mock.AnythingOfType("[]domain.TaskWrite")).
+			Return([]error{nil, errors.New("task not found")}, domain.ErrBatchAborted)
+
+		ops := []domain.BatchOperation{
+			{Op: domain.BatchCreate, Create: domain.CreateTaskRequest{Title: "Task", DueDate: dueDate}},
+			{Op: domain.BatchDelete, ID: "999"},
+		}
+		results, err := taskUseCase.ExecuteBatch(context.Background(), ops, true)
+
+		assert.ErrorIs(t, err, domain.ErrBatchAborted)
+		assert.Nil(t, results[0].Task)
+		assert.ErrorIs(t, results[0].Error, domain.ErrBatchAborted)
+		assert.EqualError(t, results[1].Error, "task not found")
+		mockTaskRepo.AssertExpectations(t)
+	})
+}
+
+func TestTaskUseCase_Subtasks(t *testing.T) {
+	t.Run("completing a task with open items is blocked by default", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{
+			ID:       "1",
+			Status:   "in_progress",
+			Subtasks: domain.SubtaskCount{Total: 2, Completed: 1},
+		}, nil)
+
+		_, err := taskUseCase.UpdateTask(context.Background(), "1", domain.UpdateTaskRequest{Status: "completed"})
+
+		assert.ErrorIs(t, err, domain.ErrOpenSubtasks)
+		mockTaskRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("cascade completes subtasks and checks off the checklist", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo, usecase.WithCompletionPolicy(domain.CompletionCascade))
+
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{
+			ID:        "1",
+			Status:    "in_progress",
+			Checklist: []domain.ChecklistItem{{ID: "a", Text: "Draft"}},
+			Subtasks:  domain.SubtaskCount{Total: 2, Completed: 1},
+		}, nil).Once()
+		mockTaskRepo.On("GetSubtasks", mock.Anything, []string{"1"}).Return([]domain.Task{
+			{ID: "2", ParentID: "1", Status: "completed"},
+			{ID: "3", ParentID: "1", Status: "pending"},
+		}, nil)
+		mockTaskRepo.On("GetByID", mock.Anything, "3").Return(domain.Task{ID: "3", ParentID: "1", Status: "pending"}, nil)
+		mockTaskRepo.On("Update", mock.Anything, "3", mock.AnythingOfType("domain.Task"), mock.AnythingOfType("domain.HistoryEntry")).
+			Return(domain.Task{ID: "3", Status: "completed"}, nil).Once()
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{
+			ID:        "1",
+			Status:    "in_progress",
+			Checklist: []domain.ChecklistItem{{ID: "a", Text: "Draft"}},
+			Subtasks:  domain.SubtaskCount{Total: 2, Completed: 2},
+		}, nil)
+		mockTaskRepo.On("Update", mock.Anything, "1", mock.MatchedBy(func(task domain.Task) bool {
+			return task.Status == "completed" && len(task.Checklist) == 1 && task.Checklist[0].Done
+		}), mock.AnythingOfType("domain.HistoryEntry")).Return(domain.Task{ID: "1", Status: "completed"}, nil).Once()
+
+		task, err := taskUseCase.UpdateTask(context.Background(), "1", domain.UpdateTaskRequest{Status: "completed"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "completed", task.Status)
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("cascade completes subtasks before the task, the deepest first", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo, usecase.WithCompletionPolicy(domain.CompletionCascade))
+
+		var written []string
+		record := func(args mock.Arguments) { written = append(written, args.String(1)) }
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{
+			ID:       "1",
+			Status:   "in_progress",
+			Subtasks: domain.SubtaskCount{Total: 1},
+		}, nil).Once()
+		mockTaskRepo.On("GetSubtasks", mock.Anything, []string{"1"}).Return([]domain.Task{
+			{ID: "2", ParentID: "1", Status: "pending", Subtasks: domain.SubtaskCount{Total: 1}},
+		}, nil)
+		mockTaskRepo.On("GetSubtasks", mock.Anything, []string{"2"}).Return([]domain.Task{
+			{ID: "3", ParentID: "2", Status: "pending"},
+		}, nil)
+		mockTaskRepo.On("GetByID", mock.Anything, "3").Return(domain.Task{ID: "3", ParentID: "2", Status: "pending"}, nil)
+		mockTaskRepo.On("GetByID", mock.Anything, "2").Return(domain.Task{ID: "2", ParentID: "1", Status: "pending", Subtasks: domain.SubtaskCount{Total: 1, Completed: 1}}, nil)
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{
+			ID:       "1",
+			Status:   "in_progress",
+			Subtasks: domain.SubtaskCount{Total: 1, Completed: 1},
+		}, nil)
+		for _, id := range []string{"1", "2", "3"} {
+			mockTaskRepo.On("Update", mock.Anything, id, mock.AnythingOfType("domain.Task"), mock.AnythingOfType("domain.HistoryEntry")).
+				Run(record).Return(domain.Task{ID: id, Status: "completed"}, nil).Once()
+		}
+
+		_, err := taskUseCase.UpdateTask(context.Background(), "1", domain.UpdateTaskRequest{Status: "completed"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"3", "2", "1"}, written)
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("a failed subtask write leaves the task open", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo, usecase.WithCompletionPolicy(domain.CompletionCascade))
+
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{
+			ID:       "1",
+			Status:   "in_progress",
+			Subtasks: domain.SubtaskCount{Total: 1},
+		}, nil)
+		mockTaskRepo.On("GetSubtasks", mock.Anything, []string{"1"}).Return([]domain.Task{
+			{ID: "2", ParentID: "1", Status: "pending"},
+		}, nil)
+		mockTaskRepo.On("GetByID", mock.Anything, "2").Return(domain.Task{ID: "2", ParentID: "1", Status: "pending"}, nil)
+		mockTaskRepo.On("Update", mock.Anything, "2", mock.AnythingOfType("domain.Task"), mock.AnythingOfType("domain.HistoryEntry")).
+			Return(domain.Task{}, domain.ErrConflict)
+
+		_, err := taskUseCase.UpdateTask(context.Background(), "1", domain.UpdateTaskRequest{Status: "completed"})
+
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		mockTaskRepo.AssertNotCalled(t, "Update", mock.Anything, "1", mock.Anything, mock.Anything)
+	})
+
+	t.Run("completing a completed task again closes what was left open", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo, usecase.WithCompletionPolicy(domain.CompletionCascade))
+
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{
+			ID:       "1",
+			Status:   "completed",
+			Subtasks: domain.SubtaskCount{Total: 1},
+		}, nil).Once()
+		mockTaskRepo.On("GetSubtasks", mock.Anything, []string{"1"}).Return([]domain.Task{
+			{ID: "2", ParentID: "1", Status: "pending"},
+		}, nil)
+		mockTaskRepo.On("GetByID", mock.Anything, "2").Return(domain.Task{ID: "2", ParentID: "1", Status: "pending"}, nil)
+		mockTaskRepo.On("Update", mock.Anything, "2", mock.AnythingOfType("domain.Task"), mock.AnythingOfType("domain.HistoryEntry")).
+			Return(domain.Task{ID: "2", Status: "completed"}, nil).Once()
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{
+			ID:       "1",
+			Status:   "completed",
+			Subtasks: domain.SubtaskCount{Total: 1, Completed: 1},
+		}, nil)
+		mockTaskRepo.On("Update", mock.Anything, "1", mock.AnythingOfType("domain.Task"), mock.AnythingOfType("domain.HistoryEntry")).
+			Return(domain.Task{ID: "1", Status: "completed"}, nil).Once()
+
+		_, err := taskUseCase.UpdateTask(context.Background(), "1", domain.UpdateTaskRequest{Status: "completed"})
+
+		assert.NoError(t, err)
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("cascade to a blocked subtask is refused before anything is written", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo, usecase.WithCompletionPolicy(domain.CompletionCascade))
+
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{
+			ID:       "1",
+			Status:   "in_progress",
+			Subtasks: domain.SubtaskCount{Total: 1},
+		}, nil)
+		mockTaskRepo.On("GetSubtasks", mock.Anything, []string{"1"}).Return([]domain.Task{
+			{ID: "2", ParentID: "1", Status: "pending", BlockedBy: []string{"9"}},
+		}, nil)
+		mockTaskRepo.On("GetByIDs", mock.Anything, []string{"9"}).Return([]domain.Task{{ID: "9", Status: "pending"}}, nil)
+
+		_, err := taskUseCase.UpdateTask(context.Background(), "1", domain.UpdateTaskRequest{Status: "completed"})
+
+		assert.ErrorIs(t, err, domain.ErrBlocked)
+		mockTaskRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("a task cannot become its own descendant", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{ID: "1"}, nil)
+		mockTaskRepo.On("GetByID", mock.Anything, "2").Return(domain.Task{ID: "2", ParentID: "1"}, nil)
+
+		_, err := taskUseCase.UpdateTask(context.Background(), "1", domain.UpdateTaskRequest{ParentID: "2"})
+
+		var validationErr *domain.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("missing parent is a validation error", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("GetByID", mock.Anything, "9").Return(domain.Task{}, errors.New("task not found"))
+
+		_, err := taskUseCase.CreateTask(context.Background(), domain.CreateTaskRequest{Title: "Task", DueDate: time.Now(), ParentID: "9"})
+
+		assert.EqualError(t, err, "parent task not found")
+		mockTaskRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestTaskUseCase_Checklist(t *testing.T) {
+	checklist := []domain.ChecklistItem{{ID: "a", Text: "Draft"}, {ID: "b", Text: "Review"}}
+
+	t.Run("add appends an item and records the change", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{ID: "1", Checklist: checklist}, nil)
+		mockTaskRepo.On("Update", mock.Anything, "1", mock.MatchedBy(func(task domain.Task) bool {
+			return len(task.Checklist) == 3 && task.Checklist[2].Text == "Ship" && task.Checklist[2].ID != ""
+		}), mock.MatchedBy(func(entry domain.HistoryEntry) bool {
+			return len(entry.Changes) == 1 && entry.Changes[0].Field == "checklist"
+		})).Return(domain.Task{ID: "1"}, nil)
+
+		_, err := taskUseCase.AddChecklistItem(context.Background(), "1", "  Ship ")
+
+		assert.NoError(t, err)
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("add respects the item limit", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo, usecase.WithTaskLimits(domain.TaskLimits{MaxChecklistItems: 2}))
+
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{ID: "1", Checklist: checklist}, nil)
+
+		_, err := taskUseCase.AddChecklistItem(context.Background(), "1", "Ship")
+
+		var validationErr *domain.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("toggle leaves the stored task untouched until written", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{ID: "1", Checklist: checklist}, nil)
+		mockTaskRepo.On("Update", mock.Anything, "1", mock.MatchedBy(func(task domain.Task) bool {
+			return task.Checklist[1].Done
+		}), mock.MatchedBy(func(entry domain.HistoryEntry) bool {
+			old := entry.Changes[0].Old.([]domain.ChecklistItem)
+			return !old[1].Done
+		})).Return(domain.Task{ID: "1"}, nil)
+
+		_, err := taskUseCase.ToggleChecklistItem(context.Background(), "1", "b")
+
+		assert.NoError(t, err)
+		assert.False(t, checklist[1].Done)
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown item", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{ID: "1", Checklist: checklist}, nil)
+
+		_, err := taskUseCase.RemoveChecklistItem(context.Background(), "1", "zz")
+
+		assert.EqualError(t, err, "checklist item not found")
+	})
+
+	t.Run("reorder must list every item once", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{ID: "1", Checklist: checklist}, nil)
+		mockTaskRepo.On("Update", mock.Anything, "1", mock.MatchedBy(func(task domain.Task) bool {
+			return task.Checklist[0].ID == "b" && task.Checklist[1].ID == "a"
+		}), mock.AnythingOfType("domain.HistoryEntry")).Return(domain.Task{ID: "1"}, nil)
+
+		_, err := taskUseCase.ReorderChecklist(context.Background(), "1", []string{"a", "a"})
+		var validationErr *domain.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+
+		_, err = taskUseCase.ReorderChecklist(context.Background(), "1", []string{"b", "a"})
+		assert.NoError(t, err)
+		mockTaskRepo.AssertExpectations(t)
+	})
+}
+
+func TestTaskUseCase_Dependencies(t *testing.T) {
+	t.Run("rejects a link that closes a cycle", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		// 1 is blocked by 2, which is blocked by 3. Making 3 wait on 1 closes the loop.
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{ID: "1", BlockedBy: []string{"2"}}, nil)
+		mockTaskRepo.On("GetByID", mock.Anything, "3").Return(domain.Task{ID: "3"}, nil)
+		mockTaskRepo.On("GetByIDs", mock.Anything, []string{"1"}).Return([]domain.Task{{ID: "1", BlockedBy: []string{"2"}}}, nil)
+		mockTaskRepo.On("GetByIDs", mock.Anything, []string{"2"}).Return([]domain.Task{{ID: "2", BlockedBy: []string{"3"}}}, nil)
+
+		_, err := taskUseCase.AddDependency(context.Background(), "3", "1")
+
+		assert.EqualError(t, err, "dependency would create a cycle")
+		mockTaskRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("adds a link and records it", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("GetByID", mock.Anything, "1").Return(domain.Task{ID: "1"}, nil)
+		mockTaskRepo.On("GetByID", mock.Anything, "2").Return(domain.Task{ID: "2"}, nil)
+		mockTaskRepo.On("GetByIDs", mock.Anything, []string{"1"}).Return([]domain.Task{{ID: "1"}}, nil)
+		mockTaskRepo.On("Update", mock.Anything, "2", mock.MatchedBy(func(task domain.Task) bool {
+			return assert.ObjectsAreEqual([]string{"1"}, task.BlockedBy)
+		}), mock.MatchedBy(func(entry domain.HistoryEntry) bool {
+			return len(entry.Changes) == 1 && entry.Changes[0].Field == "blocked_by"
+		})).Return(domain.Task{ID: "2", BlockedBy: []string{"1"}}, nil)
+
+		task, err := taskUseCase.AddDependency(context.Background(), "2", "1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1"}, task.BlockedBy)
+		mockTaskRepo.AssertExpectations(t)
+	})
+
+	t.Run("a task cannot block itself", func(t *testing.T) {
+		taskUseCase := usecase.NewTaskUseCase(new(mocks.MockTaskRepository))
+
+		_, err := taskUseCase.AddDependency(context.Background(), "1", "1")
+
+		var validationErr *domain.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("unfinished blockers keep a task from starting", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("GetByID", mock.Anything, "2").Return(domain.Task{ID: "2", Status: "pending", BlockedBy: []string{"1"}}, nil)
+		mockTaskRepo.On("GetByIDs", mock.Anything, []string{"1"}).Return([]domain.Task{{ID: "1", Status: "in_progress"}}, nil)
+
+		_, err := taskUseCase.UpdateTask(context.Background(), "2", domain.UpdateTaskRequest{Status: "in_progress"})
+
+		assert.ErrorIs(t, err, domain.ErrBlocked)
+	})
+
+	t.Run("batch updates respect blockers", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		mockTaskRepo.On("GetByID", mock.Anything, "2").Return(domain.Task{ID: "2", Status: "pending", BlockedBy: []string{"1"}}, nil)
+		mockTaskRepo.On("GetByIDs", mock.Anything, []string{"1"}).Return([]domain.Task{{ID: "1", Status: "pending"}}, nil)
+
+		results, err := taskUseCase.ExecuteBatch(context.Background(), []domain.BatchOperation{
+			{Op: domain.BatchUpdate, ID: "2", Update: domain.UpdateTaskRequest{Status: "completed"}},
+		}, false)
+
+		assert.NoError(t, err)
+		assert.ErrorIs(t, results[0].Error, domain.ErrBlocked)
+		mockTaskRepo.AssertNotCalled(t, "BulkWrite", mock.Anything, mock.Anything)
+	})
+
+	t.Run("graph lists blockers and dependents as trees", func(t *testing.T) {
+		mockTaskRepo := new(mocks.MockTaskRepository)
+		taskUseCase := usecase.NewTaskUseCase(mockTaskRepo)
+
+		// 1 <- 2 <- 3: task 2 is blocked by 1 and blocks 3.
+		mockTaskRepo.On("GetByID", mock.Anything, "2").Return(domain.Task{ID: "2", BlockedBy: []string{"1"}}, nil)
+		mockTaskRepo.On("GetByIDs", mock.Anything, []string{"1"}).Return([]domain.Task{{ID: "1"}}, nil)
+		mockTaskRepo.On("GetBlockedBy", mock.Anything, []string{"2"}).Return([]domain.Task{{ID: "3", BlockedBy: []string{"2"}}}, nil)
+		mockTaskRepo.On("GetBlockedBy", mock.Anything, []string{"3"}).Return([]domain.Task{}, nil)
+
+		graph, err := taskUseCase.GetDependencyGraph(context.Background(), "2")
+
+		assert.NoError(t, err)
+		assert.Len(t, graph.Upstream, 1)
+		assert.Equal(t, "1", graph.Upstream[0].Task.ID)
+		assert.Len(t, graph.Downstream, 1)
+		assert.Equal(t, "3", graph.Downstream[0].Task.ID)
+		assert.Empty(t, graph.Downstream[0].Children)
+	})
+
+	t.Run("a link is taken out again when a concurrent one closes a cycle", func(t *testing.T) {
+		repo := &interleavedTaskRepository{TaskRepository: repository.NewTaskRepositoryMemory()}
+		taskUseCase := usecase.NewTaskUseCase(repo)
+		a, err := taskUseCase.CreateTask(team, domain.CreateTaskRequest{Title: "A", DueDate: time.Now().Add(time.Hour)})
+		require.NoError(t, err)
+		b, err := taskUseCase.CreateTask(team, domain.CreateTaskRequest{Title: "B", DueDate: time.Now().Add(time.Hour)})
+		require.NoError(t, err)
+		// B→A lands after A→B has been checked but before it is written.
+		repo.beforeUpdate = func() {
+			_, err := usecase.NewTaskUseCase(repo.TaskRepository).AddDependency(team, b.ID, a.ID)
+			require.NoError(t, err)
+		}
+
+		_, err = taskUseCase.AddDependency(team, a.ID, b.ID)
+
+		assert.EqualError(t, err, "dependency would create a cycle")
+		got, err := taskUseCase.GetTaskByID(team, a.ID)
+		require.NoError(t, err)
+		assert.Empty(t, got.BlockedBy)
+		got, err = taskUseCase.GetTaskByID(team, b.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{a.ID}, got.BlockedBy)
+	})
+
+	t.Run("tasks shared by several paths are expanded once", func(t *testing.T) {
+		taskUseCase := usecase.NewTaskUseCase(repository.NewTaskRepositoryMemory())
+		ids := map[string]string{}
+		for _, title := range []string{"root", "base", "left", "right", "top"} {
+			task, err := taskUseCase.CreateTask(team, domain.CreateTaskRequest{Title: title, DueDate: time.Now().Add(time.Hour)})
+			require.NoError(t, err)
+			ids[title] = task.ID
+		}
+		// top waits on left and right, which both wait on base, which waits
+		// on root.
+		for _, link := range [][2]string{{"top", "left"}, {"top", "right"}, {"left", "base"}, {"right", "base"}, {"base", "root"}} {
+			_, err := taskUseCase.AddDependency(team, ids[link[0]], ids[link[1]])
+			require.NoError(t, err)
+		}
+
+		graph, err := taskUseCase.GetDependencyGraph(team, ids["top"])
+
+		require.NoError(t, err)
+		require.Len(t, graph.Upstream, 2)
+		require.Len(t, graph.Upstream[0].Children, 1)
+		assert.Equal(t, ids["base"], graph.Upstream[0].Children[0].Task.ID)
+		require.Len(t, graph.Upstream[0].Children[0].Children, 1)
+		assert.Equal(t, ids["root"], graph.Upstream[0].Children[0].Children[0].Task.ID)
+		require.Len(t, graph.Upstream[1].Children, 1)
+		assert.Equal(t, ids["base"], graph.Upstream[1].Children[0].Task.ID)
+		assert.Empty(t, graph.Upstream[1].Children[0].Children)
+	})
+}
+
+// interleavedTaskRepository runs beforeUpdate, once, ahead of the first
+// Update, standing in for a request that writes in between.
+type interleavedTaskRepository struct {
+	domain.TaskRepository
+	beforeUpdate func()
+}
+
+func (r *interleavedTaskRepository) Update(ctx context.Context, id string, task domain.Task, entry domain.HistoryEntry) (domain.Task, error) {
+	if before := r.beforeUpdate; before != nil {
+		r.beforeUpdate = nil
+		before()
+	}
+	return r.TaskRepository.Update(ctx, id, task, entry)
+}
+
+func TestTaskUseCase_Priority(t *testing.T) {
+	ctx := team
+
+	t.Run("defaults to medium and rejects unknown levels", func(t *testing.T) {
+		taskUseCase := usecase.NewTaskUseCase(repository.NewTaskRepositoryMemory())
+
+		task, err := taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "A", DueDate: time.Now()})
+		require.NoError(t, err)
+		assert.Equal(t, domain.PriorityMedium, task.Priority)
+
+		_, err = taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "A", DueDate: time.Now(), Priority: "critical"})
+		var validationErr *domain.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+
+		_, err = taskUseCase.UpdateTask(ctx, task.ID, domain.UpdateTaskRequest{Priority: "critical"})
+		assert.ErrorAs(t, err, &validationErr)
+
+		task, err = taskUseCase.UpdateTask(ctx, task.ID, domain.UpdateTaskRequest{Priority: domain.PriorityUrgent})
+		require.NoError(t, err)
+		assert.Equal(t, domain.PriorityUrgent, task.Priority)
+		entries, _, err := taskUseCase.GetHistory(ctx, task.ID, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.FieldChange{{Field: "priority", Old: domain.PriorityMedium, New: domain.PriorityUrgent}}, entries[0].Changes)
+	})
+
+	t.Run("smart sort puts the most pressing task first", func(t *testing.T) {
+		taskUseCase := usecase.NewTaskUseCase(repository.NewTaskRepositoryMemory())
+		_, err := taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "later", DueDate: time.Now().Add(30 * 24 * time.Hour), Priority: domain.PriorityLow})
+		require.NoError(t, err)
+		urgent, err := taskUseCase.CreateTask(ctx, domain.CreateTaskRequest{Title: "now", DueDate: time.Now().Add(time.Hour), Priority: domain.PriorityUrgent})
+		require.NoError(t, err)
+
+		tasks, err := taskUseCase.FindTasks(ctx, domain.TaskFilter{Sort: domain.SortSmart})
+		require.NoError(t, err)
+		require.Len(t, tasks, 2)
+		assert.Equal(t, urgent.ID, tasks[0].ID)
+
+		_, err = taskUseCase.FindTasks(ctx, domain.TaskFilter{Sort: "title"})
+		var validationErr *domain.ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+}
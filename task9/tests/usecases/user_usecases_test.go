@@ -1,7 +1,9 @@
 package usecases
 
 import (
+	"context"
 	"errors"
+	"task9/config"
 	"task9/domain"
 	"task9/infrastructure"
 	"task9/tests/mocks"
@@ -14,8 +16,8 @@ import (
 
 func TestAuthUseCase_Register(t *testing.T) {
 	mockUserRepo := new(mocks.MockUserRepository)
-	passwordHasher := infrastructure.NewBcryptHasher()
-	tokenGenerator := infrastructure.NewJWTGenerator()
+	passwordHasher := infrastructure.NewBcryptHasher(config.Default().Password.BcryptCost)
+	tokenGenerator := infrastructure.NewJWTGenerator(config.Default().Auth)
 
 	authUseCase := usecase.NewAuthUseCase(mockUserRepo, passwordHasher, tokenGenerator)
 
@@ -25,14 +27,14 @@ func TestAuthUseCase_Register(t *testing.T) {
 			Password: "password123",
 		}
 
-		mockUserRepo.On("IsFirstUser").Return(true, nil)
-		mockUserRepo.On("Create", mock.AnythingOfType("domain.User")).Return(domain.User{
+		mockUserRepo.On("IsFirstUser", mock.Anything).Return(true, nil)
+		mockUserRepo.On("Create", mock.Anything, mock.AnythingOfType("domain.User")).Return(domain.User{
 			ID:       "123",
 			Username: "firstuser",
 			Role:     "admin",
 		}, nil)
 
-		user, err := authUseCase.Register(req)
+		user, err := authUseCase.Register(context.Background(), req)
 
 		assert.NoError(t, err)
 		assert.Equal(t, "admin", user.Role)
@@ -46,14 +48,14 @@ func TestAuthUseCase_Register(t *testing.T) {
 			Password: "password123",
 		}
 
-		mockUserRepo.On("IsFirstUser").Return(false, nil)
-		mockUserRepo.On("Create", mock.AnythingOfType("domain.User")).Return(domain.User{
+		mockUserRepo.On("IsFirstUser", mock.Anything).Return(false, nil)
+		mockUserRepo.On("Create", mock.Anything, mock.AnythingOfType("domain.User")).Return(domain.User{
 			ID:       "456",
 			Username: "regularuser",
 			Role:     "user",
 		}, nil)
 
-		user, err := authUseCase.Register(req)
+		user, err := authUseCase.Register(context.Background(), req)
 
 		assert.NoError(t, err)
 		assert.Equal(t, "user", user.Role)
@@ -66,7 +68,7 @@ func TestAuthUseCase_Register(t *testing.T) {
 			Password: "short",
 		}
 
-		_, err := authUseCase.Register(req)
+		_, err := authUseCase.Register(context.Background(), req)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "password must be at least 6 characters")
@@ -78,10 +80,10 @@ func TestAuthUseCase_Register(t *testing.T) {
 			Password: "password123",
 		}
 
-		mockUserRepo.On("IsFirstUser").Return(false, nil)
-		mockUserRepo.On("Create", mock.AnythingOfType("domain.User")).Return(domain.User{}, errors.New("username already exists"))
+		mockUserRepo.On("IsFirstUser", mock.Anything).Return(false, nil)
+		mockUserRepo.On("Create", mock.Anything, mock.AnythingOfType("domain.User")).Return(domain.User{}, errors.New("username already exists"))
 
-		_, err := authUseCase.Register(req)
+		_, err := authUseCase.Register(context.Background(), req)
 
 		assert.Error(t, err)
 		mockUserRepo.AssertExpectations(t)
@@ -90,8 +92,8 @@ func TestAuthUseCase_Register(t *testing.T) {
 
 func TestAuthUseCase_Login(t *testing.T) {
 	mockUserRepo := new(mocks.MockUserRepository)
-	passwordHasher := infrastructure.NewBcryptHasher()
-	tokenGenerator := infrastructure.NewJWTGenerator()
+	passwordHasher := infrastructure.NewBcryptHasher(config.Default().Password.BcryptCost)
+	tokenGenerator := infrastructure.NewJWTGenerator(config.Default().Auth)
 
 	authUseCase := usecase.NewAuthUseCase(mockUserRepo, passwordHasher, tokenGenerator)
 
@@ -103,14 +105,14 @@ func TestAuthUseCase_Login(t *testing.T) {
 
 		hashedPassword, _ := passwordHasher.Hash("password123")
 
-		mockUserRepo.On("GetByUsername", "testuser").Return(domain.User{
+		mockUserRepo.On("GetByUsername", mock.Anything, "testuser").Return(domain.User{
 			ID:       "123",
 			Username: "testuser",
 			Password: hashedPassword,
 			Role:     "user",
 		}, nil)
 
-		token, user, err := authUseCase.Login(req)
+		token, user, err := authUseCase.Login(context.Background(), req)
 
 		assert.NoError(t, err)
 		assert.NotEmpty(t, token)
@@ -125,9 +127,9 @@ func TestAuthUseCase_Login(t *testing.T) {
 			Password: "password123",
 		}
 
-		mockUserRepo.On("GetByUsername", "nonexistent").Return(domain.User{}, errors.New("user not found"))
+		mockUserRepo.On("GetByUsername", mock.Anything, "nonexistent").Return(domain.User{}, errors.New("user not found"))
 
-		_, _, err := authUseCase.Login(req)
+		_, _, err := authUseCase.Login(context.Background(), req)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid credentials")
@@ -142,14 +144,14 @@ func TestAuthUseCase_Login(t *testing.T) {
 
 		hashedPassword, _ := passwordHasher.Hash("correctpassword")
 
-		mockUserRepo.On("GetByUsername", "testuser").Return(domain.User{
+		mockUserRepo.On("GetByUsername", mock.Anything, "testuser").Return(domain.User{
 			ID:       "123",
 			Username: "testuser",
 			Password: hashedPassword,
 			Role:     "user",
 		}, nil)
 
-		_, _, err := authUseCase.Login(req)
+		_, _, err := authUseCase.Login(context.Background(), req)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid credentials")
@@ -159,27 +161,49 @@ func TestAuthUseCase_Login(t *testing.T) {
 
 func TestAuthUseCase_PromoteUser(t *testing.T) {
 	mockUserRepo := new(mocks.MockUserRepository)
-	passwordHasher := infrastructure.NewBcryptHasher()
-	tokenGenerator := infrastructure.NewJWTGenerator()
+	passwordHasher := infrastructure.NewBcryptHasher(config.Default().Password.BcryptCost)
+	tokenGenerator := infrastructure.NewJWTGenerator(config.Default().Auth)
 
 	authUseCase := usecase.NewAuthUseCase(mockUserRepo, passwordHasher, tokenGenerator)
 
 	t.Run("successful promotion", func(t *testing.T) {
-		mockUserRepo.On("UpdateRole", "testuser", "admin").Return(nil)
+		mockUserRepo.On("UpdateRole", mock.Anything, "testuser", "admin").Return(nil)
 
-		err := authUseCase.PromoteUser("testuser")
+		err := authUseCase.PromoteUser(context.Background(), "testuser")
 
 		assert.NoError(t, err)
 		mockUserRepo.AssertExpectations(t)
 	})
 
 	t.Run("user not found", func(t *testing.T) {
-		mockUserRepo.On("UpdateRole", "nonexistent", "admin").Return(errors.New("user not found"))
+		mockUserRepo.On("UpdateRole", mock.Anything, "nonexistent", "admin").Return(errors.New("user not found"))
 
-		err := authUseCase.PromoteUser("nonexistent")
+		err := authUseCase.PromoteUser(context.Background(), "nonexistent")
 
 		assert.Error(t, err)
 		mockUserRepo.AssertExpectations(t)
 	})
 }
 
+func TestAuthUseCase_IssueStreamToken(t *testing.T) {
+	tokenGenerator := infrastructure.NewJWTGenerator(config.Default().Auth)
+	authUseCase := usecase.NewAuthUseCase(new(mocks.MockUserRepository), infrastructure.NewBcryptHasher(config.Default().Password.BcryptCost), tokenGenerator)
+
+	t.Run("for the logged-in user", func(t *testing.T) {
+		ctx := domain.WithActor(context.Background(), domain.Actor{UserID: "1", Username: "alice", Role: "admin"})
+
+		token, _, err := authUseCase.IssueStreamToken(ctx)
+
+		assert.NoError(t, err)
+		claims, err := tokenGenerator.ValidateStream(token)
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", claims["username"])
+		assert.Equal(t, "admin", claims["role"])
+	})
+
+	t.Run("without a user", func(t *testing.T) {
+		_, _, err := authUseCase.IssueStreamToken(context.Background())
+
+		assert.Error(t, err)
+	})
+}
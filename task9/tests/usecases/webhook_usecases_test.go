@@ -0,0 +1,332 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"task9/domain"
+	"task9/infrastructure"
+	"task9/repository"
+	"task9/tests/mocks"
+	"task9/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWebhookSender struct {
+	mu     sync.Mutex
+	sent   []domain.WebhookDelivery
+	status int
+	err    error
+}
+
+func (f *fakeWebhookSender) Send(ctx context.Context, subscription domain.WebhookSubscription, delivery domain.WebhookDelivery) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, delivery)
+	if f.err != nil {
+		return f.status, f.err
+	}
+	return 200, nil
+}
+
+func newWebhookUseCase(sender domain.WebhookSender, opts ...usecase.WebhookUseCaseOption) *usecase.WebhookUseCase {
+	return usecase.NewWebhookUseCase(repository.NewWebhookRepositoryMemory(), repository.NewWebhookDeliveryRepositoryMemory(), sender, opts...)
+}
+
+// deliveredEvents decodes the payloads queued for a webhook, oldest first.
+func deliveredEvents(t *testing.T, webhooks *usecase.WebhookUseCase, id string) []map[string]interface{} {
+	deliveries, _, err := webhooks.ListDeliveries(context.Background(), id, "", 1, 100)
+	require.NoError(t, err)
+
+	events := make([]map[string]interface{}, 0, len(deliveries))
+	for i := len(deliveries) - 1; i >= 0; i-- {
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal(deliveries[i].Payload, &event))
+		assert.Equal(t, deliveries[i].EventType, event["type"])
+		events = append(events, event)
+	}
+	return events
+}
+
+func eventTypes(events []map[string]interface{}) []string {
+	types := make([]string, 0, len(events))
+	for _, event := range events {
+		types = append(types, event["type"].(string))
+	}
+	return types
+}
+
+func TestWebhookUseCase_CreateWebhook(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("generates a secret and defaults to active", func(t *testing.T) {
+		webhooks := newWebhookUseCase(&fakeWebhookSender{})
+
+		subscription, err := webhooks.CreateWebhook(ctx, domain.WebhookRequest{
+			URL:    "https://example.com/hooks",
+			Events: []string{"task.created", " task.created ", "user.registered"},
+		})
+
+		require.NoError(t, err)
+		assert.True(t, subscription.Active)
+		assert.Len(t, subscription.Secret, 64)
+		assert.Equal(t, []string{"task.created", "user.registered"}, subscription.Events)
+	})
+
+	t.Run("validates the request", func(t *testing.T) {
+		webhooks := newWebhookUseCase(&fakeWebhookSender{})
+		requests := map[string]domain.WebhookRequest{
+			"missing url":    {Events: []string{"*"}},
+			"missing events": {URL: "https://example.com"},
+			"empty events":   {URL: "https://example.com", Events: []string{}},
+			"relative url":   {URL: "/hooks", Events: []string{"*"}},
+			"ftp url":        {URL: "ftp://example.com", Events: []string{"*"}},
+			"unknown event":  {URL: "https://example.com", Events: []string{"task.archived"}},
+			"short secret":   {URL: "https://example.com", Events: []string{"*"}, Secret: "short"},
+		}
+		for name, req := range requests {
+			_, err := webhooks.CreateWebhook(ctx, req)
+			var validationErr *domain.ValidationError
+			assert.ErrorAs(t, err, &validationErr, name)
+		}
+	})
+
+	t.Run("update keeps omitted fields", func(t *testing.T) {
+		webhooks := newWebhookUseCase(&fakeWebhookSender{})
+		created, err := webhooks.CreateWebhook(ctx, domain.WebhookRequest{URL: "https://example.com", Events: []string{"*"}, Secret: "0123456789abcdef"})
+		require.NoError(t, err)
+
+		inactive := false
+		updated, err := webhooks.UpdateWebhook(ctx, created.ID, domain.WebhookRequest{Active: &inactive})
+
+		require.NoError(t, err)
+		assert.False(t, updated.Active)
+		assert.Equal(t, created.URL, updated.URL)
+		assert.Equal(t, created.Events, updated.Events)
+		assert.Equal(t, "0123456789abcdef", updated.Secret)
+	})
+}
+
+func TestWebhookUseCase_TaskEvents(t *testing.T) {
+	ctx := domain.WithActor(team, domain.Actor{UserID: "7", Username: "alice"})
+	webhooks := newWebhookUseCase(&fakeWebhookSender{})
+	all, err := webhooks.CreateWebhook(ctx, domain.WebhookRequest{URL: "https://example.com/all", Events: []string{"*"}})
+	require.NoError(t, err)
+	completions, err := webhooks.CreateWebhook(ctx, domain.WebhookRequest{URL: "https://example.com/done", Events: []string{domain.EventTaskCompleted}})
+	require.NoError(t, err)
+
+	tasks := usecase.NewTaskUseCase(repository.NewTaskRepositoryMemory(), usecase.WithEvents(webhooks))
+
+	task, err := tasks.CreateTask(ctx, domain.CreateTaskRequest{Title: "Write report", DueDate: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	_, err = tasks.UpdateTask(ctx, task.ID, domain.UpdateTaskRequest{Status: "completed"})
+	require.NoError(t, err)
+	_, err = tasks.UpdateTask(ctx, task.ID, domain.UpdateTaskRequest{Status: "completed"})
+	require.NoError(t, err, "an update that changes nothing publishes nothing")
+	require.NoError(t, tasks.DeleteTask(ctx, task.ID))
+	_, err = tasks.RestoreTask(ctx, task.ID)
+	require.NoError(t, err)
+	_, err = tasks.ExecuteBatch(ctx, []domain.BatchOperation{
+		{Op: domain.BatchCreate, Create: domain.CreateTaskRequest{Title: "Batch", DueDate: time.Now().Add(time.Hour)}},
+		{Op: domain.BatchUpdate, ID: task.ID, Update: domain.UpdateTaskRequest{Title: "Final report"}},
+	}, false)
+	require.NoError(t, err)
+
+	events := deliveredEvents(t, webhooks, all.ID)
+	assert.Equal(t, []string{
+		domain.EventTaskCreated,
+		domain.EventTaskUpdated,
+		domain.EventTaskCompleted,
+		domain.EventTaskDeleted,
+		domain.EventTaskRestored,
+		domain.EventTaskCreated,
+		domain.EventTaskUpdated,
+	}, eventTypes(events))
+
+	created := events[0]
+	assert.NotEmpty(t, created["id"])
+	assert.Equal(t, map[string]interface{}{"id": "7", "username": "alice"}, created["actor"])
+	assert.Equal(t, "Write report", created["data"].(map[string]interface{})["task"].(map[string]interface{})["title"])
+
+	changes := events[1]["data"].(map[string]interface{})["changes"]
+	assert.Equal(t, []interface{}{map[string]interface{}{"field": "status", "old": "pending", "new": "completed"}}, changes)
+	renamed := events[6]["data"].(map[string]interface{})["changes"]
+	assert.Equal(t, []interface{}{map[string]interface{}{"field": "title", "old": "Write report", "new": "Final report"}}, renamed)
+
+	assert.Equal(t, []string{domain.EventTaskCompleted}, eventTypes(deliveredEvents(t, webhooks, completions.ID)))
+}
+
+func TestWebhookUseCase_UserEvents(t *testing.T) {
+	ctx := context.Background()
+	webhooks := newWebhookUseCase(&fakeWebhookSender{})
+	subscription, err := webhooks.CreateWebhook(ctx, domain.WebhookRequest{URL: "https://example.com", Events: []string{domain.EventUserRegistered, domain.EventUserPromoted}})
+	require.NoError(t, err)
+
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockUserRepo.On("IsFirstUser", mock.Anything).Return(false, nil)
+	mockUserRepo.On("Create", mock.Anything, mock.AnythingOfType("domain.User")).Return(domain.User{ID: "42", Username: "bob", Password: "hash", Role: "user"}, nil)
+	mockUserRepo.On("UpdateRole", mock.Anything, "bob", "admin").Return(nil)
+	mockUserRepo.On("GetByUsername", mock.Anything, "bob").Return(domain.User{ID: "42", Username: "bob", Password: "hash", Role: "admin"}, nil)
+
+	auth := usecase.NewAuthUseCase(mockUserRepo, infrastructure.NewBcryptHasher(4), nil, usecase.WithUserEvents(webhooks))
+	_, err = auth.Register(ctx, domain.RegisterRequest{Username: "bob", Password: "password123"})
+	require.NoError(t, err)
+	require.NoError(t, auth.PromoteUser(ctx, "bob"))
+
+	events := deliveredEvents(t, webhooks, subscription.ID)
+	require.Equal(t, []string{domain.EventUserRegistered, domain.EventUserPromoted}, eventTypes(events))
+	assert.Equal(t, map[string]interface{}{"id": "42", "username": "bob", "role": "user"}, events[0]["data"].(map[string]interface{})["user"])
+	assert.Equal(t, "admin", events[1]["data"].(map[string]interface{})["user"].(map[string]interface{})["role"])
+}
+
+func TestWebhookUseCase_DeliverPending(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	publish := func(t *testing.T, webhooks *usecase.WebhookUseCase, events ...string) domain.WebhookSubscription {
+		subscription, err := webhooks.CreateWebhook(ctx, domain.WebhookRequest{URL: "https://example.com", Events: []string{"*"}})
+		require.NoError(t, err)
+		for _, eventType := range events {
+			event := domain.Event{ID: eventType, Type: eventType, OccurredAt: now}
+			require.NoError(t, webhooks.Publish(ctx, event))
+		}
+		return subscription
+	}
+
+	t.Run("sends each delivery once", func(t *testing.T) {
+		sender := &fakeWebhookSender{}
+		webhooks := newWebhookUseCase(sender)
+		subscription := publish(t, webhooks, domain.EventTaskCreated, domain.EventTaskDeleted)
+
+		delivered, err := webhooks.DeliverPending(ctx, now)
+		require.NoError(t, err)
+		assert.Equal(t, 2, delivered)
+		assert.Len(t, sender.sent, 2)
+		assert.Equal(t, 1, sender.sent[0].Attempts)
+
+		delivered, err = webhooks.DeliverPending(ctx, now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, delivered)
+
+		deliveries, total, err := webhooks.ListDeliveries(ctx, subscription.ID, domain.WebhookSucceeded, 1, 20)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		assert.False(t, deliveries[0].DeliveredAt.IsZero())
+	})
+
+	t.Run("the wait stops growing at a day", func(t *testing.T) {
+		sender := &fakeWebhookSender{status: 503, err: errors.New("webhook answered 503 Service Unavailable")}
+		webhooks := newWebhookUseCase(sender, usecase.WithWebhookRetries(40, time.Hour))
+		subscription := publish(t, webhooks, domain.EventTaskCreated)
+
+		at := now
+		for attempt := 1; attempt < 40; attempt++ {
+			_, err := webhooks.DeliverPending(ctx, at)
+			require.NoError(t, err)
+			deliveries, _, err := webhooks.ListDeliveries(ctx, subscription.ID, domain.WebhookPending, 1, 20)
+			require.NoError(t, err)
+			require.Len(t, deliveries, 1)
+			want := 24 * time.Hour
+			if attempt <= 5 {
+				want = time.Hour << (attempt - 1)
+			}
+			assert.Equal(t, want, deliveries[0].NextAttemptAt.Sub(at), "attempt %d", attempt)
+			at = deliveries[0].NextAttemptAt
+		}
+	})
+
+	t.Run("backs off and gives up after the last attempt", func(t *testing.T) {
+		sender := &fakeWebhookSender{status: 503, err: errors.New("webhook answered 503 Service Unavailable")}
+		webhooks := newWebhookUseCase(sender, usecase.WithWebhookRetries(3, time.Minute))
+		subscription := publish(t, webhooks, domain.EventTaskCreated)
+
+		_, err := webhooks.DeliverPending(ctx, now)
+		require.NoError(t, err)
+		deliveries, _, err := webhooks.ListDeliveries(ctx, subscription.ID, "", 1, 20)
+		require.NoError(t, err)
+		delivery := deliveries[0]
+		assert.Equal(t, domain.WebhookPending, delivery.Status)
+		assert.Equal(t, 503, delivery.LastStatusCode)
+		assert.Equal(t, "webhook answered 503 Service Unavailable", delivery.LastError)
+		assert.Equal(t, now.Add(time.Minute), delivery.NextAttemptAt)
+
+		_, err = webhooks.DeliverPending(ctx, now.Add(30*time.Second))
+		require.NoError(t, err)
+		assert.Len(t, sender.sent, 1, "not due before the backoff")
+
+		_, err = webhooks.DeliverPending(ctx, now.Add(time.Minute))
+		require.NoError(t, err)
+		deliveries, _, err = webhooks.ListDeliveries(ctx, subscription.ID, "", 1, 20)
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(3*time.Minute), deliveries[0].NextAttemptAt, "the wait doubles")
+
+		_, err = webhooks.DeliverPending(ctx, now.Add(3*time.Minute))
+		require.NoError(t, err)
+		deliveries, _, err = webhooks.ListDeliveries(ctx, subscription.ID, domain.WebhookDead, 1, 20)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, 3, deliveries[0].Attempts)
+
+		_, err = webhooks.DeliverPending(ctx, now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Len(t, sender.sent, 3)
+
+		sender.err = nil
+		retried, err := webhooks.RetryDelivery(ctx, subscription.ID, deliveries[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.WebhookPending, retried.Status)
+		assert.Zero(t, retried.Attempts)
+
+		delivered, err := webhooks.DeliverPending(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+
+		_, err = webhooks.RetryDelivery(ctx, subscription.ID, deliveries[0].ID)
+		var validationErr *domain.ValidationError
+		assert.ErrorAs(t, err, &validationErr, "only dead deliveries can be retried")
+	})
+
+	t.Run("inactive and deleted webhooks make deliveries dead", func(t *testing.T) {
+		sender := &fakeWebhookSender{}
+		webhooks := newWebhookUseCase(sender)
+		paused := publish(t, webhooks, domain.EventTaskCreated)
+		inactive := false
+		_, err := webhooks.UpdateWebhook(ctx, paused.ID, domain.WebhookRequest{Active: &inactive})
+		require.NoError(t, err)
+
+		delivered, err := webhooks.DeliverPending(ctx, now)
+		require.NoError(t, err)
+		assert.Zero(t, delivered)
+		assert.Empty(t, sender.sent)
+
+		deliveries, _, err := webhooks.ListDeliveries(ctx, paused.ID, domain.WebhookDead, 1, 20)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, "webhook is inactive", deliveries[0].LastError)
+		assert.Zero(t, deliveries[0].Attempts)
+
+		require.NoError(t, webhooks.DeleteWebhook(ctx, paused.ID))
+		_, _, err = webhooks.ListDeliveries(ctx, paused.ID, "", 1, 20)
+		assert.EqualError(t, err, "webhook not found")
+	})
+
+	t.Run("inactive webhooks receive nothing new", func(t *testing.T) {
+		webhooks := newWebhookUseCase(&fakeWebhookSender{})
+		subscription := publish(t, webhooks)
+		inactive := false
+		_, err := webhooks.UpdateWebhook(ctx, subscription.ID, domain.WebhookRequest{Active: &inactive})
+		require.NoError(t, err)
+
+		require.NoError(t, webhooks.Publish(ctx, domain.Event{ID: "1", Type: domain.EventTaskCreated, OccurredAt: now}))
+
+		_, total, err := webhooks.ListDeliveries(ctx, subscription.ID, "", 1, 20)
+		require.NoError(t, err)
+		assert.Zero(t, total)
+	})
+}
@@ -0,0 +1,168 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"task9/domain"
+	"task9/repository"
+	"task9/tests/mocks"
+	"task9/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// inWorkspace gives ctx the role its actor has in the workspace, as
+// RequireWorkspace does for requests.
+func inWorkspace(t *testing.T, repo domain.WorkspaceRepository, ctx context.Context, workspaceID string) context.Context {
+	t.Helper()
+	actor := domain.ActorFrom(ctx)
+	membership, err := repo.GetMember(ctx, workspaceID, actor.UserID)
+	require.NoError(t, err)
+	actor.Role = membership.Role
+	return domain.WithWorkspace(domain.WithActor(ctx, actor), workspaceID)
+}
+
+func TestWorkspaceUseCase(t *testing.T) {
+	workspaceRepo := repository.NewWorkspaceRepositoryMemory()
+	userRepo := new(mocks.MockUserRepository)
+	userRepo.On("GetByUsername", mock.Anything, "bob").Return(domain.User{ID: "2", Username: "bob"}, nil)
+	userRepo.On("GetByUsername", mock.Anything, mock.Anything).Return(domain.User{}, errors.New("user not found"))
+	workspaces := usecase.NewWorkspaceUseCase(workspaceRepo, userRepo)
+
+	team, err := workspaces.CreateWorkspace(alice, "  Team ")
+	require.NoError(t, err)
+	assert.Equal(t, "Team", team.Name)
+	assert.Equal(t, domain.WorkspaceAdmin, team.Role, "the creator is the first admin")
+
+	t.Run("names are validated", func(t *testing.T) {
+		_, err := workspaces.CreateWorkspace(alice, " ")
+		assert.EqualError(t, err, "workspace name is required")
+		_, err = workspaces.CreateWorkspace(alice, strings.Repeat("a", 101))
+		assert.EqualError(t, err, "workspace name must be at most 100 characters")
+	})
+
+	t.Run("members are added by username", func(t *testing.T) {
+		member, err := workspaces.AddMember(inWorkspace(t, workspaceRepo, alice, team.ID), team.ID, domain.MemberRequest{Username: "bob"})
+		require.NoError(t, err)
+		assert.Equal(t, domain.Membership{WorkspaceID: team.ID, UserID: "2", Username: "bob", Role: domain.WorkspaceMember, CreatedAt: member.CreatedAt}, member)
+
+		_, err = workspaces.AddMember(alice, team.ID, domain.MemberRequest{Username: "bob"})
+		assert.ErrorIs(t, err, domain.ErrAlreadyMember)
+		_, err = workspaces.AddMember(alice, team.ID, domain.MemberRequest{Username: "carol"})
+		assert.EqualError(t, err, "user not found")
+		_, err = workspaces.AddMember(alice, team.ID, domain.MemberRequest{Username: "bob", Role: "owner"})
+		assert.EqualError(t, err, "role must be admin or member")
+	})
+
+	t.Run("users only see their workspaces", func(t *testing.T) {
+		_, err := workspaces.CreateWorkspace(alice, "Private")
+		require.NoError(t, err)
+
+		listed, err := workspaces.ListWorkspaces(bob)
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		assert.Equal(t, team.ID, listed[0].ID)
+		assert.Equal(t, domain.WorkspaceMember, listed[0].Role)
+
+		listed, err = workspaces.ListWorkspaces(alice)
+		require.NoError(t, err)
+		assert.Len(t, listed, 2)
+
+		_, err = workspaces.GetWorkspace(bob, listed[0].ID)
+		assert.EqualError(t, err, "workspace not found")
+	})
+
+	t.Run("members can only remove themselves", func(t *testing.T) {
+		err := workspaces.RemoveMember(inWorkspace(t, workspaceRepo, bob, team.ID), team.ID, "1")
+		assert.ErrorIs(t, err, domain.ErrNotWorkspaceAdmin)
+	})
+
+	t.Run("a workspace keeps an admin", func(t *testing.T) {
+		_, err := workspaces.UpdateMember(alice, team.ID, "1", domain.WorkspaceMember)
+		assert.ErrorIs(t, err, domain.ErrLastWorkspaceAdmin)
+		assert.ErrorIs(t, workspaces.RemoveMember(inWorkspace(t, workspaceRepo, alice, team.ID), team.ID, "1"), domain.ErrLastWorkspaceAdmin)
+
+		promoted, err := workspaces.UpdateMember(alice, team.ID, "2", domain.WorkspaceAdmin)
+		require.NoError(t, err)
+		assert.Equal(t, domain.WorkspaceAdmin, promoted.Role)
+		require.NoError(t, workspaces.RemoveMember(inWorkspace(t, workspaceRepo, alice, team.ID), team.ID, "1"))
+
+		members, err := workspaces.ListMembers(bob, team.ID)
+		require.NoError(t, err)
+		require.Len(t, members, 1)
+		assert.Equal(t, "bob", members[0].Username)
+	})
+}
+
+func TestWorkspaceIsolation(t *testing.T) {
+	taskRepo := repository.NewTaskRepositoryMemory()
+	tagRepo := repository.NewTagRepositoryMemory()
+	commentRepo := repository.NewCommentRepositoryMemory()
+	tasks := usecase.NewTaskUseCase(taskRepo, usecase.WithTags(tagRepo), usecase.WithComments(commentRepo))
+	tags := usecase.NewTagUseCase(tagRepo, taskRepo)
+	comments := usecase.NewCommentUseCase(commentRepo, taskRepo)
+	due := time.Now().Add(24 * time.Hour)
+
+	teamCtx := domain.WithWorkspace(adminCtx, "team")
+	otherCtx := domain.WithWorkspace(adminCtx, "other")
+	for _, ctx := range []context.Context{teamCtx, otherCtx} {
+		_, err := tags.CreateTag(ctx, domain.TagRequest{Name: "backend"})
+		require.NoError(t, err, "tag names are unique per workspace")
+	}
+	task, err := tasks.CreateTask(teamCtx, domain.CreateTaskRequest{Title: "Team task", DueDate: due, Tags: []string{"backend"}, ExternalID: "JIRA-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "team", task.WorkspaceID)
+	_, err = tasks.CreateTask(otherCtx, domain.CreateTaskRequest{Title: "Other task", DueDate: due, ExternalID: "JIRA-1"})
+	require.NoError(t, err, "external IDs are unique per workspace")
+	_, err = comments.AddComment(teamCtx, task.ID, "hello")
+	require.NoError(t, err)
+
+	t.Run("other workspaces cannot read the task", func(t *testing.T) {
+		listed, err := tasks.GetAllTasks(otherCtx)
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		assert.Equal(t, "Other task", listed[0].Title)
+
+		_, err = tasks.GetTaskByID(otherCtx, task.ID)
+		assert.EqualError(t, err, "task not found")
+		_, err = comments.ListComments(otherCtx, task.ID)
+		assert.EqualError(t, err, "task not found")
+	})
+
+	t.Run("other workspaces cannot change the task", func(t *testing.T) {
+		_, err := tasks.UpdateTask(otherCtx, task.ID, domain.UpdateTaskRequest{Title: "Hijacked"})
+		assert.EqualError(t, err, "task not found")
+		assert.EqualError(t, tasks.DeleteTask(otherCtx, task.ID), "task not found")
+		_, err = tasks.CreateTask(otherCtx, domain.CreateTaskRequest{Title: "Child", DueDate: due, ParentID: task.ID})
+		assert.Error(t, err, "parents come from the same workspace")
+
+		require.NoError(t, tags.DeleteTag(otherCtx, "backend"))
+		unchanged, err := tasks.GetTaskByID(teamCtx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Team task", unchanged.Title)
+		assert.Equal(t, []string{"backend"}, unchanged.Tags)
+	})
+
+	t.Run("unscoped contexts are refused", func(t *testing.T) {
+		_, err := taskRepo.GetAll(context.Background())
+		assert.ErrorIs(t, err, domain.ErrNoWorkspace)
+		unscoped := domain.WithActor(context.Background(), domain.Actor{UserID: "1", Username: "alice", Role: "user"})
+		_, err = tasks.GetTaskByID(unscoped, task.ID)
+		assert.EqualError(t, err, "task not found")
+		_, err = tasks.CreateTask(unscoped, domain.CreateTaskRequest{Title: "Nowhere", DueDate: due})
+		assert.ErrorIs(t, err, domain.ErrNoWorkspace)
+		_, err = tasks.CreateTask(domain.WithWorkspace(unscoped, ""), domain.CreateTaskRequest{Title: "Nowhere", DueDate: due})
+		assert.ErrorIs(t, err, domain.ErrNoWorkspace)
+	})
+
+	t.Run("jobs opt in to every workspace", func(t *testing.T) {
+		all, err := taskRepo.GetAll(domain.AllWorkspaces(context.Background()))
+		require.NoError(t, err)
+		assert.Len(t, all, 2)
+	})
+}
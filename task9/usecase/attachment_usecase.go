@@ -0,0 +1,222 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"path"
+	"path/filepath"
+	"strings"
+	"task9/domain"
+	"time"
+	"unicode"
+)
+
+type AttachmentUseCase struct {
+	attachmentRepo domain.AttachmentRepository
+	taskRepo       domain.TaskRepository
+	blobs          domain.BlobStore
+	limits         domain.AttachmentLimits
+	projects       *ProjectGuard
+}
+
+type AttachmentUseCaseOption func(*AttachmentUseCase)
+
+func WithAttachmentLimits(limits domain.AttachmentLimits) AttachmentUseCaseOption {
+	return func(uc *AttachmentUseCase) {
+		uc.limits = limits
+	}
+}
+
+// WithAttachmentProjects keeps the attachments of tasks in archived projects
+// from being uploaded or deleted.
+func WithAttachmentProjects(projects *ProjectGuard) AttachmentUseCaseOption {
+	return func(uc *AttachmentUseCase) {
+		uc.projects = projects
+	}
+}
+
+func NewAttachmentUseCase(attachmentRepo domain.AttachmentRepository, taskRepo domain.TaskRepository, blobs domain.BlobStore, opts ...AttachmentUseCaseOption) *AttachmentUseCase {
+	uc := &AttachmentUseCase{attachmentRepo: attachmentRepo, taskRepo: taskRepo, blobs: blobs}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+func (uc *AttachmentUseCase) ListAttachments(ctx context.Context, taskID string) ([]domain.Attachment, error) {
+	if _, err := uc.taskRepo.GetByID(ctx, taskID); err != nil {
+		return nil, err
+	}
+	return uc.attachmentRepo.ListByTask(ctx, taskID)
+}
+
+// UploadAttachment stores the upload on the task as the actor in ctx. The
+// body is streamed into the blob store; one that grows past the size limit
+// fails with domain.ErrAttachmentTooLarge and leaves nothing behind.
+func (uc *AttachmentUseCase) UploadAttachment(ctx context.Context, taskID string, upload domain.AttachmentUpload) (domain.Attachment, error) {
+	filename := cleanFilename(upload.Filename)
+	if filename == "" {
+		return domain.Attachment{}, domain.NewValidationError("file name must not be empty")
+	}
+	contentType := attachmentType(upload.ContentType, filename)
+	if !uc.limits.Allows(contentType) {
+		return domain.Attachment{}, fmt.Errorf("%w: %s", domain.ErrAttachmentType, contentType)
+	}
+	task, err := uc.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+	if err := uc.projects.CheckWritable(ctx, task); err != nil {
+		return domain.Attachment{}, err
+	}
+
+	key := "tasks/" + strings.ToLower(taskID) + "/" + randomHex(16)
+	body := upload.Body
+	if uc.limits.MaxBytes > 0 {
+		body = &sizeLimitReader{r: body, remaining: uc.limits.MaxBytes}
+	}
+	size, err := uc.blobs.Put(ctx, key, body)
+	if err != nil {
+		return domain.Attachment{}, err
+	}
+
+	actor := domain.ActorFrom(ctx)
+	attachment, err := uc.attachmentRepo.Create(ctx, domain.Attachment{
+		TaskID:      taskID,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        size,
+		BlobKey:     key,
+		UploaderID:  actor.UserID,
+		Uploader:    actor.Username,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		uc.deleteBlob(ctx, key)
+		return domain.Attachment{}, err
+	}
+	return attachment, nil
+}
+
+// OpenAttachment returns an attachment with its content. The caller closes
+// the reader.
+func (uc *AttachmentUseCase) OpenAttachment(ctx context.Context, taskID, attachmentID string) (domain.Attachment, io.ReadCloser, error) {
+	_, attachment, err := uc.findAttachment(ctx, taskID, attachmentID)
+	if err != nil {
+		return domain.Attachment{}, nil, err
+	}
+	content, err := uc.blobs.Open(ctx, attachment.BlobKey)
+	if err != nil {
+		return domain.Attachment{}, nil, err
+	}
+	return attachment, content, nil
+}
+
+// DeleteAttachment removes an attachment and its content. Uploaders may
+// delete their own attachments and admins may delete any attachment.
+func (uc *AttachmentUseCase) DeleteAttachment(ctx context.Context, taskID, attachmentID string) error {
+	task, attachment, err := uc.findAttachment(ctx, taskID, attachmentID)
+	if err != nil {
+		return err
+	}
+	if err := uc.projects.CheckWritable(ctx, task); err != nil {
+		return err
+	}
+	actor := domain.ActorFrom(ctx)
+	if attachment.UploaderID != actor.UserID && actor.Role != "admin" {
+		return domain.ErrNotUploader
+	}
+	if err := uc.attachmentRepo.Delete(ctx, attachmentID); err != nil {
+		return err
+	}
+	uc.deleteBlob(ctx, attachment.BlobKey)
+	return nil
+}
+
+// findAttachment returns an attachment along with the task it belongs to.
+func (uc *AttachmentUseCase) findAttachment(ctx context.Context, taskID, attachmentID string) (domain.Task, domain.Attachment, error) {
+	task, err := uc.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return domain.Task{}, domain.Attachment{}, err
+	}
+	attachment, err := uc.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil {
+		return domain.Task{}, domain.Attachment{}, err
+	}
+	if attachment.TaskID != taskID {
+		return domain.Task{}, domain.Attachment{}, errors.New("attachment not found")
+	}
+	return task, attachment, nil
+}
+
+// deleteBlob removes content whose metadata is already gone. A failure only
+// leaves an unreachable blob behind, so it is logged rather than returned.
+func (uc *AttachmentUseCase) deleteBlob(ctx context.Context, key string) {
+	deleteBlobs(ctx, uc.blobs, []string{key})
+}
+
+func deleteBlobs(ctx context.Context, blobs domain.BlobStore, keys []string) {
+	for _, key := range keys {
+		if err := blobs.Delete(ctx, key); err != nil {
+			log.Printf("delete attachment blob %s: %v", key, err)
+		}
+	}
+}
+
+// cleanFilename keeps the last element of a client supplied path, without
+// control characters, so that it is safe to echo back in a header.
+func cleanFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// attachmentType is the declared media type without parameters, or the one
+// implied by the file extension when the client did not say.
+func attachmentType(declared, filename string) string {
+	mediaType, _, err := mime.ParseMediaType(declared)
+	if err != nil || mediaType == "application/octet-stream" {
+		mediaType = ""
+	}
+	if mediaType == "" {
+		if byExt, _, err := mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(filename))); err == nil {
+			mediaType = byExt
+		}
+	}
+	if mediaType == "" {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
+
+// sizeLimitReader fails with domain.ErrAttachmentTooLarge as soon as more
+// than remaining bytes have been read.
+type sizeLimitReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, domain.ErrAttachmentTooLarge
+	}
+	return n, err
+}
@@ -1,30 +1,56 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log"
 	"task9/domain"
+	"time"
 )
 
 type AuthUseCase struct {
-	userRepo      domain.UserRepository
+	userRepo       domain.UserRepository
 	passwordHasher domain.PasswordHasher
 	tokenGenerator domain.TokenGenerator
+	passwordPolicy domain.PasswordPolicy
+	events         domain.EventPublisher
 }
 
-func NewAuthUseCase(userRepo domain.UserRepository, passwordHasher domain.PasswordHasher, tokenGenerator domain.TokenGenerator) *AuthUseCase {
-	return &AuthUseCase{
+type AuthUseCaseOption func(*AuthUseCase)
+
+func WithPasswordPolicy(policy domain.PasswordPolicy) AuthUseCaseOption {
+	return func(uc *AuthUseCase) {
+		uc.passwordPolicy = policy
+	}
+}
+
+// WithUserEvents publishes an event when a user registers or is promoted.
+func WithUserEvents(publisher domain.EventPublisher) AuthUseCaseOption {
+	return func(uc *AuthUseCase) {
+		uc.events = publisher
+	}
+}
+
+func NewAuthUseCase(userRepo domain.UserRepository, passwordHasher domain.PasswordHasher, tokenGenerator domain.TokenGenerator, opts ...AuthUseCaseOption) *AuthUseCase {
+	uc := &AuthUseCase{
 		userRepo:       userRepo,
 		passwordHasher: passwordHasher,
 		tokenGenerator: tokenGenerator,
+		passwordPolicy: domain.PasswordPolicy{MinLength: 6},
+	}
+	for _, opt := range opts {
+		opt(uc)
 	}
+	return uc
 }
 
-func (uc *AuthUseCase) Register(req domain.RegisterRequest) (domain.User, error) {
-	if len(req.Password) < 6 {
-		return domain.User{}, errors.New("password must be at least 6 characters")
+func (uc *AuthUseCase) Register(ctx context.Context, req domain.RegisterRequest) (domain.User, error) {
+	if len(req.Password) < uc.passwordPolicy.MinLength {
+		return domain.User{}, domain.NewValidationError(fmt.Sprintf("password must be at least %d characters", uc.passwordPolicy.MinLength))
 	}
 
-	isFirst, err := uc.userRepo.IsFirstUser()
+	isFirst, err := uc.userRepo.IsFirstUser(ctx)
 	if err != nil {
 		return domain.User{}, err
 	}
@@ -45,11 +71,16 @@ func (uc *AuthUseCase) Register(req domain.RegisterRequest) (domain.User, error)
 		Role:     role,
 	}
 
-	return uc.userRepo.Create(user)
+	created, err := uc.userRepo.Create(ctx, user)
+	if err != nil {
+		return domain.User{}, err
+	}
+	uc.publishUser(ctx, domain.EventUserRegistered, created)
+	return created, nil
 }
 
-func (uc *AuthUseCase) Login(req domain.LoginRequest) (string, domain.User, error) {
-	user, err := uc.userRepo.GetByUsername(req.Username)
+func (uc *AuthUseCase) Login(ctx context.Context, req domain.LoginRequest) (string, domain.User, error) {
+	user, err := uc.userRepo.GetByUsername(ctx, req.Username)
 	if err != nil {
 		return "", domain.User{}, errors.New("invalid credentials")
 	}
@@ -67,7 +98,41 @@ func (uc *AuthUseCase) Login(req domain.LoginRequest) (string, domain.User, erro
 	return token, user, nil
 }
 
-func (uc *AuthUseCase) PromoteUser(username string) error {
-	return uc.userRepo.UpdateRole(username, "admin")
+// IssueStreamToken gives the logged-in user a short-lived token for the task
+// streams, for clients that cannot send the Authorization header there.
+func (uc *AuthUseCase) IssueStreamToken(ctx context.Context) (string, time.Time, error) {
+	actor := domain.ActorFrom(ctx)
+	if actor.UserID == "" {
+		return "", time.Time{}, errors.New("unauthorized")
+	}
+	return uc.tokenGenerator.GenerateStream(actor.UserID, actor.Username, actor.Role)
+}
+
+func (uc *AuthUseCase) PromoteUser(ctx context.Context, username string) error {
+	if err := uc.userRepo.UpdateRole(ctx, username, "admin"); err != nil {
+		return err
+	}
+	if uc.events != nil {
+		user, err := uc.userRepo.GetByUsername(ctx, username)
+		if err != nil {
+			log.Printf("publish %s event for user %s: %v", domain.EventUserPromoted, username, err)
+			return nil
+		}
+		uc.publishUser(ctx, domain.EventUserPromoted, user)
+	}
+	return nil
 }
 
+// publishUser tells the publisher about a change to user, logging rather
+// than returning failures since the change has already been written.
+func (uc *AuthUseCase) publishUser(ctx context.Context, eventType string, user domain.User) {
+	if uc.events == nil {
+		return
+	}
+	user.Password = ""
+	event := newEvent(ctx, eventType)
+	event.User = &user
+	if err := uc.events.Publish(ctx, event); err != nil {
+		log.Printf("publish %s event for user %s: %v", eventType, user.Username, err)
+	}
+}
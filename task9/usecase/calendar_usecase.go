@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"task9/domain"
+)
+
+// CalendarUseCase manages the users' calendar feeds. Calendar apps cannot
+// log in, so a feed is reached by a secret token in its URL; only the hash
+// of the token is stored.
+type CalendarUseCase struct {
+	userRepo      domain.UserRepository
+	taskRepo      domain.TaskRepository
+	workspaceRepo domain.WorkspaceRepository
+}
+
+func NewCalendarUseCase(userRepo domain.UserRepository, taskRepo domain.TaskRepository, workspaceRepo domain.WorkspaceRepository) *CalendarUseCase {
+	return &CalendarUseCase{userRepo: userRepo, taskRepo: taskRepo, workspaceRepo: workspaceRepo}
+}
+
+// RotateFeedToken gives the acting user a new feed token. The feed URL with
+// the old token stops working. The token cannot be looked up later.
+func (uc *CalendarUseCase) RotateFeedToken(ctx context.Context) (string, error) {
+	token := randomHex(32)
+	if err := uc.userRepo.SetCalendarTokenHash(ctx, domain.ActorFrom(ctx).UserID, hashFeedToken(token)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// DisableFeed turns the acting user's feed off until a token is issued
+// again.
+func (uc *CalendarUseCase) DisableFeed(ctx context.Context) error {
+	return uc.userRepo.SetCalendarTokenHash(ctx, domain.ActorFrom(ctx).UserID, "")
+}
+
+// Feed calls fn with every live task that has a due date in the workspaces
+// of the user token belongs to, one workspace after the other and each in
+// storage order. It returns ErrCalendarFeedNotFound if token belongs to no
+// user.
+func (uc *CalendarUseCase) Feed(ctx context.Context, token string, fn func(domain.Task) error) error {
+	if token == "" {
+		return domain.ErrCalendarFeedNotFound
+	}
+	user, err := uc.userRepo.GetByCalendarTokenHash(ctx, hashFeedToken(token))
+	if err != nil {
+		if err.Error() == "user not found" {
+			return domain.ErrCalendarFeedNotFound
+		}
+		return err
+	}
+	workspaces, err := uc.workspaceRepo.ListForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, workspace := range workspaces {
+		err := uc.taskRepo.Each(domain.WithWorkspace(ctx, workspace.ID), domain.TaskFilter{}, func(task domain.Task) error {
+			if task.DueDate.IsZero() {
+				return nil
+			}
+			return fn(task)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"task9/domain"
+	"time"
+)
+
+type CommentUseCase struct {
+	commentRepo domain.CommentRepository
+	taskRepo    domain.TaskRepository
+	limits      domain.CommentLimits
+	projects    *ProjectGuard
+}
+
+type CommentUseCaseOption func(*CommentUseCase)
+
+func WithCommentLimits(limits domain.CommentLimits) CommentUseCaseOption {
+	return func(uc *CommentUseCase) {
+		uc.limits = limits
+	}
+}
+
+// WithCommentProjects keeps the comments of tasks in archived projects from
+// being added, edited or deleted.
+func WithCommentProjects(projects *ProjectGuard) CommentUseCaseOption {
+	return func(uc *CommentUseCase) {
+		uc.projects = projects
+	}
+}
+
+func NewCommentUseCase(commentRepo domain.CommentRepository, taskRepo domain.TaskRepository, opts ...CommentUseCaseOption) *CommentUseCase {
+	uc := &CommentUseCase{commentRepo: commentRepo, taskRepo: taskRepo}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+func (uc *CommentUseCase) ListComments(ctx context.Context, taskID string) ([]domain.Comment, error) {
+	if _, err := uc.taskRepo.GetByID(ctx, taskID); err != nil {
+		return nil, err
+	}
+	return uc.commentRepo.ListByTask(ctx, taskID)
+}
+
+// AddComment posts a comment on the task as the actor in ctx.
+func (uc *CommentUseCase) AddComment(ctx context.Context, taskID string, body string) (domain.Comment, error) {
+	body, err := uc.checkBody(body)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	task, err := uc.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	if err := uc.projects.CheckWritable(ctx, task); err != nil {
+		return domain.Comment{}, err
+	}
+
+	actor := domain.ActorFrom(ctx)
+	now := time.Now()
+	return uc.commentRepo.Create(ctx, domain.Comment{
+		TaskID:    taskID,
+		AuthorID:  actor.UserID,
+		Author:    actor.Username,
+		Body:      body,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
+
+// EditComment changes the body of a comment. Only its author may edit it.
+func (uc *CommentUseCase) EditComment(ctx context.Context, taskID, commentID string, body string) (domain.Comment, error) {
+	body, err := uc.checkBody(body)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	comment, err := uc.findComment(ctx, taskID, commentID)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	if comment.AuthorID != domain.ActorFrom(ctx).UserID {
+		return domain.Comment{}, domain.ErrForbidden
+	}
+	return uc.commentRepo.UpdateBody(ctx, commentID, body)
+}
+
+// DeleteComment removes a comment. Authors may delete their own comments and
+// admins may delete any comment.
+func (uc *CommentUseCase) DeleteComment(ctx context.Context, taskID, commentID string) error {
+	comment, err := uc.findComment(ctx, taskID, commentID)
+	if err != nil {
+		return err
+	}
+	actor := domain.ActorFrom(ctx)
+	if comment.AuthorID != actor.UserID && actor.Role != "admin" {
+		return domain.ErrForbidden
+	}
+	return uc.commentRepo.Delete(ctx, commentID)
+}
+
+// findComment returns a comment of the task that is about to change.
+func (uc *CommentUseCase) findComment(ctx context.Context, taskID, commentID string) (domain.Comment, error) {
+	task, err := uc.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	if err := uc.projects.CheckWritable(ctx, task); err != nil {
+		return domain.Comment{}, err
+	}
+	comment, err := uc.commentRepo.GetByID(ctx, commentID)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	if comment.TaskID != taskID {
+		return domain.Comment{}, errors.New("comment not found")
+	}
+	return comment, nil
+}
+
+func (uc *CommentUseCase) checkBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", domain.NewValidationError("comment body must not be empty")
+	}
+	if uc.limits.MaxBodyLength > 0 && len(body) > uc.limits.MaxBodyLength {
+		return "", domain.NewValidationError(fmt.Sprintf("comment body must be at most %d characters", uc.limits.MaxBodyLength))
+	}
+	return body, nil
+}
@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"task9/domain"
+)
+
+// StreamEvent is a task event as sent to live subscribers. ID orders the
+// events of one hub and is what clients resume from. Task is nil when the
+// subscriber may not see the task, e.g. a task moved to the trash for users
+// who are not admins; TaskID is always set.
+type StreamEvent struct {
+	ID     string
+	Type   string
+	TaskID string
+	Task   *domain.Task
+
+	seq uint64
+}
+
+// EventHub fans task events out to the live subscribers of this process. It
+// keeps the latest events so that a client that reconnects can pick up after
+// the last event it saw. Subscribers that fall behind are dropped rather
+// than slowing down the requests that publish events.
+type EventHub struct {
+	mu          sync.Mutex
+	epoch       string
+	seq         uint64
+	history     []StreamEvent
+	historySize int
+	bufferSize  int
+	subscribers map[*EventSubscription]struct{}
+	closed      bool
+}
+
+type EventHubOption func(*EventHub)
+
+// WithEventHistory sets how many events are kept for clients that resume.
+// The default is 1000.
+func WithEventHistory(size int) EventHubOption {
+	return func(h *EventHub) {
+		h.historySize = size
+	}
+}
+
+// WithSubscriberBuffer sets how many events may wait for a subscriber before
+// it is dropped. The default is 64.
+func WithSubscriberBuffer(size int) EventHubOption {
+	return func(h *EventHub) {
+		h.bufferSize = size
+	}
+}
+
+func NewEventHub(opts ...EventHubOption) *EventHub {
+	h := &EventHub{
+		// Event IDs start with the epoch so that IDs handed out before a
+		// restart are recognised as unknown rather than mistaken for new ones.
+		epoch:       randomHex(4),
+		historySize: 1000,
+		bufferSize:  64,
+		subscribers: map[*EventSubscription]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Publish passes task creations, updates, deletions and restores on to the
+// subscribers. Other events are ignored; a completion always comes with an
+// update.
+func (h *EventHub) Publish(ctx context.Context, event domain.Event) error {
+	if event.Task == nil || event.Type == domain.EventTaskCompleted {
+		return nil
+	}
+	task := *event.Task
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil
+	}
+
+	h.seq++
+	streamEvent := StreamEvent{
+		ID:     h.epoch + "-" + strconv.FormatUint(h.seq, 10),
+		Type:   event.Type,
+		TaskID: task.ID,
+		Task:   &task,
+		seq:    h.seq,
+	}
+	h.history = append(h.history, streamEvent)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+
+	for subscription := range h.subscribers {
+		if !subscription.wants(streamEvent) {
+			continue
+		}
+		select {
+		case subscription.events <- subscription.visible(streamEvent):
+		default:
+			subscription.lagged = true
+			h.drop(subscription)
+		}
+	}
+	return nil
+}
+
+// Subscribe starts a subscription for the user in ctx, to the events of the
+// workspace ctx is scoped to, or of every workspace if ctx is marked with
+// domain.AllWorkspaces. Any other ctx gets no events. With a lastEventID,
+// the events after it are returned first. reset reports that events after it
+// are no longer known, because it dates from before a restart or has left
+// the history, so the client has to reload its tasks.
+func (h *EventHub) Subscribe(ctx context.Context, lastEventID string) (subscription *EventSubscription, backlog []StreamEvent, reset bool) {
+	workspaceID, err := domain.WorkspaceScope(ctx)
+	subscription = &EventSubscription{
+		hub:         h,
+		admin:       domain.ActorFrom(ctx).Role == "admin",
+		workspaceID: workspaceID,
+		all:         err == nil && workspaceID == "",
+		events:      make(chan StreamEvent, h.bufferSize),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(subscription.events)
+		return subscription, nil, false
+	}
+	h.subscribers[subscription] = struct{}{}
+
+	if lastEventID == "" {
+		return subscription, nil, false
+	}
+	seq, ok := h.parseID(lastEventID)
+	if !ok || seq > h.seq || (seq < h.seq && (len(h.history) == 0 || h.history[0].seq > seq+1)) {
+		return subscription, nil, true
+	}
+	for _, event := range h.history {
+		if event.seq > seq && subscription.wants(event) {
+			backlog = append(backlog, subscription.visible(event))
+		}
+	}
+	return subscription, backlog, false
+}
+
+// Close ends every subscription, e.g. when the server shuts down.
+func (h *EventHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for subscription := range h.subscribers {
+		h.drop(subscription)
+	}
+}
+
+func (h *EventHub) parseID(id string) (uint64, bool) {
+	epoch, seq, ok := strings.Cut(id, "-")
+	if !ok || epoch != h.epoch {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil
+}
+
+// drop removes subscription and closes its channel. h.mu must be held.
+func (h *EventHub) drop(subscription *EventSubscription) {
+	if _, ok := h.subscribers[subscription]; !ok {
+		return
+	}
+	delete(h.subscribers, subscription)
+	close(subscription.events)
+}
+
+// EventSubscription receives the events published after it was started.
+type EventSubscription struct {
+	hub         *EventHub
+	admin       bool
+	workspaceID string
+	all         bool
+	events      chan StreamEvent
+	lagged      bool
+}
+
+// Events is closed when the subscription ends.
+func (s *EventSubscription) Events() <-chan StreamEvent {
+	return s.events
+}
+
+// Lagged reports whether the subscription was dropped because its events
+// were not taken fast enough. The client can resume from its last event.
+func (s *EventSubscription) Lagged() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.lagged
+}
+
+func (s *EventSubscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.drop(s)
+}
+
+// wants reports whether event is about a task in the subscription's
+// workspace.
+func (s *EventSubscription) wants(event StreamEvent) bool {
+	if s.all {
+		return true
+	}
+	return s.workspaceID != "" && event.Task.WorkspaceID == s.workspaceID
+}
+
+// visible hides the content of tasks in the trash from users who are not
+// admins; like GET /workspaces/{wid}/tasks/trash, it is for admins only.
+func (s *EventSubscription) visible(event StreamEvent) StreamEvent {
+	if event.Type == domain.EventTaskDeleted && !s.admin {
+		event.Task = nil
+	}
+	return event
+}
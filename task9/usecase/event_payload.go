@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"task9/domain"
+	"time"
+)
+
+// eventPayload is the JSON body of a webhook delivery. It is encoded once,
+// when the event is published, so every attempt sends the same bytes.
+type eventPayload struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Actor      *eventActor `json:"actor,omitempty"`
+	Data       eventData   `json:"data"`
+}
+
+type eventActor struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type eventData struct {
+	Task    *eventTask    `json:"task,omitempty"`
+	Changes []eventChange `json:"changes,omitempty"`
+	User    *eventUser    `json:"user,omitempty"`
+}
+
+type eventTask struct {
+	ID          string               `json:"id"`
+	WorkspaceID string               `json:"workspace_id,omitempty"`
+	Title       string               `json:"title"`
+	Description string               `json:"description"`
+	DueDate     time.Time            `json:"due_date"`
+	Status      string               `json:"status"`
+	Priority    string               `json:"priority"`
+	ParentID    string               `json:"parent_id,omitempty"`
+	Checklist   []eventChecklistItem `json:"checklist,omitempty"`
+	BlockedBy   []string             `json:"blocked_by,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+type eventChecklistItem struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+type eventChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+type eventUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+func newEventPayload(event domain.Event) eventPayload {
+	payload := eventPayload{ID: event.ID, Type: event.Type, OccurredAt: event.OccurredAt.UTC()}
+	if event.Actor.UserID != "" {
+		payload.Actor = &eventActor{ID: event.Actor.UserID, Username: event.Actor.Username}
+	}
+	if task := event.Task; task != nil {
+		payload.Data.Task = &eventTask{
+			ID:          task.ID,
+			WorkspaceID: task.WorkspaceID,
+			Title:       task.Title,
+			Description: task.Description,
+			DueDate:     task.DueDate,
+			Status:      task.Status,
+			Priority:    task.Priority,
+			ParentID:    task.ParentID,
+			Checklist:   newEventChecklist(task.Checklist),
+			BlockedBy:   task.BlockedBy,
+			Tags:        task.Tags,
+			CreatedAt:   task.CreatedAt,
+			UpdatedAt:   task.UpdatedAt,
+		}
+	}
+	for _, change := range event.Changes {
+		payload.Data.Changes = append(payload.Data.Changes, eventChange{
+			Field: change.Field,
+			Old:   eventValue(change.Old),
+			New:   eventValue(change.New),
+		})
+	}
+	if user := event.User; user != nil {
+		payload.Data.User = &eventUser{ID: user.ID, Username: user.Username, Role: user.Role}
+	}
+	return payload
+}
+
+func newEventChecklist(items []domain.ChecklistItem) []eventChecklistItem {
+	var checklist []eventChecklistItem
+	for _, item := range items {
+		checklist = append(checklist, eventChecklistItem{ID: item.ID, Text: item.Text, Done: item.Done})
+	}
+	return checklist
+}
+
+// eventValue gives history values that have no JSON form of their own the
+// same shape as in the task.
+func eventValue(v interface{}) interface{} {
+	if items, ok := v.([]domain.ChecklistItem); ok {
+		return newEventChecklist(items)
+	}
+	return v
+}
+
+// newEvent starts an event of eventType happening now on behalf of the actor
+// in ctx.
+func newEvent(ctx context.Context, eventType string) domain.Event {
+	return domain.Event{
+		ID:         randomHex(16),
+		Type:       eventType,
+		OccurredAt: time.Now(),
+		Actor:      domain.ActorFrom(ctx),
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+	"task9/domain"
+)
+
+// ProjectGuard keeps the tasks of archived projects read-only, along with
+// their comments and attachments. The task, comment and attachment use cases
+// share one so that they agree on what may change. A nil guard allows
+// everything.
+type ProjectGuard struct {
+	projectRepo domain.ProjectRepository
+}
+
+func NewProjectGuard(projectRepo domain.ProjectRepository) *ProjectGuard {
+	return &ProjectGuard{projectRepo: projectRepo}
+}
+
+// CheckWritable rejects changes to a task whose project is archived. A task
+// whose project no longer exists is writable.
+func (g *ProjectGuard) CheckWritable(ctx context.Context, task domain.Task) error {
+	archived, err := g.Archived(ctx, task.ProjectID)
+	if err != nil {
+		return err
+	}
+	if archived {
+		return domain.ErrProjectArchived
+	}
+	return nil
+}
+
+// Archived reports whether the project exists and is archived.
+func (g *ProjectGuard) Archived(ctx context.Context, projectID string) (bool, error) {
+	if g == nil || projectID == "" {
+		return false, nil
+	}
+	project, err := g.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		if isProjectNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return project.Archived, nil
+}
+
+// Target returns the project a task is about to join, which must exist and
+// not be archived.
+func (g *ProjectGuard) Target(ctx context.Context, projectID string) (domain.Project, error) {
+	if g == nil {
+		return domain.Project{}, nil
+	}
+	project, err := g.projectRepo.GetByID(ctx, projectID)
+	if err != nil {
+		if isProjectNotFound(err) {
+			return domain.Project{}, domain.NewValidationError("project not found")
+		}
+		return domain.Project{}, err
+	}
+	if project.Archived {
+		return domain.Project{}, domain.ErrProjectArchived
+	}
+	return project, nil
+}
+
+func isProjectNotFound(err error) bool {
+	return err.Error() == "project not found" || err.Error() == "invalid project ID format"
+}
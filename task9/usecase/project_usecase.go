@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"task9/domain"
+	"time"
+)
+
+const (
+	maxProjectNameLength        = 100
+	maxProjectDescriptionLength = 1000
+)
+
+// ProjectUseCase manages the projects of a workspace. Like WorkspaceUseCase it
+// leaves to the routes which members may change projects.
+type ProjectUseCase struct {
+	projectRepo domain.ProjectRepository
+	taskRepo    domain.TaskRepository
+	events      domain.EventPublisher
+}
+
+type ProjectUseCaseOption func(*ProjectUseCase)
+
+// WithProjectEvents publishes task.updated for every live task that leaves
+// a deleted project. It can be given more than once, like WithEvents.
+func WithProjectEvents(publisher domain.EventPublisher) ProjectUseCaseOption {
+	return func(uc *ProjectUseCase) {
+		uc.events = addPublisher(uc.events, publisher)
+	}
+}
+
+func NewProjectUseCase(projectRepo domain.ProjectRepository, taskRepo domain.TaskRepository, opts ...ProjectUseCaseOption) *ProjectUseCase {
+	uc := &ProjectUseCase{projectRepo: projectRepo, taskRepo: taskRepo}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+// ListProjects returns the projects ordered by name. A non-nil archived keeps
+// only the projects that are, or are not, archived.
+func (uc *ProjectUseCase) ListProjects(ctx context.Context, archived *bool) ([]domain.Project, error) {
+	projects, err := uc.projectRepo.List(ctx)
+	if err != nil || archived == nil {
+		return projects, err
+	}
+	filtered := []domain.Project{}
+	for _, project := range projects {
+		if project.Archived == *archived {
+			filtered = append(filtered, project)
+		}
+	}
+	return filtered, nil
+}
+
+func (uc *ProjectUseCase) GetProject(ctx context.Context, id string) (domain.Project, error) {
+	return uc.projectRepo.GetByID(ctx, id)
+}
+
+// CreateProject creates a project owned by the actor in ctx.
+func (uc *ProjectUseCase) CreateProject(ctx context.Context, req domain.ProjectRequest) (domain.Project, error) {
+	project, err := checkProject(domain.Project{DefaultStatus: "pending"}, req)
+	if err != nil {
+		return domain.Project{}, err
+	}
+	actor := domain.ActorFrom(ctx)
+	project.OwnerID = actor.UserID
+	project.Owner = actor.Username
+	project.CreatedAt = time.Now()
+	project.UpdatedAt = project.CreatedAt
+	return uc.projectRepo.Create(ctx, project)
+}
+
+// UpdateProject edits a project. Empty fields of req and a nil Archived keep
+// their current value.
+func (uc *ProjectUseCase) UpdateProject(ctx context.Context, id string, req domain.ProjectRequest) (domain.Project, error) {
+	current, err := uc.projectRepo.GetByID(ctx, id)
+	if err != nil {
+		return domain.Project{}, err
+	}
+	project, err := checkProject(current, req)
+	if err != nil {
+		return domain.Project{}, err
+	}
+	project.UpdatedAt = time.Now()
+	return uc.projectRepo.Update(ctx, id, project)
+}
+
+// DeleteProject removes a project. Its tasks are kept and leave the project.
+func (uc *ProjectUseCase) DeleteProject(ctx context.Context, id string) error {
+	if err := uc.projectRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	entry := domain.NewHistoryEntry(domain.ActorFrom(ctx), domain.HistoryUpdate, nil)
+	revisions, err := uc.taskRepo.RemoveProject(ctx, id, entry)
+	publishRevisions(ctx, uc.events, revisions)
+	return err
+}
+
+// ProjectStats counts the live tasks of a project by status and priority.
+func (uc *ProjectUseCase) ProjectStats(ctx context.Context, id string) (domain.ProjectStats, error) {
+	if _, err := uc.projectRepo.GetByID(ctx, id); err != nil {
+		return domain.ProjectStats{}, err
+	}
+
+	stats := domain.ProjectStats{ByStatus: map[string]int{}, ByPriority: map[string]int{}}
+	now := time.Now()
+	err := uc.taskRepo.Each(ctx, domain.TaskFilter{ProjectID: id}, func(task domain.Task) error {
+		stats.Total++
+		stats.ByStatus[task.Status]++
+		stats.ByPriority[task.Priority]++
+		if task.Status != "completed" && !task.DueDate.IsZero() && task.DueDate.Before(now) {
+			stats.Overdue++
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.ProjectStats{}, err
+	}
+	return stats, nil
+}
+
+// checkProject applies req on top of project and validates the result.
+func checkProject(project domain.Project, req domain.ProjectRequest) (domain.Project, error) {
+	if name := strings.TrimSpace(req.Name); name != "" {
+		project.Name = name
+	}
+	if description := strings.TrimSpace(req.Description); description != "" {
+		project.Description = description
+	}
+	if req.DefaultStatus != "" {
+		project.DefaultStatus = req.DefaultStatus
+	}
+	if req.Archived != nil {
+		project.Archived = *req.Archived
+	}
+
+	if project.Name == "" {
+		return domain.Project{}, domain.NewValidationError("project name is required")
+	}
+	if len(project.Name) > maxProjectNameLength {
+		return domain.Project{}, domain.NewValidationError(fmt.Sprintf("project name must be at most %d characters", maxProjectNameLength))
+	}
+	if len(project.Description) > maxProjectDescriptionLength {
+		return domain.Project{}, domain.NewValidationError(fmt.Sprintf("description must be at most %d characters", maxProjectDescriptionLength))
+	}
+	if !isValidStatus(project.DefaultStatus) {
+		return domain.Project{}, domain.NewValidationError("default_status must be one of: pending, in_progress, completed")
+	}
+	return project, nil
+}
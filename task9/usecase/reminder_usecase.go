@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"task9/domain"
+	"time"
+)
+
+// ReminderUseCase sends a reminder when a task's due date approaches and
+// once it has passed. Deliveries are claimed through the reminder repository
+// first, so replicas running the same scan never send a reminder twice.
+type ReminderUseCase struct {
+	taskRepo     domain.TaskRepository
+	reminderRepo domain.ReminderRepository
+	notifier     domain.Notifier
+	windows      []time.Duration
+	overdue      bool
+	lookback     time.Duration
+	lease        time.Duration
+	maxAttempts  int
+	owner        string
+}
+
+type ReminderUseCaseOption func(*ReminderUseCase)
+
+// WithReminderWindows sets how long before the due date reminders are sent,
+// e.g. 24h and 1h. The default is 24h and 1h.
+func WithReminderWindows(windows ...time.Duration) ReminderUseCaseOption {
+	return func(uc *ReminderUseCase) {
+		uc.windows = append([]time.Duration(nil), windows...)
+	}
+}
+
+// WithOverdueReminders turns the reminder for tasks past their due date on
+// or off. It is on by default.
+func WithOverdueReminders(enabled bool) ReminderUseCaseOption {
+	return func(uc *ReminderUseCase) {
+		uc.overdue = enabled
+	}
+}
+
+// WithOverdueLookback sets how long after its due date a task still gets
+// its overdue reminder, e.g. after an outage. Older overdue tasks are not
+// read by the scan at all. The default is 24h.
+func WithOverdueLookback(lookback time.Duration) ReminderUseCaseOption {
+	return func(uc *ReminderUseCase) {
+		uc.lookback = lookback
+	}
+}
+
+// WithReminderMaxAttempts sets how many failed deliveries a reminder gets
+// before it is given up. Zero means no limit. The default is 5.
+func WithReminderMaxAttempts(maxAttempts int) ReminderUseCaseOption {
+	return func(uc *ReminderUseCase) {
+		uc.maxAttempts = maxAttempts
+	}
+}
+
+// WithReminderLease sets how long a claimed reminder is reserved for its
+// sender. It should be well above the time a delivery can take.
+func WithReminderLease(lease time.Duration) ReminderUseCaseOption {
+	return func(uc *ReminderUseCase) {
+		uc.lease = lease
+	}
+}
+
+// WithReminderOwner names this process in reminder leases. The default is
+// unique per process.
+func WithReminderOwner(owner string) ReminderUseCaseOption {
+	return func(uc *ReminderUseCase) {
+		uc.owner = owner
+	}
+}
+
+func NewReminderUseCase(taskRepo domain.TaskRepository, reminderRepo domain.ReminderRepository, notifier domain.Notifier, opts ...ReminderUseCaseOption) *ReminderUseCase {
+	uc := &ReminderUseCase{
+		taskRepo:     taskRepo,
+		reminderRepo: reminderRepo,
+		notifier:     notifier,
+		windows:      []time.Duration{24 * time.Hour, time.Hour},
+		overdue:      true,
+		lookback:     24 * time.Hour,
+		lease:        5 * time.Minute,
+		maxAttempts:  5,
+	}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	if uc.owner == "" {
+		uc.owner = processOwner()
+	}
+	sort.Slice(uc.windows, func(i, j int) bool { return uc.windows[i] < uc.windows[j] })
+	return uc
+}
+
+// SendDueReminders delivers the reminders that are due at now and returns
+// how many were sent. A failed delivery is released and tried again on the
+// next call, until it has failed as many times as the attempt limit allows.
+func (uc *ReminderUseCase) SendDueReminders(ctx context.Context, now time.Time) (int, error) {
+	horizon := now
+	if len(uc.windows) > 0 {
+		horizon = now.Add(uc.windows[len(uc.windows)-1])
+	}
+	// Tasks whose overdue reminder is behind them need not be read at all.
+	from := now
+	if uc.overdue {
+		from = now.Add(-uc.lookback)
+	}
+	tasks, err := uc.taskRepo.GetDueBetween(ctx, from, horizon)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, task := range tasks {
+		reminder, ok := uc.reminderFor(task, now)
+		if !ok {
+			continue
+		}
+		delivered, err := uc.deliver(ctx, reminder, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("task %s: %w", task.ID, err))
+			continue
+		}
+		if delivered {
+			sent++
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// reminderFor picks the reminder due for task at now: the overdue one once
+// the due date has passed, otherwise the one for the narrowest window the
+// due date falls in. Wider windows skipped that way, e.g. for a task created
+// an hour before it is due, are never sent later.
+func (uc *ReminderUseCase) reminderFor(task domain.Task, now time.Time) (domain.Reminder, bool) {
+	reminder := domain.Reminder{TaskID: task.ID, Title: task.Title, DueDate: task.DueDate}
+	until := task.DueDate.Sub(now)
+	if until <= 0 {
+		if !uc.overdue {
+			return domain.Reminder{}, false
+		}
+		reminder.Kind = domain.ReminderOverdue
+		reminder.Key = fmt.Sprintf("%s:%s:%d", task.ID, reminder.Kind, task.DueDate.UnixMilli())
+		return reminder, true
+	}
+
+	for _, window := range uc.windows {
+		if until <= window {
+			reminder.Kind = domain.ReminderDueSoon
+			reminder.Window = window
+			reminder.Key = fmt.Sprintf("%s:%s:%s:%d", task.ID, reminder.Kind, window, task.DueDate.UnixMilli())
+			return reminder, true
+		}
+	}
+	return domain.Reminder{}, false
+}
+
+// deliver claims and sends one reminder. If the process dies between sending
+// and MarkSent the lease runs out and the reminder is sent again, so delivery
+// is at least once.
+func (uc *ReminderUseCase) deliver(ctx context.Context, reminder domain.Reminder, now time.Time) (bool, error) {
+	claimed, err := uc.reminderRepo.Claim(ctx, reminder, uc.owner, now, uc.lease, uc.maxAttempts)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	if err := uc.notifier.Notify(ctx, reminder); err != nil {
+		if releaseErr := uc.reminderRepo.Release(ctx, reminder.Key, uc.owner, err); releaseErr != nil {
+			return false, errors.Join(err, releaseErr)
+		}
+		return false, err
+	}
+	return true, uc.reminderRepo.MarkSent(ctx, reminder.Key, uc.owner, time.Now())
+}
+
+// processOwner identifies this process among replicas: the host name plus a
+// random suffix, since replicas in containers often share a PID.
+func processOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return host + "-" + randomHex(4)
+}
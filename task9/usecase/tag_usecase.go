@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"task9/domain"
+	"time"
+)
+
+const (
+	maxTagNameLength        = 32
+	maxTagDescriptionLength = 200
+)
+
+var tagColorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+type TagUseCase struct {
+	tagRepo  domain.TagRepository
+	taskRepo domain.TaskRepository
+	events   domain.EventPublisher
+	projects *ProjectGuard
+}
+
+type TagUseCaseOption func(*TagUseCase)
+
+// WithTagEvents publishes task.updated for every live task that renaming
+// or deleting a tag changes. It can be given more than once, like
+// WithEvents.
+func WithTagEvents(publisher domain.EventPublisher) TagUseCaseOption {
+	return func(uc *TagUseCase) {
+		uc.events = addPublisher(uc.events, publisher)
+	}
+}
+
+// WithTagProjects keeps a tag from being renamed or deleted while tasks of
+// an archived project carry it.
+func WithTagProjects(projects *ProjectGuard) TagUseCaseOption {
+	return func(uc *TagUseCase) {
+		uc.projects = projects
+	}
+}
+
+func NewTagUseCase(tagRepo domain.TagRepository, taskRepo domain.TaskRepository, opts ...TagUseCaseOption) *TagUseCase {
+	uc := &TagUseCase{tagRepo: tagRepo, taskRepo: taskRepo}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+// ListTags returns the catalogue with the number of live tasks using each tag.
+func (uc *TagUseCase) ListTags(ctx context.Context) ([]domain.Tag, error) {
+	tags, err := uc.tagRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := uc.taskRepo.CountTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tags {
+		tags[i].UsageCount = counts[tags[i].Name]
+	}
+	return tags, nil
+}
+
+func (uc *TagUseCase) CreateTag(ctx context.Context, req domain.TagRequest) (domain.Tag, error) {
+	tag, err := checkTag(domain.Tag{}, req)
+	if err != nil {
+		return domain.Tag{}, err
+	}
+	tag.CreatedAt = time.Now()
+	tag.UpdatedAt = tag.CreatedAt
+	return uc.tagRepo.Create(ctx, tag)
+}
+
+// UpdateTag edits a tag. Empty fields of req keep their current value. When
+// the tag is renamed, every task carrying it is renamed along with it.
+func (uc *TagUseCase) UpdateTag(ctx context.Context, name string, req domain.TagRequest) (domain.Tag, error) {
+	current, err := uc.tagRepo.GetByName(ctx, normalizeTagName(name))
+	if err != nil {
+		return domain.Tag{}, err
+	}
+	tag, err := checkTag(current, req)
+	if err != nil {
+		return domain.Tag{}, err
+	}
+	tag.UpdatedAt = time.Now()
+	if tag.Name != current.Name {
+		if err := uc.checkTasksWritable(ctx, current.Name); err != nil {
+			return domain.Tag{}, err
+		}
+	}
+
+	updated, err := uc.tagRepo.Update(ctx, current.Name, tag)
+	if err != nil {
+		return domain.Tag{}, err
+	}
+	if updated.Name != current.Name {
+		entry := domain.NewHistoryEntry(domain.ActorFrom(ctx), domain.HistoryUpdate, nil)
+		revisions, err := uc.taskRepo.RenameTag(ctx, current.Name, updated.Name, entry)
+		publishRevisions(ctx, uc.events, revisions)
+		if err != nil {
+			return domain.Tag{}, err
+		}
+	}
+
+	counts, err := uc.taskRepo.CountTags(ctx)
+	if err != nil {
+		return domain.Tag{}, err
+	}
+	updated.UsageCount = counts[updated.Name]
+	return updated, nil
+}
+
+// DeleteTag removes a tag from the catalogue and from every task.
+func (uc *TagUseCase) DeleteTag(ctx context.Context, name string) error {
+	name = normalizeTagName(name)
+	if err := uc.checkTasksWritable(ctx, name); err != nil {
+		return err
+	}
+	if err := uc.tagRepo.Delete(ctx, name); err != nil {
+		return err
+	}
+	entry := domain.NewHistoryEntry(domain.ActorFrom(ctx), domain.HistoryUpdate, nil)
+	revisions, err := uc.taskRepo.RemoveTag(ctx, name, entry)
+	publishRevisions(ctx, uc.events, revisions)
+	return err
+}
+
+// checkTasksWritable returns ErrProjectArchived if a live task carrying
+// the tag belongs to an archived project.
+func (uc *TagUseCase) checkTasksWritable(ctx context.Context, name string) error {
+	if uc.projects == nil {
+		return nil
+	}
+	checked := map[string]bool{}
+	return uc.taskRepo.Each(ctx, domain.TaskFilter{Tags: []string{name}}, func(task domain.Task) error {
+		if checked[task.ProjectID] {
+			return nil
+		}
+		checked[task.ProjectID] = true
+		return uc.projects.CheckWritable(ctx, task)
+	})
+}
+
+// checkTag applies req on top of tag and validates the result.
+func checkTag(tag domain.Tag, req domain.TagRequest) (domain.Tag, error) {
+	if name := normalizeTagName(req.Name); name != "" {
+		tag.Name = name
+	}
+	if color := strings.ToLower(strings.TrimSpace(req.Color)); color != "" {
+		tag.Color = color
+	}
+	if description := strings.TrimSpace(req.Description); description != "" {
+		tag.Description = description
+	}
+
+	if err := checkTagName(tag.Name); err != nil {
+		return domain.Tag{}, err
+	}
+	if tag.Color != "" && !tagColorPattern.MatchString(tag.Color) {
+		return domain.Tag{}, domain.NewValidationError("color must be a hex color such as #1e90ff")
+	}
+	if len(tag.Description) > maxTagDescriptionLength {
+		return domain.Tag{}, domain.NewValidationError(fmt.Sprintf("description must be at most %d characters", maxTagDescriptionLength))
+	}
+	return tag, nil
+}
+
+// checkTagName rejects names that could not be used in a ?tags= filter.
+func checkTagName(name string) error {
+	if name == "" {
+		return domain.NewValidationError("tag name is required")
+	}
+	if len(name) > maxTagNameLength {
+		return domain.NewValidationError(fmt.Sprintf("tag name must be at most %d characters", maxTagNameLength))
+	}
+	if strings.Contains(name, ",") {
+		return domain.NewValidationError("tag name must not contain commas")
+	}
+	return nil
+}
+
+// normalizeTagName makes tag names case-insensitive.
+func normalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
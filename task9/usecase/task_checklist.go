@@ -0,0 +1,228 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"task9/domain"
+)
+
+var errChecklistItemNotFound = errors.New("checklist item not found")
+
+// maxSubtaskDepth bounds the parent chain walked when a task is attached to
+// a new parent.
+const maxSubtaskDepth = 32
+
+func (uc *TaskUseCase) AddChecklistItem(ctx context.Context, id, text string) (domain.Task, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return domain.Task{}, domain.NewValidationError("text is required")
+	}
+	if uc.limits.MaxTitleLength > 0 && len(text) > uc.limits.MaxTitleLength {
+		return domain.Task{}, domain.NewValidationError(fmt.Sprintf("text must be at most %d characters", uc.limits.MaxTitleLength))
+	}
+
+	itemID, err := newChecklistItemID()
+	if err != nil {
+		return domain.Task{}, err
+	}
+
+	return uc.modifyTask(ctx, id, func(task *domain.Task) error {
+		if uc.limits.MaxChecklistItems > 0 && len(task.Checklist) >= uc.limits.MaxChecklistItems {
+			return domain.NewValidationError(fmt.Sprintf("a checklist can have at most %d items", uc.limits.MaxChecklistItems))
+		}
+		task.Checklist = append(task.Checklist, domain.ChecklistItem{ID: itemID, Text: text})
+		return nil
+	})
+}
+
+// ToggleChecklistItem flips the done state of one checklist item.
+func (uc *TaskUseCase) ToggleChecklistItem(ctx context.Context, id, itemID string) (domain.Task, error) {
+	return uc.modifyTask(ctx, id, func(task *domain.Task) error {
+		i := checklistIndex(task.Checklist, itemID)
+		if i < 0 {
+			return errChecklistItemNotFound
+		}
+		task.Checklist[i].Done = !task.Checklist[i].Done
+		return nil
+	})
+}
+
+func (uc *TaskUseCase) RemoveChecklistItem(ctx context.Context, id, itemID string) (domain.Task, error) {
+	return uc.modifyTask(ctx, id, func(task *domain.Task) error {
+		i := checklistIndex(task.Checklist, itemID)
+		if i < 0 {
+			return errChecklistItemNotFound
+		}
+		task.Checklist = append(task.Checklist[:i], task.Checklist[i+1:]...)
+		return nil
+	})
+}
+
+// ReorderChecklist puts the checklist items in the order of itemIDs, which
+// must name every item exactly once.
+func (uc *TaskUseCase) ReorderChecklist(ctx context.Context, id string, itemIDs []string) (domain.Task, error) {
+	return uc.modifyTask(ctx, id, func(task *domain.Task) error {
+		if len(itemIDs) != len(task.Checklist) {
+			return domain.NewValidationError("item_ids must list every checklist item exactly once")
+		}
+		reordered := make([]domain.ChecklistItem, 0, len(itemIDs))
+		seen := map[string]bool{}
+		for _, itemID := range itemIDs {
+			i := checklistIndex(task.Checklist, itemID)
+			if i < 0 || seen[itemID] {
+				return domain.NewValidationError("item_ids must list every checklist item exactly once")
+			}
+			seen[itemID] = true
+			reordered = append(reordered, task.Checklist[i])
+		}
+		task.Checklist = reordered
+		return nil
+	})
+}
+
+func checklistIndex(items []domain.ChecklistItem, itemID string) int {
+	for i, item := range items {
+		if item.ID == itemID {
+			return i
+		}
+	}
+	return -1
+}
+
+func newChecklistItemID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// checkParent makes sure parentID names an existing task and that making it
+// the parent of task id would not create a cycle. id is empty for new tasks.
+func (uc *TaskUseCase) checkParent(ctx context.Context, id, parentID string) error {
+	ancestor := parentID
+	for depth := 0; ancestor != ""; depth++ {
+		if ancestor == id {
+			return domain.NewValidationError("a task cannot be a subtask of itself or of its own subtasks")
+		}
+		if depth == maxSubtaskDepth {
+			return domain.NewValidationError(fmt.Sprintf("subtasks can be nested at most %d levels deep", maxSubtaskDepth))
+		}
+
+		task, err := uc.taskRepo.GetByID(ctx, ancestor)
+		if err != nil {
+			if ancestor == parentID && (err.Error() == "task not found" || err.Error() == "invalid task ID format") {
+				return domain.NewValidationError("parent task not found")
+			}
+			return err
+		}
+		ancestor = task.ParentID
+	}
+	return nil
+}
+
+// completeOpenItems applies the completion policy to a task that is about to
+// be completed while subtasks or checklist items are still open. Under the
+// cascade policy the checklist is checked off here; the subtasks must have
+// been completed by cascadeCompletion already.
+func (uc *TaskUseCase) completeOpenItems(task *domain.Task) error {
+	if !task.HasOpenItems() {
+		return nil
+	}
+	if uc.completion != domain.CompletionCascade {
+		return domain.ErrOpenSubtasks
+	}
+	for i := range task.Checklist {
+		task.Checklist[i].Done = true
+	}
+	if task.Subtasks.Completed < task.Subtasks.Total {
+		// A subtask was added or reopened since cascadeCompletion ran.
+		return domain.ErrOpenSubtasks
+	}
+	return nil
+}
+
+// cascadeCompletion completes the open subtasks of task id under the cascade
+// policy, the deepest first, so that the task itself can be written as
+// completed last. Every subtask is checked for blockers before any is
+// written. A failure leaves the task as it was; the subtasks completed until
+// then stay completed, which the completion policy allows.
+func (uc *TaskUseCase) cascadeCompletion(ctx context.Context, id string) error {
+	if uc.completion != domain.CompletionCascade {
+		return nil
+	}
+	task, err := uc.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if task.Subtasks.Completed >= task.Subtasks.Total {
+		return nil
+	}
+	if err := uc.projects.CheckWritable(ctx, task); err != nil {
+		return err
+	}
+	if task.Status != "completed" {
+		if err := uc.checkBlockers(ctx, task, "completed"); err != nil {
+			return err
+		}
+	}
+
+	open, err := uc.openSubtasks(ctx, task)
+	if err != nil {
+		return err
+	}
+	for _, subtaskID := range open {
+		_, err := uc.modifyTask(ctx, subtaskID, func(subtask *domain.Task) error {
+			if subtask.Status == "completed" {
+				return nil
+			}
+			if err := uc.checkBlockers(ctx, *subtask, "completed"); err != nil {
+				return err
+			}
+			for i := range subtask.Checklist {
+				subtask.Checklist[i].Done = true
+			}
+			subtask.Status = "completed"
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to complete subtask %s: %w", subtaskID, err)
+		}
+	}
+	return nil
+}
+
+// openSubtasks returns the open subtasks of task and their open subtasks in
+// turn, the deepest first, after checking each for blockers.
+func (uc *TaskUseCase) openSubtasks(ctx context.Context, task domain.Task) ([]string, error) {
+	var open, parents []string
+	if task.Subtasks.Completed < task.Subtasks.Total {
+		parents = []string{task.ID}
+	}
+	for depth := 0; len(parents) > 0 && depth < maxSubtaskDepth; depth++ {
+		subtasks, err := uc.taskRepo.GetSubtasks(ctx, parents)
+		if err != nil {
+			return nil, err
+		}
+		parents = nil
+		for _, subtask := range subtasks {
+			if subtask.Status == "completed" {
+				continue
+			}
+			if err := uc.checkBlockers(ctx, subtask, "completed"); err != nil {
+				return nil, fmt.Errorf("cannot complete subtask %s: %w", subtask.ID, err)
+			}
+			open = append(open, subtask.ID)
+			if subtask.Subtasks.Completed < subtask.Subtasks.Total {
+				parents = append(parents, subtask.ID)
+			}
+		}
+	}
+	slices.Reverse(open)
+	return open, nil
+}
@@ -0,0 +1,264 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"task9/domain"
+)
+
+var errDependencyNotFound = errors.New("dependency not found")
+
+var errDependencyCycle = domain.NewValidationError("dependency would create a cycle")
+
+// maxGraphDepth bounds how many levels GetDependencyGraph follows in each
+// direction.
+const maxGraphDepth = 10
+
+// AddDependency records that task id is blocked by blockerID. Links that
+// would make a task wait on itself, directly or through other tasks, are
+// rejected.
+//
+// The cycle check reads other tasks than the one written, so a concurrent
+// request can close the loop between the check and the write, e.g. when
+// A→B and B→A are added at the same time. The check is therefore repeated
+// once the link is written, and the link taken out again if it now closes
+// a cycle: whichever link of a loop is written last sees the others.
+func (uc *TaskUseCase) AddDependency(ctx context.Context, id, blockerID string) (domain.Task, error) {
+	if blockerID == id {
+		return domain.Task{}, domain.NewValidationError("a task cannot block itself")
+	}
+	if _, err := uc.taskRepo.GetByID(ctx, blockerID); err != nil {
+		if err.Error() == "task not found" || err.Error() == "invalid task ID format" {
+			return domain.Task{}, domain.NewValidationError("blocking task not found")
+		}
+		return domain.Task{}, err
+	}
+
+	added := false
+	task, err := uc.modifyTask(ctx, id, func(task *domain.Task) error {
+		added = false
+		for _, existing := range task.BlockedBy {
+			if existing == blockerID {
+				return nil
+			}
+		}
+		cycle, err := uc.waitsOn(ctx, blockerID, id)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return errDependencyCycle
+		}
+		task.BlockedBy = append(append([]string(nil), task.BlockedBy...), blockerID)
+		added = true
+		return nil
+	})
+	if err != nil || !added {
+		return task, err
+	}
+
+	cycle, err := uc.waitsOn(ctx, blockerID, id)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	if cycle {
+		if _, err := uc.RemoveDependency(ctx, id, blockerID); err != nil && !errors.Is(err, errDependencyNotFound) {
+			return domain.Task{}, err
+		}
+		return domain.Task{}, errDependencyCycle
+	}
+	return task, nil
+}
+
+func (uc *TaskUseCase) RemoveDependency(ctx context.Context, id, blockerID string) (domain.Task, error) {
+	return uc.modifyTask(ctx, id, func(task *domain.Task) error {
+		blockedBy := make([]string, 0, len(task.BlockedBy))
+		for _, existing := range task.BlockedBy {
+			if existing != blockerID {
+				blockedBy = append(blockedBy, existing)
+			}
+		}
+		if len(blockedBy) == len(task.BlockedBy) {
+			return errDependencyNotFound
+		}
+		task.BlockedBy = blockedBy
+		return nil
+	})
+}
+
+// waitsOn reports whether task from is blocked by target, directly or
+// through a chain of blockers. It walks the blockers breadth first, one
+// repository read per level.
+func (uc *TaskUseCase) waitsOn(ctx context.Context, from, target string) (bool, error) {
+	visited := map[string]bool{from: true}
+	frontier := []string{from}
+	for len(frontier) > 0 {
+		tasks, err := uc.taskRepo.GetByIDs(ctx, frontier)
+		if err != nil {
+			return false, err
+		}
+		frontier = nil
+		for _, task := range tasks {
+			for _, blockerID := range task.BlockedBy {
+				if blockerID == target {
+					return true, nil
+				}
+				if !visited[blockerID] {
+					visited[blockerID] = true
+					frontier = append(frontier, blockerID)
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// checkBlockers returns domain.ErrBlocked when a task with unfinished
+// blockers is moved to in_progress or completed. Blockers in the trash no
+// longer count.
+func (uc *TaskUseCase) checkBlockers(ctx context.Context, task domain.Task, status string) error {
+	if (status != "in_progress" && status != "completed") || len(task.BlockedBy) == 0 {
+		return nil
+	}
+	blockers, err := uc.taskRepo.GetByIDs(ctx, task.BlockedBy)
+	if err != nil {
+		return err
+	}
+	for _, blocker := range blockers {
+		if blocker.Status != "completed" {
+			return domain.ErrBlocked
+		}
+	}
+	return nil
+}
+
+// GetDependencyGraph returns the tasks blocking task id (upstream) and the
+// tasks it blocks (downstream) as trees, up to maxGraphDepth levels deep.
+func (uc *TaskUseCase) GetDependencyGraph(ctx context.Context, id string) (domain.DependencyGraph, error) {
+	task, err := uc.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return domain.DependencyGraph{}, err
+	}
+
+	upstream, err := uc.collectUpstream(ctx, task)
+	if err != nil {
+		return domain.DependencyGraph{}, err
+	}
+	downstream, err := uc.collectDownstream(ctx, task)
+	if err != nil {
+		return domain.DependencyGraph{}, err
+	}
+
+	return domain.DependencyGraph{
+		Task:       task,
+		Upstream:   buildDependencyTree(task.ID, upstream),
+		Downstream: buildDependencyTree(task.ID, downstream),
+	}, nil
+}
+
+// collectUpstream maps every task reachable through BlockedBy to its
+// blockers.
+func (uc *TaskUseCase) collectUpstream(ctx context.Context, root domain.Task) (map[string][]domain.Task, error) {
+	edges := map[string][]domain.Task{}
+	known := map[string]domain.Task{root.ID: root}
+	frontier := []domain.Task{root}
+	for depth := 0; depth < maxGraphDepth && len(frontier) > 0; depth++ {
+		var ids []string
+		for _, task := range frontier {
+			for _, blockerID := range task.BlockedBy {
+				if _, ok := known[blockerID]; !ok {
+					ids = append(ids, blockerID)
+				}
+			}
+		}
+		var blockers []domain.Task
+		if len(ids) > 0 {
+			var err error
+			if blockers, err = uc.taskRepo.GetByIDs(ctx, ids); err != nil {
+				return nil, err
+			}
+		}
+		for _, blocker := range blockers {
+			known[blocker.ID] = blocker
+		}
+
+		for _, task := range frontier {
+			for _, blockerID := range task.BlockedBy {
+				if blocker, ok := known[blockerID]; ok {
+					edges[task.ID] = append(edges[task.ID], blocker)
+				}
+			}
+		}
+		frontier = blockers
+	}
+	return edges, nil
+}
+
+// collectDownstream maps every task reachable through reverse BlockedBy
+// links to the tasks it blocks.
+func (uc *TaskUseCase) collectDownstream(ctx context.Context, root domain.Task) (map[string][]domain.Task, error) {
+	edges := map[string][]domain.Task{}
+	visited := map[string]bool{root.ID: true}
+	frontier := []string{root.ID}
+	for depth := 0; depth < maxGraphDepth && len(frontier) > 0; depth++ {
+		inFrontier := map[string]bool{}
+		for _, id := range frontier {
+			inFrontier[id] = true
+		}
+
+		dependents, err := uc.taskRepo.GetBlockedBy(ctx, frontier)
+		if err != nil {
+			return nil, err
+		}
+		frontier = nil
+		for _, dependent := range dependents {
+			for _, blockerID := range dependent.BlockedBy {
+				if inFrontier[blockerID] {
+					edges[blockerID] = append(edges[blockerID], dependent)
+				}
+			}
+			if !visited[dependent.ID] {
+				visited[dependent.ID] = true
+				frontier = append(frontier, dependent.ID)
+			}
+		}
+	}
+	return edges, nil
+}
+
+// buildDependencyTree lays out the tasks reachable from id through edges as
+// a tree, level by level. A task reached along several paths, as in a
+// diamond, only lists its children where it is first reached, at the
+// shallowest level, so the tree grows with the number of links rather than
+// the number of paths.
+func buildDependencyTree(id string, edges map[string][]domain.Task) []domain.DependencyNode {
+	expanded := map[string]bool{id: true}
+	roots := newDependencyNodes(edges[id])
+	var level []*domain.DependencyNode
+	for i := range roots {
+		level = append(level, &roots[i])
+	}
+	for depth := 1; depth < maxGraphDepth && len(level) > 0; depth++ {
+		var next []*domain.DependencyNode
+		for _, node := range level {
+			if expanded[node.Task.ID] {
+				continue
+			}
+			expanded[node.Task.ID] = true
+			node.Children = newDependencyNodes(edges[node.Task.ID])
+			for i := range node.Children {
+				next = append(next, &node.Children[i])
+			}
+		}
+		level = next
+	}
+	return roots
+}
+
+func newDependencyNodes(tasks []domain.Task) []domain.DependencyNode {
+	nodes := make([]domain.DependencyNode, 0, len(tasks))
+	for _, task := range tasks {
+		nodes = append(nodes, domain.DependencyNode{Task: task})
+	}
+	return nodes
+}
@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"task9/domain"
+)
+
+// WithEvents publishes an event for every task that is created, changed,
+// completed, moved to the trash or restored, whichever way it happens. It
+// can be given more than once; every publisher gets every event.
+func WithEvents(publisher domain.EventPublisher) TaskUseCaseOption {
+	return func(uc *TaskUseCase) {
+		uc.events = addPublisher(uc.events, publisher)
+	}
+}
+
+// addPublisher returns a publisher that sends every event to both current,
+// which may be nil, and publisher.
+func addPublisher(current, publisher domain.EventPublisher) domain.EventPublisher {
+	switch current := current.(type) {
+	case nil:
+		return publisher
+	case eventPublishers:
+		return append(current, publisher)
+	default:
+		return eventPublishers{current, publisher}
+	}
+}
+
+type eventPublishers []domain.EventPublisher
+
+func (p eventPublishers) Publish(ctx context.Context, event domain.Event) error {
+	var errs []error
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// publishTask tells the publisher about a change to task. The change has
+// already been written, so a failure to publish is logged rather than
+// failing the request.
+func (uc *TaskUseCase) publishTask(ctx context.Context, eventType string, task domain.Task, changes []domain.FieldChange) {
+	publishTask(ctx, uc.events, eventType, task, changes)
+}
+
+func publishTask(ctx context.Context, events domain.EventPublisher, eventType string, task domain.Task, changes []domain.FieldChange) {
+	if events == nil {
+		return
+	}
+	event := newEvent(ctx, eventType)
+	event.Task = &task
+	event.Changes = changes
+	if err := events.Publish(ctx, event); err != nil {
+		log.Printf("publish %s event for task %s: %v", eventType, task.ID, err)
+	}
+}
+
+// publishRevisions publishes task.updated for the tasks a change across
+// many tasks, such as renaming a tag, has altered. Trashed tasks are
+// hidden, so their revisions are not published.
+func publishRevisions(ctx context.Context, events domain.EventPublisher, revisions []domain.TaskRevision) {
+	for _, revision := range revisions {
+		if revision.Task.DeletedAt.IsZero() {
+			publishTask(ctx, events, domain.EventTaskUpdated, revision.Task, revision.Changes)
+		}
+	}
+}
+
+// publishUpdate publishes task.updated for a change that touched anything,
+// and task.completed as well when it completed the task.
+func (uc *TaskUseCase) publishUpdate(ctx context.Context, before, after domain.Task) {
+	changes := domain.TaskChanges(before, after)
+	if len(changes) == 0 {
+		return
+	}
+	uc.publishTask(ctx, domain.EventTaskUpdated, after, changes)
+	if before.Status != "completed" && after.Status == "completed" {
+		uc.publishTask(ctx, domain.EventTaskCompleted, after, nil)
+	}
+}
+
+// batchSnapshot reads the tasks a batch is about to update or delete, so
+// that their events can tell what changed. It reads nothing when no one is
+// listening.
+func (uc *TaskUseCase) batchSnapshot(ctx context.Context, writes []domain.TaskWrite) map[string]domain.Task {
+	if uc.events == nil {
+		return nil
+	}
+	before := map[string]domain.Task{}
+	for _, write := range writes {
+		if write.Op == domain.BatchCreate {
+			continue
+		}
+		if task, err := uc.taskRepo.GetByID(ctx, write.ID); err == nil {
+			before[write.ID] = task
+		}
+	}
+	return before
+}
+
+// publishBatch publishes the events of the batch operations that succeeded.
+func (uc *TaskUseCase) publishBatch(ctx context.Context, writes []domain.TaskWrite, writeIndex []int, results []domain.BatchResult, before map[string]domain.Task) {
+	if uc.events == nil {
+		return
+	}
+	for j, i := range writeIndex {
+		if results[i].Error != nil {
+			continue
+		}
+		switch writes[j].Op {
+		case domain.BatchCreate:
+			uc.publishTask(ctx, domain.EventTaskCreated, *results[i].Task, nil)
+		case domain.BatchUpdate:
+			if after, err := uc.taskRepo.GetByID(ctx, writes[j].ID); err == nil {
+				uc.publishUpdate(ctx, before[writes[j].ID], after)
+			}
+		case domain.BatchDelete:
+			task, ok := before[writes[j].ID]
+			if !ok {
+				task = domain.Task{ID: writes[j].ID}
+			}
+			uc.publishTask(ctx, domain.EventTaskDeleted, task, nil)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"task9/domain"
+)
+
+// WithProjects makes tasks only join projects that exist, start with their
+// project's default status and become read-only while it is archived.
+func WithProjects(projects *ProjectGuard) TaskUseCaseOption {
+	return func(uc *TaskUseCase) {
+		uc.projects = projects
+	}
+}
+
+// joinProject checks that a new task can be created in the project of req and
+// fills in the project's default status when req has none.
+func (uc *TaskUseCase) joinProject(ctx context.Context, req domain.CreateTaskRequest) (domain.CreateTaskRequest, error) {
+	if req.ProjectID == "" || uc.projects == nil {
+		return req, nil
+	}
+	project, err := uc.projects.Target(ctx, req.ProjectID)
+	if err != nil {
+		return domain.CreateTaskRequest{}, err
+	}
+	if req.Status == "" {
+		req.Status = project.DefaultStatus
+	}
+	return req, nil
+}
+
+// checkWritableID is ProjectGuard.CheckWritable for a task that has not been
+// read yet.
+func (uc *TaskUseCase) checkWritableID(ctx context.Context, id string) error {
+	if uc.projects == nil {
+		return nil
+	}
+	task, err := uc.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return uc.projects.CheckWritable(ctx, task)
+}
+
+// checkRestorable rejects bringing a task back from the trash into an
+// archived project.
+func (uc *TaskUseCase) checkRestorable(ctx context.Context, id string) error {
+	if uc.projects == nil {
+		return nil
+	}
+	task, err := uc.taskRepo.GetDeletedByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return uc.projects.CheckWritable(ctx, task)
+}
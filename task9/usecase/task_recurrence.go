@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task9/domain"
+	"task9/recurrence"
+	"time"
+)
+
+// SetRecurrence makes the series of task id repeat by rule from now on. The
+// rule goes on the latest occurrence and its due date becomes the new start,
+// so COUNT is counted from there. A task that is not recurring yet starts a
+// series of its own.
+func (uc *TaskUseCase) SetRecurrence(ctx context.Context, id, rule string) (domain.Task, error) {
+	head, err := uc.seriesHead(ctx, id)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	canonical, err := checkRecurrence(rule, head.DueDate)
+	if err != nil {
+		return domain.Task{}, err
+	}
+
+	return uc.modifyTask(ctx, head.ID, func(task *domain.Task) error {
+		seriesID := task.ID
+		if task.Recurrence != nil {
+			if task.Recurrence.NextID != "" {
+				return domain.ErrConflict
+			}
+			seriesID = task.Recurrence.SeriesID
+		}
+		task.Recurrence = &domain.Recurrence{Rule: canonical, SeriesID: seriesID, Start: task.DueDate}
+		return nil
+	})
+}
+
+// StopRecurrence ends the series of task id. Occurrences that already exist
+// are kept.
+func (uc *TaskUseCase) StopRecurrence(ctx context.Context, id string) (domain.Task, error) {
+	head, err := uc.seriesHead(ctx, id)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	if !head.Recurrence.IsActive() {
+		return domain.Task{}, domain.NewValidationError("task is not recurring")
+	}
+
+	return uc.modifyTask(ctx, head.ID, func(task *domain.Task) error {
+		if !task.Recurrence.IsActive() {
+			return domain.ErrConflict
+		}
+		task.Recurrence.Rule = ""
+		return nil
+	})
+}
+
+// seriesHead follows the series of task id to its latest occurrence, which
+// is where the series goes on from.
+func (uc *TaskUseCase) seriesHead(ctx context.Context, id string) (domain.Task, error) {
+	task, err := uc.taskRepo.GetByID(ctx, id)
+	for err == nil && task.Recurrence != nil && task.Recurrence.NextID != "" {
+		var next domain.Task
+		next, err = uc.taskRepo.GetByID(ctx, task.Recurrence.NextID)
+		if err != nil && err.Error() == "task not found" {
+			return domain.Task{}, domain.NewValidationError("the series continues in a task that is in the trash")
+		}
+		task = next
+	}
+	return task, err
+}
+
+// GenerateOccurrences creates the next occurrence of every recurring task
+// that has been completed or whose due date has passed, and returns how many
+// were created. Occurrences missed while the task was overdue are skipped,
+// so a series never produces a backlog of tasks that are already late.
+func (uc *TaskUseCase) GenerateOccurrences(ctx context.Context, now time.Time) (int, error) {
+	tasks, err := uc.taskRepo.GetRecurring(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	var errs []error
+	for _, task := range tasks {
+		ok, err := uc.generateNext(inTaskWorkspace(ctx, task), task, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("task %s: %w", task.ID, err))
+			continue
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, errors.Join(errs...)
+}
+
+// inTaskWorkspace scopes ctx to the workspace of task, so that what a job
+// does for one task, such as checking its project, stays in that task's
+// workspace.
+func inTaskWorkspace(ctx context.Context, task domain.Task) context.Context {
+	if task.WorkspaceID == "" {
+		return ctx
+	}
+	return domain.WithWorkspace(ctx, task.WorkspaceID)
+}
+
+func (uc *TaskUseCase) generateNext(ctx context.Context, task domain.Task, now time.Time) (bool, error) {
+	// A series pauses while its project is archived.
+	if archived, err := uc.projects.Archived(ctx, task.ProjectID); err != nil || archived {
+		return false, err
+	}
+	rule, err := recurrence.Parse(task.Recurrence.Rule)
+	if err != nil {
+		return false, err
+	}
+
+	after := task.DueDate
+	if now.After(after) {
+		after = now
+	}
+	due, ok := rule.Next(task.Recurrence.Start, after)
+	if !ok {
+		// The series has run out through COUNT or UNTIL; stop it so that
+		// it is not picked up again.
+		_, err := uc.modifyTask(ctx, task.ID, func(task *domain.Task) error {
+			if task.Recurrence.IsActive() {
+				task.Recurrence.Rule = ""
+			}
+			return nil
+		})
+		return false, err
+	}
+
+	next := domain.Task{
+		WorkspaceID: task.WorkspaceID,
+		Title:       task.Title,
+		Description: task.Description,
+		DueDate:     due,
+		Status:      "pending",
+		Priority:    task.Priority,
+		ParentID:    task.ParentID,
+		ProjectID:   task.ProjectID,
+		Tags:        task.Tags,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Recurrence: &domain.Recurrence{
+			Rule:     task.Recurrence.Rule,
+			SeriesID: task.Recurrence.SeriesID,
+			Start:    task.Recurrence.Start,
+		},
+	}
+	for _, item := range task.Checklist {
+		next.Checklist = append(next.Checklist, domain.ChecklistItem{ID: item.ID, Text: item.Text})
+	}
+
+	created, err := uc.taskRepo.CreateOccurrence(ctx, task.ID, next, createEntry(ctx, next))
+	if errors.Is(err, domain.ErrConflict) {
+		// Another process has continued the series meanwhile.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	uc.publishTask(ctx, domain.EventTaskCreated, created, nil)
+	return true, nil
+}
+
+// checkRecurrence validates rule for a task due at dueDate and returns it in
+// canonical form.
+func checkRecurrence(rule string, dueDate time.Time) (string, error) {
+	parsed, err := recurrence.Parse(rule)
+	if err != nil {
+		return "", domain.NewValidationError("invalid recurrence: " + err.Error())
+	}
+	if dueDate.IsZero() {
+		return "", domain.NewValidationError("a recurring task needs a due date")
+	}
+	return parsed.String(), nil
+}
@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"task9/domain"
+	"time"
+)
+
+const (
+	// defaultStatsDays is how many days of daily counts TaskStats returns
+	// when the caller does not pick a range.
+	defaultStatsDays = 30
+	maxStatsDays     = 366
+)
+
+// TaskStats summarises the tasks matching filter, with daily counts from the
+// day of from to the day of to. A zero to is today and a zero from is
+// defaultStatsDays days up to to. The tasks are those of the workspace in
+// ctx, like every other task read.
+func (uc *TaskUseCase) TaskStats(ctx context.Context, filter domain.TaskFilter, from, to time.Time) (domain.TaskStats, error) {
+	filter, err := checkFilter(filter)
+	if err != nil {
+		return domain.TaskStats{}, err
+	}
+	filter.Sort = ""
+
+	now := time.Now()
+	if to.IsZero() {
+		to = now
+	}
+	to = domain.StartOfDay(to)
+	if from.IsZero() {
+		from = to.AddDate(0, 0, 1-defaultStatsDays)
+	}
+	from = domain.StartOfDay(from)
+	if from.After(to) {
+		return domain.TaskStats{}, domain.NewValidationError("from must not be after to")
+	}
+	if days := int(to.Sub(from)/(24*time.Hour)) + 1; days > maxStatsDays {
+		return domain.TaskStats{}, domain.NewValidationError(fmt.Sprintf("the range can span at most %d days", maxStatsDays))
+	}
+
+	return uc.taskRepo.Stats(ctx, domain.TaskStatsQuery{Filter: filter, From: from, To: to, Now: now})
+}
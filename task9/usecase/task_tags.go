@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"task9/domain"
+)
+
+// WithTags makes tasks only accept tags that exist in the catalogue.
+func WithTags(tagRepo domain.TagRepository) TaskUseCaseOption {
+	return func(uc *TaskUseCase) {
+		uc.tagRepo = tagRepo
+	}
+}
+
+// checkTags normalizes and de-duplicates the tags given for a task and,
+// with a catalogue configured, makes sure every one of them exists. A nil
+// list stays nil so that updates can tell "unchanged" from "cleared".
+func (uc *TaskUseCase) checkTags(ctx context.Context, tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = normalizeTagName(tag)
+		if err := checkTagName(tag); err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if uc.tagRepo == nil || len(normalized) == 0 {
+		return normalized, nil
+	}
+
+	catalogue, err := uc.tagRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(catalogue))
+	for _, tag := range catalogue {
+		known[tag.Name] = true
+	}
+	for _, tag := range normalized {
+		if !known[tag] {
+			return nil, domain.NewValidationError(fmt.Sprintf("unknown tag: %s", tag))
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"task9/domain"
+)
+
+// ImportSource returns the next row of an import, or io.EOF after the last
+// one. A *domain.ValidationError rejects just that row; any other error ends
+// the import.
+type ImportSource func() (domain.CreateTaskRequest, error)
+
+// ExportTasks calls fn for each task matching filter, in storage order, so
+// that an export never has to hold every task at once. Sorting would need
+// them all, so filter.Sort must be empty.
+func (uc *TaskUseCase) ExportTasks(ctx context.Context, filter domain.TaskFilter, fn func(domain.Task) error) error {
+	if filter.Sort != "" {
+		return domain.NewValidationError("exports cannot be sorted")
+	}
+	filter, err := checkFilter(filter)
+	if err != nil {
+		return err
+	}
+	return uc.taskRepo.Each(ctx, filter, fn)
+}
+
+// ImportTasks creates a task for each row read from next, applying the same
+// rules as a batch create. A row whose ExternalID is already taken is
+// rejected, unless opts.Upsert is set, in which case it updates that task
+// like UpdateTask: empty fields keep their current value and the recurrence
+// of an existing task is left alone. Rows are written one by one, so a
+// failed row does not hold back the others, and the report lists every
+// row that failed. The import stops early only if next fails or ctx ends;
+// the rows before that stay imported.
+func (uc *TaskUseCase) ImportTasks(ctx context.Context, next ImportSource, opts domain.ImportOptions) (domain.ImportReport, error) {
+	var report domain.ImportReport
+	for {
+		req, err := next()
+		if errors.Is(err, io.EOF) {
+			return report, nil
+		}
+		var validationErr *domain.ValidationError
+		if err != nil && !errors.As(err, &validationErr) {
+			return report, err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return report, ctxErr
+		}
+
+		report.Rows++
+		updated := false
+		if err == nil {
+			updated, err = uc.importTask(ctx, req, opts)
+		}
+		switch {
+		case err != nil:
+			report.Errors = append(report.Errors, domain.ImportRowError{Row: report.Rows, ExternalID: req.ExternalID, Err: err})
+		case updated:
+			report.Updated++
+		default:
+			report.Created++
+		}
+	}
+}
+
+// importTask writes a single row and reports whether it updated an existing
+// task rather than creating one.
+func (uc *TaskUseCase) importTask(ctx context.Context, req domain.CreateTaskRequest, opts domain.ImportOptions) (bool, error) {
+	if req.ExternalID != "" {
+		existing, err := uc.taskRepo.GetByExternalID(ctx, req.ExternalID)
+		switch {
+		case err == nil && !opts.Upsert:
+			return false, domain.ErrExternalIDTaken
+		case err == nil && !existing.DeletedAt.IsZero():
+			return true, errors.New("the task with this external_id is in the trash")
+		case err == nil:
+			return true, uc.importUpdate(ctx, existing.ID, req, opts.DryRun)
+		case err.Error() != "task not found":
+			return false, err
+		}
+	}
+
+	write, err := uc.prepareWrite(ctx, domain.BatchOperation{Op: domain.BatchCreate, Create: req})
+	if err != nil || opts.DryRun {
+		return false, err
+	}
+	created, err := uc.taskRepo.Create(ctx, write.Task, write.History)
+	if err != nil {
+		return false, err
+	}
+	uc.publishTask(ctx, domain.EventTaskCreated, created, nil)
+	return false, nil
+}
+
+func (uc *TaskUseCase) importUpdate(ctx context.Context, id string, req domain.CreateTaskRequest, dryRun bool) error {
+	update := domain.UpdateTaskRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		DueDate:     req.DueDate,
+		Status:      req.Status,
+		Priority:    req.Priority,
+		ParentID:    req.ParentID,
+		ProjectID:   req.ProjectID,
+		Tags:        req.Tags,
+	}
+	if dryRun {
+		_, err := uc.prepareWrite(ctx, domain.BatchOperation{Op: domain.BatchUpdate, ID: id, Update: update})
+		return err
+	}
+	_, err := uc.UpdateTask(ctx, id, update)
+	return err
+}
@@ -1,77 +1,724 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"task9/domain"
 	"time"
 )
 
 type TaskUseCase struct {
-	taskRepo domain.TaskRepository
+	taskRepo    domain.TaskRepository
+	commentRepo domain.CommentRepository
+	attachments domain.AttachmentRepository
+	blobs       domain.BlobStore
+	tagRepo     domain.TagRepository
+	projects    *ProjectGuard
+	limits      domain.TaskLimits
+	completion  domain.CompletionPolicy
+	events      domain.EventPublisher
 }
 
-func NewTaskUseCase(taskRepo domain.TaskRepository) *TaskUseCase {
-	return &TaskUseCase{taskRepo: taskRepo}
+type TaskUseCaseOption func(*TaskUseCase)
+
+func WithTaskLimits(limits domain.TaskLimits) TaskUseCaseOption {
+	return func(uc *TaskUseCase) {
+		uc.limits = limits
+	}
 }
 
-func (uc *TaskUseCase) GetAllTasks() ([]domain.Task, error) {
-	return uc.taskRepo.GetAll()
+// WithComments makes task reads report comment counts and removes a task's
+// comments when the task is purged.
+func WithComments(commentRepo domain.CommentRepository) TaskUseCaseOption {
+	return func(uc *TaskUseCase) {
+		uc.commentRepo = commentRepo
+	}
 }
 
-func (uc *TaskUseCase) GetTaskByID(id string) (domain.Task, error) {
-	return uc.taskRepo.GetByID(id)
+// WithAttachments removes a task's attachments, content included, when the
+// task is purged.
+func WithAttachments(attachmentRepo domain.AttachmentRepository, blobs domain.BlobStore) TaskUseCaseOption {
+	return func(uc *TaskUseCase) {
+		uc.attachments = attachmentRepo
+		uc.blobs = blobs
+	}
 }
 
-func (uc *TaskUseCase) CreateTask(req domain.CreateTaskRequest) (domain.Task, error) {
+// WithCompletionPolicy sets what happens when a task with open subtasks or
+// checklist items is completed. The default is domain.CompletionBlock.
+func WithCompletionPolicy(policy domain.CompletionPolicy) TaskUseCaseOption {
+	return func(uc *TaskUseCase) {
+		uc.completion = policy
+	}
+}
+
+func NewTaskUseCase(taskRepo domain.TaskRepository, opts ...TaskUseCaseOption) *TaskUseCase {
+	uc := &TaskUseCase{taskRepo: taskRepo, completion: domain.CompletionBlock}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+func (uc *TaskUseCase) GetAllTasks(ctx context.Context) ([]domain.Task, error) {
+	tasks, err := uc.taskRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return uc.withCommentCounts(ctx, tasks)
+}
+
+// FindTasks lists the tasks matching filter in the order it asks for. Tag
+// names are matched case-insensitively, like everywhere else.
+func (uc *TaskUseCase) FindTasks(ctx context.Context, filter domain.TaskFilter) ([]domain.Task, error) {
+	if filter.Sort != "" && filter.Sort != domain.SortSmart {
+		return nil, domain.NewValidationError("sort must be: smart")
+	}
+	filter, err := checkFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []domain.Task
+	if filter.IsEmpty() {
+		tasks, err = uc.taskRepo.GetAll(ctx)
+	} else {
+		tasks, err = uc.taskRepo.Find(ctx, filter)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if filter.Sort == domain.SortSmart {
+		domain.SortTasksSmart(tasks, time.Now())
+	}
+	return uc.withCommentCounts(ctx, tasks)
+}
+
+// checkFilter validates the tag part of filter and normalizes its tag names.
+func checkFilter(filter domain.TaskFilter) (domain.TaskFilter, error) {
+	if filter.TagMatch != "" && filter.TagMatch != domain.TagMatchAny && filter.TagMatch != domain.TagMatchAll {
+		return filter, domain.NewValidationError("tag_match must be one of: any, all")
+	}
+
+	var tags []string
+	for _, tag := range filter.Tags {
+		if tag = normalizeTagName(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	filter.Tags = tags
+	return filter, nil
+}
+
+func (uc *TaskUseCase) GetTaskByID(ctx context.Context, id string) (domain.Task, error) {
+	task, err := uc.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	return uc.withCommentCount(ctx, task)
+}
+
+// GetSubtasks returns the direct subtasks of a task.
+func (uc *TaskUseCase) GetSubtasks(ctx context.Context, id string) ([]domain.Task, error) {
+	if _, err := uc.taskRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	tasks, err := uc.taskRepo.GetSubtasks(ctx, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	return uc.withCommentCounts(ctx, tasks)
+}
+
+func (uc *TaskUseCase) CreateTask(ctx context.Context, req domain.CreateTaskRequest) (domain.Task, error) {
+	req, err := uc.joinProject(ctx, req)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	task, err := uc.newTask(req)
+	if err != nil {
+		return domain.Task{}, err
+	}
+	if task.Tags, err = uc.checkTags(ctx, req.Tags); err != nil {
+		return domain.Task{}, err
+	}
+	if err := uc.checkParent(ctx, "", req.ParentID); err != nil {
+		return domain.Task{}, err
+	}
+
+	created, err := uc.taskRepo.Create(ctx, task, createEntry(ctx, task))
+	if err != nil {
+		return domain.Task{}, err
+	}
+	uc.publishTask(ctx, domain.EventTaskCreated, created, nil)
+	return created, nil
+}
+
+// updateAttempts bounds how often a task is re-read after another request
+// changed it between the read and the conditional write.
+const updateAttempts = 3
+
+func (uc *TaskUseCase) UpdateTask(ctx context.Context, id string, req domain.UpdateTaskRequest) (domain.Task, error) {
+	if err := uc.checkLimits(req.Title, req.Description); err != nil {
+		return domain.Task{}, err
+	}
+	if req.Status != "" && !isValidStatus(req.Status) {
+		return domain.Task{}, errors.New("invalid status")
+	}
+	if err := checkPriority(req.Priority); err != nil {
+		return domain.Task{}, err
+	}
+	tags, err := uc.checkTags(ctx, req.Tags)
+	if err != nil {
+		return domain.Task{}, err
+	}
+
+	// Subtasks are completed before the task, so that a completed task never
+	// has open subtasks, even when completing one of them fails.
+	if req.Status == "completed" {
+		if err := uc.cascadeCompletion(ctx, id); err != nil {
+			return domain.Task{}, err
+		}
+	}
+
+	return uc.modifyTask(ctx, id, func(task *domain.Task) error {
+		if req.Title != "" {
+			task.Title = req.Title
+		}
+		if req.Description != "" {
+			task.Description = req.Description
+		}
+		if !req.DueDate.IsZero() {
+			task.DueDate = req.DueDate
+		}
+		if req.ParentID != "" && req.ParentID != task.ParentID {
+			if err := uc.checkParent(ctx, id, req.ParentID); err != nil {
+				return err
+			}
+			task.ParentID = req.ParentID
+		}
+		if req.ProjectID != "" {
+			task.ProjectID = req.ProjectID
+		}
+		if req.Status != "" && req.Status != task.Status {
+			if err := uc.checkBlockers(ctx, *task, req.Status); err != nil {
+				return err
+			}
+		}
+		// Under the cascade policy, completing a completed task again also
+		// closes whatever was left open in it.
+		if req.Status == "completed" && (req.Status != task.Status || uc.completion == domain.CompletionCascade) {
+			if err := uc.completeOpenItems(task); err != nil {
+				return err
+			}
+		}
+		if req.Status != "" {
+			task.Status = req.Status
+		}
+		if req.Priority != "" {
+			task.Priority = req.Priority
+		}
+		if tags != nil {
+			task.Tags = tags
+		}
+		return nil
+	})
+}
+
+// modifyTask applies mutate to the current state of the task and writes the
+// result, starting over from a fresh read if the task changed in between.
+func (uc *TaskUseCase) modifyTask(ctx context.Context, id string, mutate func(task *domain.Task) error) (domain.Task, error) {
+	var err error
+	for attempt := 0; attempt < updateAttempts; attempt++ {
+		var task domain.Task
+		task, err = uc.modifyTaskOnce(ctx, id, mutate)
+		if !errors.Is(err, domain.ErrConflict) {
+			return task, err
+		}
+	}
+	return domain.Task{}, err
+}
+
+func (uc *TaskUseCase) modifyTaskOnce(ctx context.Context, id string, mutate func(task *domain.Task) error) (domain.Task, error) {
+	before, err := uc.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return domain.Task{}, err
+	}
+
+	if err := uc.projects.CheckWritable(ctx, before); err != nil {
+		return domain.Task{}, err
+	}
+
+	task := before
+	task.Checklist = append([]domain.ChecklistItem(nil), before.Checklist...)
+	if before.Recurrence != nil {
+		recurrence := *before.Recurrence
+		task.Recurrence = &recurrence
+	}
+	if err := mutate(&task); err != nil {
+		return domain.Task{}, err
+	}
+	if task.ProjectID != before.ProjectID && uc.projects != nil {
+		if _, err := uc.projects.Target(ctx, task.ProjectID); err != nil {
+			return domain.Task{}, err
+		}
+	}
+	task.UpdatedAt = time.Now()
+
+	// Lists are only written when they changed, since writing back an
+	// unchanged copy could undo a concurrent edit.
+	changes := domain.TaskChanges(before, task)
+	task.Checklist = changedList(task.Checklist, hasChange(changes, "checklist"))
+	task.BlockedBy = changedList(task.BlockedBy, hasChange(changes, "blocked_by"))
+	task.Tags = changedList(task.Tags, hasChange(changes, "tags"))
+	if !hasChange(changes, "recurrence") {
+		task.Recurrence = nil
+	}
+
+	updated, err := uc.taskRepo.Update(ctx, id, task, domain.NewHistoryEntry(domain.ActorFrom(ctx), domain.HistoryUpdate, changes))
+	if err != nil {
+		return domain.Task{}, err
+	}
+	uc.publishUpdate(ctx, before, updated)
+	return uc.withCommentCount(ctx, updated)
+}
+
+// changedList returns nil for an unchanged list, which Update leaves alone,
+// and a non-nil list otherwise so that emptying it is written too.
+func changedList[T any](list []T, changed bool) []T {
+	if !changed {
+		return nil
+	}
+	if list == nil {
+		return []T{}
+	}
+	return list
+}
+
+func hasChange(changes []domain.FieldChange, field string) bool {
+	for _, change := range changes {
+		if change.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteTask moves the task to the trash on behalf of the actor in ctx. It
+// can be brought back with RestoreTask until it is purged.
+func (uc *TaskUseCase) DeleteTask(ctx context.Context, id string) error {
+	var task domain.Task
+	if uc.events != nil || uc.projects != nil {
+		var err error
+		if task, err = uc.taskRepo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		if err := uc.projects.CheckWritable(ctx, task); err != nil {
+			return err
+		}
+	}
+	if err := uc.taskRepo.Delete(ctx, id, domain.NewHistoryEntry(domain.ActorFrom(ctx), domain.HistoryDelete, nil)); err != nil {
+		return err
+	}
+	uc.publishTask(ctx, domain.EventTaskDeleted, task, nil)
+	return nil
+}
+
+func (uc *TaskUseCase) GetTrash(ctx context.Context) ([]domain.Task, error) {
+	tasks, err := uc.taskRepo.GetDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return uc.withCommentCounts(ctx, tasks)
+}
+
+func (uc *TaskUseCase) RestoreTask(ctx context.Context, id string) (domain.Task, error) {
+	if err := uc.checkRestorable(ctx, id); err != nil {
+		return domain.Task{}, err
+	}
+	task, err := uc.taskRepo.Restore(ctx, id, domain.NewHistoryEntry(domain.ActorFrom(ctx), domain.HistoryRestore, nil))
+	if err != nil {
+		return domain.Task{}, err
+	}
+	uc.publishTask(ctx, domain.EventTaskRestored, task, nil)
+	return uc.withCommentCount(ctx, task)
+}
+
+// GetHistory returns one page of the task's change history, newest first,
+// along with the total number of entries.
+func (uc *TaskUseCase) GetHistory(ctx context.Context, id string, page, pageSize int) ([]domain.HistoryEntry, int, error) {
+	if page < 1 {
+		return nil, 0, domain.NewValidationError("page must be at least 1")
+	}
+	if pageSize < 1 || pageSize > maxHistoryPageSize {
+		return nil, 0, domain.NewValidationError(fmt.Sprintf("page_size must be between 1 and %d", maxHistoryPageSize))
+	}
+	return uc.taskRepo.GetHistory(ctx, id, (page-1)*pageSize, pageSize)
+}
+
+const maxHistoryPageSize = 100
+
+func (uc *TaskUseCase) PurgeTask(ctx context.Context, id string) error {
+	if err := uc.taskRepo.Purge(ctx, id); err != nil {
+		return err
+	}
+	return uc.purgeDependents(ctx, []string{id})
+}
+
+func (uc *TaskUseCase) EmptyTrash(ctx context.Context) (int64, error) {
+	return uc.purgeDeleted(ctx, time.Now())
+}
+
+// PurgeExpired permanently removes tasks that have been in the trash for
+// longer than retention.
+func (uc *TaskUseCase) PurgeExpired(ctx context.Context, retention time.Duration) (int64, error) {
+	return uc.purgeDeleted(ctx, time.Now().Add(-retention))
+}
+
+func (uc *TaskUseCase) purgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	ids, err := uc.taskRepo.PurgeDeleted(ctx, before)
+	if err != nil {
+		return int64(len(ids)), err
+	}
+	return int64(len(ids)), uc.purgeDependents(ctx, ids)
+}
+
+// purgeDependents removes what belongs to purged tasks. Comments and
+// attachments of tasks that are only in the trash are kept so that a restore
+// brings them back.
+func (uc *TaskUseCase) purgeDependents(ctx context.Context, taskIDs []string) error {
+	if err := uc.purgeComments(ctx, taskIDs); err != nil {
+		return err
+	}
+	return uc.purgeAttachments(ctx, taskIDs)
+}
+
+func (uc *TaskUseCase) purgeComments(ctx context.Context, taskIDs []string) error {
+	if uc.commentRepo == nil || len(taskIDs) == 0 {
+		return nil
+	}
+	_, err := uc.commentRepo.DeleteByTasks(ctx, taskIDs)
+	return err
+}
+
+func (uc *TaskUseCase) purgeAttachments(ctx context.Context, taskIDs []string) error {
+	if uc.attachments == nil || len(taskIDs) == 0 {
+		return nil
+	}
+	deleted, err := uc.attachments.DeleteByTasks(ctx, taskIDs)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(deleted))
+	for _, attachment := range deleted {
+		keys = append(keys, attachment.BlobKey)
+	}
+	deleteBlobs(ctx, uc.blobs, keys)
+	return nil
+}
+
+func (uc *TaskUseCase) withCommentCount(ctx context.Context, task domain.Task) (domain.Task, error) {
+	tasks, err := uc.withCommentCounts(ctx, []domain.Task{task})
+	if err != nil {
+		return domain.Task{}, err
+	}
+	return tasks[0], nil
+}
+
+func (uc *TaskUseCase) withCommentCounts(ctx context.Context, tasks []domain.Task) ([]domain.Task, error) {
+	if uc.commentRepo == nil || len(tasks) == 0 {
+		return tasks, nil
+	}
+
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	counts, err := uc.commentRepo.CountByTasks(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tasks {
+		tasks[i].CommentCount = counts[tasks[i].ID]
+	}
+	return tasks, nil
+}
+
+// ExecuteBatch applies a list of create/update/delete operations and returns
+// one result per operation. In atomic mode either every operation succeeds or
+// none is applied, and the returned error is domain.ErrBatchAborted.
+func (uc *TaskUseCase) ExecuteBatch(ctx context.Context, ops []domain.BatchOperation, atomic bool) ([]domain.BatchResult, error) {
+	if len(ops) == 0 {
+		return nil, domain.NewValidationError("batch must contain at least one operation")
+	}
+	if uc.limits.MaxBatchSize > 0 && len(ops) > uc.limits.MaxBatchSize {
+		return nil, domain.NewValidationError(fmt.Sprintf("batch must contain at most %d operations", uc.limits.MaxBatchSize))
+	}
+
+	results := make([]domain.BatchResult, len(ops))
+	var writes []domain.TaskWrite
+	var writeIndex []int
+	invalid := false
+
+	for i, op := range ops {
+		results[i] = domain.BatchResult{Op: op.Op, ID: op.ID}
+
+		write, err := uc.prepareWrite(ctx, op)
+		if err != nil {
+			results[i].Error = err
+			invalid = true
+			continue
+		}
+		writes = append(writes, write)
+		writeIndex = append(writeIndex, i)
+	}
+
+	if atomic && invalid {
+		markNotApplied(results)
+		return results, domain.ErrBatchAborted
+	}
+	if len(writes) == 0 {
+		return results, nil
+	}
+
+	before := uc.batchSnapshot(ctx, writes)
+	bulkWrite := uc.taskRepo.BulkWrite
+	if atomic {
+		bulkWrite = uc.taskRepo.BulkWriteAtomic
+	}
+	errs, err := bulkWrite(ctx, writes)
+	if err != nil && !errors.Is(err, domain.ErrBatchAborted) {
+		return nil, err
+	}
+
+	for j, i := range writeIndex {
+		if j < len(errs) && errs[j] != nil {
+			results[i].Error = errs[j]
+			continue
+		}
+		if writes[j].Op == domain.BatchCreate {
+			task := writes[j].Task
+			results[i].ID = writes[j].ID
+			results[i].Task = &task
+		}
+	}
+
+	if err != nil {
+		markNotApplied(results)
+		return results, err
+	}
+
+	uc.publishBatch(ctx, writes, writeIndex, results, before)
+	if uc.completion == domain.CompletionCascade {
+		uc.cascadeBatchCompletions(ctx, writes, writeIndex, results)
+	}
+	return results, nil
+}
+
+// checkBatchStatus applies the blocker and completion rules of UpdateTask to
+// a batch update. Cascading is left to cascadeBatchCompletions.
+func (uc *TaskUseCase) checkBatchStatus(ctx context.Context, id, status string) error {
+	task, err := uc.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if task.Status == status {
+		return nil
+	}
+	if err := uc.checkBlockers(ctx, task, status); err != nil {
+		return err
+	}
+	if status == "completed" && uc.completion != domain.CompletionCascade && task.HasOpenItems() {
+		return domain.ErrOpenSubtasks
+	}
+	return nil
+}
+
+// cascadeBatchCompletions closes the open items of tasks the batch completed.
+// It runs after the batch has been written, so a failure is reported on the
+// operation's result without undoing the completion itself.
+func (uc *TaskUseCase) cascadeBatchCompletions(ctx context.Context, writes []domain.TaskWrite, writeIndex []int, results []domain.BatchResult) {
+	for j, i := range writeIndex {
+		if results[i].Error != nil || writes[j].Op != domain.BatchUpdate || writes[j].Task.Status != "completed" {
+			continue
+		}
+		task, err := uc.taskRepo.GetByID(ctx, writes[j].ID)
+		if err == nil && !task.HasOpenItems() {
+			continue
+		}
+		if err == nil {
+			err = uc.cascadeCompletion(ctx, writes[j].ID)
+		}
+		if err == nil {
+			_, err = uc.modifyTask(ctx, writes[j].ID, uc.completeOpenItems)
+		}
+		if err != nil {
+			results[i].Error = fmt.Errorf("task completed but its open items were not: %w", err)
+		}
+	}
+}
+
+func (uc *TaskUseCase) prepareWrite(ctx context.Context, op domain.BatchOperation) (domain.TaskWrite, error) {
+	switch op.Op {
+	case domain.BatchCreate:
+		if op.Create.Title == "" {
+			return domain.TaskWrite{}, domain.NewValidationError("title is required")
+		}
+		if op.Create.DueDate.IsZero() {
+			return domain.TaskWrite{}, domain.NewValidationError("due_date is required")
+		}
+		req, err := uc.joinProject(ctx, op.Create)
+		if err != nil {
+			return domain.TaskWrite{}, err
+		}
+		task, err := uc.newTask(req)
+		if err != nil {
+			return domain.TaskWrite{}, err
+		}
+		if task.Tags, err = uc.checkTags(ctx, op.Create.Tags); err != nil {
+			return domain.TaskWrite{}, err
+		}
+		if err := uc.checkParent(ctx, "", op.Create.ParentID); err != nil {
+			return domain.TaskWrite{}, err
+		}
+		return domain.TaskWrite{Op: op.Op, Task: task, History: createEntry(ctx, task)}, nil
+	case domain.BatchUpdate:
+		if op.ID == "" {
+			return domain.TaskWrite{}, domain.NewValidationError("id is required")
+		}
+		if op.Update.Status != "" && !isValidStatus(op.Update.Status) {
+			return domain.TaskWrite{}, errors.New("invalid status")
+		}
+		if err := checkPriority(op.Update.Priority); err != nil {
+			return domain.TaskWrite{}, err
+		}
+		if err := uc.checkLimits(op.Update.Title, op.Update.Description); err != nil {
+			return domain.TaskWrite{}, err
+		}
+		tags, err := uc.checkTags(ctx, op.Update.Tags)
+		if err != nil {
+			return domain.TaskWrite{}, err
+		}
+		if err := uc.checkWritableID(ctx, op.ID); err != nil {
+			return domain.TaskWrite{}, err
+		}
+		if op.Update.ProjectID != "" && uc.projects != nil {
+			if _, err := uc.projects.Target(ctx, op.Update.ProjectID); err != nil {
+				return domain.TaskWrite{}, err
+			}
+		}
+		if op.Update.ParentID != "" {
+			if err := uc.checkParent(ctx, op.ID, op.Update.ParentID); err != nil {
+				return domain.TaskWrite{}, err
+			}
+		}
+		if op.Update.Status == "in_progress" || op.Update.Status == "completed" {
+			if err := uc.checkBatchStatus(ctx, op.ID, op.Update.Status); err != nil {
+				return domain.TaskWrite{}, err
+			}
+		}
+		return domain.TaskWrite{Op: op.Op, ID: op.ID, Task: domain.Task{
+			Title:       op.Update.Title,
+			Description: op.Update.Description,
+			DueDate:     op.Update.DueDate,
+			Status:      op.Update.Status,
+			Priority:    op.Update.Priority,
+			ParentID:    op.Update.ParentID,
+			ProjectID:   op.Update.ProjectID,
+			Tags:        tags,
+			UpdatedAt:   time.Now(),
+		}, History: domain.NewHistoryEntry(domain.ActorFrom(ctx), domain.HistoryUpdate, nil)}, nil
+	case domain.BatchDelete:
+		if op.ID == "" {
+			return domain.TaskWrite{}, domain.NewValidationError("id is required")
+		}
+		if err := uc.checkWritableID(ctx, op.ID); err != nil {
+			return domain.TaskWrite{}, err
+		}
+		return domain.TaskWrite{Op: op.Op, ID: op.ID, History: domain.NewHistoryEntry(domain.ActorFrom(ctx), domain.HistoryDelete, nil)}, nil
+	default:
+		return domain.TaskWrite{}, domain.NewValidationError("op must be one of: create, update, delete")
+	}
+}
+
+func markNotApplied(results []domain.BatchResult) {
+	for i := range results {
+		results[i].Task = nil
+		if results[i].Error == nil {
+			results[i].Error = domain.ErrBatchAborted
+		}
+	}
+}
+
+func (uc *TaskUseCase) newTask(req domain.CreateTaskRequest) (domain.Task, error) {
 	status := req.Status
 	if status == "" {
 		status = "pending"
 	}
 
-	if status != "pending" && status != "in_progress" && status != "completed" {
+	if !isValidStatus(status) {
 		return domain.Task{}, errors.New("invalid status")
 	}
 
+	priority := req.Priority
+	if priority == "" {
+		priority = domain.PriorityMedium
+	}
+	if err := checkPriority(priority); err != nil {
+		return domain.Task{}, err
+	}
+
+	if err := uc.checkLimits(req.Title, req.Description); err != nil {
+		return domain.Task{}, err
+	}
+
 	now := time.Now()
 	task := domain.Task{
 		Title:       req.Title,
 		Description: req.Description,
 		DueDate:     req.DueDate,
 		Status:      status,
+		Priority:    priority,
+		ParentID:    req.ParentID,
+		ProjectID:   req.ProjectID,
 		CreatedAt:   now,
 		UpdatedAt:   now,
+		ExternalID:  req.ExternalID,
+	}
+	if req.Recurrence != "" {
+		rule, err := checkRecurrence(req.Recurrence, req.DueDate)
+		if err != nil {
+			return domain.Task{}, err
+		}
+		task.Recurrence = &domain.Recurrence{Rule: rule, Start: req.DueDate}
 	}
+	return task, nil
+}
 
-	return uc.taskRepo.Create(task)
+func createEntry(ctx context.Context, task domain.Task) domain.HistoryEntry {
+	return domain.NewHistoryEntry(domain.ActorFrom(ctx), domain.HistoryCreate, domain.TaskChanges(domain.Task{}, task))
 }
 
-func (uc *TaskUseCase) UpdateTask(id string, req domain.UpdateTaskRequest) (domain.Task, error) {
-	existingTask, err := uc.taskRepo.GetByID(id)
-	if err != nil {
-		return domain.Task{}, err
-	}
+func isValidStatus(status string) bool {
+	return status == "pending" || status == "in_progress" || status == "completed"
+}
 
-	if req.Title != "" {
-		existingTask.Title = req.Title
+// checkPriority accepts an empty priority, which leaves it unchanged on
+// update.
+func checkPriority(priority string) error {
+	if priority != "" && !domain.IsValidPriority(priority) {
+		return domain.NewValidationError("priority must be one of: low, medium, high, urgent")
 	}
-	if req.Description != "" {
-		existingTask.Description = req.Description
-	}
-	if !req.DueDate.IsZero() {
-		existingTask.DueDate = req.DueDate
-	}
-	if req.Status != "" {
-		if req.Status != "pending" && req.Status != "in_progress" && req.Status != "completed" {
-			return domain.Task{}, errors.New("invalid status")
-		}
-		existingTask.Status = req.Status
-	}
-
-	existingTask.UpdatedAt = time.Now()
-
-	return uc.taskRepo.Update(id, existingTask)
+	return nil
 }
 
-func (uc *TaskUseCase) DeleteTask(id string) error {
-	return uc.taskRepo.Delete(id)
+func (uc *TaskUseCase) checkLimits(title, description string) error {
+	if uc.limits.MaxTitleLength > 0 && len(title) > uc.limits.MaxTitleLength {
+		return domain.NewValidationError(fmt.Sprintf("title must be at most %d characters", uc.limits.MaxTitleLength))
+	}
+	if uc.limits.MaxDescriptionLength > 0 && len(description) > uc.limits.MaxDescriptionLength {
+		return domain.NewValidationError(fmt.Sprintf("description must be at most %d characters", uc.limits.MaxDescriptionLength))
+	}
+	return nil
 }
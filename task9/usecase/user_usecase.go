@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"task9/domain/entity"
 	"task9/domain/repository"
@@ -17,8 +18,8 @@ func NewUserUseCase(userRepo repository.UserRepository) *UserUseCase {
 	}
 }
 
-func (uc *UserUseCase) Register(username, password string) (*entity.User, error) {
-	existing, _ := uc.userRepo.FindByUsername(username)
+func (uc *UserUseCase) Register(ctx context.Context, username, password string) (*entity.User, error) {
+	existing, _ := uc.userRepo.FindByUsername(ctx, username)
 	if existing != nil {
 		return nil, errors.New("username already exists")
 	}
@@ -28,7 +29,7 @@ func (uc *UserUseCase) Register(username, password string) (*entity.User, error)
 		return nil, err
 	}
 
-	count, err := uc.userRepo.Count()
+	count, err := uc.userRepo.Count(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +40,7 @@ func (uc *UserUseCase) Register(username, password string) (*entity.User, error)
 	}
 
 	user := entity.NewUser("", username, string(hashedPassword), role)
-	if err := uc.userRepo.Create(user); err != nil {
+	if err := uc.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
@@ -47,8 +48,8 @@ func (uc *UserUseCase) Register(username, password string) (*entity.User, error)
 	return user, nil
 }
 
-func (uc *UserUseCase) Login(username, password string) (*entity.User, error) {
-	user, err := uc.userRepo.FindByUsername(username)
+func (uc *UserUseCase) Login(ctx context.Context, username, password string) (*entity.User, error) {
+	user, err := uc.userRepo.FindByUsername(ctx, username)
 	if err != nil {
 		return nil, errors.New("invalid credentials")
 	}
@@ -62,18 +63,18 @@ func (uc *UserUseCase) Login(username, password string) (*entity.User, error) {
 	return user, nil
 }
 
-func (uc *UserUseCase) PromoteUser(username string) error {
-	user, err := uc.userRepo.FindByUsername(username)
+func (uc *UserUseCase) PromoteUser(ctx context.Context, username string) error {
+	user, err := uc.userRepo.FindByUsername(ctx, username)
 	if err != nil {
 		return errors.New("user not found")
 	}
 
 	user.PromoteToAdmin()
-	return uc.userRepo.Update(user)
+	return uc.userRepo.Update(ctx, user)
 }
 
-func (uc *UserUseCase) GetUserByID(id string) (*entity.User, error) {
-	user, err := uc.userRepo.FindByID(id)
+func (uc *UserUseCase) GetUserByID(ctx context.Context, id string) (*entity.User, error) {
+	user, err := uc.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
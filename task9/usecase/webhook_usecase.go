@@ -0,0 +1,318 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"task9/domain"
+	"time"
+)
+
+const minWebhookSecretLength = 16
+
+// maxWebhookBackoff caps the wait between two attempts, however many have
+// failed.
+const maxWebhookBackoff = 24 * time.Hour
+
+// WebhookUseCase manages webhook subscriptions and delivers events to them.
+// Publish only queues deliveries; DeliverPending sends them, retrying failed
+// attempts with exponential backoff until they run out and are dead.
+type WebhookUseCase struct {
+	webhookRepo  domain.WebhookRepository
+	deliveryRepo domain.WebhookDeliveryRepository
+	sender       domain.WebhookSender
+	maxAttempts  int
+	backoff      time.Duration
+	lease        time.Duration
+	batchSize    int
+	owner        string
+}
+
+type WebhookUseCaseOption func(*WebhookUseCase)
+
+// WithWebhookRetries sets how many attempts a delivery gets before it is dead
+// and the wait after the first failed one, which doubles after each further
+// failure up to a day. The default is 8 attempts starting at 30s.
+func WithWebhookRetries(maxAttempts int, backoff time.Duration) WebhookUseCaseOption {
+	return func(uc *WebhookUseCase) {
+		uc.maxAttempts = maxAttempts
+		uc.backoff = backoff
+	}
+}
+
+// WithWebhookLease sets how long a claimed delivery is reserved for its
+// sender. It should be well above the HTTP timeout.
+func WithWebhookLease(lease time.Duration) WebhookUseCaseOption {
+	return func(uc *WebhookUseCase) {
+		uc.lease = lease
+	}
+}
+
+// WithWebhookBatchSize bounds how many deliveries one DeliverPending call
+// attempts.
+func WithWebhookBatchSize(size int) WebhookUseCaseOption {
+	return func(uc *WebhookUseCase) {
+		uc.batchSize = size
+	}
+}
+
+// WithWebhookOwner names this process in delivery leases. The default is
+// unique per process.
+func WithWebhookOwner(owner string) WebhookUseCaseOption {
+	return func(uc *WebhookUseCase) {
+		uc.owner = owner
+	}
+}
+
+func NewWebhookUseCase(webhookRepo domain.WebhookRepository, deliveryRepo domain.WebhookDeliveryRepository, sender domain.WebhookSender, opts ...WebhookUseCaseOption) *WebhookUseCase {
+	uc := &WebhookUseCase{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		sender:       sender,
+		maxAttempts:  8,
+		backoff:      30 * time.Second,
+		lease:        2 * time.Minute,
+		batchSize:    20,
+	}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	if uc.owner == "" {
+		uc.owner = processOwner()
+	}
+	return uc
+}
+
+func (uc *WebhookUseCase) ListWebhooks(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	return uc.webhookRepo.List(ctx)
+}
+
+func (uc *WebhookUseCase) GetWebhook(ctx context.Context, id string) (domain.WebhookSubscription, error) {
+	return uc.webhookRepo.GetByID(ctx, id)
+}
+
+// CreateWebhook adds a subscription. Without a secret in req, a random one
+// is generated.
+func (uc *WebhookUseCase) CreateWebhook(ctx context.Context, req domain.WebhookRequest) (domain.WebhookSubscription, error) {
+	if req.URL == "" {
+		return domain.WebhookSubscription{}, domain.NewValidationError("url is required")
+	}
+	if req.Events == nil {
+		return domain.WebhookSubscription{}, domain.NewValidationError("events is required")
+	}
+	if req.Secret == "" {
+		req.Secret = randomHex(32)
+	}
+
+	subscription, err := checkWebhook(domain.WebhookSubscription{Active: true}, req)
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	subscription.CreatedAt = time.Now()
+	subscription.UpdatedAt = subscription.CreatedAt
+	return uc.webhookRepo.Create(ctx, subscription)
+}
+
+// UpdateWebhook edits a subscription. Deliveries already queued keep going
+// to it; they are sent with its URL and secret at the time of each attempt.
+func (uc *WebhookUseCase) UpdateWebhook(ctx context.Context, id string, req domain.WebhookRequest) (domain.WebhookSubscription, error) {
+	current, err := uc.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	subscription, err := checkWebhook(current, req)
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+	subscription.UpdatedAt = time.Now()
+	return uc.webhookRepo.Update(ctx, id, subscription)
+}
+
+// DeleteWebhook removes a subscription along with its delivery log.
+func (uc *WebhookUseCase) DeleteWebhook(ctx context.Context, id string) error {
+	if err := uc.webhookRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	_, err := uc.deliveryRepo.DeleteBySubscription(ctx, id)
+	return err
+}
+
+// ListDeliveries returns one page of the subscription's delivery log, newest
+// first, along with the total number of deliveries. An empty status lists
+// them all.
+func (uc *WebhookUseCase) ListDeliveries(ctx context.Context, id, status string, page, pageSize int) ([]domain.WebhookDelivery, int, error) {
+	if status != "" && status != domain.WebhookPending && status != domain.WebhookSucceeded && status != domain.WebhookDead {
+		return nil, 0, domain.NewValidationError("status must be one of: pending, succeeded, dead")
+	}
+	if page < 1 {
+		return nil, 0, domain.NewValidationError("page must be at least 1")
+	}
+	if pageSize < 1 || pageSize > maxHistoryPageSize {
+		return nil, 0, domain.NewValidationError(fmt.Sprintf("page_size must be between 1 and %d", maxHistoryPageSize))
+	}
+	if _, err := uc.webhookRepo.GetByID(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	return uc.deliveryRepo.ListBySubscription(ctx, id, status, (page-1)*pageSize, pageSize)
+}
+
+// RetryDelivery puts a dead delivery back in the queue with a fresh set of
+// attempts, e.g. after the receiver has been fixed.
+func (uc *WebhookUseCase) RetryDelivery(ctx context.Context, id, deliveryID string) (domain.WebhookDelivery, error) {
+	if _, err := uc.webhookRepo.GetByID(ctx, id); err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	delivery, err := uc.deliveryRepo.GetByID(ctx, deliveryID)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	if delivery.SubscriptionID != id {
+		return domain.WebhookDelivery{}, errors.New("delivery not found")
+	}
+	if delivery.Status != domain.WebhookDead {
+		return domain.WebhookDelivery{}, domain.NewValidationError("only dead deliveries can be retried")
+	}
+	return uc.deliveryRepo.Requeue(ctx, deliveryID, time.Now())
+}
+
+// Publish queues event for every active subscription that wants it.
+func (uc *WebhookUseCase) Publish(ctx context.Context, event domain.Event) error {
+	subscriptions, err := uc.webhookRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []domain.WebhookDelivery
+	var payload []byte
+	for _, subscription := range subscriptions {
+		if !subscription.Wants(event.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(newEventPayload(event)); err != nil {
+				return err
+			}
+		}
+		deliveries = append(deliveries, domain.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+			Status:         domain.WebhookPending,
+			NextAttemptAt:  event.OccurredAt,
+			CreatedAt:      event.OccurredAt,
+			UpdatedAt:      event.OccurredAt,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return uc.deliveryRepo.Create(ctx, deliveries)
+}
+
+// DeliverPending attempts the deliveries that are due at now and returns how
+// many succeeded. Failed attempts are recorded on the delivery rather than
+// returned; the error only reports trouble with the queue itself.
+func (uc *WebhookUseCase) DeliverPending(ctx context.Context, now time.Time) (int, error) {
+	succeeded := 0
+	for i := 0; i < uc.batchSize; i++ {
+		delivery, ok, err := uc.deliveryRepo.ClaimNext(ctx, now, uc.owner, uc.lease)
+		if err != nil || !ok {
+			return succeeded, err
+		}
+		if err := uc.attempt(ctx, &delivery, now); err != nil {
+			return succeeded, fmt.Errorf("delivery %s: %w", delivery.ID, err)
+		}
+		if delivery.Status == domain.WebhookSucceeded {
+			succeeded++
+		}
+	}
+	return succeeded, nil
+}
+
+// attempt sends a claimed delivery once and records the outcome. If the
+// process dies before recording it, the lease runs out and the delivery is
+// sent again, so delivery is at least once.
+func (uc *WebhookUseCase) attempt(ctx context.Context, delivery *domain.WebhookDelivery, now time.Time) error {
+	subscription, err := uc.webhookRepo.GetByID(ctx, delivery.SubscriptionID)
+	switch {
+	case err != nil && err.Error() == "webhook not found":
+		delivery.Status = domain.WebhookDead
+		delivery.LastError = "webhook was deleted"
+	case err != nil:
+		return err
+	case !subscription.Active:
+		delivery.Status = domain.WebhookDead
+		delivery.LastError = "webhook is inactive"
+	default:
+		delivery.Attempts++
+		delivery.LastStatusCode, err = uc.sender.Send(ctx, subscription, *delivery)
+		if err == nil {
+			delivery.Status = domain.WebhookSucceeded
+			delivery.LastError = ""
+			delivery.DeliveredAt = time.Now()
+		} else {
+			delivery.LastError = err.Error()
+			if delivery.Attempts >= uc.maxAttempts {
+				delivery.Status = domain.WebhookDead
+			} else {
+				delivery.NextAttemptAt = now.Add(uc.retryDelay(delivery.Attempts))
+			}
+		}
+	}
+	delivery.UpdatedAt = time.Now()
+	return uc.deliveryRepo.Record(ctx, *delivery, uc.owner)
+}
+
+// retryDelay is the wait after the given number of failed attempts. It
+// doubles step by step rather than by shifting, which would overflow after
+// about 30 attempts.
+func (uc *WebhookUseCase) retryDelay(attempts int) time.Duration {
+	delay := uc.backoff
+	for i := 1; i < attempts && delay < maxWebhookBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxWebhookBackoff)
+}
+
+// checkWebhook applies req to current and validates the result.
+func checkWebhook(current domain.WebhookSubscription, req domain.WebhookRequest) (domain.WebhookSubscription, error) {
+	subscription := current
+	if req.URL != "" {
+		parsed, err := url.Parse(req.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return domain.WebhookSubscription{}, domain.NewValidationError("url must be an absolute http or https URL")
+		}
+		subscription.URL = req.URL
+	}
+	if req.Events != nil {
+		events := []string{}
+		for _, event := range req.Events {
+			event = strings.TrimSpace(event)
+			if event != "*" && !slices.Contains(domain.EventTypes, event) {
+				return domain.WebhookSubscription{}, domain.NewValidationError(fmt.Sprintf("unknown event %q, events must be * or one of: %s", event, strings.Join(domain.EventTypes, ", ")))
+			}
+			if !slices.Contains(events, event) {
+				events = append(events, event)
+			}
+		}
+		if len(events) == 0 {
+			return domain.WebhookSubscription{}, domain.NewValidationError("events must not be empty")
+		}
+		subscription.Events = events
+	}
+	if req.Secret != "" {
+		if len(req.Secret) < minWebhookSecretLength {
+			return domain.WebhookSubscription{}, domain.NewValidationError(fmt.Sprintf("secret must be at least %d characters", minWebhookSecretLength))
+		}
+		subscription.Secret = req.Secret
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+	return subscription, nil
+}
@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"task9/domain"
+	"time"
+)
+
+const maxWorkspaceNameLength = 100
+
+// WorkspaceUseCase manages workspaces and their members. Which of its
+// methods a member may call is decided by the routes, through the role the
+// member has in the workspace.
+type WorkspaceUseCase struct {
+	workspaceRepo domain.WorkspaceRepository
+	userRepo      domain.UserRepository
+}
+
+func NewWorkspaceUseCase(workspaceRepo domain.WorkspaceRepository, userRepo domain.UserRepository) *WorkspaceUseCase {
+	return &WorkspaceUseCase{workspaceRepo: workspaceRepo, userRepo: userRepo}
+}
+
+// CreateWorkspace creates a workspace with the actor in ctx as its admin.
+func (uc *WorkspaceUseCase) CreateWorkspace(ctx context.Context, name string) (domain.Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return domain.Workspace{}, domain.NewValidationError("workspace name is required")
+	}
+	if len(name) > maxWorkspaceNameLength {
+		return domain.Workspace{}, domain.NewValidationError(fmt.Sprintf("workspace name must be at most %d characters", maxWorkspaceNameLength))
+	}
+
+	now := time.Now()
+	workspace, err := uc.workspaceRepo.Create(ctx, domain.Workspace{Name: name, CreatedAt: now})
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+	actor := domain.ActorFrom(ctx)
+	_, err = uc.workspaceRepo.AddMember(ctx, domain.Membership{
+		WorkspaceID: workspace.ID,
+		UserID:      actor.UserID,
+		Username:    actor.Username,
+		Role:        domain.WorkspaceAdmin,
+		CreatedAt:   now,
+	})
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+	workspace.Role = domain.WorkspaceAdmin
+	return workspace, nil
+}
+
+// ListWorkspaces returns the workspaces the actor in ctx belongs to.
+func (uc *WorkspaceUseCase) ListWorkspaces(ctx context.Context) ([]domain.Workspace, error) {
+	return uc.workspaceRepo.ListForUser(ctx, domain.ActorFrom(ctx).UserID)
+}
+
+// GetWorkspace returns a workspace with the role of the actor in ctx, who
+// must be a member.
+func (uc *WorkspaceUseCase) GetWorkspace(ctx context.Context, id string) (domain.Workspace, error) {
+	workspace, err := uc.workspaceRepo.GetByID(ctx, id)
+	if err != nil {
+		return domain.Workspace{}, err
+	}
+	membership, err := uc.workspaceRepo.GetMember(ctx, id, domain.ActorFrom(ctx).UserID)
+	if err != nil {
+		if err.Error() == "member not found" {
+			return domain.Workspace{}, errors.New("workspace not found")
+		}
+		return domain.Workspace{}, err
+	}
+	workspace.Role = membership.Role
+	return workspace, nil
+}
+
+func (uc *WorkspaceUseCase) ListMembers(ctx context.Context, workspaceID string) ([]domain.Membership, error) {
+	return uc.workspaceRepo.ListMembers(ctx, workspaceID)
+}
+
+// AddMember adds the user called req.Username to the workspace.
+func (uc *WorkspaceUseCase) AddMember(ctx context.Context, workspaceID string, req domain.MemberRequest) (domain.Membership, error) {
+	role, err := checkWorkspaceRole(req.Role)
+	if err != nil {
+		return domain.Membership{}, err
+	}
+	user, err := uc.userRepo.GetByUsername(ctx, strings.TrimSpace(req.Username))
+	if err != nil {
+		return domain.Membership{}, err
+	}
+	return uc.workspaceRepo.AddMember(ctx, domain.Membership{
+		WorkspaceID: workspaceID,
+		UserID:      user.ID,
+		Username:    user.Username,
+		Role:        role,
+		CreatedAt:   time.Now(),
+	})
+}
+
+// UpdateMember changes a member's role. The last admin cannot be demoted.
+func (uc *WorkspaceUseCase) UpdateMember(ctx context.Context, workspaceID, userID, role string) (domain.Membership, error) {
+	role, err := checkWorkspaceRole(role)
+	if err != nil {
+		return domain.Membership{}, err
+	}
+	if role != domain.WorkspaceAdmin {
+		if err := uc.keepAnAdmin(ctx, workspaceID, userID); err != nil {
+			return domain.Membership{}, err
+		}
+	}
+	return uc.workspaceRepo.UpdateMemberRole(ctx, workspaceID, userID, role)
+}
+
+// RemoveMember takes a user out of the workspace. Admins may remove anyone
+// and members only themselves; the last admin cannot be removed.
+func (uc *WorkspaceUseCase) RemoveMember(ctx context.Context, workspaceID, userID string) error {
+	actor := domain.ActorFrom(ctx)
+	if actor.UserID != userID && actor.Role != domain.WorkspaceAdmin {
+		return domain.ErrNotWorkspaceAdmin
+	}
+	if err := uc.keepAnAdmin(ctx, workspaceID, userID); err != nil {
+		return err
+	}
+	return uc.workspaceRepo.RemoveMember(ctx, workspaceID, userID)
+}
+
+// keepAnAdmin returns ErrLastWorkspaceAdmin if userID is the only admin of
+// the workspace.
+func (uc *WorkspaceUseCase) keepAnAdmin(ctx context.Context, workspaceID, userID string) error {
+	members, err := uc.workspaceRepo.ListMembers(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if member.Role == domain.WorkspaceAdmin && member.UserID != userID {
+			return nil
+		}
+	}
+	for _, member := range members {
+		if member.UserID == userID && member.Role == domain.WorkspaceAdmin {
+			return domain.ErrLastWorkspaceAdmin
+		}
+	}
+	return nil
+}
+
+func checkWorkspaceRole(role string) (string, error) {
+	switch role {
+	case "":
+		return domain.WorkspaceMember, nil
+	case domain.WorkspaceAdmin, domain.WorkspaceMember:
+		return role, nil
+	default:
+		return "", domain.NewValidationError("role must be admin or member")
+	}
+}